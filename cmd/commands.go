@@ -382,11 +382,13 @@ support tags for organization and full-text search.
 Examples:
   noteleaf note create "Meeting notes" "Discussed project timeline"
   noteleaf note create -i
-  noteleaf note create --file ~/documents/draft.md`,
+  noteleaf note create --file ~/documents/draft.md
+  noteleaf note create --encrypt "Bank details" "..."`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			interactive, _ := cmd.Flags().GetBool("interactive")
 			editor, _ := cmd.Flags().GetBool("editor")
 			filePath, _ := cmd.Flags().GetString("file")
+			encrypt, _ := cmd.Flags().GetBool("encrypt")
 
 			var title, content string
 			if len(args) > 0 {
@@ -397,12 +399,14 @@ Examples:
 			}
 
 			defer c.handler.Close()
+			c.handler.EncryptNewNotes(encrypt)
 			return c.handler.CreateWithOptions(cmd.Context(), title, content, filePath, interactive, editor)
 		},
 	}
 	createCmd.Flags().BoolP("interactive", "i", false, "Open interactive editor")
 	createCmd.Flags().BoolP("editor", "e", false, "Prompt to open note in editor after creation")
 	createCmd.Flags().StringP("file", "f", "", "Create note from markdown file")
+	createCmd.Flags().Bool("encrypt", false, "Store the note content encrypted")
 	root.AddCommand(createCmd)
 
 	listCmd := &cobra.Command{
@@ -488,9 +492,244 @@ undone. You will be prompted for confirmation before deletion.`,
 		},
 	})
 
+	root.AddCommand(&cobra.Command{
+		Use:   "history [note-id]",
+		Short: "List stored revisions of a note",
+		Long: `Show the revision history of a note.
+
+A revision is recorded every time a note's content changes. Revisions are
+stored compressed and identical content is only stored once. Old revisions are
+pruned according to the note_history_keep_last and note_history_keep_daily_days
+configuration options.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if noteID, err := handlers.ParseID(args[0], "note"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.History(cmd.Context(), noteID)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "diff [note-id] [rev1] [rev2]",
+		Short: "Show changes between note revisions",
+		Long: `Show a coloured unified diff between two revisions of a note.
+
+With no revisions the latest revision is compared against the previous one.
+With one revision it is compared against the note's current content.
+
+Examples:
+  noteleaf note diff 1
+  noteleaf note diff 1 3
+  noteleaf note diff 1 2 5`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noteID, err := handlers.ParseID(args[0], "note")
+			if err != nil {
+				return err
+			}
+
+			revs := make([]int, 2)
+			for i, arg := range args[1:] {
+				if revs[i], err = parseRevision(arg); err != nil {
+					return err
+				}
+			}
+
+			defer c.handler.Close()
+			return c.handler.Diff(cmd.Context(), noteID, revs[0], revs[1])
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "restore [note-id] [rev]",
+		Short: "Restore a note to an earlier revision",
+		Long: `Replace a note's content with the content of an earlier revision.
+
+The restore is recorded as a new revision, so it can itself be undone.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noteID, err := handlers.ParseID(args[0], "note")
+			if err != nil {
+				return err
+			}
+
+			rev, err := parseRevision(args[1])
+			if err != nil {
+				return err
+			}
+
+			defer c.handler.Close()
+			return c.handler.Restore(cmd.Context(), noteID, rev)
+		},
+	})
+
+	syncCmd := &cobra.Command{
+		Use:   "sync [--watch]",
+		Short: "Sync notes with markdown files in the notes directory",
+		Long: `Reconcile the notes database with the markdown files in notes_dir.
+
+Each note is mirrored to a .md file with YAML front matter holding its title,
+tags, archived state and leaflet keys. New files become notes, new notes become
+files, and edits on either side are carried over. Renamed files keep their note
+through content hash matching. When a note and its file both changed since the
+last sync, the most recently modified side wins unless --conflict=prompt is set.
+
+Notes whose file was deleted are only reported; pass --delete to remove them
+from the database. A configured notes_dir that does not exist is an error rather
+than being recreated empty.
+
+With --watch, the directory is monitored and external edits are synced as they
+happen.
+
+Examples:
+  noteleaf note sync
+  noteleaf note sync --watch
+  noteleaf note sync --conflict prompt
+  noteleaf note sync --delete`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			watch, _ := cmd.Flags().GetBool("watch")
+			conflict, _ := cmd.Flags().GetString("conflict")
+			del, _ := cmd.Flags().GetBool("delete")
+
+			defer c.handler.Close()
+			return c.handler.Sync(cmd.Context(), handlers.NoteSyncOptions{Watch: watch, Conflict: conflict, Delete: del})
+		},
+	}
+	syncCmd.Flags().BoolP("watch", "w", false, "Keep running and sync changes as files are edited")
+	syncCmd.Flags().String("conflict", handlers.ConflictMtime, "Conflict resolution: mtime or prompt")
+	syncCmd.Flags().Bool("delete", false, "Remove notes whose file was deleted from the notes directory")
+	root.AddCommand(syncCmd)
+
+	exportCmd := &cobra.Command{
+		Use:   "export [ids|filter...]",
+		Short: "Export notes to markdown, HTML, EPUB or a static site",
+		Long: `Export notes rendered with the same markdown pipeline used for publishing.
+
+Notes are selected by ID, by tag with tag:<name>, by title search, or with all.
+Archived notes are skipped unless --archived is set. Local images are copied
+next to the output and links between exported notes ([[Title]], note:<id> or
+relative .md links) point at the exported files.
+
+Formats:
+  md    markdown files with front matter
+  html  one standalone, print ready HTML page per note (use your browser to save as PDF)
+  epub  a single EPUB book bundling the selected notes
+  site  a static site with an index, tag pages and one page per note
+
+Examples:
+  noteleaf note export 1 2 3 --out ./export
+  noteleaf note export tag:research --format epub --title "Research" --out ./books
+  noteleaf note export all --format site --out ./public
+
+Encrypted notes are skipped unless --unlock is set, which decrypts them in
+memory for the export.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			out, _ := cmd.Flags().GetString("out")
+			archived, _ := cmd.Flags().GetBool("archived")
+			title, _ := cmd.Flags().GetString("title")
+			unlock, _ := cmd.Flags().GetBool("unlock")
+
+			defer c.handler.Close()
+			return c.handler.Export(cmd.Context(), args, handlers.NoteExportOptions{
+				Format: format, OutDir: out, Archived: archived, Title: title, Unlock: unlock,
+			})
+		},
+	}
+	exportCmd.Flags().StringP("format", "f", handlers.ExportMarkdown, "Export format: md, html, epub or site")
+	exportCmd.Flags().StringP("out", "o", "", "Output directory")
+	exportCmd.Flags().Bool("archived", false, "Include archived notes")
+	exportCmd.Flags().String("title", "", "Title of the EPUB book or site")
+	exportCmd.Flags().Bool("unlock", false, "Decrypt and include encrypted notes")
+	exportCmd.MarkFlagRequired("out")
+	root.AddCommand(exportCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import --from <source> [path]",
+		Short: "Import notes from Obsidian, Notion, Bear or Evernote",
+		Long: `Import notes exported from another application.
+
+Sources:
+  obsidian  a vault directory
+  notion    a markdown export, as a directory or the downloaded .zip
+  bear      a markdown or TextBundle export, as a directory or .zip
+  enex      an Evernote .enex file, or a directory of them
+
+Folders, notebooks and tags become noteleaf tags, and created/modified times
+are kept. Attachments are copied into the attachments folder of the data
+directory. Wiki links and links between exported pages are rewritten to
+note:<id> links. Notes that were already imported are skipped, so running
+an import again only brings in new notes.
+
+Examples:
+  noteleaf note import --from obsidian ~/Documents/Vault
+  noteleaf note import --from notion ~/Downloads/Export.zip
+  noteleaf note import --from enex ~/Downloads/Notebook.enex`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString("from")
+
+			defer c.handler.Close()
+			return c.handler.Import(cmd.Context(), from, args[0])
+		},
+	}
+	importCmd.Flags().String("from", "", "Source application: obsidian, notion, bear or enex")
+	importCmd.MarkFlagRequired("from")
+	root.AddCommand(importCmd)
+
+	root.AddCommand(&cobra.Command{
+		Use:   "encrypt [note-id]",
+		Short: "Encrypt a note's content",
+		Long: `Replace a note's content with age ciphertext.
+
+The note is encrypted with the passphrase you are prompted for, or with the
+age identity file set as note_identity_file in the configuration. Set
+NOTELEAF_NOTE_PASSPHRASE to supply the passphrase non-interactively.
+
+Earlier plaintext revisions are removed from the note's history. Encrypted
+notes are decrypted in memory by read and edit, are left out of content search
+and cannot be published. Export skips them unless --unlock is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if noteID, err := handlers.ParseID(args[0], "note"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Encrypt(cmd.Context(), noteID)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "decrypt [note-id]",
+		Short: "Store an encrypted note as plaintext again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if noteID, err := handlers.ParseID(args[0], "note"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Decrypt(cmd.Context(), noteID)
+			}
+		},
+	})
+
 	return root
 }
 
+func parseRevision(arg string) (int, error) {
+	rev, err := strconv.Atoi(arg)
+	if err != nil || rev < 1 {
+		return 0, fmt.Errorf("invalid revision: %s", arg)
+	}
+	return rev, nil
+}
+
 // ArticleCommand implements [CommandGroup] for article-related commands
 type ArticleCommand struct {
 	handler *handlers.ArticleHandler
@@ -518,14 +757,34 @@ metadata including author, title, and publication date.`,
 		Long: `Parse and save article content from a supported website.
 
 The article will be parsed using domain-specific XPath rules and saved
-as both Markdown and HTML files. Article metadata is stored in the database.`,
+as both Markdown and HTML files. Article metadata is stored in the database.
+
+Academic papers can be added as arxiv:<id>, doi:<doi>, an arXiv or doi.org
+link, or a link to a PDF. Their authors, venue, year and abstract are looked
+up on arXiv or Crossref and kept for 'article cite', and the text of the PDF
+is extracted into the markdown file when it can be downloaded.
+
+With --archive, images and media are downloaded next to the article so it
+still reads when the site is gone; --self-contained also embeds them in the
+HTML file. The article_archive and article_self_contained config options
+make these the default.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := c.handler.DefaultSaveOptions()
+			if cmd.Flags().Changed("archive") {
+				opts.Archive, _ = cmd.Flags().GetBool("archive")
+			}
+			if cmd.Flags().Changed("self-contained") {
+				opts.SelfContained, _ = cmd.Flags().GetBool("self-contained")
+				opts.Archive = opts.Archive || opts.SelfContained
+			}
 
 			defer c.handler.Close()
-			return c.handler.Add(cmd.Context(), args[0])
+			return c.handler.AddWithOptions(cmd.Context(), args[0], opts)
 		},
 	}
+	addCmd.Flags().Bool("archive", false, "Download images and media for offline reading")
+	addCmd.Flags().Bool("self-contained", false, "Embed archived assets in the HTML file as data URIs")
 	root.AddCommand(addCmd)
 
 	listCmd := &cobra.Command{
@@ -534,24 +793,161 @@ as both Markdown and HTML files. Article metadata is stored in the database.`,
 		Aliases: []string{"ls"},
 		Long: `List saved articles with optional filtering.
 
-Use query to filter by title, or use flags for more specific filtering.`,
+Use query to filter by title, or use flags for more specific filtering.
+Archived articles are hidden unless --archived or --status archived is given.
+Use --broken to list articles whose link failed its last 'article check'.
+Use -i to browse the reading queue interactively.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			author, _ := cmd.Flags().GetString("author")
 			limit, _ := cmd.Flags().GetInt("limit")
+			status, _ := cmd.Flags().GetString("status")
+			tags, _ := cmd.Flags().GetStringSlice("tag")
+			favorites, _ := cmd.Flags().GetBool("favorites")
+			broken, _ := cmd.Flags().GetBool("broken")
+			interactive, _ := cmd.Flags().GetBool("interactive")
+
+			for _, flag := range []string{"unread", "reading", "read", "archived"} {
+				if set, _ := cmd.Flags().GetBool(flag); set {
+					if status != "" && status != flag {
+						return fmt.Errorf("only one status filter can be used at a time")
+					}
+					status = flag
+				}
+			}
 
 			var query string
 			if len(args) > 0 {
 				query = strings.Join(args, " ")
 			}
 
+			filter := handlers.ArticleListFilter{
+				Query:     query,
+				Author:    author,
+				Status:    status,
+				Tags:      tags,
+				Favorites: favorites,
+				Broken:    broken,
+				Limit:     limit,
+			}
+
 			defer c.handler.Close()
-			return c.handler.List(cmd.Context(), query, author, limit)
+			return c.handler.ListFiltered(cmd.Context(), filter, interactive)
 		},
 	}
 	listCmd.Flags().String("author", "", "Filter by author")
 	listCmd.Flags().IntP("limit", "l", 0, "Limit number of results (0 = no limit)")
+	listCmd.Flags().String("status", "", "Filter by status (unread|reading|read|archived)")
+	listCmd.Flags().Bool("unread", false, "Show only unread articles")
+	listCmd.Flags().Bool("reading", false, "Show only articles being read")
+	listCmd.Flags().Bool("read", false, "Show only read articles")
+	listCmd.Flags().Bool("archived", false, "Show only archived articles")
+	listCmd.Flags().StringSlice("tag", nil, "Show only articles with this tag (repeatable)")
+	listCmd.Flags().Bool("favorites", false, "Show only favourite articles")
+	listCmd.Flags().Bool("broken", false, "Show only articles whose link is dead")
+	listCmd.Flags().BoolP("interactive", "i", false, "Browse the reading queue interactively")
 	root.AddCommand(listCmd)
 
+	root.AddCommand(&cobra.Command{
+		Use:   "done <id>",
+		Short: "Mark article as read",
+		Long:  "Mark an article as read and set its progress to 100%.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.MarkDone(cmd.Context(), articleID)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "archive <id>",
+		Short: "Archive article",
+		Long:  "Move an article out of the reading queue. Its files are kept; use --archived with list to see it.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Archive(cmd.Context(), articleID)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "status <id> <status>",
+		Short: "Update article status (unread|reading|read|archived)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.UpdateStatus(cmd.Context(), articleID, args[1])
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "progress <id> <percentage>",
+		Short: "Update reading progress percentage (0-100)",
+		Long: `Set how far through an article you are.
+
+Unread articles move to reading, and 100% marks the article read.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			articleID, err := handlers.ParseID(args[0], "article")
+			if err != nil {
+				return err
+			}
+			progress, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid progress percentage: %s", args[1])
+			}
+			defer c.handler.Close()
+			return c.handler.UpdateProgress(cmd.Context(), articleID, progress)
+		},
+	})
+
+	favoriteCmd := &cobra.Command{
+		Use:     "favorite <id>",
+		Short:   "Mark article as a favourite",
+		Aliases: []string{"fav"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remove, _ := cmd.Flags().GetBool("remove")
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.SetFavorite(cmd.Context(), articleID, !remove)
+			}
+		},
+	}
+	favoriteCmd.Flags().Bool("remove", false, "Remove the article from favourites")
+	root.AddCommand(favoriteCmd)
+
+	tagCmd := &cobra.Command{
+		Use:   "tag <id> <tag>...",
+		Short: "Add tags to an article",
+		Long:  "Add one or more tags to an article, or remove them with --remove. Filter by tag with 'article list --tag'.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remove, _ := cmd.Flags().GetBool("remove")
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Tag(cmd.Context(), articleID, args[1:], remove)
+			}
+		},
+	}
+	tagCmd.Flags().Bool("remove", false, "Remove the tags instead of adding them")
+	root.AddCommand(tagCmd)
+
 	viewCmd := &cobra.Command{
 		Use:     "view <id>",
 		Short:   "View article details and content preview",
@@ -590,6 +986,124 @@ This displays the complete article content using syntax highlighting and proper
 	}
 	root.AddCommand(readCmd)
 
+	highlightCmd := &cobra.Command{
+		Use:   "highlight <id> <quote>",
+		Short: "Highlight a quote in an article",
+		Long: `Save a quote from an article as a highlight, with an optional note.
+
+The quote is located in the saved markdown ignoring case, punctuation and
+formatting, and a few mistyped letters are tolerated, so text copied from
+'article read' or typed from memory still matches. Highlights are marked in
+'article view'; 'article highlights export' collects them into a note.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			note, _ := cmd.Flags().GetString("note")
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Highlight(cmd.Context(), articleID, args[1], note)
+			}
+		},
+	}
+	highlightCmd.Flags().StringP("note", "n", "", "Your note on the highlight")
+	root.AddCommand(highlightCmd)
+	root.AddCommand(c.highlightsCommand())
+
+	citeCmd := &cobra.Command{
+		Use:   "cite <id>",
+		Short: "Print the citation of an article",
+		Long: `Print the citation of an article as BibTeX, CSL-JSON or an APA reference.
+
+Papers added from arXiv, a DOI or a PDF are cited from their bibliographic
+record; other articles are cited as web pages.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.Cite(cmd.Context(), articleID, format)
+			}
+		},
+	}
+	citeCmd.Flags().StringP("format", "f", "bibtex", "Citation format: bibtex, csl-json or apa")
+	root.AddCommand(citeCmd)
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export citations for every saved article",
+		Long: `Export the citations of the whole library as a BibTeX file or a CSL-JSON
+array, for LaTeX, pandoc or a reference manager.
+
+Cite keys such as lovelace2021sparse are built from the first author, year
+and title and are the same in both formats.
+
+Examples:
+  noteleaf article export --bibtex > library.bib
+  noteleaf article export --csl-json --out library.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bibtex, _ := cmd.Flags().GetBool("bibtex")
+			cslJSON, _ := cmd.Flags().GetBool("csl-json")
+			out, _ := cmd.Flags().GetString("out")
+
+			format := "bibtex"
+			switch {
+			case bibtex && cslJSON:
+				return fmt.Errorf("choose one of --bibtex or --csl-json")
+			case cslJSON:
+				format = "csl-json"
+			}
+
+			defer c.handler.Close()
+			return c.handler.ExportCitations(cmd.Context(), format, out)
+		},
+	}
+	exportCmd.Flags().Bool("bibtex", false, "Export BibTeX entries (the default)")
+	exportCmd.Flags().Bool("csl-json", false, "Export a CSL-JSON array")
+	exportCmd.Flags().StringP("out", "o", "", "Write to a file instead of standard output")
+	root.AddCommand(exportCmd)
+
+	checkCmd := &cobra.Command{
+		Use:   "check [id...]",
+		Short: "Check that saved article links still work",
+		Long: `Request the URLs of the given articles, or of every article with --all, and
+record their HTTP status, redirects and when they were checked.
+
+Pages that are gone (404, 410, or a host that does not resolve or refuses
+connections) and whose saved markdown is missing are fetched again from a web
+archive (the Wayback Machine unless article_archive_endpoint is set). Saved
+copies are never replaced, and other errors such as 429 or 503 are reported as
+possibly temporary. Articles that moved permanently can have their URL updated: you are
+asked about each one, or --update-urls updates them all.
+
+Examples:
+  noteleaf article check 12
+  noteleaf article check --all --update-urls
+  noteleaf article list --broken`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			updateURLs, _ := cmd.Flags().GetBool("update-urls")
+
+			var ids []int64
+			for _, arg := range args {
+				id, err := handlers.ParseID(arg, "article")
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+			}
+
+			defer c.handler.Close()
+			return c.handler.CheckLinks(cmd.Context(), ids, handlers.ArticleCheckOptions{All: all, UpdateURLs: updateURLs})
+		},
+	}
+	checkCmd.Flags().BoolP("all", "a", false, "Check every saved article")
+	checkCmd.Flags().Bool("update-urls", false, "Replace URLs that moved permanently without asking")
+	root.AddCommand(checkCmd)
+
 	removeCmd := &cobra.Command{
 		Use:     "remove <id>",
 		Short:   "Remove article and associated files",
@@ -610,6 +1124,44 @@ and HTML files. This operation cannot be undone.`,
 	}
 	root.AddCommand(removeCmd)
 
+	importCmd := &cobra.Command{
+		Use:   "import --from <source> <file>",
+		Short: "Import a reading list from Pocket, Instapaper, Wallabag or bookmarks",
+		Long: `Import saved links exported from another read-later service.
+
+Sources:
+  pocket      the ril_export.html or CSV export
+  instapaper  the CSV export
+  wallabag    the JSON export
+  bookmarks   a Netscape bookmarks.html file exported from a browser
+
+Every link is fetched and parsed like 'article add'. The time each link was
+saved, its tags and its read, archived and starred state are kept; bookmark
+folders become tags. Links that are already saved are not fetched again;
+their tags and starred state are combined with the export's, and they take
+the earlier saved time and the further of the two reading states.
+
+Progress is stored as the import runs. If it is interrupted, or some links
+fail to download, run the same command again to continue.
+
+Examples:
+  noteleaf article import --from pocket ~/Downloads/ril_export.html
+  noteleaf article import --from wallabag ~/Downloads/wallabag-export.json
+  noteleaf article import --from bookmarks ~/bookmarks.html`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString("from")
+
+			defer c.handler.Close()
+			return c.handler.Import(cmd.Context(), from, args[0])
+		},
+	}
+	importCmd.Flags().String("from", "", "Source: pocket, instapaper, wallabag or bookmarks")
+	importCmd.MarkFlagRequired("from")
+	root.AddCommand(importCmd)
+	root.AddCommand(c.rulesCommand())
+	root.AddCommand(c.feedCommand())
+
 	originalHelpFunc := root.HelpFunc()
 	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		originalHelpFunc(cmd, args)
@@ -622,6 +1174,212 @@ and HTML files. This operation cannot be undone.`,
 	return root
 }
 
+func (c *ArticleCommand) rulesCommand() *cobra.Command {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manage article parsing rules",
+		Long: `Manage the XPath rules used to extract articles.
+
+Rules use the FiveFilters site config format. Rules in the article-rules
+directory beside the config file are loaded after the built-in rules and
+replace them for the same domain, so a site can be fixed or added without
+rebuilding noteleaf.`,
+	}
+
+	rulesCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "List loaded parsing rules",
+		Aliases: []string{"ls"},
+		Long:    "List every loaded rule with whether it is built in or a user rule, and its last test result.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer c.handler.Close()
+			return c.handler.ListRules(cmd.Context())
+		},
+	})
+
+	testCmd := &cobra.Command{
+		Use:   "test <domain>",
+		Short: "Run a rule against its test URLs",
+		Long: `Parse each test_url of a rule and report whether the rule found the
+title and body, and the extraction confidence.
+
+Pages are cached as fixtures the first time they are fetched, so later runs
+work offline: a fixture is parsed on its own, without following the rule's
+single_page_link or next_page_link. Use --live to fetch every page again,
+follow those links and refresh the fixtures.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			live, _ := cmd.Flags().GetBool("live")
+			defer c.handler.Close()
+			return c.handler.TestRule(cmd.Context(), args[0], live)
+		},
+	}
+	testCmd.Flags().Bool("live", false, "Fetch the test URLs instead of using cached fixtures")
+	rulesCmd.AddCommand(testCmd)
+
+	newCmd := &cobra.Command{
+		Use:   "new <url>",
+		Short: "Draft a rule for a site from an article URL",
+		Long: `Fetch an article, detect its title, author, date and body with the
+heuristic extractor and write a rule for its site to the user rules
+directory, with the URL as a test_url. Review the XPaths, then run
+'article rules test <domain>'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			defer c.handler.Close()
+			return c.handler.NewRule(cmd.Context(), args[0], force)
+		},
+	}
+	newCmd.Flags().Bool("force", false, "Replace an existing user rule for the site")
+	rulesCmd.AddCommand(newCmd)
+
+	return rulesCmd
+}
+
+func (c *ArticleCommand) highlightsCommand() *cobra.Command {
+	highlightsCmd := &cobra.Command{
+		Use:   "highlights",
+		Short: "Manage article highlights",
+		Long:  "Export or remove the highlights saved with 'article highlight'. 'article view' lists them.",
+	}
+
+	highlightsCmd.AddCommand(&cobra.Command{
+		Use:   "export <id>",
+		Short: "Collect an article's highlights into a note",
+		Long: `Write every highlight of an article, with its note and date, to a note
+that links back to the article's URL.
+
+The first export creates the note; later exports update the same note, so
+run it again after adding highlights. Earlier versions of the note are kept
+in its history.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if articleID, err := handlers.ParseID(args[0], "article"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.ExportHighlights(cmd.Context(), articleID)
+			}
+		},
+	})
+
+	highlightsCmd.AddCommand(&cobra.Command{
+		Use:     "remove <id> <highlight-id>",
+		Short:   "Remove a highlight from an article",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			articleID, err := handlers.ParseID(args[0], "article")
+			if err != nil {
+				return err
+			}
+			highlightID, err := handlers.ParseID(args[1], "highlight")
+			if err != nil {
+				return err
+			}
+			defer c.handler.Close()
+			return c.handler.RemoveHighlight(cmd.Context(), articleID, highlightID)
+		},
+	})
+	return highlightsCmd
+}
+
+func (c *ArticleCommand) feedCommand() *cobra.Command {
+	feedCmd := &cobra.Command{
+		Use:   "feed",
+		Short: "Manage RSS, Atom and JSON feed subscriptions",
+		Long: `Subscribe to feeds whose new entries are saved to the reading queue.
+
+Refreshing a feed sends the validators of the last fetch, so unchanged feeds
+cost a single request. New entries are parsed like 'article add', skipping
+entries that were saved before or whose URL is already in the queue.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <url>",
+		Short: "Subscribe to a feed",
+		Long: `Subscribe to an RSS, Atom or JSON feed. The feed is fetched once to check
+it; run 'article feed refresh' to import its entries.
+
+Entries must mention one of the --include keywords (when given) and none of
+the --exclude keywords in their title or summary. --max-age skips entries
+published more than that many days ago. Articles saved from the feed get the
+--tag tags.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			include, _ := cmd.Flags().GetStringSlice("include")
+			exclude, _ := cmd.Flags().GetStringSlice("exclude")
+			maxAge, _ := cmd.Flags().GetInt("max-age")
+			tags, _ := cmd.Flags().GetStringSlice("tag")
+
+			defer c.handler.Close()
+			return c.handler.AddFeed(cmd.Context(), args[0], handlers.FeedOptions{
+				Include: include,
+				Exclude: exclude,
+				MaxAge:  maxAge,
+				Tags:    tags,
+			})
+		},
+	}
+	addCmd.Flags().StringSlice("include", nil, "Only save entries mentioning one of these keywords")
+	addCmd.Flags().StringSlice("exclude", nil, "Skip entries mentioning any of these keywords")
+	addCmd.Flags().Int("max-age", 0, "Skip entries older than this many days")
+	addCmd.Flags().StringSlice("tag", nil, "Tag every article saved from the feed")
+	feedCmd.AddCommand(addCmd)
+
+	feedCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "List feed subscriptions",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer c.handler.Close()
+			return c.handler.ListFeeds(cmd.Context())
+		},
+	})
+
+	refreshCmd := &cobra.Command{
+		Use:   "refresh [id...]",
+		Short: "Fetch feeds and save their new entries",
+		Long:  "Fetch the given feeds, or every feed with --all, and save new entries that pass the feed's filters as unread articles.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+
+			var ids []int64
+			for _, arg := range args {
+				id, err := handlers.ParseID(arg, "feed")
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+			}
+
+			defer c.handler.Close()
+			return c.handler.RefreshFeeds(cmd.Context(), ids, all)
+		},
+	}
+	refreshCmd.Flags().BoolP("all", "a", false, "Refresh every feed")
+	feedCmd.AddCommand(refreshCmd)
+
+	feedCmd.AddCommand(&cobra.Command{
+		Use:     "remove <id>",
+		Short:   "Unsubscribe from a feed",
+		Aliases: []string{"rm"},
+		Long:    "Remove a feed subscription. Articles already saved from the feed are kept.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if feedID, err := handlers.ParseID(args[0], "feed"); err != nil {
+				return err
+			} else {
+				defer c.handler.Close()
+				return c.handler.RemoveFeed(cmd.Context(), feedID)
+			}
+		},
+	})
+
+	return feedCmd
+}
+
 // ConfigCommand implements [CommandGroup] for configuration management commands
 type ConfigCommand struct {
 	handler *handlers.ConfigHandler
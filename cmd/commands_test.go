@@ -298,6 +298,14 @@ func TestCommandGroup(t *testing.T) {
 				"read [note-id]",
 				"edit [note-id]",
 				"remove [note-id]",
+				"history [note-id]",
+				"diff [note-id] [rev1] [rev2]",
+				"restore [note-id] [rev]",
+				"sync [--watch]",
+				"export [ids|filter...]",
+				"import --from <source> [path]",
+				"encrypt [note-id]",
+				"decrypt [note-id]",
 			}
 
 			for _, expected := range expectedSubcommands {
@@ -376,7 +384,7 @@ func TestCommandGroup(t *testing.T) {
 				subcommandNames[i] = subcmd.Use
 			}
 
-			for _, expected := range []string{"add <url>", "list [query]", "view <id>", "remove <id>"} {
+			for _, expected := range []string{"add <url>", "list [query]", "view <id>", "remove <id>", "rules", "done <id>", "tag <id> <tag>...", "feed", "import --from <source> <file>", "highlight <id> <quote>", "highlights", "cite <id>", "export", "check [id...]"} {
 				if !findSubcommand(subcommandNames, expected) {
 					t.Errorf("Expected subcommand '%s' not found in %v", expected, subcommandNames)
 				}
@@ -849,6 +857,119 @@ func TestCommandExecution(t *testing.T) {
 			}
 		})
 
+		t.Run("history command with valid note ID", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			err := handler.CreateWithOptions(context.Background(), "test note", "test content", "", false, false)
+			if err != nil {
+				t.Fatalf("failed to create test note: %v", err)
+			}
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"history", "1"})
+			if err := cmd.Execute(); err != nil {
+				t.Errorf("note history command failed: %v", err)
+			}
+		})
+
+		t.Run("diff command with invalid revision", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"diff", "1", "zero"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected note diff command to fail with invalid revision")
+			}
+		})
+
+		t.Run("restore command requires revision", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"restore", "1"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected note restore command to fail without revision")
+			}
+		})
+
+		t.Run("export command writes html", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			err := handler.CreateWithOptions(context.Background(), "test note", "test content", "", false, false)
+			if err != nil {
+				t.Fatalf("failed to create test note: %v", err)
+			}
+
+			out := t.TempDir()
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"export", "1", "--format", "html", "--out", out})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("note export command failed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(out, "test-note.html")); err != nil {
+				t.Errorf("expected exported page: %v", err)
+			}
+		})
+
+		t.Run("export command requires out", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"export", "all"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected note export command to fail without --out")
+			}
+		})
+
+		t.Run("encrypt command", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+			t.Setenv("NOTELEAF_NOTE_PASSPHRASE", "correct horse")
+
+			err := handler.CreateWithOptions(context.Background(), "test note", "test content", "", false, false)
+			if err != nil {
+				t.Fatalf("failed to create test note: %v", err)
+			}
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"encrypt", "1"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("note encrypt command failed: %v", err)
+			}
+		})
+
+		t.Run("decrypt command fails for plain note", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			err := handler.CreateWithOptions(context.Background(), "test note", "test content", "", false, false)
+			if err != nil {
+				t.Fatalf("failed to create test note: %v", err)
+			}
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"decrypt", "1"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected note decrypt command to fail for a note that is not encrypted")
+			}
+		})
+
+		t.Run("encrypt command with invalid ID", func(t *testing.T) {
+			handler, cleanup := createTestNoteHandler(t)
+			defer cleanup()
+
+			cmd := NewNoteCommand(handler).Create()
+			cmd.SetArgs([]string{"encrypt", "invalid"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected note encrypt command to fail with invalid ID")
+			}
+		})
+
 		t.Run("edit command with invalid ID", func(t *testing.T) {
 			handler, cleanup := createTestNoteHandler(t)
 			defer cleanup()
@@ -45,6 +45,14 @@ func NewApp() (*App, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	// tokens an older version left in the config file move on the first run of any command
+	if err := store.MigrateSecrets(config); err != nil {
+		ui.Warningln("Credentials are still in the config file: %v", err)
+	}
+	if config.SecretStore == store.SecretStoreSecretService && !store.SecretServiceAvailable() {
+		ui.Warningln("secret_store is secret-service, but secret-tool (libsecret) or a D-Bus session is missing; using the encrypted secrets file")
+	}
+
 	return &App{db, config}, nil
 }
 
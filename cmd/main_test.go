@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -44,6 +46,36 @@ func TestNewApp(t *testing.T) {
 		}
 	})
 
+	t.Run("MigratesPlaintextCredentials", func(t *testing.T) {
+		origDB := store.NewDatabase
+		defer func() { store.NewDatabase = origDB }()
+		store.NewDatabase = func() (*store.Database, error) { return &store.Database{}, nil }
+
+		configPath := filepath.Join(t.TempDir(), ".noteleaf.conf.toml")
+		t.Setenv("NOTELEAF_CONFIG", configPath)
+		legacy := "atproto_did = \"did:plc:test\"\natproto_access_jwt = \"access-token\"\n"
+		if err := os.WriteFile(configPath, []byte(legacy), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		secrets := store.NewMemorySecretStore()
+		store.UseSecretStore(secrets)
+		defer store.UseSecretStore(nil)
+
+		if _, err := NewApp(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "access-token") {
+			t.Errorf("expected startup to move the token out of the config file, got:\n%s", data)
+		}
+		if value, err := secrets.Get("atproto_access_jwt"); err != nil || value != "access-token" {
+			t.Errorf("expected the token in the secret store, got %q, %v", value, err)
+		}
+	})
+
 	t.Run("DBError", func(t *testing.T) {
 		origDB := store.NewDatabase
 		defer func() { store.NewDatabase = origDB }()
@@ -51,7 +51,17 @@ For the password, use an app password (not your main password):
 2. Create a new app password named "noteleaf"
 3. Use that password here
 
-If credentials are not provided via flags, use the interactive input.`,
+If credentials are not provided via flags, use the interactive input.
+
+With --oauth, noteleaf signs in through your browser instead of using an app
+password. It resolves your handle to your PDS, finds its authorization server
+and opens the authorization page; the browser then redirects back to a
+temporary server on 127.0.0.1. Tokens are bound to a key kept in your
+configuration and refreshed automatically.
+
+Examples:
+  noteleaf pub auth alice.bsky.social --password xxxx-xxxx-xxxx-xxxx
+  noteleaf pub auth alice.bsky.social --oauth`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var handle string
@@ -69,6 +79,14 @@ If credentials are not provided via flags, use the interactive input.`,
 
 			password, _ := cmd.Flags().GetString("password")
 
+			if oauth, _ := cmd.Flags().GetBool("oauth"); oauth {
+				if password != "" {
+					return fmt.Errorf("--password cannot be used with --oauth")
+				}
+				defer c.handler.Close()
+				return c.handler.AuthOAuth(cmd.Context(), handle)
+			}
+
 			if handle != "" && password != "" {
 				defer c.handler.Close()
 				return c.handler.Auth(cmd.Context(), handle, password)
@@ -89,6 +107,7 @@ If credentials are not provided via flags, use the interactive input.`,
 		},
 	}
 	authCmd.Flags().StringP("password", "p", "", "App password (will prompt if not provided)")
+	authCmd.Flags().Bool("oauth", false, "Sign in through the browser with OAuth instead of an app password")
 	root.AddCommand(authCmd)
 
 	pullCmd := &cobra.Command{
@@ -100,9 +119,13 @@ This will:
 - Connect to your BlueSky/leaflet account
 - Fetch all documents in your repository
 - Create new notes for documents not yet synced
-- Update existing notes that have changed
+- Update notes whose documents changed on leaflet but not locally
+- Flag notes changed both locally and on leaflet as conflicts
+- Flag notes whose documents were deleted on leaflet
 
-Notes are matched by their leaflet record key (rkey) stored in the database.`,
+Notes are matched by their leaflet record key (rkey) stored in the database.
+Local edits are never overwritten: review conflicts and deletions with
+'noteleaf pub status' and settle them with 'noteleaf pub resolve'.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer c.handler.Close()
 			return c.handler.Pull(cmd.Context())
@@ -186,14 +209,17 @@ Examples:
 
 	statusCmd := &cobra.Command{
 		Use:   "status",
-		Short: "Show leaflet authentication status",
-		Long:  "Display current authentication status and session information.",
+		Short: "Show leaflet authentication and sync status",
+		Long: `Display current authentication status and session information, followed by
+notes that need attention: conflicts and deletions found by the last pull,
+and local edits that have not been pushed yet.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer c.handler.Close()
 			status := c.handler.GetAuthStatus()
 			fmt.Println("Leaflet Status:")
 			fmt.Printf("  %s\n", status)
-			return nil
+			fmt.Println()
+			return c.handler.SyncStatus(cmd.Context())
 		},
 	}
 	root.AddCommand(statusCmd)
@@ -211,7 +237,16 @@ Examples:
   noteleaf pub post 123                # Publish note 123
   noteleaf pub post 123 --draft        # Create as draft
   noteleaf pub post 123 --preview      # Preview without posting
-  noteleaf pub post 123 --validate     # Validate conversion only`,
+  noteleaf pub post 123 --validate     # Validate conversion only
+  noteleaf pub post 123 --publication "Game Dev"
+
+Without --publication the note goes to the publication routed from its tags
+(leaflet_publication_routes), then the configured leaflet_publication, then the
+first publication on the account.
+
+Images are uploaded as blobs, downscaled if they exceed the PDS size limit.
+Remote images are downloaded first, and images uploaded before are reused.
+--preview lists the images and the sizes they would be uploaded at.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			noteID, err := parseNoteID(args[0])
@@ -220,6 +255,7 @@ Examples:
 			}
 
 			isDraft, _ := cmd.Flags().GetBool("draft")
+			publication, _ := cmd.Flags().GetString("publication")
 			preview, _ := cmd.Flags().GetBool("preview")
 			validate, _ := cmd.Flags().GetBool("validate")
 			output, _ := cmd.Flags().GetString("output")
@@ -233,17 +269,18 @@ Examples:
 			defer c.handler.Close()
 
 			if preview {
-				return c.handler.PostPreview(cmd.Context(), noteID, isDraft, output, plaintext)
+				return c.handler.PostPreview(cmd.Context(), noteID, isDraft, publication, output, plaintext)
 			}
 
 			if validate {
-				return c.handler.PostValidate(cmd.Context(), noteID, isDraft, output, plaintext)
+				return c.handler.PostValidate(cmd.Context(), noteID, isDraft, publication, output, plaintext)
 			}
 
-			return c.handler.Post(cmd.Context(), noteID, isDraft)
+			return c.handler.Post(cmd.Context(), noteID, isDraft, publication)
 		},
 	}
 	postCmd.Flags().Bool("draft", false, "Create as draft instead of publishing")
+	postCmd.Flags().String("publication", "", "Publication name, rkey or AT URI to post to")
 	postCmd.Flags().Bool("preview", false, "Show what would be posted without actually posting")
 	postCmd.Flags().Bool("validate", false, "Validate markdown conversion without posting")
 	postCmd.Flags().StringP("output", "o", "", "Write document to file (defaults to JSON format)")
@@ -303,6 +340,52 @@ Examples:
 	patchCmd.Flags().Bool("txt", false, "Alias for --plaintext")
 	root.AddCommand(patchCmd)
 
+	publishCmd := &cobra.Command{
+		Use:   "publish [note-id]",
+		Short: "Publish a leaflet draft",
+		Long: `Turn a draft on leaflet into a published document.
+
+The note's current content is published, and the document keeps its record
+key (rkey) unless leaflet already uses it for a published document.
+
+Examples:
+  noteleaf pub publish 123`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noteID, err := parseNoteID(args[0])
+			if err != nil {
+				return err
+			}
+
+			defer c.handler.Close()
+			return c.handler.Publish(cmd.Context(), noteID)
+		},
+	}
+	root.AddCommand(publishCmd)
+
+	unpublishCmd := &cobra.Command{
+		Use:   "unpublish [note-id]",
+		Short: "Move a published document back to drafts",
+		Long: `Turn a published leaflet document back into a draft.
+
+The note's current content is saved as the draft, and the document keeps its
+record key (rkey) unless leaflet already uses it for a draft.
+
+Examples:
+  noteleaf pub unpublish 123`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noteID, err := parseNoteID(args[0])
+			if err != nil {
+				return err
+			}
+
+			defer c.handler.Close()
+			return c.handler.Unpublish(cmd.Context(), noteID)
+		},
+	}
+	root.AddCommand(unpublishCmd)
+
 	pushCmd := &cobra.Command{
 		Use:   "push [note-ids...] [--file files...]",
 		Short: "Create or update multiple documents on leaflet",
@@ -320,16 +403,21 @@ Examples:
   noteleaf pub push --file article.md           # Create note from file and push
   noteleaf pub push --file a.md b.md --draft    # Create notes from multiple files
   noteleaf pub push 1 2 --dry-run               # Validate without pushing
-  noteleaf pub push --file article.md --dry-run # Create note but don't push`,
+  noteleaf pub push --file article.md --dry-run # Create note but don't push
+  noteleaf pub push 1 2 --publication "Game Dev" # Post new documents to a publication
+
+--publication only applies to notes posted for the first time; updated documents
+stay in their publication.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			isDraft, _ := cmd.Flags().GetBool("draft")
+			publication, _ := cmd.Flags().GetString("publication")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			files, _ := cmd.Flags().GetStringSlice("file")
 
 			defer c.handler.Close()
 
 			if len(files) > 0 {
-				return c.handler.PushFromFiles(cmd.Context(), files, isDraft, dryRun)
+				return c.handler.PushFromFiles(cmd.Context(), files, isDraft, publication, dryRun)
 			}
 
 			if len(args) == 0 {
@@ -345,13 +433,62 @@ Examples:
 				noteIDs[i] = id
 			}
 
-			return c.handler.Push(cmd.Context(), noteIDs, isDraft, dryRun)
+			return c.handler.Push(cmd.Context(), noteIDs, isDraft, publication, dryRun)
 		},
 	}
 	pushCmd.Flags().Bool("draft", false, "Create/update as drafts instead of publishing")
+	pushCmd.Flags().String("publication", "", "Publication name, rkey or AT URI for new documents")
 	pushCmd.Flags().Bool("dry-run", false, "Create note records but skip leaflet push")
 	pushCmd.Flags().StringSliceP("file", "f", []string{}, "Create notes from markdown files before pushing")
 	root.AddCommand(pushCmd)
+
+	root.AddCommand(c.publicationsCommand())
+	root.AddCommand(c.queueCommand())
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve [note-id]",
+		Short: "Resolve a conflict found by pull",
+		Long: `Settle a note that pull flagged as changed both locally and on leaflet, or
+whose document was deleted on leaflet.
+
+For a conflict:
+  --ours    keep the local note; the next patch overwrites leaflet
+  --theirs  replace the note with the leaflet version
+  --merge   merge both versions, marking lines changed on both sides
+
+For a deleted document:
+  --ours    keep the note, unlinked from leaflet
+  --theirs  archive the note
+
+Examples:
+  noteleaf pub resolve 123 --merge
+  noteleaf pub resolve 123 --theirs`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noteID, err := parseNoteID(args[0])
+			if err != nil {
+				return err
+			}
+
+			var strategy string
+			for _, name := range []string{handlers.ResolveOurs, handlers.ResolveTheirs, handlers.ResolveMerge} {
+				if set, _ := cmd.Flags().GetBool(name); set {
+					strategy = name
+				}
+			}
+			if strategy == "" {
+				return fmt.Errorf("choose one of --ours, --theirs, or --merge")
+			}
+
+			defer c.handler.Close()
+			return c.handler.Resolve(cmd.Context(), noteID, strategy)
+		},
+	}
+	resolveCmd.Flags().Bool(handlers.ResolveOurs, false, "Keep the local version")
+	resolveCmd.Flags().Bool(handlers.ResolveTheirs, false, "Take the leaflet version")
+	resolveCmd.Flags().Bool(handlers.ResolveMerge, false, "Merge both versions")
+	resolveCmd.MarkFlagsMutuallyExclusive(handlers.ResolveOurs, handlers.ResolveTheirs, handlers.ResolveMerge)
+	root.AddCommand(resolveCmd)
 	return root
 }
 
@@ -362,3 +499,159 @@ func parseNoteID(arg string) (int64, error) {
 	}
 	return noteID, nil
 }
+
+func (c *PublicationCommand) publicationsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "publications",
+		Short: "Manage leaflet publications",
+		Long: `List, create and update the publications on your leaflet account.
+
+Publications can be referred to by name, rkey or AT URI. Notes are routed to a
+publication with --publication on post and push, or by tag in the config:
+
+  leaflet_publication = "Blog"
+
+  [leaflet_publication_routes]
+  gamedev = "Game Dev"`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List publications",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer c.handler.Close()
+			return c.handler.ListPublications(cmd.Context())
+		},
+	}
+	root.AddCommand(listCmd)
+
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a publication",
+		Long: `Create a new publication on leaflet.
+
+Examples:
+  noteleaf pub publications create "Game Dev" --description "Devlogs" --base-path gamedev.leaflet.pub
+  noteleaf pub publications create "Notes" --icon icon.png`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := publicationOptions(cmd)
+			opts.Name = args[0]
+
+			defer c.handler.Close()
+			return c.handler.CreatePublication(cmd.Context(), opts)
+		},
+	}
+	addPublicationFlags(createCmd)
+	root.AddCommand(createCmd)
+
+	updateCmd := &cobra.Command{
+		Use:   "update [publication]",
+		Short: "Update a publication",
+		Long: `Change the name, description, base path or icon of a publication.
+
+Only the fields given are changed.
+
+Examples:
+  noteleaf pub publications update "Game Dev" --name "Game Development"
+  noteleaf pub publications update 3lbq... --icon icon.png`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := publicationOptions(cmd)
+			opts.Name, _ = cmd.Flags().GetString("name")
+
+			defer c.handler.Close()
+			return c.handler.UpdatePublication(cmd.Context(), args[0], opts)
+		},
+	}
+	updateCmd.Flags().String("name", "", "New publication name")
+	addPublicationFlags(updateCmd)
+	root.AddCommand(updateCmd)
+
+	return root
+}
+
+func addPublicationFlags(cmd *cobra.Command) {
+	cmd.Flags().String("description", "", "Publication description")
+	cmd.Flags().String("base-path", "", "Domain and path the publication is served from")
+	cmd.Flags().String("icon", "", "Image file to use as the publication icon")
+}
+
+func publicationOptions(cmd *cobra.Command) handlers.PublicationOptions {
+	description, _ := cmd.Flags().GetString("description")
+	basePath, _ := cmd.Flags().GetString("base-path")
+	icon, _ := cmd.Flags().GetString("icon")
+	return handlers.PublicationOptions{Description: description, BasePath: basePath, IconPath: icon}
+}
+
+func (c *PublicationCommand) queueCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "queue",
+		Short: "Manage leaflet operations queued while offline",
+		Long: `When leaflet cannot be reached or the session has expired, post, patch and
+push queue their changes instead of failing. Several changes to the same
+note are combined, so only the latest version is uploaded.
+
+Use flush to send the queue once you are back online.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List queued operations",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer c.handler.Close()
+			return c.handler.QueueList(cmd.Context())
+		},
+	}
+	root.AddCommand(listCmd)
+
+	flushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Send queued operations to leaflet",
+		Long: `Send queued operations in the order they were queued.
+
+Temporary failures are retried with exponential backoff. If leaflet is still
+unreachable the flush stops and the remaining operations stay queued. Operations
+leaflet rejects are marked failed and skipped until dropped or queued again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer c.handler.Close()
+			return c.handler.QueueFlush(cmd.Context())
+		},
+	}
+	root.AddCommand(flushCmd)
+
+	dropCmd := &cobra.Command{
+		Use:   "drop [note-id]",
+		Short: "Discard queued operations without sending them",
+		Long: `Discard the queued operation for a note, or every queued operation with --all.
+
+Examples:
+  noteleaf pub queue drop 123
+  noteleaf pub queue drop --all`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			if all == (len(args) == 1) {
+				return fmt.Errorf("give a note ID or --all")
+			}
+
+			var noteID int64
+			if len(args) == 1 {
+				id, err := parseNoteID(args[0])
+				if err != nil {
+					return err
+				}
+				noteID = id
+			}
+
+			defer c.handler.Close()
+			return c.handler.QueueDrop(cmd.Context(), noteID, all)
+		},
+	}
+	dropCmd.Flags().Bool("all", false, "Discard every queued operation")
+	root.AddCommand(dropCmd)
+
+	return root
+}
@@ -71,7 +71,12 @@ func TestPublicationCommand(t *testing.T) {
 				"status",
 				"post [note-id]",
 				"patch [note-id]",
+				"publish [note-id]",
+				"unpublish [note-id]",
 				"push [note-ids...] [--file files...]",
+				"resolve [note-id]",
+				"publications",
+				"queue",
 			}
 
 			for _, expected := range expectedSubcommands {
@@ -247,6 +252,172 @@ func TestPublicationCommand(t *testing.T) {
 		})
 	})
 
+	t.Run("Publish Commands", func(t *testing.T) {
+		for _, name := range []string{"publish", "unpublish"} {
+			t.Run(name+" requires note ID argument", func(t *testing.T) {
+				handler, cleanup := createTestPublicationHandler(t)
+				defer cleanup()
+
+				cmd := NewPublicationCommand(handler).Create()
+				cmd.SetArgs([]string{name})
+				if err := cmd.Execute(); err == nil {
+					t.Error("Expected error for missing note ID")
+				}
+			})
+
+			t.Run(name+" fails when not authenticated", func(t *testing.T) {
+				handler, cleanup := createTestPublicationHandler(t)
+				defer cleanup()
+
+				cmd := NewPublicationCommand(handler).Create()
+				cmd.SetArgs([]string{name, "123"})
+				err := cmd.Execute()
+
+				if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+					t.Errorf("Expected 'not authenticated' error, got: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Resolve Command", func(t *testing.T) {
+		t.Run("requires a strategy", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"resolve", "1"})
+			err := cmd.Execute()
+
+			if err == nil || !strings.Contains(err.Error(), "--ours, --theirs, or --merge") {
+				t.Errorf("Expected missing strategy error, got: %v", err)
+			}
+		})
+
+		t.Run("rejects more than one strategy", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"resolve", "1", "--ours", "--theirs"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("Expected error when combining strategies")
+			}
+		})
+
+		t.Run("fails for unknown note", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"resolve", "999", "--merge"})
+			err := cmd.Execute()
+
+			if err == nil || !strings.Contains(err.Error(), "failed to get note") {
+				t.Errorf("Expected missing note error, got: %v", err)
+			}
+		})
+	})
+
+	t.Run("Publications Command", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"publications", "list"},
+			{"publications", "create", "Blog"},
+			{"publications", "update", "Blog", "--name", "Journal"},
+		} {
+			t.Run(args[1]+" fails when not authenticated", func(t *testing.T) {
+				handler, cleanup := createTestPublicationHandler(t)
+				defer cleanup()
+
+				cmd := NewPublicationCommand(handler).Create()
+				cmd.SetArgs(args)
+				err := cmd.Execute()
+
+				if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+					t.Errorf("Expected 'not authenticated' error, got: %v", err)
+				}
+			})
+		}
+
+		t.Run("create requires a name", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"publications", "create"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("Expected error for missing name")
+			}
+		})
+
+		t.Run("post accepts publication flag", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"post", "123", "--publication", "Blog"})
+			err := cmd.Execute()
+
+			if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+				t.Errorf("Expected 'not authenticated' error, got: %v", err)
+			}
+		})
+	})
+
+	t.Run("Queue Command", func(t *testing.T) {
+		t.Run("list works offline", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"queue", "list"})
+			if err := cmd.Execute(); err != nil {
+				t.Errorf("queue list failed: %v", err)
+			}
+		})
+
+		t.Run("flush fails when not authenticated", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"queue", "flush"})
+			err := cmd.Execute()
+
+			if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+				t.Errorf("Expected 'not authenticated' error, got: %v", err)
+			}
+		})
+
+		t.Run("drop requires a note ID or --all", func(t *testing.T) {
+			for _, args := range [][]string{{"queue", "drop"}, {"queue", "drop", "1", "--all"}} {
+				handler, cleanup := createTestPublicationHandler(t)
+
+				cmd := NewPublicationCommand(handler).Create()
+				cmd.SetArgs(args)
+				err := cmd.Execute()
+				cleanup()
+
+				if err == nil || !strings.Contains(err.Error(), "note ID or --all") {
+					t.Errorf("%v: expected usage error, got: %v", args, err)
+				}
+			}
+		})
+
+		t.Run("drop fails when nothing is queued", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"queue", "drop", "42"})
+			err := cmd.Execute()
+
+			if err == nil || !strings.Contains(err.Error(), "nothing queued") {
+				t.Errorf("Expected 'nothing queued' error, got: %v", err)
+			}
+		})
+	})
+
 	t.Run("Post Command", func(t *testing.T) {
 		t.Run("requires note ID argument", func(t *testing.T) {
 			handler, cleanup := createTestPublicationHandler(t)
@@ -496,6 +667,27 @@ func TestPublicationCommand(t *testing.T) {
 			}
 		})
 
+		t.Run("oauth validates empty handle", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			err := handler.AuthOAuth(context.Background(), "")
+			if err == nil || !strings.Contains(err.Error(), "handle is required") {
+				t.Errorf("Expected 'handle is required' error, got: %v", err)
+			}
+		})
+
+		t.Run("auth rejects password with oauth", func(t *testing.T) {
+			handler, cleanup := createTestPublicationHandler(t)
+			defer cleanup()
+
+			cmd := NewPublicationCommand(handler).Create()
+			cmd.SetArgs([]string{"auth", "alice.test", "--oauth", "--password", "secret"})
+			if err := cmd.Execute(); err == nil {
+				t.Error("Expected error when combining --oauth and --password")
+			}
+		})
+
 		t.Run("auth validates empty password", func(t *testing.T) {
 			handler, cleanup := createTestPublicationHandler(t)
 			defer cleanup()
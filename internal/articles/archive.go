@@ -0,0 +1,198 @@
+package articles
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// maxAssetBytes caps the size of a single downloaded asset
+const maxAssetBytes = 25 << 20
+
+// assetDirSuffix is appended to an article's file name to name its asset directory
+const assetDirSuffix = "_assets"
+
+// mediaExtensions are file extensions of links archived as inline media alongside images
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".webm": true, ".ogv": true, ".mov": true, ".m4v": true,
+	".mp3": true, ".m4a": true, ".ogg": true, ".oga": true, ".wav": true, ".flac": true, ".opus": true,
+	".gif": true, ".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".avif": true, ".svg": true,
+}
+
+// SaveOptions controls how [ArticleParser.SaveArticleWithOptions] stores an article
+type SaveOptions struct {
+	// Archive downloads images and media into an asset directory beside the article and
+	// points the markdown and HTML at the local copies
+	Archive bool
+	// SelfContained embeds the downloaded assets in the HTML file as data URIs, so it can be
+	// opened on its own. It has no effect without Archive.
+	SelfContained bool
+}
+
+// SavedArticle lists the files written for an article
+type SavedArticle struct {
+	MarkdownPath string
+	HTMLPath     string
+	AssetDir     string   // set when at least one asset was downloaded
+	Assets       int      // distinct asset files written
+	Failed       []string // asset URLs that could not be downloaded and still point at the site
+}
+
+// AssetDir returns the directory archived assets of the article saved at markdownPath are stored in
+func AssetDir(markdownPath string) string {
+	return strings.TrimSuffix(markdownPath, filepath.Ext(markdownPath)) + assetDirSuffix
+}
+
+// archiveAssets downloads the images and media referenced in md into assetDir, deduplicated by
+// content hash, and returns md with those references pointing at the local files. The second
+// result maps each local reference to a data URI for the asset.
+func (p *ArticleParser) archiveAssets(md, assetDir string, saved *SavedArticle) (string, map[string]string, error) {
+	local := map[string]string{}
+	dataURIs := map[string]string{}
+	written := map[string]bool{}
+
+	for _, ref := range assetURLs(md) {
+		if _, done := local[ref]; done {
+			continue
+		}
+
+		data, contentType, err := p.fetchAsset(ref)
+		if err != nil {
+			saved.Failed = append(saved.Failed, ref)
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		name := hex.EncodeToString(sum[:8]) + assetExtension(ref, contentType)
+		if !written[name] {
+			if err := os.MkdirAll(assetDir, 0755); err != nil {
+				return "", nil, fmt.Errorf("failed to create asset directory: %w", err)
+			}
+			if err := os.WriteFile(filepath.Join(assetDir, name), data, 0644); err != nil {
+				return "", nil, fmt.Errorf("failed to write asset %s: %w", name, err)
+			}
+			written[name] = true
+		}
+
+		rel := filepath.Base(assetDir) + "/" + name
+		local[ref] = rel
+		dataURIs[rel] = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+
+	saved.Assets = len(written)
+	if saved.Assets > 0 {
+		saved.AssetDir = assetDir
+	}
+	return rewriteDestinations(md, local), dataURIs, nil
+}
+
+// assetURLs returns the remote images in md and links to media files, in document order
+func assetURLs(md string) []string {
+	doc := parser.NewWithExtensions(parser.CommonExtensions).Parse([]byte(md))
+
+	var refs []string
+	ast.WalkFunc(doc, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch node := n.(type) {
+		case *ast.Image:
+			if isRemote(string(node.Destination)) {
+				refs = append(refs, string(node.Destination))
+			}
+		case *ast.Link:
+			dest := string(node.Destination)
+			if u, err := url.Parse(dest); err == nil && isRemote(dest) && mediaExtensions[strings.ToLower(path.Ext(u.Path))] {
+				refs = append(refs, dest)
+			}
+		}
+		return ast.GoToNext
+	})
+	return refs
+}
+
+func isRemote(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// fetchAsset downloads an image or media file, rejecting HTML pages and files over [maxAssetBytes]
+func (p *ArticleParser) fetchAsset(ref string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if u, err := url.Parse(ref); err == nil {
+		if rule := p.findRule(u.Hostname()); rule != nil {
+			for header, value := range rule.Headers {
+				if value != "" {
+					req.Header.Set(header, value)
+				}
+			}
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read asset: %w", err)
+	}
+	if len(data) > maxAssetBytes {
+		return nil, "", fmt.Errorf("asset is larger than %d bytes", maxAssetBytes)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if contentType == "text/html" {
+		return nil, "", fmt.Errorf("asset is an HTML page")
+	}
+	return data, contentType, nil
+}
+
+// assetExtension picks a file extension from the asset URL, or from its content type when the URL has none
+func assetExtension(ref, contentType string) string {
+	if u, err := url.Parse(ref); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); mediaExtensions[ext] {
+			return ext
+		}
+	}
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// rewriteDestinations replaces link and image destinations in markdown source
+func rewriteDestinations(md string, replacements map[string]string) string {
+	for from, to := range replacements {
+		if from == to {
+			continue
+		}
+		md = strings.ReplaceAll(md, "]("+from+")", "]("+to+")")
+		md = strings.ReplaceAll(md, "]("+from+" ", "]("+to+" ")
+		md = strings.ReplaceAll(md, "](<"+from+">", "](<"+to+">")
+	}
+	return md
+}
@@ -0,0 +1,116 @@
+package articles
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngBytes is the start of a PNG file, enough for content sniffing
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestSaveArticleWithOptions(t *testing.T) {
+	newParser := func(t *testing.T) *ArticleParser {
+		t.Helper()
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.SetHTTPClient(newMockHTTPClient(t, func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Path {
+			case "/a.png", "/copy-of-a":
+				resp := htmlResponse(http.StatusOK, string(pngBytes))
+				resp.Header.Set("Content-Type", "application/octet-stream")
+				return resp, nil
+			case "/clip.mp4":
+				resp := htmlResponse(http.StatusOK, "video data")
+				resp.Header.Set("Content-Type", "video/mp4")
+				return resp, nil
+			case "/page.html":
+				return htmlResponse(http.StatusOK, "<html></html>"), nil
+			}
+			return htmlResponse(http.StatusNotFound, ""), nil
+		}))
+		return parser
+	}
+
+	content := &ParsedContent{
+		Title: "Archived Story",
+		URL:   "https://example.com/story",
+		Content: "![A](https://example.com/a.png) and ![same](https://example.com/copy-of-a \"Copy\")\n\n" +
+			"[![Video](https://example.com/a.png)](https://example.com/clip.mp4)\n\n" +
+			"![gone](https://example.com/missing.png) and [a page](https://example.com/page.html)",
+	}
+
+	t.Run("downloads assets and rewrites references", func(t *testing.T) {
+		dir := t.TempDir()
+		saved, err := newParser(t).SaveArticleWithOptions(content, dir, SaveOptions{Archive: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if saved.AssetDir != filepath.Join(dir, "archived-story_assets") || saved.AssetDir != AssetDir(saved.MarkdownPath) {
+			t.Errorf("Expected the asset directory beside the article, got %q", saved.AssetDir)
+		}
+		if saved.Assets != 2 {
+			t.Errorf("Expected the duplicate image to be stored once next to the video, got %d assets", saved.Assets)
+		}
+		if len(saved.Failed) != 1 || saved.Failed[0] != "https://example.com/missing.png" {
+			t.Errorf("Expected the missing image to be reported, got %v", saved.Failed)
+		}
+
+		files, err := os.ReadDir(saved.AssetDir)
+		if err != nil || len(files) != 2 {
+			t.Fatalf("Expected two asset files, got %v (%v)", files, err)
+		}
+
+		md, _ := os.ReadFile(saved.MarkdownPath)
+		for _, want := range []string{"](archived-story_assets/", `.png "Copy")`, ".mp4)", "](https://example.com/missing.png)", "](https://example.com/page.html)"} {
+			if !strings.Contains(string(md), want) {
+				t.Errorf("Expected markdown to contain %q, got:\n%s", want, md)
+			}
+		}
+		if strings.Contains(string(md), "https://example.com/a.png") {
+			t.Errorf("Expected every reference to the image to be local, got:\n%s", md)
+		}
+
+		html, _ := os.ReadFile(saved.HTMLPath)
+		if !strings.Contains(string(html), `src="archived-story_assets/`) || strings.Contains(string(html), "data:") {
+			t.Errorf("Expected HTML to reference the asset files, got:\n%s", html)
+		}
+	})
+
+	t.Run("embeds assets in self-contained HTML", func(t *testing.T) {
+		saved, err := newParser(t).SaveArticleWithOptions(content, t.TempDir(), SaveOptions{Archive: true, SelfContained: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		html, _ := os.ReadFile(saved.HTMLPath)
+		for _, want := range []string{`src="data:image/png;base64,`, `href="data:video/mp4;base64,`} {
+			if !strings.Contains(string(html), want) {
+				t.Errorf("Expected HTML to contain %q, got:\n%s", want, html)
+			}
+		}
+		md, _ := os.ReadFile(saved.MarkdownPath)
+		if strings.Contains(string(md), "data:") {
+			t.Error("Expected the markdown to keep file references")
+		}
+	})
+
+	t.Run("keeps remote references without archiving", func(t *testing.T) {
+		saved, err := newParser(t).SaveArticleWithOptions(content, t.TempDir(), SaveOptions{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if saved.AssetDir != "" || saved.Assets != 0 {
+			t.Errorf("Expected no assets, got %+v", saved)
+		}
+		md, _ := os.ReadFile(saved.MarkdownPath)
+		if !strings.Contains(string(md), "](https://example.com/a.png)") {
+			t.Errorf("Expected remote references, got:\n%s", md)
+		}
+	})
+}
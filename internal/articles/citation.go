@@ -0,0 +1,408 @@
+package articles
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+// CitationFormats lists the formats accepted by [FormatCitations]
+var CitationFormats = []string{"bibtex", "csl-json", "apa"}
+
+// FormatCitations renders citations as BibTeX entries, a CSL-JSON array or APA references.
+// Cite keys are derived from the first author, year and title, with a letter appended to
+// tell apart works that would share one.
+func FormatCitations(citations []*models.Citation, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "bibtex", "bib":
+		return BibTeX(citations), nil
+	case "csl-json", "csl", "json":
+		return CSLJSON(citations)
+	case "apa":
+		var refs []string
+		for _, citation := range citations {
+			refs = append(refs, APA(citation))
+		}
+		return strings.Join(refs, "\n\n") + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown citation format %q; use one of %s", format, strings.Join(CitationFormats, ", "))
+	}
+}
+
+// ArticleCitation returns the citation of a saved article: its paper record when it has one, or
+// a web page citation built from its title, author, date and URL
+func ArticleCitation(article *models.Article) *models.Citation {
+	if article.Citation != nil {
+		return article.Citation
+	}
+
+	citation := &models.Citation{
+		ID:    strconv.FormatInt(article.ID, 10),
+		Type:  "webpage",
+		Title: article.Title,
+		URL:   article.URL,
+	}
+	if u, err := url.Parse(article.URL); err == nil {
+		citation.ContainerTitle = strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	if article.Author != "" {
+		names := strings.NewReplacer(" and ", ";", " & ", ";", ", ", ";").Replace(article.Author)
+		for name := range strings.SplitSeq(names, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				citation.Author = append(citation.Author, splitName(name))
+			}
+		}
+	}
+	if date, ok := parseArticleDate(article.Date); ok {
+		citation.Issued = models.NewCitationDate(date.Year(), int(date.Month()), date.Day())
+	}
+	if !article.Created.IsZero() {
+		citation.Accessed = models.NewCitationDate(article.Created.Year(), int(article.Created.Month()), article.Created.Day())
+	}
+	return citation
+}
+
+func parseArticleDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range slices.Concat(feedDateLayouts, []string{"January 2, 2006", "2 January 2006", "Jan 2, 2006", "2006-01", "2006"}) {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// citeKeys returns a key such as lovelace2021sparse for each citation, in order
+func citeKeys(citations []*models.Citation) []string {
+	keys := make([]string, len(citations))
+	count := map[string]int{}
+	for i, citation := range citations {
+		keys[i] = citeKey(citation)
+		count[keys[i]]++
+	}
+
+	next := map[string]int{}
+	for i, key := range keys {
+		if count[key] > 1 {
+			keys[i] = key + suffixLetters(next[key])
+			next[key]++
+		}
+	}
+	return keys
+}
+
+func suffixLetters(n int) string {
+	s := ""
+	for n >= 0 {
+		s = string(rune('a'+n%26)) + s
+		n = n/26 - 1
+	}
+	return s
+}
+
+var citeKeyStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "on": true, "of": true, "in": true, "for": true, "to": true,
+	"and": true, "with": true, "from": true, "towards": true, "toward": true, "is": true, "are": true,
+}
+
+func citeKey(citation *models.Citation) string {
+	author := "anon"
+	if len(citation.Author) > 0 {
+		name := citation.Author[0]
+		if name.Family != "" {
+			author = keyPart(name.Family)
+		} else if fields := strings.Fields(name.Literal); len(fields) > 0 {
+			author = keyPart(fields[0])
+		}
+	}
+	if author == "" {
+		author = "anon"
+	}
+
+	year := "nd"
+	if y := citation.Issued.Year(); y != 0 {
+		year = strconv.Itoa(y)
+	}
+
+	word := ""
+	for field := range strings.FieldsSeq(citation.Title) {
+		if part := keyPart(field); part != "" && !citeKeyStopWords[part] {
+			word = part
+			break
+		}
+	}
+	return author + year + word
+}
+
+var keyFolds = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae", "ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ß", "ss",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ł", "l", "š", "s", "ž", "z", "č", "c",
+)
+
+// keyPart lowercases s and keeps its ASCII letters and digits, folding common accented letters
+func keyPart(s string) string {
+	var b strings.Builder
+	for _, r := range keyFolds.Replace(strings.ToLower(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// BibTeX renders citations as BibTeX entries. arXiv preprints become @misc entries with their
+// eprint, and web pages @misc entries with their URL and access date.
+func BibTeX(citations []*models.Citation) string {
+	keys := citeKeys(citations)
+	var b strings.Builder
+	for i, citation := range citations {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeBibTeXEntry(&b, keys[i], citation)
+	}
+	return b.String()
+}
+
+var bibtexMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+func writeBibTeXEntry(b *strings.Builder, key string, citation *models.Citation) {
+	type field struct{ name, value string }
+	var fields []field
+	add := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			fields = append(fields, field{name, value})
+		}
+	}
+
+	entry := "misc"
+	container := ""
+	switch citation.Type {
+	case "article-journal":
+		entry, container = "article", "journal"
+	case "paper-conference":
+		entry, container = "inproceedings", "booktitle"
+	case "chapter":
+		entry, container = "incollection", "booktitle"
+	case "book":
+		entry = "book"
+	case "thesis":
+		entry = "phdthesis"
+	case "report":
+		entry = "techreport"
+	}
+
+	var authors []string
+	for _, name := range citation.Author {
+		switch {
+		case name.Literal != "":
+			authors = append(authors, "{"+escapeBibTeX(name.Literal)+"}")
+		case name.Given != "":
+			authors = append(authors, escapeBibTeX(name.Family+", "+name.Given))
+		default:
+			authors = append(authors, escapeBibTeX(name.Family))
+		}
+	}
+	add("author", strings.Join(authors, " and "))
+	if citation.Title != "" {
+		add("title", "{"+escapeBibTeX(citation.Title)+"}")
+	}
+
+	if container != "" {
+		add(container, escapeBibTeX(citation.ContainerTitle))
+	}
+	if year := citation.Issued.Year(); year != 0 {
+		add("year", strconv.Itoa(year))
+	}
+	if month := citation.Issued.Month(); month >= 1 && month <= 12 {
+		add("month", bibtexMonths[month-1])
+	}
+	add("volume", escapeBibTeX(citation.Volume))
+	add("number", escapeBibTeX(citation.Issue))
+	add("pages", strings.ReplaceAll(strings.ReplaceAll(citation.Page, "--", "-"), "-", "--"))
+
+	switch entry {
+	case "phdthesis":
+		add("school", escapeBibTeX(citation.Publisher))
+	case "techreport":
+		add("institution", escapeBibTeX(citation.Publisher))
+	case "misc":
+		if eprint, ok := strings.CutPrefix(citation.Number, "arXiv:"); ok {
+			add("eprint", eprint)
+			add("archivePrefix", "arXiv")
+		} else if citation.Type == "webpage" {
+			add("howpublished", escapeBibTeX(citation.ContainerTitle))
+		} else {
+			add("publisher", escapeBibTeX(citation.Publisher))
+		}
+	default:
+		add("publisher", escapeBibTeX(citation.Publisher))
+	}
+
+	add("doi", citation.DOI)
+	add("url", citation.URL)
+	if citation.Type == "webpage" {
+		add("urldate", citation.Accessed.String())
+	}
+
+	fmt.Fprintf(b, "@%s{%s,\n", entry, key)
+	for i, f := range fields {
+		sep := ","
+		if i == len(fields)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(b, "  %s = {%s}%s\n", f.name, f.value, sep)
+	}
+	b.WriteString("}\n")
+}
+
+var bibtexEscapes = strings.NewReplacer(`&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`)
+
+func escapeBibTeX(s string) string {
+	return bibtexEscapes.Replace(collapseSpace(s))
+}
+
+// CSLJSON renders citations as an indented CSL-JSON array, using the BibTeX cite keys as ids so
+// both exports cite a work the same way
+func CSLJSON(citations []*models.Citation) (string, error) {
+	keys := citeKeys(citations)
+	items := make([]models.Citation, len(citations))
+	for i, citation := range citations {
+		items[i] = *citation
+		items[i].ID = keys[i]
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode CSL-JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// APA renders a citation as an APA 7th edition reference, with markdown italics
+func APA(citation *models.Citation) string {
+	var parts []string
+
+	date := "n.d."
+	if year := citation.Issued.Year(); year != 0 {
+		date = strconv.Itoa(year)
+		if citation.Type == "webpage" && citation.Issued.Month() != 0 {
+			date += ", " + time.Month(citation.Issued.Month()).String()
+			if day := citation.Issued.Day(); day != 0 {
+				date += " " + strconv.Itoa(day)
+			}
+		}
+	}
+
+	title := strings.TrimSuffix(collapseSpace(citation.Title), ".")
+	standalone := citation.ContainerTitle == "" || citation.Type == "book" || citation.Type == "report" ||
+		citation.Type == "thesis" || citation.Type == "document" || citation.Type == "webpage"
+	if standalone {
+		title = "*" + title + "*"
+	}
+
+	if authors := apaAuthors(citation.Author); authors != "" {
+		parts = append(parts, authors, "("+date+").", title+".")
+	} else {
+		parts = append(parts, title+".", "("+date+").")
+	}
+
+	switch {
+	case citation.Type == "article-journal" && citation.ContainerTitle != "":
+		source := "*" + citation.ContainerTitle
+		if citation.Volume != "" {
+			source += ", " + citation.Volume + "*"
+			if citation.Issue != "" {
+				source += "(" + citation.Issue + ")"
+			}
+		} else {
+			source += "*"
+		}
+		if citation.Page != "" {
+			source += ", " + apaPages(citation.Page)
+		}
+		parts = append(parts, source+".")
+	case (citation.Type == "paper-conference" || citation.Type == "chapter") && citation.ContainerTitle != "":
+		source := "In *" + citation.ContainerTitle + "*"
+		if citation.Page != "" {
+			source += " (pp. " + apaPages(citation.Page) + ")"
+		}
+		parts = append(parts, source+".")
+		if citation.Publisher != "" {
+			parts = append(parts, citation.Publisher+".")
+		}
+	case citation.ContainerTitle != "" && !standalone:
+		parts = append(parts, "*"+citation.ContainerTitle+"*.")
+	case citation.Type == "webpage" && citation.ContainerTitle != "":
+		parts = append(parts, citation.ContainerTitle+".")
+	case citation.Publisher != "":
+		parts = append(parts, citation.Publisher+".")
+	}
+
+	switch {
+	case citation.DOI != "":
+		parts = append(parts, "https://doi.org/"+citation.DOI)
+	case citation.URL != "":
+		parts = append(parts, citation.URL)
+	}
+	return strings.Join(parts, " ")
+}
+
+// apaPages writes a page range with an en dash
+func apaPages(pages string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(pages, "--", "-"), "-", "–")
+}
+
+// apaAuthors lists authors as "Family, G. M." joined with commas and an ampersand, giving the
+// first nineteen and the last of longer lists
+func apaAuthors(names []models.CitationName) string {
+	var authors []string
+	for _, name := range names {
+		if name.Literal != "" || name.Given == "" {
+			authors = append(authors, name.String())
+			continue
+		}
+		authors = append(authors, name.Family+", "+initials(name.Given))
+	}
+
+	switch n := len(authors); {
+	case n == 0:
+		return ""
+	case n == 1:
+		return authors[0]
+	case n == 2:
+		return authors[0] + ", & " + authors[1]
+	case n > 20:
+		return strings.Join(authors[:19], ", ") + ", . . . " + authors[n-1]
+	default:
+		return strings.Join(authors[:n-1], ", ") + ", & " + authors[n-1]
+	}
+}
+
+// initials abbreviates given names: "Jean-Paul Ada" becomes "J.-P. A."
+func initials(given string) string {
+	var words []string
+	for word := range strings.FieldsSeq(given) {
+		var parts []string
+		for part := range strings.SplitSeq(word, "-") {
+			if r := []rune(strings.TrimSuffix(part, ".")); len(r) > 0 {
+				parts = append(parts, string(r[0])+".")
+			}
+		}
+		if len(parts) > 0 {
+			words = append(words, strings.Join(parts, "-"))
+		}
+	}
+	return strings.Join(words, " ")
+}
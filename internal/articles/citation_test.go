@@ -0,0 +1,118 @@
+package articles
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func TestFormatCitations(t *testing.T) {
+	journal := &models.Citation{
+		Type:           "article-journal",
+		Title:          "Sparse Retrieval for Small Archives",
+		Author:         []models.CitationName{{Family: "Lovelace", Given: "Ada"}, {Family: "Turing", Given: "Alan Mathison"}},
+		ContainerTitle: "Journal of Personal Archives",
+		Publisher:      "Example Press",
+		Issued:         models.NewCitationDate(2021, 3, 0),
+		Volume:         "12",
+		Issue:          "3",
+		Page:           "45-67",
+		DOI:            "10.5555/fixture.2021.42",
+	}
+	preprint := &models.Citation{
+		Type:           "article",
+		Title:          "Sparse Retrieval & 100% Recall",
+		Author:         []models.CitationName{{Family: "Lovelace", Given: "Ada"}},
+		ContainerTitle: "arXiv",
+		Publisher:      "arXiv",
+		Number:         "arXiv:2009.03017",
+		Issued:         models.NewCitationDate(2021, 9, 7),
+		DOI:            "10.48550/arXiv.2009.03017",
+	}
+	web := ArticleCitation(&models.Article{
+		ID:      7,
+		URL:     "https://www.example.com/posts/wal",
+		Title:   "Why Write-Ahead Logs",
+		Author:  "Jean-Paul Sartre and Simone de Beauvoir",
+		Date:    "2024-05-01",
+		Created: time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC),
+	})
+
+	t.Run("web articles are cited as web pages", func(t *testing.T) {
+		if web.Type != "webpage" || web.ContainerTitle != "example.com" || web.Issued.String() != "2024-05-01" || web.Accessed.String() != "2024-06-02" {
+			t.Errorf("unexpected web citation: %+v", web)
+		}
+		if len(web.Author) != 2 || web.Author[1].Family != "de Beauvoir" {
+			t.Errorf("unexpected authors: %+v", web.Author)
+		}
+		paper := &models.Article{Citation: journal}
+		if ArticleCitation(paper) != journal {
+			t.Error("expected a paper's own citation")
+		}
+	})
+
+	t.Run("bibtex", func(t *testing.T) {
+		output, err := FormatCitations([]*models.Citation{journal, preprint, web}, "bibtex")
+		if err != nil {
+			t.Fatalf("FormatCitations failed: %v", err)
+		}
+		for _, want := range []string{
+			"@article{lovelace2021sparsea,\n  author = {Lovelace, Ada and Turing, Alan Mathison},\n  title = {{Sparse Retrieval for Small Archives}},\n  journal = {Journal of Personal Archives},\n  year = {2021},\n  month = {mar},\n  volume = {12},\n  number = {3},\n  pages = {45--67},\n  publisher = {Example Press},\n  doi = {10.5555/fixture.2021.42}\n}\n",
+			"@misc{lovelace2021sparseb,",
+			"title = {{Sparse Retrieval \\& 100\\% Recall}},",
+			"eprint = {2009.03017},\n  archivePrefix = {arXiv},",
+			"@misc{sartre2024why,",
+			"howpublished = {example.com},",
+			"url = {https://www.example.com/posts/wal},\n  urldate = {2024-06-02}\n}",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected BibTeX to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("csl-json uses the cite keys as ids", func(t *testing.T) {
+		output, err := FormatCitations([]*models.Citation{journal, web}, "csl-json")
+		if err != nil {
+			t.Fatalf("FormatCitations failed: %v", err)
+		}
+		var items []models.Citation
+		if err := json.Unmarshal([]byte(output), &items); err != nil {
+			t.Fatalf("invalid CSL-JSON: %v\n%s", err, output)
+		}
+		if len(items) != 2 || items[0].ID != "lovelace2021sparse" || items[1].ID != "sartre2024why" {
+			t.Errorf("unexpected items: %+v", items)
+		}
+		if !strings.Contains(output, `"container-title": "Journal of Personal Archives"`) || !strings.Contains(output, `"date-parts": [`) {
+			t.Errorf("expected CSL field names, got:\n%s", output)
+		}
+		if journal.ID != "" {
+			t.Error("expected the citation itself to be left unchanged")
+		}
+	})
+
+	t.Run("apa", func(t *testing.T) {
+		for _, tc := range []struct {
+			citation *models.Citation
+			want     string
+		}{
+			{journal, "Lovelace, A., & Turing, A. M. (2021). Sparse Retrieval for Small Archives. *Journal of Personal Archives, 12*(3), 45–67. https://doi.org/10.5555/fixture.2021.42"},
+			{preprint, "Lovelace, A. (2021). Sparse Retrieval & 100% Recall. *arXiv*. https://doi.org/10.48550/arXiv.2009.03017"},
+			{web, "Sartre, J.-P., & de Beauvoir, S. (2024, May 1). *Why Write-Ahead Logs*. example.com. https://www.example.com/posts/wal"},
+			{&models.Citation{Type: "document", Title: "Untitled notes"}, "*Untitled notes*. (n.d.)."},
+		} {
+			if got := APA(tc.citation); got != tc.want {
+				t.Errorf("APA mismatch:\n got: %s\nwant: %s", got, tc.want)
+			}
+		}
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		if _, err := FormatCitations([]*models.Citation{journal}, "mla"); err == nil {
+			t.Error("expected an error for an unknown format")
+		}
+	})
+}
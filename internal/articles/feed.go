@@ -0,0 +1,415 @@
+package articles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html/charset"
+)
+
+// maxFeedBytes caps the size of a downloaded feed document
+const maxFeedBytes = 10 << 20
+
+const feedAcceptHeader = "application/feed+json, application/atom+xml, application/rss+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5"
+
+// Feed is a parsed RSS, Atom or JSON Feed document
+type Feed struct {
+	Title   string
+	SiteURL string
+	Entries []FeedEntry
+}
+
+// FeedEntry is one item of a feed
+type FeedEntry struct {
+	GUID      string // the entry's id or guid, or its URL when it has neither
+	URL       string
+	Title     string
+	Summary   string    // plain text of the summary or content, for keyword filters
+	Published time.Time // zero when the feed gives no date
+}
+
+// FeedResponse is the result of a conditional feed fetch
+type FeedResponse struct {
+	Feed         *Feed // nil when NotModified
+	ETag         string
+	LastModified string
+	NotModified  bool // the server answered 304 to the validators
+}
+
+// FetchFeed downloads and parses the feed at feedURL. When etag or lastModified are set they are
+// sent as If-None-Match and If-Modified-Since, and an unchanged feed comes back with NotModified.
+func (p *ArticleParser) FetchFeed(feedURL, etag, lastModified string) (*FeedResponse, error) {
+	if p.client == nil {
+		return nil, fmt.Errorf("no HTTP client configured")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", feedAcceptHeader)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FeedResponse{ETag: etag, LastModified: lastModified, NotModified: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if len(data) > maxFeedBytes {
+		return nil, fmt.Errorf("feed is larger than %d bytes", maxFeedBytes)
+	}
+
+	finalURL := feedURL
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL.String()
+	}
+	feed, err := ParseFeed(data, finalURL)
+	if err != nil {
+		return nil, err
+	}
+	return &FeedResponse{
+		Feed:         feed,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// ParseFeed parses an RSS 2.0, RSS 1.0 (RDF), Atom or JSON Feed document. Relative links are
+// resolved against feedURL.
+func ParseFeed(data []byte, feedURL string) (*Feed, error) {
+	base, _ := url.Parse(feedURL)
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONFeed(trimmed, base)
+	}
+
+	root, err := feedRoot(trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss", "RDF":
+		var doc rssDocument
+		if err := newFeedDecoder(trimmed).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+		}
+		return doc.feed(base), nil
+	case "feed":
+		var doc atomFeed
+		if err := newFeedDecoder(trimmed).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse Atom feed: %w", err)
+		}
+		return doc.feed(base), nil
+	default:
+		return nil, fmt.Errorf("not a feed: unexpected <%s> document", root)
+	}
+}
+
+func newFeedDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	return decoder
+}
+
+// feedRoot returns the local name of the document element
+func feedRoot(data []byte) (string, error) {
+	decoder := newFeedDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("not a feed: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+type xmlLink struct {
+	XMLName xml.Name
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []xmlLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	About       string    `xml:"about,attr"`
+	Description string    `xml:"description"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string    `xml:"pubDate"`
+	Date        string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Links []xmlLink `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"` // RSS 1.0 puts items beside the channel
+}
+
+// rssLink returns the text of the first plain <link>, skipping atom:link elements
+func rssLink(links []xmlLink) string {
+	for _, link := range links {
+		if value := strings.TrimSpace(link.Value); value != "" && link.Href == "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (d *rssDocument) feed(base *url.URL) *Feed {
+	feed := &Feed{
+		Title:   collapseSpace(d.Channel.Title),
+		SiteURL: resolveFeedURL(base, rssLink(d.Channel.Links)),
+	}
+
+	for _, item := range append(d.Channel.Items, d.Items...) {
+		link := resolveFeedURL(base, rssLink(item.Links))
+		guid := strings.TrimSpace(item.GUID)
+		if guid == "" {
+			guid = strings.TrimSpace(item.About)
+		}
+		if link == "" && strings.HasPrefix(guid, "http") {
+			link = guid
+		}
+
+		summary := item.Description
+		if summary == "" {
+			summary = item.Content
+		}
+		published := item.PubDate
+		if published == "" {
+			published = item.Date
+		}
+
+		feed.addEntry(FeedEntry{
+			GUID:      guid,
+			URL:       link,
+			Title:     collapseSpace(item.Title),
+			Summary:   plainText(summary),
+			Published: parseFeedDate(published),
+		})
+	}
+	return feed
+}
+
+type atomEntry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Links     []xmlLink `xml:"link"`
+	Summary   string    `xml:"summary"`
+	Content   string    `xml:"content"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+}
+
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Links   []xmlLink   `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink returns the href of the alternate link, which is the default relation
+func atomLink(links []xmlLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+func (d *atomFeed) feed(base *url.URL) *Feed {
+	feed := &Feed{
+		Title:   collapseSpace(d.Title),
+		SiteURL: resolveFeedURL(base, atomLink(d.Links)),
+	}
+
+	for _, entry := range d.Entries {
+		summary := entry.Summary
+		if summary == "" {
+			summary = entry.Content
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+
+		feed.addEntry(FeedEntry{
+			GUID:      strings.TrimSpace(entry.ID),
+			URL:       resolveFeedURL(base, atomLink(entry.Links)),
+			Title:     collapseSpace(entry.Title),
+			Summary:   plainText(summary),
+			Published: parseFeedDate(published),
+		})
+	}
+	return feed
+}
+
+type jsonFeed struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	Items       []struct {
+		ID            any    `json:"id"`
+		URL           string `json:"url"`
+		ExternalURL   string `json:"external_url"`
+		Title         string `json:"title"`
+		Summary       string `json:"summary"`
+		ContentText   string `json:"content_text"`
+		ContentHTML   string `json:"content_html"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+func parseJSONFeed(data []byte, base *url.URL) (*Feed, error) {
+	var doc jsonFeed
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON feed: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("not a feed: JSON document without a jsonfeed.org version")
+	}
+
+	feed := &Feed{
+		Title:   collapseSpace(doc.Title),
+		SiteURL: resolveFeedURL(base, doc.HomePageURL),
+	}
+
+	for _, item := range doc.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+		var guid string
+		if item.ID != nil {
+			guid = strings.TrimSpace(fmt.Sprint(item.ID))
+		}
+
+		summary := item.Summary
+		for _, content := range []string{item.ContentText, item.ContentHTML} {
+			if summary == "" {
+				summary = content
+			}
+		}
+		published := item.DatePublished
+		if published == "" {
+			published = item.DateModified
+		}
+
+		feed.addEntry(FeedEntry{
+			GUID:      guid,
+			URL:       resolveFeedURL(base, link),
+			Title:     collapseSpace(item.Title),
+			Summary:   plainText(summary),
+			Published: parseFeedDate(published),
+		})
+	}
+	return feed, nil
+}
+
+// addEntry appends entry when it links somewhere, using the URL as the GUID when it has none
+func (f *Feed) addEntry(entry FeedEntry) {
+	if entry.URL == "" {
+		return
+	}
+	if entry.GUID == "" {
+		entry.GUID = entry.URL
+	}
+	if entry.Title == "" {
+		entry.Title = entry.URL
+	}
+	f.Entries = append(f.Entries, entry)
+}
+
+func resolveFeedURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// plainText returns the text of an HTML fragment with whitespace collapsed
+func plainText(fragment string) string {
+	if !strings.Contains(fragment, "<") {
+		return collapseSpace(fragment)
+	}
+	doc, err := htmlquery.Parse(strings.NewReader(fragment))
+	if err != nil {
+		return collapseSpace(fragment)
+	}
+	return collapseSpace(htmlquery.InnerText(doc))
+}
+
+// collapseSpace trims value and joins runs of whitespace into single spaces
+func collapseSpace(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedDate parses the date formats RSS, Atom and JSON feeds use in practice, returning the
+// zero time for anything else
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
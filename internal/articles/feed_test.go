@@ -0,0 +1,216 @@
+package articles
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+const rssFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+	<channel>
+		<title>Example  Blog</title>
+		<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+		<link>https://example.com/</link>
+		<item>
+			<title>First Post</title>
+			<link>/posts/first</link>
+			<guid isPermaLink="false">post-1</guid>
+			<description>&lt;p&gt;An &lt;b&gt;introduction&lt;/b&gt; to feeds.&lt;/p&gt;</description>
+			<pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate>
+		</item>
+		<item>
+			<title>Second Post</title>
+			<link>https://example.com/posts/second</link>
+			<content:encoded><![CDATA[<p>Only full content here.</p>]]></content:encoded>
+		</item>
+		<item>
+			<title>No Link</title>
+			<guid isPermaLink="false">post-3</guid>
+		</item>
+	</channel>
+</rss>`
+
+const rdfFixture = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="https://example.org/">
+		<title>RDF Site</title>
+		<link>https://example.org/</link>
+	</channel>
+	<item rdf:about="https://example.org/one">
+		<title>One</title>
+		<link>https://example.org/one</link>
+		<dc:date>2024-03-01T10:00:00Z</dc:date>
+	</item>
+</rdf:RDF>`
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Atom Journal</title>
+	<link href="https://atom.example/" />
+	<link rel="self" href="https://atom.example/atom.xml" />
+	<entry>
+		<id>tag:atom.example,2024:1</id>
+		<title>Atom Entry</title>
+		<link rel="self" href="https://atom.example/entries/1.xml" />
+		<link rel="alternate" href="entries/1" />
+		<summary type="html">&lt;p&gt;Summary text&lt;/p&gt;</summary>
+		<updated>2024-05-01T12:00:00Z</updated>
+	</entry>
+</feed>`
+
+const jsonFeedFixture = `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "JSON Notes",
+	"home_page_url": "https://json.example/",
+	"items": [
+		{"id": 42, "url": "https://json.example/notes/42", "title": "Numbered", "content_html": "<p>Hello <em>there</em></p>", "date_published": "2024-06-01T08:00:00+02:00"},
+		{"id": "b", "external_url": "https://elsewhere.example/b", "content_text": "Plain"}
+	]
+}`
+
+func TestParseFeed(t *testing.T) {
+	t.Run("parses RSS 2.0", func(t *testing.T) {
+		feed, err := ParseFeed([]byte(rssFixture), "https://example.com/feed.xml")
+		if err != nil {
+			t.Fatalf("ParseFeed failed: %v", err)
+		}
+		if feed.Title != "Example Blog" || feed.SiteURL != "https://example.com/" {
+			t.Errorf("unexpected feed metadata: %+v", feed)
+		}
+		if len(feed.Entries) != 2 {
+			t.Fatalf("expected entries without links to be skipped, got %d entries", len(feed.Entries))
+		}
+
+		first := feed.Entries[0]
+		if first.GUID != "post-1" || first.URL != "https://example.com/posts/first" {
+			t.Errorf("unexpected first entry: %+v", first)
+		}
+		if first.Summary != "An introduction to feeds." {
+			t.Errorf("expected a plain text summary, got %q", first.Summary)
+		}
+		if want := time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC); !first.Published.Equal(want) {
+			t.Errorf("expected published %v, got %v", want, first.Published)
+		}
+
+		second := feed.Entries[1]
+		if second.GUID != second.URL {
+			t.Errorf("expected the URL as GUID, got %q", second.GUID)
+		}
+		if second.Summary != "Only full content here." {
+			t.Errorf("expected content:encoded as summary, got %q", second.Summary)
+		}
+		if !second.Published.IsZero() {
+			t.Errorf("expected no published date, got %v", second.Published)
+		}
+	})
+
+	t.Run("parses RSS 1.0", func(t *testing.T) {
+		feed, err := ParseFeed([]byte(rdfFixture), "https://example.org/index.rdf")
+		if err != nil {
+			t.Fatalf("ParseFeed failed: %v", err)
+		}
+		if feed.Title != "RDF Site" || len(feed.Entries) != 1 {
+			t.Fatalf("unexpected feed: %+v", feed)
+		}
+		entry := feed.Entries[0]
+		if entry.GUID != "https://example.org/one" || entry.Published.IsZero() {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("parses Atom", func(t *testing.T) {
+		feed, err := ParseFeed([]byte(atomFixture), "https://atom.example/atom.xml")
+		if err != nil {
+			t.Fatalf("ParseFeed failed: %v", err)
+		}
+		if feed.Title != "Atom Journal" || feed.SiteURL != "https://atom.example/" {
+			t.Errorf("unexpected feed metadata: %+v", feed)
+		}
+		if len(feed.Entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(feed.Entries))
+		}
+		entry := feed.Entries[0]
+		if entry.URL != "https://atom.example/entries/1" {
+			t.Errorf("expected the resolved alternate link, got %q", entry.URL)
+		}
+		if entry.GUID != "tag:atom.example,2024:1" || entry.Summary != "Summary text" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+		if entry.Published.IsZero() {
+			t.Error("expected updated to be used as the date")
+		}
+	})
+
+	t.Run("parses JSON Feed", func(t *testing.T) {
+		feed, err := ParseFeed([]byte(jsonFeedFixture), "https://json.example/feed.json")
+		if err != nil {
+			t.Fatalf("ParseFeed failed: %v", err)
+		}
+		if feed.Title != "JSON Notes" || len(feed.Entries) != 2 {
+			t.Fatalf("unexpected feed: %+v", feed)
+		}
+		if entry := feed.Entries[0]; entry.GUID != "42" || entry.Summary != "Hello there" || entry.Published.IsZero() {
+			t.Errorf("unexpected first entry: %+v", entry)
+		}
+		if entry := feed.Entries[1]; entry.URL != "https://elsewhere.example/b" || entry.Title != entry.URL {
+			t.Errorf("expected external_url and a URL title, got %+v", entry)
+		}
+	})
+
+	t.Run("rejects documents that are not feeds", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"html":  "<html><body>Not a feed</body></html>",
+			"json":  `{"title": "Not a feed"}`,
+			"empty": "",
+		} {
+			if _, err := ParseFeed([]byte(data), "https://example.com/"); err == nil {
+				t.Errorf("expected an error for %s", name)
+			}
+		}
+	})
+}
+
+func TestFetchFeed(t *testing.T) {
+	parser, err := NewArticleParser(nil)
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	parser.SetHTTPClient(newMockHTTPClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return htmlResponse(http.StatusNotModified, ""), nil
+		}
+		if req.URL.Path == "/missing.xml" {
+			return htmlResponse(http.StatusNotFound, ""), nil
+		}
+		resp := htmlResponse(http.StatusOK, rssFixture)
+		resp.Header.Set("Content-Type", "application/rss+xml")
+		resp.Header.Set("ETag", `"v1"`)
+		resp.Header.Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		return resp, nil
+	}))
+
+	resp, err := parser.FetchFeed("https://example.com/feed.xml", "", "")
+	if err != nil {
+		t.Fatalf("FetchFeed failed: %v", err)
+	}
+	if resp.NotModified || resp.Feed == nil || len(resp.Feed.Entries) != 2 {
+		t.Fatalf("expected a parsed feed, got %+v", resp)
+	}
+	if resp.ETag != `"v1"` || resp.LastModified == "" {
+		t.Errorf("expected validators from the response, got %q and %q", resp.ETag, resp.LastModified)
+	}
+
+	resp, err = parser.FetchFeed("https://example.com/feed.xml", resp.ETag, resp.LastModified)
+	if err != nil {
+		t.Fatalf("conditional FetchFeed failed: %v", err)
+	}
+	if !resp.NotModified || resp.Feed != nil || resp.ETag != `"v1"` {
+		t.Errorf("expected a not modified response keeping the validators, got %+v", resp)
+	}
+
+	if _, err := parser.FetchFeed("https://example.com/missing.xml", "", ""); err == nil {
+		t.Error("expected an error for a missing feed")
+	}
+}
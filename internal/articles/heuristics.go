@@ -16,7 +16,8 @@ type ExtractionResult struct {
 	SiteName         string
 	Language         string
 	Confidence       float64
-	ExtractionMethod string // "heuristic" or "xpath" or "dual"
+	ExtractionMethod string     // "heuristic" or "xpath" or "dual"
+	Node             *html.Node // element the content was taken from, for converting its structure
 }
 
 // HeuristicExtractor implements Readability-style content extraction.
@@ -59,6 +60,7 @@ func (e *HeuristicExtractor) ExtractContent(doc *html.Node) *ExtractionResult {
 		Content:          content,
 		Confidence:       topCandidate.ConfidenceLevel,
 		ExtractionMethod: "heuristic",
+		Node:             topCandidate.Node,
 	}
 
 	return result
@@ -369,6 +371,7 @@ func (e *HeuristicExtractor) CompareWithXPath(doc *html.Node, xpathNode *html.No
 			Content:          xpathContent,
 			Confidence:       0.85,
 			ExtractionMethod: "xpath-preferred",
+			Node:             xpathNode,
 		}
 	} else if float64(heuristicLen) > float64(xpathLen)*1.5 {
 		heuristicResult.Confidence = 0.80
@@ -438,6 +441,7 @@ func (e *HeuristicExtractor) ExtractWithSemanticHTML(doc *html.Node) *Extraction
 				Content:          content,
 				Confidence:       0.90,
 				ExtractionMethod: "semantic-html",
+				Node:             articleNode,
 			}
 		}
 	}
@@ -450,6 +454,7 @@ func (e *HeuristicExtractor) ExtractWithSemanticHTML(doc *html.Node) *Extraction
 				Content:          content,
 				Confidence:       0.88,
 				ExtractionMethod: "semantic-html",
+				Node:             mainNode,
 			}
 		}
 	}
@@ -0,0 +1,145 @@
+package articles
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxQuoteErrorRate is the share of a quote's letters that may differ from the article text
+const maxQuoteErrorRate = 5
+
+// foldedText is text reduced to lowercase letters and digits with every other run of characters
+// collapsed to one space, remembering where each rune came from in the original.
+type foldedText struct {
+	runes []rune
+	start []int // byte offset where each rune begins in the original
+	end   []int // byte offset just after each rune in the original
+}
+
+// foldText folds markdown for matching. Link and image targets are skipped so that a quote copied
+// from the rendered article still lines up with the stored markdown.
+func foldText(s string) foldedText {
+	var f foldedText
+	space := true
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "](") {
+			if j := strings.IndexAny(s[i+2:], ")\n"); j >= 0 && s[i+2+j] == ')' {
+				i += j + 3
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			f.runes = append(f.runes, unicode.ToLower(r))
+			f.start = append(f.start, i)
+			f.end = append(f.end, i+size)
+			space = false
+		case !space:
+			f.runes = append(f.runes, ' ')
+			f.start = append(f.start, i)
+			f.end = append(f.end, i+size)
+			space = true
+		}
+		i += size
+	}
+
+	if n := len(f.runes); n > 0 && f.runes[n-1] == ' ' {
+		f.runes, f.start, f.end = f.runes[:n-1], f.start[:n-1], f.end[:n-1]
+	}
+	return f
+}
+
+// FindQuote locates a quote in an article's markdown and returns the byte offsets of the matching
+// text. Case, punctuation, whitespace and markdown syntax are ignored, and a few differing letters
+// (about one in five) are tolerated so a quote typed from memory or with a typo still matches.
+// The first exact match wins; otherwise the closest one does.
+func FindQuote(markdown, quote string) (start, end int, ok bool) {
+	pattern := foldText(quote).runes
+	if len(pattern) == 0 {
+		return 0, 0, false
+	}
+	text := foldText(markdown)
+
+	from, to := -1, -1
+	for i := 0; i+len(pattern) <= len(text.runes); i++ {
+		if slices.Equal(text.runes[i:i+len(pattern)], pattern) {
+			from, to = i, i+len(pattern)
+			break
+		}
+	}
+	if from < 0 {
+		if from, to = closestMatch(text.runes, pattern, len(pattern)/maxQuoteErrorRate); from < 0 {
+			return 0, 0, false
+		}
+	}
+
+	for from < to && text.runes[from] == ' ' {
+		from++
+	}
+	for to > from && text.runes[to-1] == ' ' {
+		to--
+	}
+	if from == to {
+		return 0, 0, false
+	}
+	start, end = widenToMarkup(markdown, text.start[from], text.end[to-1])
+	return start, end, true
+}
+
+// widenToMarkup grows a match to take in emphasis and link syntax at its edges, so the quoted
+// markdown stays well formed
+func widenToMarkup(markdown string, start, end int) (int, int) {
+	for start > 0 && strings.IndexByte("*_`[", markdown[start-1]) >= 0 {
+		start--
+	}
+	for end < len(markdown) && strings.IndexByte("*_`", markdown[end]) >= 0 {
+		end++
+	}
+	if strings.HasPrefix(markdown[end:], "](") {
+		if j := strings.IndexAny(markdown[end+2:], ")\n"); j >= 0 && markdown[end+2+j] == ')' {
+			end += j + 3
+		}
+	}
+	return start, end
+}
+
+// closestMatch finds the substring of text with the smallest edit distance to pattern, allowing at
+// most maxErrors edits. It returns the rune range of the match, or -1 when nothing is close enough.
+func closestMatch(text, pattern []rune, maxErrors int) (from, to int) {
+	// cost[i] is the edit distance between pattern[:i] and the best substring of text ending at the
+	// current position; origin[i] is where that substring starts.
+	cost := make([]int, len(pattern)+1)
+	origin := make([]int, len(pattern)+1)
+	for i := range cost {
+		cost[i] = i
+	}
+
+	best, from, to := maxErrors+1, -1, -1
+	for j := 1; j <= len(text); j++ {
+		diag, diagOrigin := cost[0], origin[0]
+		origin[0] = j
+		for i := 1; i <= len(pattern); i++ {
+			next, nextOrigin := diag, diagOrigin
+			if pattern[i-1] != text[j-1] {
+				next++
+			}
+			if cost[i-1]+1 < next {
+				next, nextOrigin = cost[i-1]+1, origin[i-1]
+			}
+			if cost[i]+1 < next {
+				next, nextOrigin = cost[i]+1, origin[i]
+			}
+			diag, diagOrigin = cost[i], origin[i]
+			cost[i], origin[i] = next, nextOrigin
+		}
+
+		if cost[len(pattern)] < best {
+			best, from, to = cost[len(pattern)], origin[len(pattern)], j
+		}
+	}
+	return from, to
+}
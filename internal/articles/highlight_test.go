@@ -0,0 +1,61 @@
+package articles
+
+import "testing"
+
+func TestFindQuote(t *testing.T) {
+	markdown := `# Notes on Storage
+
+SQLite is a **small, fast**, self-contained engine. It is the [most used](https://sqlite.org/mostdeployed.html) database
+engine in the world.
+
+Write-ahead logging lets readers continue while a writer commits.
+`
+
+	tests := []struct {
+		name  string
+		quote string
+		want  string
+		found bool
+	}{
+		{name: "exact", quote: "self-contained engine", want: "self-contained engine", found: true},
+		{name: "case and punctuation", quote: "sqlite is a small fast self contained", want: "SQLite is a **small, fast**, self-contained", found: true},
+		{name: "across links and lines", quote: "It is the most used database engine in the world.", want: "It is the [most used](https://sqlite.org/mostdeployed.html) database\nengine in the world", found: true},
+		{name: "keeps emphasis and links whole", quote: "small fast", want: "**small, fast**", found: true},
+		{name: "ends inside a link", quote: "It is the most used", want: "It is the [most used](https://sqlite.org/mostdeployed.html)", found: true},
+		{name: "typo", quote: "write ahead loging lets readers continue", want: "Write-ahead logging lets readers continue", found: true},
+		{name: "missing word", quote: "readers continue while writer commits", want: "readers continue while a writer commits", found: true},
+		{name: "unrelated", quote: "PostgreSQL uses multiversion concurrency control", found: false},
+		{name: "empty", quote: " ... ", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := FindQuote(markdown, tt.quote)
+			if ok != tt.found {
+				t.Fatalf("expected found=%v, got %v", tt.found, ok)
+			}
+			if ok && markdown[start:end] != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, markdown[start:end])
+			}
+		})
+	}
+
+	t.Run("first exact match wins", func(t *testing.T) {
+		start, _, ok := FindQuote("one two. one two.", "one two")
+		if !ok || start != 0 {
+			t.Errorf("expected the first occurrence, got start=%d ok=%v", start, ok)
+		}
+	})
+
+	t.Run("keeps multibyte offsets", func(t *testing.T) {
+		text := "Café culture — naïve readers"
+		start, end, ok := FindQuote(text, "naive readers")
+		if !ok || text[start:end] != "naïve readers" {
+			t.Errorf("expected a missing accent to be tolerated, got %q (ok=%v)", text[start:end], ok)
+		}
+		start, end, ok = FindQuote(text, "CAFÉ CULTURE")
+		if !ok || text[start:end] != "Café culture" {
+			t.Errorf("unexpected match %q (ok=%v)", text[start:end], ok)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package articles
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// maxRedirects is how many redirects [ArticleParser.CheckLink] follows before giving up
+const maxRedirects = 10
+
+// LinkCheck is the result of checking that a saved article's URL still resolves
+type LinkCheck struct {
+	URL       string     // the URL that was checked
+	Status    int        // HTTP status of the final response, 0 when there was none
+	FinalURL  string     // URL of the final response, after redirects
+	Redirects []Redirect // redirects followed, in order
+	Err       error      // why no response was received
+}
+
+// Redirect is one redirect followed while checking a link
+type Redirect struct {
+	From   string
+	To     string
+	Status int
+}
+
+// Broken reports whether the check failed: the link was unreachable or answered with a client or
+// server error. Some of those, such as 429 or 503 from a rate limiter, may pass on the next check.
+func (c *LinkCheck) Broken() bool {
+	return c.Err != nil || c.Status >= 400
+}
+
+// Dead reports whether the page is gone rather than temporarily unavailable: its host does not
+// resolve or refuses connections, or it answers with 404 Not Found or 410 Gone
+func (c *LinkCheck) Dead() bool {
+	if c.Err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(c.Err, &dnsErr) {
+			return dnsErr.IsNotFound
+		}
+		return errors.Is(c.Err, syscall.ECONNREFUSED) || errors.Is(c.Err, syscall.EHOSTUNREACH) || errors.Is(c.Err, syscall.ENETUNREACH)
+	}
+	return c.Status == http.StatusNotFound || c.Status == http.StatusGone
+}
+
+// Permanent reports whether the link only went through permanent redirects (301 or 308) to a
+// working page, so the saved URL can be replaced by the final one
+func (c *LinkCheck) Permanent() bool {
+	if len(c.Redirects) == 0 || c.Broken() || c.FinalURL == c.URL {
+		return false
+	}
+	for _, redirect := range c.Redirects {
+		if redirect.Status != http.StatusMovedPermanently && redirect.Status != http.StatusPermanentRedirect {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckLink requests a URL and records the status of the response and the redirects on the way.
+// A HEAD request is tried first; servers that refuse HEAD are asked again with GET, whose
+// body is not read.
+func (p *ArticleParser) CheckLink(s string) *LinkCheck {
+	check := &LinkCheck{URL: s}
+
+	base := p.client
+	if base == nil {
+		base = http.DefaultClient
+	}
+	client := *base
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		redirect := Redirect{From: via[len(via)-1].URL.String(), To: req.URL.String()}
+		if req.Response != nil {
+			redirect.Status = req.Response.StatusCode
+		}
+		check.Redirects = append(check.Redirects, redirect)
+		return nil
+	}
+
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		check.Redirects, check.Status, check.FinalURL, check.Err = nil, 0, "", nil
+
+		req, err := http.NewRequest(method, s, nil)
+		if err != nil {
+			check.Err = fmt.Errorf("invalid URL: %w", err)
+			return check
+		}
+		if rule := p.findRule(req.URL.Hostname()); rule != nil {
+			for header, value := range rule.Headers {
+				if value != "" {
+					req.Header.Set(header, value)
+				}
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			check.Err = err
+			continue
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		check.Status = resp.StatusCode
+		check.FinalURL = resp.Request.URL.String()
+		if !headRefused(method, resp.StatusCode) {
+			break
+		}
+	}
+	return check
+}
+
+// headRefused reports whether a HEAD response is worth retrying with GET: many servers answer
+// HEAD with 403, 404, 405 or 501, or fail it, while serving the page normally
+func headRefused(method string, status int) bool {
+	if method != http.MethodHead {
+		return false
+	}
+	return status == http.StatusForbidden || status == http.StatusMethodNotAllowed ||
+		status == http.StatusNotImplemented || status == http.StatusNotFound
+}
+
+// ArchiveURL returns the address of an archived copy of pageURL on an archive such as the Wayback
+// Machine. An endpoint containing {url} has it replaced; otherwise the page URL is appended
+// after a slash, as in https://web.archive.org/web/https://example.com/post.
+func ArchiveURL(endpoint, pageURL string) string {
+	if strings.Contains(endpoint, "{url}") {
+		return strings.ReplaceAll(endpoint, "{url}", pageURL)
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + pageURL
+}
@@ -0,0 +1,131 @@
+package articles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckLink(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			userAgent = r.Header.Get("User-Agent")
+		case "/moved":
+			http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+		case "/moved-again":
+			http.Redirect(w, r, "/ok", http.StatusPermanentRedirect)
+		case "/temporary":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusMovedPermanently)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/blocked":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	parser, err := NewArticleParser(server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.AddRule("127.0.0.1", &ParsingRule{Headers: map[string]string{"User-Agent": "noteleaf-test"}})
+
+	t.Run("working page", func(t *testing.T) {
+		check := parser.CheckLink(server.URL + "/ok")
+		if check.Broken() || check.Permanent() || check.Status != http.StatusOK || len(check.Redirects) != 0 {
+			t.Errorf("expected a plain 200, got %+v", check)
+		}
+		if userAgent != "noteleaf-test" {
+			t.Errorf("expected the rule's headers to be sent, got User-Agent %q", userAgent)
+		}
+	})
+
+	t.Run("permanent redirects", func(t *testing.T) {
+		check := parser.CheckLink(server.URL + "/moved")
+		if !check.Permanent() || check.FinalURL != server.URL+"/ok" || len(check.Redirects) != 2 {
+			t.Fatalf("expected two permanent redirects to /ok, got %+v", check)
+		}
+		if check.Redirects[0].Status != http.StatusMovedPermanently || check.Redirects[1].Status != http.StatusPermanentRedirect {
+			t.Errorf("unexpected redirect statuses %+v", check.Redirects)
+		}
+	})
+
+	t.Run("temporary redirect is not permanent", func(t *testing.T) {
+		check := parser.CheckLink(server.URL + "/temporary")
+		if check.Broken() || check.Permanent() || check.FinalURL != server.URL+"/ok" {
+			t.Errorf("expected a working temporary redirect, got %+v", check)
+		}
+	})
+
+	t.Run("falls back to GET when HEAD is refused", func(t *testing.T) {
+		check := parser.CheckLink(server.URL + "/no-head")
+		if check.Broken() || check.Status != http.StatusOK {
+			t.Errorf("expected GET to succeed, got %+v", check)
+		}
+	})
+
+	t.Run("dead pages", func(t *testing.T) {
+		for path, status := range map[string]int{"/missing": http.StatusNotFound, "/gone": http.StatusGone} {
+			check := parser.CheckLink(server.URL + path)
+			if !check.Broken() || !check.Dead() || check.Status != status || check.Err != nil {
+				t.Errorf("%s: expected a dead page with status %d, got %+v", path, status, check)
+			}
+		}
+	})
+
+	t.Run("errors that may pass are broken but not dead", func(t *testing.T) {
+		for path, status := range map[string]int{"/error": http.StatusInternalServerError, "/limited": http.StatusTooManyRequests, "/blocked": http.StatusForbidden} {
+			check := parser.CheckLink(server.URL + path)
+			if !check.Broken() || check.Dead() || check.Status != status {
+				t.Errorf("%s: expected a broken but live page with status %d, got %+v", path, status, check)
+			}
+		}
+	})
+
+	t.Run("redirect loop", func(t *testing.T) {
+		check := parser.CheckLink(server.URL + "/loop")
+		if !check.Broken() || check.Dead() || check.Err == nil || check.Permanent() {
+			t.Errorf("expected the loop to fail, got %+v", check)
+		}
+	})
+
+	t.Run("unreachable host", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		check := parser.CheckLink(closed.URL + "/ok")
+		if !check.Broken() || !check.Dead() || check.Err == nil || check.Status != 0 {
+			t.Errorf("expected a refused connection, got %+v", check)
+		}
+	})
+}
+
+func TestArchiveURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://web.archive.org/web", "https://web.archive.org/web/https://example.com/post?id=1"},
+		{"https://web.archive.org/web/", "https://web.archive.org/web/https://example.com/post?id=1"},
+		{"https://archive.example.org/newest/{url}", "https://archive.example.org/newest/https://example.com/post?id=1"},
+	}
+	for _, tt := range tests {
+		if got := ArchiveURL(tt.endpoint, "https://example.com/post?id=1"); got != tt.want {
+			t.Errorf("ArchiveURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
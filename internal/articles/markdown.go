@@ -0,0 +1,627 @@
+package articles
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// markdownConverter renders an extracted article body as CommonMark, with GFM
+// tables and strikethrough. Relative link and image URLs are resolved against base.
+type markdownConverter struct {
+	base *url.URL
+}
+
+// convertToMarkdown renders node and its descendants as markdown, resolving
+// relative URLs against sourceURL
+func convertToMarkdown(node *html.Node, sourceURL string) string {
+	c := &markdownConverter{}
+	if base, err := url.Parse(sourceURL); err == nil && base.IsAbs() {
+		c.base = base
+	}
+	return strings.Join(c.blocks(node), "\n\n")
+}
+
+var (
+	collapseSpaces = regexp.MustCompile(`[ \t\r\n\f]+`)
+	orderedMarker  = regexp.MustCompile(`^(\d+)([.)])`)
+	languageClass  = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([\w+#.-]+)`)
+)
+
+// skippedElements never contain article text
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "head": true,
+	"meta": true, "link": true, "button": true, "input": true, "select": true,
+	"textarea": true, "svg": true, "canvas": true, "iframe": true, "object": true, "embed": true,
+}
+
+// blockElements start a new markdown block
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"center": true, "dd": true, "details": true, "dialog": true, "dir": true, "div": true,
+	"dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hgroup": true, "hr": true, "html": true,
+	"li": true, "main": true, "menu": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "summary": true, "table": true, "ul": true,
+}
+
+func isBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockElements[n.Data]
+}
+
+// blocks renders the children of n as markdown blocks. Runs of inline content
+// between block children become paragraphs.
+func (c *markdownConverter) blocks(n *html.Node) []string {
+	var out []string
+	var inline strings.Builder
+	flush := func() {
+		if text := cleanInline(inline.String()); text != "" {
+			out = append(out, escapeLineStart(text))
+		}
+		inline.Reset()
+	}
+
+	if n.Type == html.ElementNode && !blockElements[n.Data] && n.Parent != nil {
+		// a lone inline element, like a <span> picked as the article body
+		inline.WriteString(c.inline(n))
+		flush()
+		return out
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && skippedElements[child.Data] {
+			continue
+		}
+		if isBlock(child) {
+			flush()
+			out = append(out, c.block(child)...)
+			continue
+		}
+		inline.WriteString(c.inline(child))
+	}
+	flush()
+	return out
+}
+
+// block renders a block-level element
+func (c *markdownConverter) block(n *html.Node) []string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.ReplaceAll(cleanInline(c.children(n)), "\\\n", " ")
+		if text == "" {
+			return nil
+		}
+		level, _ := strconv.Atoi(n.Data[1:])
+		return []string{strings.Repeat("#", level) + " " + text}
+	case "p", "dt", "summary":
+		text := cleanInline(c.children(n))
+		if text == "" {
+			return nil
+		}
+		if n.Data == "dt" {
+			text = "**" + text + "**"
+		}
+		return []string{escapeLineStart(text)}
+	case "hr":
+		return []string{"---"}
+	case "pre":
+		return []string{c.codeBlock(n)}
+	case "blockquote":
+		inner := c.blocks(n)
+		if len(inner) == 0 {
+			return nil
+		}
+		return []string{prefixLines(strings.Join(inner, "\n\n"), "> ", ">")}
+	case "ul", "ol", "menu", "dir":
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "li":
+		// a list item outside a list, treated like a one item list
+		return c.blocks(n)
+	case "table":
+		if table := c.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case "figcaption":
+		text := cleanInline(c.children(n))
+		if text == "" {
+			return nil
+		}
+		return []string{"*" + text + "*"}
+	default:
+		return c.blocks(n)
+	}
+}
+
+// children renders the inline content of n's children
+func (c *markdownConverter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+// inline renders n as inline markdown. Block elements nested in inline ones
+// are flattened into the surrounding text.
+func (c *markdownConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeText(collapseSpaces.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if skippedElements[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\\\n"
+	case "a":
+		return c.link(n)
+	case "img":
+		return c.image(n)
+	case "video", "audio":
+		return c.media(n)
+	case "strong", "b":
+		return wrapInline(c.children(n), "**")
+	case "em", "i":
+		return wrapInline(c.children(n), "*")
+	case "del", "s", "strike":
+		return wrapInline(c.children(n), "~~")
+	case "code", "kbd", "samp", "tt":
+		return codeSpan(textContent(n))
+	}
+
+	text := c.children(n)
+	if isBlock(n) {
+		return " " + text + " "
+	}
+	return text
+}
+
+// link renders an anchor. Anchors without text fall back to their URL, and
+// anchors without a usable href keep only their text. Titles that repeat the
+// link text are dropped.
+func (c *markdownConverter) link(n *html.Node) string {
+	text := c.children(n)
+	href := c.resolve(attr(n, "href"))
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+
+	leading, inner, trailing := splitSpace(text)
+	if strings.TrimSpace(strings.ReplaceAll(inner, "\\\n", "")) == "" {
+		return leading + "<" + href + ">" + trailing
+	}
+	linkTitle := attr(n, "title")
+	if strings.TrimSpace(linkTitle) == strings.TrimSpace(textContent(n)) {
+		linkTitle = ""
+	}
+	return leading + "[" + inner + "](" + destination(href) + title(linkTitle) + ")" + trailing
+}
+
+// image renders an img, preferring the lazy loading source when src is a placeholder
+func (c *markdownConverter) image(n *html.Node) string {
+	src := attr(n, "src")
+	if src == "" || strings.HasPrefix(src, "data:") {
+		for _, key := range []string{"data-src", "data-original", "data-lazy-src"} {
+			if lazy := attr(n, key); lazy != "" {
+				src = lazy
+				break
+			}
+		}
+	}
+	src = c.resolve(src)
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return ""
+	}
+
+	alt := escapeText(collapseSpaces.ReplaceAllString(strings.TrimSpace(attr(n, "alt")), " "))
+	return "![" + alt + "](" + destination(src) + title(attr(n, "title")) + ")"
+}
+
+// media renders a video or audio element as a link to its source, showing a video's poster image
+// when it has one. Elements without a usable source keep only their fallback text.
+func (c *markdownConverter) media(n *html.Node) string {
+	src := attr(n, "src")
+	for child := n.FirstChild; child != nil && src == ""; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "source" {
+			src = attr(child, "src")
+		}
+	}
+	src = c.resolve(src)
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return c.children(n)
+	}
+
+	label := "Video"
+	if n.Data == "audio" {
+		label = "Audio"
+	}
+	if poster := c.resolve(attr(n, "poster")); poster != "" && !strings.HasPrefix(poster, "data:") {
+		label = "![" + label + "](" + destination(poster) + ")"
+	}
+	return "[" + label + "](" + destination(src) + ")"
+}
+
+// codeBlock renders a pre element as a fenced code block, taking the language
+// from a language-* class on the pre or its code element
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	code := textContent(n)
+	code = strings.TrimSuffix(strings.TrimPrefix(code, "\n"), "\n")
+
+	lang := codeLanguage(n)
+	for child := n.FirstChild; child != nil && lang == ""; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "code" {
+			lang = codeLanguage(child)
+		}
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+func codeLanguage(n *html.Node) string {
+	if m := languageClass.FindStringSubmatch(attr(n, "class")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// list renders ul and ol elements. Lists whose items are all single blocks are
+// rendered tight, others loose.
+func (c *markdownConverter) list(n *html.Node) string {
+	ordered := n.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); ordered && err == nil {
+		number = start
+	}
+
+	var items []string
+	loose := false
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+
+		var content []string
+		switch child.Data {
+		case "li":
+			content = c.blocks(child)
+		case "ul", "ol":
+			// a nested list written directly inside its parent list
+			if len(items) > 0 {
+				nested := c.list(child)
+				if nested != "" {
+					last := items[len(items)-1]
+					indent := strings.Repeat(" ", listIndent(last))
+					items[len(items)-1] = last + "\n" + prefixLines(nested, indent, "")
+				}
+				continue
+			}
+			content = c.block(child)
+		default:
+			content = c.block(child)
+		}
+
+		marker := "-"
+		if ordered {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		sep := "\n"
+		if paragraphCount(child) > 1 {
+			loose, sep = true, "\n\n"
+		}
+		body := strings.Join(content, sep)
+		indent := strings.Repeat(" ", len(marker)+1)
+		items = append(items, marker+" "+strings.TrimPrefix(prefixLines(body, indent, ""), indent))
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+	sep := "\n"
+	if loose {
+		sep = "\n\n"
+	}
+	return strings.Join(items, sep)
+}
+
+// paragraphCount counts the blocks in a list item other than nested lists, plus one
+// for any inline text before them
+func paragraphCount(li *html.Node) int {
+	count, text := 0, false
+	for child := li.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case isBlock(child) && child.Data != "ul" && child.Data != "ol":
+			count++
+		case child.Type == html.TextNode && strings.TrimSpace(child.Data) != "",
+			child.Type == html.ElementNode && !isBlock(child):
+			text = true
+		}
+	}
+	if text {
+		count++
+	}
+	return count
+}
+
+// listIndent returns the width of the marker that starts a rendered list item
+func listIndent(item string) int {
+	if m := orderedMarker.FindString(item); m != "" {
+		return len(m) + 1
+	}
+	return 2
+}
+
+// table renders a GFM table. The first row is the header; cells are flattened to
+// a single line and rows are padded to the widest row.
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	var spans []int // rows each column is still covered by a rowspan from above
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				if row := c.tableRow(child, &spans); len(row) > 0 {
+					rows = append(rows, row)
+				}
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	var b strings.Builder
+	if caption := findChild(n, "caption"); caption != nil {
+		if text := cleanInline(c.children(caption)); text != "" {
+			b.WriteString("*" + text + "*\n\n")
+		}
+	}
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// tableRow renders the cells of a tr, leaving cells covered by a rowspan or
+// colspan empty so later cells stay in their column
+func (c *markdownConverter) tableRow(tr *html.Node, spans *[]int) []string {
+	var row []string
+	skipSpanned := func() {
+		for len(row) < len(*spans) && (*spans)[len(row)] > 0 {
+			(*spans)[len(row)]--
+			row = append(row, "")
+		}
+	}
+
+	for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+		if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+			continue
+		}
+		skipSpanned()
+
+		colspan, _ := strconv.Atoi(attr(cell, "colspan"))
+		rowspan, _ := strconv.Atoi(attr(cell, "rowspan"))
+		colspan = min(max(colspan, 1), 100)
+		text := c.tableCell(cell)
+		for i := range colspan {
+			col := len(row)
+			for len(*spans) <= col {
+				*spans = append(*spans, 0)
+			}
+			if rowspan > 1 {
+				(*spans)[col] = rowspan - 1
+			}
+			if i == 0 {
+				row = append(row, text)
+			} else {
+				row = append(row, "")
+			}
+		}
+	}
+	if len(row) > 0 {
+		skipSpanned()
+	}
+	return row
+}
+
+// tableCell flattens a cell's content to one line with pipes escaped
+func (c *markdownConverter) tableCell(n *html.Node) string {
+	var parts []string
+	for _, block := range c.blocks(n) {
+		parts = append(parts, strings.ReplaceAll(block, "\n", " "))
+	}
+	text := strings.ReplaceAll(strings.Join(parts, " "), "\\ ", " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// resolve makes ref absolute against the source URL
+func (c *markdownConverter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || c.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return c.base.ResolveReference(u).String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func findChild(n *html.Node, tag string) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// textContent returns the raw text under n, with <br> as a newline
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			b.WriteString(node.Data)
+		case node.Type == html.ElementNode && node.Data == "br":
+			b.WriteString("\n")
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// cleanInline trims a run of inline markdown and removes the whitespace around hard line breaks
+func cleanInline(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(collapseSpaces.ReplaceAllString(line, " "))
+		if line == "" || line == "\\" {
+			continue
+		}
+		out = append(out, line)
+	}
+	text := strings.Join(out, "\n")
+	return strings.TrimSpace(strings.TrimSuffix(text, "\\"))
+}
+
+// wrapInline surrounds text with an emphasis delimiter, keeping surrounding
+// whitespace outside it so the delimiters stay flanking
+func wrapInline(text, delim string) string {
+	leading, inner, trailing := splitSpace(text)
+	if strings.TrimSpace(inner) == "" {
+		return text
+	}
+	return leading + delim + inner + delim + trailing
+}
+
+func splitSpace(text string) (leading, inner, trailing string) {
+	inner = strings.TrimLeft(text, " ")
+	leading = text[:len(text)-len(inner)]
+	trimmed := strings.TrimRight(inner, " ")
+	return leading, trimmed, inner[len(trimmed):]
+}
+
+// codeSpan renders text as inline code, using a fence longer than any backtick run in it
+func codeSpan(text string) string {
+	text = collapseSpaces.ReplaceAllString(text, " ")
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// escapeText escapes characters that would otherwise be read as inline markdown
+func escapeText(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var blockStart = regexp.MustCompile(`^(#{1,6}(?:\s|$)|[-+>](?:\s|$)|\d+[.)](?:\s|$)|=+\s*$|-{3,}\s*$)`)
+
+// escapeLineStart escapes text at the start of each line of a paragraph that
+// would turn it into a heading, list item, quote or rule
+func escapeLineStart(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if loc := blockStart.FindStringIndex(line); loc != nil {
+			if m := orderedMarker.FindStringSubmatchIndex(line); m != nil {
+				lines[i] = line[:m[3]] + `\` + line[m[3]:]
+				continue
+			}
+			lines[i] = `\` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// destination formats a link destination, wrapping it in angle brackets when it
+// contains spaces or unbalanced parentheses
+func destination(href string) string {
+	if strings.ContainsAny(href, " <>") || strings.Count(href, "(") != strings.Count(href, ")") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(href) + ">"
+	}
+	return href
+}
+
+func title(t string) string {
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return ""
+	}
+	return ` "` + strings.ReplaceAll(t, `"`, `\"`) + `"`
+}
+
+// prefixLines prepends prefix to each non-empty line of s, and empty to empty lines
+func prefixLines(s, prefix, empty string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = empty
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
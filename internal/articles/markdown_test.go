@@ -0,0 +1,168 @@
+package articles
+
+import (
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+	"golang.org/x/net/html"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden markdown files in examples")
+
+// convertFragment converts the children of body in an HTML fragment
+func convertFragment(t *testing.T, fragment string) string {
+	t.Helper()
+	doc := parseHTML("<html><body>" + fragment + "</body></html>")
+	body := findElement(doc, "body")
+	if body == nil {
+		t.Fatal("fragment should have a body")
+	}
+	return convertToMarkdown(body, "https://example.com/blog/post")
+}
+
+func TestConvertToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "headings and paragraphs",
+			html: "<h1>Title</h1><p>First   paragraph\nwraps.</p><h3>Section</h3><p>Second.</p>",
+			want: "# Title\n\nFirst paragraph wraps.\n\n### Section\n\nSecond.",
+		},
+		{
+			name: "loose inline text becomes a paragraph",
+			html: "<div>Some <b>bold</b> text<p>Then a paragraph.</p></div>",
+			want: "Some **bold** text\n\nThen a paragraph.",
+		},
+		{
+			name: "emphasis and strikethrough",
+			html: "<p><em>one</em> <strong>two</strong> <del>three</del> <i> spaced </i>end</p>",
+			want: "*one* **two** ~~three~~ *spaced* end",
+		},
+		{
+			name: "links resolve against the source URL",
+			html: `<p><a href="/about">About</a>, <a href="other">other</a>, <a href="#top">top</a> and <a href="https://go.dev" title="Go site">Go</a></p>`,
+			want: `[About](https://example.com/about), [other](https://example.com/blog/other), [top](https://example.com/blog/post#top) and [Go](https://go.dev "Go site")`,
+		},
+		{
+			name: "links without text become autolinks",
+			html: `<p><a href="https://example.com/x"></a></p>`,
+			want: "<https://example.com/x>",
+		},
+		{
+			name: "images use lazy sources",
+			html: `<p><img src="/a.png" alt="A"> <img data-src="b.png" alt="B"></p>`,
+			want: "![A](https://example.com/a.png) ![B](https://example.com/blog/b.png)",
+		},
+		{
+			name: "video and audio link to their sources",
+			html: `<p><video poster="/poster.jpg"><source src="clip.mp4"></video> <audio src="/talk.mp3"></audio></p>`,
+			want: "[![Video](https://example.com/poster.jpg)](https://example.com/blog/clip.mp4) [Audio](https://example.com/talk.mp3)",
+		},
+		{
+			name: "code spans and blocks",
+			html: "<p>Run <code>go test</code> or <code>a`b</code></p><pre><code class=\"language-go\">func main() {\n\tprintln(\"hi\")\n}\n</code></pre>",
+			want: "Run `go test` or ``a`b``\n\n```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```",
+		},
+		{
+			name: "unordered and nested lists",
+			html: "<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>",
+			want: "- One\n- Two\n  - Nested",
+		},
+		{
+			name: "ordered lists keep their start",
+			html: `<ol start="3"><li>Three</li><li><p>Four</p><p>More</p></li></ol>`,
+			want: "3. Three\n\n4. Four\n\n   More",
+		},
+		{
+			name: "blockquotes",
+			html: "<blockquote><p>Quoted</p><p>Twice</p></blockquote>",
+			want: "> Quoted\n>\n> Twice",
+		},
+		{
+			name: "tables with header and spans",
+			html: `<table><caption>Roles</caption><tr><th>Year</th><th>Title</th><th>Role</th></tr>` +
+				`<tr><td rowspan="2">1985</td><td>Back to the Future</td><td>Doc</td></tr>` +
+				`<tr><td>Clue</td><td>Plum | Prof</td></tr>` +
+				`<tr><td colspan="2">Total</td><td>2</td></tr></table>`,
+			want: "*Roles*\n\n| Year | Title | Role |\n| --- | --- | --- |\n| 1985 | Back to the Future | Doc |\n|  | Clue | Plum \\| Prof |\n| Total |  | 2 |",
+		},
+		{
+			name: "escapes markdown syntax in text",
+			html: "<p>1. not a list *or* [link] with_under</p><p># not a heading</p>",
+			want: "1\\. not a list \\*or\\* \\[link\\] with\\_under\n\n\\# not a heading",
+		},
+		{
+			name: "skips scripts and forms",
+			html: "<p>Keep</p><script>alert(1)</script><form><input value=\"x\"><button>Go</button></form><style>p{}</style>",
+			want: "Keep",
+		},
+		{
+			name: "horizontal rules",
+			html: "<p>Above</p><hr><p>Below</p>",
+			want: "Above\n\n---\n\nBelow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertFragment(t, tt.html); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+var canonicalLink = regexp.MustCompile(`<link rel="canonical" href="([^"]+)"`)
+
+// TestMarkdownGolden parses every page in examples with the rule for its canonical URL
+// and compares the markdown body with the .md file beside it. Run with -update after
+// an intended change to the conversion.
+func TestMarkdownGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("examples", "*.html"))
+	shared.AssertNoError(t, err, "should list examples")
+	shared.AssertTrue(t, len(files) > 0, "examples should not be empty")
+
+	parser, err := NewArticleParser(nil)
+	shared.AssertNoError(t, err, "should create parser")
+
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".html")
+		goldenPath := strings.TrimSuffix(path, ".html") + ".md"
+
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			shared.AssertNoError(t, err, "should read example")
+
+			match := canonicalLink.FindSubmatch(source)
+			if match == nil {
+				t.Fatalf("%s has no canonical link", path)
+			}
+			sourceURL := html.UnescapeString(string(match[1]))
+			u, err := url.Parse(sourceURL)
+			shared.AssertNoError(t, err, "should parse canonical URL")
+
+			content, err := parser.Parse(string(source), u.Hostname(), sourceURL)
+			shared.AssertNoError(t, err, "should parse example")
+			got := content.Content + "\n"
+
+			if *updateGolden {
+				shared.AssertNoError(t, os.WriteFile(goldenPath, []byte(got), 0o644), "should write golden file")
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			shared.AssertNoError(t, err, "should read golden file")
+			if got != string(want) {
+				t.Fatalf("markdown differs from %s; run with -update to see the changes", goldenPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package articles
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+const (
+	// DefaultArxivAPI is the arXiv API queried for the metadata of arXiv papers
+	DefaultArxivAPI = "https://export.arxiv.org/api/query"
+	// DefaultCrossrefAPI is the Crossref works endpoint queried for the metadata of DOIs
+	DefaultCrossrefAPI = "https://api.crossref.org/works/"
+)
+
+var (
+	arxivIDPattern     = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z][a-z.\-]*(\.[A-Z]{2})?/\d{7})(v\d+)?$`)
+	arxivInText        = regexp.MustCompile(`(?i)\barXiv:\s?(\d{4}\.\d{4,5})(v\d+)?`)
+	doiPattern         = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+	doiInText          = regexp.MustCompile(`\b10\.\d{4,9}/[^\s"<>]+`)
+	arxivVersion       = regexp.MustCompile(`v\d+$`)
+	markdownBlockStart = regexp.MustCompile(`^(#|>|[-*+]\s|\d+[.)]\s|\|)`)
+)
+
+// ResolveReference turns the paper shorthands accepted by article add into URLs:
+// arxiv:2009.03017 becomes https://arxiv.org/abs/2009.03017 and doi:10.1000/xyz becomes
+// https://doi.org/10.1000/xyz. Anything else is returned unchanged.
+func ResolveReference(ref string) string {
+	ref = strings.TrimSpace(ref)
+	scheme, id, ok := strings.Cut(ref, ":")
+	if !ok {
+		return ref
+	}
+	id = strings.TrimSpace(id)
+	switch strings.ToLower(scheme) {
+	case "arxiv":
+		if arxivIDPattern.MatchString(id) {
+			return "https://arxiv.org/abs/" + id
+		}
+	case "doi":
+		if doiPattern.MatchString(id) {
+			return "https://doi.org/" + id
+		}
+	}
+	return ref
+}
+
+// paperReference recognises arXiv abstract and PDF pages and DOI links, returning "arxiv" or
+// "doi" and the paper's identifier
+func paperReference(u *url.URL) (kind, id string) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch host {
+	case "arxiv.org", "export.arxiv.org":
+		for _, prefix := range []string{"/abs/", "/pdf/"} {
+			if rest, ok := strings.CutPrefix(u.Path, prefix); ok {
+				rest = strings.TrimSuffix(rest, ".pdf")
+				if arxivIDPattern.MatchString(rest) {
+					return "arxiv", rest
+				}
+			}
+		}
+	case "doi.org", "dx.doi.org":
+		if doi := strings.TrimPrefix(u.Path, "/"); doiPattern.MatchString(doi) {
+			return "doi", doi
+		}
+	}
+	return "", ""
+}
+
+// SetPaperServices overrides the arXiv API and Crossref works endpoints used to look up papers.
+// Empty values keep the current endpoint.
+func (p *ArticleParser) SetPaperServices(arxivAPI, crossrefAPI string) {
+	if arxivAPI != "" {
+		p.arxivAPI = arxivAPI
+	}
+	if crossrefAPI != "" {
+		p.crossrefAPI = crossrefAPI
+	}
+}
+
+// paper is the metadata of a paper found through arXiv or Crossref
+type paper struct {
+	citation *models.Citation
+	pdfURL   string // where the full text can be downloaded, if known
+}
+
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links []struct {
+			Href  string `xml:"href,attr"`
+			Title string `xml:"title,attr"`
+			Type  string `xml:"type,attr"`
+		} `xml:"link"`
+		DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+		JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	} `xml:"entry"`
+}
+
+// lookupArxiv fetches the metadata of an arXiv paper. Papers that have since been published
+// with a DOI are cited from their Crossref record, keeping the arXiv abstract and PDF.
+func (p *ArticleParser) lookupArxiv(id string) (*paper, error) {
+	data, _, err := p.fetchBytes(p.arxivAPI+"?id_list="+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query arXiv: %w", err)
+	}
+
+	var feed arxivFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse arXiv response: %w", err)
+	}
+	if len(feed.Entries) == 0 || strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return nil, fmt.Errorf("arXiv paper %s not found", id)
+	}
+	entry := feed.Entries[0]
+
+	baseID := arxivVersion.ReplaceAllString(id, "")
+	citation := &models.Citation{
+		ID:             "arXiv:" + baseID,
+		Type:           "article",
+		Title:          collapseSpace(entry.Title),
+		ContainerTitle: "arXiv",
+		Publisher:      "arXiv",
+		Number:         "arXiv:" + baseID,
+		DOI:            "10.48550/arXiv." + baseID,
+		URL:            "https://arxiv.org/abs/" + baseID,
+		Abstract:       collapseSpace(entry.Summary),
+	}
+	for _, author := range entry.Authors {
+		citation.Author = append(citation.Author, splitName(author.Name))
+	}
+	if published, err := time.Parse(time.RFC3339, strings.TrimSpace(entry.Published)); err == nil {
+		citation.Issued = models.NewCitationDate(published.Year(), int(published.Month()), published.Day())
+	}
+
+	result := &paper{citation: citation}
+	for _, link := range entry.Links {
+		if link.Title == "pdf" || link.Type == "application/pdf" {
+			result.pdfURL = link.Href
+		}
+	}
+
+	if doi := strings.TrimSpace(entry.DOI); doi != "" {
+		if published, err := p.lookupDOI(doi); err == nil {
+			if published.citation.Abstract == "" {
+				published.citation.Abstract = citation.Abstract
+			}
+			result.citation = published.citation
+		} else if ref := collapseSpace(entry.JournalRef); ref != "" {
+			citation.DOI, citation.ContainerTitle = doi, ref
+		}
+	}
+	return result, nil
+}
+
+type crossrefResponse struct {
+	Message struct {
+		Type           string   `json:"type"`
+		Title          []string `json:"title"`
+		ContainerTitle []string `json:"container-title"`
+		Publisher      string   `json:"publisher"`
+		Author         []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+			Name   string `json:"name"`
+		} `json:"author"`
+		Issued struct {
+			DateParts [][]int `json:"date-parts"`
+		} `json:"issued"`
+		Abstract string `json:"abstract"`
+		Volume   string `json:"volume"`
+		Issue    string `json:"issue"`
+		Page     string `json:"page"`
+		DOI      string `json:"DOI"`
+		URL      string `json:"URL"`
+		Link     []struct {
+			URL         string `json:"URL"`
+			ContentType string `json:"content-type"`
+		} `json:"link"`
+	} `json:"message"`
+}
+
+// crossrefTypes maps Crossref work types to CSL item types
+var crossrefTypes = map[string]string{
+	"journal-article":     "article-journal",
+	"proceedings-article": "paper-conference",
+	"book-chapter":        "chapter",
+	"book":                "book",
+	"monograph":           "book",
+	"edited-book":         "book",
+	"posted-content":      "article",
+	"report":              "report",
+	"dissertation":        "thesis",
+	"dataset":             "dataset",
+}
+
+// lookupDOI fetches the metadata of a DOI from Crossref
+func (p *ArticleParser) lookupDOI(doi string) (*paper, error) {
+	data, _, err := p.fetchBytes(p.crossrefAPI+url.PathEscape(doi), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up DOI %s: %w", doi, err)
+	}
+
+	var response crossrefResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Crossref response: %w", err)
+	}
+	work := response.Message
+	if len(work.Title) == 0 {
+		return nil, fmt.Errorf("DOI %s has no title", doi)
+	}
+
+	citation := &models.Citation{
+		ID:        doi,
+		Type:      crossrefTypes[work.Type],
+		Title:     collapseSpace(work.Title[0]),
+		Publisher: work.Publisher,
+		Volume:    work.Volume,
+		Issue:     work.Issue,
+		Page:      work.Page,
+		DOI:       doi,
+		URL:       "https://doi.org/" + doi,
+		Abstract:  strings.TrimSpace(strings.TrimPrefix(plainText(work.Abstract), "Abstract")),
+	}
+	if citation.Type == "" {
+		citation.Type = "article-journal"
+	}
+	if len(work.ContainerTitle) > 0 {
+		citation.ContainerTitle = collapseSpace(work.ContainerTitle[0])
+	}
+	for _, author := range work.Author {
+		switch {
+		case author.Family != "":
+			citation.Author = append(citation.Author, models.CitationName{Family: author.Family, Given: author.Given})
+		case author.Name != "":
+			citation.Author = append(citation.Author, models.CitationName{Literal: author.Name})
+		}
+	}
+	if parts := work.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 {
+		date := append(slices.Clone(parts[0]), 0, 0)
+		citation.Issued = models.NewCitationDate(date[0], date[1], date[2])
+	}
+
+	result := &paper{citation: citation}
+	for _, link := range work.Link {
+		if link.ContentType == "application/pdf" {
+			result.pdfURL = link.URL
+			break
+		}
+	}
+	return result, nil
+}
+
+// parseArxiv saves an arXiv paper with its abstract and, when the PDF can be read, its full text
+func (p *ArticleParser) parseArxiv(id string) (*ParsedContent, error) {
+	found, err := p.lookupArxiv(id)
+	if err != nil {
+		return nil, err
+	}
+	return paperContent(found.citation, p.paperText(found.pdfURL), "https://arxiv.org/abs/"+id, "arxiv")
+}
+
+// parseDOI saves a paper found by DOI with its abstract and, when the publisher offers an
+// open PDF, its full text
+func (p *ArticleParser) parseDOI(doi string) (*ParsedContent, error) {
+	found, err := p.lookupDOI(doi)
+	if err != nil {
+		return nil, err
+	}
+	return paperContent(found.citation, p.paperText(found.pdfURL), "https://doi.org/"+doi, "doi")
+}
+
+// parsePDF saves a downloaded PDF. A DOI or arXiv identifier on its first page is looked up for
+// the citation; otherwise the citation comes from the PDF's document information.
+func (p *ArticleParser) parsePDF(data []byte, sourceURL string) (*ParsedContent, error) {
+	doc, err := ExtractPDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	firstPage := ""
+	if len(doc.Pages) > 0 {
+		firstPage = doc.Pages[0]
+	}
+
+	var found *paper
+	if match := arxivInText.FindStringSubmatch(firstPage); match != nil {
+		found, _ = p.lookupArxiv(match[1])
+	}
+	if found == nil {
+		if doi := doiInText.FindString(firstPage); doi != "" {
+			found, _ = p.lookupDOI(strings.TrimRight(doi, ".,;)]"))
+		}
+	}
+
+	citation := pdfCitation(doc, sourceURL)
+	if found != nil {
+		citation = found.citation
+	}
+	return paperContent(citation, doc.Text(), sourceURL, "pdf")
+}
+
+// pdfCitation describes a PDF that no bibliographic service knows from its document information,
+// using its first paragraph as the title when it has none
+func pdfCitation(doc *PDFDocument, sourceURL string) *models.Citation {
+	citation := &models.Citation{Type: "document", Title: doc.Title, URL: sourceURL}
+
+	lower := strings.ToLower(doc.Title)
+	if citation.Title == "" || strings.HasSuffix(lower, ".pdf") || strings.HasSuffix(lower, ".tex") || strings.HasSuffix(lower, ".dvi") {
+		citation.Title = ""
+		for _, page := range doc.Pages {
+			if first, _, _ := strings.Cut(page, "\n\n"); first != "" {
+				citation.Title = truncateWords(first, 150)
+				break
+			}
+		}
+	}
+
+	if doc.Author != "" {
+		for name := range strings.SplitSeq(strings.ReplaceAll(doc.Author, " and ", ";"), ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				citation.Author = append(citation.Author, splitName(name))
+			}
+		}
+	}
+	if !doc.Created.IsZero() {
+		citation.Issued = models.NewCitationDate(doc.Created.Year(), int(doc.Created.Month()), doc.Created.Day())
+	}
+	return citation
+}
+
+// paperText downloads and extracts the text of a paper's PDF, returning "" when there is none
+// or it cannot be read, in which case the paper is saved with its abstract alone
+func (p *ArticleParser) paperText(pdfURL string) string {
+	if pdfURL == "" {
+		return ""
+	}
+	data, contentType, err := p.fetchBytes(pdfURL, nil)
+	if err != nil || !IsPDF(contentType, data) {
+		return ""
+	}
+	doc, err := ExtractPDF(data)
+	if err != nil {
+		return ""
+	}
+	return doc.Text()
+}
+
+// paperContent builds the saved article of a paper: its abstract followed by the full text
+func paperContent(citation *models.Citation, fullText, sourceURL, method string) (*ParsedContent, error) {
+	var b strings.Builder
+	if citation.Abstract != "" {
+		b.WriteString("## Abstract\n\n" + escapeParagraph(citation.Abstract) + "\n\n")
+	}
+	if fullText = strings.TrimSpace(fullText); fullText != "" {
+		b.WriteString("## Full Text\n\n")
+		for paragraph := range strings.SplitSeq(fullText, "\n\n") {
+			b.WriteString(escapeParagraph(paragraph) + "\n\n")
+		}
+	}
+	if b.Len() == 0 {
+		return nil, fmt.Errorf("no abstract or full text available for %s", sourceURL)
+	}
+
+	var authors []string
+	for _, name := range citation.Author {
+		authors = append(authors, name.String())
+	}
+
+	content := &ParsedContent{
+		Title:            citation.Title,
+		Author:           strings.Join(authors, ", "),
+		Date:             citation.Issued.String(),
+		Content:          strings.TrimRight(b.String(), "\n") + "\n",
+		URL:              sourceURL,
+		Confidence:       0.5,
+		ExtractionMethod: method,
+		Pages:            1,
+		Citation:         citation,
+	}
+	if fullText != "" {
+		content.Confidence = 1
+	}
+	if content.Title == "" {
+		content.Title = sourceURL
+	}
+	content.WordCount, content.ReadingTime = readingStats(content.Content)
+	return content, nil
+}
+
+// escapeParagraph keeps extracted text from being read as markdown block syntax
+func escapeParagraph(text string) string {
+	text = collapseSpace(text)
+	if markdownBlockStart.MatchString(text) {
+		return `\` + text
+	}
+	return text
+}
+
+// splitName splits a personal name into given and family names, keeping lowercase particles
+// such as "van" or "de" with the family name
+func splitName(name string) models.CitationName {
+	fields := strings.Fields(name)
+	if len(fields) < 2 {
+		return models.CitationName{Literal: strings.Join(fields, " ")}
+	}
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return models.CitationName{Family: collapseSpace(family), Given: collapseSpace(given)}
+	}
+
+	split := len(fields) - 1
+	for split > 1 && unicode.IsLower([]rune(fields[split-1])[0]) {
+		split--
+	}
+	return models.CitationName{Given: strings.Join(fields[:split], " "), Family: strings.Join(fields[split:], " ")}
+}
+
+// truncateWords shortens text to at most limit bytes, cutting at a word boundary
+func truncateWords(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := strings.LastIndex(text[:limit], " ")
+	if cut <= 0 {
+		cut = limit
+	}
+	return text[:cut] + "…"
+}
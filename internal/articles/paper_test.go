@@ -0,0 +1,221 @@
+package articles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const arxivFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <title>arXiv Query: id_list=2009.03017</title>
+  <entry>
+    <id>http://arxiv.org/abs/2009.03017v2</id>
+    <published>2020-09-07T10:12:00Z</published>
+    <title>A Beta Test of
+      Unicode Maps</title>
+    <summary>  We study how file formats
+      affect small libraries.
+    </summary>
+    <author><name>Grace Hopper</name></author>
+    <author><name>Ludwig van Beethoven</name></author>
+    <link href="http://arxiv.org/abs/2009.03017v2" rel="alternate" type="text/html"/>
+    <link title="pdf" href="SERVER/pdf/2009.03017v2" rel="related" type="application/pdf"/>
+  </entry>
+</feed>`
+
+const arxivErrorFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/api/errors#incorrect_id_format_for_0000.0000</id>
+    <title>Error</title>
+    <summary>incorrect id format for 0000.0000</summary>
+  </entry>
+</feed>`
+
+const crossrefFixture = `{
+  "status": "ok",
+  "message": {
+    "type": "journal-article",
+    "title": ["Sparse Retrieval for Small Archives"],
+    "container-title": ["Journal of Personal Archives"],
+    "publisher": "Example Press",
+    "author": [
+      {"given": "Ada", "family": "Lovelace", "sequence": "first"},
+      {"given": "Alan", "family": "Turing", "sequence": "additional"},
+      {"name": "The Archive Consortium", "sequence": "additional"}
+    ],
+    "issued": {"date-parts": [[2021, 3]]},
+    "abstract": "<jats:title>Abstract</jats:title><jats:p>Personal archives rarely need <jats:italic>dense</jats:italic> indexes.</jats:p>",
+    "volume": "12",
+    "issue": "3",
+    "page": "45-67",
+    "DOI": "10.5555/fixture.2021.42",
+    "link": [
+      {"URL": "SERVER/files/landing.html", "content-type": "text/html"},
+      {"URL": "SERVER/files/simple.pdf", "content-type": "application/pdf"}
+    ]
+  }
+}`
+
+// newPaperServer stubs the arXiv API, Crossref and the PDFs they link to
+func newPaperServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fill := func(s string) string { return strings.ReplaceAll(s, "SERVER", server.URL) }
+		switch {
+		case r.URL.Path == "/api/query" && r.URL.Query().Get("id_list") == "2009.03017":
+			w.Write([]byte(fill(arxivFixture)))
+		case r.URL.Path == "/api/query":
+			w.Write([]byte(arxivErrorFixture))
+		case r.URL.Path == "/works/10.5555/fixture.2021.42":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(fill(crossrefFixture)))
+		case r.URL.Path == "/pdf/2009.03017v2":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(readExample(t, "compressed.pdf"))
+		case r.URL.Path == "/files/simple.pdf":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(readExample(t, "simple.pdf"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newPaperParser(t *testing.T, server *httptest.Server) *ArticleParser {
+	t.Helper()
+	parser, err := NewArticleParser(server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetPaperServices(server.URL+"/api/query", server.URL+"/works/")
+	return parser
+}
+
+func TestResolveReference(t *testing.T) {
+	for ref, want := range map[string]string{
+		"arxiv:2009.03017":                 "https://arxiv.org/abs/2009.03017",
+		"arXiv:2009.03017v2":               "https://arxiv.org/abs/2009.03017v2",
+		"arxiv:hep-th/9901001":             "https://arxiv.org/abs/hep-th/9901001",
+		" doi:10.1145/3292500.3330701 ":    "https://doi.org/10.1145/3292500.3330701",
+		"DOI:10.5555/fixture.2021.42":      "https://doi.org/10.5555/fixture.2021.42",
+		"arxiv:not-an-id":                  "arxiv:not-an-id",
+		"https://example.com/paper.pdf":    "https://example.com/paper.pdf",
+		"https://arxiv.org/abs/2009.03017": "https://arxiv.org/abs/2009.03017",
+	} {
+		if got := ResolveReference(ref); got != want {
+			t.Errorf("ResolveReference(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestParsePapers(t *testing.T) {
+	server := newPaperServer(t)
+
+	t.Run("arXiv papers get metadata, abstract and full text", func(t *testing.T) {
+		for _, link := range []string{"https://arxiv.org/abs/2009.03017", "https://arxiv.org/pdf/2009.03017.pdf"} {
+			content, err := newPaperParser(t, server).ParseURL(link)
+			if err != nil {
+				t.Fatalf("ParseURL(%s) failed: %v", link, err)
+			}
+			if content.Title != "A Beta Test of Unicode Maps" || content.Author != "Grace Hopper, Ludwig van Beethoven" || content.Date != "2020-09-07" {
+				t.Errorf("unexpected metadata: %q by %q on %q", content.Title, content.Author, content.Date)
+			}
+			if content.ExtractionMethod != "arxiv" || content.URL != "https://arxiv.org/abs/2009.03017" {
+				t.Errorf("unexpected method %q or URL %q", content.ExtractionMethod, content.URL)
+			}
+
+			citation := content.Citation
+			if citation == nil {
+				t.Fatal("expected a citation")
+			}
+			if citation.Number != "arXiv:2009.03017" || citation.DOI != "10.48550/arXiv.2009.03017" || citation.Abstract != "We study how file formats affect small libraries." {
+				t.Errorf("unexpected citation: %+v", citation)
+			}
+			if citation.Author[1].Given != "Ludwig" || citation.Author[1].Family != "van Beethoven" {
+				t.Errorf("expected the particle to stay with the family name, got %+v", citation.Author[1])
+			}
+
+			for _, want := range []string{"## Abstract\n\nWe study how file formats affect small libraries.", "## Full Text", "We find that file formats matter."} {
+				if !strings.Contains(content.Content, want) {
+					t.Errorf("expected content to contain %q, got:\n%s", want, content.Content)
+				}
+			}
+			if content.WordCount == 0 {
+				t.Error("expected a word count")
+			}
+		}
+	})
+
+	t.Run("DOIs are looked up on Crossref", func(t *testing.T) {
+		content, err := newPaperParser(t, server).ParseURL("https://doi.org/10.5555/fixture.2021.42")
+		if err != nil {
+			t.Fatalf("ParseURL failed: %v", err)
+		}
+		citation := content.Citation
+		if citation.Type != "article-journal" || citation.ContainerTitle != "Journal of Personal Archives" || citation.Volume != "12" || citation.Page != "45-67" {
+			t.Errorf("unexpected citation: %+v", citation)
+		}
+		if citation.Issued.String() != "2021-03" || content.Date != "2021-03" {
+			t.Errorf("unexpected issued date %q", citation.Issued.String())
+		}
+		if citation.Abstract != "Personal archives rarely need dense indexes." {
+			t.Errorf("expected the JATS markup to be removed, got %q", citation.Abstract)
+		}
+		if citation.Author[2].Literal != "The Archive Consortium" {
+			t.Errorf("expected an organisation author, got %+v", citation.Author[2])
+		}
+		if !strings.Contains(content.Content, "a carefully tuned inverted index") {
+			t.Errorf("expected the text of the linked PDF, got:\n%s", content.Content)
+		}
+	})
+
+	t.Run("PDF links are cited from the DOI on their first page", func(t *testing.T) {
+		content, err := newPaperParser(t, server).ParseURL(server.URL + "/files/simple.pdf")
+		if err != nil {
+			t.Fatalf("ParseURL failed: %v", err)
+		}
+		if content.ExtractionMethod != "pdf" || content.URL != server.URL+"/files/simple.pdf" {
+			t.Errorf("unexpected method %q or URL %q", content.ExtractionMethod, content.URL)
+		}
+		if content.Citation.ContainerTitle != "Journal of Personal Archives" {
+			t.Errorf("expected the Crossref record, got %+v", content.Citation)
+		}
+		if !strings.Contains(content.Content, "The second page continues the argument") {
+			t.Errorf("expected the full text, got:\n%s", content.Content)
+		}
+	})
+
+	t.Run("unknown papers fail", func(t *testing.T) {
+		parser := newPaperParser(t, server)
+		if _, err := parser.ParseURL("https://arxiv.org/abs/0000.00000"); err == nil {
+			t.Error("expected an error for an unknown arXiv id")
+		}
+		if _, err := parser.ParseURL("https://doi.org/10.5555/missing"); err == nil {
+			t.Error("expected an error for an unknown DOI")
+		}
+	})
+}
+
+func TestPDFCitation(t *testing.T) {
+	doc := &PDFDocument{
+		Title:  "draft-v3.pdf",
+		Author: "Ada Lovelace and Turing, Alan",
+		Pages:  []string{"Notes on the Analytical Engine\n\nThe engine weaves algebraic patterns."},
+	}
+	citation := pdfCitation(doc, "https://example.com/notes.pdf")
+	if citation.Type != "document" || citation.Title != "Notes on the Analytical Engine" {
+		t.Errorf("expected the first paragraph to replace a file name title, got %+v", citation)
+	}
+	if len(citation.Author) != 2 || citation.Author[0].Family != "Lovelace" || citation.Author[1].Family != "Turing" || citation.Author[1].Given != "Alan" {
+		t.Errorf("unexpected authors %+v", citation.Author)
+	}
+	if citation.Issued != nil {
+		t.Errorf("expected no issued date, got %v", citation.Issued)
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/antchfx/htmlquery"
 	"github.com/gomarkdown/markdown"
@@ -31,23 +35,50 @@ type ParsedContent struct {
 	Date             string
 	Content          string
 	URL              string
-	Confidence       float64 // 0-1 scale, confidence in extraction quality
-	ExtractionMethod string  // "xpath", "heuristic", "dual-validated", etc.
+	Confidence       float64          // 0-1 scale, confidence in extraction quality
+	ExtractionMethod string           // "xpath", "heuristic", "dual-validated", etc.
+	Pages            int              // number of pages the content was collected from
+	Rule             string           // domain of the rule the body was extracted with, "" for heuristic extraction
+	NativeAd         bool             // the page matched a native_ad_clue of its rule
+	WordCount        int              // words in Content, ignoring markdown syntax and link targets
+	ReadingTime      int              // estimated minutes to read Content at [wordsPerMinute]
+	Citation         *models.Citation // bibliographic record, set for academic papers
 }
 
-// ParsingRule represents XPath rules for extracting content from a specific domain
+// ParsingRule represents XPath rules for extracting content from a specific domain.
+//
+// Rules use the FiveFilters site_config format (https://github.com/fivefilters/ftr-site-config).
 type ParsingRule struct {
 	Domain            string
+	Source            string // RuleSourceEmbedded, or the path of the rule file it was loaded from
 	Title             string
 	Author            string
 	Date              string
 	Body              string
+	BodyAlternatives  []string // further body XPaths, tried in order when Body matches nothing
 	Strip             []string // XPath selectors for elements to remove
 	StripIDsOrClasses []string
+	StripImageSrc     []string // images whose src contains one of these are removed
+	SinglePageLinks   []string // XPaths to a link to the whole article on one page
+	NextPageLinks     []string // XPaths to the link to the next page of the article
+	NativeAdClues     []string // XPaths that mark a page as sponsored content
+	Replacements      []StringReplacement
 	TestURLs          []string
 	Headers           map[string]string
 	Prune             bool
 	Tidy              bool
+	// DisableAutodetect stops the parser from falling back to heuristic extraction
+	// when the body XPaths match nothing (autodetect_on_failure: no)
+	DisableAutodetect bool
+}
+
+// RuleSourceEmbedded is the [ParsingRule.Source] of the rules built into noteleaf
+const RuleSourceEmbedded = "embedded"
+
+// StringReplacement is a find_string/replace_string pair applied to a page's HTML before it is parsed
+type StringReplacement struct {
+	Find    string
+	Replace string
 }
 
 // Parser interface defines methods for parsing articles from URLs
@@ -56,10 +87,28 @@ type Parser interface {
 	ParseURL(url string) (*ParsedContent, error)
 	// Convert HTML content directly to markdown using domain-specific rules
 	Convert(htmlContent, domain, sourceURL string) (string, error)
+	// Parse extracts article content from HTML fetched from sourceURL
+	Parse(htmlContent, domain, sourceURL string) (*ParsedContent, error)
+	// ParseOffline extracts article content like Parse without fetching any other page
+	ParseOffline(htmlContent, domain, sourceURL string) (*ParsedContent, error)
 	// GetSupportedDomains returns a list of domains that have parsing rules
 	GetSupportedDomains() []string
+	// Rules returns the loaded parsing rules sorted by domain
+	Rules() []*ParsingRule
+	// Rule returns the rule named domain or, failing that, the rule for the host domain
+	Rule(domain string) *ParsingRule
+	// FetchHTML downloads a page with the headers of the rule for its host
+	FetchHTML(url string) (string, error)
+	// ScaffoldRule drafts a rule file for a page from heuristic extraction
+	ScaffoldRule(htmlContent, sourceURL string) (string, error)
+	// FetchFeed downloads and parses a feed, sending the validators of the last fetch
+	FetchFeed(feedURL, etag, lastModified string) (*FeedResponse, error)
+	// CheckLink requests a URL and reports its status and the redirects it went through
+	CheckLink(url string) *LinkCheck
 	// SaveArticle saves the parsed content to filesystem and returns file paths
 	SaveArticle(content *ParsedContent, storageDir string) (markdownPath, htmlPath string, err error)
+	// SaveArticleWithOptions saves the parsed content, optionally archiving its images and media
+	SaveArticleWithOptions(content *ParsedContent, storageDir string, opts SaveOptions) (*SavedArticle, error)
 }
 
 // ArticleParser implements the Parser interface
@@ -68,6 +117,8 @@ type ArticleParser struct {
 	client            *http.Client
 	heuristicExtract  *HeuristicExtractor
 	metadataExtractor *MetadataExtractor
+	arxivAPI          string
+	crossrefAPI       string
 }
 
 // NewArticleParser creates a new ArticleParser with the specified HTTP client and loaded rules
@@ -77,6 +128,8 @@ func NewArticleParser(client *http.Client) (*ArticleParser, error) {
 		client:            client,
 		heuristicExtract:  NewHeuristicExtractor(),
 		metadataExtractor: NewMetadataExtractor(),
+		arxivAPI:          DefaultArxivAPI,
+		crossrefAPI:       DefaultCrossrefAPI,
 	}
 
 	if err := parser.loadRules(); err != nil {
@@ -88,6 +141,9 @@ func NewArticleParser(client *http.Client) (*ArticleParser, error) {
 
 // AddRule adds or replaces a parsing rule for a specific domain
 func (p *ArticleParser) AddRule(domain string, rule *ParsingRule) {
+	if rule.Domain == "" {
+		rule.Domain = domain
+	}
 	p.rules[domain] = rule
 }
 
@@ -97,19 +153,41 @@ func (p *ArticleParser) SetHTTPClient(client *http.Client) {
 }
 
 func (p *ArticleParser) loadRules() error {
-	entries, err := rulesFS.ReadDir("rules")
+	rules, err := fs.Sub(rulesFS, "rules")
+	if err != nil {
+		return fmt.Errorf("failed to read rules directory: %w", err)
+	}
+	return p.loadRulesFS(rules, func(name string) string { return RuleSourceEmbedded })
+}
+
+// LoadRulesDir loads the rule files in dir, replacing loaded rules for the same domains.
+// A missing directory is not an error.
+func (p *ArticleParser) LoadRulesDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return p.loadRulesFS(os.DirFS(dir), func(name string) string { return filepath.Join(dir, name) })
+}
+
+// loadRulesFS parses every <domain>.txt file at the root of fsys, recording where each rule came from
+func (p *ArticleParser) loadRulesFS(fsys fs.FS, source func(name string) string) error {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return fmt.Errorf("failed to read rules directory: %w", err)
 	}
 
 	for _, entry := range entries {
-		if !strings.HasSuffix(entry.Name(), ".txt") {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txt") {
 			continue
 		}
 
+		// vendored site configs ship files such as LICENSE.txt beside the rules
 		domain := strings.TrimSuffix(entry.Name(), ".txt")
+		if !strings.Contains(domain, ".") {
+			continue
+		}
 
-		content, err := rulesFS.ReadFile(filepath.Join("rules", entry.Name()))
+		content, err := fs.ReadFile(fsys, entry.Name())
 		if err != nil {
 			return fmt.Errorf("failed to read rule file %s: %w", entry.Name(), err)
 		}
@@ -118,6 +196,7 @@ func (p *ArticleParser) loadRules() error {
 		if err != nil {
 			return fmt.Errorf("failed to parse rule file %s: %w", entry.Name(), err)
 		}
+		rule.Source = source(entry.Name())
 
 		p.rules[domain] = rule
 	}
@@ -127,6 +206,7 @@ func (p *ArticleParser) loadRules() error {
 
 func (p *ArticleParser) parseRules(domain, content string) (*ParsingRule, error) {
 	rule := &ParsingRule{Domain: domain, Strip: []string{}}
+	var finds, replaces []string
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -135,6 +215,17 @@ func (p *ArticleParser) parseRules(domain, content string) (*ParsingRule, error)
 			continue
 		}
 
+		// replace_string(<find>): <replace> may have colons inside the parentheses
+		if strings.HasPrefix(line, "replace_string(") {
+			if end := strings.Index(line, "):"); end > 0 {
+				rule.Replacements = append(rule.Replacements, StringReplacement{
+					Find:    line[len("replace_string("):end],
+					Replace: strings.TrimSpace(line[end+2:]),
+				})
+			}
+			continue
+		}
+
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) != 2 {
 			continue
@@ -151,11 +242,29 @@ func (p *ArticleParser) parseRules(domain, content string) (*ParsingRule, error)
 		case "date":
 			rule.Date = value
 		case "body":
-			rule.Body = value
+			if rule.Body == "" {
+				rule.Body = value
+			} else {
+				rule.BodyAlternatives = append(rule.BodyAlternatives, value)
+			}
 		case "strip":
 			rule.Strip = append(rule.Strip, value)
 		case "strip_id_or_class":
 			rule.StripIDsOrClasses = append(rule.StripIDsOrClasses, value)
+		case "strip_image_src":
+			rule.StripImageSrc = append(rule.StripImageSrc, value)
+		case "single_page_link":
+			rule.SinglePageLinks = append(rule.SinglePageLinks, value)
+		case "next_page_link":
+			rule.NextPageLinks = append(rule.NextPageLinks, value)
+		case "native_ad_clue":
+			rule.NativeAdClues = append(rule.NativeAdClues, value)
+		case "find_string":
+			finds = append(finds, value)
+		case "replace_string":
+			replaces = append(replaces, value)
+		case "autodetect_on_failure":
+			rule.DisableAutodetect = !parseBool(value)
 		case "prune":
 			rule.Prune = parseBool(value)
 		case "tidy":
@@ -179,6 +288,13 @@ func (p *ArticleParser) parseRules(domain, content string) (*ParsingRule, error)
 		return nil, fmt.Errorf("error reading rule file: %w", err)
 	}
 
+	// find_string and replace_string lines pair up in order; unmatched counts mean a broken rule, so neither applies
+	if len(finds) == len(replaces) {
+		for i := range finds {
+			rule.Replacements = append(rule.Replacements, StringReplacement{Find: finds[i], Replace: replaces[i]})
+		}
+	}
+
 	return rule, nil
 }
 
@@ -191,28 +307,70 @@ func parseBool(value string) bool {
 	}
 }
 
+// findRule returns the rule for a host: a rule named after the host itself, with or without
+// a leading "www.", or a wildcard rule for one of its parent domains (".example.com").
 func (p *ArticleParser) findRule(domain string) *ParsingRule {
-	for ruleDomain, rule := range p.rules {
-		if domain == ruleDomain || strings.HasSuffix(domain, ruleDomain) {
+	domain = strings.ToLower(domain)
+	if rule, ok := p.rules[domain]; ok {
+		return rule
+	}
+
+	host := strings.TrimPrefix(domain, "www.")
+	if rule, ok := p.rules[host]; ok {
+		return rule
+	}
+
+	for parent := strings.TrimPrefix(host, "."); strings.Contains(parent, "."); {
+		if rule, ok := p.rules["."+parent]; ok {
 			return rule
 		}
+		parent = parent[strings.Index(parent, ".")+1:]
 	}
 	return nil
 }
 
-// ParseURL extracts article content from a given URL
+// ParseURL extracts article content from a given URL.
+//
+// arXiv and DOI links are looked up through the arXiv API and Crossref for the paper's citation,
+// abstract and, when a PDF is available, full text. Other URLs that serve a PDF have its text
+// extracted.
 func (p *ArticleParser) ParseURL(s string) (*ParsedContent, error) {
 	parsedURL, err := url.Parse(s)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
+	switch kind, id := paperReference(parsedURL); kind {
+	case "arxiv":
+		return p.parseArxiv(id)
+	case "doi":
+		return p.parseDOI(id)
+	}
+
 	domain := parsedURL.Hostname()
-	rule := p.findRule(domain)
-	req, err := http.NewRequest(http.MethodGet, s, nil)
+	data, contentType, err := p.fetchBytes(s, p.findRule(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	if IsPDF(contentType, data) {
+		return p.parsePDF(data, s)
+	}
+	return p.Parse(string(data), domain, s)
+}
+
+// fetch downloads a page, sending the rule's http_header values
+func (p *ArticleParser) fetch(s string, rule *ParsingRule) (string, error) {
+	data, _, err := p.fetchBytes(s, rule)
+	return string(data), err
+}
 
+// fetchBytes downloads a URL, sending the rule's http_header values, and returns the body with
+// its content type
+func (p *ArticleParser) fetchBytes(s string, rule *ParsingRule) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, s, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if rule != nil {
@@ -228,44 +386,103 @@ func (p *ArticleParser) ParseURL(s string) (*ParsedContent, error) {
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
 
-	htmlBytes, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return p.Parse(string(htmlBytes), domain, s)
+	return data, resp.Header.Get("Content-Type"), nil
 }
 
 // ParseHTML extracts article content from HTML string using domain-specific rules with heuristic fallback.
 // Implements dual validation: compares XPath results with heuristic extraction when rules exist.
+// The chosen body element is converted to markdown, keeping its headings, links, lists, code,
+// tables, emphasis and images, with relative URLs resolved against sourceURL.
+//
+// When the parser has an HTTP client, a rule's single_page_link is followed to the whole
+// article and its next_page_link is followed page by page, appending each page's body.
 func (p *ArticleParser) Parse(htmlContent, domain, sourceURL string) (*ParsedContent, error) {
-	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	content, err := p.parse(htmlContent, domain, sourceURL, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, err
 	}
+	content.WordCount, content.ReadingTime = readingStats(content.Content)
+	return content, nil
+}
+
+// ParseOffline extracts article content from HTML like [ArticleParser.Parse], but never follows a
+// rule's single_page_link or next_page_link, so only the given page is read
+func (p *ArticleParser) ParseOffline(htmlContent, domain, sourceURL string) (*ParsedContent, error) {
+	offline := *p
+	offline.client = nil
+	return offline.Parse(htmlContent, domain, sourceURL)
+}
+
+// wordsPerMinute is the reading speed reading time estimates assume
+const wordsPerMinute = 230
 
+// linkTarget matches the target of a markdown link or image
+var linkTarget = regexp.MustCompile(`\]\([^)]*\)`)
+
+// readingStats counts the words in markdown and estimates the minutes needed to read them, rounding up.
+// Link targets and tokens without letters or digits, such as list markers and fences, are not words.
+func readingStats(markdown string) (words, minutes int) {
+	for _, field := range strings.Fields(linkTarget.ReplaceAllString(markdown, "] ")) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	if words == 0 {
+		return 0, 0
+	}
+	return words, (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+func (p *ArticleParser) parse(htmlContent, domain, sourceURL string, followSinglePage bool) (*ParsedContent, error) {
 	rule := p.findRule(domain)
+	if rule != nil {
+		htmlContent = rule.replaceStrings(htmlContent)
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
 
 	if rule == nil {
 		return p.parseWithHeuristics(doc, sourceURL)
 	}
 
+	if followSinglePage && p.client != nil {
+		if link := rule.pageLink(doc, rule.SinglePageLinks, sourceURL); link != "" && link != sourceURL {
+			if page, err := p.fetch(link, rule); err == nil {
+				if content, err := p.parse(page, domain, link, false); err == nil {
+					content.URL = sourceURL
+					return content, nil
+				}
+			}
+		}
+	}
+
 	content := &ParsedContent{
 		URL:              sourceURL,
 		ExtractionMethod: "xpath",
 		Confidence:       0.85,
+		Pages:            1,
+		Rule:             rule.Domain,
+		NativeAd:         rule.isNativeAd(doc),
 	}
 
 	if rule.Title != "" {
-		if titleNode := htmlquery.FindOne(doc, rule.Title); titleNode != nil {
+		if titleNode := queryOne(doc, rule.Title); titleNode != nil {
 			content.Title = strings.TrimSpace(htmlquery.InnerText(titleNode))
 		}
 	}
@@ -274,7 +491,7 @@ func (p *ArticleParser) Parse(htmlContent, domain, sourceURL string) (*ParsedCon
 	}
 
 	if rule.Author != "" {
-		if authorNode := htmlquery.FindOne(doc, rule.Author); authorNode != nil {
+		if authorNode := queryOne(doc, rule.Author); authorNode != nil {
 			content.Author = strings.TrimSpace(htmlquery.InnerText(authorNode))
 		}
 	}
@@ -283,7 +500,7 @@ func (p *ArticleParser) Parse(htmlContent, domain, sourceURL string) (*ParsedCon
 	}
 
 	if rule.Date != "" {
-		if dateNode := htmlquery.FindOne(doc, rule.Date); dateNode != nil {
+		if dateNode := queryOne(doc, rule.Date); dateNode != nil {
 			content.Date = strings.TrimSpace(htmlquery.InnerText(dateNode))
 		}
 	}
@@ -292,33 +509,39 @@ func (p *ArticleParser) Parse(htmlContent, domain, sourceURL string) (*ParsedCon
 	}
 
 	if rule.Body != "" {
-		bodyNode := htmlquery.FindOne(doc, rule.Body)
+		bodyNode := rule.extractBody(doc)
 		if bodyNode == nil {
-			return p.parseWithHeuristics(doc, sourceURL)
-		}
-
-		for _, stripXPath := range rule.Strip {
-			removeNodesByXPath(bodyNode, stripXPath)
-		}
-
-		for _, identifier := range rule.StripIDsOrClasses {
-			removeNodesByIdentifier(bodyNode, identifier)
+			if rule.DisableAutodetect {
+				return nil, fmt.Errorf("could not find the article body using the rule for %s", rule.Domain)
+			}
+			fallback, err := p.parseWithHeuristics(doc, sourceURL)
+			if err != nil {
+				return nil, err
+			}
+			fallback.NativeAd = content.NativeAd
+			return fallback, nil
 		}
 
-		removeDefaultNonContentNodes(bodyNode)
-
-		xpathContent := normalizeWhitespace(htmlquery.InnerText(bodyNode))
-
+		contentNode := bodyNode
 		heuristicResult := p.heuristicExtract.CompareWithXPath(doc, bodyNode)
 		if heuristicResult != nil {
-			content.Content = heuristicResult.Content
-			if content.Content == "" {
-				content.Content = xpathContent
+			if heuristicResult.Content != "" && heuristicResult.Node != nil {
+				contentNode = heuristicResult.Node
 			}
 			content.Confidence = heuristicResult.Confidence
 			content.ExtractionMethod = heuristicResult.ExtractionMethod
-		} else {
-			content.Content = xpathContent
+		}
+
+		content.Content = convertToMarkdown(contentNode, sourceURL)
+		if content.Content == "" {
+			content.Content = normalizeWhitespace(htmlquery.InnerText(bodyNode))
+		}
+
+		if p.client != nil {
+			for _, page := range p.nextPages(doc, rule, sourceURL) {
+				content.Content += "\n\n" + page
+				content.Pages++
+			}
 		}
 	}
 
@@ -329,6 +552,39 @@ func (p *ArticleParser) Parse(htmlContent, domain, sourceURL string) (*ParsedCon
 	return content, nil
 }
 
+// nextPages follows the rule's next_page_link from the first page of an article and returns the body
+// of each following page as markdown. It stops at [maxArticlePages], at a page it has already seen, or
+// at the first page that can't be fetched or has no body, keeping the pages collected so far.
+func (p *ArticleParser) nextPages(doc *exhtml.Node, rule *ParsingRule, pageURL string) []string {
+	seen := map[string]bool{pageURL: true}
+	var pages []string
+	for len(pages) < maxArticlePages-1 {
+		next := rule.pageLink(doc, rule.NextPageLinks, pageURL)
+		if next == "" || seen[next] {
+			break
+		}
+		seen[next] = true
+
+		page, err := p.fetch(next, rule)
+		if err != nil {
+			break
+		}
+		if doc, err = htmlquery.Parse(strings.NewReader(rule.replaceStrings(page))); err != nil {
+			break
+		}
+
+		body := rule.extractBody(doc)
+		if body == nil {
+			break
+		}
+		if md := convertToMarkdown(body, next); md != "" {
+			pages = append(pages, md)
+		}
+		pageURL = next
+	}
+	return pages
+}
+
 // parseWithHeuristics performs heuristic-only extraction when no XPath rule exists.
 func (p *ArticleParser) parseWithHeuristics(doc *exhtml.Node, sourceURL string) (*ParsedContent, error) {
 	result := p.heuristicExtract.ExtractWithSemanticHTML(doc)
@@ -352,14 +608,22 @@ func (p *ArticleParser) parseWithHeuristics(doc *exhtml.Node, sourceURL string)
 		}
 	}
 
+	body := result.Content
+	if result.Node != nil {
+		if md := convertToMarkdown(result.Node, sourceURL); md != "" {
+			body = md
+		}
+	}
+
 	content := &ParsedContent{
 		Title:            result.Title,
 		Author:           result.Author,
 		Date:             result.PublishedDate,
-		Content:          result.Content,
+		Content:          body,
 		URL:              sourceURL,
 		Confidence:       result.Confidence,
 		ExtractionMethod: result.ExtractionMethod,
+		Pages:            1,
 	}
 
 	if content.Title == "" {
@@ -383,7 +647,10 @@ func removeNodesByXPath(root *exhtml.Node, xpath string) {
 		return
 	}
 
-	nodes := htmlquery.Find(root, xpath)
+	nodes, err := htmlquery.QueryAll(root, xpath)
+	if err != nil {
+		return
+	}
 	for _, node := range nodes {
 		if node != nil && node.Parent != nil {
 			node.Parent.RemoveChild(node)
@@ -391,6 +658,15 @@ func removeNodesByXPath(root *exhtml.Node, xpath string) {
 	}
 }
 
+// queryOne is [htmlquery.FindOne] for XPaths from rule files, which may not compile: those match nothing
+func queryOne(root *exhtml.Node, xpath string) *exhtml.Node {
+	node, err := htmlquery.Query(root, xpath)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
 func removeNodesByIdentifier(root *exhtml.Node, identifier string) {
 	identifier = strings.TrimSpace(identifier)
 	if root == nil || identifier == "" {
@@ -468,10 +744,47 @@ func (p *ArticleParser) GetSupportedDomains() []string {
 	return domains
 }
 
+// Rules returns the loaded parsing rules sorted by domain
+func (p *ArticleParser) Rules() []*ParsingRule {
+	rules := make([]*ParsingRule, 0, len(p.rules))
+	for _, domain := range slices.Sorted(maps.Keys(p.rules)) {
+		rules = append(rules, p.rules[domain])
+	}
+	return rules
+}
+
+// Rule returns the rule named domain, or the rule that applies to the host domain when none is named so
+func (p *ArticleParser) Rule(domain string) *ParsingRule {
+	if rule, ok := p.rules[domain]; ok {
+		return rule
+	}
+	return p.findRule(domain)
+}
+
+// FetchHTML downloads a page with the headers of the rule for its host
+func (p *ArticleParser) FetchHTML(s string) (string, error) {
+	parsedURL, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	return p.fetch(s, p.findRule(parsedURL.Hostname()))
+}
+
 // SaveArticle saves the parsed content to filesystem and returns file paths
 func (p *ArticleParser) SaveArticle(content *ParsedContent, dir string) (markdownPath, htmlPath string, err error) {
+	saved, err := p.SaveArticleWithOptions(content, dir, SaveOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	return saved.MarkdownPath, saved.HTMLPath, nil
+}
+
+// SaveArticleWithOptions saves the parsed content to the filesystem, archiving its images and
+// media for offline reading when opts.Archive is set. Assets that fail to download are left
+// pointing at the site and listed in [SavedArticle.Failed].
+func (p *ArticleParser) SaveArticleWithOptions(content *ParsedContent, dir string, opts SaveOptions) (*SavedArticle, error) {
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", "", fmt.Errorf("failed to create storage directory: %w", err)
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
 
 	slug := p.slugify(content.Title)
@@ -481,14 +794,16 @@ func (p *ArticleParser) SaveArticle(content *ParsedContent, dir string) (markdow
 
 	baseMarkdownPath := filepath.Join(dir, slug+".md")
 	baseHTMLPath := filepath.Join(dir, slug+".html")
-	markdownPath = baseMarkdownPath
-	htmlPath = baseHTMLPath
+	markdownPath := baseMarkdownPath
+	htmlPath := baseHTMLPath
 
 	counter := 1
 	for {
 		if _, err := os.Stat(markdownPath); os.IsNotExist(err) {
 			if _, err := os.Stat(htmlPath); os.IsNotExist(err) {
-				break
+				if _, err := os.Stat(AssetDir(markdownPath)); os.IsNotExist(err) {
+					break
+				}
 			}
 		}
 		markdownPath = filepath.Join(dir, fmt.Sprintf("%s_%d.md", slug, counter))
@@ -496,20 +811,36 @@ func (p *ArticleParser) SaveArticle(content *ParsedContent, dir string) (markdow
 		counter++
 	}
 
+	saved := &SavedArticle{MarkdownPath: markdownPath, HTMLPath: htmlPath}
 	markdownContent := p.createMarkdown(content)
+	htmlMarkdown := markdownContent
+
+	if opts.Archive && p.client != nil {
+		archived, dataURIs, err := p.archiveAssets(markdownContent, AssetDir(markdownPath), saved)
+		if err != nil {
+			os.RemoveAll(AssetDir(markdownPath))
+			return nil, err
+		}
+		markdownContent, htmlMarkdown = archived, archived
+		if opts.SelfContained {
+			htmlMarkdown = rewriteDestinations(archived, dataURIs)
+		}
+	}
 
 	if err := os.WriteFile(markdownPath, []byte(markdownContent), 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write markdown file: %w", err)
+		os.RemoveAll(AssetDir(markdownPath))
+		return nil, fmt.Errorf("failed to write markdown file: %w", err)
 	}
 
-	htmlContent := p.createHTML(content, markdownContent)
+	htmlContent := p.createHTML(content, htmlMarkdown)
 
 	if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
 		os.Remove(markdownPath)
-		return "", "", fmt.Errorf("failed to write HTML file: %w", err)
+		os.RemoveAll(AssetDir(markdownPath))
+		return nil, fmt.Errorf("failed to write HTML file: %w", err)
 	}
 
-	return markdownPath, htmlPath, nil
+	return saved, nil
 }
 
 func (p *ArticleParser) slugify(title string) string {
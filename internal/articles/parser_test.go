@@ -380,6 +380,31 @@ body: //article
 			}
 		})
 
+		t.Run("counts words and estimates reading time", func(t *testing.T) {
+			htmlContent := `<html>
+			<head><title>Reading Time Test</title></head>
+			<body>
+				<h1 id="firstHeading">Reading Time Test</h1>
+				<div id="bodyContent">
+					<p>` + strings.Repeat("word ", 500) + `</p>
+					<p>A <a href="https://example.com/a/very/long/link">link</a> counts once.</p>
+				</div>
+			</body>
+		</html>`
+
+			content, err := parser.Parse(htmlContent, ".wikipedia.org", "https://en.wikipedia.org/wiki/Reading_Time")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if content.WordCount != 504 {
+				t.Errorf("Expected 504 words, got %d", content.WordCount)
+			}
+			if content.ReadingTime != 3 {
+				t.Errorf("Expected a 3 minute read, got %d", content.ReadingTime)
+			}
+		})
+
 		t.Run("falls back to metadata extractor when XPath fails", func(t *testing.T) {
 			htmlContent := `<html><head>
 				<title>Metadata Fallback Test</title>
@@ -0,0 +1,1126 @@
+package articles
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFDocument is the text and document information of a PDF
+type PDFDocument struct {
+	Title   string
+	Author  string
+	Subject string
+	Created time.Time
+	Pages   []string // text of each page, paragraphs separated by blank lines
+}
+
+// Text returns the text of every page, paragraphs separated by blank lines
+func (d *PDFDocument) Text() string {
+	var pages []string
+	for _, page := range d.Pages {
+		if page != "" {
+			pages = append(pages, page)
+		}
+	}
+	return strings.Join(pages, "\n\n")
+}
+
+// IsPDF reports whether a response is a PDF, by its content type or its leading bytes
+func IsPDF(contentType string, data []byte) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), "application/pdf") ||
+		bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-"))
+}
+
+// ExtractPDF reads the text and document information of a PDF.
+//
+// It reads the objects directly rather than trusting the cross-reference table, so damaged or
+// incrementally updated files still open, and decodes Flate, ASCIIHex and ASCII85 streams,
+// object streams, ToUnicode CMaps and simple font encodings with Differences. Encrypted
+// files and text drawn as images (scanned pages) are not supported.
+func ExtractPDF(data []byte) (doc *PDFDocument, err error) {
+	// the parser bounds-checks what it reads from the file; this keeps a missed case in a
+	// downloaded PDF from taking the whole command down
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	file := &pdfFile{objects: make(map[int]any)}
+	file.load(data)
+	if file.trailer[pdfName("Encrypt")] != nil {
+		return nil, fmt.Errorf("encrypted PDFs are not supported")
+	}
+
+	doc = &PDFDocument{}
+	if info, ok := file.resolve(file.trailer[pdfName("Info")]).(pdfDict); ok {
+		doc.Title = collapseSpace(file.text(info["Title"]))
+		doc.Author = collapseSpace(file.text(info["Author"]))
+		doc.Subject = collapseSpace(file.text(info["Subject"]))
+		doc.Created = parsePDFDate(file.text(info["CreationDate"]))
+	}
+
+	for _, page := range file.pages() {
+		doc.Pages = append(doc.Pages, file.pageText(page))
+	}
+	if strings.TrimSpace(doc.Text()) == "" {
+		return doc, fmt.Errorf("no text found in PDF; scanned documents are not supported")
+	}
+	return doc, nil
+}
+
+type (
+	pdfName   string
+	pdfString string
+	pdfDict   map[pdfName]any
+	pdfArray  []any
+	pdfRef    struct{ num, gen int }
+	pdfStream struct {
+		dict pdfDict
+		raw  []byte
+	}
+	pdfKeyword string
+)
+
+// pdfFile holds the objects of a PDF by object number
+type pdfFile struct {
+	objects map[int]any
+	trailer pdfDict
+}
+
+var pdfObjectStart = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+const (
+	maxPDFNesting    = 256      // deepest array or dictionary nesting read
+	maxPDFStreamSize = 64 << 20 // largest decoded stream kept
+)
+
+// pdfIndex converts a number read from the file to an index in [0, limit],
+// rejecting negative, fractional and overflowing values
+func pdfIndex(value any, limit int) (int, bool) {
+	n, ok := value.(float64)
+	if !ok || n < 0 || n > float64(limit) || n != math.Trunc(n) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// load collects every "n g obj ... endobj" in data, then the objects packed into object streams.
+// Later definitions win, as they do for incremental updates.
+func (f *pdfFile) load(data []byte) {
+	f.trailer = pdfDict{}
+	var objectStreams []*pdfStream
+
+	for pos := 0; pos < len(data); {
+		loc := pdfObjectStart.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		lex := &pdfLexer{data: data, pos: pos + loc[1]}
+		value := lex.value()
+
+		if dict, ok := value.(pdfDict); ok && lex.nextKeyword("stream") {
+			stream := &pdfStream{dict: dict, raw: lex.streamData(dict)}
+			value = stream
+			switch dict["Type"] {
+			case pdfName("ObjStm"):
+				objectStreams = append(objectStreams, stream)
+			case pdfName("XRef"):
+				f.mergeTrailer(dict)
+			}
+		}
+		f.objects[num] = value
+		pos = max(lex.pos, pos+loc[1])
+	}
+
+	for _, stream := range objectStreams {
+		f.loadObjectStream(stream)
+	}
+
+	for i := 0; ; {
+		j := bytes.Index(data[i:], []byte("trailer"))
+		if j < 0 {
+			break
+		}
+		lex := &pdfLexer{data: data, pos: i + j + len("trailer")}
+		if dict, ok := lex.value().(pdfDict); ok {
+			f.mergeTrailer(dict)
+		}
+		i += j + len("trailer")
+	}
+}
+
+func (f *pdfFile) mergeTrailer(dict pdfDict) {
+	for _, key := range []pdfName{"Root", "Info", "Encrypt"} {
+		if value, ok := dict[key]; ok {
+			f.trailer[key] = value
+		}
+	}
+}
+
+// loadObjectStream adds the objects packed in an object stream that are not defined directly
+func (f *pdfFile) loadObjectStream(stream *pdfStream) {
+	data, err := f.decode(stream)
+	if err != nil {
+		return
+	}
+	first, ok := pdfIndex(f.resolve(stream.dict["First"]), len(data))
+	if !ok {
+		return
+	}
+	// every header entry takes several bytes, so /N can never exceed /First
+	n, ok := pdfIndex(f.resolve(stream.dict["N"]), first)
+	if !ok {
+		return
+	}
+
+	header := &pdfLexer{data: data[:first]}
+	for range n {
+		num, ok1 := pdfIndex(header.value(), math.MaxInt32)
+		offset, ok2 := pdfIndex(header.value(), len(data)-first)
+		if !ok1 || !ok2 {
+			return
+		}
+		if _, defined := f.objects[num]; defined {
+			continue
+		}
+		if start := first + offset; start < len(data) {
+			lex := &pdfLexer{data: data, pos: start}
+			f.objects[num] = lex.value()
+		}
+	}
+}
+
+// resolve follows indirect references, giving up on cycles
+func (f *pdfFile) resolve(value any) any {
+	for range 32 {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value = f.objects[ref.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(value any) pdfDict {
+	switch v := f.resolve(value).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// text decodes a PDF text string, which is UTF-16BE when it starts with a byte order mark
+func (f *pdfFile) text(value any) string {
+	s, ok := f.resolve(value).(pdfString)
+	if !ok {
+		return ""
+	}
+	if b := []byte(s); len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	return decodeSimple([]byte(s), nil)
+}
+
+// decode applies a stream's filters
+func (f *pdfFile) decode(stream *pdfStream) ([]byte, error) {
+	var filters []pdfName
+	switch filter := f.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = append(filters, filter)
+	case pdfArray:
+		for _, name := range filter {
+			if name, ok := f.resolve(name).(pdfName); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	data := stream.raw
+	for _, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			err = fmt.Errorf("unsupported filter %s", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data, keeping what could be read from truncated or damaged streams
+func inflate(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxPDFStreamSize))
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	return out, nil
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, b := range data {
+		if b == '>' {
+			break
+		}
+		if isHexDigit(b) {
+			digits = append(digits, b)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	return hex.DecodeString(string(digits))
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pages returns the page dictionaries in document order, with inherited resources filled in
+func (f *pdfFile) pages() []pdfDict {
+	var pages []pdfDict
+	seen := map[any]bool{}
+
+	var walk func(node any, resources any)
+	walk = func(node any, resources any) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		dict := f.dict(node)
+		if dict == nil {
+			return
+		}
+		if r, ok := dict["Resources"]; ok {
+			resources = r
+		}
+		if dict["Type"] == pdfName("Page") || dict["Kids"] == nil {
+			page := pdfDict{"Contents": dict["Contents"], "Resources": resources}
+			pages = append(pages, page)
+			return
+		}
+		if kids, ok := f.resolve(dict["Kids"]).(pdfArray); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+		}
+	}
+
+	if root := f.dict(f.trailer["Root"]); root != nil {
+		walk(root["Pages"], nil)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// without a usable page tree, take every page object in object number order
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	for _, num := range nums {
+		if dict := f.dict(f.objects[num]); dict != nil && dict["Type"] == pdfName("Page") {
+			pages = append(pages, dict)
+		}
+	}
+	return pages
+}
+
+// pageText runs a page's content streams and returns its text
+func (f *pdfFile) pageText(page pdfDict) string {
+	var content []byte
+	contents := f.resolve(page["Contents"])
+	if array, ok := contents.(pdfArray); ok {
+		for _, part := range array {
+			if stream, ok := f.resolve(part).(*pdfStream); ok {
+				if data, err := f.decode(stream); err == nil {
+					content = append(append(content, data...), '\n')
+				}
+			}
+		}
+	} else if stream, ok := contents.(*pdfStream); ok {
+		content, _ = f.decode(stream)
+	}
+
+	fonts := map[pdfName]*pdfFont{}
+	if resources := f.dict(page["Resources"]); resources != nil {
+		for name, font := range f.dict(resources["Font"]) {
+			fonts[name] = f.font(font)
+		}
+	}
+
+	w := &pdfTextWriter{}
+	w.run(content, fonts)
+	return w.String()
+}
+
+// pdfFont maps the character codes of a font to text
+type pdfFont struct {
+	codeLength int               // bytes per character code
+	toUnicode  map[uint32]string // from the ToUnicode CMap
+	encoding   map[byte]string   // Differences of a simple font's encoding
+}
+
+func (f *pdfFile) font(value any) *pdfFont {
+	dict := f.dict(value)
+	font := &pdfFont{codeLength: 1}
+	if dict == nil {
+		return font
+	}
+	if dict["Subtype"] == pdfName("Type0") {
+		font.codeLength = 2
+	}
+
+	if stream, ok := f.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decode(stream); err == nil {
+			font.toUnicode, font.codeLength = parseCMap(data, font.codeLength)
+		}
+	}
+
+	if encoding := f.dict(dict["Encoding"]); encoding != nil {
+		if differences, ok := f.resolve(encoding["Differences"]).(pdfArray); ok {
+			font.encoding = map[byte]string{}
+			code := 0
+			for _, item := range differences {
+				switch v := f.resolve(item).(type) {
+				case float64:
+					code = int(v)
+				case pdfName:
+					if code >= 0 && code < 256 {
+						if text, ok := glyphText(string(v)); ok {
+							font.encoding[byte(code)] = text
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+	return font
+}
+
+// decode converts a shown string to text
+func (font *pdfFont) decode(s []byte) string {
+	if font == nil {
+		return decodeSimple(s, nil)
+	}
+	if font.toUnicode == nil {
+		if font.codeLength == 2 {
+			return "" // composite fonts without a ToUnicode map cannot be read
+		}
+		return decodeSimple(s, font.encoding)
+	}
+
+	var b strings.Builder
+	for i := 0; i+font.codeLength <= len(s); i += font.codeLength {
+		var code uint32
+		for _, c := range s[i : i+font.codeLength] {
+			code = code<<8 | uint32(c)
+		}
+		if text, ok := font.toUnicode[code]; ok {
+			b.WriteString(text)
+		} else if font.codeLength == 1 {
+			b.WriteString(decodeSimple(s[i:i+1], font.encoding))
+		}
+	}
+	return b.String()
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseCMap(data []byte, codeLength int) (map[uint32]string, int) {
+	mapping := map[uint32]string{}
+	lex := &pdfLexer{data: data}
+	var operands []any
+
+	for {
+		token := lex.value()
+		if token == nil && lex.pos >= len(data) {
+			break
+		}
+		keyword, ok := token.(pdfKeyword)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch keyword {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].(pdfString); ok && len(lo) > 0 {
+					codeLength = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					mapping[cmapCode(src)] = utf16BE([]byte(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := cmapCode(lo), cmapCode(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []byte(dst)
+					for code := start; code <= end; code++ {
+						mapping[code] = utf16BE(addToLastByte(base, int(code-start)))
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && start+uint32(j) <= end {
+							mapping[start+uint32(j)] = utf16BE([]byte(s))
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return mapping, codeLength
+}
+
+func cmapCode(s pdfString) uint32 {
+	var code uint32
+	for _, c := range []byte(s) {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+func addToLastByte(b []byte, n int) []byte {
+	out := slices.Clone(b)
+	for i := len(out) - 1; i >= 0 && n > 0; i-- {
+		sum := int(out[i]) + n
+		out[i] = byte(sum)
+		n = sum >> 8
+	}
+	return out
+}
+
+func utf16BE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// winAnsiHigh maps the WinAnsiEncoding codes 0x80-0x9F, which differ from Latin-1
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰',
+	0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// decodeSimple decodes single byte codes as WinAnsiEncoding, with differences taking precedence
+func decodeSimple(s []byte, differences map[byte]string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if text, ok := differences[c]; ok {
+			b.WriteString(text)
+		} else if r, ok := winAnsiHigh[c]; ok {
+			b.WriteRune(r)
+		} else if c >= 0x20 || c == '\t' || c == '\n' {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// glyphNames maps the glyph names common in font encodings to their text
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$", "percent": "%",
+	"ampersand": "&", "quotesingle": "'", "quoteright": "’", "quoteleft": "‘", "parenleft": "(",
+	"parenright": ")", "asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "period": ".",
+	"slash": "/", "colon": ":", "semicolon": ";", "less": "<", "equal": "=", "greater": ">",
+	"question": "?", "at": "@", "bracketleft": "[", "backslash": "\\", "bracketright": "]",
+	"asciicircum": "^", "underscore": "_", "grave": "`", "braceleft": "{", "bar": "|",
+	"braceright": "}", "asciitilde": "~", "quotedblleft": "“", "quotedblright": "”",
+	"quotesinglbase": "‚", "quotedblbase": "„", "endash": "–", "emdash": "—", "bullet": "•",
+	"ellipsis": "…", "dagger": "†", "daggerdbl": "‡", "section": "§", "paragraph": "¶",
+	"degree": "°", "copyright": "©", "registered": "®", "trademark": "™", "minus": "−",
+	"multiply": "×", "divide": "÷", "periodcentered": "·", "fi": "fi", "fl": "fl", "ff": "ff",
+	"ffi": "ffi", "ffl": "ffl", "dotlessi": "ı", "germandbls": "ß", "ae": "æ", "AE": "Æ",
+	"oe": "œ", "OE": "Œ", "oslash": "ø", "Oslash": "Ø", "zero": "0", "one": "1", "two": "2",
+	"three": "3", "four": "4", "five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+}
+
+// glyphText returns the text of a glyph name: a letter, a name from [glyphNames], or uniXXXX
+func glyphText(name string) (string, bool) {
+	if len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return name, true
+	}
+	if text, ok := glyphNames[name]; ok {
+		return text, true
+	}
+	for _, prefix := range []string{"uni", "u"} {
+		if code, ok := strings.CutPrefix(name, prefix); ok && len(code) >= 4 && len(code) <= 6 {
+			if r, err := strconv.ParseUint(code, 16, 32); err == nil {
+				return string(rune(r)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// pdfTextWriter turns the text operators of a content stream into lines and paragraphs
+type pdfTextWriter struct {
+	paragraphs []string
+	line       strings.Builder
+	lines      []string
+
+	y, lastGap float64 // baseline of the current line and the usual distance between lines
+	scale      float64 // vertical scale of the text matrix
+	leading    float64
+	size       float64 // font size of the text being shown
+	lineSize   float64 // font size of the current line
+	started    bool
+}
+
+func (w *pdfTextWriter) run(content []byte, fonts map[pdfName]*pdfFont) {
+	lex := &pdfLexer{data: content}
+	var operands []any
+	var font *pdfFont
+	w.scale = 1
+
+	for lex.pos < len(content) {
+		token := lex.value()
+		keyword, ok := token.(pdfKeyword)
+		if !ok {
+			if token != nil {
+				operands = append(operands, token)
+			}
+			continue
+		}
+
+		number := func(i int) float64 {
+			if i >= 0 && i < len(operands) {
+				if n, ok := operands[i].(float64); ok {
+					return n
+				}
+			}
+			return 0
+		}
+
+		switch keyword {
+		case "BI":
+			lex.skipInlineImage()
+		case "Tf":
+			if name, ok := firstOf[pdfName](operands); ok {
+				font = fonts[name]
+			}
+			w.size = number(len(operands) - 1)
+		case "TL":
+			w.leading = number(0)
+		case "Td", "TD":
+			if keyword == "TD" {
+				w.leading = -number(1)
+			}
+			w.move(number(0), w.y+number(1)*w.scale)
+		case "Tm":
+			if d := number(3); d != 0 {
+				w.scale = math.Abs(d)
+			}
+			w.move(0, number(5))
+		case "T*":
+			w.move(0, w.y-w.leading*w.scale)
+		case "Tj":
+			w.show(operands, font)
+		case "'":
+			w.move(0, w.y-w.leading*w.scale)
+			w.show(operands, font)
+		case "\"":
+			w.move(0, w.y-w.leading*w.scale)
+			w.show(operands[min(2, len(operands)):], font)
+		case "TJ":
+			if array, ok := firstOf[pdfArray](operands); ok {
+				for _, item := range array {
+					switch v := item.(type) {
+					case pdfString:
+						w.write(font.decode([]byte(v)))
+					case float64:
+						if v < -200 {
+							w.space()
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	w.endParagraph()
+}
+
+func firstOf[T any](operands []any) (T, bool) {
+	for _, operand := range operands {
+		if v, ok := operand.(T); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func (w *pdfTextWriter) show(operands []any, font *pdfFont) {
+	if s, ok := firstOf[pdfString](operands); ok {
+		w.write(font.decode([]byte(s)))
+	}
+}
+
+// move handles a text position change: a new baseline starts a line, or a paragraph when the
+// gap is clearly larger than the usual line spacing, the font size changes, as it does after a
+// heading, or the text jumps back up, as it does at a new column; a move along the same
+// baseline separates words.
+func (w *pdfTextWriter) move(dx, y float64) {
+	if !w.started {
+		w.y, w.started = y, true
+		return
+	}
+
+	gap := w.y - y
+	if math.Abs(gap) < 1 {
+		if dx != 0 {
+			w.space()
+		}
+		return
+	}
+	w.y = y
+
+	if w.line.Len() > 0 && w.textSize() != w.lineSize {
+		w.endParagraph()
+		return
+	}
+	if gap < 0 || (w.lastGap > 0 && gap > w.lastGap*1.4) {
+		w.endParagraph()
+		return
+	}
+	w.lastGap = gap
+	w.endLine()
+}
+
+func (w *pdfTextWriter) write(text string) {
+	if w.line.Len() == 0 {
+		w.lineSize = w.textSize()
+	}
+	w.line.WriteString(text)
+}
+
+// textSize is the font size of shown text in text space, rounded to hide rounding noise
+func (w *pdfTextWriter) textSize() float64 {
+	return math.Round(w.size * w.scale)
+}
+
+func (w *pdfTextWriter) space() {
+	if s := w.line.String(); s != "" && !strings.HasSuffix(s, " ") {
+		w.line.WriteByte(' ')
+	}
+}
+
+func (w *pdfTextWriter) endLine() {
+	if line := collapseSpace(w.line.String()); line != "" {
+		w.lines = append(w.lines, line)
+	}
+	w.line.Reset()
+}
+
+// endParagraph joins the pending lines, rejoining words hyphenated across a line break
+func (w *pdfTextWriter) endParagraph() {
+	w.endLine()
+	if len(w.lines) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	for i, line := range w.lines {
+		if i > 0 {
+			prev := w.lines[i-1]
+			if strings.HasSuffix(prev, "-") && len(prev) > 1 && startsLower(line) {
+				s := b.String()
+				b.Reset()
+				b.WriteString(strings.TrimSuffix(s, "-"))
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	w.paragraphs = append(w.paragraphs, b.String())
+	w.lines = nil
+}
+
+func startsLower(s string) bool {
+	for _, r := range s {
+		return r >= 'a' && r <= 'z'
+	}
+	return false
+}
+
+func (w *pdfTextWriter) String() string {
+	return strings.Join(w.paragraphs, "\n\n")
+}
+
+// parsePDFDate reads a date such as D:20200907120000+02'00'
+func parsePDFDate(value string) time.Time {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	value = strings.ReplaceAll(value, "'", "")
+	for _, layout := range []string{"20060102150405-0700", "20060102150405Z", "20060102150405", "200601021504", "20060102", "200601", "2006"} {
+		n := len(layout)
+		if layout == "20060102150405-0700" {
+			n = len("20060102150405+0000")
+		}
+		if len(value) < n {
+			continue
+		}
+		candidate := value[:n]
+		if layout == "20060102150405Z" && !strings.HasSuffix(candidate, "Z") {
+			continue
+		}
+		if t, err := time.Parse(layout, candidate); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// pdfLexer reads PDF objects and content stream operators
+type pdfLexer struct {
+	data  []byte
+	pos   int
+	depth int // arrays and dictionaries currently open
+}
+
+func isPDFSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == 0
+}
+
+func isPDFDelimiter(b byte) bool {
+	return strings.IndexByte("()<>[]{}/%", b) >= 0
+}
+
+func isHexDigit(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'f' || b >= 'A' && b <= 'F'
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch b := l.data[l.pos]; {
+		case isPDFSpace(b):
+			l.pos++
+		case b == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// nextKeyword consumes keyword when it is the next token
+func (l *pdfLexer) nextKeyword(keyword string) bool {
+	start := l.pos
+	if l.value() == pdfKeyword(keyword) {
+		return true
+	}
+	l.pos = start
+	return false
+}
+
+// value reads the next object, reference or operator; nil at the end of the data
+func (l *pdfLexer) value() any {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	switch b := l.data[l.pos]; {
+	case b == '/':
+		return l.name()
+	case b == '(':
+		return l.literalString()
+	case b == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		if l.depth >= maxPDFNesting {
+			return nil
+		}
+		l.depth++
+		defer func() { l.depth-- }()
+		dict := pdfDict{}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return dict
+			}
+			if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+				l.pos += 2
+				return dict
+			}
+			key, ok := l.value().(pdfName)
+			if !ok {
+				continue
+			}
+			dict[key] = l.value()
+		}
+	case b == '<':
+		return l.hexString()
+	case b == '[':
+		l.pos++
+		if l.depth >= maxPDFNesting {
+			return nil
+		}
+		l.depth++
+		defer func() { l.depth-- }()
+		array := pdfArray{}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return array
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return array
+			}
+			array = append(array, l.value())
+		}
+	case b == '>' || b == ']' || b == ')' || b == '{' || b == '}':
+		l.pos++
+		return pdfKeyword(string(b))
+	case b == '+' || b == '-' || b == '.' || (b >= '0' && b <= '9'):
+		return l.number()
+	default:
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		switch word := string(l.data[start:l.pos]); word {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		default:
+			return pdfKeyword(word)
+		}
+	}
+}
+
+// number reads a number, or an indirect reference "num gen R"
+func (l *pdfLexer) number() any {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) && (l.data[l.pos] >= '0' && l.data[l.pos] <= '9' || l.data[l.pos] == '.') {
+		l.pos++
+	}
+	n, _ := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+
+	if n == math.Trunc(n) && n >= 0 && !strings.ContainsAny(string(l.data[start:l.pos]), ".+-") {
+		save := l.pos
+		l.skipSpace()
+		genStart := l.pos
+		for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+			l.pos++
+		}
+		if l.pos > genStart {
+			gen, _ := strconv.Atoi(string(l.data[genStart:l.pos]))
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == 'R' && (l.pos+1 == len(l.data) || isPDFSpace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+				l.pos++
+				return pdfRef{num: int(n), gen: gen}
+			}
+		}
+		l.pos = save
+	}
+	return n
+}
+
+func (l *pdfLexer) name() pdfName {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) && isHexDigit(l.data[l.pos+1]) && isHexDigit(l.data[l.pos+2]) {
+			v, _ := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8)
+			b = append(b, byte(v))
+			l.pos += 3
+			continue
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++
+	var b []byte
+	depth := 0
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return pdfString(b)
+			}
+			depth--
+		case '\\':
+			if l.pos >= len(l.data) {
+				return pdfString(b)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return pdfString(b)
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		end = len(l.data) - l.pos
+	}
+	decoded, _ := decodeASCIIHex(l.data[l.pos : l.pos+end])
+	l.pos += end + 1
+	return pdfString(decoded)
+}
+
+// streamData returns the bytes of a stream whose "stream" keyword was just read, using /Length
+// when it is direct and plausible and searching for "endstream" otherwise
+func (l *pdfLexer) streamData(dict pdfDict) []byte {
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+
+	if length, ok := pdfIndex(dict["Length"], len(l.data)-start); ok {
+		end := start + length
+		rest := bytes.TrimLeft(l.data[end:min(end+32, len(l.data))], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			l.pos = end
+			l.nextKeyword("endstream")
+			return l.data[start:end]
+		}
+	}
+
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		l.pos = len(l.data)
+		return l.data[start:]
+	}
+	l.pos = start + end + len("endstream")
+	return bytes.TrimRight(l.data[start:start+end], "\r\n")
+}
+
+// skipInlineImage moves past the data of an inline image, up to its EI operator
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos < len(l.data) {
+		i := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if i < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		l.pos += i + 2
+		before, after := l.pos-3, l.pos
+		if before >= 0 && isPDFSpace(l.data[before]) && (after >= len(l.data) || isPDFSpace(l.data[after])) {
+			return
+		}
+	}
+}
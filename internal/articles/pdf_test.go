@@ -0,0 +1,102 @@
+package articles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readExample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("examples", name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return data
+}
+
+func TestExtractPDF(t *testing.T) {
+	t.Run("reads an uncompressed PDF with document information", func(t *testing.T) {
+		doc, err := ExtractPDF(readExample(t, "simple.pdf"))
+		if err != nil {
+			t.Fatalf("ExtractPDF failed: %v", err)
+		}
+		if doc.Title != "Sparse Retrieval for Small Archives" || doc.Author != "Ada Lovelace; Alan Turing" {
+			t.Errorf("unexpected document information: %q by %q", doc.Title, doc.Author)
+		}
+		if doc.Created.Year() != 2021 || doc.Created.Month() != 3 || doc.Created.Day() != 14 {
+			t.Errorf("unexpected creation date %v", doc.Created)
+		}
+		if len(doc.Pages) != 2 {
+			t.Fatalf("expected 2 pages, got %d", len(doc.Pages))
+		}
+
+		paragraphs := strings.Split(doc.Pages[0], "\n\n")
+		if paragraphs[0] != "Sparse Retrieval for Small Archives" {
+			t.Errorf("expected the title in its own paragraph, got %q", paragraphs[0])
+		}
+		for _, want := range []string{
+			"a carefully tuned inverted index",
+			"We measure this on three corpora.",
+			"Readers keep more than they read. (Citation needed.) Café owners too.",
+		} {
+			if !strings.Contains(doc.Pages[0], want) {
+				t.Errorf("expected page 1 to contain %q, got:\n%s", want, doc.Pages[0])
+			}
+		}
+		if !strings.HasSuffix(doc.Text(), "\n\nThe second page continues the argument with measurements.") {
+			t.Errorf("expected the second page after the first, got:\n%s", doc.Text())
+		}
+	})
+
+	t.Run("reads object streams, ToUnicode maps and encoding differences", func(t *testing.T) {
+		doc, err := ExtractPDF(readExample(t, "compressed.pdf"))
+		if err != nil {
+			t.Fatalf("ExtractPDF failed: %v", err)
+		}
+		if doc.Title != "A βeta Test" || doc.Author != "Grace Hopper" {
+			t.Errorf("unexpected document information: %q by %q", doc.Title, doc.Author)
+		}
+		for _, want := range []string{"A βeta test of unicode maps\n\n", "We find that file formats matter.", "Small libraries benefit the most."} {
+			if !strings.Contains(doc.Text(), want) {
+				t.Errorf("expected text to contain %q, got:\n%s", want, doc.Text())
+			}
+		}
+	})
+
+	t.Run("rejects files it cannot read", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"not a PDF": "<html><body>Not found</body></html>",
+			"encrypted": "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R /Encrypt 2 0 R >>\n",
+			"no text":   "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n2 0 obj << /Type /Pages /Kids [3 0 R] >> endobj\n3 0 obj << /Type /Page >> endobj\ntrailer << /Root 1 0 R >>\n",
+		} {
+			if _, err := ExtractPDF([]byte(data)); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+func FuzzExtractPDF(f *testing.F) {
+	for _, name := range []string{"simple.pdf", "compressed.pdf"} {
+		data, err := os.ReadFile(filepath.Join("examples", name))
+		if err != nil {
+			f.Fatalf("failed to read %s: %v", name, err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("%PDF-1.5\n1 0 obj << /Type /ObjStm /N 1 /First -21 >> stream\n1 0 endstream endobj\n"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj << /Length 99999999999999999999 >> stream\nendstream endobj\n"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj " + strings.Repeat("[", 1000) + " endobj\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := ExtractPDF(data)
+		if err != nil && strings.HasPrefix(err.Error(), "malformed PDF") {
+			t.Fatalf("parser panicked: %v", err)
+		}
+		if err == nil && doc == nil {
+			t.Fatal("expected a document when there is no error")
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package articles
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	exhtml "golang.org/x/net/html"
+)
+
+// ScaffoldRule drafts a rule file for the page at sourceURL from heuristic extraction: XPaths for
+// the title, author, date and body the heuristics found, strip lines for navigation and forms inside
+// the body, and the page as a test_url. The draft is a starting point to review, not a finished rule.
+func (p *ArticleParser) ScaffoldRule(htmlContent, sourceURL string) (string, error) {
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	result := p.heuristicExtract.ExtractWithSemanticHTML(doc)
+	if result == nil || result.Node == nil {
+		return "", fmt.Errorf("could not detect the article body on %s", sourceURL)
+	}
+
+	body := nodeXPath(doc, result.Node)
+	if body == "" {
+		return "", fmt.Errorf("could not build an XPath for the article body on %s", sourceURL)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Drafted by noteleaf from %s on %s.\n", sourceURL, time.Now().Format("2006-01-02"))
+	fmt.Fprintf(&b, "# Review the XPaths below, then run: noteleaf article rules test <domain>\n\n")
+
+	title := p.metadataExtractor.ExtractTitle(doc)
+	for _, h1 := range htmlquery.Find(doc, "//h1") {
+		if xpath := nodeXPath(doc, h1); xpath != "" && normalizeWhitespace(htmlquery.InnerText(h1)) == title {
+			fmt.Fprintf(&b, "title: %s\n", xpath)
+			break
+		}
+	}
+
+	found := map[string]bool{}
+	for _, field := range []struct{ key, xpath string }{
+		{"author", "//meta[@name='author']/@content"},
+		{"author", "//a[@rel='author']"},
+		{"date", "//meta[@property='article:published_time']/@content"},
+		{"date", "//time[@datetime]/@datetime"},
+	} {
+		if found[field.key] {
+			continue
+		}
+		if node := queryOne(doc, field.xpath); node != nil && strings.TrimSpace(htmlquery.InnerText(node)) != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.key, field.xpath)
+			found[field.key] = true
+		}
+	}
+
+	fmt.Fprintf(&b, "body: %s\n", body)
+
+	var strip []string
+	if node := queryOne(doc, body); node != nil {
+		for _, tag := range []string{"nav", "aside", "form", "footer"} {
+			if len(htmlquery.Find(node, ".//"+tag)) > 0 {
+				strip = append(strip, body+"//"+tag)
+			}
+		}
+	}
+	if len(strip) > 0 {
+		b.WriteString("\n")
+		for _, xpath := range strip {
+			fmt.Fprintf(&b, "strip: %s\n", xpath)
+		}
+	}
+
+	fmt.Fprintf(&b, "\ntest_url: %s\n", sourceURL)
+	return b.String(), nil
+}
+
+// nodeXPath returns an XPath that selects only n in doc, preferring ids and classes to positions.
+// n may belong to a cleaned copy of doc, so candidates are checked against doc itself.
+func nodeXPath(doc, n *exhtml.Node) string {
+	if n == nil || n.Type != exhtml.ElementNode {
+		return ""
+	}
+
+	var candidates []string
+	if id := strings.TrimSpace(attr(n, "id")); id != "" {
+		candidates = append(candidates, fmt.Sprintf("//%s[@id=%s]", n.Data, buildXPathLiteral(id)))
+	}
+	for _, class := range strings.Fields(attr(n, "class")) {
+		candidates = append(candidates, fmt.Sprintf("//%s[contains(concat(' ',normalize-space(@class),' '),%s)]", n.Data, buildXPathLiteral(" "+class+" ")))
+	}
+	candidates = append(candidates, "//"+n.Data, positionXPath(n))
+
+	for _, xpath := range candidates {
+		if nodes, err := htmlquery.QueryAll(doc, xpath); err == nil && len(nodes) == 1 {
+			return xpath
+		}
+	}
+	return ""
+}
+
+// positionXPath returns the absolute path to n by element positions, such as /html/body/div[2]/article
+func positionXPath(n *exhtml.Node) string {
+	var steps []string
+	for ; n != nil && n.Type == exhtml.ElementNode; n = n.Parent {
+		index, count := 1, 1
+		if n.Parent != nil {
+			index, count = 0, 0
+			for sibling := n.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == exhtml.ElementNode && sibling.Data == n.Data {
+					count++
+					if sibling == n {
+						index = count
+					}
+				}
+			}
+		}
+		step := n.Data
+		if count > 1 {
+			step = fmt.Sprintf("%s[%d]", n.Data, index)
+		}
+		steps = append([]string{step}, steps...)
+	}
+	return "/" + strings.Join(steps, "/")
+}
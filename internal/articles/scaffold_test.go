@@ -0,0 +1,64 @@
+package articles
+
+import (
+	"strings"
+	"testing"
+)
+
+var storyParagraphs = strings.Repeat("<p>"+strings.Repeat("A sentence for the body of the story, long enough to read. ", 5)+"</p>", 5)
+
+func TestScaffoldRule(t *testing.T) {
+	parser, err := NewArticleParser(nil)
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	t.Run("drafts XPaths for the detected content", func(t *testing.T) {
+		draft, err := parser.ScaffoldRule(`<html><head><title>Story</title>
+			<meta property="article:published_time" content="2024-05-01"></head><body>
+			<h1 class="headline big">Story</h1>
+			<div class="story">`+storyParagraphs+`
+			<aside>Related</aside></div>
+			<div class="story-footer">More</div>
+		</body></html>`, "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, want := range []string{
+			"title: //h1[contains(concat(' ',normalize-space(@class),' '),' headline ')]",
+			"date: //meta[@property='article:published_time']/@content",
+			"body: //div[contains(concat(' ',normalize-space(@class),' '),' story ')]",
+			"strip: //div[contains(concat(' ',normalize-space(@class),' '),' story ')]//aside",
+			"test_url: https://example.com/story",
+		} {
+			if !strings.Contains(draft, want) {
+				t.Errorf("Expected draft to contain %q, got:\n%s", want, draft)
+			}
+		}
+
+		rule, err := parser.parseRules("example.com", draft)
+		if err != nil {
+			t.Fatalf("Expected the draft to parse, got %v", err)
+		}
+		if rule.Body == "" || len(rule.TestURLs) != 1 {
+			t.Errorf("Expected a body and a test URL, got %+v", rule)
+		}
+	})
+
+	t.Run("falls back to positions without ids or classes", func(t *testing.T) {
+		draft, err := parser.ScaffoldRule(`<html><body><div><p>Nav</p></div><div>`+storyParagraphs+`</div></body></html>`, "https://example.com/plain")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(draft, "body: /html/body/div[2]") {
+			t.Errorf("Expected a positional body XPath, got:\n%s", draft)
+		}
+	})
+
+	t.Run("fails on pages without content", func(t *testing.T) {
+		if _, err := parser.ScaffoldRule(`<html><body></body></html>`, "https://example.com/empty"); err == nil {
+			t.Error("Expected an error for an empty page")
+		}
+	})
+}
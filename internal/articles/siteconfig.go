@@ -0,0 +1,98 @@
+package articles
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	exhtml "golang.org/x/net/html"
+)
+
+// maxArticlePages caps how many pages of a multi-page article are collected
+const maxArticlePages = 10
+
+// replaceStrings applies the rule's find_string/replace_string pairs to raw HTML
+func (r *ParsingRule) replaceStrings(htmlContent string) string {
+	for _, replacement := range r.Replacements {
+		if replacement.Find != "" {
+			htmlContent = strings.ReplaceAll(htmlContent, replacement.Find, replacement.Replace)
+		}
+	}
+	return htmlContent
+}
+
+// extractBody returns the first node matched by the rule's body XPaths with the strip,
+// strip_id_or_class and strip_image_src directives applied, or nil when none match
+func (r *ParsingRule) extractBody(doc *exhtml.Node) *exhtml.Node {
+	var body *exhtml.Node
+	for _, xpath := range append([]string{r.Body}, r.BodyAlternatives...) {
+		if xpath == "" {
+			continue
+		}
+		if body = queryOne(doc, xpath); body != nil {
+			break
+		}
+	}
+	if body == nil {
+		return nil
+	}
+
+	for _, stripXPath := range r.Strip {
+		removeNodesByXPath(body, stripXPath)
+	}
+
+	for _, identifier := range r.StripIDsOrClasses {
+		removeNodesByIdentifier(body, identifier)
+	}
+
+	for _, src := range r.StripImageSrc {
+		if src = strings.TrimSpace(src); src != "" {
+			removeNodesByXPath(body, ".//img[contains(@src, "+buildXPathLiteral(src)+")]")
+		}
+	}
+
+	removeDefaultNonContentNodes(body)
+	return body
+}
+
+// pageLink returns the absolute URL of the first link matched by xpaths, which may select
+// either an element with an href or the href attribute itself
+func (r *ParsingRule) pageLink(doc *exhtml.Node, xpaths []string, pageURL string) string {
+	for _, xpath := range xpaths {
+		node := queryOne(doc, xpath)
+		if node == nil {
+			continue
+		}
+
+		href := htmlquery.SelectAttr(node, "href")
+		if href == "" && node.Data != "a" {
+			href = htmlquery.InnerText(node)
+		}
+		if href = strings.TrimSpace(href); href == "" || strings.HasPrefix(href, "#") {
+			continue
+		}
+
+		base, err := url.Parse(pageURL)
+		if err != nil {
+			return href
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		resolved := base.ResolveReference(ref)
+		resolved.Fragment = ""
+		return resolved.String()
+	}
+	return ""
+}
+
+// isNativeAd reports whether the page matches one of the rule's native_ad_clue XPaths
+func (r *ParsingRule) isNativeAd(doc *exhtml.Node) bool {
+	for _, xpath := range r.NativeAdClues {
+		if queryOne(doc, xpath) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package articles
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSiteConfigDirectives(t *testing.T) {
+	t.Run("parses FiveFilters directives", func(t *testing.T) {
+		parser := &ArticleParser{rules: make(map[string]*ParsingRule)}
+		rule, err := parser.parseRules("example.com", `body: //article
+body: //div[@id='content']
+strip_image_src: /ads/
+single_page_link: //a[@class='print']
+next_page_link: //a[@rel='next']/@href
+native_ad_clue: //div[@class='sponsored']
+find_string: <p class="lede">
+replace_string: <p>
+find_string: <br /><br />
+replace_string:
+replace_string(<a href="https://example.com/x">): <a>
+autodetect_on_failure: no
+http_header(user-agent): Mozilla/5.0`)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if rule.Body != "//article" || len(rule.BodyAlternatives) != 1 || rule.BodyAlternatives[0] != "//div[@id='content']" {
+			t.Errorf("Expected body alternatives to be kept in order, got %q and %v", rule.Body, rule.BodyAlternatives)
+		}
+		if len(rule.StripImageSrc) != 1 || len(rule.SinglePageLinks) != 1 || len(rule.NextPageLinks) != 1 || len(rule.NativeAdClues) != 1 {
+			t.Errorf("Expected one of each link and clue directive, got %+v", rule)
+		}
+		if !rule.DisableAutodetect {
+			t.Error("Expected autodetect_on_failure: no to disable autodetection")
+		}
+		if rule.Headers["User-Agent"] != "Mozilla/5.0" {
+			t.Errorf("Expected user agent header, got %v", rule.Headers)
+		}
+
+		want := []StringReplacement{
+			{Find: `<a href="https://example.com/x">`, Replace: "<a>"},
+			{Find: `<p class="lede">`, Replace: "<p>"},
+			{Find: "<br /><br />", Replace: ""},
+		}
+		if len(rule.Replacements) != len(want) {
+			t.Fatalf("Expected %d replacements, got %+v", len(want), rule.Replacements)
+		}
+		for i := range want {
+			if rule.Replacements[i] != want[i] {
+				t.Errorf("Expected replacement %d to be %+v, got %+v", i, want[i], rule.Replacements[i])
+			}
+		}
+	})
+
+	t.Run("ignores unpaired find_string lines", func(t *testing.T) {
+		parser := &ArticleParser{rules: make(map[string]*ParsingRule)}
+		rule, err := parser.parseRules("example.com", "find_string: a\nfind_string: b\nreplace_string: c")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(rule.Replacements) != 0 {
+			t.Errorf("Expected no replacements, got %+v", rule.Replacements)
+		}
+	})
+
+	t.Run("applies replacements, body alternatives and image stripping", func(t *testing.T) {
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.AddRule("example.com", &ParsingRule{
+			Title:            "//h1",
+			Body:             "//article",
+			BodyAlternatives: []string{"//div[@id='story']"},
+			StripImageSrc:    []string{"/ads/"},
+			Replacements:     []StringReplacement{{Find: "<span class=\"drop\">", Replace: "<span>"}},
+		})
+
+		content, err := parser.Parse(`<html><body><h1>Story</h1><div id="story">
+			<p><span class="drop">Once</span> upon a time.</p>
+			<img src="https://example.com/ads/banner.png" alt="ad">
+			<img src="/photo.jpg" alt="photo">
+		</div></body></html>`, "example.com", "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(content.Content, "Once upon a time.") {
+			t.Errorf("Expected body from the alternative XPath, got %q", content.Content)
+		}
+		if strings.Contains(content.Content, "banner.png") || !strings.Contains(content.Content, "https://example.com/photo.jpg") {
+			t.Errorf("Expected only the ad image to be stripped, got %q", content.Content)
+		}
+	})
+
+	t.Run("fails without autodetection when the body is missing", func(t *testing.T) {
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.AddRule("example.com", &ParsingRule{Title: "//h1", Body: "//article", DisableAutodetect: true})
+
+		_, err = parser.Parse(`<html><body><h1>Story</h1><main><p>Text</p></main></body></html>`, "example.com", "https://example.com/story")
+		if err == nil || !strings.Contains(err.Error(), "could not find the article body") {
+			t.Errorf("Expected a missing body error, got %v", err)
+		}
+	})
+
+	t.Run("flags native ads", func(t *testing.T) {
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.AddRule("example.com", &ParsingRule{Title: "//h1", Body: "//article", NativeAdClues: []string{"//div[@class='sponsored']"}})
+
+		content, err := parser.Parse(`<html><body><h1>Story</h1><div class="sponsored">Paid post</div><article><p>Text</p></article></body></html>`, "example.com", "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !content.NativeAd {
+			t.Error("Expected the page to be flagged as a native ad")
+		}
+	})
+
+	t.Run("ignores XPaths that don't compile", func(t *testing.T) {
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.AddRule("example.com", &ParsingRule{Title: "//h1[", Body: "//article", Strip: []string{"//div[@"}})
+
+		content, err := parser.Parse(`<html><head><title>Fallback</title></head><body><article><p>Text</p></article></body></html>`, "example.com", "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content.Title != "Fallback" {
+			t.Errorf("Expected the metadata title, got %q", content.Title)
+		}
+	})
+}
+
+func TestMultiPageArticles(t *testing.T) {
+	pages := map[string]string{
+		"https://example.com/story": `<html><body><h1>Story</h1>
+			<article><p>Page one.</p></article>
+			<a class="next" href="/story?page=2">Next</a></body></html>`,
+		"https://example.com/story?page=2": `<html><body><h1>Story</h1>
+			<article><p>Page two.</p></article>
+			<a class="next" href="story?page=3">Next</a></body></html>`,
+		"https://example.com/story?page=3": `<html><body><h1>Story</h1>
+			<article><p>Page three.</p></article>
+			<a class="next" href="/story">Back to the start</a></body></html>`,
+		"https://example.com/print/story": `<html><body><h1>Story</h1>
+			<article><p>Page one.</p><p>Page two.</p></article></body></html>`,
+	}
+
+	newParser := func(t *testing.T, rule *ParsingRule, requested *[]string) *ArticleParser {
+		t.Helper()
+		parser, err := NewArticleParser(nil)
+		if err != nil {
+			t.Fatalf("Failed to create parser: %v", err)
+		}
+		parser.AddRule("example.com", rule)
+		parser.SetHTTPClient(newMockHTTPClient(t, func(req *http.Request) (*http.Response, error) {
+			*requested = append(*requested, req.URL.String()+" "+req.Header.Get("User-Agent"))
+			if body, ok := pages[req.URL.String()]; ok {
+				return htmlResponse(http.StatusOK, body), nil
+			}
+			return htmlResponse(http.StatusNotFound, ""), nil
+		}))
+		return parser
+	}
+
+	t.Run("follows next page links and concatenates pages", func(t *testing.T) {
+		var requested []string
+		parser := newParser(t, &ParsingRule{
+			Title:         "//h1",
+			Body:          "//article",
+			NextPageLinks: []string{"//a[@class='next']"},
+			Headers:       map[string]string{"User-Agent": "noteleaf-test"},
+		}, &requested)
+
+		content, err := parser.ParseURL("https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content.Content != "Page one.\n\nPage two.\n\nPage three." {
+			t.Errorf("Expected the three pages in order, got %q", content.Content)
+		}
+		if content.Pages != 3 {
+			t.Errorf("Expected 3 pages, got %d", content.Pages)
+		}
+		if len(requested) != 3 {
+			t.Fatalf("Expected each page to be fetched once, got %v", requested)
+		}
+		for _, req := range requested {
+			if !strings.HasSuffix(req, " noteleaf-test") {
+				t.Errorf("Expected rule headers on every page request, got %q", req)
+			}
+		}
+	})
+
+	t.Run("prefers the single page view", func(t *testing.T) {
+		var requested []string
+		parser := newParser(t, &ParsingRule{
+			Title:           "//h1",
+			Body:            "//article",
+			SinglePageLinks: []string{"//a[@class='print']/@href"},
+			NextPageLinks:   []string{"//a[@class='next']"},
+		}, &requested)
+
+		content, err := parser.Parse(`<html><body><h1>Story</h1><article><p>Page one.</p></article>
+			<a class="print" href="/print/story">Print</a><a class="next" href="/story?page=2">Next</a></body></html>`,
+			"example.com", "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content.Content != "Page one.\n\nPage two." {
+			t.Errorf("Expected the single page body, got %q", content.Content)
+		}
+		if content.URL != "https://example.com/story" {
+			t.Errorf("Expected the original URL to be kept, got %q", content.URL)
+		}
+		if len(requested) != 1 {
+			t.Errorf("Expected only the single page view to be fetched, got %v", requested)
+		}
+	})
+
+	t.Run("keeps collected pages when a later page fails", func(t *testing.T) {
+		var requested []string
+		parser := newParser(t, &ParsingRule{Title: "//h1", Body: "//article", NextPageLinks: []string{"//a[@class='next']"}}, &requested)
+
+		content, err := parser.Parse(`<html><body><h1>Story</h1><article><p>Only page.</p></article>
+			<a class="next" href="/missing">Next</a></body></html>`, "example.com", "https://example.com/story")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content.Content != "Only page." || content.Pages != 1 {
+			t.Errorf("Expected just the first page, got %q (%d pages)", content.Content, content.Pages)
+		}
+	})
+}
+
+func TestFindRule(t *testing.T) {
+	parser := &ArticleParser{rules: map[string]*ParsingRule{
+		"example.com":    {Domain: "example.com"},
+		".wikipedia.org": {Domain: ".wikipedia.org"},
+		"x.com":          {Domain: "x.com"},
+	}}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"blog.example.com", ""},
+		{"en.wikipedia.org", ".wikipedia.org"},
+		{"en.m.wikipedia.org", ".wikipedia.org"},
+		{".wikipedia.org", ".wikipedia.org"},
+		{"fox.com", ""},
+		{"EXAMPLE.com", "example.com"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if rule := parser.findRule(tt.host); rule != nil {
+			got = rule.Domain
+		}
+		if got != tt.want {
+			t.Errorf("findRule(%q): expected %q, got %q", tt.host, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// articleWorkers bounds how many pages feed refreshes, imports and link checks fetch at once
+	articleWorkers = 4
+
+	// Per-host rate limit for batches of pages, so a large feed, import or link check does not hammer a site
+	articleHostRequestsPerSecond = 2
+	articleHostBurst             = 2
+)
+
+// pageResult is the outcome of parsing one page of a [ArticleHandler.parsePages] batch
+type pageResult struct {
+	index   int // position of the page in the batch
+	content *articles.ParsedContent
+	err     error
+}
+
+// parsePages parses the pages at urls with [fetchPages] and calls handle from the calling
+// goroutine as each page finishes
+func (h *ArticleHandler) parsePages(ctx context.Context, urls []string, handle func(pageResult)) {
+	fetchPages(ctx, urls, h.parser.ParseURL, func(i int, content *articles.ParsedContent, err error) {
+		handle(pageResult{index: i, content: content, err: err})
+	})
+}
+
+// fetchPages runs fetch on each of urls with up to [articleWorkers] workers, rate limited per
+// host so a large batch does not hammer one site, and calls handle from the calling goroutine
+// with the index of each url as it finishes. URLs not started when ctx is cancelled are not
+// reported.
+func fetchPages[T any](ctx context.Context, urls []string, fetch func(string) (T, error), handle func(int, T, error)) {
+	type result struct {
+		index int
+		value T
+		err   error
+	}
+
+	var (
+		mu       sync.Mutex
+		limiters = map[string]*rate.Limiter{}
+		wg       sync.WaitGroup
+		jobs     = make(chan int)
+		results  = make(chan result)
+	)
+
+	limiterFor := func(pageURL string) *rate.Limiter {
+		host := ""
+		if u, err := url.Parse(pageURL); err == nil {
+			host = u.Hostname()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if limiters[host] == nil {
+			limiters[host] = rate.NewLimiter(rate.Limit(articleHostRequestsPerSecond), articleHostBurst)
+		}
+		return limiters[host]
+	}
+
+	for range min(articleWorkers, len(urls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := result{index: i}
+				if err := limiterFor(urls[i]).Wait(ctx); err != nil {
+					r.err = fmt.Errorf("rate limit wait failed: %w", err)
+				} else {
+					r.value, r.err = fetch(urls[i])
+				}
+				results <- r
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range urls {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		handle(r.index, r.value, r.err)
+	}
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// FeedOptions are the per-feed filters and tags set by `article feed add`
+type FeedOptions struct {
+	Include []string // entries must mention one of these keywords in the title or summary
+	Exclude []string // entries mentioning any of these keywords are skipped
+	MaxAge  int      // days; older entries are skipped, 0 keeps everything
+	Tags    []string // added to every article saved from the feed
+}
+
+// feedRefreshStats counts what happened to the entries of one refresh
+type feedRefreshStats struct {
+	added, filtered, known, failed int
+}
+
+// cleanKeywords trims keywords and drops empty ones
+func cleanKeywords(values []string) []string {
+	var cleaned []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			cleaned = append(cleaned, value)
+		}
+	}
+	return cleaned
+}
+
+// AddFeed subscribes to the RSS, Atom or JSON feed at feedURL. The feed is fetched once to check
+// it and find its title; its entries are imported by the next refresh.
+func (h *ArticleHandler) AddFeed(ctx context.Context, feedURL string, opts FeedOptions) error {
+	parsedURL, err := url.Parse(feedURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid feed URL: %s", feedURL)
+	}
+	if opts.MaxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+
+	existing, err := h.repos.Articles.GetFeedByURL(ctx, feedURL)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing feed: %w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("already subscribed to %s (ID: %d)", feedURL, existing.ID)
+	}
+
+	ui.Infoln("Fetching feed from: %s", feedURL)
+	resp, err := h.parser.FetchFeed(feedURL, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	feed := &models.ArticleFeed{
+		URL:     feedURL,
+		Title:   resp.Feed.Title,
+		SiteURL: resp.Feed.SiteURL,
+		Include: cleanKeywords(opts.Include),
+		Exclude: cleanKeywords(opts.Exclude),
+		MaxAge:  opts.MaxAge,
+		Tags:    cleanKeywords(opts.Tags),
+	}
+	if feed.Title == "" {
+		feed.Title = parsedURL.Hostname()
+	}
+
+	id, err := h.repos.Articles.CreateFeed(ctx, feed)
+	if err != nil {
+		return fmt.Errorf("failed to save feed: %w", err)
+	}
+
+	ui.Successln("Subscribed to %s (ID: %d)", ui.TableTitleStyle.Render(feed.Title), id)
+	ui.Infoln("%d entries in the feed", len(resp.Feed.Entries))
+	ui.Infoln("Import them with: noteleaf article feed refresh %d", id)
+	return nil
+}
+
+// ListFeeds prints the feed subscriptions with their filters and the state of the last refresh
+func (h *ArticleHandler) ListFeeds(ctx context.Context) error {
+	feeds, err := h.repos.Articles.ListFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list feeds: %w", err)
+	}
+
+	if len(feeds) == 0 {
+		ui.Warningln("No feeds found.")
+		ui.Infoln("Subscribe with: noteleaf article feed add <url>")
+		return nil
+	}
+
+	ui.Infoln("Found %d feed(s):\n", len(feeds))
+	for _, feed := range feeds {
+		ui.Infoln("ID: %d", feed.ID)
+		ui.Infoln("Title: %s", ui.TableTitleStyle.Render(feed.Title))
+		ui.Infoln("URL: %s", feed.URL)
+		if len(feed.Include) > 0 {
+			ui.Infoln("Include: %s", strings.Join(feed.Include, ", "))
+		}
+		if len(feed.Exclude) > 0 {
+			ui.Infoln("Exclude: %s", strings.Join(feed.Exclude, ", "))
+		}
+		if feed.MaxAge > 0 {
+			ui.Infoln("Max age: %d day(s)", feed.MaxAge)
+		}
+		if len(feed.Tags) > 0 {
+			ui.Infoln("Tags: %s", strings.Join(feed.Tags, ", "))
+		}
+
+		if count, err := h.repos.Articles.CountFeedItems(ctx, feed.ID); err == nil {
+			ui.Infoln("Saved entries: %d", count)
+		}
+		if feed.LastFetched != nil {
+			ui.Infoln("Last refreshed: %s", feed.LastFetched.Format("2006-01-02 15:04:05"))
+		} else {
+			ui.Infoln("Last refreshed: never")
+		}
+		if feed.LastError != "" {
+			ui.Warningln("Last error: %s", feed.LastError)
+		}
+		ui.Plainln("---")
+	}
+	return nil
+}
+
+// RemoveFeed unsubscribes from a feed, keeping the articles already saved from it
+func (h *ArticleHandler) RemoveFeed(ctx context.Context, id int64) error {
+	feed, err := h.repos.Articles.GetFeed(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get feed: %w", err)
+	}
+	if err := h.repos.Articles.DeleteFeed(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove feed: %w", err)
+	}
+
+	ui.Titleln("Feed removed: %s (ID: %d)", feed.Title, id)
+	return nil
+}
+
+// RefreshFeeds fetches the given feeds, or every feed when all is set, and saves their new
+// entries as unread articles. A feed that fails is reported and the others still refresh.
+func (h *ArticleHandler) RefreshFeeds(ctx context.Context, ids []int64, all bool) error {
+	var feeds []*models.ArticleFeed
+	switch {
+	case all:
+		list, err := h.repos.Articles.ListFeeds(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list feeds: %w", err)
+		}
+		feeds = list
+	case len(ids) > 0:
+		for _, id := range ids {
+			feed, err := h.repos.Articles.GetFeed(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to get feed %d: %w", id, err)
+			}
+			feeds = append(feeds, feed)
+		}
+	default:
+		return fmt.Errorf("specify feed IDs or use --all")
+	}
+
+	if len(feeds) == 0 {
+		ui.Warningln("No feeds to refresh.")
+		return nil
+	}
+
+	var total feedRefreshStats
+	var failedFeeds int
+	for _, feed := range feeds {
+		stats, err := h.refreshFeed(ctx, feed)
+		if err != nil {
+			ui.Errorln("  %v", err)
+			failedFeeds++
+			continue
+		}
+		total.added += stats.added
+		total.filtered += stats.filtered
+		total.known += stats.known
+		total.failed += stats.failed
+	}
+
+	ui.Newline()
+	ui.Successln("Saved %d new article(s) from %d feed(s)", total.added, len(feeds)-failedFeeds)
+	if total.filtered > 0 || total.known > 0 {
+		ui.Infoln("Skipped %d filtered and %d already saved entries", total.filtered, total.known)
+	}
+	if total.failed > 0 {
+		ui.Warningln("%d entries could not be saved and will be retried on the next refresh", total.failed)
+	}
+	if failedFeeds > 0 {
+		return fmt.Errorf("%d of %d feed(s) failed to refresh", failedFeeds, len(feeds))
+	}
+	return nil
+}
+
+// refreshFeed runs a conditional fetch of feed, saves the new entries that pass its filters and
+// records the validators and outcome of the fetch on the feed
+func (h *ArticleHandler) refreshFeed(ctx context.Context, feed *models.ArticleFeed) (feedRefreshStats, error) {
+	var stats feedRefreshStats
+	ui.Titleln("Refreshing %s", feed.Title)
+
+	now := time.Now()
+	feed.LastFetched = &now
+
+	resp, err := h.parser.FetchFeed(feed.URL, feed.ETag, feed.LastModified)
+	if err != nil {
+		feed.LastError = err.Error()
+		if updateErr := h.repos.Articles.UpdateFeed(ctx, feed); updateErr != nil {
+			return stats, fmt.Errorf("failed to update feed: %w", updateErr)
+		}
+		return stats, fmt.Errorf("failed to fetch %s: %w", feed.URL, err)
+	}
+
+	if resp.NotModified {
+		ui.Infoln("  Not modified since the last refresh")
+		feed.LastError = ""
+		if err := h.repos.Articles.UpdateFeed(ctx, feed); err != nil {
+			return stats, fmt.Errorf("failed to update feed: %w", err)
+		}
+		return stats, nil
+	}
+
+	candidates, err := h.newFeedEntries(ctx, feed, resp.Feed, now, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	dir, err := h.getStorageDirectory()
+	if err != nil {
+		return stats, fmt.Errorf("failed to get article storage dir %w", err)
+	}
+
+	urls := make([]string, len(candidates))
+	for i, entry := range candidates {
+		urls[i] = entry.URL
+	}
+
+	opts := h.DefaultSaveOptions()
+	var recordErr error
+	h.parsePages(ctx, urls, func(result pageResult) {
+		entry := candidates[result.index]
+		if recordErr != nil {
+			return
+		}
+		if result.err != nil {
+			ui.Errorln("  ✗ %s: %v", entry.Title, result.err)
+			stats.failed++
+			return
+		}
+
+		article, _, err := h.saveParsed(ctx, dir, entry.URL, result.content, opts, feed.Tags)
+		if err != nil {
+			ui.Errorln("  ✗ %s: %v", entry.Title, err)
+			stats.failed++
+			return
+		}
+		if err := h.repos.Articles.AddFeedItem(ctx, &models.ArticleFeedItem{
+			FeedID: feed.ID, GUID: entry.GUID, URL: entry.URL, ArticleID: article.ID,
+		}); err != nil {
+			recordErr = fmt.Errorf("failed to record feed entry: %w", err)
+			return
+		}
+
+		ui.Successln("  + %s (ID: %d)", article.Title, article.ID)
+		stats.added++
+	})
+	if recordErr != nil {
+		return stats, recordErr
+	}
+
+	// Validators are only kept once every entry is saved: a conditional request answered with
+	// 304 would otherwise never retry the entries that failed
+	feed.ETag = resp.ETag
+	feed.LastModified = resp.LastModified
+	feed.LastError = ""
+	if stats.failed > 0 {
+		feed.ETag, feed.LastModified = "", ""
+		feed.LastError = fmt.Sprintf("%d entries could not be saved", stats.failed)
+	}
+	if err := h.repos.Articles.UpdateFeed(ctx, feed); err != nil {
+		return stats, fmt.Errorf("failed to update feed: %w", err)
+	}
+	return stats, nil
+}
+
+// newFeedEntries returns the entries of parsed that pass the filters of feed and have not been
+// saved before. Entries whose URL is already saved as an article are recorded against that article.
+func (h *ArticleHandler) newFeedEntries(ctx context.Context, feed *models.ArticleFeed, parsed *articles.Feed, now time.Time, stats *feedRefreshStats) ([]articles.FeedEntry, error) {
+	var candidates []articles.FeedEntry
+	seen := map[string]bool{}
+
+	for _, entry := range parsed.Entries {
+		if seen[entry.GUID] || seen[entry.URL] {
+			continue
+		}
+		seen[entry.GUID], seen[entry.URL] = true, true
+
+		if !feedEntryAllowed(feed, entry, now) {
+			stats.filtered++
+			continue
+		}
+
+		known, err := h.repos.Articles.HasFeedItem(ctx, feed.ID, entry.GUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check feed entry: %w", err)
+		}
+		if known {
+			stats.known++
+			continue
+		}
+
+		if existing, err := h.repos.Articles.GetByURL(ctx, entry.URL); err == nil {
+			if err := h.repos.Articles.AddFeedItem(ctx, &models.ArticleFeedItem{
+				FeedID: feed.ID, GUID: entry.GUID, URL: entry.URL, ArticleID: existing.ID,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to record feed entry: %w", err)
+			}
+			stats.known++
+			continue
+		}
+
+		candidates = append(candidates, entry)
+	}
+	return candidates, nil
+}
+
+// feedEntryAllowed applies the max age and keyword filters of feed to entry. Entries without a
+// date pass the age filter; keywords match the title and summary case-insensitively.
+func feedEntryAllowed(feed *models.ArticleFeed, entry articles.FeedEntry, now time.Time) bool {
+	if feed.MaxAge > 0 && !entry.Published.IsZero() && entry.Published.Before(now.AddDate(0, 0, -feed.MaxAge)) {
+		return false
+	}
+
+	text := strings.ToLower(entry.Title + " " + entry.Summary)
+	mentions := func(keyword string) bool { return strings.Contains(text, strings.ToLower(keyword)) }
+
+	for _, keyword := range feed.Exclude {
+		if mentions(keyword) {
+			return false
+		}
+	}
+	if len(feed.Include) == 0 {
+		return true
+	}
+	for _, keyword := range feed.Include {
+		if mentions(keyword) {
+			return true
+		}
+	}
+	return false
+}
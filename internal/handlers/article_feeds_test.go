@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+)
+
+// feedServer serves an RSS feed with an ETag and one article page per entry path
+type feedServer struct {
+	*httptest.Server
+	feedRequests atomic.Int32
+	notModified  atomic.Int32
+	pages        atomic.Int32
+	failing      atomic.Bool // when set, /posts/broken answers with a server error
+	items        func(base string) string
+}
+
+func newFeedServer(t *testing.T, items func(base string) string) *feedServer {
+	t.Helper()
+	fs := &feedServer{items: items}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed.xml" {
+			fs.feedRequests.Add(1)
+			if r.Header.Get("If-None-Match") == `"feed-v1"` {
+				fs.notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Header().Set("ETag", `"feed-v1"`)
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test Feed</title><link>%s/</link>%s</channel></rss>`,
+				fs.URL, fs.items(fs.URL))
+			return
+		}
+
+		fs.pages.Add(1)
+		if r.URL.Path == "/posts/broken" && fs.failing.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		title := strings.TrimPrefix(r.URL.Path, "/posts/")
+		fmt.Fprintf(w, `<html><body><h1 id="headline">%s</h1><div id="story"><p>The story of %s, told in a few words.</p></div></body></html>`, title, title)
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func feedItem(base, slug, description string, published time.Time) string {
+	return fmt.Sprintf(`<item><title>%s</title><link>%s/posts/%s</link><guid>%s</guid><description>%s</description><pubDate>%s</pubDate></item>`,
+		slug, base, slug, slug, description, published.Format(time.RFC1123Z))
+}
+
+func TestArticleFeeds(t *testing.T) {
+	ctx := context.Background()
+	recent := time.Now().Add(-24 * time.Hour)
+
+	newHelper := func(t *testing.T) *ArticleTestHelper {
+		t.Helper()
+		helper := NewArticleTestHelper(t)
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title: "//h1[@id='headline']",
+			Body:  "//div[@id='story']",
+		})
+		return helper
+	}
+
+	t.Run("add validates and rejects duplicates", func(t *testing.T) {
+		helper := newHelper(t)
+		server := newFeedServer(t, func(base string) string { return feedItem(base, "alpha", "Go news", recent) })
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{Tags: []string{"feeds", " "}}), "add feed")
+		})
+		helper.suite.AssertError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{}), "duplicate feed")
+		helper.suite.AssertError(helper.AddFeed(ctx, "not a url", FeedOptions{}), "invalid URL")
+		helper.suite.AssertError(helper.AddFeed(ctx, server.URL+"/posts/page", FeedOptions{}), "page that is not a feed")
+
+		feeds, err := helper.repos.Articles.ListFeeds(ctx)
+		helper.suite.AssertNoError(err, "list feeds")
+		if len(feeds) != 1 || feeds[0].Title != "Test Feed" || strings.Join(feeds[0].Tags, ",") != "feeds" {
+			t.Fatalf("expected one feed with cleaned tags, got %+v", feeds)
+		}
+		if feeds[0].ETag != "" {
+			t.Error("expected no validators before the first refresh")
+		}
+
+		articles, err := helper.repos.Articles.List(ctx, &repo.ArticleListOptions{})
+		helper.suite.AssertNoError(err, "list articles")
+		if len(articles) != 0 {
+			t.Errorf("expected adding a feed not to import entries, got %d articles", len(articles))
+		}
+	})
+
+	t.Run("refresh saves new entries with filters, tags and conditional requests", func(t *testing.T) {
+		helper := newHelper(t)
+		server := newFeedServer(t, func(base string) string {
+			return feedItem(base, "alpha", "All about Go generics", recent) +
+				feedItem(base, "beta", "Go and sponsored content", recent) +
+				feedItem(base, "gamma", "Gardening tips", recent) +
+				feedItem(base, "delta", "Old Go release notes", time.Now().AddDate(0, 0, -60)) +
+				feedItem(base, "epsilon", "More Go", recent)
+		})
+
+		existingID := helper.CreateTestArticle(t, server.URL+"/posts/epsilon", "Epsilon", "", "")
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{
+				Include: []string{"go"},
+				Exclude: []string{"Sponsored"},
+				MaxAge:  30,
+				Tags:    []string{"golang", "feed"},
+			}), "add feed")
+		})
+		feeds, _ := helper.repos.Articles.ListFeeds(ctx)
+		feedID := feeds[0].ID
+
+		helper.suite.AssertError(helper.RefreshFeeds(ctx, nil, false), "refresh needs IDs or --all")
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, []int64{feedID}, false), "first refresh")
+		})
+		if !strings.Contains(output, "Saved 1 new article(s)") {
+			t.Errorf("expected one saved article, got:\n%s", output)
+		}
+		if server.pages.Load() != 1 {
+			t.Errorf("expected only the alpha page to be fetched, got %d page requests", server.pages.Load())
+		}
+
+		saved, err := helper.repos.Articles.List(ctx, &repo.ArticleListOptions{Tags: []string{"golang", "feed"}})
+		helper.suite.AssertNoError(err, "list tagged articles")
+		if len(saved) != 1 || saved[0].URL != server.URL+"/posts/alpha" || !saved[0].IsUnread() {
+			t.Fatalf("expected the alpha article saved unread with the feed tags, got %+v", saved)
+		}
+
+		count, err := helper.repos.Articles.CountFeedItems(ctx, feedID)
+		helper.suite.AssertNoError(err, "count feed items")
+		if count != 2 {
+			t.Errorf("expected alpha and the already saved epsilon to be recorded, got %d", count)
+		}
+		if has, _ := helper.repos.Articles.HasFeedItem(ctx, feedID, "epsilon"); !has {
+			t.Error("expected epsilon to be recorded against the existing article")
+		}
+		if _, err := helper.repos.Articles.Get(ctx, existingID); err != nil {
+			t.Errorf("expected the existing article to be kept: %v", err)
+		}
+
+		feed, err := helper.repos.Articles.GetFeed(ctx, feedID)
+		helper.suite.AssertNoError(err, "get feed")
+		if feed.ETag != `"feed-v1"` || feed.LastFetched == nil || feed.LastError != "" {
+			t.Errorf("expected the refresh to be recorded on the feed, got %+v", feed)
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "second refresh")
+		})
+		if server.notModified.Load() != 1 || !strings.Contains(output, "Not modified") {
+			t.Errorf("expected a conditional request answered with 304, got:\n%s", output)
+		}
+		if server.pages.Load() != 1 {
+			t.Errorf("expected no more page requests, got %d", server.pages.Load())
+		}
+	})
+
+	t.Run("refresh skips entries already saved from the feed", func(t *testing.T) {
+		helper := newHelper(t)
+		server := newFeedServer(t, func(base string) string {
+			return feedItem(base, "one", "First", recent) + feedItem(base, "two", "Second", recent) + feedItem(base, "three", "Third", recent)
+		})
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{}), "add feed")
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "first refresh")
+		})
+
+		feeds, _ := helper.repos.Articles.ListFeeds(ctx)
+		feeds[0].ETag = ""
+		helper.suite.AssertNoError(helper.repos.Articles.UpdateFeed(ctx, feeds[0]), "drop validators")
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "second refresh")
+		})
+		if !strings.Contains(output, "Saved 0 new article(s)") || !strings.Contains(output, "3 already saved") {
+			t.Errorf("expected every entry to be skipped, got:\n%s", output)
+		}
+		if server.pages.Load() != 3 {
+			t.Errorf("expected each page to be fetched once, got %d", server.pages.Load())
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ListFeeds(ctx), "list feeds")
+		})
+		for _, want := range []string{"Test Feed", "Saved entries: 3", "/feed.xml"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected feed list to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("refresh retries failed entries instead of trusting a 304", func(t *testing.T) {
+		helper := newHelper(t)
+		server := newFeedServer(t, func(base string) string {
+			return feedItem(base, "fine", "Fine", recent) + feedItem(base, "broken", "Broken", recent)
+		})
+		server.failing.Store(true)
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{}), "add feed")
+			helper.RefreshFeeds(ctx, nil, true)
+		})
+		feeds, _ := helper.repos.Articles.ListFeeds(ctx)
+		if feeds[0].ETag != "" || feeds[0].LastError == "" {
+			t.Fatalf("expected no validators to be kept after a failed entry, got %+v", feeds[0])
+		}
+
+		server.failing.Store(false)
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "second refresh")
+		})
+		if server.notModified.Load() != 0 || !strings.Contains(output, "Saved 1 new article(s)") {
+			t.Errorf("expected the failed entry to be fetched and saved, got:\n%s", output)
+		}
+		if server.pages.Load() != 3 {
+			t.Errorf("expected only the failed page to be fetched again, got %d page requests", server.pages.Load())
+		}
+
+		feeds, _ = helper.repos.Articles.ListFeeds(ctx)
+		if feeds[0].ETag != `"feed-v1"` || feeds[0].LastError != "" {
+			t.Errorf("expected validators once every entry is saved, got %+v", feeds[0])
+		}
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "third refresh")
+		})
+		if server.notModified.Load() != 1 {
+			t.Errorf("expected the next refresh to be answered with 304, got %d", server.notModified.Load())
+		}
+	})
+
+	t.Run("refresh records fetch errors and remove keeps articles", func(t *testing.T) {
+		helper := newHelper(t)
+		server := newFeedServer(t, func(base string) string { return feedItem(base, "kept", "Kept", recent) })
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.AddFeed(ctx, server.URL+"/feed.xml", FeedOptions{}), "add feed")
+			helper.suite.AssertNoError(helper.RefreshFeeds(ctx, nil, true), "refresh")
+		})
+		feeds, _ := helper.repos.Articles.ListFeeds(ctx)
+		feedID := feeds[0].ID
+
+		server.Close()
+		feeds[0].ETag = ""
+		helper.suite.AssertNoError(helper.repos.Articles.UpdateFeed(ctx, feeds[0]), "drop validators")
+		captureStdout(t, func() {
+			helper.suite.AssertError(helper.RefreshFeeds(ctx, []int64{feedID}, false), "refresh of an unreachable feed")
+		})
+		feed, err := helper.repos.Articles.GetFeed(ctx, feedID)
+		helper.suite.AssertNoError(err, "get feed")
+		if feed.LastError == "" {
+			t.Error("expected the fetch error to be recorded")
+		}
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RemoveFeed(ctx, feedID), "remove feed")
+		})
+		helper.suite.AssertError(helper.RemoveFeed(ctx, feedID), "remove missing feed")
+
+		articles, err := helper.repos.Articles.List(ctx, &repo.ArticleListOptions{})
+		helper.suite.AssertNoError(err, "list articles")
+		if len(articles) != 1 {
+			t.Errorf("expected the saved article to outlive its feed, got %d", len(articles))
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// Highlight finds a quote in an article's saved markdown and stores it as a highlight with an optional note
+func (h *ArticleHandler) Highlight(ctx context.Context, id int64, quote, note string) error {
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+
+	if strings.TrimSpace(quote) == "" {
+		return fmt.Errorf("quote cannot be empty")
+	}
+
+	content, err := os.ReadFile(article.MarkdownPath)
+	if err != nil {
+		return fmt.Errorf("failed to read markdown file: %w", err)
+	}
+
+	start, end, ok := articles.FindQuote(string(content), quote)
+	if !ok {
+		return fmt.Errorf("quote not found in %s", article.Title)
+	}
+
+	highlight := &models.ArticleHighlight{
+		ArticleID: article.ID,
+		Quote:     string(content[start:end]),
+		Note:      strings.TrimSpace(note),
+		Start:     start,
+		End:       end,
+	}
+	if _, err := h.repos.Articles.CreateHighlight(ctx, highlight); err != nil {
+		return fmt.Errorf("failed to save highlight: %w", err)
+	}
+
+	ui.Successln("Highlight %d saved on %s (line %d)", highlight.ID, article.Title, lineOf(content, start))
+	ui.Plainln("  %s", ui.HighlightStyle.Render(collapseQuote(highlight.Quote)))
+	if article.NoteID != nil {
+		ui.Infoln("Run 'noteleaf article highlights export %d' to update note %d", article.ID, *article.NoteID)
+	}
+	return nil
+}
+
+// RemoveHighlight deletes one of an article's highlights
+func (h *ArticleHandler) RemoveHighlight(ctx context.Context, articleID, highlightID int64) error {
+	if err := h.repos.Articles.DeleteHighlight(ctx, articleID, highlightID); err != nil {
+		return err
+	}
+	ui.Successln("Highlight %d removed", highlightID)
+	return nil
+}
+
+// ExportHighlights writes every highlight of an article to its linked note, creating the note on the
+// first export. The note is regenerated each time; earlier versions stay in the note's history.
+func (h *ArticleHandler) ExportHighlights(ctx context.Context, id int64) error {
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+
+	highlights, err := h.repos.Articles.ListHighlights(ctx, article.ID)
+	if err != nil {
+		return err
+	}
+	if len(highlights) == 0 {
+		return fmt.Errorf("%s has no highlights; add one with 'noteleaf article highlight %d \"quote\"'", article.Title, article.ID)
+	}
+
+	var note *models.Note
+	if article.NoteID != nil {
+		if note, err = h.repos.Notes.Get(ctx, *article.NoteID); err != nil {
+			note = nil
+		}
+	}
+
+	title := "Highlights: " + article.Title
+	content := formatHighlightsNote(article, highlights)
+
+	if note != nil {
+		if note.Encrypted {
+			return fmt.Errorf("note %d is encrypted; decrypt it with 'noteleaf note decrypt %d' before exporting again", note.ID, note.ID)
+		}
+		note.Title = title
+		note.Content = content
+		note.Tags = mergeTags(note.Tags, article.Tags)
+		if err := h.repos.Notes.Update(ctx, note); err != nil {
+			return fmt.Errorf("failed to update note: %w", err)
+		}
+		ui.Successln("Updated note %d with %d highlight(s) from %s", note.ID, len(highlights), article.Title)
+		return nil
+	}
+
+	note = &models.Note{Title: title, Content: content, Tags: article.Tags}
+	if _, err := h.repos.Notes.Create(ctx, note); err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+
+	article.NoteID = &note.ID
+	if err := h.repos.Articles.Update(ctx, article); err != nil {
+		return fmt.Errorf("failed to link note to article: %w", err)
+	}
+	ui.Successln("Created note %d with %d highlight(s) from %s", note.ID, len(highlights), article.Title)
+	return nil
+}
+
+// formatHighlightsNote renders an article's highlights as the markdown body of its note
+func formatHighlightsNote(article *models.Article, highlights []*models.ArticleHighlight) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source: [%s](%s)\n", article.Title, article.URL)
+	if article.Author != "" {
+		fmt.Fprintf(&b, "Author: %s\n", article.Author)
+	}
+	if article.Date != "" {
+		fmt.Fprintf(&b, "Published: %s\n", article.Date)
+	}
+	fmt.Fprintf(&b, "Saved: %s\n", article.Created.Format("2006-01-02"))
+
+	for _, highlight := range highlights {
+		b.WriteString("\n")
+		for line := range strings.SplitSeq(strings.TrimSpace(highlight.Quote), "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		b.WriteString("\n")
+		if highlight.Note != "" {
+			b.WriteString(highlight.Note + "\n\n")
+		}
+		fmt.Fprintf(&b, "*Highlighted %s*\n", highlight.Created.Format("2006-01-02"))
+	}
+	return b.String()
+}
+
+// markHighlights styles the highlighted parts of an article's markdown. Highlights whose text no
+// longer matches the file, e.g. after the article was saved again, are left out.
+func markHighlights(content string, highlights []*models.ArticleHighlight) string {
+	var b strings.Builder
+	last := 0
+	for _, highlight := range highlights {
+		if highlight.Start < last || highlight.End > len(content) || content[highlight.Start:highlight.End] != highlight.Quote {
+			continue
+		}
+		b.WriteString(content[last:highlight.Start])
+		for i, line := range strings.Split(highlight.Quote, "\n") {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if line != "" {
+				b.WriteString(ui.HighlightStyle.Render(line))
+			}
+		}
+		last = highlight.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// showHighlights lists an article's highlights for [ArticleHandler.View]
+func showHighlights(content []byte, highlights []*models.ArticleHighlight) {
+	ui.Headerln("--- Highlights (%d) ---", len(highlights))
+	for _, highlight := range highlights {
+		location := ""
+		if highlight.End <= len(content) && string(content[highlight.Start:highlight.End]) == highlight.Quote {
+			location = fmt.Sprintf(" line %d,", lineOf(content, highlight.Start))
+		}
+		ui.Plainln("[%d]%s %s", highlight.ID, location, highlight.Created.Format("2006-01-02"))
+		ui.Plainln("  %s", ui.HighlightStyle.Render(collapseQuote(highlight.Quote)))
+		if highlight.Note != "" {
+			ui.Plainln("  %s", ui.MutedStyle.Render(highlight.Note))
+		}
+	}
+	ui.Newline()
+}
+
+func lineOf(content []byte, offset int) int {
+	return 1 + strings.Count(string(content[:offset]), "\n")
+}
+
+func collapseQuote(quote string) string {
+	return strings.Join(strings.Fields(quote), " ")
+}
+
+func mergeTags(tags, more []string) []string {
+	merged := append([]string(nil), tags...)
+	for _, tag := range more {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+const highlightArticleMarkdown = `# Notes on Storage
+
+SQLite is a **small, fast**, self-contained engine. It is the [most used](https://sqlite.org/mostdeployed.html) database
+engine in the world.
+
+Write-ahead logging lets readers continue while a writer commits.
+`
+
+func TestArticleHighlights(t *testing.T) {
+	ctx := context.Background()
+
+	newArticle := func(t *testing.T) (*ArticleTestHelper, int64) {
+		t.Helper()
+		helper := NewArticleTestHelper(t)
+		id := helper.CreateTestArticle(t, "https://example.com/storage", "Notes on Storage", "Kim", "2024-05-01")
+		article, err := helper.repos.Articles.Get(ctx, id)
+		helper.suite.AssertNoError(err, "get article")
+		helper.suite.AssertNoError(os.WriteFile(article.MarkdownPath, []byte(highlightArticleMarkdown), 0o644), "write markdown")
+		return helper, id
+	}
+
+	t.Run("stores the quote as it appears in the markdown", func(t *testing.T) {
+		helper, id := newArticle(t)
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Highlight(ctx, id, "write ahead loging lets readers continue", "  Why WAL helps  "), "highlight")
+		})
+		if !strings.Contains(output, "line 6") {
+			t.Errorf("expected the line of the quote, got:\n%s", output)
+		}
+
+		highlights, err := helper.repos.Articles.ListHighlights(ctx, id)
+		helper.suite.AssertNoError(err, "list highlights")
+		if len(highlights) != 1 {
+			t.Fatalf("expected 1 highlight, got %d", len(highlights))
+		}
+		if highlights[0].Quote != "Write-ahead logging lets readers continue" || highlights[0].Note != "Why WAL helps" {
+			t.Errorf("unexpected highlight: %+v", highlights[0])
+		}
+		if highlightArticleMarkdown[highlights[0].Start:highlights[0].End] != highlights[0].Quote {
+			t.Errorf("offsets do not point at the quote: %d-%d", highlights[0].Start, highlights[0].End)
+		}
+	})
+
+	t.Run("rejects quotes that are empty or not in the article", func(t *testing.T) {
+		helper, id := newArticle(t)
+		helper.suite.AssertError(helper.Highlight(ctx, id, "   ", ""), "empty quote")
+		helper.suite.AssertError(helper.Highlight(ctx, id, "PostgreSQL uses multiversion concurrency control", ""), "missing quote")
+		helper.suite.AssertError(helper.Highlight(ctx, 9999, "SQLite", ""), "unknown article")
+	})
+
+	t.Run("view lists and marks highlights", func(t *testing.T) {
+		helper, id := newArticle(t)
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Highlight(ctx, id, "most used database engine", "Citation needed"), "highlight")
+		})
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.View(ctx, id), "view")
+		})
+		for _, want := range []string{"Highlights (1)", "line 3", "[most used](https://sqlite.org/mostdeployed.html) database engine", "Citation needed"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected view to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("export creates and then updates the linked note", func(t *testing.T) {
+		helper, id := newArticle(t)
+		helper.suite.AssertError(helper.ExportHighlights(ctx, id), "export without highlights")
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Highlight(ctx, id, "self-contained engine", "Embedded"), "highlight")
+			helper.suite.AssertNoError(helper.ExportHighlights(ctx, id), "first export")
+		})
+
+		article, err := helper.repos.Articles.Get(ctx, id)
+		helper.suite.AssertNoError(err, "get article")
+		if article.NoteID == nil {
+			t.Fatal("expected the article to be linked to a note")
+		}
+		noteID := *article.NoteID
+
+		note, err := helper.repos.Notes.Get(ctx, noteID)
+		helper.suite.AssertNoError(err, "get note")
+		for _, want := range []string{"Source: [Notes on Storage](https://example.com/storage)", "Author: Kim", "> self-contained engine", "Embedded", "*Highlighted "} {
+			if !strings.Contains(note.Content, want) {
+				t.Errorf("expected note to contain %q, got:\n%s", want, note.Content)
+			}
+		}
+		if note.Title != "Highlights: Notes on Storage" {
+			t.Errorf("unexpected note title %q", note.Title)
+		}
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Highlight(ctx, id, "readers continue while a writer commits", ""), "second highlight")
+			helper.suite.AssertNoError(helper.ExportHighlights(ctx, id), "second export")
+		})
+
+		note, err = helper.repos.Notes.Get(ctx, noteID)
+		helper.suite.AssertNoError(err, "get updated note")
+		if !strings.Contains(note.Content, "> readers continue while a writer commits") || strings.Index(note.Content, "self-contained") > strings.Index(note.Content, "readers continue") {
+			t.Errorf("expected both highlights in article order, got:\n%s", note.Content)
+		}
+
+		helper.suite.AssertNoError(helper.repos.Notes.Delete(ctx, noteID), "delete note")
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ExportHighlights(ctx, id), "export after the note was deleted")
+		})
+		article, err = helper.repos.Articles.Get(ctx, id)
+		helper.suite.AssertNoError(err, "get article")
+		if article.NoteID == nil || *article.NoteID == noteID {
+			t.Errorf("expected a new note to be linked, got %v", article.NoteID)
+		}
+	})
+
+	t.Run("remove deletes a highlight", func(t *testing.T) {
+		helper, id := newArticle(t)
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Highlight(ctx, id, "SQLite is a small, fast", ""), "highlight")
+		})
+		highlights, err := helper.repos.Articles.ListHighlights(ctx, id)
+		helper.suite.AssertNoError(err, "list highlights")
+
+		helper.suite.AssertError(helper.RemoveHighlight(ctx, id, highlights[0].ID+100), "unknown highlight")
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.RemoveHighlight(ctx, id, highlights[0].ID), "remove highlight")
+		})
+		highlights, err = helper.repos.Articles.ListHighlights(ctx, id)
+		helper.suite.AssertNoError(err, "list highlights")
+		if len(highlights) != 0 {
+			t.Errorf("expected no highlights, got %d", len(highlights))
+		}
+	})
+}
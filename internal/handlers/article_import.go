@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Supported reading list import sources
+const (
+	ImportPocket     = "pocket"
+	ImportInstapaper = "instapaper"
+	ImportWallabag   = "wallabag"
+	ImportBookmarks  = "bookmarks"
+)
+
+// maxListedFailures caps how many failed links are printed after an import
+const maxListedFailures = 10
+
+// bookmarkRootFolders are the top level folders browsers export, which are not useful as tags
+var bookmarkRootFolders = []string{
+	"bookmarks", "bookmarks bar", "bookmarks toolbar", "bookmarks menu", "other bookmarks",
+	"mobile bookmarks", "favorites bar", "favourites bar", "other favorites", "other favourites",
+}
+
+// Import saves the links of a Pocket, Instapaper, Wallabag or browser bookmarks export as articles,
+// keeping when each was saved, its tags and whether it was read or archived. Links that are already
+// saved take that state without being fetched again; the others are fetched and parsed by a pool
+// of workers. Progress is stored as it is made, so running the same import again after an
+// interruption continues where it stopped and retries links that failed.
+func (h *ArticleHandler) Import(ctx context.Context, source, path string) error {
+	switch source {
+	case ImportPocket, ImportInstapaper, ImportWallabag, ImportBookmarks:
+	default:
+		return fmt.Errorf("unsupported import source %q: must be one of pocket, instapaper, wallabag, bookmarks", source)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	imp, err := h.repos.Articles.GetImport(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	if imp == nil {
+		items, err := readReadingList(source, data)
+		if err != nil {
+			return fmt.Errorf("failed to read %s export: %w", source, err)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("no links found in %s", path)
+		}
+
+		imp = &models.ArticleImport{Source: source, Path: path, Hash: hash}
+		if _, err := h.repos.Articles.CreateImport(ctx, imp, items); err != nil {
+			return fmt.Errorf("failed to save import: %w", err)
+		}
+		ui.Infoln("Found %d link(s) in %s", len(items), path)
+	} else {
+		ui.Infoln("Resuming the import of %s started %s", imp.Path, imp.Created.Format("2006-01-02 15:04"))
+	}
+
+	return h.runImport(ctx, imp)
+}
+
+// runImport saves the unfinished items of imp until they are done or the user interrupts
+func (h *ArticleHandler) runImport(ctx context.Context, imp *models.ArticleImport) error {
+	items, err := h.repos.Articles.UnfinishedImportItems(ctx, imp.ID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		ui.Successln("Nothing left to import: all %d link(s) from %s are saved", imp.Total, imp.Path)
+		return nil
+	}
+
+	dir, err := h.getStorageDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to get article storage dir %w", err)
+	}
+
+	bar := ui.NewProgressBar(os.Stdout, "Importing", imp.Total)
+	bar.Add(imp.Total - len(items))
+
+	// Links already in the queue are not fetched again, but take the export's reading state
+	var pending []*models.ArticleImportItem
+	for _, item := range items {
+		existing, err := h.repos.Articles.FindByURL(ctx, item.URL)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			pending = append(pending, item)
+			continue
+		}
+		mergeImportItem(existing, item)
+		if err := h.repos.Articles.MergeImport(ctx, existing, item); err != nil {
+			return err
+		}
+		bar.Add(1)
+	}
+
+	fetchCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	urls := make([]string, len(pending))
+	for i, item := range pending {
+		urls[i] = item.URL
+	}
+
+	opts := h.DefaultSaveOptions()
+	var storeErr error
+	h.parsePages(fetchCtx, urls, func(result pageResult) {
+		item := pending[result.index]
+		if storeErr != nil || (result.err != nil && fetchCtx.Err() != nil) {
+			return // interrupted or broken: leave the item pending for the next run
+		}
+
+		err := result.err
+		if err == nil {
+			err = h.saveImported(ctx, dir, item, result, opts)
+		}
+		if err != nil {
+			item.State, item.Error = models.ImportFailed, err.Error()
+			storeErr = h.repos.Articles.SetImportItemState(ctx, item)
+		}
+		bar.Add(1)
+	})
+	bar.Finish()
+	if storeErr != nil {
+		return storeErr
+	}
+
+	return h.printImportResult(ctx, imp, fetchCtx.Err() != nil)
+}
+
+// saveImported writes a parsed import item and stores it with the state it had in the other application
+func (h *ArticleHandler) saveImported(ctx context.Context, dir string, item *models.ArticleImportItem, result pageResult, opts articles.SaveOptions) error {
+	article, _, err := h.writeArticle(dir, item.URL, result.content, opts)
+	if err != nil {
+		return err
+	}
+	if article.Title == "" {
+		article.Title = item.Title
+	}
+	article.Status = item.Status
+	article.Favorite = item.Favorite
+	article.Tags = item.Tags
+	article.Created = item.Saved
+
+	if err := h.repos.Articles.ImportArticle(ctx, article, item); err != nil {
+		removeArticleFiles(article)
+		return fmt.Errorf("failed to save article to database: %w", err)
+	}
+	return nil
+}
+
+// importStatusRank orders reading statuses by how far along an article is
+var importStatusRank = map[string]int{
+	models.ArticleUnread: 0, models.ArticleReading: 1, models.ArticleRead: 2, models.ArticleArchived: 3,
+}
+
+// mergeImportItem applies an import item to an article saved before the import: tags are
+// combined, a favorite in either place is kept, the status only moves forward (unread, reading,
+// read, archived) and the earlier of the two saved times wins
+func mergeImportItem(article *models.Article, item *models.ArticleImportItem) {
+	for _, tag := range item.Tags {
+		if !slices.Contains(article.Tags, tag) {
+			article.Tags = append(article.Tags, tag)
+		}
+	}
+	article.Favorite = article.Favorite || item.Favorite
+	if importStatusRank[item.Status] > importStatusRank[article.Status] {
+		article.Status = item.Status
+	}
+	if !item.Saved.IsZero() && (article.Created.IsZero() || item.Saved.Before(article.Created)) {
+		article.Created = item.Saved
+	}
+}
+
+func (h *ArticleHandler) printImportResult(ctx context.Context, imp *models.ArticleImport, interrupted bool) error {
+	counts, err := h.repos.Articles.CountImportItems(ctx, imp.ID)
+	if err != nil {
+		return err
+	}
+
+	ui.Successln("Imported %d of %d link(s) from %s", counts[models.ImportDone], imp.Total, imp.Path)
+	if interrupted {
+		ui.Warningln("Import interrupted with %d link(s) left; run the same command again to continue", counts[models.ImportPending]+counts[models.ImportFailed])
+		return nil
+	}
+	if counts[models.ImportFailed] == 0 {
+		return nil
+	}
+
+	failed, err := h.repos.Articles.UnfinishedImportItems(ctx, imp.ID)
+	if err != nil {
+		return err
+	}
+	ui.Warningln("%d link(s) could not be saved:", counts[models.ImportFailed])
+	for i, item := range failed {
+		if i == maxListedFailures {
+			ui.Plainln("  ... and %d more", len(failed)-maxListedFailures)
+			break
+		}
+		ui.Plainln("  %s: %s", item.URL, item.Error)
+	}
+	ui.Infoln("Run the same command again to retry them")
+	return nil
+}
+
+// readReadingList reads the links of an export, dropping links that are not http(s) and
+// repeated URLs
+func readReadingList(source string, data []byte) ([]*models.ArticleImportItem, error) {
+	var items []*models.ArticleImportItem
+	var err error
+
+	switch source {
+	case ImportPocket:
+		if looksLikeHTML(data) {
+			items, err = readPocketHTML(data)
+		} else {
+			items, err = readPocketCSV(data)
+		}
+	case ImportInstapaper:
+		items, err = readInstapaperCSV(data)
+	case ImportWallabag:
+		items, err = readWallabagJSON(data)
+	case ImportBookmarks:
+		items, err = readBookmarksHTML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	return slices.DeleteFunc(items, func(item *models.ArticleImportItem) bool {
+		item.URL = strings.TrimSpace(item.URL)
+		item.Title = strings.TrimSpace(item.Title)
+		item.Tags = cleanKeywords(item.Tags)
+		u, err := url.Parse(item.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || seen[item.URL] {
+			return true
+		}
+		seen[item.URL] = true
+		return false
+	}), nil
+}
+
+func looksLikeHTML(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<"))
+}
+
+// readPocketHTML reads Pocket's ril_export.html, where links are listed under "Unread" and
+// "Read Archive" headings
+func readPocketHTML(data []byte) ([]*models.ArticleImportItem, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.ArticleImportItem
+	archived := false
+	for n := range doc.Descendants() {
+		switch n.DataAtom {
+		case atom.H1:
+			archived = strings.Contains(strings.ToLower(nodeText(n)), "archive")
+		case atom.A:
+			item := &models.ArticleImportItem{
+				URL:   htmlAttr(n, "href"),
+				Title: nodeText(n),
+				Saved: parseUnixTime(htmlAttr(n, "time_added")),
+			}
+			if tags := htmlAttr(n, "tags"); tags != "" {
+				item.Tags = strings.Split(tags, ",")
+			}
+			if archived {
+				item.Status = models.ArticleArchived
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// readPocketCSV reads Pocket's CSV export with title, url, time_added, tags (separated by |) and
+// status columns
+func readPocketCSV(data []byte) ([]*models.ArticleImportItem, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.ArticleImportItem
+	for _, row := range rows {
+		item := &models.ArticleImportItem{
+			URL:   row["url"],
+			Title: row["title"],
+			Saved: parseUnixTime(row["time_added"]),
+		}
+		if row["tags"] != "" {
+			item.Tags = strings.Split(row["tags"], "|")
+		}
+		if row["status"] == "archive" || row["status"] == "archived" {
+			item.Status = models.ArticleArchived
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readInstapaperCSV reads Instapaper's CSV export. The Unread, Archive and Starred folders set
+// the reading state; other folders become tags, along with the Tags column of newer exports.
+func readInstapaperCSV(data []byte) ([]*models.ArticleImportItem, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.ArticleImportItem
+	for _, row := range rows {
+		item := &models.ArticleImportItem{
+			URL:   row["url"],
+			Title: row["title"],
+			Saved: parseUnixTime(row["timestamp"]),
+		}
+
+		switch folder := row["folder"]; strings.ToLower(folder) {
+		case "", "unread":
+		case "archive":
+			item.Status = models.ArticleArchived
+		case "starred":
+			item.Favorite = true
+		default:
+			item.Tags = append(item.Tags, folder)
+		}
+
+		if tags := row["tags"]; tags != "" {
+			var list []string
+			if err := json.Unmarshal([]byte(tags), &list); err != nil {
+				list = strings.Split(tags, ",")
+			}
+			item.Tags = append(item.Tags, list...)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readCSV reads a CSV file with a header row into maps keyed by the lowercased column names
+func readCSV(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	if !slices.Contains(header, "url") {
+		return nil, fmt.Errorf("no url column in the CSV header")
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		row := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// wallabagFlag accepts the 0/1 numbers and booleans Wallabag versions use for flags
+type wallabagFlag bool
+
+func (f *wallabagFlag) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*f = wallabagFlag(value == "1" || value == "true")
+	return nil
+}
+
+// wallabagTag accepts tags exported as plain strings or as objects with a label
+type wallabagTag string
+
+func (t *wallabagTag) UnmarshalJSON(data []byte) error {
+	var label string
+	if err := json.Unmarshal(data, &label); err == nil {
+		*t = wallabagTag(label)
+		return nil
+	}
+	var object struct {
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*t = wallabagTag(object.Label)
+	return nil
+}
+
+// readWallabagJSON reads Wallabag's JSON export, an array of entries
+func readWallabagJSON(data []byte) ([]*models.ArticleImportItem, error) {
+	var entries []struct {
+		URL        string        `json:"url"`
+		Title      string        `json:"title"`
+		IsArchived wallabagFlag  `json:"is_archived"`
+		IsStarred  wallabagFlag  `json:"is_starred"`
+		Tags       []wallabagTag `json:"tags"`
+		CreatedAt  string        `json:"created_at"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	var items []*models.ArticleImportItem
+	for _, entry := range entries {
+		item := &models.ArticleImportItem{
+			URL:      entry.URL,
+			Title:    entry.Title,
+			Favorite: bool(entry.IsStarred),
+		}
+		if entry.IsArchived {
+			item.Status = models.ArticleArchived
+		}
+		for _, tag := range entry.Tags {
+			item.Tags = append(item.Tags, string(tag))
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05-0700", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, entry.CreatedAt); err == nil {
+				item.Saved = t
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readBookmarksHTML reads the Netscape bookmark file Firefox, Chrome and Safari export. The folder
+// path of each bookmark, below the browser's own top level folders, becomes a tag.
+func readBookmarksHTML(data []byte) ([]*models.ArticleImportItem, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.ArticleImportItem
+	for n := range doc.Descendants() {
+		if n.DataAtom != atom.A {
+			continue
+		}
+
+		item := &models.ArticleImportItem{
+			URL:   htmlAttr(n, "href"),
+			Title: nodeText(n),
+			Saved: parseUnixTime(htmlAttr(n, "add_date")),
+		}
+		if tags := htmlAttr(n, "tags"); tags != "" {
+			item.Tags = strings.Split(tags, ",")
+		}
+		if folder := bookmarkFolder(n); folder != "" {
+			item.Tags = append(item.Tags, folder)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// bookmarkFolder returns the folder path of a bookmark link. Each folder is a <DT> holding an
+// <H3> name followed by the <DL> of its contents.
+func bookmarkFolder(link *html.Node) string {
+	var folders []string
+	for n := link.Parent; n != nil; n = n.Parent {
+		if n.DataAtom != atom.Dl || n.Parent == nil || n.Parent.DataAtom != atom.Dt {
+			continue
+		}
+		for child := n.Parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom == atom.H3 {
+				name := nodeText(child)
+				if name != "" && htmlAttr(child, "personal_toolbar_folder") == "" &&
+					!slices.Contains(bookmarkRootFolders, strings.ToLower(name)) {
+					folders = append(folders, name)
+				}
+				break
+			}
+		}
+	}
+	slices.Reverse(folders)
+	return strings.Join(folders, "/")
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			b.WriteString(d.Data)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// parseUnixTime parses a timestamp in seconds since the epoch, returning the zero time when it is not one
+func parseUnixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+)
+
+const pocketHTMLExport = `<!DOCTYPE html>
+<html><head><title>Pocket Export</title></head><body>
+<h1>Unread</h1>
+<ul>
+	<li><a href="%[1]s/posts/one" time_added="1609459200" tags="go,databases">One</a></li>
+	<li><a href="javascript:void(0)" time_added="1609459200">Not a link</a></li>
+	<li><a href="%[1]s/posts/two" time_added="1609545600" tags="">Two</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+	<li><a href="%[1]s/posts/three" time_added="1577836800" tags="old">Three</a></li>
+	<li><a href="%[1]s/posts/one" time_added="1577836800">One again</a></li>
+</ul>
+</body></html>`
+
+const bookmarksExport = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1600000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://example.com/top" ADD_DATE="1600000001">Top</A>
+        <DT><H3 ADD_DATE="1600000000">Reading</H3>
+        <DL><p>
+            <DT><H3 ADD_DATE="1600000000">Go</H3>
+            <DL><p>
+                <DT><A HREF="https://go.dev/blog/" ADD_DATE="1600000002" TAGS="golang">Go Blog</A>
+            </DL><p>
+            <DT><A HREF="https://example.com/reading" ADD_DATE="1600000003">Reading item</A>
+        </DL><p>
+    </DL><p>
+    <DT><A HREF="place:sort=8&maxResults=10">Recent</A>
+</DL><p>`
+
+func TestReadReadingList(t *testing.T) {
+	t.Run("pocket HTML", func(t *testing.T) {
+		items, err := readReadingList(ImportPocket, fmt.Appendf(nil, pocketHTMLExport, "https://example.com"))
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("expected 3 links without the duplicate and javascript link, got %d", len(items))
+		}
+		if items[0].Title != "One" || strings.Join(items[0].Tags, ",") != "go,databases" || items[0].Saved.Unix() != 1609459200 {
+			t.Errorf("unexpected first item: %+v", items[0])
+		}
+		if items[0].Status != "" || items[2].Status != models.ArticleArchived {
+			t.Errorf("expected only the Read Archive link to be archived, got %q and %q", items[0].Status, items[2].Status)
+		}
+	})
+
+	t.Run("pocket CSV", func(t *testing.T) {
+		data := "title,url,time_added,tags,status\n" +
+			"First,https://example.com/a,1700000000,go|rust,unread\n" +
+			"\"Second, with comma\",https://example.com/b,1700000100,,archive\n"
+		items, err := readReadingList(ImportPocket, []byte(data))
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(items) != 2 || strings.Join(items[0].Tags, ",") != "go,rust" || items[1].Title != "Second, with comma" {
+			t.Fatalf("unexpected items: %+v", items)
+		}
+		if items[1].Status != models.ArticleArchived || items[1].Saved.Unix() != 1700000100 {
+			t.Errorf("unexpected second item: %+v", items[1])
+		}
+	})
+
+	t.Run("instapaper CSV", func(t *testing.T) {
+		data := "URL,Title,Selection,Folder,Timestamp,Tags\n" +
+			"https://example.com/a,A,,Unread,1700000000,[]\n" +
+			"https://example.com/b,B,,Archive,1700000001,\"[\"\"go\"\"]\"\n" +
+			"https://example.com/c,C,,Starred,1700000002,\n" +
+			"https://example.com/d,D,,Recipes,1700000003,\n"
+		items, err := readReadingList(ImportInstapaper, []byte(data))
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(items) != 4 {
+			t.Fatalf("expected 4 items, got %d", len(items))
+		}
+		if items[0].Status != "" || len(items[0].Tags) != 0 {
+			t.Errorf("unexpected unread item: %+v", items[0])
+		}
+		if items[1].Status != models.ArticleArchived || strings.Join(items[1].Tags, ",") != "go" {
+			t.Errorf("unexpected archived item: %+v", items[1])
+		}
+		if !items[2].Favorite {
+			t.Errorf("expected starred items to be favourites: %+v", items[2])
+		}
+		if strings.Join(items[3].Tags, ",") != "Recipes" {
+			t.Errorf("expected other folders to become tags: %+v", items[3])
+		}
+	})
+
+	t.Run("wallabag JSON", func(t *testing.T) {
+		data := `[
+			{"url": "https://example.com/a", "title": "A", "is_archived": 1, "is_starred": 0, "tags": ["go", "db"], "created_at": "2016-09-08T11:55:58+0200"},
+			{"url": "https://example.com/b", "title": "B", "is_archived": false, "is_starred": true, "tags": [{"label": "web"}], "created_at": "2020-01-02T03:04:05+00:00"}
+		]`
+		items, err := readReadingList(ImportWallabag, []byte(data))
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		if items[0].Status != models.ArticleArchived || items[0].Favorite || strings.Join(items[0].Tags, ",") != "go,db" || items[0].Saved.IsZero() {
+			t.Errorf("unexpected first item: %+v", items[0])
+		}
+		if items[1].Status != "" || !items[1].Favorite || strings.Join(items[1].Tags, ",") != "web" {
+			t.Errorf("unexpected second item: %+v", items[1])
+		}
+	})
+
+	t.Run("bookmarks HTML", func(t *testing.T) {
+		items, err := readReadingList(ImportBookmarks, []byte(bookmarksExport))
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("expected 3 bookmarks without the place: query, got %d", len(items))
+		}
+
+		tags := map[string]string{}
+		for _, item := range items {
+			tags[item.Title] = strings.Join(item.Tags, ",")
+		}
+		if tags["Top"] != "" || tags["Go Blog"] != "golang,Reading/Go" || tags["Reading item"] != "Reading" {
+			t.Errorf("unexpected folder tags: %v", tags)
+		}
+		if items[0].Saved.Unix() != 1600000001 {
+			t.Errorf("expected ADD_DATE as the saved time, got %v", items[0].Saved)
+		}
+	})
+
+	t.Run("rejects malformed exports", func(t *testing.T) {
+		if _, err := readReadingList(ImportWallabag, []byte("{not json")); err == nil {
+			t.Error("expected an error for invalid JSON")
+		}
+		if _, err := readReadingList(ImportInstapaper, []byte("Title,Folder\nA,Unread\n")); err == nil {
+			t.Error("expected an error for a CSV without a url column")
+		}
+	})
+}
+
+func TestArticleImport(t *testing.T) {
+	ctx := context.Background()
+
+	newHelper := func(t *testing.T) *ArticleTestHelper {
+		t.Helper()
+		helper := NewArticleTestHelper(t)
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title: "//h1[@id='headline']",
+			Body:  "//div[@id='story']",
+		})
+		return helper
+	}
+
+	var failTwo atomic.Bool
+	var pages atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages.Add(1)
+		if r.URL.Path == "/posts/two" && failTwo.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/posts/")
+		fmt.Fprintf(w, `<html><body><h1 id="headline">Story %s</h1><div id="story"><p>The story called %s.</p></div></body></html>`, name, name)
+	}))
+	defer server.Close()
+
+	writeExport := func(t *testing.T, helper *ArticleTestHelper) string {
+		t.Helper()
+		path := filepath.Join(helper.suite.TempDir(), "ril_export.html")
+		helper.suite.AssertNoError(os.WriteFile(path, fmt.Appendf(nil, pocketHTMLExport, server.URL), 0o644), "write export")
+		return path
+	}
+
+	t.Run("rejects unknown sources and missing files", func(t *testing.T) {
+		helper := newHelper(t)
+		helper.suite.AssertError(helper.Import(ctx, "delicious", "export.html"), "unknown source")
+		helper.suite.AssertError(helper.Import(ctx, ImportPocket, filepath.Join(helper.suite.TempDir(), "missing.html")), "missing file")
+
+		empty := filepath.Join(helper.suite.TempDir(), "empty.json")
+		helper.suite.AssertNoError(os.WriteFile(empty, []byte("[]"), 0o644), "write empty export")
+		helper.suite.AssertError(helper.Import(ctx, ImportWallabag, empty), "export without links")
+	})
+
+	t.Run("keeps saved time, tags and state and resumes failed links", func(t *testing.T) {
+		helper := newHelper(t)
+		path := writeExport(t, helper)
+		pages.Store(0)
+		failTwo.Store(true)
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Import(ctx, ImportPocket, path), "first import")
+		})
+		for _, want := range []string{"Found 3 link(s)", "Imported 2 of 3", "/posts/two", "HTTP error: 500", "Importing ["} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		one, err := helper.repos.Articles.GetByURL(ctx, server.URL+"/posts/one")
+		helper.suite.AssertNoError(err, "get first article")
+		if !one.Created.Equal(time.Unix(1609459200, 0)) || strings.Join(one.Tags, ",") != "go,databases" || !one.IsUnread() {
+			t.Errorf("expected the Pocket metadata on the first article, got %+v", one)
+		}
+		three, err := helper.repos.Articles.GetByURL(ctx, server.URL+"/posts/three")
+		helper.suite.AssertNoError(err, "get archived article")
+		if !three.IsArchived() || three.Title != "Story three" {
+			t.Errorf("expected the archived article, got %+v", three)
+		}
+
+		failTwo.Store(false)
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Import(ctx, ImportPocket, path), "resumed import")
+		})
+		if !strings.Contains(output, "Resuming the import") || !strings.Contains(output, "Imported 3 of 3") {
+			t.Errorf("expected the failed link to be retried, got:\n%s", output)
+		}
+		if pages.Load() != 4 {
+			t.Errorf("expected only the failed link to be fetched again, got %d page requests", pages.Load())
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Import(ctx, ImportPocket, path), "finished import")
+		})
+		if !strings.Contains(output, "Nothing left to import") || pages.Load() != 4 {
+			t.Errorf("expected a finished import to do nothing, got:\n%s", output)
+		}
+	})
+
+	t.Run("links already saved take the export's state without being fetched", func(t *testing.T) {
+		helper := newHelper(t)
+		path := writeExport(t, helper)
+		pages.Store(0)
+		failTwo.Store(false)
+
+		oneID := helper.CreateTestArticle(t, server.URL+"/posts/one", "Existing", "", "")
+		threeID := helper.CreateTestArticle(t, server.URL+"/posts/three", "Old", "", "")
+		one, err := helper.repos.Articles.Get(ctx, oneID)
+		helper.suite.AssertNoError(err, "get existing article")
+		one.Tags, one.Status = []string{"mine"}, models.ArticleReading
+		helper.suite.AssertNoError(helper.repos.Articles.Update(ctx, one), "update existing article")
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Import(ctx, ImportPocket, path), "import")
+		})
+		if pages.Load() != 1 {
+			t.Errorf("expected only the new link to be fetched, got %d page requests", pages.Load())
+		}
+
+		one, err = helper.repos.Articles.Get(ctx, oneID)
+		helper.suite.AssertNoError(err, "get merged article")
+		if strings.Join(one.Tags, ",") != "mine,go,databases" || one.Status != models.ArticleReading || !one.Created.Equal(time.Unix(1609459200, 0)) {
+			t.Errorf("expected the export's tags and saved time without going back to unread, got %+v", one)
+		}
+		three, err := helper.repos.Articles.Get(ctx, threeID)
+		helper.suite.AssertNoError(err, "get archived article")
+		if !three.IsArchived() || strings.Join(three.Tags, ",") != "old" || three.Title != "Old" {
+			t.Errorf("expected the existing article to be archived with the export's tags, got %+v", three)
+		}
+
+		all, err := helper.repos.Articles.List(ctx, &repo.ArticleListOptions{})
+		helper.suite.AssertNoError(err, "list articles")
+		if len(all) != 3 {
+			t.Errorf("expected the two existing articles and one imported article, got %d", len(all))
+		}
+	})
+}
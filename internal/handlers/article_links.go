@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+	"golang.org/x/term"
+)
+
+// DefaultArchiveEndpoint is the web archive dead links fall back to when article_archive_endpoint is not set
+const DefaultArchiveEndpoint = "https://web.archive.org/web"
+
+// ArticleCheckOptions controls [ArticleHandler.CheckLinks]
+type ArticleCheckOptions struct {
+	All        bool // check every saved article, archived ones included
+	UpdateURLs bool // replace URLs that permanently redirect without asking
+}
+
+// CheckLinks checks that the URLs of the given articles, or of every article with opts.All, still
+// resolve, and records each status and redirect on the article. Articles whose page is gone and
+// whose saved copy is missing are restored from the configured web archive, and URLs that
+// permanently redirect are updated with opts.UpdateURLs or after asking.
+func (h *ArticleHandler) CheckLinks(ctx context.Context, ids []int64, opts ArticleCheckOptions) error {
+	var list []*models.Article
+	switch {
+	case opts.All:
+		all, err := h.repos.Articles.List(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list articles: %w", err)
+		}
+		list = all
+	case len(ids) > 0:
+		for _, id := range ids {
+			article, err := h.repos.Articles.Get(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to get article %d: %w", id, err)
+			}
+			list = append(list, article)
+		}
+	default:
+		return fmt.Errorf("specify article IDs or use --all")
+	}
+
+	if len(list) == 0 {
+		ui.Warningln("No articles to check.")
+		return nil
+	}
+
+	urls := make([]string, len(list))
+	for i, article := range list {
+		urls[i] = article.URL
+	}
+
+	checkLink := func(u string) (*articles.LinkCheck, error) { return h.parser.CheckLink(u), nil }
+
+	var (
+		storeErr  error
+		ok        int
+		broken    int
+		dead      []*models.Article
+		redirects []*models.Article
+	)
+	fetchPages(ctx, urls, checkLink, func(i int, check *articles.LinkCheck, err error) {
+		article := list[i]
+		if storeErr != nil || err != nil {
+			return // only an interrupted rate limit wait fails; CheckLink reports errors in the check
+		}
+
+		recordLinkCheck(article, check)
+		if storeErr = h.repos.Articles.SaveLinkCheck(ctx, article); storeErr != nil {
+			return
+		}
+
+		switch {
+		case check.Dead():
+			ui.Errorln("  %d %s: %s", article.ID, article.URL, article.LinkError)
+			dead = append(dead, article)
+			broken++
+		case check.Broken():
+			ui.Errorln("  %d %s: %s, may be temporary", article.ID, article.URL, article.LinkError)
+			broken++
+		case check.Permanent():
+			ui.Warningln("  %d %s: moved to %s (%d)", article.ID, article.URL, article.RedirectURL, article.RedirectStatus)
+			redirects = append(redirects, article)
+		default:
+			if article.RedirectURL != "" {
+				ui.Infoln("  %d %s: ok, redirects to %s", article.ID, article.URL, article.RedirectURL)
+			} else {
+				ui.Successln("  %d %s: ok", article.ID, article.URL)
+			}
+			ok++
+		}
+	})
+	if storeErr != nil {
+		return storeErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, article := range dead {
+		if err := h.fetchArchivedCopy(ctx, article); err != nil {
+			return err
+		}
+	}
+	if err := h.updateRedirectedURLs(ctx, redirects, opts.UpdateURLs); err != nil {
+		return err
+	}
+
+	ui.Newline()
+	ui.Successln("Checked %d article(s): %d ok, %d moved, %d broken", len(list), ok, len(redirects), broken)
+	if broken > 0 {
+		ui.Infoln("List them with 'noteleaf article list --broken'")
+	}
+	return nil
+}
+
+// recordLinkCheck copies the outcome of a link check onto article
+func recordLinkCheck(article *models.Article, check *articles.LinkCheck) {
+	now := time.Now()
+	article.LinkChecked = &now
+	article.LinkStatus = check.Status
+	article.LinkError = ""
+	article.RedirectURL = ""
+	article.RedirectStatus = 0
+
+	switch {
+	case check.Err != nil:
+		article.LinkStatus = 0
+		article.LinkError = check.Err.Error()
+	case check.Broken():
+		article.LinkError = fmt.Sprintf("HTTP %d", check.Status)
+	}
+	if len(check.Redirects) > 0 && check.FinalURL != "" && check.FinalURL != check.URL {
+		article.RedirectURL = check.FinalURL
+		article.RedirectStatus = check.Redirects[0].Status
+	}
+}
+
+// fetchArchivedCopy restores a dead article whose saved markdown is missing from its copy on the
+// configured web archive. Articles with a saved copy, highlights and all, are left alone, as are
+// ones already restored. A copy that cannot be fetched is reported without failing the check.
+func (h *ArticleHandler) fetchArchivedCopy(ctx context.Context, article *models.Article) error {
+	if article.ArchiveURL != "" || hasSavedCopy(article) {
+		return nil
+	}
+
+	endpoint := DefaultArchiveEndpoint
+	if h.config != nil && h.config.ArticleArchiveEndpoint != "" {
+		endpoint = h.config.ArticleArchiveEndpoint
+	}
+	archiveURL := articles.ArchiveURL(endpoint, article.URL)
+
+	content, err := h.parseArchivedCopy(archiveURL, article.URL)
+	if err != nil {
+		ui.Warningln("  No archived copy of %d: %v", article.ID, err)
+		return nil
+	}
+	content.Title = article.Title
+
+	dir, err := h.getStorageDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to get article storage dir %w", err)
+	}
+	saved, err := h.parser.SaveArticleWithOptions(content, dir, h.DefaultSaveOptions())
+	if err != nil {
+		return fmt.Errorf("failed to save archived copy: %w", err)
+	}
+
+	article.MarkdownPath, article.HTMLPath = saved.MarkdownPath, saved.HTMLPath
+	article.WordCount, article.ReadingTime = content.WordCount, content.ReadingTime
+	article.ArchiveURL = archiveURL
+	if err := h.repos.Articles.Update(ctx, article); err != nil {
+		removeArticleFiles(article)
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+	if err := h.repos.Articles.SaveLinkCheck(ctx, article); err != nil {
+		return err
+	}
+
+	ui.Infoln("  Restored %d from %s", article.ID, archiveURL)
+	return nil
+}
+
+// hasSavedCopy reports whether the markdown file of article is still on disk. Errors other than
+// a missing file count as present, so a passing permission problem never replaces the copy.
+func hasSavedCopy(article *models.Article) bool {
+	if article.MarkdownPath == "" {
+		return false
+	}
+	_, err := os.Stat(article.MarkdownPath)
+	return !os.IsNotExist(err)
+}
+
+// parseArchivedCopy fetches archiveURL and parses it with the rule for the site of pageURL,
+// since archives serve the page as the site did
+func (h *ArticleHandler) parseArchivedCopy(archiveURL, pageURL string) (*articles.ParsedContent, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	html, err := h.parser.FetchHTML(archiveURL)
+	if err != nil {
+		return nil, err
+	}
+	content, err := h.parser.Parse(html, u.Hostname(), archiveURL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content.Content) == "" {
+		return nil, fmt.Errorf("archived copy has no content")
+	}
+	return content, nil
+}
+
+// updateRedirectedURLs replaces the URLs of articles that permanently redirect with their new
+// location. Without update the user is asked about each one, or told how to do it when stdin
+// is not a terminal.
+func (h *ArticleHandler) updateRedirectedURLs(ctx context.Context, redirected []*models.Article, update bool) error {
+	if len(redirected) == 0 {
+		return nil
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if !update && !interactive {
+		ui.Infoln("%d article(s) moved permanently; check them again with --update-urls to update their URLs", len(redirected))
+		return nil
+	}
+
+	for _, article := range redirected {
+		if !update {
+			fmt.Printf("Update the URL of %d to %s? [y/N]: ", article.ID, article.RedirectURL)
+			var response string
+			fmt.Scanln(&response)
+			if response != "y" && response != "Y" {
+				continue
+			}
+		}
+
+		if err := h.repos.Articles.SetURL(ctx, article.ID, article.RedirectURL); err != nil {
+			ui.Warningln("  Could not update %d: %v", article.ID, err)
+			continue
+		}
+		ui.Successln("  Updated %d to %s", article.ID, article.RedirectURL)
+	}
+	return nil
+}
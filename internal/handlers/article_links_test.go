@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+)
+
+// newLinkServer serves a working page, a page that moved permanently, dead pages, a rate limited
+// page and, under /web/, an archived copy of any page
+func newLinkServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case r.URL.Path == "/gone" || r.URL.Path == "/deleted":
+			http.NotFound(w, r)
+		case r.URL.Path == "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case strings.HasPrefix(r.URL.Path, "/web/"):
+			fmt.Fprint(w, `<html><body><div id="toolbar">Wayback toolbar</div><h1 id="headline">Gone</h1><div id="story"><p>The archived story survives here.</p></div></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><h1 id="headline">Live</h1><div id="story"><p>Still online.</p></div></body></html>`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestArticleCheckLinks(t *testing.T) {
+	ctx := context.Background()
+
+	newHelper := func(t *testing.T, server *httptest.Server) *ArticleTestHelper {
+		t.Helper()
+		helper := NewArticleTestHelper(t)
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title: "//h1[@id='headline']",
+			Body:  "//div[@id='story']",
+		})
+		if helper.config == nil {
+			t.Fatal("expected the handler to have a config")
+		}
+		helper.config.ArticleArchiveEndpoint = server.URL + "/web"
+		return helper
+	}
+
+	t.Run("requires ids or --all", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+		helper.suite.AssertError(helper.CheckLinks(ctx, nil, ArticleCheckOptions{}), "no articles selected")
+		helper.suite.AssertError(helper.CheckLinks(ctx, []int64{999}, ArticleCheckOptions{}), "unknown article")
+	})
+
+	t.Run("records status, updates moved URLs and restores missing copies of dead articles", func(t *testing.T) {
+		server := newLinkServer(t)
+		helper := newHelper(t, server)
+
+		liveID := helper.CreateTestArticle(t, server.URL+"/live", "Live", "Ada", "2024-01-01")
+		movedID := helper.CreateTestArticle(t, server.URL+"/old", "Moved", "Ada", "2024-01-01")
+		deadID := helper.CreateTestArticle(t, server.URL+"/gone", "Gone", "Ada", "2024-01-01")
+		deletedID := helper.CreateTestArticle(t, server.URL+"/deleted", "Deleted", "Ada", "2024-01-01")
+		limitedID := helper.CreateTestArticle(t, server.URL+"/limited", "Limited", "Ada", "2024-01-01")
+
+		dead, err := helper.repos.Articles.Get(ctx, deadID)
+		helper.suite.AssertNoError(err, "get dead article")
+		savedMarkdown, err := os.ReadFile(dead.MarkdownPath)
+		helper.suite.AssertNoError(err, "read saved markdown")
+
+		deleted, err := helper.repos.Articles.Get(ctx, deletedID)
+		helper.suite.AssertNoError(err, "get deleted article")
+		helper.suite.AssertNoError(os.Remove(deleted.MarkdownPath), "remove saved markdown")
+		limited, err := helper.repos.Articles.Get(ctx, limitedID)
+		helper.suite.AssertNoError(err, "get limited article")
+		helper.suite.AssertNoError(os.Remove(limited.MarkdownPath), "remove saved markdown")
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.CheckLinks(ctx, nil, ArticleCheckOptions{All: true, UpdateURLs: true}), "check links")
+		})
+		if !strings.Contains(output, "1 ok, 1 moved, 3 broken") {
+			t.Errorf("expected a summary of the check, got:\n%s", output)
+		}
+
+		live, err := helper.repos.Articles.Get(ctx, liveID)
+		helper.suite.AssertNoError(err, "get live article")
+		if live.LinkChecked == nil || live.LinkStatus != http.StatusOK || live.IsBroken() {
+			t.Errorf("expected the live article to be recorded as ok, got %+v", live)
+		}
+
+		moved, err := helper.repos.Articles.Get(ctx, movedID)
+		helper.suite.AssertNoError(err, "get moved article")
+		if moved.URL != server.URL+"/new" || moved.RedirectURL != "" {
+			t.Errorf("expected the URL to be updated to %s/new, got %q (redirect %q)", server.URL, moved.URL, moved.RedirectURL)
+		}
+
+		dead, err = helper.repos.Articles.Get(ctx, deadID)
+		helper.suite.AssertNoError(err, "get dead article")
+		if !dead.IsBroken() || dead.LinkStatus != http.StatusNotFound || dead.LinkError != "HTTP 404" {
+			t.Errorf("expected the dead article to be recorded as broken, got %+v", dead)
+		}
+		if dead.ArchiveURL != "" {
+			t.Errorf("expected an article with a saved copy not to be restored, got archive URL %q", dead.ArchiveURL)
+		}
+		markdown, err := os.ReadFile(dead.MarkdownPath)
+		helper.suite.AssertNoError(err, "read kept markdown")
+		if string(markdown) != string(savedMarkdown) {
+			t.Errorf("expected the saved copy to be kept, got:\n%s", markdown)
+		}
+
+		deleted, err = helper.repos.Articles.Get(ctx, deletedID)
+		helper.suite.AssertNoError(err, "get deleted article")
+		if deleted.ArchiveURL != server.URL+"/web/"+server.URL+"/deleted" {
+			t.Errorf("unexpected archive URL %q", deleted.ArchiveURL)
+		}
+		if deleted.Title != "Deleted" || deleted.URL != server.URL+"/deleted" {
+			t.Errorf("expected the title and URL to be kept, got %q %q", deleted.Title, deleted.URL)
+		}
+		markdown, err = os.ReadFile(deleted.MarkdownPath)
+		helper.suite.AssertNoError(err, "read restored markdown")
+		if !strings.Contains(string(markdown), "archived story survives") {
+			t.Errorf("expected the archived copy to be saved, got:\n%s", markdown)
+		}
+
+		limited, err = helper.repos.Articles.Get(ctx, limitedID)
+		helper.suite.AssertNoError(err, "get limited article")
+		if !limited.IsBroken() || limited.LinkStatus != http.StatusTooManyRequests || limited.ArchiveURL != "" {
+			t.Errorf("expected a rate limited article to be broken but not restored, got %+v", limited)
+		}
+		if !strings.Contains(output, "HTTP 429, may be temporary") {
+			t.Errorf("expected the rate limit to be reported as temporary, got:\n%s", output)
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ListFiltered(ctx, ArticleListFilter{Broken: true}, false), "list broken")
+		})
+		if !strings.Contains(output, "Gone") || !strings.Contains(output, "Limited") || strings.Contains(output, "Live") || !strings.Contains(output, "broken (HTTP 404)") {
+			t.Errorf("expected only the dead article to be listed, got:\n%s", output)
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.View(ctx, deletedID), "view restored article")
+		})
+		if !strings.Contains(output, "Archived copy: "+deleted.ArchiveURL) {
+			t.Errorf("expected view to show the archived copy, got:\n%s", output)
+		}
+	})
+
+	t.Run("leaves moved URLs alone without a terminal or --update-urls", func(t *testing.T) {
+		server := newLinkServer(t)
+		helper := newHelper(t, server)
+		id := helper.CreateTestArticle(t, server.URL+"/old", "Moved", "Ada", "2024-01-01")
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.CheckLinks(ctx, []int64{id}, ArticleCheckOptions{}), "check links")
+		})
+		if !strings.Contains(output, "--update-urls") {
+			t.Errorf("expected a hint about --update-urls, got:\n%s", output)
+		}
+
+		article, err := helper.repos.Articles.Get(ctx, id)
+		helper.suite.AssertNoError(err, "get article")
+		if article.URL != server.URL+"/old" || article.RedirectURL != server.URL+"/new" || article.RedirectStatus != http.StatusMovedPermanently {
+			t.Errorf("expected the redirect to be recorded without changing the URL, got %+v", article)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// Cite prints the citation of an article as BibTeX, CSL-JSON or an APA reference. Articles saved
+// from web pages are cited as web pages.
+func (h *ArticleHandler) Cite(ctx context.Context, id int64, format string) error {
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+
+	output, err := articles.FormatCitations([]*models.Citation{articles.ArticleCitation(article)}, format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+	return nil
+}
+
+// ExportCitations writes the citations of every saved article, archived ones included, to path,
+// or to standard output when path is empty
+func (h *ArticleHandler) ExportCitations(ctx context.Context, format, path string) error {
+	saved, err := h.repos.Articles.List(ctx, &repo.ArticleListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list articles: %w", err)
+	}
+	if len(saved) == 0 {
+		return fmt.Errorf("no articles to export")
+	}
+
+	citations := make([]*models.Citation, 0, len(saved))
+	for _, article := range saved {
+		citations = append(citations, articles.ArticleCitation(article))
+	}
+
+	output, err := articles.FormatCitations(citations, format)
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		fmt.Print(output)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		return fmt.Errorf("failed to write citations: %w", err)
+	}
+	ui.Successln("Exported %d citation(s) to %s", len(citations), path)
+	return nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+)
+
+const paperArxivResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/2009.03017v1</id>
+    <published>2020-09-07T10:12:00Z</published>
+    <title>Sparse Retrieval for Small Archives</title>
+    <summary>Personal archives rarely need dense vector indexes.</summary>
+    <author><name>Ada Lovelace</name></author>
+  </entry>
+</feed>`
+
+func TestArticleCitations(t *testing.T) {
+	ctx := context.Background()
+
+	newHelper := func(t *testing.T) *ArticleTestHelper {
+		t.Helper()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("id_list") != "2009.03017" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(paperArxivResponse))
+		}))
+		t.Cleanup(server.Close)
+
+		helper := NewArticleTestHelper(t)
+		helper.ArticleHandler.parser.(*articles.ArticleParser).SetPaperServices(server.URL+"/api/query", server.URL+"/works/")
+		return helper
+	}
+
+	t.Run("adds an arXiv paper by id and cites it", func(t *testing.T) {
+		helper := newHelper(t)
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Add(ctx, "arxiv:2009.03017"), "add paper")
+		})
+
+		article, err := helper.repos.Articles.GetByURL(ctx, "https://arxiv.org/abs/2009.03017")
+		helper.suite.AssertNoError(err, "paper should be stored under its abstract page")
+		if article.Citation == nil || article.Citation.Number != "arXiv:2009.03017" {
+			t.Fatalf("expected the arXiv citation to be stored, got %+v", article.Citation)
+		}
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Add(ctx, "arXiv:2009.03017"), "add the paper again")
+		})
+		if !strings.Contains(output, "already exists") {
+			t.Errorf("expected the shorthand to find the saved paper, got:\n%s", output)
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Cite(ctx, article.ID, "bibtex"), "cite bibtex")
+		})
+		for _, want := range []string{"@misc{lovelace2020sparse,", "eprint = {2009.03017}", "doi = {10.48550/arXiv.2009.03017}"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected BibTeX to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Cite(ctx, article.ID, "apa"), "cite apa")
+		})
+		if !strings.HasPrefix(output, "Lovelace, A. (2020). Sparse Retrieval for Small Archives. *arXiv*.") {
+			t.Errorf("unexpected APA reference:\n%s", output)
+		}
+
+		helper.suite.AssertError(helper.Cite(ctx, article.ID, "mla"), "unknown format")
+		helper.suite.AssertError(helper.Cite(ctx, 9999, "bibtex"), "unknown article")
+	})
+
+	t.Run("exports the whole library", func(t *testing.T) {
+		helper := newHelper(t)
+		helper.suite.AssertError(helper.ExportCitations(ctx, "bibtex", ""), "empty library")
+
+		helper.CreateTestArticle(t, "https://example.com/wal", "Why Write-Ahead Logs", "Kim Lee", "2024-05-01")
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.Add(ctx, "arxiv:2009.03017"), "add paper")
+		})
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ExportCitations(ctx, "bibtex", ""), "export to stdout")
+		})
+		if strings.Count(output, "\n@") != 1 || !strings.Contains(output, "@misc{lee2024why,") || !strings.Contains(output, "@misc{lovelace2020sparse,") {
+			t.Errorf("expected a web page and a paper entry, got:\n%s", output)
+		}
+
+		path := filepath.Join(helper.suite.TempDir(), "library.json")
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ExportCitations(ctx, "csl-json", path), "export to file")
+		})
+		data, err := os.ReadFile(path)
+		helper.suite.AssertNoError(err, "read export")
+		if !strings.Contains(string(data), `"id": "lee2024why"`) || !strings.Contains(string(data), `"type": "webpage"`) {
+			t.Errorf("unexpected CSL-JSON export:\n%s", data)
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+	"github.com/stormlightlabs/noteleaf/internal/store"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+const (
+	articleRulesDirName   = "article-rules"           // beside the config file
+	articleFixturesDir    = "article-fixtures"        // in the data directory
+	articleRuleTestsFile  = "article-rule-tests.json" // in the data directory
+	articleRuleSourceUser = "user"
+)
+
+// ruleTestRecord is the last result of `article rules test` for a domain
+type ruleTestRecord struct {
+	Tested     time.Time `json:"tested"`
+	Passed     int       `json:"passed"`
+	Total      int       `json:"total"`
+	Confidence float64   `json:"confidence"`
+}
+
+// articleRulesDir returns the directory user rules are loaded from, <config_dir>/article-rules
+func articleRulesDir() (string, error) {
+	configPath, err := store.GetConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config path: %w", err)
+	}
+	return filepath.Join(filepath.Dir(configPath), articleRulesDirName), nil
+}
+
+// loadUserRules adds the rules in the user rules directory to parser, replacing embedded rules for the same domains
+func loadUserRules(parser *articles.ArticleParser) error {
+	dir, err := articleRulesDir()
+	if err != nil {
+		return err
+	}
+	if err := parser.LoadRulesDir(dir); err != nil {
+		return fmt.Errorf("failed to load article rules from %s: %w", dir, err)
+	}
+	return nil
+}
+
+func loadRuleTests() (map[string]ruleTestRecord, string, error) {
+	dataDir, err := store.GetDataDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get data directory: %w", err)
+	}
+	path := filepath.Join(dataDir, articleRuleTestsFile)
+
+	records := map[string]ruleTestRecord{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return records, path, nil
+	} else if err != nil {
+		return nil, "", fmt.Errorf("failed to read rule test results: %w", err)
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, "", fmt.Errorf("failed to parse rule test results: %w", err)
+	}
+	return records, path, nil
+}
+
+// ListRules prints the loaded parsing rules with where each came from and its last test result
+func (h *ArticleHandler) ListRules(ctx context.Context) error {
+	records, _, err := loadRuleTests()
+	if err != nil {
+		return err
+	}
+
+	rules := h.parser.Rules()
+	if len(rules) == 0 {
+		ui.Warningln("No parsing rules loaded.")
+		return nil
+	}
+
+	ui.Headerln("Parsing rules (%d):", len(rules))
+	for _, rule := range rules {
+		source := rule.Source
+		if source != articles.RuleSourceEmbedded {
+			source = articleRuleSourceUser
+		}
+
+		result := "not tested"
+		if record, ok := records[rule.Domain]; ok {
+			result = fmt.Sprintf("%d/%d passed, confidence %.2f (%s)", record.Passed, record.Total, record.Confidence, record.Tested.Format("2006-01-02"))
+		}
+		ui.Plainln("  %-32s %-8s %s", rule.Domain, source, result)
+	}
+
+	if dir, err := articleRulesDir(); err == nil {
+		ui.Newline()
+		ui.Headerln("%s %s", ui.TableHeaderStyle.Render("User rules directory:"), dir)
+	}
+	return nil
+}
+
+// TestRule parses each test_url of the rule for domain and reports whether the rule extracted a
+// title and body, and with what confidence. Pages are read from cached fixtures when available
+// and parsed without following page links; live fetches every page and refreshes its fixture.
+func (h *ArticleHandler) TestRule(ctx context.Context, domain string, live bool) error {
+	rule := h.parser.Rule(domain)
+	if rule == nil {
+		return fmt.Errorf("no parsing rule for %s", domain)
+	}
+	if len(rule.TestURLs) == 0 {
+		return fmt.Errorf("rule %s has no test_url lines", rule.Domain)
+	}
+
+	ui.Titleln("Testing %s (%d URL(s))", rule.Domain, len(rule.TestURLs))
+
+	record := ruleTestRecord{Tested: time.Now(), Total: len(rule.TestURLs)}
+	var confidence float64
+	for _, testURL := range rule.TestURLs {
+		htmlContent, cached, err := h.ruleFixture(rule.Domain, testURL, live)
+		if err != nil {
+			ui.Errorln("  FAIL %s: %v", testURL, err)
+			continue
+		}
+
+		parsedURL, err := url.Parse(testURL)
+		if err != nil {
+			ui.Errorln("  FAIL %s: invalid URL: %v", testURL, err)
+			continue
+		}
+
+		// a fixture is tested on its own: following its page links would need the network
+		parse := h.parser.Parse
+		if cached {
+			parse = h.parser.ParseOffline
+		}
+		content, err := parse(htmlContent, parsedURL.Hostname(), testURL)
+		switch {
+		case err != nil:
+			ui.Errorln("  FAIL %s: %v", testURL, err)
+			continue
+		case content.Rule == "":
+			ui.Errorln("  FAIL %s: body not found, the heuristics extracted the page instead", testURL)
+			continue
+		case content.Rule != rule.Domain:
+			ui.Errorln("  FAIL %s: the rule for %s applies to this URL instead", testURL, content.Rule)
+			continue
+		case content.Content == "":
+			ui.Errorln("  FAIL %s: the rule has no body", testURL)
+			continue
+		}
+
+		source := "live"
+		if cached {
+			source = "fixture"
+		}
+		ui.Successln("  PASS %s (%s)", testURL, source)
+		ui.Plainln("       %s, %d words, %d page(s), %s, confidence %.2f",
+			content.Title, len(strings.Fields(content.Content)), content.Pages, content.ExtractionMethod, content.Confidence)
+
+		record.Passed++
+		confidence += content.Confidence
+	}
+
+	if record.Passed > 0 {
+		record.Confidence = confidence / float64(record.Passed)
+	}
+
+	records, path, err := loadRuleTests()
+	if err != nil {
+		return err
+	}
+	records[rule.Domain] = record
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode rule test results: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save rule test results: %w", err)
+	}
+
+	ui.Newline()
+	if record.Passed < record.Total {
+		return fmt.Errorf("%d of %d test URL(s) failed for %s", record.Total-record.Passed, record.Total, rule.Domain)
+	}
+	ui.Successln("All %d test URL(s) passed", record.Total)
+	return nil
+}
+
+// fixturePath returns where the page at testURL is cached for the rule for domain
+func fixturePath(domain, testURL string) (string, error) {
+	dataDir, err := store.GetDataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get data directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(testURL))
+	return filepath.Join(dataDir, articleFixturesDir, domain, hex.EncodeToString(sum[:8])+".html"), nil
+}
+
+func saveFixture(path, htmlContent string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(htmlContent), 0o644); err != nil {
+		return fmt.Errorf("failed to save fixture: %w", err)
+	}
+	return nil
+}
+
+// ruleFixture returns the cached copy of a test URL, fetching and caching it when there is none or live is set
+func (h *ArticleHandler) ruleFixture(domain, testURL string, live bool) (string, bool, error) {
+	path, err := fixturePath(domain, testURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	if !live {
+		if data, err := os.ReadFile(path); err == nil {
+			return string(data), true, nil
+		}
+	}
+
+	htmlContent, err := h.parser.FetchHTML(testURL)
+	if err != nil {
+		return "", false, err
+	}
+	return htmlContent, false, saveFixture(path, htmlContent)
+}
+
+// NewRule drafts a rule for the host of pageURL from heuristic extraction and saves it to the
+// user rules directory, refusing to replace an existing user rule unless force is set
+func (h *ArticleHandler) NewRule(ctx context.Context, pageURL string, force bool) error {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil || parsedURL.Hostname() == "" {
+		return fmt.Errorf("invalid URL: %s", pageURL)
+	}
+	domain := strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
+
+	dir, err := articleRulesDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, domain+".txt")
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("rule %s already exists, use --force to replace it", path)
+	}
+
+	htmlContent, err := h.parser.FetchHTML(pageURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch page: %w", err)
+	}
+
+	draft, err := h.parser.ScaffoldRule(htmlContent, pageURL)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create rules directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(draft), 0o644); err != nil {
+		return fmt.Errorf("failed to write rule: %w", err)
+	}
+	if fixture, err := fixturePath(domain, pageURL); err == nil {
+		if err := saveFixture(fixture, htmlContent); err != nil {
+			ui.Warningln("Could not cache the page for rule tests: %v", err)
+		}
+	}
+
+	ui.Successln("Drafted rule %s", path)
+	ui.Newline()
+	ui.Plainln("%s", strings.TrimRight(draft, "\n"))
+	ui.Newline()
+	ui.Infoln("Review the XPaths, then run: noteleaf article rules test %s", domain)
+	return nil
+}
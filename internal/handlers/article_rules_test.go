@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/articles"
+)
+
+const ruleTestPage = `<html>
+	<head><title>Rule Test Article</title><meta name="author" content="Ada Writer"></head>
+	<body>
+		<nav><a href="/">Home</a></nav>
+		<h1 id="headline">Rule Test Article</h1>
+		<div id="story">
+			<p>The first paragraph of a long story about rules, with enough words to look like an article body.</p>
+			<p>The second paragraph keeps going so the heuristics are confident this is the content of the page.</p>
+			<p>The third paragraph wraps things up, closing the story about parsing rules and their tests.</p>
+		</div>
+	</body>
+</html>`
+
+func TestArticleRules(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user rules replace embedded rules", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		dir := filepath.Join(suite.TempDir(), articleRulesDirName)
+		suite.AssertNoError(os.MkdirAll(dir, 0o755), "create rules dir")
+		suite.AssertNoError(os.WriteFile(filepath.Join(dir, "arxiv.org.txt"), []byte("title: //h2\nbody: //main\n"), 0o644), "write rule")
+
+		handler, err := NewArticleHandler()
+		suite.AssertNoError(err, "create handler")
+		defer handler.Close()
+
+		rule := handler.parser.Rule("arxiv.org")
+		if rule == nil || rule.Title != "//h2" || rule.Source != filepath.Join(dir, "arxiv.org.txt") {
+			t.Fatalf("expected the user rule to replace the embedded one, got %+v", rule)
+		}
+
+		output := captureStdout(t, func() {
+			suite.AssertNoError(handler.ListRules(ctx), "list rules")
+		})
+		for _, want := range []string{"arxiv.org", "user", "baseballprospectus.com", articles.RuleSourceEmbedded, "not tested", dir} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected rules list to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("test runs test URLs and caches fixtures", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Write([]byte(ruleTestPage))
+		}))
+		defer server.Close()
+
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title:    "//h1[@id='headline']",
+			Body:     "//div[@id='story']",
+			TestURLs: []string{server.URL + "/story"},
+		})
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.TestRule(ctx, "127.0.0.1", false), "first test run")
+			helper.suite.AssertNoError(helper.TestRule(ctx, "127.0.0.1", false), "second test run")
+		})
+		if requests.Load() != 1 {
+			t.Errorf("expected the second run to use the cached fixture, got %d requests", requests.Load())
+		}
+		if !strings.Contains(output, "PASS") || !strings.Contains(output, "(fixture)") {
+			t.Errorf("expected passing runs from live and fixture, got:\n%s", output)
+		}
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.TestRule(ctx, "127.0.0.1", true), "live test run")
+		})
+		if requests.Load() != 2 {
+			t.Errorf("expected --live to fetch again, got %d requests", requests.Load())
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.ListRules(ctx), "list rules")
+		})
+		if !strings.Contains(output, "1/1 passed") {
+			t.Errorf("expected the last test result in the list, got:\n%s", output)
+		}
+	})
+
+	t.Run("test does not follow page links from fixtures", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.URL.Path == "/story/2" {
+				w.Write([]byte(`<html><body><h1 id="headline">Rule Test Article</h1><div id="story"><p>The second page of the story.</p></div></body></html>`))
+				return
+			}
+			w.Write([]byte(strings.Replace(ruleTestPage, "</div>", `</div><a class="next" href="/story/2">Next</a>`, 1)))
+		}))
+		defer server.Close()
+
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title:         "//h1[@id='headline']",
+			Body:          "//div[@id='story']",
+			NextPageLinks: []string{"//a[@class='next']"},
+			TestURLs:      []string{server.URL + "/story"},
+		})
+
+		output := captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.TestRule(ctx, "127.0.0.1", false), "first test run")
+		})
+		if requests.Load() != 2 || !strings.Contains(output, "2 page(s)") {
+			t.Fatalf("expected the first run to fetch both pages, got %d requests:\n%s", requests.Load(), output)
+		}
+
+		output = captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.TestRule(ctx, "127.0.0.1", false), "fixture test run")
+		})
+		if requests.Load() != 2 {
+			t.Errorf("expected the fixture run to stay offline, got %d requests", requests.Load())
+		}
+		if !strings.Contains(output, "(fixture)") || !strings.Contains(output, "1 page(s)") {
+			t.Errorf("expected the fixture to be parsed on its own, got:\n%s", output)
+		}
+	})
+
+	t.Run("test fails when the body is missing", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(ruleTestPage))
+		}))
+		defer server.Close()
+
+		helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+			Title:    "//h1[@id='headline']",
+			Body:     "//div[@id='missing']",
+			TestURLs: []string{server.URL + "/story"},
+		})
+
+		var err error
+		output := captureStdout(t, func() {
+			err = helper.TestRule(ctx, "127.0.0.1", false)
+		})
+		helper.suite.AssertError(err, "test should fail")
+		if !strings.Contains(output, "FAIL") {
+			t.Errorf("expected a failing URL, got:\n%s", output)
+		}
+	})
+
+	t.Run("test rejects unknown domains and rules without test URLs", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+		helper.AddTestRule("example.org", &articles.ParsingRule{Title: "//h1"})
+
+		helper.suite.AssertError(helper.TestRule(ctx, "unknown.example", false), "unknown domain")
+		helper.suite.AssertError(helper.TestRule(ctx, "example.org", false), "no test URLs")
+	})
+
+	t.Run("new drafts a rule from heuristic extraction", func(t *testing.T) {
+		helper := NewArticleTestHelper(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(ruleTestPage))
+		}))
+		defer server.Close()
+		pageURL := server.URL + "/story"
+
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.NewRule(ctx, pageURL, false), "draft rule")
+		})
+
+		path := filepath.Join(helper.suite.TempDir(), articleRulesDirName, "127.0.0.1.txt")
+		data, err := os.ReadFile(path)
+		helper.suite.AssertNoError(err, "read drafted rule")
+		for _, want := range []string{"title: //h1[@id='headline']", "author: //meta[@name='author']/@content", "body: //div[@id='story']", "test_url: " + pageURL} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("expected the draft to contain %q, got:\n%s", want, data)
+			}
+		}
+
+		helper.suite.AssertError(helper.NewRule(ctx, pageURL, false), "existing rule without --force")
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(helper.NewRule(ctx, pageURL, true), "replace rule with --force")
+		})
+
+		handler, err := NewArticleHandler()
+		helper.suite.AssertNoError(err, "reload handler")
+		defer handler.Close()
+
+		server.Close()
+		captureStdout(t, func() {
+			helper.suite.AssertNoError(handler.TestRule(ctx, "127.0.0.1", false), "drafted rule passes from its fixture")
+		})
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -51,6 +52,9 @@ func NewArticleHandler() (*ArticleHandler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize article parser: %w", err)
 	}
+	if err := loadUserRules(parser); err != nil {
+		return nil, err
+	}
 
 	return &ArticleHandler{
 		db:     db,
@@ -108,8 +112,26 @@ func (h *ArticleHandler) Close() error {
 	return nil
 }
 
-// Add handles adding an article from a URL
+// Add handles adding an article from a URL, archiving its assets when the config asks for it
 func (h *ArticleHandler) Add(ctx context.Context, url string) error {
+	return h.AddWithOptions(ctx, url, h.DefaultSaveOptions())
+}
+
+// DefaultSaveOptions returns the archiving options set by article_archive and article_self_contained
+func (h *ArticleHandler) DefaultSaveOptions() articles.SaveOptions {
+	if h.config == nil {
+		return articles.SaveOptions{}
+	}
+	return articles.SaveOptions{
+		Archive:       h.config.ArticleArchive || h.config.ArticleSelfContained,
+		SelfContained: h.config.ArticleSelfContained,
+	}
+}
+
+// AddWithOptions handles adding an article from a URL, an arxiv:<id> or a doi:<doi>, downloading
+// its images and media for offline reading when opts.Archive is set
+func (h *ArticleHandler) AddWithOptions(ctx context.Context, url string, opts articles.SaveOptions) error {
+	url = articles.ResolveReference(url)
 	existing, err := h.repos.Articles.GetByURL(ctx, url)
 	if err == nil {
 		ui.Warningln("Article already exists: %s (ID: %d)", ui.TableTitleStyle.Render(existing.Title), existing.ID)
@@ -127,32 +149,20 @@ func (h *ArticleHandler) Add(ctx context.Context, url string) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse article: %w", err)
 	}
-
-	mdPath, htmlPath, err := h.parser.SaveArticle(content, dir)
-	if err != nil {
-		return fmt.Errorf("failed to save article: %w", err)
+	if content.NativeAd {
+		ui.Warningln("This page looks like sponsored content (native ad)")
 	}
-
-	article := &models.Article{
-		URL:          url,
-		Title:        content.Title,
-		Author:       content.Author,
-		Date:         content.Date,
-		MarkdownPath: mdPath,
-		HTMLPath:     htmlPath,
-		Created:      time.Now(),
-		Modified:     time.Now(),
+	if content.Pages > 1 {
+		ui.Infoln("Collected %d pages", content.Pages)
 	}
 
-	id, err := h.repos.Articles.Create(ctx, article)
+	article, saved, err := h.saveParsed(ctx, dir, url, content, opts, nil)
 	if err != nil {
-		os.Remove(article.MarkdownPath)
-		os.Remove(article.HTMLPath)
-		return fmt.Errorf("failed to save article to database: %w", err)
+		return err
 	}
 
 	ui.Infoln("Article saved successfully!")
-	ui.Infoln("ID: %d", id)
+	ui.Infoln("ID: %d", article.ID)
 	ui.Infoln("Title: %s", ui.TableTitleStyle.Render(article.Title))
 	if article.Author != "" {
 		ui.Infoln("Author: %s", ui.TableHeaderStyle.Render(article.Author))
@@ -160,21 +170,118 @@ func (h *ArticleHandler) Add(ctx context.Context, url string) error {
 	if article.Date != "" {
 		ui.Infoln("Date: %s", article.Date)
 	}
+	if article.WordCount > 0 {
+		ui.Infoln("Length: %d words, about %d min", article.WordCount, article.ReadingTime)
+	}
 	ui.Infoln("Markdown: %s", article.MarkdownPath)
 	ui.Infoln("HTML: %s", article.HTMLPath)
+	if saved.AssetDir != "" {
+		ui.Infoln("Assets: %s (%d file(s))", saved.AssetDir, saved.Assets)
+	}
+	if len(saved.Failed) > 0 {
+		ui.Warningln("%d asset(s) could not be downloaded and still point at the site", len(saved.Failed))
+	}
+	if article.Citation != nil {
+		ui.Infoln("Cite it with 'noteleaf article cite %d'", article.ID)
+	}
 
 	return nil
 }
 
-// List handles listing articles with optional filtering
+// saveParsed writes parsed content to dir and records it as an unread article with tags,
+// removing the files again when the database insert fails
+func (h *ArticleHandler) saveParsed(ctx context.Context, dir, url string, content *articles.ParsedContent, opts articles.SaveOptions, tags []string) (*models.Article, *articles.SavedArticle, error) {
+	article, saved, err := h.writeArticle(dir, url, content, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	article.Tags = tags
+
+	if _, err := h.repos.Articles.Create(ctx, article); err != nil {
+		removeArticleFiles(article)
+		return nil, nil, fmt.Errorf("failed to save article to database: %w", err)
+	}
+	return article, saved, nil
+}
+
+// writeArticle writes parsed content to dir and returns the unread article describing it, which
+// the caller still has to store
+func (h *ArticleHandler) writeArticle(dir, url string, content *articles.ParsedContent, opts articles.SaveOptions) (*models.Article, *articles.SavedArticle, error) {
+	saved, err := h.parser.SaveArticleWithOptions(content, dir, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save article: %w", err)
+	}
+
+	article := &models.Article{
+		URL:          url,
+		Title:        content.Title,
+		Author:       content.Author,
+		Date:         content.Date,
+		MarkdownPath: saved.MarkdownPath,
+		HTMLPath:     saved.HTMLPath,
+		Status:       models.ArticleUnread,
+		WordCount:    content.WordCount,
+		ReadingTime:  content.ReadingTime,
+		Citation:     content.Citation,
+		Created:      time.Now(),
+		Modified:     time.Now(),
+	}
+	return article, saved, nil
+}
+
+// removeArticleFiles deletes the files written for an article that could not be stored
+func removeArticleFiles(article *models.Article) {
+	os.Remove(article.MarkdownPath)
+	os.Remove(article.HTMLPath)
+	if article.MarkdownPath != "" {
+		os.RemoveAll(articles.AssetDir(article.MarkdownPath))
+	}
+}
+
+// ArticleListFilter selects the articles shown by [ArticleHandler.ListFiltered]
+type ArticleListFilter struct {
+	Query     string   // matched against titles
+	Author    string   // matched against authors
+	Status    string   // unread, reading, read or archived; archived articles are hidden when empty
+	Tags      []string // articles must have every tag
+	Favorites bool     // only favourites
+	Broken    bool     // only articles whose last link check failed, archived ones included
+	Limit     int
+}
+
+// List handles listing articles with optional filtering, leaving out archived articles
 func (h *ArticleHandler) List(ctx context.Context, query string, author string, limit int) error {
-	opts := &repo.ArticleListOptions{
-		Title:  query,
-		Author: author,
-		Limit:  limit,
+	return h.ListFiltered(ctx, ArticleListFilter{Query: query, Author: author, Limit: limit}, false)
+}
+
+// ListFiltered prints the articles matching filter with their reading state, or opens them in an
+// interactive list when interactive is set
+func (h *ArticleHandler) ListFiltered(ctx context.Context, filter ArticleListFilter, interactive bool) error {
+	if filter.Status != "" && !slices.Contains((&models.Article{}).ValidStatuses(), filter.Status) {
+		return fmt.Errorf("invalid status filter: %s (use: %s)", filter.Status, strings.Join((&models.Article{}).ValidStatuses(), ", "))
+	}
+
+	archived := false
+	opts := repo.ArticleListOptions{
+		Title:    filter.Query,
+		Author:   filter.Author,
+		Status:   filter.Status,
+		Archived: &archived,
+		Favorite: filter.Favorites,
+		Tags:     filter.Tags,
+		Broken:   filter.Broken,
+		Limit:    filter.Limit,
+	}
+	if filter.Broken && filter.Status == "" {
+		opts.Archived = nil
+	}
+
+	if interactive {
+		articleList := ui.NewArticleListFromList(h.repos.Articles, os.Stdout, os.Stdin, false, opts)
+		return articleList.Browse(ctx)
 	}
 
-	articles, err := h.repos.Articles.List(ctx, opts)
+	articles, err := h.repos.Articles.List(ctx, &opts)
 	if err != nil {
 		return fmt.Errorf("failed to list articles: %w", err)
 	}
@@ -187,7 +294,11 @@ func (h *ArticleHandler) List(ctx context.Context, query string, author string,
 	ui.Infoln("Found %d article(s):\n", len(articles))
 	for _, article := range articles {
 		ui.Infoln("ID: %d", article.ID)
-		ui.Infoln("Title: %s", ui.TableTitleStyle.Render(article.Title))
+		title := article.Title
+		if article.Favorite {
+			title = "★ " + title
+		}
+		ui.Infoln("Title: %s", ui.TableTitleStyle.Render(title))
 		if article.Author != "" {
 			ui.Infoln("Author: %s", ui.TableHeaderStyle.Render(article.Author))
 		}
@@ -195,12 +306,143 @@ func (h *ArticleHandler) List(ctx context.Context, query string, author string,
 			ui.Infoln("Date: %s", article.Date)
 		}
 		ui.Infoln("URL: %s", article.URL)
+		ui.Infoln("Status: %s", formatArticleStatus(article))
+		if article.IsBroken() {
+			ui.Warningln("Link: %s", formatLinkStatus(article))
+		}
+		if article.ReadingTime > 0 {
+			ui.Infoln("Reading time: %d min", article.ReadingTime)
+		}
+		if len(article.Tags) > 0 {
+			ui.Infoln("Tags: %s", strings.Join(article.Tags, ", "))
+		}
 		ui.Infoln("Added: %s", article.Created.Format("2006-01-02 15:04:05"))
 		ui.Plainln("---")
 	}
 	return nil
 }
 
+// formatArticleStatus returns the status of an article with its progress while it is being read
+func formatArticleStatus(article *models.Article) string {
+	if article.Status == models.ArticleReading && article.Progress > 0 {
+		return fmt.Sprintf("%s (%d%%)", article.Status, article.Progress)
+	}
+	return article.Status
+}
+
+// formatLinkStatus describes the result of the last link check of an article
+func formatLinkStatus(article *models.Article) string {
+	var status string
+	switch {
+	case article.LinkChecked == nil:
+		return "not checked"
+	case article.IsBroken():
+		status = "broken"
+		if article.LinkError != "" {
+			status += " (" + article.LinkError + ")"
+		}
+	case article.RedirectURL != "":
+		status = fmt.Sprintf("redirects to %s (%d)", article.RedirectURL, article.RedirectStatus)
+	default:
+		status = fmt.Sprintf("ok (%d)", article.LinkStatus)
+	}
+	return status + ", checked " + article.LinkChecked.Format("2006-01-02 15:04")
+}
+
+// MarkDone marks an article as read
+func (h *ArticleHandler) MarkDone(ctx context.Context, id int64) error {
+	return h.setStatus(ctx, id, models.ArticleRead)
+}
+
+// Archive moves an article out of the reading queue without deleting it
+func (h *ArticleHandler) Archive(ctx context.Context, id int64) error {
+	return h.setStatus(ctx, id, models.ArticleArchived)
+}
+
+// UpdateStatus sets the reading status of an article (unread, reading, read or archived)
+func (h *ArticleHandler) UpdateStatus(ctx context.Context, id int64, status string) error {
+	if !slices.Contains((&models.Article{}).ValidStatuses(), status) {
+		return fmt.Errorf("invalid status: %s (use: %s)", status, strings.Join((&models.Article{}).ValidStatuses(), ", "))
+	}
+	return h.setStatus(ctx, id, status)
+}
+
+func (h *ArticleHandler) setStatus(ctx context.Context, id int64, status string) error {
+	if err := h.repos.Articles.SetStatus(ctx, id, status); err != nil {
+		return fmt.Errorf("failed to update article status: %w", err)
+	}
+
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	ui.Successln("Article marked %s: %s", status, article.Title)
+	return nil
+}
+
+// UpdateProgress records how far through an article the reader is, updating its status to match
+func (h *ArticleHandler) UpdateProgress(ctx context.Context, id int64, progress int) error {
+	if err := h.repos.Articles.SetProgress(ctx, id, progress); err != nil {
+		return fmt.Errorf("failed to update article progress: %w", err)
+	}
+
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	ui.Successln("Article progress updated: %s -> %d%% (%s)", article.Title, article.Progress, article.Status)
+	return nil
+}
+
+// SetFavorite marks an article as a favourite, or unmarks it
+func (h *ArticleHandler) SetFavorite(ctx context.Context, id int64, favorite bool) error {
+	if err := h.repos.Articles.SetFavorite(ctx, id, favorite); err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	if favorite {
+		ui.Successln("Added to favourites: %s", article.Title)
+	} else {
+		ui.Successln("Removed from favourites: %s", article.Title)
+	}
+	return nil
+}
+
+// Tag adds tags to an article, or removes them when remove is set
+func (h *ArticleHandler) Tag(ctx context.Context, id int64, tags []string, remove bool) error {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		var err error
+		if remove {
+			err = h.repos.Articles.RemoveTag(ctx, id, tag)
+		} else {
+			err = h.repos.Articles.AddTag(ctx, id, tag)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update article tags: %w", err)
+		}
+	}
+
+	article, err := h.repos.Articles.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	if len(article.Tags) == 0 {
+		ui.Successln("%s has no tags", article.Title)
+	} else {
+		ui.Successln("%s tagged: %s", article.Title, strings.Join(article.Tags, ", "))
+	}
+	return nil
+}
+
 // View handles viewing an article by ID
 func (h *ArticleHandler) View(ctx context.Context, id int64) error {
 	article, err := h.repos.Articles.Get(ctx, id)
@@ -216,6 +458,22 @@ func (h *ArticleHandler) View(ctx context.Context, id int64) error {
 		ui.Infoln("Date: %s", article.Date)
 	}
 	ui.Infoln("URL: %s", article.URL)
+	if article.LinkChecked != nil {
+		ui.Infoln("Link: %s", formatLinkStatus(article))
+	}
+	if article.ArchiveURL != "" {
+		ui.Infoln("Archived copy: %s", article.ArchiveURL)
+	}
+	ui.Infoln("Status: %s", formatArticleStatus(article))
+	if article.Favorite {
+		ui.Infoln("Favourite: yes")
+	}
+	if article.WordCount > 0 {
+		ui.Infoln("Length: %d words, about %d min", article.WordCount, article.ReadingTime)
+	}
+	if len(article.Tags) > 0 {
+		ui.Infoln("Tags: %s", strings.Join(article.Tags, ", "))
+	}
 	ui.Infoln("Added: %s", article.Created.Format("2006-01-02 15:04:05"))
 	ui.Infoln("Modified: %s", article.Modified.Format("2006-01-02 15:04:05"))
 	ui.Newline()
@@ -233,21 +491,31 @@ func (h *ArticleHandler) View(ctx context.Context, id int64) error {
 	}
 	ui.Newline()
 
-	if _, err := os.Stat(article.MarkdownPath); err == nil {
+	highlights, err := h.repos.Articles.ListHighlights(ctx, article.ID)
+	if err != nil {
+		return err
+	}
+	content, readErr := os.ReadFile(article.MarkdownPath)
+	if len(highlights) > 0 {
+		showHighlights(content, highlights)
+		if article.NoteID != nil {
+			ui.Infoln("Exported to note %d", *article.NoteID)
+			ui.Newline()
+		}
+	}
+
+	if readErr == nil {
 		ui.Headerln("--- Content Preview ---")
-		content, err := os.ReadFile(article.MarkdownPath)
-		if err == nil {
-			lines := strings.Split(string(content), "\n")
-			previewLines := min(len(lines), 20)
+		lines := strings.Split(markHighlights(string(content), highlights), "\n")
+		previewLines := min(len(lines), 20)
 
-			for i := range previewLines {
-				ui.Plainln("%v", lines[i])
-			}
+		for i := range previewLines {
+			ui.Plainln("%v", lines[i])
+		}
 
-			if len(lines) > previewLines {
-				ui.Plainln("\n... (%d more lines)", len(lines)-previewLines)
-				ui.Plainln("Read full content: %s", article.MarkdownPath)
-			}
+		if len(lines) > previewLines {
+			ui.Plainln("\n... (%d more lines)", len(lines)-previewLines)
+			ui.Plainln("Read full content: %s", article.MarkdownPath)
 		}
 	}
 
@@ -278,6 +546,16 @@ func (h *ArticleHandler) Remove(ctx context.Context, id int64) error {
 		}
 	}
 
+	// without a markdown path AssetDir would name "_assets" in the working directory
+	if article.MarkdownPath != "" {
+		assetDir := articles.AssetDir(article.MarkdownPath)
+		if _, err := os.Stat(assetDir); err == nil {
+			if rmErr := os.RemoveAll(assetDir); rmErr != nil {
+				ui.Warningln("Warning: failed to remove archived assets: %v", rmErr)
+			}
+		}
+	}
+
 	ui.Titleln("Article removed: %s (ID: %d)", article.Title, id)
 	return nil
 }
@@ -303,6 +581,9 @@ func (h *ArticleHandler) Help() error {
 		return fmt.Errorf("failed to get storage directory: %w", err)
 	}
 	ui.Headerln("%s %s", ui.TableHeaderStyle.Render("Storage directory:"), dir)
+	if rulesDir, err := articleRulesDir(); err == nil {
+		ui.Headerln("%s %s", ui.TableHeaderStyle.Render("User rules directory:"), rulesDir)
+	}
 
 	return nil
 }
@@ -323,6 +604,13 @@ func (h *ArticleHandler) Read(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to read markdown file: %w", err)
 	}
 
+	if article.IsUnread() {
+		article.Status = models.ArticleReading
+		if err := h.repos.Articles.Update(ctx, article); err != nil {
+			return fmt.Errorf("failed to update article status: %w", err)
+		}
+	}
+
 	if rendered, err := renderMarkdown(string(content)); err != nil {
 		return err
 	} else {
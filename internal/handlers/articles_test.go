@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -106,6 +108,58 @@ func TestArticleHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("archives assets and removes them with the article", func(t *testing.T) {
+			helper := NewArticleTestHelper(t)
+			ctx := context.Background()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/photo.png" {
+					w.Header().Set("Content-Type", "image/png")
+					w.Write([]byte("\x89PNG\r\n\x1a\n"))
+					return
+				}
+				w.Write([]byte(`<html>
+					<head><title>Archive Article</title></head>
+					<body>
+						<h1 id="firstHeading">Archive Article</h1>
+						<div id="bodyContent">
+							<p>An article with a photo.</p>
+							<p><img src="/photo.png" alt="Photo"></p>
+						</div>
+					</body>
+				</html>`))
+			}))
+			defer server.Close()
+
+			helper.AddTestRule("127.0.0.1", &articles.ParsingRule{
+				Domain: "127.0.0.1",
+				Title:  "//h1[@id='firstHeading']",
+				Body:   "//div[@id='bodyContent']",
+			})
+
+			err := helper.AddWithOptions(ctx, server.URL+"/archived", articles.SaveOptions{Archive: true})
+			shared.AssertNoError(t, err, "Add with archive should succeed")
+
+			saved, err := helper.repos.Articles.GetByURL(ctx, server.URL+"/archived")
+			shared.AssertNoError(t, err, "article should be saved")
+
+			assetDir := articles.AssetDir(saved.MarkdownPath)
+			files, err := os.ReadDir(assetDir)
+			if err != nil || len(files) != 1 {
+				t.Fatalf("Expected one archived asset, got %v (%v)", files, err)
+			}
+			md, err := os.ReadFile(saved.MarkdownPath)
+			shared.AssertNoError(t, err, "markdown should be readable")
+			if !strings.Contains(string(md), "]("+filepath.Base(assetDir)+"/") {
+				t.Errorf("Expected the markdown to reference the archived image, got:\n%s", md)
+			}
+
+			shared.AssertNoError(t, helper.Remove(ctx, saved.ID), "Remove should succeed")
+			if _, err := os.Stat(assetDir); !os.IsNotExist(err) {
+				t.Errorf("Expected the asset directory to be removed, got %v", err)
+			}
+		})
+
 		t.Run("handles duplicate article", func(t *testing.T) {
 			helper := NewArticleTestHelper(t)
 			ctx := context.Background()
@@ -282,6 +336,79 @@ func TestArticleHandler(t *testing.T) {
 		})
 	})
 
+	t.Run("Reading Queue", func(t *testing.T) {
+		t.Run("tracks status, progress, favourites and tags", func(t *testing.T) {
+			helper := NewArticleTestHelper(t)
+			ctx := context.Background()
+			id := helper.CreateTestArticle(t, "https://example.com/queue", "Queue Article", "Author", "2024-01-01")
+
+			captureStdout(t, func() {
+				shared.AssertNoError(t, helper.UpdateProgress(ctx, id, 40), "UpdateProgress should succeed")
+				shared.AssertNoError(t, helper.SetFavorite(ctx, id, true), "SetFavorite should succeed")
+				shared.AssertNoError(t, helper.Tag(ctx, id, []string{"go", " ", "databases"}, false), "Tag should succeed")
+				shared.AssertNoError(t, helper.Tag(ctx, id, []string{"databases"}, true), "Tag --remove should succeed")
+			})
+
+			article, err := helper.repos.Articles.Get(ctx, id)
+			shared.AssertNoError(t, err, "article should exist")
+			shared.AssertEqual(t, models.ArticleReading, article.Status, "progress should start reading")
+			shared.AssertEqual(t, 40, article.Progress, "progress should be saved")
+			shared.AssertTrue(t, article.Favorite, "article should be a favourite")
+			shared.AssertEqual(t, "go", strings.Join(article.Tags, ","), "tags should be saved")
+
+			captureStdout(t, func() {
+				shared.AssertNoError(t, helper.MarkDone(ctx, id), "MarkDone should succeed")
+			})
+			article, _ = helper.repos.Articles.Get(ctx, id)
+			shared.AssertEqual(t, models.ArticleRead, article.Status, "done should mark the article read")
+			shared.AssertEqual(t, 100, article.Progress, "done should complete the progress")
+
+			captureStdout(t, func() {
+				shared.AssertError(t, helper.UpdateProgress(ctx, id, 150), "progress over 100 should fail")
+				shared.AssertError(t, helper.UpdateStatus(ctx, id, "skimmed"), "unknown statuses should fail")
+				shared.AssertError(t, helper.MarkDone(ctx, 99999), "missing articles should fail")
+			})
+		})
+
+		t.Run("lists by status and tag, hiding archived articles", func(t *testing.T) {
+			helper := NewArticleTestHelper(t)
+			ctx := context.Background()
+			unread := helper.CreateTestArticle(t, "https://example.com/unread", "Unread Go Article", "Author", "2024-01-01")
+			read := helper.CreateTestArticle(t, "https://example.com/read", "Read Go Article", "Author", "2024-01-02")
+			archived := helper.CreateTestArticle(t, "https://example.com/archived", "Archived Article", "Author", "2024-01-03")
+
+			captureStdout(t, func() {
+				shared.AssertNoError(t, helper.Tag(ctx, unread, []string{"go"}, false), "tag unread")
+				shared.AssertNoError(t, helper.Tag(ctx, read, []string{"go"}, false), "tag read")
+				shared.AssertNoError(t, helper.MarkDone(ctx, read), "mark read")
+				shared.AssertNoError(t, helper.Archive(ctx, archived), "archive")
+			})
+
+			output := captureStdout(t, func() {
+				shared.AssertNoError(t, helper.ListFiltered(ctx, ArticleListFilter{Status: models.ArticleUnread, Tags: []string{"go"}}, false), "list unread go")
+			})
+			if !strings.Contains(output, "Unread Go Article") || strings.Contains(output, "Read Go Article") {
+				t.Errorf("expected only the unread go article, got:\n%s", output)
+			}
+
+			output = captureStdout(t, func() {
+				shared.AssertNoError(t, helper.List(ctx, "", "", 0), "list")
+			})
+			if strings.Contains(output, "Archived Article") {
+				t.Errorf("expected archived articles to be hidden, got:\n%s", output)
+			}
+
+			output = captureStdout(t, func() {
+				shared.AssertNoError(t, helper.ListFiltered(ctx, ArticleListFilter{Status: models.ArticleArchived}, false), "list archived")
+			})
+			if !strings.Contains(output, "Archived Article") {
+				t.Errorf("expected the archived article, got:\n%s", output)
+			}
+
+			shared.AssertError(t, helper.ListFiltered(ctx, ArticleListFilter{Status: "skimmed"}, false), "unknown status filter should fail")
+		})
+	})
+
 	t.Run("View", func(t *testing.T) {
 		t.Run("views article successfully", func(t *testing.T) {
 			helper := NewArticleTestHelper(t)
@@ -428,6 +555,26 @@ func TestArticleHandler(t *testing.T) {
 			shared.AssertNoError(t, err, "Remove should succeed even when files don't exist")
 		})
 
+		t.Run("leaves the working directory alone for articles without files", func(t *testing.T) {
+			helper := NewArticleTestHelper(t)
+			ctx := context.Background()
+
+			cwd := t.TempDir()
+			t.Chdir(cwd)
+			kept := filepath.Join(cwd, "_assets", "image.png")
+			shared.AssertNoError(t, os.MkdirAll(filepath.Dir(kept), 0o755), "create _assets")
+			shared.AssertNoError(t, os.WriteFile(kept, []byte("png"), 0o644), "write asset")
+
+			id := helper.CreateTestArticle(t, "https://example.com/unsaved", "Unsaved", "Author", "2024-01-01")
+			_, err := helper.db.Exec("UPDATE articles SET markdown_path = '', html_path = '' WHERE id = ?", id)
+			shared.AssertNoError(t, err, "clear file paths")
+
+			shared.AssertNoError(t, helper.Remove(ctx, id), "Remove should succeed")
+			if _, err := os.Stat(kept); err != nil {
+				t.Errorf("expected _assets in the working directory to be kept: %v", err)
+			}
+		})
+
 		t.Run("handles database error", func(t *testing.T) {
 			helper := NewArticleTestHelper(t)
 			ctx := context.Background()
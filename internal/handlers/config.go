@@ -3,11 +3,15 @@ package handlers
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/stormlightlabs/noteleaf/internal/store"
 )
 
+// redacted is displayed in place of credentials, which `config get` never prints
+const redacted = "<redacted>"
+
 // ConfigHandler handles [store.Config]-related operations
 type ConfigHandler struct {
 	config *store.Config
@@ -33,6 +37,9 @@ func (h *ConfigHandler) Get(key string) error {
 	if err != nil {
 		return err
 	}
+	if store.IsSecretKey(key) {
+		value = redacted
+	}
 
 	fmt.Printf("%s = %v\n", key, value)
 	return nil
@@ -48,6 +55,9 @@ func (h *ConfigHandler) Set(key, value string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
+	if store.IsSecretKey(key) {
+		value = redacted
+	}
 	fmt.Printf("Set %s = %s\n", key, value)
 	return nil
 }
@@ -89,6 +99,10 @@ func (h *ConfigHandler) displayAll() error {
 		}
 
 		tagName := strings.Split(tomlTag, ",")[0]
+		if store.IsSecretKey(tagName) {
+			fmt.Printf("%s = %s\n", tagName, redacted)
+			continue
+		}
 
 		switch value.Kind() {
 		case reflect.String:
@@ -148,6 +162,12 @@ func (h *ConfigHandler) setConfigValue(key, value string) error {
 			case reflect.Bool:
 				boolVal := value == "true" || value == "1" || value == "yes"
 				fieldValue.SetBool(boolVal)
+			case reflect.Int:
+				intVal, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("invalid integer value for key %s: %s", key, value)
+				}
+				fieldValue.SetInt(int64(intVal))
 			default:
 				return fmt.Errorf("unsupported field type for key %s", key)
 			}
@@ -86,6 +86,42 @@ func TestConfigHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("Get never prints credentials", func(t *testing.T) {
+			store.UseSecretStore(store.NewMemorySecretStore())
+			defer store.UseSecretStore(nil)
+
+			config, err := store.LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			config.SyncToken = "sync-secret"
+			config.ATProtoAccessJWT = "access-secret"
+			if err := store.SaveConfig(config); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+
+			handler, err := NewConfigHandler()
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+
+			output := captureStdout(t, func() {
+				if err := handler.Get(""); err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if err := handler.Get("sync_token"); err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+			})
+
+			if strings.Contains(output, "sync-secret") || strings.Contains(output, "access-secret") {
+				t.Errorf("Output should not contain credentials, got: %s", output)
+			}
+			if !strings.Contains(output, "sync_token = <redacted>") || !strings.Contains(output, "atproto_access_jwt = <redacted>") {
+				t.Errorf("Output should show redacted credentials, got: %s", output)
+			}
+		})
+
 		t.Run("Get unknown config key", func(t *testing.T) {
 			handler, err := NewConfigHandler()
 			if err != nil {
@@ -203,6 +239,31 @@ func TestConfigHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("Set integer config value", func(t *testing.T) {
+			handler, err := NewConfigHandler()
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+
+			if err := handler.Set("note_history_keep_last", "25"); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+
+			loadedConfig, err := store.LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+
+			if loadedConfig.NoteHistoryKeepLast != 25 {
+				t.Errorf("Expected note_history_keep_last 25, got %d", loadedConfig.NoteHistoryKeepLast)
+			}
+
+			err = handler.Set("note_history_keep_last", "many")
+			if err == nil || !strings.Contains(err.Error(), "invalid integer") {
+				t.Errorf("Expected invalid integer error, got: %v", err)
+			}
+		})
+
 		t.Run("Set unknown config key", func(t *testing.T) {
 			handler, err := NewConfigHandler()
 			if err != nil {
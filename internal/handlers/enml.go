@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// enmlMediaFunc returns the markdown for an <en-media> element given its resource hash
+type enmlMediaFunc func(hash string) string
+
+// enmlWriter renders Evernote's ENML (a restricted XHTML) as markdown
+type enmlWriter struct {
+	buf   strings.Builder
+	media enmlMediaFunc
+	lists []enmlList
+	pre   int
+}
+
+type enmlList struct {
+	ordered bool
+	index   int
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// enmlToMarkdown converts the contents of an ENEX <content> element to markdown
+func enmlToMarkdown(enml string, media enmlMediaFunc) (string, error) {
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse note content: %w", err)
+	}
+
+	w := &enmlWriter{media: media}
+	w.walk(doc)
+
+	out := blankLines.ReplaceAllString(w.buf.String(), "\n\n")
+	return strings.TrimSpace(out) + "\n", nil
+}
+
+func (w *enmlWriter) tail() string {
+	s := w.buf.String()
+	if len(s) > 2 {
+		s = s[len(s)-2:]
+	}
+	return s
+}
+
+// blank ends the current block with an empty line
+func (w *enmlWriter) blank() {
+	w.trimTrailingSpace()
+	switch t := w.tail(); {
+	case t == "":
+	case strings.HasSuffix(t, "\n\n"):
+	case strings.HasSuffix(t, "\n"):
+		w.buf.WriteString("\n")
+	default:
+		w.buf.WriteString("\n\n")
+	}
+}
+
+// newline ends the current line
+func (w *enmlWriter) newline() {
+	w.trimTrailingSpace()
+	if t := w.tail(); t != "" && !strings.HasSuffix(t, "\n") {
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *enmlWriter) trimTrailingSpace() {
+	s := w.buf.String()
+	trimmed := strings.TrimRight(s, " \t")
+	if len(trimmed) != len(s) {
+		w.buf.Reset()
+		w.buf.WriteString(trimmed)
+	}
+}
+
+func (w *enmlWriter) text(s string) {
+	if w.pre > 0 {
+		w.buf.WriteString(s)
+		return
+	}
+
+	lead, trail := startsWithSpace(s), endsWithSpace(s)
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		lead = true
+	}
+	if t := w.tail(); lead && t != "" && !strings.HasSuffix(t, "\n") && !strings.HasSuffix(t, " ") {
+		w.buf.WriteString(" ")
+	}
+	w.buf.WriteString(s)
+	if trail && s != "" {
+		w.buf.WriteString(" ")
+	}
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s[:1], " \t\r\n") == ""
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s[len(s)-1:], " \t\r\n") == ""
+}
+
+func (w *enmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// wrap renders children between inline markers, dropping the markers when there's no text
+func (w *enmlWriter) wrap(n *html.Node, marker string) {
+	inner := &enmlWriter{media: w.media, lists: w.lists}
+	inner.children(n)
+	text := strings.TrimSpace(inner.buf.String())
+	if text == "" {
+		return
+	}
+	if t := w.tail(); t != "" && !strings.HasSuffix(t, "\n") && !strings.HasSuffix(t, " ") && hasLeadingSpace(n) {
+		w.buf.WriteString(" ")
+	}
+	w.buf.WriteString(marker + text + marker)
+	if c := n.LastChild; c != nil && c.Type == html.TextNode && endsWithSpace(c.Data) {
+		w.buf.WriteString(" ")
+	}
+}
+
+func hasLeadingSpace(n *html.Node) bool {
+	if c := n.FirstChild; c != nil && c.Type == html.TextNode {
+		return startsWithSpace(c.Data)
+	}
+	return false
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// walk renders a node. ENML's self-closing <en-media/> and <en-todo/> aren't void elements
+// to an HTML parser, so content following them ends up as their children.
+func (w *enmlWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	switch n.Data {
+	case "script", "style", "head", "title":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.blank()
+		w.buf.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.blank()
+	case "p", "div", "section", "article", "center":
+		if len(w.lists) > 0 {
+			w.children(n)
+			w.newline()
+			return
+		}
+		w.blank()
+		w.children(n)
+		w.blank()
+	case "br":
+		w.trimTrailingSpace()
+		w.buf.WriteString("\n")
+	case "strong", "b":
+		w.wrap(n, "**")
+	case "em", "i":
+		w.wrap(n, "*")
+	case "s", "strike", "del":
+		w.wrap(n, "~~")
+	case "code", "tt":
+		if w.pre > 0 {
+			w.children(n)
+			return
+		}
+		w.wrap(n, "`")
+	case "a":
+		href := htmlAttr(n, "href")
+		inner := &enmlWriter{media: w.media}
+		inner.children(n)
+		label := strings.TrimSpace(inner.buf.String())
+		switch {
+		case href == "":
+			w.text(label)
+		case label == "" || label == href:
+			w.text("<" + href + ">")
+		default:
+			w.text(fmt.Sprintf("[%s](%s)", label, href))
+		}
+	case "img":
+		if src := htmlAttr(n, "src"); src != "" {
+			w.text(fmt.Sprintf("![%s](%s)", htmlAttr(n, "alt"), src))
+		}
+	case "en-media":
+		if w.media != nil {
+			w.text(w.media(htmlAttr(n, "hash")))
+		}
+		w.children(n)
+	case "en-todo":
+		box := "[ ] "
+		if htmlAttr(n, "checked") == "true" {
+			box = "[x] "
+		}
+		if len(w.lists) == 0 {
+			box = "- " + box
+		}
+		w.buf.WriteString(box)
+		w.children(n)
+	case "ul", "ol":
+		if len(w.lists) == 0 {
+			w.blank()
+		} else {
+			w.newline()
+		}
+		w.lists = append(w.lists, enmlList{ordered: n.Data == "ol"})
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.blank()
+		}
+	case "li":
+		w.newline()
+		depth := len(w.lists)
+		marker := "- "
+		if depth > 0 {
+			list := &w.lists[depth-1]
+			list.index++
+			if list.ordered {
+				marker = fmt.Sprintf("%d. ", list.index)
+			}
+		} else {
+			depth = 1
+		}
+		w.buf.WriteString(strings.Repeat("  ", depth-1) + marker)
+		w.children(n)
+		w.newline()
+	case "blockquote":
+		inner := &enmlWriter{media: w.media}
+		inner.children(n)
+		w.blank()
+		for line := range strings.SplitSeq(strings.TrimSpace(blankLines.ReplaceAllString(inner.buf.String(), "\n\n")), "\n") {
+			w.buf.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		w.blank()
+	case "pre":
+		w.blank()
+		w.buf.WriteString("```\n")
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.newline()
+		w.buf.WriteString("```")
+		w.blank()
+	case "hr":
+		w.blank()
+		w.buf.WriteString("---")
+		w.blank()
+	case "table":
+		w.blank()
+		w.table(n)
+		w.blank()
+	default:
+		w.children(n)
+	}
+}
+
+// table renders rows as a pipe table, treating the first row as the header
+func (w *enmlWriter) table(n *html.Node) {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.Data != "tr" {
+				collect(c)
+				continue
+			}
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					inner := &enmlWriter{media: w.media}
+					inner.children(cell)
+					text := strings.Join(strings.Fields(inner.buf.String()), " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	writeRow := func(row []string) {
+		cells := make([]string, cols)
+		copy(cells, row)
+		w.buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	writeRow(rows[0])
+	w.buf.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+}
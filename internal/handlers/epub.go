@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+// epubNamespace seeds the deterministic book identifier so re-exporting the same notes keeps the same id
+var epubNamespace = uuid.MustParse("6f1a2c9e-54b8-4d8e-9a67-0d3c51a8e6f2")
+
+// epubChapter is a single note rendered as an EPUB content document
+type epubChapter struct {
+	ID        string
+	Href      string
+	Title     string
+	Body      string
+	ShowTitle bool
+}
+
+// epubItem is an entry of the package manifest that isn't a chapter
+type epubItem struct {
+	ID        string
+	Href      string
+	MediaType string
+}
+
+type epubPackage struct {
+	Identifier string
+	Title      string
+	Modified   string
+	Chapters   []epubChapter
+	Images     []epubItem
+}
+
+// exportEPUB bundles the notes into a single EPUB 3 book in the output directory.
+//
+// Images are staged in a temporary directory and packed into the archive under OEBPS/images.
+func (e *noteExporter) exportEPUB(title string) (string, error) {
+	stage, err := os.MkdirTemp("", "noteleaf-epub-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	outDir := e.outDir
+	e.outDir, e.assetDir = stage, "images"
+	defer func() { e.outDir, e.assetDir = outDir, "assets" }()
+
+	pkg := epubPackage{
+		Title:    title,
+		Modified: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+
+	var ids []string
+	for i, note := range e.notes {
+		body := e.renderBody(note, true, "../", func(target *models.Note) string {
+			return e.slugs[target.ID] + ".xhtml"
+		})
+		pkg.Chapters = append(pkg.Chapters, epubChapter{
+			ID:        fmt.Sprintf("note-%d", i+1),
+			Href:      "text/" + e.slugs[note.ID] + ".xhtml",
+			Title:     note.Title,
+			Body:      string(body),
+			ShowTitle: !startsWithHeading(note.Content),
+		})
+		ids = append(ids, fmt.Sprint(note.ID))
+	}
+	pkg.Identifier = uuid.NewSHA1(epubNamespace, []byte(title+"\x00"+strings.Join(ids, ","))).String()
+
+	for i, asset := range e.assetList {
+		mediaType := mime.TypeByExtension(strings.ToLower(path.Ext(asset)))
+		if mediaType == "" {
+			mediaType = "application/octet-stream"
+		}
+		pkg.Images = append(pkg.Images, epubItem{ID: fmt.Sprintf("image-%d", i+1), Href: asset, MediaType: mediaType})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// The mimetype entry must come first and be stored uncompressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return "", fmt.Errorf("failed to write epub: %w", err)
+	}
+	if _, err := w.Write([]byte("application/epub+zip")); err != nil {
+		return "", fmt.Errorf("failed to write epub: %w", err)
+	}
+
+	entries := []struct {
+		name string
+		tmpl *template.Template
+		data any
+	}{
+		{"META-INF/container.xml", epubContainerTemplate, nil},
+		{"OEBPS/content.opf", epubPackageTemplate, pkg},
+		{"OEBPS/nav.xhtml", epubNavTemplate, pkg},
+	}
+	for _, entry := range entries {
+		var content bytes.Buffer
+		if err := entry.tmpl.Execute(&content, entry.data); err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", entry.name, err)
+		}
+		if err := addZipFile(zw, entry.name, content.Bytes()); err != nil {
+			return "", err
+		}
+	}
+
+	if err := addZipFile(zw, "OEBPS/style.css", []byte(exportStylesheet)); err != nil {
+		return "", err
+	}
+
+	for _, chapter := range pkg.Chapters {
+		var content bytes.Buffer
+		if err := epubChapterTemplate.Execute(&content, chapter); err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", chapter.Href, err)
+		}
+		if err := addZipFile(zw, "OEBPS/"+chapter.Href, content.Bytes()); err != nil {
+			return "", err
+		}
+	}
+
+	for _, asset := range e.assetList {
+		data, err := os.ReadFile(filepath.Join(stage, filepath.FromSlash(asset)))
+		if err != nil {
+			return "", fmt.Errorf("failed to read image %s: %w", asset, err)
+		}
+		if err := addZipFile(zw, "OEBPS/"+asset, data); err != nil {
+			return "", err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write epub: %w", err)
+	}
+
+	target := filepath.Join(outDir, slugify(title)+".epub")
+	if err := writeFile(target, &buf); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+func addZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to epub: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to epub: %w", name, err)
+	}
+	return nil
+}
+
+// The EPUB templates use text/template: chapter bodies are already rendered XHTML
+// and titles are escaped explicitly with the xml function.
+var epubFuncs = template.FuncMap{"xml": template.HTMLEscapeString}
+
+var epubContainerTemplate = template.Must(template.New("container").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var epubPackageTemplate = template.Must(template.New("package").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">urn:uuid:{{.Identifier}}</dc:identifier>
+    <dc:title>{{xml .Title}}</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+{{- range .Chapters}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="application/xhtml+xml"/>
+{{- end}}
+{{- range .Images}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="{{.MediaType}}"/>
+{{- end}}
+  </manifest>
+  <spine>
+{{- range .Chapters}}
+    <itemref idref="{{.ID}}"/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+var epubNavTemplate = template.Must(template.New("nav").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+  <title>{{xml .Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{xml .Title}}</h1>
+    <ol>
+{{- range .Chapters}}
+      <li><a href="{{.Href}}">{{xml .Title}}</a></li>
+{{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var epubChapterTemplate = template.Must(template.New("chapter").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+  <title>{{xml .Title}}</title>
+  <link rel="stylesheet" type="text/css" href="../style.css"/>
+</head>
+<body>
+{{- if .ShowTitle}}
+  <h1>{{xml .Title}}</h1>
+{{- end}}
+{{.Body}}
+</body>
+</html>
+`))
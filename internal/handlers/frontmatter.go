@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// noteFrontMatter is the YAML header of a note file on disk.
+//
+// Keys noteleaf doesn't know about (e.g. Obsidian aliases) are kept in Extra
+// so they survive a round trip.
+type noteFrontMatter struct {
+	ID          int64           `yaml:"id,omitempty"`
+	Title       string          `yaml:"title,omitempty"`
+	Tags        frontMatterTags `yaml:"tags,omitempty"`
+	Archived    bool            `yaml:"archived,omitempty"`
+	Created     *time.Time      `yaml:"created,omitempty"`
+	LeafletRKey *string         `yaml:"leaflet_rkey,omitempty"`
+	LeafletCID  *string         `yaml:"leaflet_cid,omitempty"`
+	Publication *string         `yaml:"publication,omitempty"` // leaflet publication name, rkey or AT URI
+	PublishedAt *time.Time      `yaml:"published_at,omitempty"`
+	IsDraft     bool            `yaml:"draft,omitempty"`
+	Encrypted   bool            `yaml:"encrypted,omitempty"`
+	Extra       map[string]any  `yaml:",inline"`
+
+	keys map[string]bool // keys the header contained, so absent ones can be told from empty ones
+}
+
+// has reports whether the parsed header contained key
+func (fm noteFrontMatter) has(key string) bool {
+	return fm.keys[key]
+}
+
+// frontMatterTags accepts both a YAML list and a comma separated string
+type frontMatterTags []string
+
+func (t *frontMatterTags) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		var tags []string
+		for tag := range strings.SplitSeq(value.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		*t = tags
+		return nil
+	case yaml.SequenceNode:
+		var tags []string
+		if err := value.Decode(&tags); err != nil {
+			return err
+		}
+		*t = tags
+		return nil
+	default:
+		return fmt.Errorf("tags must be a list or a comma separated string")
+	}
+}
+
+// splitFrontMatter separates a YAML front matter block from the markdown body.
+//
+// Files without front matter return a zero value and the content unchanged.
+func splitFrontMatter(content string) (noteFrontMatter, string, error) {
+	var fm noteFrontMatter
+
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, frontMatterDelimiter+"\n") {
+		return fm, content, nil
+	}
+
+	rest := normalized[len(frontMatterDelimiter)+1:]
+	var header, body string
+	if strings.HasPrefix(rest, frontMatterDelimiter+"\n") || rest == frontMatterDelimiter {
+		body = strings.TrimPrefix(strings.TrimPrefix(rest, frontMatterDelimiter), "\n")
+	} else {
+		end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+		if end < 0 {
+			if !strings.HasSuffix(rest, "\n"+frontMatterDelimiter) {
+				return fm, content, nil
+			}
+			end = len(rest) - len(frontMatterDelimiter) - 1
+			header, body = rest[:end], ""
+		} else {
+			header, body = rest[:end], rest[end+len(frontMatterDelimiter)+2:]
+		}
+	}
+
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return fm, content, fmt.Errorf("invalid front matter: %w", err)
+	}
+	var keys map[string]any
+	if err := yaml.Unmarshal([]byte(header), &keys); err == nil {
+		fm.keys = make(map[string]bool, len(keys))
+		for key := range keys {
+			fm.keys[key] = true
+		}
+	}
+	return fm, body, nil
+}
+
+// frontMatterFromNote fills front matter fields from a note, keeping any extra keys of base
+func frontMatterFromNote(note *models.Note, base noteFrontMatter) noteFrontMatter {
+	fm := noteFrontMatter{
+		ID:          note.ID,
+		Title:       note.Title,
+		Tags:        frontMatterTags(note.Tags),
+		Archived:    note.Archived,
+		LeafletRKey: note.LeafletRKey,
+		LeafletCID:  note.LeafletCID,
+		Publication: note.LeafletPublication,
+		IsDraft:     note.IsDraft,
+		Encrypted:   note.Encrypted,
+		Extra:       base.Extra,
+	}
+	if !note.Created.IsZero() {
+		created := note.Created.UTC().Truncate(time.Second)
+		fm.Created = &created
+	}
+	if note.PublishedAt != nil {
+		published := note.PublishedAt.UTC().Truncate(time.Second)
+		fm.PublishedAt = &published
+	}
+	return fm
+}
+
+// renderNoteFile produces the on-disk representation of a note: front matter followed by content
+func renderNoteFile(note *models.Note, base noteFrontMatter) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(frontMatterFromNote(note, base)); err != nil {
+		return "", fmt.Errorf("failed to encode front matter: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode front matter: %w", err)
+	}
+
+	var out strings.Builder
+	out.WriteString(frontMatterDelimiter + "\n")
+	out.Write(buf.Bytes())
+	out.WriteString(frontMatterDelimiter + "\n")
+	out.WriteString(note.Content)
+	if note.Content != "" && !strings.HasSuffix(note.Content, "\n") {
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify converts a title into a lowercase, dash separated file name stem
+func slugify(title string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 100 {
+		slug = strings.Trim(slug[:100], "-")
+	}
+	if slug == "" {
+		slug = "untitled"
+	}
+	return slug
+}
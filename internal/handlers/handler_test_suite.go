@@ -31,8 +31,10 @@ func NewHandlerTestSuite(t *testing.T) *HandlerTestSuite {
 	oldNoteleafDataDir := os.Getenv("NOTELEAF_DATA_DIR")
 	os.Setenv("NOTELEAF_CONFIG", filepath.Join(tempDir, ".noteleaf.conf.toml"))
 	os.Setenv("NOTELEAF_DATA_DIR", tempDir)
+	store.UseSecretStore(store.NewMemorySecretStore())
 
 	cleanup := func() {
+		store.UseSecretStore(nil)
 		os.Setenv("NOTELEAF_CONFIG", oldNoteleafConfig)
 		os.Setenv("NOTELEAF_DATA_DIR", oldNoteleafDataDir)
 		os.RemoveAll(tempDir)
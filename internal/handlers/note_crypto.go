@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// notePassphraseEnv supplies the passphrase for encrypted notes without prompting
+const notePassphraseEnv = "NOTELEAF_NOTE_PASSPHRASE"
+
+// scryptWorkFactor is the log2 scrypt cost for passphrase encryption
+var scryptWorkFactor = 18
+
+// Encrypt replaces a note's content with age ciphertext and drops its plaintext history
+func (h *NoteHandler) Encrypt(ctx context.Context, id int64) error {
+	note, err := h.repos.Notes.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+	if note.Encrypted {
+		return fmt.Errorf("note %d is already encrypted", id)
+	}
+
+	ciphertext, err := h.encryptContent(note.Content)
+	if err != nil {
+		return err
+	}
+
+	note.Content = ciphertext
+	note.Encrypted = true
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	removed, err := h.repos.Notes.ClearHistory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove plaintext history: %w", err)
+	}
+
+	ui.Successln("Encrypted note: %s (ID: %d)", note.Title, id)
+	if removed > 0 {
+		ui.Infoln("Removed %d plaintext revision(s) from history", removed)
+	}
+	return nil
+}
+
+// Decrypt stores a note's content as plaintext again
+func (h *NoteHandler) Decrypt(ctx context.Context, id int64) error {
+	note, err := h.repos.Notes.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+	if !note.Encrypted {
+		return fmt.Errorf("note %d is not encrypted", id)
+	}
+
+	plaintext, err := h.decryptContent(note.Content)
+	if err != nil {
+		return err
+	}
+
+	note.Content = plaintext
+	note.Encrypted = false
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	ui.Successln("Decrypted note: %s (ID: %d)", note.Title, id)
+	return nil
+}
+
+// EncryptNewNotes makes notes created by this handler store their content encrypted
+func (h *NoteHandler) EncryptNewNotes(enabled bool) {
+	h.encryptNew = enabled
+}
+
+// createNote stores a new note, encrypting its content first when requested
+func (h *NoteHandler) createNote(ctx context.Context, note *models.Note) (int64, error) {
+	if h.encryptNew {
+		ciphertext, err := h.encryptContent(note.Content)
+		if err != nil {
+			return 0, err
+		}
+		note.Content = ciphertext
+		note.Encrypted = true
+	}
+	return h.repos.Notes.Create(ctx, note)
+}
+
+// unlockNote returns a copy of note with its content decrypted. Plain notes are returned as is.
+func (h *NoteHandler) unlockNote(note *models.Note) (*models.Note, error) {
+	if !note.Encrypted {
+		return note, nil
+	}
+
+	plaintext, err := h.decryptContent(note.Content)
+	if err != nil {
+		return nil, err
+	}
+	unlocked := *note
+	unlocked.Content = plaintext
+	return &unlocked, nil
+}
+
+func (h *NoteHandler) encryptContent(plaintext string) (string, error) {
+	recipients, err := h.noteKey().Recipients()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt note: %w", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", fmt.Errorf("failed to encrypt note: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt note: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt note: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func (h *NoteHandler) decryptContent(ciphertext string) (string, error) {
+	key := h.noteKey()
+	identities, err := key.Identities()
+	if err != nil {
+		return "", err
+	}
+
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), identities...)
+	if err != nil {
+		key.Forget()
+		return "", fmt.Errorf("failed to decrypt note: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt note: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// noteKey returns the age key for encrypted notes: the configured identity file
+// or the user's passphrase, which is asked for at most once per handler
+func (h *NoteHandler) noteKey() *shared.AgeKey {
+	return &shared.AgeKey{
+		IdentityFile:  h.config.NoteIdentityFile,
+		IdentityKey:   "note_identity_file",
+		PassphraseEnv: notePassphraseEnv,
+		Prompt:        "Note passphrase: ",
+		Ask:           h.promptPassphraseFunc,
+		WorkFactor:    scryptWorkFactor,
+		Cache:         &h.passphraseCache,
+	}
+}
+
+// secureTempDir returns a memory backed directory for decrypted temp files when the
+// platform has one, or "" to use the default temp directory
+func secureTempDir() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		if f, err := os.CreateTemp("/dev/shm", "noteleaf-probe-*"); err == nil {
+			f.Close()
+			os.Remove(f.Name())
+			return "/dev/shm"
+		}
+	}
+	return ""
+}
+
+// removeSecureFile overwrites a temp file holding plaintext before deleting it
+func removeSecureFile(path string) {
+	if info, err := os.Stat(path); err == nil {
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			f.Write(make([]byte, info.Size()))
+			f.Sync()
+			f.Close()
+		}
+	}
+	os.Remove(path)
+}
+
+// isCiphertext reports whether content is an armored age file
+func isCiphertext(content string) bool {
+	return strings.HasPrefix(strings.TrimSpace(content), armor.Header)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func newCryptoTestHandler(t *testing.T) *NoteHandler {
+	t.Helper()
+	handler, _ := newSyncTestHandler(t)
+
+	factor := scryptWorkFactor
+	scryptWorkFactor = 10
+	t.Cleanup(func() { scryptWorkFactor = factor })
+	t.Setenv(notePassphraseEnv, "")
+
+	handler.promptPassphraseFunc = func(string, bool) (string, error) { return "correct horse", nil }
+	return handler
+}
+
+func createCryptoTestNote(t *testing.T, handler *NoteHandler, content string) int64 {
+	t.Helper()
+	id, err := handler.repos.Notes.Create(context.Background(), &models.Note{Title: "Secret", Content: content})
+	if err != nil {
+		t.Fatalf("Failed to create note: %v", err)
+	}
+	return id
+}
+
+func TestNoteEncryption(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("encrypt and decrypt with passphrase", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		id := createCryptoTestNote(t, handler, "the vault code is 1234")
+
+		note, _ := handler.repos.Notes.Get(ctx, id)
+		note.Content = "the vault code is 4321"
+		if err := handler.repos.Notes.Update(ctx, note); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+
+		if err := handler.Encrypt(ctx, id); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+
+		stored, _ := handler.repos.Notes.Get(ctx, id)
+		if !stored.Encrypted || !isCiphertext(stored.Content) {
+			t.Fatalf("Expected ciphertext to be stored, got %q", stored.Content)
+		}
+		if strings.Contains(stored.Content, "vault code") {
+			t.Error("Expected plaintext to be removed from the note")
+		}
+
+		revisions, err := handler.repos.Notes.ListRevisions(ctx, id)
+		if err != nil {
+			t.Fatalf("ListRevisions failed: %v", err)
+		}
+		for _, rev := range revisions {
+			if strings.Contains(rev.Content, "vault code") {
+				t.Errorf("Expected plaintext revision %d to be removed", rev.Revision)
+			}
+		}
+
+		if err := handler.Encrypt(ctx, id); err == nil {
+			t.Error("Expected error encrypting an encrypted note")
+		}
+
+		if err := handler.Decrypt(ctx, id); err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		stored, _ = handler.repos.Notes.Get(ctx, id)
+		if stored.Encrypted || stored.Content != "the vault code is 4321" {
+			t.Errorf("Expected plaintext after decrypt, got %q", stored.Content)
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		id := createCryptoTestNote(t, handler, "secret")
+		if err := handler.Encrypt(ctx, id); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+
+		handler.passphraseCache = ""
+		handler.promptPassphraseFunc = func(string, bool) (string, error) { return "wrong", nil }
+		if err := handler.Decrypt(ctx, id); err == nil {
+			t.Error("Expected decrypt to fail with the wrong passphrase")
+		}
+
+		stored, _ := handler.repos.Notes.Get(ctx, id)
+		if !stored.Encrypted {
+			t.Error("Expected note to stay encrypted")
+		}
+	})
+
+	t.Run("passphrase from environment", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		t.Setenv(notePassphraseEnv, "from env")
+		handler.promptPassphraseFunc = func(string, bool) (string, error) {
+			t.Error("Did not expect a prompt")
+			return "", nil
+		}
+
+		passphrase, err := handler.noteKey().Passphrase(true)
+		if err != nil || passphrase != "from env" {
+			t.Errorf("Expected passphrase from environment, got %q, %v", passphrase, err)
+		}
+	})
+
+	t.Run("identity file", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		handler.promptPassphraseFunc = func(string, bool) (string, error) {
+			t.Error("Did not expect a prompt when an identity file is configured")
+			return "", nil
+		}
+
+		identity, err := age.GenerateX25519Identity()
+		if err != nil {
+			t.Fatalf("Failed to generate identity: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "key.txt")
+		if err := os.WriteFile(path, []byte(identity.String()+"\n"), 0600); err != nil {
+			t.Fatalf("Failed to write identity: %v", err)
+		}
+		handler.config.NoteIdentityFile = path
+
+		id := createCryptoTestNote(t, handler, "keyed")
+		if err := handler.Encrypt(ctx, id); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+
+		note, _ := handler.repos.Notes.Get(ctx, id)
+		unlocked, err := handler.unlockNote(note)
+		if err != nil {
+			t.Fatalf("unlockNote failed: %v", err)
+		}
+		if unlocked.Content != "keyed" {
+			t.Errorf("Expected decrypted content, got %q", unlocked.Content)
+		}
+		if !isCiphertext(note.Content) {
+			t.Error("Expected unlockNote to leave the stored note untouched")
+		}
+	})
+
+	t.Run("create encrypted", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		handler.EncryptNewNotes(true)
+
+		if err := handler.Create(ctx, "Diary", "dear diary", "", false); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		notes := listAllNotes(t, handler)
+		if len(notes) != 1 || !notes[0].Encrypted || strings.Contains(notes[0].Content, "dear diary") {
+			t.Fatalf("Expected an encrypted note, got %+v", notes)
+		}
+
+		if err := handler.View(ctx, notes[0].ID); err != nil {
+			t.Errorf("View failed: %v", err)
+		}
+	})
+
+	t.Run("edit re-encrypts", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		id := createCryptoTestNote(t, handler, "# Secret\n\nbefore\n")
+		if err := handler.Encrypt(ctx, id); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+
+		var tempPath string
+		handler.openInEditorFunc = func(editor, path string) error {
+			tempPath = path
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(string(data), "before") {
+				t.Errorf("Expected decrypted content in editor, got %q", data)
+			}
+			return os.WriteFile(path, []byte("# Secret\n\nafter\n"), 0600)
+		}
+
+		if err := handler.Edit(ctx, id); err != nil {
+			t.Fatalf("Edit failed: %v", err)
+		}
+		if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+			t.Error("Expected the temporary file to be removed")
+		}
+
+		note, _ := handler.repos.Notes.Get(ctx, id)
+		if !note.Encrypted || strings.Contains(note.Content, "after") {
+			t.Fatalf("Expected edited note to stay encrypted, got %q", note.Content)
+		}
+		unlocked, err := handler.unlockNote(note)
+		if err != nil || !strings.Contains(unlocked.Content, "after") {
+			t.Errorf("Expected edited content after decrypting, got %q, %v", unlocked.Content, err)
+		}
+	})
+
+	t.Run("export skips encrypted notes unless unlocked", func(t *testing.T) {
+		handler := newCryptoTestHandler(t)
+		createCryptoTestNote(t, handler, "plain")
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "Hidden", Content: "hidden text"})
+		if err != nil {
+			t.Fatalf("Failed to create note: %v", err)
+		}
+		if err := handler.Encrypt(ctx, id); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+
+		out := t.TempDir()
+		if err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: ExportMarkdown, OutDir: out}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "hidden.md")); !os.IsNotExist(err) {
+			t.Error("Expected encrypted note to be skipped")
+		}
+
+		out = t.TempDir()
+		if err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: ExportMarkdown, OutDir: out, Unlock: true}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if !strings.Contains(readFile(t, filepath.Join(out, "hidden.md")), "hidden text") {
+			t.Error("Expected unlocked note to be exported as plaintext")
+		}
+	})
+}
@@ -0,0 +1,667 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// Supported note export formats
+const (
+	ExportMarkdown = "md"
+	ExportHTML     = "html"
+	ExportEPUB     = "epub"
+	ExportSite     = "site"
+)
+
+// NoteExportOptions configures [NoteHandler.Export]
+type NoteExportOptions struct {
+	Format   string // one of ExportMarkdown, ExportHTML, ExportEPUB or ExportSite
+	OutDir   string
+	Archived bool   // include archived notes when selecting by filter
+	Title    string // title of the e-book or site
+	Unlock   bool   // decrypt encrypted notes in memory instead of skipping them
+}
+
+// unlockForExport decrypts encrypted notes when unlock is set and drops them otherwise
+func (h *NoteHandler) unlockForExport(notes []*models.Note, unlock bool) ([]*models.Note, error) {
+	kept := notes[:0]
+	skipped := 0
+	for _, note := range notes {
+		if !note.Encrypted {
+			kept = append(kept, note)
+			continue
+		}
+		if !unlock {
+			skipped++
+			continue
+		}
+		unlocked, err := h.unlockNote(note)
+		if err != nil {
+			return nil, err
+		}
+		kept = append(kept, unlocked)
+	}
+	if skipped > 0 {
+		ui.Warningln("Skipped %d encrypted note(s); use --unlock to include them", skipped)
+	}
+	return kept, nil
+}
+
+// wikiLinkPattern matches [[Target]], [[Target|Label]] and [[Target#Heading]]
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\]|#]+)(#[^\]|]*)?(?:\|([^\]]+))?\]\]`)
+
+// noteExporter holds state shared while exporting a set of notes
+type noteExporter struct {
+	notes     []*models.Note
+	slugs     map[int64]string
+	byTitle   map[string]*models.Note
+	byFile    map[string]*models.Note
+	conv      *public.MarkdownConverter
+	outDir    string
+	assetDir  string // directory assets are copied to, relative to outDir
+	assets    map[string]string
+	notesDir  string
+	assetList []string
+}
+
+// Export writes the selected notes to opts.OutDir in the requested format.
+//
+// Selectors are note IDs, "tag:<name>", "all", or text matched against note titles.
+func (h *NoteHandler) Export(ctx context.Context, selectors []string, opts NoteExportOptions) error {
+	switch opts.Format {
+	case ExportMarkdown, ExportHTML, ExportEPUB, ExportSite:
+	default:
+		return fmt.Errorf("unsupported export format %q: must be one of md, html, epub, site", opts.Format)
+	}
+	if opts.OutDir == "" {
+		return fmt.Errorf("output directory is required")
+	}
+	if opts.Title == "" {
+		opts.Title = "Noteleaf Notes"
+	}
+
+	notes, err := h.selectNotes(ctx, selectors, opts.Archived)
+	if err != nil {
+		return err
+	}
+	if notes, err = h.unlockForExport(notes, opts.Unlock); err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		ui.Warningln("No notes matched the selection")
+		return nil
+	}
+
+	if err := os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	notesDir, _ := h.getNotesDirectory()
+	exp := newNoteExporter(notes, opts.OutDir, notesDir)
+
+	var output string
+	switch opts.Format {
+	case ExportMarkdown:
+		output, err = exp.exportMarkdown()
+	case ExportHTML:
+		output, err = exp.exportHTML()
+	case ExportSite:
+		output, err = exp.exportSite(opts.Title)
+	case ExportEPUB:
+		output, err = exp.exportEPUB(opts.Title)
+	}
+	if err != nil {
+		return err
+	}
+
+	ui.Successln("Exported %d note(s) to %s", len(notes), output)
+	if len(exp.assetList) > 0 {
+		ui.Infoln("Copied %d image(s)", len(exp.assetList))
+	}
+	return nil
+}
+
+// selectNotes resolves export selectors to notes, keeping the order they were asked for
+func (h *NoteHandler) selectNotes(ctx context.Context, selectors []string, includeArchived bool) ([]*models.Note, error) {
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("no notes selected: pass note IDs, tag:<name>, a title search or all")
+	}
+
+	var archived *bool
+	if !includeArchived {
+		archived = new(bool)
+	}
+
+	var notes []*models.Note
+	seen := map[int64]bool{}
+	add := func(found ...*models.Note) {
+		for _, note := range found {
+			if !seen[note.ID] {
+				seen[note.ID] = true
+				notes = append(notes, note)
+			}
+		}
+	}
+
+	for _, selector := range selectors {
+		for part := range strings.SplitSeq(selector, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+				note, err := h.repos.Notes.Get(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				add(note)
+				continue
+			}
+
+			if tag, ok := strings.CutPrefix(part, "tag:"); ok {
+				found, err := h.repos.Notes.GetByTags(ctx, []string{tag})
+				if err != nil {
+					return nil, fmt.Errorf("failed to find notes tagged %s: %w", tag, err)
+				}
+				for _, note := range found {
+					if includeArchived || !note.Archived {
+						add(note)
+					}
+				}
+				continue
+			}
+
+			options := repo.NoteListOptions{Archived: archived}
+			if part != "all" {
+				options.Title = part
+			}
+			found, err := h.repos.Notes.List(ctx, options)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list notes: %w", err)
+			}
+			add(found...)
+		}
+	}
+
+	return notes, nil
+}
+
+func newNoteExporter(notes []*models.Note, outDir, notesDir string) *noteExporter {
+	exp := &noteExporter{
+		notes:    notes,
+		slugs:    map[int64]string{},
+		byTitle:  map[string]*models.Note{},
+		byFile:   map[string]*models.Note{},
+		conv:     public.NewMarkdownConverter(),
+		outDir:   outDir,
+		assetDir: "assets",
+		assets:   map[string]string{},
+		notesDir: notesDir,
+	}
+
+	used := map[string]bool{}
+	for _, note := range notes {
+		slug := slugify(note.Title)
+		for i := 2; used[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", slugify(note.Title), i)
+		}
+		used[slug] = true
+		exp.slugs[note.ID] = slug
+
+		exp.byTitle[strings.ToLower(note.Title)] = note
+		exp.byFile[slug+".md"] = note
+		if note.FilePath != "" {
+			exp.byFile[filepath.Base(note.FilePath)] = note
+		}
+	}
+	return exp
+}
+
+// resolveNote finds the exported note a link destination points to.
+//
+// Supported forms are note:<id>, wiki:<title> (from [[wiki links]]) and relative .md paths.
+func (e *noteExporter) resolveNote(dest string) (*models.Note, string) {
+	dest, fragment, _ := strings.Cut(dest, "#")
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+
+	if id, ok := strings.CutPrefix(dest, "note:"); ok {
+		noteID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, ""
+		}
+		for _, note := range e.notes {
+			if note.ID == noteID {
+				return note, fragment
+			}
+		}
+		return nil, ""
+	}
+
+	if title, ok := strings.CutPrefix(dest, "wiki:"); ok {
+		title, _ = url.PathUnescape(title)
+		if note := e.byTitle[strings.ToLower(strings.TrimSpace(title))]; note != nil {
+			return note, fragment
+		}
+		return e.byFile[slugify(title)+".md"], fragment
+	}
+
+	if strings.Contains(dest, "://") || !strings.HasSuffix(strings.ToLower(dest), ".md") {
+		return nil, ""
+	}
+	name, err := url.PathUnescape(path.Base(dest))
+	if err != nil {
+		return nil, ""
+	}
+	return e.byFile[name], fragment
+}
+
+// expandWikiLinks turns [[Target|Label]] into markdown links with a wiki: destination
+func expandWikiLinks(md string) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(md, func(match string) string {
+		groups := wikiLinkPattern.FindStringSubmatch(match)
+		target, heading, label := strings.TrimSpace(groups[1]), groups[2], groups[3]
+		if label == "" {
+			label = target
+		}
+		if heading != "" {
+			heading = "#" + slugify(strings.TrimPrefix(heading, "#"))
+		}
+		return fmt.Sprintf("[%s](wiki:%s%s)", label, url.PathEscape(target), heading)
+	})
+}
+
+// copyAsset copies a local image referenced by note into the asset directory and
+// returns its path relative to outDir. Remote and missing images return "".
+func (e *noteExporter) copyAsset(note *models.Note, dest string) string {
+	if dest == "" || strings.Contains(dest, "://") || strings.HasPrefix(dest, "data:") {
+		return ""
+	}
+
+	src, err := url.PathUnescape(dest)
+	if err != nil {
+		src = dest
+	}
+	if !filepath.IsAbs(src) {
+		base := e.notesDir
+		if dir := extractNoteDirectory(note); dir != "" {
+			base = dir
+		}
+		src = filepath.Join(base, src)
+	}
+
+	if rel, ok := e.assets[src]; ok {
+		return rel
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		ui.Warningln("Skipping image %s in %q: %v", dest, note.Title, err)
+		e.assets[src] = ""
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:4]) + "-" + filepath.Base(src)
+	if err := os.MkdirAll(filepath.Join(e.outDir, e.assetDir), 0755); err != nil {
+		ui.Warningln("Failed to create asset directory: %v", err)
+		return ""
+	}
+	if err := os.WriteFile(filepath.Join(e.outDir, e.assetDir, name), data, 0644); err != nil {
+		ui.Warningln("Failed to copy image %s: %v", src, err)
+		return ""
+	}
+
+	rel := path.Join(e.assetDir, name)
+	e.assets[src] = rel
+	e.assetList = append(e.assetList, rel)
+	return rel
+}
+
+// renderBody renders a note's markdown to HTML with images copied and note links resolved.
+// prefix is prepended to asset paths and linkFor maps a linked note to its output path.
+func (e *noteExporter) renderBody(note *models.Note, xhtml bool, assetPrefix string, linkFor func(*models.Note) string) []byte {
+	return e.conv.ToHTML(expandWikiLinks(note.Content), public.HTMLOptions{
+		XHTML: xhtml,
+		RewriteImage: func(dest string) string {
+			if rel := e.copyAsset(note, dest); rel != "" {
+				return assetPrefix + rel
+			}
+			return ""
+		},
+		RewriteLink: func(dest string) string {
+			target, fragment := e.resolveNote(dest)
+			if target != nil {
+				return linkFor(target) + fragment
+			}
+			if title, ok := strings.CutPrefix(dest, "wiki:"); ok {
+				unescaped, _ := url.PathUnescape(title)
+				return "#" + slugify(unescaped)
+			}
+			return ""
+		},
+	})
+}
+
+func (e *noteExporter) exportMarkdown() (string, error) {
+	for _, note := range e.notes {
+		content := e.rewriteMarkdown(note)
+
+		exported := *note
+		exported.Content = content
+		rendered, err := renderNoteFile(&exported, noteFrontMatter{})
+		if err != nil {
+			return "", err
+		}
+
+		target := filepath.Join(e.outDir, e.slugs[note.ID]+".md")
+		if err := os.WriteFile(target, []byte(rendered), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return e.outDir, nil
+}
+
+// rewriteMarkdown points image references at copied assets and note links at exported files
+func (e *noteExporter) rewriteMarkdown(note *models.Note) string {
+	content := wikiLinkPattern.ReplaceAllStringFunc(note.Content, func(match string) string {
+		groups := wikiLinkPattern.FindStringSubmatch(match)
+		target, _ := e.resolveNote("wiki:" + url.PathEscape(strings.TrimSpace(groups[1])))
+		if target == nil {
+			return match
+		}
+		label := groups[3]
+		if label == "" {
+			label = strings.TrimSpace(groups[1])
+		}
+		return fmt.Sprintf("[%s](%s.md)", label, e.slugs[target.ID])
+	})
+
+	replacements := map[string]string{}
+	for _, dest := range e.conv.ImageURLs(content) {
+		if rel := e.copyAsset(note, dest); rel != "" {
+			replacements[dest] = rel
+		}
+	}
+	for _, dest := range e.conv.LinkURLs(content) {
+		if target, fragment := e.resolveNote(dest); target != nil {
+			replacements[dest] = e.slugs[target.ID] + ".md" + fragment
+		}
+	}
+	return rewriteMarkdownDestinations(content, replacements)
+}
+
+// rewriteMarkdownDestinations replaces link and image destinations in markdown source
+func rewriteMarkdownDestinations(md string, replacements map[string]string) string {
+	for from, to := range replacements {
+		if from == to {
+			continue
+		}
+		md = strings.ReplaceAll(md, "]("+from+")", "]("+to+")")
+		md = strings.ReplaceAll(md, "]("+from+" ", "]("+to+" ")
+		md = strings.ReplaceAll(md, "](<"+from+">", "](<"+to+">")
+	}
+	return md
+}
+
+// exportPage is the data rendered by the HTML page templates
+type exportPage struct {
+	Title      string
+	SiteTitle  string
+	Stylesheet string
+	InlineCSS  template.CSS
+	Root       string // relative path from the page to the site root
+	Note       *models.Note
+	Tags       []exportTagLink
+	Body       template.HTML
+	ShowTitle  bool
+	Entries    []exportEntry
+	TagIndex   []exportTagLink
+}
+
+type exportEntry struct {
+	Title    string
+	Href     string
+	Modified string
+	Tags     []exportTagLink
+}
+
+type exportTagLink struct {
+	Name  string
+	Href  string
+	Count int
+}
+
+func (e *noteExporter) notePage(note *models.Note, body []byte, root string, tagHref func(string) string) exportPage {
+	page := exportPage{
+		Title:     note.Title,
+		Root:      root,
+		Note:      note,
+		Body:      template.HTML(body),
+		ShowTitle: !startsWithHeading(note.Content),
+	}
+	for _, tag := range note.Tags {
+		page.Tags = append(page.Tags, exportTagLink{Name: tag, Href: tagHref(tag)})
+	}
+	return page
+}
+
+func (e *noteExporter) exportHTML() (string, error) {
+	for _, note := range e.notes {
+		body := e.renderBody(note, false, "", func(target *models.Note) string {
+			return e.slugs[target.ID] + ".html"
+		})
+
+		page := e.notePage(note, body, "", func(string) string { return "" })
+		page.InlineCSS = template.CSS(exportStylesheet)
+
+		target := filepath.Join(e.outDir, e.slugs[note.ID]+".html")
+		if err := writeTemplate(target, notePageTemplate, page); err != nil {
+			return "", err
+		}
+	}
+	return e.outDir, nil
+}
+
+func (e *noteExporter) exportSite(title string) (string, error) {
+	for _, dir := range []string{"notes", "tags"} {
+		if err := os.MkdirAll(filepath.Join(e.outDir, dir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s directory: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(e.outDir, "style.css"), []byte(exportStylesheet), 0644); err != nil {
+		return "", fmt.Errorf("failed to write stylesheet: %w", err)
+	}
+
+	tagHref := func(prefix string) func(string) string {
+		return func(tag string) string { return prefix + "tags/" + slugify(tag) + ".html" }
+	}
+
+	byTag := map[string][]*models.Note{}
+	var entries []exportEntry
+	for _, note := range e.notes {
+		body := e.renderBody(note, false, "../", func(target *models.Note) string {
+			return e.slugs[target.ID] + ".html"
+		})
+
+		page := e.notePage(note, body, "../", tagHref("../"))
+		page.SiteTitle = title
+		page.Stylesheet = "../style.css"
+
+		target := filepath.Join(e.outDir, "notes", e.slugs[note.ID]+".html")
+		if err := writeTemplate(target, notePageTemplate, page); err != nil {
+			return "", err
+		}
+
+		for _, tag := range note.Tags {
+			byTag[tag] = append(byTag[tag], note)
+		}
+		entries = append(entries, e.entry(note, "notes/", tagHref("")))
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	var tagIndex []exportTagLink
+	for _, tag := range tags {
+		tagIndex = append(tagIndex, exportTagLink{Name: tag, Href: tagHref("")(tag), Count: len(byTag[tag])})
+
+		var tagEntries []exportEntry
+		for _, note := range byTag[tag] {
+			tagEntries = append(tagEntries, e.entry(note, "../notes/", tagHref("../")))
+		}
+		page := exportPage{Title: "Tagged " + tag, SiteTitle: title, Stylesheet: "../style.css", Root: "../", Entries: tagEntries}
+		if err := writeTemplate(filepath.Join(e.outDir, "tags", slugify(tag)+".html"), indexPageTemplate, page); err != nil {
+			return "", err
+		}
+	}
+
+	index := exportPage{Title: title, SiteTitle: title, Stylesheet: "style.css", Entries: entries, TagIndex: tagIndex}
+	indexPath := filepath.Join(e.outDir, "index.html")
+	if err := writeTemplate(indexPath, indexPageTemplate, index); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
+
+func (e *noteExporter) entry(note *models.Note, prefix string, tagHref func(string) string) exportEntry {
+	entry := exportEntry{
+		Title:    note.Title,
+		Href:     prefix + e.slugs[note.ID] + ".html",
+		Modified: note.Modified.Format("2006-01-02"),
+	}
+	for _, tag := range note.Tags {
+		entry.Tags = append(entry.Tags, exportTagLink{Name: tag, Href: tagHref(tag)})
+	}
+	return entry
+}
+
+func startsWithHeading(content string) bool {
+	return strings.HasPrefix(strings.TrimSpace(content), "# ")
+}
+
+func writeTemplate(target string, tmpl *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", filepath.Base(target), err)
+	}
+	return writeFile(target, &buf)
+}
+
+func writeFile(target string, r io.Reader) error {
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return f.Close()
+}
+
+const exportStylesheet = `body { font-family: Georgia, "Times New Roman", serif; line-height: 1.6; max-width: 760px; margin: 0 auto; padding: 2rem 1rem; color: #222; }
+h1, h2, h3, h4, nav, .meta, .tags { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; }
+a { color: #2458a6; }
+img { max-width: 100%; height: auto; }
+pre { background: #f4f4f4; padding: 0.75rem; border-radius: 4px; overflow-x: auto; }
+code { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+blockquote { border-left: 4px solid #ccc; margin-left: 0; padding-left: 1rem; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25rem 0.5rem; }
+nav { margin-bottom: 2rem; font-size: 0.9rem; }
+.meta { color: #777; font-size: 0.85rem; }
+.tags a, .tag { display: inline-block; background: #eef2f8; border-radius: 3px; padding: 0 0.4rem; margin-right: 0.25rem; text-decoration: none; font-size: 0.85rem; }
+ul.entries { list-style: none; padding: 0; }
+ul.entries li { margin-bottom: 0.75rem; }
+@page { margin: 2cm; }
+@media print {
+  body { max-width: none; padding: 0; font-size: 11pt; }
+  nav { display: none; }
+  a { color: inherit; text-decoration: none; }
+  pre, blockquote, img, table { page-break-inside: avoid; }
+  h1, h2, h3 { page-break-after: avoid; }
+}
+`
+
+var notePageTemplate = template.Must(template.New("note").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>{{.Title}}</title>
+  {{- if .Stylesheet}}
+  <link rel="stylesheet" href="{{.Stylesheet}}">
+  {{- end}}
+  {{- if .InlineCSS}}
+  <style>{{.InlineCSS}}</style>
+  {{- end}}
+</head>
+<body>
+{{- if .SiteTitle}}
+  <nav><a href="{{.Root}}index.html">{{.SiteTitle}}</a></nav>
+{{- end}}
+  <article>
+  {{- if .ShowTitle}}
+    <h1>{{.Title}}</h1>
+  {{- end}}
+    <p class="meta">Created {{.Note.Created.Format "2006-01-02"}} · Modified {{.Note.Modified.Format "2006-01-02"}}</p>
+  {{- if .Tags}}
+    <p class="tags">{{range .Tags}}{{if .Href}}<a href="{{.Href}}">{{.Name}}</a>{{else}}<span class="tag">{{.Name}}</span>{{end}} {{end}}</p>
+  {{- end}}
+{{.Body}}
+  </article>
+</body>
+</html>
+`))
+
+var indexPageTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.Stylesheet}}">
+</head>
+<body>
+{{- if .Root}}
+  <nav><a href="{{.Root}}index.html">{{.SiteTitle}}</a></nav>
+{{- end}}
+  <h1>{{.Title}}</h1>
+{{- if .TagIndex}}
+  <h2>Tags</h2>
+  <p class="tags">{{range .TagIndex}}<a href="{{.Href}}">{{.Name}} ({{.Count}})</a> {{end}}</p>
+  <h2>Notes</h2>
+{{- end}}
+  <ul class="entries">
+  {{- range .Entries}}
+    <li><a href="{{.Href}}">{{.Title}}</a> <span class="meta">{{.Modified}}</span>
+      {{- if .Tags}}<br><span class="tags">{{range .Tags}}<a href="{{.Href}}">{{.Name}}</a> {{end}}</span>{{end}}</li>
+  {{- end}}
+  </ul>
+</body>
+</html>
+`))
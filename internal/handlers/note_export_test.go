@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func newExportTestHandler(t *testing.T) (*NoteHandler, string) {
+	t.Helper()
+	handler, notesDir := newSyncTestHandler(t)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(notesDir, "diagram.png"), []byte("png-bytes"), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	notes := []*models.Note{
+		{Title: "First Note", Content: "# First Note\n\nSee [[Second Note]] and ![diagram](diagram.png)\n", Tags: []string{"research"}},
+		{Title: "Second Note", Content: "Back to [first](note:1) and [web](https://example.com)\n", Tags: []string{"research", "draft"}},
+		{Title: "Archived", Content: "old\n", Archived: true},
+	}
+	for _, note := range notes {
+		if _, err := handler.repos.Notes.Create(ctx, note); err != nil {
+			t.Fatalf("Failed to create note: %v", err)
+		}
+	}
+	return handler, notesDir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestNoteExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("selection", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+
+		notes, err := handler.selectNotes(ctx, []string{"all"}, false)
+		if err != nil {
+			t.Fatalf("selectNotes failed: %v", err)
+		}
+		if len(notes) != 2 {
+			t.Errorf("Expected 2 active notes, got %d", len(notes))
+		}
+
+		notes, err = handler.selectNotes(ctx, []string{"all"}, true)
+		if err != nil {
+			t.Fatalf("selectNotes failed: %v", err)
+		}
+		if len(notes) != 3 {
+			t.Errorf("Expected archived notes to be included, got %d", len(notes))
+		}
+
+		notes, err = handler.selectNotes(ctx, []string{"2,1", "tag:research", "Second"}, false)
+		if err != nil {
+			t.Fatalf("selectNotes failed: %v", err)
+		}
+		if len(notes) != 2 || notes[0].ID != 2 || notes[1].ID != 1 {
+			t.Errorf("Expected notes 2 and 1 without duplicates, got %v", notes)
+		}
+
+		if _, err := handler.selectNotes(ctx, []string{"99"}, false); err == nil {
+			t.Error("Expected error for missing note")
+		}
+	})
+
+	t.Run("rejects bad options", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+
+		if err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: "docx", OutDir: t.TempDir()}); err == nil {
+			t.Error("Expected error for unsupported format")
+		}
+		if err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: ExportMarkdown}); err == nil {
+			t.Error("Expected error without output directory")
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+		out := t.TempDir()
+
+		if err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: ExportMarkdown, OutDir: out}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+
+		first := readFile(t, filepath.Join(out, "first-note.md"))
+		if !strings.Contains(first, "title: First Note") {
+			t.Error("Expected front matter in exported markdown")
+		}
+		if !strings.Contains(first, "[Second Note](second-note.md)") {
+			t.Errorf("Expected wiki link to be resolved, got:\n%s", first)
+		}
+		if !strings.Contains(first, "](assets/") {
+			t.Errorf("Expected image to point at copied asset, got:\n%s", first)
+		}
+
+		second := readFile(t, filepath.Join(out, "second-note.md"))
+		if !strings.Contains(second, "[first](first-note.md)") {
+			t.Errorf("Expected note: link to be resolved, got:\n%s", second)
+		}
+
+		assets, _ := filepath.Glob(filepath.Join(out, "assets", "*-diagram.png"))
+		if len(assets) != 1 {
+			t.Errorf("Expected image to be copied, found %v", assets)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+		out := t.TempDir()
+
+		if err := handler.Export(ctx, []string{"1", "2"}, NoteExportOptions{Format: ExportHTML, OutDir: out}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+
+		first := readFile(t, filepath.Join(out, "first-note.html"))
+		if !strings.Contains(first, "@media print") {
+			t.Error("Expected print stylesheet")
+		}
+		if !strings.Contains(first, `href="second-note.html"`) {
+			t.Errorf("Expected inter-note link, got:\n%s", first)
+		}
+		if strings.Count(first, "<h1") != 1 {
+			t.Error("Expected title heading not to be duplicated")
+		}
+
+		second := readFile(t, filepath.Join(out, "second-note.html"))
+		if !strings.Contains(second, "<h1>Second Note</h1>") {
+			t.Error("Expected title heading to be added")
+		}
+	})
+
+	t.Run("site", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+		out := t.TempDir()
+
+		err := handler.Export(ctx, []string{"all"}, NoteExportOptions{Format: ExportSite, OutDir: out, Title: "My Notes"})
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+
+		index := readFile(t, filepath.Join(out, "index.html"))
+		for _, expected := range []string{"My Notes", `href="notes/first-note.html"`, `href="tags/research.html"`, "research (2)"} {
+			if !strings.Contains(index, expected) {
+				t.Errorf("Expected index to contain %q", expected)
+			}
+		}
+
+		tagPage := readFile(t, filepath.Join(out, "tags", "draft.html"))
+		if !strings.Contains(tagPage, `href="../notes/second-note.html"`) {
+			t.Errorf("Expected tag page to link note, got:\n%s", tagPage)
+		}
+
+		page := readFile(t, filepath.Join(out, "notes", "first-note.html"))
+		if !strings.Contains(page, `src="../assets/`) {
+			t.Error("Expected image path relative to site root")
+		}
+		if _, err := os.Stat(filepath.Join(out, "style.css")); err != nil {
+			t.Errorf("Expected stylesheet: %v", err)
+		}
+	})
+
+	t.Run("epub", func(t *testing.T) {
+		handler, _ := newExportTestHandler(t)
+		out := t.TempDir()
+
+		err := handler.Export(ctx, []string{"tag:research"}, NoteExportOptions{Format: ExportEPUB, OutDir: out, Title: "Research"})
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+
+		zr, err := zip.OpenReader(filepath.Join(out, "research.epub"))
+		if err != nil {
+			t.Fatalf("Failed to open epub: %v", err)
+		}
+		defer zr.Close()
+
+		if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+			t.Error("Expected uncompressed mimetype as first entry")
+		}
+
+		files := map[string]string{}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", f.Name, err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(data)
+		}
+
+		for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/text/first-note.xhtml", "OEBPS/text/second-note.xhtml"} {
+			if _, ok := files[name]; !ok {
+				t.Errorf("Expected %s in epub", name)
+			}
+		}
+
+		opf := files["OEBPS/content.opf"]
+		if !strings.Contains(opf, `href="images/`) || !strings.Contains(opf, `media-type="image/png"`) {
+			t.Errorf("Expected image in manifest, got:\n%s", opf)
+		}
+		if !strings.Contains(files["OEBPS/text/first-note.xhtml"], `href="second-note.xhtml"`) {
+			t.Error("Expected chapter link to resolve to other chapter")
+		}
+		if _, err := os.Stat(filepath.Join(out, "assets")); !os.IsNotExist(err) {
+			t.Error("Expected images to be bundled, not copied to the output directory")
+		}
+	})
+}
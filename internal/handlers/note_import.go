@@ -0,0 +1,829 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/store"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// Supported note import sources
+const (
+	ImportObsidian = "obsidian"
+	ImportNotion   = "notion"
+	ImportBear     = "bear"
+	ImportENEX     = "enex"
+)
+
+var (
+	// notionIDSuffix matches the page ID Notion appends to exported file and folder names
+	notionIDSuffix = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+	// notionProperty matches a "Key: value" line of the property block under a Notion page title
+	notionProperty = regexp.MustCompile(`^([A-Z][\w ]{0,40}):\s+(.+)$`)
+	// wikiEmbedPattern matches Obsidian embeds such as ![[diagram.png]] or ![[Note|Label]]
+	wikiEmbedPattern = regexp.MustCompile(`!\[\[([^\]|#]+)(#[^\]|]*)?(?:\|([^\]]+))?\]\]`)
+	// bearTagPattern matches Bear's multi-word tags, which are closed with a trailing #
+	bearTagPattern = regexp.MustCompile(`(?:^|\s)#([^\s#][^#\n]*[^\s#\\])#(?:\s|$)`)
+	// inlineTagPattern matches #tag and #nested/tag
+	inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+	inlineCode       = regexp.MustCompile("`[^`\n]*`")
+	markdownLink     = regexp.MustCompile(`(\[[^\]]*\]\()(<[^>]+>|[^)\s]+)`)
+)
+
+var notionDateLayouts = []string{"January 2, 2006 3:04 PM", "January 2, 2006", "2006-01-02T15:04:05Z07:00", "2006-01-02"}
+
+// importedNote is a note read from another application, before it's stored
+type importedNote struct {
+	path      string // location in the export, relative to its root
+	dir       string // directory relative links and attachments resolve against
+	title     string
+	content   string
+	tags      []string
+	created   time.Time
+	modified  time.Time
+	resources map[string]enexResource // ENEX attachments keyed by MD5
+}
+
+// noteImporter holds state shared while importing one export
+type noteImporter struct {
+	source      string
+	root        string
+	attachDir   string
+	files       map[string]string // lowercased base name to path, for resolving Obsidian embeds
+	conv        *public.MarkdownConverter
+	attachments int
+}
+
+// importTarget is a note that imported links can point at
+type importTarget struct {
+	note *models.Note
+	id   int64
+}
+
+func (t importTarget) ID() int64 {
+	if t.note != nil {
+		return t.note.ID
+	}
+	return t.id
+}
+
+// Import brings notes in from an Obsidian vault, a Notion or Bear markdown export, or an
+// Evernote ENEX file. Notes already imported (matched by content hash) are skipped.
+func (h *NoteHandler) Import(ctx context.Context, source, path string) error {
+	switch source {
+	case ImportObsidian, ImportNotion, ImportBear, ImportENEX:
+	default:
+		return fmt.Errorf("unsupported import source %q: must be one of obsidian, notion, bear, enex", source)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	root := path
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".zip") {
+		tmp, err := os.MkdirTemp("", "noteleaf-import-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+
+		if err := extractZip(path, tmp); err != nil {
+			return err
+		}
+		root, info = tmp, nil
+	}
+
+	attachDir, err := h.getAttachmentsDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to get attachments directory: %w", err)
+	}
+
+	imp := &noteImporter{
+		source:    source,
+		root:      root,
+		attachDir: attachDir,
+		files:     map[string]string{},
+		conv:      public.NewMarkdownConverter(),
+	}
+
+	var items []*importedNote
+	switch {
+	case source == ImportENEX:
+		items, err = imp.readENEX(path)
+	case info != nil && !info.IsDir():
+		return fmt.Errorf("%s import expects a directory or .zip export", source)
+	default:
+		items, err = imp.readMarkdownExport()
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.storeImported(ctx, imp, items, path)
+}
+
+// storeImported saves new notes, skipping any whose content hash was imported before
+func (h *NoteHandler) storeImported(ctx context.Context, imp *noteImporter, items []*importedNote, path string) error {
+	targets := map[string]importTarget{}
+	addTarget := func(item *importedNote, target importTarget) {
+		keys := []string{strings.ToLower(item.title)}
+		if item.path != "" && !strings.Contains(item.path, ":") {
+			stem := strings.TrimSuffix(filepath.ToSlash(item.path), filepath.Ext(item.path))
+			keys = append(keys, strings.ToLower(stem), strings.ToLower(filepath.Base(stem)),
+				strings.ToLower(cleanNotionName(filepath.Base(stem))))
+		}
+		for _, key := range keys {
+			if _, ok := targets[key]; !ok && key != "" {
+				targets[key] = target
+			}
+		}
+	}
+
+	var notes []*models.Note
+	var records []*models.NoteImport
+	sources := map[*models.Note]*importedNote{}
+	seen := map[string]bool{}
+	copies := map[string]int{}
+	skipped := 0
+
+	for _, item := range items {
+		// Only the same note read twice is a duplicate within one run; distinct notes
+		// may share content, such as several empty "Untitled" ones
+		key := repo.HashContent(item.path + "\n" + item.title + "\n" + item.content)
+		if seen[key] {
+			skipped++
+			continue
+		}
+		seen[key] = true
+
+		hash := importHash(item, copies)
+		existing, err := h.repos.Notes.GetImport(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			addTarget(item, importTarget{id: existing.NoteID})
+			skipped++
+			continue
+		}
+
+		note := &models.Note{
+			Title:    item.title,
+			Content:  imp.copyAttachments(item),
+			Tags:     item.tags,
+			Created:  item.created,
+			Modified: item.modified,
+		}
+		notes = append(notes, note)
+		records = append(records, &models.NoteImport{Hash: hash, Source: imp.source, Path: item.path})
+		sources[note] = item
+		addTarget(item, importTarget{note: note})
+	}
+
+	if len(notes) == 0 {
+		ui.Infoln("Nothing to import from %s (%d note(s) already imported)", path, skipped)
+		return nil
+	}
+
+	err := h.repos.Notes.ImportNotes(ctx, notes, records, func(note *models.Note) string {
+		return imp.rewriteLinks(sources[note], note.Content, targets)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import notes: %w", err)
+	}
+
+	ui.Successln("Imported %d note(s) from %s", len(notes), path)
+	if skipped > 0 {
+		ui.Infoln("Skipped %d note(s) already imported", skipped)
+	}
+	if imp.attachments > 0 {
+		ui.Infoln("Copied %d attachment(s) to %s", imp.attachments, imp.attachDir)
+	}
+	return nil
+}
+
+// importHash returns the content hash an import record is keyed by. Notes with the
+// same title and content are numbered in export order, so each gets its own record
+// and importing the export again skips every copy that was already imported.
+func importHash(item *importedNote, copies map[string]int) string {
+	hash := repo.HashContent(item.title + "\n" + item.content)
+	n := copies[hash]
+	copies[hash]++
+	if n == 0 {
+		return hash
+	}
+	return repo.HashContent(fmt.Sprintf("%s\n%d", hash, n))
+}
+
+// readMarkdownExport reads every markdown file under the import root
+func (imp *noteImporter) readMarkdownExport() ([]*importedNote, error) {
+	var items []*importedNote
+	err := filepath.WalkDir(imp.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if strings.HasPrefix(name, ".") && path != imp.root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if strings.EqualFold(filepath.Ext(name), ".textbundle") {
+				item, err := imp.readTextBundle(path)
+				if err != nil {
+					return err
+				}
+				if item != nil {
+					items = append(items, item)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isMarkdownFile(name) {
+			imp.files[strings.ToLower(name)] = path
+			return nil
+		}
+
+		item, err := imp.readMarkdownFile(path, path)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s export: %w", imp.source, err)
+	}
+	return items, nil
+}
+
+// readTextBundle reads a Bear TextBundle directory, which holds text.md and an assets folder
+func (imp *noteImporter) readTextBundle(dir string) (*importedNote, error) {
+	for _, name := range []string{"text.md", "text.markdown", "text.txt"} {
+		path := filepath.Join(dir, name)
+		if fileExists(path) {
+			return imp.readMarkdownFile(path, dir)
+		}
+	}
+	return nil, nil
+}
+
+// readMarkdownFile parses one exported file. location is the file, or the bundle it belongs to.
+func (imp *noteImporter) readMarkdownFile(path, location string) (*importedNote, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, body, err := splitFrontMatter(string(data))
+	if err != nil {
+		ui.Warningln("Ignoring front matter of %s: %v", path, err)
+	}
+
+	rel, err := filepath.Rel(imp.root, location)
+	if err != nil {
+		rel = filepath.Base(location)
+	}
+
+	item := &importedNote{
+		path:     filepath.ToSlash(rel),
+		dir:      filepath.Dir(path),
+		content:  body,
+		created:  info.ModTime(),
+		modified: info.ModTime(),
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	headingTitle, _, _ := parseNoteContent(body)
+	item.title = firstNonEmpty(fm.Title, headingTitle, cleanNotionName(stem))
+
+	tags := []string(fm.Tags)
+	if dir := filepath.Dir(rel); dir != "." {
+		var parts []string
+		for part := range strings.SplitSeq(filepath.ToSlash(dir), "/") {
+			parts = append(parts, cleanNotionName(part))
+		}
+		tags = append(tags, strings.Join(parts, "/"))
+	}
+
+	switch imp.source {
+	case ImportNotion:
+		props := notionProperties(body)
+		if value, ok := props["tags"]; ok {
+			tags = append(tags, strings.Split(value, ",")...)
+		}
+		if t, ok := parseNotionDate(props, "created", "created time"); ok {
+			item.created = t
+		}
+		if t, ok := parseNotionDate(props, "last edited", "last edited time", "updated"); ok {
+			item.modified = t
+		}
+	case ImportObsidian, ImportBear:
+		tags = append(tags, inlineTags(body, imp.source == ImportBear)...)
+	}
+
+	if fm.Created != nil {
+		item.created = *fm.Created
+	}
+	// explicit creation times are more reliable than file times, which copying may reset
+	if item.modified.Before(item.created) {
+		item.modified = item.created
+	}
+	item.tags = normalizeTags(tags)
+	return item, nil
+}
+
+// notionProperties reads the "Key: value" block Notion writes under the page title
+func notionProperties(body string) map[string]string {
+	props := map[string]string{}
+	started := false
+	for line := range strings.SplitSeq(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "# ") && !started:
+		case line == "":
+			if started {
+				return props
+			}
+		default:
+			m := notionProperty.FindStringSubmatch(line)
+			if m == nil {
+				return props
+			}
+			started = true
+			props[strings.ToLower(m[1])] = m[2]
+		}
+	}
+	return props
+}
+
+func parseNotionDate(props map[string]string, keys ...string) (time.Time, bool) {
+	for _, key := range keys {
+		value, ok := props[key]
+		if !ok {
+			continue
+		}
+		for _, layout := range notionDateLayouts {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// inlineTags collects #tags outside of code. Bear also allows #multi word tags#.
+func inlineTags(body string, bear bool) []string {
+	var tags []string
+	inFence := false
+	for line := range strings.SplitSeq(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		line = inlineCode.ReplaceAllString(line, "")
+		if bear {
+			for _, m := range bearTagPattern.FindAllStringSubmatch(line, -1) {
+				tags = append(tags, m[1])
+			}
+			line = bearTagPattern.ReplaceAllString(line, " ")
+		}
+		for _, m := range inlineTagPattern.FindAllStringSubmatch(line, -1) {
+			if strings.Trim(m[1], "0123456789") != "" {
+				tags = append(tags, strings.TrimRight(m[1], "/-"))
+			}
+		}
+	}
+	return tags
+}
+
+// normalizeTags lowercases tags, joins words with dashes and drops duplicates
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))), "-")
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func cleanNotionName(name string) string {
+	return notionIDSuffix.ReplaceAllString(name, "")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func isMarkdownFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// copyAttachments copies local files referenced by a note into the attachments directory
+// and returns the content pointing at the copies
+func (imp *noteImporter) copyAttachments(item *importedNote) string {
+	content := wikiEmbedPattern.ReplaceAllStringFunc(item.content, func(match string) string {
+		groups := wikiEmbedPattern.FindStringSubmatch(match)
+		target := strings.TrimSpace(groups[1])
+		if isMarkdownFile(target) || filepath.Ext(target) == "" {
+			return match
+		}
+
+		src := imp.locate(item, target)
+		if src == "" {
+			return match
+		}
+		dest, err := imp.saveAttachmentFile(src)
+		if err != nil {
+			ui.Warningln("Skipping attachment %s of %q: %v", target, item.title, err)
+			return match
+		}
+		return attachmentMarkdown(filepath.Base(target), dest, mime.TypeByExtension(filepath.Ext(target)))
+	})
+
+	replacements := map[string]string{}
+	dests := append(imp.conv.ImageURLs(content), imp.conv.LinkURLs(content)...)
+	for _, dest := range dests {
+		if _, done := replacements[dest]; done || !isLocalReference(dest) {
+			continue
+		}
+		src := imp.locate(item, dest)
+		if src == "" {
+			continue
+		}
+		saved, err := imp.saveAttachmentFile(src)
+		if err != nil {
+			ui.Warningln("Skipping attachment %s of %q: %v", dest, item.title, err)
+			continue
+		}
+		replacements[dest] = saved
+	}
+	return rewriteMarkdownDestinations(content, replacements)
+}
+
+// isLocalReference reports whether a link destination may point at a file in the export
+func isLocalReference(dest string) bool {
+	if dest == "" || strings.HasPrefix(dest, "#") || strings.Contains(dest, ":") {
+		return false
+	}
+	return !isMarkdownFile(strings.SplitN(dest, "#", 2)[0])
+}
+
+// locate finds a referenced file relative to the note, the export root, or by name anywhere in it
+func (imp *noteImporter) locate(item *importedNote, ref string) string {
+	ref = strings.Trim(ref, "<>")
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+	ref = filepath.FromSlash(ref)
+
+	for _, base := range []string{item.dir, imp.root} {
+		if base == "" {
+			continue
+		}
+		path := filepath.Join(base, ref)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && isWithin(imp.root, path) {
+			return path
+		}
+	}
+	return imp.files[strings.ToLower(filepath.Base(ref))]
+}
+
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (imp *noteImporter) saveAttachmentFile(src string) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	return imp.saveAttachment(filepath.Base(src), data)
+}
+
+// saveAttachment stores data under a content addressed name and returns a link destination for it
+func (imp *noteImporter) saveAttachment(name string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name = hex.EncodeToString(sum[:4]) + "-" + strings.Join(strings.Fields(filepath.Base(name)), "-")
+	path := filepath.Join(imp.attachDir, name)
+
+	if !fileExists(path) {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write attachment: %w", err)
+		}
+	}
+	imp.attachments++
+	return (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath(), nil
+}
+
+func attachmentMarkdown(name, dest, mediaType string) string {
+	if strings.HasPrefix(mediaType, "image/") {
+		return fmt.Sprintf("![%s](%s)", name, dest)
+	}
+	return fmt.Sprintf("[%s](%s)", name, dest)
+}
+
+// rewriteLinks turns wiki links and links to exported markdown files into note:<id> links
+func (imp *noteImporter) rewriteLinks(item *importedNote, content string, targets map[string]importTarget) string {
+	lookup := func(ref string) (importTarget, bool) {
+		ref = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(filepath.ToSlash(ref), filepath.Ext(ref))))
+		if target, ok := targets[ref]; ok {
+			return target, true
+		}
+		target, ok := targets[strings.ToLower(filepath.Base(ref))]
+		return target, ok
+	}
+
+	rewriteWiki := func(pattern *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			target, ok := lookup(groups[1])
+			if !ok {
+				return match
+			}
+			label := firstNonEmpty(groups[3], groups[1])
+			fragment := ""
+			if heading := strings.TrimPrefix(groups[2], "#"); heading != "" {
+				fragment = "#" + slugify(heading)
+			}
+			return fmt.Sprintf("[%s](note:%d%s)", label, target.ID(), fragment)
+		}
+	}
+	content = wikiEmbedPattern.ReplaceAllStringFunc(content, rewriteWiki(wikiEmbedPattern))
+	content = wikiLinkPattern.ReplaceAllStringFunc(content, rewriteWiki(wikiLinkPattern))
+
+	if item == nil || item.dir == "" {
+		return content
+	}
+	return markdownLink.ReplaceAllStringFunc(content, func(match string) string {
+		groups := markdownLink.FindStringSubmatch(match)
+		dest, fragment, _ := strings.Cut(strings.Trim(groups[2], "<>"), "#")
+		if !isMarkdownFile(dest) || strings.Contains(dest, "://") {
+			return match
+		}
+		if unescaped, err := url.PathUnescape(dest); err == nil {
+			dest = unescaped
+		}
+
+		rel, err := filepath.Rel(imp.root, filepath.Join(item.dir, filepath.FromSlash(dest)))
+		if err != nil {
+			return match
+		}
+		target, ok := lookup(rel)
+		if !ok {
+			return match
+		}
+		if fragment != "" {
+			fragment = "#" + fragment
+		}
+		return fmt.Sprintf("%snote:%d%s", groups[1], target.ID(), fragment)
+	})
+}
+
+// enexExport is the root element of an Evernote export
+type enexExport struct {
+	Notes []enexNote `xml:"note"`
+}
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// readENEX reads an .enex file, or every .enex file in a directory
+func (imp *noteImporter) readENEX(path string) ([]*importedNote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.enex"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .enex files found in %s", path)
+		}
+	}
+
+	var items []*importedNote
+	for _, file := range files {
+		notes, err := imp.readENEXFile(file)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, notes...)
+	}
+	return items, nil
+}
+
+func (imp *noteImporter) readENEXFile(path string) ([]*importedNote, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var export enexExport
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	if err := dec.Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	notebook := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var items []*importedNote
+	for i, n := range export.Notes {
+		item := &importedNote{
+			path:      fmt.Sprintf("%s:%d", filepath.Base(path), i+1),
+			title:     firstNonEmpty(n.Title, "Untitled"),
+			tags:      normalizeTags(append(n.Tags, notebook)),
+			resources: map[string]enexResource{},
+		}
+		item.created, _ = time.Parse("20060102T150405Z", n.Created)
+		item.modified, _ = time.Parse("20060102T150405Z", n.Updated)
+		if item.modified.IsZero() {
+			item.modified = item.created
+		}
+
+		for _, res := range n.Resources {
+			data, err := res.decode()
+			if err != nil {
+				ui.Warningln("Skipping attachment of %q: %v", item.title, err)
+				continue
+			}
+			sum := md5.Sum(data)
+			item.resources[hex.EncodeToString(sum[:])] = res
+		}
+
+		content, err := enmlToMarkdown(n.Content, func(hash string) string {
+			return imp.saveResource(item, hash)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %q: %w", item.title, err)
+		}
+		item.content = content
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r enexResource) decode() ([]byte, error) {
+	if r.Data.Encoding != "" && r.Data.Encoding != "base64" {
+		return nil, fmt.Errorf("unsupported resource encoding %q", r.Data.Encoding)
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(r.Data.Value), ""))
+}
+
+// saveResource stores the ENEX attachment with the given hash and returns markdown referencing it
+func (imp *noteImporter) saveResource(item *importedNote, hash string) string {
+	res, ok := item.resources[strings.ToLower(hash)]
+	if !ok {
+		return ""
+	}
+	data, err := res.decode()
+	if err != nil {
+		return ""
+	}
+
+	name := res.FileName
+	if name == "" {
+		name = "attachment-" + hash[:min(8, len(hash))]
+		if exts, _ := mime.ExtensionsByType(res.Mime); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+
+	dest, err := imp.saveAttachment(name, data)
+	if err != nil {
+		ui.Warningln("Skipping attachment %s of %q: %v", name, item.title, err)
+		return ""
+	}
+	return attachmentMarkdown(name, dest, res.Mime)
+}
+
+// extractZip unpacks an export archive, refusing entries that would land outside dir
+func extractZip(path, dir string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !isWithin(dir, target) {
+			return fmt.Errorf("invalid path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+		os.Chtimes(target, f.Modified, f.Modified)
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (h *NoteHandler) getAttachmentsDirectory() (string, error) {
+	dataDir := h.config.DataDir
+	if dataDir == "" {
+		var err error
+		if dataDir, err = store.GetDataDir(); err != nil {
+			return "", err
+		}
+	}
+
+	dir := filepath.Join(dataDir, "attachments")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
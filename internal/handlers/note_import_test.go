@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func notesByTitle(t *testing.T, handler *NoteHandler) map[string]*models.Note {
+	t.Helper()
+	byTitle := map[string]*models.Note{}
+	for _, note := range listAllNotes(t, handler) {
+		byTitle[note.Title] = note
+	}
+	return byTitle
+}
+
+func TestENMLToMarkdown(t *testing.T) {
+	enml := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note>
+<h2>Plan</h2>
+<div>Some <b>bold</b> and <i>italic</i> text with a <a href="https://example.com">link</a>.</div>
+<div><br/></div>
+<div><en-todo checked="true"/>Done</div>
+<div><en-todo/>Open</div>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<blockquote><div>quoted</div></blockquote>
+<pre>code  block</pre>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>
+<en-media hash="abc" type="image/png"/>
+</en-note>`
+
+	out, err := enmlToMarkdown(enml, func(hash string) string { return "![img](" + hash + ".png)" })
+	if err != nil {
+		t.Fatalf("enmlToMarkdown failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## Plan",
+		"Some **bold** and *italic* text with a [link](https://example.com).",
+		"- [x] Done",
+		"- [ ] Open",
+		"- one\n- two\n  1. nested",
+		"> quoted",
+		"```\ncode  block\n```",
+		"| A | B |\n| --- | --- |\n| 1 | 2 |",
+		"![img](abc.png)",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "\n\n\n") {
+		t.Error("Expected blank lines to be collapsed")
+	}
+}
+
+func TestInlineTags(t *testing.T) {
+	body := "# Heading\n\nText #project and #area/work but not #123 or `#code`\n\n```\n#fenced\n```\n"
+	tags := inlineTags(body, false)
+	if !slices.Equal(tags, []string{"project", "area/work"}) {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+
+	tags = inlineTags("Bear #multi word tag# and #simple\n", true)
+	if !slices.Equal(normalizeTags(tags), []string{"multi-word-tag", "simple"}) {
+		t.Errorf("Unexpected bear tags: %v", tags)
+	}
+}
+
+func TestNoteImport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects unknown source", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		if err := handler.Import(ctx, "onenote", t.TempDir()); err == nil {
+			t.Error("Expected error for unsupported source")
+		}
+		if err := handler.Import(ctx, ImportObsidian, filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("Expected error for missing path")
+		}
+	})
+
+	t.Run("obsidian vault", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		vault := t.TempDir()
+		writeTree(t, vault, map[string]string{
+			"Home.md":                     "---\ntags: [index]\ncreated: 2020-05-06T07:08:09Z\n---\n# Home\n\nSee [[Projects/Roadmap|the roadmap]] and [[Missing]].\n\n![[diagram.png]]\n",
+			"Projects/Roadmap.md":         "Roadmap body #planning\n\nBack to [[Home#Intro]]\n",
+			"attachments/diagram.png":     "png-data",
+			".obsidian/workspace.json":    "{}",
+			".trash/Deleted.md":           "gone",
+			"Projects/Work/Meeting 01.md": "Notes with ![shot](../../attachments/diagram.png)\n",
+		})
+		modTime := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+		os.Chtimes(filepath.Join(vault, "Projects", "Roadmap.md"), modTime, modTime)
+
+		if err := handler.Import(ctx, ImportObsidian, vault); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+
+		notes := notesByTitle(t, handler)
+		if len(notes) != 3 {
+			t.Fatalf("Expected 3 notes, got %d", len(notes))
+		}
+
+		home, roadmap, meeting := notes["Home"], notes["Roadmap"], notes["Meeting 01"]
+		if home == nil || roadmap == nil || meeting == nil {
+			t.Fatalf("Missing imported notes: %v", notes)
+		}
+
+		if !strings.Contains(home.Content, fmt.Sprintf("[the roadmap](note:%d)", roadmap.ID)) {
+			t.Errorf("Expected wiki link to be rewritten, got:\n%s", home.Content)
+		}
+		if !strings.Contains(home.Content, "[[Missing]]") {
+			t.Error("Expected unresolved wiki link to be kept")
+		}
+		if !strings.Contains(roadmap.Content, fmt.Sprintf("[Home](note:%d#intro)", home.ID)) {
+			t.Errorf("Expected heading link to be rewritten, got:\n%s", roadmap.Content)
+		}
+		if !strings.Contains(home.Content, "![diagram.png](") || strings.Contains(home.Content, "![[") {
+			t.Errorf("Expected embed to become an image, got:\n%s", home.Content)
+		}
+		if strings.Contains(meeting.Content, "../../attachments") {
+			t.Errorf("Expected relative image to be copied, got:\n%s", meeting.Content)
+		}
+
+		attachments, _ := filepath.Glob(filepath.Join(os.Getenv("NOTELEAF_DATA_DIR"), "attachments", "*-diagram.png"))
+		if len(attachments) != 1 {
+			t.Errorf("Expected a single copied attachment, got %v", attachments)
+		}
+
+		if !slices.Contains(home.Tags, "index") {
+			t.Errorf("Expected front matter tags, got %v", home.Tags)
+		}
+		if !slices.Contains(roadmap.Tags, "projects") || !slices.Contains(roadmap.Tags, "planning") {
+			t.Errorf("Expected folder and inline tags, got %v", roadmap.Tags)
+		}
+		if !slices.Contains(meeting.Tags, "projects/work") {
+			t.Errorf("Expected nested folder tag, got %v", meeting.Tags)
+		}
+
+		if !home.Created.Equal(time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)) {
+			t.Errorf("Expected created from front matter, got %v", home.Created)
+		}
+		if !roadmap.Modified.Equal(modTime) {
+			t.Errorf("Expected modified from file time, got %v", roadmap.Modified)
+		}
+
+		t.Run("is idempotent", func(t *testing.T) {
+			if err := handler.Import(ctx, ImportObsidian, vault); err != nil {
+				t.Fatalf("Second import failed: %v", err)
+			}
+			if n := len(listAllNotes(t, handler)); n != 3 {
+				t.Errorf("Expected no duplicates, got %d notes", n)
+			}
+
+			writeTree(t, vault, map[string]string{"New.md": "Links [[Home]]\n"})
+			if err := handler.Import(ctx, ImportObsidian, vault); err != nil {
+				t.Fatalf("Third import failed: %v", err)
+			}
+			notes := notesByTitle(t, handler)
+			if len(notes) != 4 {
+				t.Fatalf("Expected only the new note to be added, got %d notes", len(notes))
+			}
+			if !strings.Contains(notes["New"].Content, fmt.Sprintf("(note:%d)", home.ID)) {
+				t.Errorf("Expected link to previously imported note, got:\n%s", notes["New"].Content)
+			}
+		})
+	})
+
+	t.Run("notion zip", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		id := "0123456789abcdef0123456789abcdef"
+		other := "fedcba9876543210fedcba9876543210"
+
+		archive := filepath.Join(t.TempDir(), "Export.zip")
+		f, err := os.Create(archive)
+		if err != nil {
+			t.Fatalf("Failed to create zip: %v", err)
+		}
+		zw := zip.NewWriter(f)
+		for name, content := range map[string]string{
+			"Workspace " + id + "/Reading List " + other + ".md": "# Reading List\n\nCreated: March 4, 2022 10:30 AM\nTags: books, Later\n\nBack to [Workspace](../Workspace%20" + id + ".md)\n",
+			"Workspace " + id + ".md":                            "# Workspace\n\nSee [Reading List](Workspace%20" + id + "/Reading%20List%20" + other + ".md)\n",
+		} {
+			w, _ := zw.Create(name)
+			w.Write([]byte(content))
+		}
+		zw.Close()
+		f.Close()
+
+		if err := handler.Import(ctx, ImportNotion, archive); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+
+		notes := notesByTitle(t, handler)
+		workspace, reading := notes["Workspace"], notes["Reading List"]
+		if workspace == nil || reading == nil {
+			t.Fatalf("Missing imported notes: %v", notes)
+		}
+
+		if !strings.Contains(workspace.Content, fmt.Sprintf("[Reading List](note:%d)", reading.ID)) {
+			t.Errorf("Expected page link to be rewritten, got:\n%s", workspace.Content)
+		}
+		if !strings.Contains(reading.Content, fmt.Sprintf("[Workspace](note:%d)", workspace.ID)) {
+			t.Errorf("Expected parent link to be rewritten, got:\n%s", reading.Content)
+		}
+		for _, tag := range []string{"workspace", "books", "later"} {
+			if !slices.Contains(reading.Tags, tag) {
+				t.Errorf("Expected tag %q, got %v", tag, reading.Tags)
+			}
+		}
+		if reading.Created.Year() != 2022 || reading.Created.Month() != time.March {
+			t.Errorf("Expected created from Notion property, got %v", reading.Created)
+		}
+	})
+
+	t.Run("bear export", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		export := t.TempDir()
+		writeTree(t, export, map[string]string{
+			"Groceries.md":                   "# Groceries\n\n- milk #home/errands #weekly shop#\n",
+			"Trip.textbundle/text.md":        "# Trip\n\n![](assets/map.jpg)\n",
+			"Trip.textbundle/assets/map.jpg": "jpg-data",
+			"Trip.textbundle/info.json":      "{}",
+		})
+
+		if err := handler.Import(ctx, ImportBear, export); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+
+		notes := notesByTitle(t, handler)
+		if len(notes) != 2 {
+			t.Fatalf("Expected 2 notes, got %d", len(notes))
+		}
+		if tags := notes["Groceries"].Tags; !slices.Contains(tags, "home/errands") || !slices.Contains(tags, "weekly-shop") {
+			t.Errorf("Unexpected tags: %v", tags)
+		}
+		if trip := notes["Trip"]; trip == nil || strings.Contains(trip.Content, "assets/map.jpg") {
+			t.Errorf("Expected textbundle asset to be copied, got %v", trip)
+		}
+	})
+
+	t.Run("enex", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		image := base64.StdEncoding.EncodeToString([]byte("image-bytes"))
+
+		enex := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20230101T000000Z" application="Evernote" version="10">
+  <note>
+    <title>Recipe</title>
+    <created>20190102T030405Z</created>
+    <updated>20200102T030405Z</updated>
+    <tag>Cooking</tag>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>Mix <b>well</b></div><en-media hash="HASH" type="image/png"/></en-note>]]></content>
+    <resource>
+      <data encoding="base64">` + image + `</data>
+      <mime>image/png</mime>
+      <resource-attributes><file-name>photo.png</file-name></resource-attributes>
+    </resource>
+  </note>
+  <note>
+    <title>Empty</title>
+    <created>20190102T030405Z</created>
+    <content><![CDATA[<en-note><div>Nothing</div></en-note>]]></content>
+  </note>
+</en-export>`
+
+		enex = strings.ReplaceAll(enex, "HASH", fmt.Sprintf("%x", md5.Sum([]byte("image-bytes"))))
+
+		path := filepath.Join(t.TempDir(), "Kitchen.enex")
+		writeTestFile(t, path, enex, time.Time{})
+
+		if err := handler.Import(ctx, ImportENEX, path); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+
+		notes := notesByTitle(t, handler)
+		recipe := notes["Recipe"]
+		if recipe == nil {
+			t.Fatalf("Missing imported note: %v", notes)
+		}
+		if !strings.Contains(recipe.Content, "Mix **well**") || !strings.Contains(recipe.Content, "![photo.png](") {
+			t.Errorf("Unexpected content:\n%s", recipe.Content)
+		}
+		if !slices.Equal(recipe.Tags, []string{"cooking", "kitchen"}) {
+			t.Errorf("Expected tag and notebook tags, got %v", recipe.Tags)
+		}
+		if !recipe.Created.Equal(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)) || !recipe.Modified.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("Expected ENEX timestamps, got %v / %v", recipe.Created, recipe.Modified)
+		}
+		if empty := notes["Empty"]; empty == nil || !empty.Modified.Equal(empty.Created) {
+			t.Error("Expected missing updated time to fall back to created")
+		}
+
+		if err := handler.Import(ctx, ImportENEX, path); err != nil {
+			t.Fatalf("Second import failed: %v", err)
+		}
+		if n := len(listAllNotes(t, handler)); n != 2 {
+			t.Errorf("Expected no duplicates, got %d notes", n)
+		}
+	})
+
+	t.Run("keeps distinct notes with the same content", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+
+		enex := `<?xml version="1.0" encoding="UTF-8"?>
+<en-export>
+  <note><content><![CDATA[<en-note></en-note>]]></content></note>
+  <note><content><![CDATA[<en-note></en-note>]]></content></note>
+  <note><title>Untitled</title><content><![CDATA[<en-note></en-note>]]></content></note>
+</en-export>`
+		path := filepath.Join(t.TempDir(), "Inbox.enex")
+		writeTestFile(t, path, enex, time.Time{})
+
+		if err := handler.Import(ctx, ImportENEX, path); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if n := len(listAllNotes(t, handler)); n != 3 {
+			t.Fatalf("Expected every empty note to be imported, got %d notes", n)
+		}
+
+		if err := handler.Import(ctx, ImportENEX, path); err != nil {
+			t.Fatalf("Second import failed: %v", err)
+		}
+		if n := len(listAllNotes(t, handler)); n != 3 {
+			t.Errorf("Expected re-import to skip every copy, got %d notes", n)
+		}
+
+		more := strings.Replace(enex, "</en-export>", `<note><content><![CDATA[<en-note></en-note>]]></content></note>
+</en-export>`, 1)
+		writeTestFile(t, path, more, time.Time{})
+		if err := handler.Import(ctx, ImportENEX, path); err != nil {
+			t.Fatalf("Third import failed: %v", err)
+		}
+		if n := len(listAllNotes(t, handler)); n != 4 {
+			t.Errorf("Expected only the new copy to be imported, got %d notes", n)
+		}
+	})
+}
@@ -0,0 +1,643 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/store"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+const (
+	// ConflictMtime resolves sync conflicts in favour of the most recently modified side
+	ConflictMtime = "mtime"
+	// ConflictPrompt asks which side to keep for every sync conflict
+	ConflictPrompt = "prompt"
+
+	syncDebounce = 250 * time.Millisecond
+)
+
+// NoteSyncOptions configures [NoteHandler.Sync]
+type NoteSyncOptions struct {
+	Watch    bool
+	Conflict string // ConflictMtime (default) or ConflictPrompt
+	// Delete removes notes whose synced file was deleted; without it they are only reported
+	Delete bool
+}
+
+type syncSide int
+
+const (
+	syncSkip syncSide = iota
+	syncUseFile
+	syncUseNote
+)
+
+type conflictPromptFunc func(note *models.Note, path string) (syncSide, error)
+
+type noteSyncResult struct {
+	imported     int
+	exported     int
+	updatedNotes int
+	updatedFiles int
+	renamed      int
+	deleted      int
+	kept         int // notes whose file was deleted but were not removed
+	conflicts    int
+	skipped      int
+}
+
+func (r noteSyncResult) changed() bool {
+	return r.imported+r.exported+r.updatedNotes+r.updatedFiles+r.renamed+r.deleted+r.kept+r.skipped > 0
+}
+
+// noteFile is a markdown file found in the notes directory
+type noteFile struct {
+	path           string
+	fm             noteFrontMatter
+	hasFrontMatter bool
+	body           string
+	hash           string
+	modTime        time.Time
+}
+
+// Sync reconciles the markdown files in the notes directory with the notes table.
+//
+// Notes and files are paired by their last synced path, then by the id in the
+// file's front matter and finally by content hash, which lets renamed files keep
+// their note. When both sides changed since the last sync the conflict is
+// resolved by modification time or by prompting.
+func (h *NoteHandler) Sync(ctx context.Context, opts NoteSyncOptions) error {
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = ConflictMtime
+	case ConflictMtime, ConflictPrompt:
+	default:
+		return fmt.Errorf("invalid conflict strategy %q: must be %s or %s", opts.Conflict, ConflictMtime, ConflictPrompt)
+	}
+
+	dir, err := h.getNotesDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to get notes directory: %w", err)
+	}
+
+	result, err := h.syncOnce(ctx, dir, opts)
+	if err != nil {
+		return err
+	}
+	printSyncResult(dir, result)
+
+	if !opts.Watch {
+		return nil
+	}
+	return h.watchNotes(ctx, dir, opts)
+}
+
+func (h *NoteHandler) watchNotes(ctx context.Context, dir string, opts NoteSyncOptions) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	ui.Infoln("Watching %s for changes (Ctrl+C to stop)", dir)
+
+	timer := time.NewTimer(syncDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isNoteFileName(filepath.Base(event.Name)) {
+				timer.Reset(syncDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			ui.Warningln("Watcher error: %v", err)
+		case <-timer.C:
+			result, err := h.syncOnce(ctx, dir, opts)
+			if err != nil {
+				ui.Warningln("Sync failed: %v", err)
+				continue
+			}
+			if result.changed() {
+				printSyncResult(dir, result)
+			}
+		}
+	}
+}
+
+func (h *NoteHandler) syncOnce(ctx context.Context, dir string, opts NoteSyncOptions) (noteSyncResult, error) {
+	var result noteSyncResult
+
+	files, unreadable, err := scanNoteFiles(dir)
+	if err != nil {
+		return result, err
+	}
+	// a file that failed to parse still exists, so its note is left alone
+	present := func(path string) bool {
+		return files[path] != nil || unreadable[path]
+	}
+
+	notes, err := h.repos.Notes.List(ctx, repo.NoteListOptions{})
+	if err != nil {
+		return result, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	states, err := h.repos.Notes.ListSyncStates(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	notesByID := make(map[int64]*models.Note, len(notes))
+	for _, note := range notes {
+		notesByID[note.ID] = note
+	}
+	stateByNote := make(map[int64]*models.NoteSyncState, len(states))
+	stateByPath := make(map[string]*models.NoteSyncState, len(states))
+	for _, st := range states {
+		stateByNote[st.NoteID] = st
+		stateByPath[st.Path] = st
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	pairs := map[string]*models.Note{}
+	claimed := map[int64]bool{}
+	pair := func(path string, note *models.Note) {
+		pairs[path] = note
+		claimed[note.ID] = true
+	}
+	// a note can take over a new file only if its previous file is gone
+	available := func(note *models.Note) bool {
+		if note == nil || claimed[note.ID] {
+			return false
+		}
+		st := stateByNote[note.ID]
+		return st == nil || !present(st.Path)
+	}
+
+	for _, path := range paths {
+		if st := stateByPath[path]; st != nil && notesByID[st.NoteID] != nil {
+			pair(path, notesByID[st.NoteID])
+		}
+	}
+
+	for _, path := range paths {
+		if f := files[path]; pairs[path] == nil && f.fm.ID != 0 && available(notesByID[f.fm.ID]) {
+			pair(path, notesByID[f.fm.ID])
+		}
+	}
+
+	for _, path := range paths {
+		if pairs[path] != nil {
+			continue
+		}
+		bodyHash := repo.HashContent(files[path].body)
+		for _, note := range notes {
+			if available(note) && repo.HashContent(note.Content) == bodyHash {
+				pair(path, note)
+				break
+			}
+		}
+	}
+
+	taken := map[string]bool{}
+	for path := range files {
+		taken[path] = true
+	}
+	for path := range unreadable {
+		taken[path] = true
+	}
+
+	for _, path := range paths {
+		f := files[path]
+		note := pairs[path]
+		if note == nil {
+			if err := h.importNoteFile(ctx, f); err != nil {
+				return result, err
+			}
+			result.imported++
+			continue
+		}
+
+		if err := h.reconcileNote(ctx, note, f, stateByNote[note.ID], opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	var removed []*models.Note
+	for _, note := range notes {
+		if claimed[note.ID] {
+			continue
+		}
+
+		st := stateByNote[note.ID]
+		if st != nil && unreadable[st.Path] {
+			continue
+		}
+		if st != nil {
+			noteHash, err := canonicalNoteHash(note)
+			if err != nil {
+				return result, err
+			}
+			if noteHash == st.NoteHash {
+				removed = append(removed, note)
+				continue
+			}
+		}
+
+		path := uniqueNotePath(dir, note.Title, taken)
+		if st != nil && !taken[st.Path] {
+			path = st.Path
+		}
+		taken[path] = true
+
+		if err := h.writeNoteFile(ctx, note, path, noteFrontMatter{}); err != nil {
+			return result, err
+		}
+		result.exported++
+	}
+
+	if len(removed) == 0 {
+		return result, nil
+	}
+
+	// every synced file vanishing at once is far more likely an unmounted drive
+	// or a moved vault than a deliberate clean-up
+	allGone := len(removed) > 1 && len(removed) == len(states)
+	switch {
+	case allGone && opts.Watch:
+		ui.Warningln("All %d synced files are missing from %s; not removing any notes", len(removed), dir)
+		result.kept += len(removed)
+		return result, nil
+	case !opts.Delete:
+		result.kept += len(removed)
+		return result, nil
+	}
+
+	for _, note := range removed {
+		if err := h.repos.Notes.Delete(ctx, note.ID); err != nil {
+			return result, fmt.Errorf("failed to delete note %d: %w", note.ID, err)
+		}
+		result.deleted++
+	}
+	return result, nil
+}
+
+func (h *NoteHandler) reconcileNote(ctx context.Context, note *models.Note, f *noteFile, st *models.NoteSyncState, opts NoteSyncOptions, result *noteSyncResult) error {
+	noteHash, err := canonicalNoteHash(note)
+	if err != nil {
+		return err
+	}
+
+	renamed := st != nil && st.Path != f.path
+	fileChanged := st == nil || f.hash != st.FileHash
+	noteChanged := st == nil || noteHash != st.NoteHash
+
+	if st == nil {
+		if rendered, err := renderNoteFile(note, f.fm); err == nil && repo.HashContent(rendered) == f.hash {
+			fileChanged, noteChanged = false, false
+		}
+	}
+
+	if renamed {
+		result.renamed++
+	}
+
+	side := syncSkip
+	switch {
+	case fileChanged && noteChanged:
+		result.conflicts++
+		if side, err = h.resolveConflict(note, f, opts); err != nil {
+			return err
+		}
+		if side == syncSkip {
+			result.skipped++
+			return nil
+		}
+	case fileChanged:
+		side = syncUseFile
+	case noteChanged:
+		side = syncUseNote
+	}
+
+	switch side {
+	case syncUseFile:
+		applyNoteFile(note, f)
+		if err := h.repos.Notes.Update(ctx, note); err != nil {
+			return fmt.Errorf("failed to update note %d from %s: %w", note.ID, f.path, err)
+		}
+		result.updatedNotes++
+		return h.saveSyncState(ctx, note, f.path, f.hash)
+	case syncUseNote:
+		if err := h.writeNoteFile(ctx, note, f.path, f.fm); err != nil {
+			return err
+		}
+		result.updatedFiles++
+		return nil
+	}
+
+	if note.FilePath != f.path {
+		note.FilePath = f.path
+		if err := h.repos.Notes.Update(ctx, note); err != nil {
+			return fmt.Errorf("failed to update note %d: %w", note.ID, err)
+		}
+	}
+	if st == nil || renamed {
+		return h.saveSyncState(ctx, note, f.path, f.hash)
+	}
+	return nil
+}
+
+func (h *NoteHandler) resolveConflict(note *models.Note, f *noteFile, opts NoteSyncOptions) (syncSide, error) {
+	if opts.Conflict == ConflictPrompt {
+		prompt := h.promptConflictFunc
+		if prompt == nil {
+			prompt = promptSyncConflict
+		}
+		return prompt(note, f.path)
+	}
+
+	if f.modTime.After(note.Modified) {
+		return syncUseFile, nil
+	}
+	return syncUseNote, nil
+}
+
+func promptSyncConflict(note *models.Note, path string) (syncSide, error) {
+	ui.Warningln("Conflict: note %d (%s) and %s both changed since the last sync", note.ID, note.Title, path)
+	for {
+		fmt.Print("Keep [f]ile, [d]atabase or [s]kip? ")
+
+		var choice string
+		if _, err := fmt.Scanln(&choice); err != nil {
+			return syncSkip, fmt.Errorf("failed to read choice: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(choice)) {
+		case "f", "file":
+			return syncUseFile, nil
+		case "d", "db", "database":
+			return syncUseNote, nil
+		case "s", "skip":
+			return syncSkip, nil
+		}
+	}
+}
+
+func (h *NoteHandler) importNoteFile(ctx context.Context, f *noteFile) error {
+	note := &models.Note{}
+	applyNoteFile(note, f)
+
+	if _, err := h.repos.Notes.Create(ctx, note); err != nil {
+		return fmt.Errorf("failed to import %s: %w", f.path, err)
+	}
+	return h.saveSyncState(ctx, note, f.path, f.hash)
+}
+
+// applyNoteFile copies the contents and front matter of a file onto a note
+func applyNoteFile(note *models.Note, f *noteFile) {
+	headingTitle, content, tags := parseNoteContent(f.body)
+
+	title := f.fm.Title
+	if title == "" {
+		title = headingTitle
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(f.path), filepath.Ext(f.path))
+	}
+
+	note.Title = title
+	note.Content = content
+	note.FilePath = f.path
+	// the body decides, so a file decrypted by hand is not stored as encrypted
+	note.Encrypted = isCiphertext(content)
+
+	if !f.hasFrontMatter {
+		note.Tags = tags
+		return
+	}
+
+	note.Tags = []string(f.fm.Tags)
+	note.Archived = f.fm.Archived
+	// publishing state is only changed by keys the file sets, so a hand written header with
+	// just tags doesn't unlink a published note
+	if f.fm.has("leaflet_rkey") {
+		note.LeafletRKey = f.fm.LeafletRKey
+	}
+	if f.fm.has("leaflet_cid") {
+		note.LeafletCID = f.fm.LeafletCID
+	}
+	if f.fm.has("publication") {
+		note.LeafletPublication = f.fm.Publication
+	}
+	if f.fm.has("published_at") {
+		note.PublishedAt = f.fm.PublishedAt
+	}
+	if f.fm.has("draft") {
+		note.IsDraft = f.fm.IsDraft
+	}
+}
+
+func (h *NoteHandler) writeNoteFile(ctx context.Context, note *models.Note, path string, base noteFrontMatter) error {
+	if note.FilePath != path {
+		note.FilePath = path
+		if err := h.repos.Notes.Update(ctx, note); err != nil {
+			return fmt.Errorf("failed to update note %d: %w", note.ID, err)
+		}
+	}
+
+	rendered, err := renderNoteFile(note, base)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".noteleaf-sync-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(rendered); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return h.saveSyncState(ctx, note, path, repo.HashContent(rendered))
+}
+
+func (h *NoteHandler) saveSyncState(ctx context.Context, note *models.Note, path, fileHash string) error {
+	noteHash, err := canonicalNoteHash(note)
+	if err != nil {
+		return err
+	}
+	return h.repos.Notes.SaveSyncState(ctx, &models.NoteSyncState{
+		NoteID:   note.ID,
+		Path:     path,
+		FileHash: fileHash,
+		NoteHash: noteHash,
+	})
+}
+
+// canonicalNoteHash hashes a note rendered without any file-specific front matter
+// keys, so it only changes when the note itself does
+func canonicalNoteHash(note *models.Note) (string, error) {
+	rendered, err := renderNoteFile(note, noteFrontMatter{})
+	if err != nil {
+		return "", err
+	}
+	return repo.HashContent(rendered), nil
+}
+
+// scanNoteFiles reads the markdown files in dir. Files whose front matter does
+// not parse are returned separately so their notes are neither overwritten nor
+// deleted.
+func scanNoteFiles(dir string) (map[string]*noteFile, map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read notes directory: %w", err)
+	}
+
+	files := map[string]*noteFile{}
+	unreadable := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isNoteFileName(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		fm, body, err := splitFrontMatter(string(data))
+		if err != nil {
+			ui.Warningln("Skipping %s: %v", path, err)
+			unreadable[path] = true
+			continue
+		}
+
+		files[path] = &noteFile{
+			path:           path,
+			fm:             fm,
+			hasFrontMatter: body != string(data),
+			body:           body,
+			hash:           repo.HashContent(string(data)),
+			modTime:        info.ModTime(),
+		}
+	}
+	return files, unreadable, nil
+}
+
+func isNoteFileName(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".md") && !strings.HasPrefix(name, ".")
+}
+
+func uniqueNotePath(dir, title string, taken map[string]bool) string {
+	slug := slugify(title)
+	path := filepath.Join(dir, slug+".md")
+	for i := 2; taken[path] || fileExists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.md", slug, i))
+	}
+	return path
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// getNotesDirectory returns the notes directory, creating the default one under
+// the data directory. A configured notes_dir must already exist, since syncing
+// against an empty stand-in for an unmounted or renamed directory would look
+// like every file was deleted.
+func (h *NoteHandler) getNotesDirectory() (string, error) {
+	if dir := h.config.NotesDir; dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", fmt.Errorf("notes directory %s is not available: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("notes directory %s is not a directory", dir)
+		}
+		return dir, nil
+	}
+
+	dataDir, err := store.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(dataDir, "notes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create notes directory: %w", err)
+	}
+	return dir, nil
+}
+
+func printSyncResult(dir string, r noteSyncResult) {
+	if !r.changed() && r.conflicts == 0 {
+		ui.Infoln("Notes in %s are up to date", dir)
+		return
+	}
+
+	ui.Successln("Synced notes with %s", dir)
+	for _, line := range []struct {
+		label string
+		count int
+	}{
+		{"Imported from files", r.imported},
+		{"Exported to files", r.exported},
+		{"Notes updated", r.updatedNotes},
+		{"Files updated", r.updatedFiles},
+		{"Renames detected", r.renamed},
+		{"Notes removed (file deleted)", r.deleted},
+		{"Conflicts", r.conflicts},
+		{"Conflicts skipped", r.skipped},
+	} {
+		if line.count > 0 {
+			fmt.Printf("  %-30s %d\n", line.label+":", line.count)
+		}
+	}
+	if r.kept > 0 {
+		ui.Warningln("%d note(s) lost their file and were kept; run 'noteleaf note sync --delete' to remove them", r.kept)
+	}
+}
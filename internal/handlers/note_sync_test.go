@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+)
+
+func newSyncTestHandler(t *testing.T) (*NoteHandler, string) {
+	t.Helper()
+	_ = NewHandlerTestSuite(t)
+
+	handler, err := NewNoteHandler()
+	if err != nil {
+		t.Fatalf("Failed to create note handler: %v", err)
+	}
+	t.Cleanup(func() { handler.Close() })
+
+	dir := t.TempDir()
+	handler.config.NotesDir = dir
+	return handler, dir
+}
+
+func syncNotes(t *testing.T, handler *NoteHandler, opts NoteSyncOptions) {
+	t.Helper()
+	if err := handler.Sync(context.Background(), opts); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+}
+
+func listAllNotes(t *testing.T, handler *NoteHandler) []*models.Note {
+	t.Helper()
+	notes, err := handler.repos.Notes.List(context.Background(), repo.NoteListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list notes: %v", err)
+	}
+	return notes
+}
+
+func writeTestFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if !modTime.IsZero() {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	t.Run("parses known and extra keys", func(t *testing.T) {
+		content := "---\ntitle: Hello\ntags: [a, b]\narchived: true\naliases: [hi]\n---\n# Hello\n\nBody\n"
+		fm, body, err := splitFrontMatter(content)
+		if err != nil {
+			t.Fatalf("splitFrontMatter failed: %v", err)
+		}
+		if fm.Title != "Hello" || !fm.Archived {
+			t.Errorf("unexpected front matter: %+v", fm)
+		}
+		if strings.Join(fm.Tags, ",") != "a,b" {
+			t.Errorf("expected tags a,b, got %v", fm.Tags)
+		}
+		if _, ok := fm.Extra["aliases"]; !ok {
+			t.Error("expected unknown keys to be preserved")
+		}
+		if body != "# Hello\n\nBody\n" {
+			t.Errorf("unexpected body %q", body)
+		}
+	})
+
+	t.Run("accepts comma separated tags", func(t *testing.T) {
+		fm, _, err := splitFrontMatter("---\ntags: one, two\n---\nbody")
+		if err != nil {
+			t.Fatalf("splitFrontMatter failed: %v", err)
+		}
+		if strings.Join(fm.Tags, ",") != "one,two" {
+			t.Errorf("expected tags one,two, got %v", fm.Tags)
+		}
+	})
+
+	t.Run("returns content without front matter unchanged", func(t *testing.T) {
+		content := "# Title\n\n---\n\nafter a rule"
+		_, body, err := splitFrontMatter(content)
+		if err != nil || body != content {
+			t.Errorf("expected unchanged content, got %q (%v)", body, err)
+		}
+	})
+
+	t.Run("rejects invalid yaml", func(t *testing.T) {
+		if _, _, err := splitFrontMatter("---\ntags: [unclosed\n---\nbody"); err == nil {
+			t.Error("expected error for invalid front matter")
+		}
+	})
+
+	t.Run("round trips rendered notes", func(t *testing.T) {
+		rkey := "abc123"
+		note := &models.Note{ID: 7, Title: "Round Trip", Content: "# Round Trip\n\ntext", Tags: []string{"x"}, LeafletRKey: &rkey, Created: time.Now()}
+		rendered, err := renderNoteFile(note, noteFrontMatter{})
+		if err != nil {
+			t.Fatalf("renderNoteFile failed: %v", err)
+		}
+
+		fm, body, err := splitFrontMatter(rendered)
+		if err != nil {
+			t.Fatalf("splitFrontMatter failed: %v", err)
+		}
+		if fm.ID != 7 || fm.Title != "Round Trip" || fm.LeafletRKey == nil || *fm.LeafletRKey != rkey {
+			t.Errorf("unexpected front matter: %+v", fm)
+		}
+		if strings.TrimSuffix(body, "\n") != note.Content {
+			t.Errorf("unexpected body %q", body)
+		}
+	})
+}
+
+func TestNoteSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects unknown conflict strategy", func(t *testing.T) {
+		handler, _ := newSyncTestHandler(t)
+		if err := handler.Sync(ctx, NoteSyncOptions{Conflict: "coinflip"}); err == nil {
+			t.Error("expected error for invalid conflict strategy")
+		}
+	})
+
+	t.Run("exports notes and imports files", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "From DB", Content: "# From DB\n\ndatabase body", Tags: []string{"db"}})
+		if err != nil {
+			t.Fatalf("Failed to create note: %v", err)
+		}
+		writeTestFile(t, filepath.Join(dir, "from-disk.md"), "---\ntags: [disk]\n---\n# From Disk\n\nfile body\n", time.Time{})
+
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		exported, err := os.ReadFile(filepath.Join(dir, "from-db.md"))
+		if err != nil {
+			t.Fatalf("Expected note to be exported: %v", err)
+		}
+		if !strings.Contains(string(exported), "tags:\n  - db") || !strings.Contains(string(exported), "database body") {
+			t.Errorf("unexpected exported file:\n%s", exported)
+		}
+
+		note, err := handler.repos.Notes.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.FilePath != filepath.Join(dir, "from-db.md") {
+			t.Errorf("expected file path to be recorded, got %q", note.FilePath)
+		}
+
+		notes := listAllNotes(t, handler)
+		if len(notes) != 2 {
+			t.Fatalf("expected 2 notes, got %d", len(notes))
+		}
+		var imported *models.Note
+		for _, n := range notes {
+			if n.ID != id {
+				imported = n
+			}
+		}
+		if imported.Title != "From Disk" || len(imported.Tags) != 1 || imported.Tags[0] != "disk" {
+			t.Errorf("unexpected imported note: %+v", imported)
+		}
+
+		before := listAllNotes(t, handler)
+		syncNotes(t, handler, NoteSyncOptions{})
+		after := listAllNotes(t, handler)
+		for i := range after {
+			if !after[i].Modified.Equal(before[i].Modified) {
+				t.Errorf("second sync should not modify note %d", after[i].ID)
+			}
+		}
+	})
+
+	t.Run("propagates edits in both directions", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		path := filepath.Join(dir, "journal.md")
+		writeTestFile(t, path, "# Journal\n\nday one\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		note := listAllNotes(t, handler)[0]
+
+		writeTestFile(t, path, "---\narchived: true\n---\n# Journal\n\nday two\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		note, _ = handler.repos.Notes.Get(ctx, note.ID)
+		if !strings.Contains(note.Content, "day two") || !note.Archived {
+			t.Errorf("expected file edit to reach the database, got %+v", note)
+		}
+
+		note.Content = "# Journal\n\nday three\n"
+		if err := handler.repos.Notes.Update(ctx, note); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), "day three") {
+			t.Errorf("expected database edit to reach the file, got:\n%s", data)
+		}
+	})
+
+	t.Run("keeps publishing state the file does not mention", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		rkey, cid, publication := "3kessay", "bafyessay", "blog"
+		published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{
+			Title: "Essay", Content: "# Essay\n\nfirst\n",
+			LeafletRKey: &rkey, LeafletCID: &cid, LeafletPublication: &publication, PublishedAt: &published,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create note: %v", err)
+		}
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		writeTestFile(t, filepath.Join(dir, "essay.md"), "---\ntags: [essay]\n---\n# Essay\n\nrevised\n", time.Now().Add(time.Minute))
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		note, err := handler.repos.Notes.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if !strings.Contains(note.Content, "revised") || len(note.Tags) != 1 || note.Tags[0] != "essay" {
+			t.Errorf("expected the file edit to reach the database, got %+v", note)
+		}
+		if note.LeafletRKey == nil || *note.LeafletRKey != rkey || note.LeafletCID == nil || *note.LeafletCID != cid {
+			t.Errorf("expected the leaflet record to be kept, got rkey %v cid %v", note.LeafletRKey, note.LeafletCID)
+		}
+		if note.LeafletPublication == nil || *note.LeafletPublication != publication {
+			t.Errorf("expected the publication to be kept, got %v", note.LeafletPublication)
+		}
+		if note.PublishedAt == nil || !note.PublishedAt.Equal(published) || note.IsDraft {
+			t.Errorf("expected the note to stay published at %v, got %v (draft %v)", published, note.PublishedAt, note.IsDraft)
+		}
+	})
+
+	t.Run("keeps unknown front matter keys", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		path := filepath.Join(dir, "aliased.md")
+		writeTestFile(t, path, "---\naliases: [other-name]\n---\n# Aliased\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		note := listAllNotes(t, handler)[0]
+		note.Content = "# Aliased\n\nmore\n"
+		if err := handler.repos.Notes.Update(ctx, note); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), "other-name") {
+			t.Errorf("expected aliases to survive, got:\n%s", data)
+		}
+	})
+
+	t.Run("detects renames by content hash", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		oldPath := filepath.Join(dir, "draft.md")
+		writeTestFile(t, oldPath, "# Draft\n\nsome words\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+		id := listAllNotes(t, handler)[0].ID
+
+		newPath := filepath.Join(dir, "final.md")
+		if err := os.Rename(oldPath, newPath); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		notes := listAllNotes(t, handler)
+		if len(notes) != 1 || notes[0].ID != id {
+			t.Fatalf("expected rename to keep note %d, got %d notes", id, len(notes))
+		}
+		if notes[0].FilePath != newPath {
+			t.Errorf("expected file path %s, got %s", newPath, notes[0].FilePath)
+		}
+		if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+			t.Error("old path should not be recreated")
+		}
+	})
+
+	t.Run("removes notes whose file was deleted with delete", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		path := filepath.Join(dir, "gone.md")
+		writeTestFile(t, path, "# Gone\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		os.Remove(path)
+		syncNotes(t, handler, NoteSyncOptions{})
+		if notes := listAllNotes(t, handler); len(notes) != 1 {
+			t.Fatalf("expected note to be kept without --delete, got %d notes", len(notes))
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("kept note should not be written back")
+		}
+
+		syncNotes(t, handler, NoteSyncOptions{Delete: true})
+		if notes := listAllNotes(t, handler); len(notes) != 0 {
+			t.Errorf("expected note to be removed, got %d notes", len(notes))
+		}
+	})
+
+	t.Run("keeps notes whose front matter does not parse", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		path := filepath.Join(dir, "broken.md")
+		writeTestFile(t, path, "---\ntags: [a]\n---\n# Broken\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+		id := listAllNotes(t, handler)[0].ID
+
+		broken := "---\ntags: [a\n---\n# Broken\n"
+		writeTestFile(t, path, broken, time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{Delete: true})
+
+		notes := listAllNotes(t, handler)
+		if len(notes) != 1 || notes[0].ID != id {
+			t.Fatalf("expected note %d to survive, got %d notes", id, len(notes))
+		}
+		if revs, err := handler.repos.Notes.ListRevisions(ctx, id); err != nil || len(revs) == 0 {
+			t.Errorf("expected revision history to survive, got %d (%v)", len(revs), err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != broken {
+			t.Errorf("unparseable file should be left alone, got:\n%s", data)
+		}
+	})
+
+	t.Run("fails when the configured notes directory is missing", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		writeTestFile(t, filepath.Join(dir, "one.md"), "# One\n", time.Time{})
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatalf("Failed to remove notes directory: %v", err)
+		}
+		if err := handler.Sync(ctx, NoteSyncOptions{Delete: true}); err == nil {
+			t.Error("expected sync to fail for a missing notes directory")
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Error("missing notes directory should not be recreated")
+		}
+		if notes := listAllNotes(t, handler); len(notes) != 1 {
+			t.Errorf("expected note to survive, got %d notes", len(notes))
+		}
+	})
+
+	t.Run("watch never removes notes when every file is gone", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+		for _, name := range []string{"one", "two"} {
+			writeTestFile(t, filepath.Join(dir, name+".md"), "# "+name+"\n", time.Time{})
+		}
+		syncNotes(t, handler, NoteSyncOptions{})
+
+		for _, name := range []string{"one", "two"} {
+			os.Remove(filepath.Join(dir, name+".md"))
+		}
+		result, err := handler.syncOnce(ctx, dir, NoteSyncOptions{Watch: true, Delete: true, Conflict: ConflictMtime})
+		if err != nil {
+			t.Fatalf("syncOnce failed: %v", err)
+		}
+		if result.deleted != 0 || result.kept != 2 {
+			t.Errorf("expected both notes to be kept, got %+v", result)
+		}
+		if notes := listAllNotes(t, handler); len(notes) != 2 {
+			t.Errorf("expected 2 notes, got %d", len(notes))
+		}
+	})
+
+	t.Run("resolves conflicts", func(t *testing.T) {
+		setup := func(t *testing.T) (*NoteHandler, string, *models.Note) {
+			handler, dir := newSyncTestHandler(t)
+			path := filepath.Join(dir, "conflict.md")
+			writeTestFile(t, path, "# Conflict\n\noriginal\n", time.Time{})
+			syncNotes(t, handler, NoteSyncOptions{})
+
+			note := listAllNotes(t, handler)[0]
+			note.Content = "# Conflict\n\ndatabase version\n"
+			if err := handler.repos.Notes.Update(ctx, note); err != nil {
+				t.Fatalf("Failed to update note: %v", err)
+			}
+			return handler, path, note
+		}
+
+		t.Run("newer file wins by mtime", func(t *testing.T) {
+			handler, path, note := setup(t)
+			writeTestFile(t, path, "# Conflict\n\nfile version\n", time.Now().Add(time.Hour))
+			syncNotes(t, handler, NoteSyncOptions{Conflict: ConflictMtime})
+
+			note, _ = handler.repos.Notes.Get(ctx, note.ID)
+			if !strings.Contains(note.Content, "file version") {
+				t.Errorf("expected file to win, got %q", note.Content)
+			}
+		})
+
+		t.Run("newer note wins by mtime", func(t *testing.T) {
+			handler, path, _ := setup(t)
+			writeTestFile(t, path, "# Conflict\n\nfile version\n", time.Now().Add(-time.Hour))
+			syncNotes(t, handler, NoteSyncOptions{Conflict: ConflictMtime})
+
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(string(data), "database version") {
+				t.Errorf("expected database to win, got:\n%s", data)
+			}
+		})
+
+		t.Run("prompt chooses side", func(t *testing.T) {
+			handler, path, note := setup(t)
+			writeTestFile(t, path, "# Conflict\n\nfile version\n", time.Now().Add(time.Hour))
+
+			prompted := false
+			handler.promptConflictFunc = func(n *models.Note, p string) (syncSide, error) {
+				prompted = n.ID == note.ID && p == path
+				return syncUseNote, nil
+			}
+			syncNotes(t, handler, NoteSyncOptions{Conflict: ConflictPrompt})
+
+			if !prompted {
+				t.Error("expected conflict prompt")
+			}
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(string(data), "database version") {
+				t.Errorf("expected prompt choice to win, got:\n%s", data)
+			}
+		})
+
+		t.Run("prompt can skip", func(t *testing.T) {
+			handler, path, note := setup(t)
+			writeTestFile(t, path, "# Conflict\n\nfile version\n", time.Time{})
+			handler.promptConflictFunc = func(*models.Note, string) (syncSide, error) { return syncSkip, nil }
+			syncNotes(t, handler, NoteSyncOptions{Conflict: ConflictPrompt})
+
+			note, _ = handler.repos.Notes.Get(ctx, note.ID)
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(note.Content, "database version") || !strings.Contains(string(data), "file version") {
+				t.Error("expected both sides to be left untouched")
+			}
+		})
+	})
+
+	t.Run("watch picks up external edits", func(t *testing.T) {
+		handler, dir := newSyncTestHandler(t)
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- handler.Sync(watchCtx, NoteSyncOptions{Watch: true}) }()
+
+		time.Sleep(100 * time.Millisecond)
+		writeTestFile(t, filepath.Join(dir, "watched.md"), "# Watched\n", time.Time{})
+
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if notes := listAllNotes(t, handler); len(notes) == 1 && notes[0].Title == "Watched" {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("watch returned error: %v", err)
+		}
+		if notes := listAllNotes(t, handler); len(notes) != 1 {
+			t.Errorf("expected watched file to be imported, got %d notes", len(notes))
+		}
+	})
+}
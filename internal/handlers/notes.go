@@ -10,6 +10,7 @@ import (
 
 	"github.com/stormlightlabs/noteleaf/internal/models"
 	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
 	"github.com/stormlightlabs/noteleaf/internal/store"
 	"github.com/stormlightlabs/noteleaf/internal/ui"
 	"github.com/stormlightlabs/noteleaf/internal/utils"
@@ -19,10 +20,14 @@ type editorFunc func(editor, filePath string) error
 
 // NoteHandler handles all note-related commands
 type NoteHandler struct {
-	db               *store.Database
-	config           *store.Config
-	repos            *repo.Repositories
-	openInEditorFunc editorFunc
+	db                   *store.Database
+	config               *store.Config
+	repos                *repo.Repositories
+	openInEditorFunc     editorFunc
+	promptConflictFunc   conflictPromptFunc
+	promptPassphraseFunc shared.PassphraseFunc
+	passphraseCache      string
+	encryptNew           bool
 }
 
 // NewNoteHandler creates a new note handler
@@ -38,6 +43,7 @@ func NewNoteHandler() (*NoteHandler, error) {
 	}
 
 	repos := repo.NewRepositories(db.DB)
+	repos.Notes.SetRevisionRetention(revisionRetention(config))
 
 	return &NoteHandler{
 		db:     db,
@@ -124,7 +130,7 @@ Enter your note content here...
 		Tags:    tags,
 	}
 
-	id, err := h.repos.Notes.Create(ctx, note)
+	id, err := h.createNote(ctx, note)
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
@@ -164,7 +170,7 @@ func (h *NoteHandler) createFromFile(ctx context.Context, filePath string) error
 		FilePath: filePath,
 	}
 
-	id, err := h.repos.Notes.Create(ctx, note)
+	id, err := h.createNote(ctx, note)
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
@@ -184,7 +190,7 @@ func (h *NoteHandler) createFromArgsWithOptions(ctx context.Context, title, cont
 		Content: content,
 	}
 
-	id, err := h.repos.Notes.Create(ctx, note)
+	id, err := h.createNote(ctx, note)
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
@@ -213,11 +219,24 @@ func (h *NoteHandler) Edit(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
-	tempFile, err := os.CreateTemp("", fmt.Sprintf("noteleaf-note-%d-*.md", id))
+	encrypted := note.Encrypted
+	tempDir := ""
+	if encrypted {
+		if note, err = h.unlockNote(note); err != nil {
+			return err
+		}
+		tempDir = secureTempDir()
+	}
+
+	tempFile, err := os.CreateTemp(tempDir, fmt.Sprintf("noteleaf-note-%d-*.md", id))
 	if err != nil {
 		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
-	defer os.Remove(tempFile.Name())
+	if encrypted {
+		defer removeSecureFile(tempFile.Name())
+	} else {
+		defer os.Remove(tempFile.Name())
+	}
 
 	fullContent := h.formatNoteForEdit(note)
 	if _, err := tempFile.WriteString(fullContent); err != nil {
@@ -249,6 +268,12 @@ func (h *NoteHandler) Edit(ctx context.Context, id int64) error {
 	note.Content = content
 	note.Tags = tags
 
+	if encrypted {
+		if note.Content, err = h.encryptContent(content); err != nil {
+			return err
+		}
+	}
+
 	if err := h.repos.Notes.Update(ctx, note); err != nil {
 		return fmt.Errorf("failed to update note: %w", err)
 	}
@@ -327,6 +352,10 @@ func (h *NoteHandler) View(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
+	if note, err = h.unlockNote(note); err != nil {
+		return err
+	}
+
 	content := h.formatNoteForView(note)
 	if rendered, err := renderMarkdown(content); err != nil {
 		return err
@@ -366,6 +395,156 @@ func (h *NoteHandler) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// History lists the stored revisions of a note, oldest first
+func (h *NoteHandler) History(ctx context.Context, id int64) error {
+	note, err := h.repos.Notes.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+
+	revisions, err := h.repos.Notes.ListRevisions(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	if len(revisions) == 0 {
+		ui.Infoln("No revisions recorded for note %d", id)
+		return nil
+	}
+
+	ui.Headerln("History for note %d: %s", note.ID, note.Title)
+	currentHash := repo.HashContent(note.Content)
+	for _, rev := range revisions {
+		marker := " "
+		if rev.Hash == currentHash && rev == revisions[len(revisions)-1] {
+			marker = "*"
+		}
+		fmt.Printf("%s %4d  %s  %8s  %s  %s\n", marker, rev.Revision, rev.Created.Format("2006-01-02 15:04"),
+			formatRevisionSize(rev.Size), rev.Hash[:8], rev.Title)
+	}
+	return nil
+}
+
+// Diff shows a coloured unified diff between two revisions of a note.
+//
+// With no revisions given the latest revision is compared against the one before it,
+// and with only from given that revision is compared against the current content.
+func (h *NoteHandler) Diff(ctx context.Context, id int64, from, to int) error {
+	note, err := h.repos.Notes.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+
+	var fromName, toName, fromContent, toContent string
+	switch {
+	case from == 0 && to == 0:
+		revisions, err := h.repos.Notes.ListRevisions(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list revisions: %w", err)
+		}
+		if len(revisions) < 2 {
+			ui.Infoln("Note %d has no earlier revision to compare against", id)
+			return nil
+		}
+		prev, latest := revisions[len(revisions)-2], revisions[len(revisions)-1]
+		fromName, fromContent = fmt.Sprintf("revision %d", prev.Revision), prev.Content
+		toName, toContent = fmt.Sprintf("revision %d", latest.Revision), latest.Content
+	case to == 0:
+		rev, err := h.repos.Notes.GetRevision(ctx, id, from)
+		if err != nil {
+			return err
+		}
+		fromName, fromContent = fmt.Sprintf("revision %d", rev.Revision), rev.Content
+		toName, toContent = "current", note.Content
+	default:
+		fromRev, err := h.repos.Notes.GetRevision(ctx, id, from)
+		if err != nil {
+			return err
+		}
+		toRev, err := h.repos.Notes.GetRevision(ctx, id, to)
+		if err != nil {
+			return err
+		}
+		fromName, fromContent = fmt.Sprintf("revision %d", fromRev.Revision), fromRev.Content
+		toName, toContent = fmt.Sprintf("revision %d", toRev.Revision), toRev.Content
+	}
+
+	diff := utils.UnifiedDiff(fromName, toName, fromContent, toContent, 3)
+	if diff == "" {
+		ui.Infoln("No differences between %s and %s", fromName, toName)
+		return nil
+	}
+
+	fmt.Print(colorizeDiff(diff))
+	return nil
+}
+
+// Restore replaces a note's content with that of an earlier revision.
+//
+// The restore is itself recorded as a new revision so it can be undone.
+func (h *NoteHandler) Restore(ctx context.Context, id int64, revision int) error {
+	note, err := h.repos.Notes.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+
+	rev, err := h.repos.Notes.GetRevision(ctx, id, revision)
+	if err != nil {
+		return err
+	}
+
+	if rev.Hash == repo.HashContent(note.Content) {
+		ui.Infoln("Note %d already matches revision %d", id, revision)
+		return nil
+	}
+
+	note.Title = rev.Title
+	note.Content = rev.Content
+	note.Encrypted = isCiphertext(rev.Content)
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	ui.Successln("Restored note %d to revision %d", id, revision)
+	return nil
+}
+
+func colorizeDiff(diff string) string {
+	var out strings.Builder
+	for line := range strings.Lines(diff) {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+			out.WriteString(ui.MutedStyle.Render(text))
+		case strings.HasPrefix(text, "@@"):
+			out.WriteString(ui.AccentStyle.Render(text))
+		case strings.HasPrefix(text, "+"):
+			out.WriteString(ui.AdditionStyle.Render(text))
+		case strings.HasPrefix(text, "-"):
+			out.WriteString(ui.DeletionStyle.Render(text))
+		default:
+			out.WriteString(text)
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func formatRevisionSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
+
+// revisionRetention builds the note history retention policy from configuration
+func revisionRetention(config *store.Config) repo.RevisionRetention {
+	return repo.RevisionRetention{
+		KeepLast:      config.NoteHistoryKeepLast,
+		KeepDailyDays: config.NoteHistoryKeepDailyDays,
+	}
+}
+
 func (h *NoteHandler) formatNoteForView(note *models.Note) string {
 	var content strings.Builder
 
@@ -918,4 +918,93 @@ This is content from the interactive editor.
 			}
 		})
 	})
+
+	t.Run("History", func(t *testing.T) {
+		ctx := context.Background()
+
+		handler, err := NewNoteHandler()
+		if err != nil {
+			t.Fatalf("Failed to create test handler: %v", err)
+		}
+		defer handler.Close()
+
+		note := &models.Note{Title: "Versioned", Content: "# Versioned\n\nfirst draft"}
+		id, err := handler.repos.Notes.Create(ctx, note)
+		if err != nil {
+			t.Fatalf("Failed to create note: %v", err)
+		}
+		note.Content = "# Versioned\n\nsecond draft"
+		if err := handler.repos.Notes.Update(ctx, note); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+
+		t.Run("lists revisions", func(t *testing.T) {
+			if err := handler.History(ctx, id); err != nil {
+				t.Errorf("History should succeed: %v", err)
+			}
+		})
+
+		t.Run("handles non-existent note", func(t *testing.T) {
+			if err := handler.History(ctx, 99999); err == nil {
+				t.Error("History should fail with non-existent note ID")
+			}
+		})
+
+		t.Run("diffs latest revisions", func(t *testing.T) {
+			if err := handler.Diff(ctx, id, 0, 0); err != nil {
+				t.Errorf("Diff should succeed: %v", err)
+			}
+		})
+
+		t.Run("diffs revision against current", func(t *testing.T) {
+			if err := handler.Diff(ctx, id, 1, 0); err != nil {
+				t.Errorf("Diff should succeed: %v", err)
+			}
+		})
+
+		t.Run("diff fails for unknown revision", func(t *testing.T) {
+			err := handler.Diff(ctx, id, 1, 42)
+			if err == nil || !strings.Contains(err.Error(), "revision 42") {
+				t.Errorf("Expected revision not found error, got: %v", err)
+			}
+		})
+
+		t.Run("restores earlier revision", func(t *testing.T) {
+			if err := handler.Restore(ctx, id, 1); err != nil {
+				t.Fatalf("Restore should succeed: %v", err)
+			}
+
+			restored, err := handler.repos.Notes.Get(ctx, id)
+			if err != nil {
+				t.Fatalf("Failed to get note: %v", err)
+			}
+			if !strings.Contains(restored.Content, "first draft") {
+				t.Errorf("Expected restored content, got %q", restored.Content)
+			}
+
+			revisions, err := handler.repos.Notes.ListRevisions(ctx, id)
+			if err != nil {
+				t.Fatalf("Failed to list revisions: %v", err)
+			}
+			if len(revisions) != 3 {
+				t.Errorf("Expected restore to add a revision, got %d revisions", len(revisions))
+			}
+		})
+
+		t.Run("restore is a no-op when content matches", func(t *testing.T) {
+			if err := handler.Restore(ctx, id, 3); err != nil {
+				t.Errorf("Restore should succeed: %v", err)
+			}
+		})
+	})
+
+	t.Run("colorizeDiff", func(t *testing.T) {
+		diff := "--- a\n+++ b\n@@ -1 +1 @@\n-old\n+new\n"
+		result := colorizeDiff(diff)
+		for _, want := range []string{"old", "new", "@@ -1 +1 @@"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("Expected colorized diff to contain %q", want)
+			}
+		}
+	})
 }
@@ -4,6 +4,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -42,23 +43,28 @@ func NewPublicationHandler() (*PublicationHandler, error) {
 	}
 
 	repos := repo.NewRepositories(db.DB)
+	repos.Notes.SetRevisionRetention(revisionRetention(config))
 	atproto := services.NewATProtoService()
 
 	d, _ := store.GetConfigDir()
 	debug := shared.NewDebugLoggerWithFile(d)
 
+	// refreshed tokens are saved right away: OAuth refresh tokens are single use
+	atproto.OnSessionUpdate(func(session *services.Session) {
+		sessionToConfig(config, session)
+		_ = store.SaveConfig(config)
+	})
+
+	if config.ATProtoDID != "" {
+		if err := store.LoadSecrets(config); err != nil {
+			ui.Warningln("Saved credentials are unavailable: %v", err)
+		}
+	}
+
 	if config.ATProtoDID != "" && config.ATProtoAccessJWT != "" && config.ATProtoRefreshJWT != "" {
 		session, err := sessionFromConfig(config)
 		if err == nil {
-			if err := atproto.RestoreSession(session); err == nil {
-				updatedSession, _ := atproto.GetSession()
-				if updatedSession != nil {
-					config.ATProtoAccessJWT = updatedSession.AccessJWT
-					config.ATProtoRefreshJWT = updatedSession.RefreshJWT
-					config.ATProtoExpiresAt = updatedSession.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
-					_ = store.SaveConfig(config)
-				}
-			}
+			_ = atproto.RestoreSession(session)
 		}
 	}
 
@@ -100,18 +106,40 @@ func (h *PublicationHandler) Auth(ctx context.Context, handle, password string)
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	return h.saveSession()
+}
+
+// AuthOAuth signs in through the browser using AT Protocol OAuth with DPoP bound tokens
+func (h *PublicationHandler) AuthOAuth(ctx context.Context, handle string) error {
+	if handle == "" {
+		return fmt.Errorf("handle is required")
+	}
+
+	ui.Infoln("Authenticating as %s with OAuth...", handle)
+
+	openURL := func(authURL string) error {
+		ui.Infoln("Opening your browser to authorize noteleaf. If it does not open, visit:")
+		ui.Plainln("  %s", authURL)
+		if err := services.OpenBrowser(authURL); err != nil {
+			ui.Warningln("%v", err)
+		}
+		return nil
+	}
+
+	if err := h.atproto.AuthenticateOAuth(ctx, handle, openURL); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	return h.saveSession()
+}
+
+// saveSession stores the authenticated session in the configuration
+func (h *PublicationHandler) saveSession() error {
 	session, err := h.atproto.GetSession()
 	if err != nil {
 		return fmt.Errorf("failed to get session after authentication: %w", err)
 	}
 
-	h.config.ATProtoDID = session.DID
-	h.config.ATProtoHandle = session.Handle
-	h.config.ATProtoAccessJWT = session.AccessJWT
-	h.config.ATProtoRefreshJWT = session.RefreshJWT
-	h.config.ATProtoPDSURL = session.PDSURL
-	h.config.ATProtoExpiresAt = session.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
-
+	sessionToConfig(h.config, session)
 	if err := store.SaveConfig(h.config); err != nil {
 		return fmt.Errorf("authentication successful but failed to save credentials: %w", err)
 	}
@@ -121,7 +149,8 @@ func (h *PublicationHandler) Auth(ctx context.Context, handle, password string)
 	return nil
 }
 
-// documentToMarkdown converts a leaflet Document to markdown content
+// documentToMarkdown converts a leaflet Document to markdown content, leaving out
+// the "Referenced by" section added when publishing with leaflet_backlinks
 func documentToMarkdown(doc services.DocumentWithMeta) (string, error) {
 	converter := public.NewMarkdownConverter()
 	var allBlocks []public.BlockWrap
@@ -135,10 +164,15 @@ func documentToMarkdown(doc services.DocumentWithMeta) (string, error) {
 		return "", fmt.Errorf("failed to convert document to markdown: %w", err)
 	}
 
-	return content, nil
+	return stripBacklinks(content), nil
 }
 
-// Pull fetches all documents from leaflet and creates/updates local notes
+// Pull fetches all documents from leaflet and merges them into local notes.
+//
+// Notes are matched to documents by rkey. Documents that changed only on leaflet
+// update their note in place, new documents become new notes, and documents that
+// changed on both sides are held as conflicts for [PublicationHandler.Resolve].
+// Notes whose document no longer exists are flagged rather than deleted.
 func (h *PublicationHandler) Pull(ctx context.Context) error {
 	if !h.atproto.IsAuthenticated() {
 		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
@@ -156,58 +190,54 @@ func (h *PublicationHandler) Pull(ctx context.Context) error {
 		return fmt.Errorf("failed to fetch documents: %w", err)
 	}
 
-	if len(docs) == 0 {
+	local, err := h.repos.Notes.GetLeafletNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch leaflet notes: %w", err)
+	}
+
+	if len(docs) == 0 && len(local) == 0 {
 		ui.Infoln("No documents found in leaflet.")
 		return nil
 	}
 
 	ui.Infoln("Found %d document(s). Syncing...\n", len(docs))
 
-	ui.Infoln("Removing existing publications...")
-	if err := h.repos.Notes.DeleteAllLeafletNotes(ctx); err != nil {
-		return fmt.Errorf("failed to delete existing publications: %w", err)
+	byRKey := make(map[string]*models.Note, len(local))
+	for _, note := range local {
+		byRKey[*note.LeafletRKey] = note
 	}
 
-	var created, failed int
-
+	var summary pullSummary
+	remote := make(map[string]bool, len(docs))
 	for _, doc := range docs {
+		remote[doc.Meta.RKey] = true
+
 		content, err := documentToMarkdown(doc)
 		if err != nil {
 			ui.Warningln("Skipping document %s: %v", doc.Document.Title, err)
-			failed++
+			summary.failed++
 			continue
 		}
 
-		note := &models.Note{
-			Title:       doc.Document.Title,
-			Content:     content,
-			LeafletRKey: &doc.Meta.RKey,
-			LeafletCID:  &doc.Meta.CID,
-			IsDraft:     doc.Meta.IsDraft,
-		}
-
-		if doc.Document.PublishedAt != "" {
-			publishedAt, err := time.Parse(time.RFC3339, doc.Document.PublishedAt)
-			if err == nil {
-				note.PublishedAt = &publishedAt
-			}
+		if err := h.pullDocument(ctx, doc, content, byRKey[doc.Meta.RKey], &summary); err != nil {
+			ui.Warningln("Failed to sync document %s: %v", doc.Document.Title, err)
+			summary.failed++
 		}
+	}
 
-		_, err = h.repos.Notes.Create(ctx, note)
-		if err != nil {
-			ui.Warningln("Failed to create note for document %s: %v", doc.Document.Title, err)
-			failed++
+	for _, note := range local {
+		if remote[*note.LeafletRKey] {
 			continue
 		}
-
-		created++
-		ui.Infoln("  Created: %s", doc.Document.Title)
+		if err := h.markRemoteDeleted(ctx, note, &summary); err != nil {
+			ui.Warningln("Failed to flag deleted document %s: %v", note.Title, err)
+			summary.failed++
+		}
 	}
 
-	if failed > 0 {
-		ui.Successln("Sync complete: %d created, %d failed", created, failed)
-	} else {
-		ui.Successln("Sync complete: %d created", created)
+	ui.Successln("Sync complete: %s", summary)
+	if summary.conflicts > 0 || summary.deleted > 0 {
+		ui.Infoln("Run 'noteleaf pub status' to review and 'noteleaf pub resolve <id>' to settle them")
 	}
 	return nil
 }
@@ -270,32 +300,62 @@ func (h *PublicationHandler) List(ctx context.Context, filter string) error {
 	ui.Infoln("Found %d %s document(s):", len(notes), filter)
 	ui.Newline()
 
-	for _, note := range notes {
-		printPublication(note)
+	order, groups := groupByPublication(notes)
+	if len(order) == 1 && order[0] == "" {
+		for _, note := range notes {
+			printPublication(note)
+		}
+		return nil
+	}
+
+	names := h.publicationNames(ctx)
+	for _, uri := range order {
+		heading := "No publication recorded"
+		if uri != "" {
+			heading = uri
+			if name, ok := names[uri]; ok {
+				heading = name
+			}
+		}
+		ui.Titleln("%s (%d)", heading, len(groups[uri]))
+		for _, note := range groups[uri] {
+			printPublication(note)
+		}
 	}
 
 	return nil
 }
 
 // Post creates a new document on leaflet from a local note
-func (h *PublicationHandler) Post(ctx context.Context, noteID int64, isDraft bool) error {
+func (h *PublicationHandler) Post(ctx context.Context, noteID int64, isDraft bool, publication string) error {
 	if !h.atproto.IsAuthenticated() {
 		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, false)
+	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, false, publication)
+	if services.IsTransient(err) {
+		if note, getErr := h.repos.Notes.Get(ctx, noteID); getErr == nil && !note.HasLeafletAssociation() {
+			op := &models.LeafletOutboxEntry{NoteID: noteID, Operation: models.OutboxCreate, IsDraft: isDraft, Publication: publication}
+			return h.enqueue(ctx, op, note, nil, err)
+		}
+	}
 	if err != nil {
 		return err
 	}
 	ui.Infoln("Creating document '%s' on leaflet...", note.Title)
 
 	result, err := h.atproto.PostDocument(ctx, *doc, isDraft)
+	if services.IsTransient(err) {
+		op := &models.LeafletOutboxEntry{NoteID: noteID, Operation: models.OutboxCreate, IsDraft: isDraft, Publication: publication}
+		return h.enqueue(ctx, op, note, doc, err)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to post document: %w", err)
+		return fmt.Errorf("failed to post document: %w", h.staleBlobError(ctx, doc.Author, err))
 	}
 
 	note.LeafletRKey = &result.Meta.RKey
 	note.LeafletCID = &result.Meta.CID
+	note.LeafletPublication = &doc.Publication
 	note.IsDraft = isDraft
 
 	if !isDraft && doc.PublishedAt != "" {
@@ -308,6 +368,12 @@ func (h *PublicationHandler) Post(ctx context.Context, noteID int64, isDraft boo
 	if err := h.repos.Notes.Update(ctx, note); err != nil {
 		return fmt.Errorf("document created but failed to update local note: %w", err)
 	}
+	if err := h.saveLeafletBase(ctx, note); err != nil {
+		return fmt.Errorf("document created but failed to record sync state: %w", err)
+	}
+	if err := h.repos.Notes.DeleteLeafletOutboxEntry(ctx, noteID); err != nil {
+		return fmt.Errorf("document created but failed to clear queued create: %w", err)
+	}
 
 	if isDraft {
 		ui.Successln("Draft created successfully!")
@@ -331,7 +397,18 @@ func (h *PublicationHandler) Patch(ctx context.Context, noteID int64) error {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, tempNote.IsDraft, true)
+	if err := h.checkLeafletSync(ctx, noteID); err != nil {
+		return err
+	}
+	if err := h.checkQueued(ctx, noteID, true); err != nil {
+		return err
+	}
+
+	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, tempNote.IsDraft, true, "")
+	if services.IsTransient(err) && tempNote.HasLeafletAssociation() {
+		op := &models.LeafletOutboxEntry{NoteID: noteID, Operation: models.OutboxUpdate, RKey: *tempNote.LeafletRKey, IsDraft: tempNote.IsDraft}
+		return h.enqueue(ctx, op, tempNote, nil, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -346,15 +423,26 @@ func (h *PublicationHandler) Patch(ctx context.Context, noteID int64) error {
 	ui.Infoln("Updating document '%s' on leaflet...", note.Title)
 
 	result, err := h.atproto.PatchDocument(ctx, *note.LeafletRKey, *doc, note.IsDraft)
+	if services.IsTransient(err) {
+		op := &models.LeafletOutboxEntry{NoteID: noteID, Operation: models.OutboxUpdate, RKey: *note.LeafletRKey, IsDraft: note.IsDraft}
+		return h.enqueue(ctx, op, note, doc, err)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to patch document: %w", err)
+		return fmt.Errorf("failed to patch document: %w", h.staleBlobError(ctx, doc.Author, err))
 	}
 
 	note.LeafletCID = &result.Meta.CID
+	note.LeafletPublication = &doc.Publication
 
 	if err := h.repos.Notes.Update(ctx, note); err != nil {
 		return fmt.Errorf("document updated but failed to update local note: %w", err)
 	}
+	if err := h.saveLeafletBase(ctx, note); err != nil {
+		return fmt.Errorf("document updated but failed to record sync state: %w", err)
+	}
+	if err := h.repos.Notes.DeleteLeafletOutboxEntry(ctx, noteID); err != nil {
+		return fmt.Errorf("document updated but failed to clear queued update: %w", err)
+	}
 
 	ui.Successln("Document updated successfully!")
 	ui.Infoln("  RKey: %s", result.Meta.RKey)
@@ -381,21 +469,125 @@ func (h *PublicationHandler) Delete(ctx context.Context, noteID int64) error {
 	ui.Infoln("Deleting document '%s' from leaflet...", note.Title)
 
 	err = h.atproto.DeleteDocument(ctx, *note.LeafletRKey, note.IsDraft)
+	if services.IsTransient(err) {
+		op := &models.LeafletOutboxEntry{NoteID: noteID, Operation: models.OutboxDelete, RKey: *note.LeafletRKey, IsDraft: note.IsDraft}
+		return h.enqueue(ctx, op, note, nil, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
 
+	if err := h.unlinkDocument(ctx, note); err != nil {
+		return err
+	}
+	if err := h.repos.Notes.DeleteLeafletOutboxEntry(ctx, noteID); err != nil {
+		return fmt.Errorf("document deleted but failed to clear queued changes: %w", err)
+	}
+
+	ui.Successln("Document deleted successfully!")
+
+	return nil
+}
+
+// unlinkDocument clears a note's leaflet association after its document was deleted
+func (h *PublicationHandler) unlinkDocument(ctx context.Context, note *models.Note) error {
 	note.LeafletRKey = nil
 	note.LeafletCID = nil
+	note.LeafletPublication = nil
 	note.PublishedAt = nil
 	note.IsDraft = false
 
 	if err := h.repos.Notes.Update(ctx, note); err != nil {
 		return fmt.Errorf("document deleted but failed to update local note: %w", err)
 	}
+	if err := h.repos.Notes.DeleteLeafletSyncState(ctx, note.ID); err != nil {
+		return fmt.Errorf("document deleted but failed to clear sync state: %w", err)
+	}
+	return nil
+}
 
-	ui.Successln("Document deleted successfully!")
+// Publish turns a leaflet draft into a published document with the note's current content
+func (h *PublicationHandler) Publish(ctx context.Context, noteID int64) error {
+	return h.setDraft(ctx, noteID, false)
+}
+
+// Unpublish turns a published leaflet document back into a draft
+func (h *PublicationHandler) Unpublish(ctx context.Context, noteID int64) error {
+	return h.setDraft(ctx, noteID, true)
+}
+
+// setDraft moves a note's document between the draft and published collections
+func (h *PublicationHandler) setDraft(ctx context.Context, noteID int64, isDraft bool) error {
+	if !h.atproto.IsAuthenticated() {
+		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
+	}
 
+	current, err := h.repos.Notes.Get(ctx, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+
+	if !current.HasLeafletAssociation() {
+		return fmt.Errorf("note not on leaflet - use 'noteleaf pub post %d' first", noteID)
+	}
+	if current.IsDraft == isDraft {
+		if isDraft {
+			return fmt.Errorf("note %d is already a draft", noteID)
+		}
+		return fmt.Errorf("note %d is already published", noteID)
+	}
+
+	if err := h.checkLeafletSync(ctx, noteID); err != nil {
+		return err
+	}
+	if err := h.checkQueued(ctx, noteID, false); err != nil {
+		return err
+	}
+
+	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, true, "")
+	if err != nil {
+		return err
+	}
+
+	if isDraft {
+		ui.Infoln("Moving '%s' back to drafts...", note.Title)
+	} else {
+		ui.Infoln("Publishing draft '%s'...", note.Title)
+	}
+
+	result, err := h.atproto.MoveDocument(ctx, *note.LeafletRKey, *doc, isDraft)
+	if err != nil {
+		return fmt.Errorf("failed to move document: %w", h.staleBlobError(ctx, doc.Author, err))
+	}
+
+	if result.Meta.RKey != *note.LeafletRKey {
+		ui.Warningln("Record key %s was taken; the document now uses %s", *note.LeafletRKey, result.Meta.RKey)
+	}
+
+	note.LeafletRKey = &result.Meta.RKey
+	note.LeafletCID = &result.Meta.CID
+	note.IsDraft = isDraft
+	note.PublishedAt = nil
+	if !isDraft {
+		if publishedAt, err := time.Parse(time.RFC3339, doc.PublishedAt); err == nil {
+			note.PublishedAt = &publishedAt
+		}
+	}
+
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("document moved but failed to update local note: %w", err)
+	}
+	if err := h.saveLeafletBase(ctx, note); err != nil {
+		return fmt.Errorf("document moved but failed to record sync state: %w", err)
+	}
+
+	if isDraft {
+		ui.Successln("Document moved to drafts")
+	} else {
+		ui.Successln("Draft published successfully!")
+	}
+	ui.Infoln("  RKey: %s", result.Meta.RKey)
+	ui.Infoln("  CID: %s", result.Meta.CID)
 	return nil
 }
 
@@ -477,7 +669,7 @@ func parseNoteContent(content string) (title, noteContent string, tags []string)
 }
 
 // PushFromFiles creates notes from files and pushes them to leaflet
-func (h *PublicationHandler) PushFromFiles(ctx context.Context, filePaths []string, isDraft bool, dryRun bool) error {
+func (h *PublicationHandler) PushFromFiles(ctx context.Context, filePaths []string, isDraft bool, publication string, dryRun bool) error {
 	if len(filePaths) == 0 {
 		return fmt.Errorf("no file paths provided")
 	}
@@ -508,11 +700,15 @@ func (h *PublicationHandler) PushFromFiles(ctx context.Context, filePaths []stri
 		return nil
 	}
 
-	return h.Push(ctx, noteIDs, isDraft, dryRun)
+	return h.Push(ctx, noteIDs, isDraft, publication, dryRun)
 }
 
-// Push creates or updates multiple documents on leaflet from local notes
-func (h *PublicationHandler) Push(ctx context.Context, noteIDs []int64, isDraft bool, dryRun bool) error {
+// Push creates or updates multiple documents on leaflet from local notes.
+//
+// Notes are pushed so that the notes they link to get documents first, letting their
+// links point at those documents. Documents whose links went stale along the way are
+// patched once more at the end.
+func (h *PublicationHandler) Push(ctx context.Context, noteIDs []int64, isDraft bool, publication string, dryRun bool) error {
 	if !dryRun && !h.atproto.IsAuthenticated() {
 		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
 	}
@@ -527,8 +723,11 @@ func (h *PublicationHandler) Push(ctx context.Context, noteIDs []int64, isDraft
 		ui.Infoln("Processing %d note(s)...\n", len(noteIDs))
 	}
 
-	var created, updated, failed int
+	noteIDs, early := h.orderForPush(ctx, noteIDs)
+
+	var created, updated, queued, failed int
 	var errors []string
+	var pushed []int64
 
 	for _, noteID := range noteIDs {
 		note, err := h.repos.Notes.Get(ctx, noteID)
@@ -540,7 +739,11 @@ func (h *PublicationHandler) Push(ctx context.Context, noteIDs []int64, isDraft
 		}
 
 		if dryRun {
-			_, _, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, note.HasLeafletAssociation())
+			explicit := publication
+			if note.HasLeafletAssociation() {
+				explicit = ""
+			}
+			_, _, _, err := h.prepareDocument(ctx, noteID, isDraft, note.HasLeafletAssociation(), explicit, true)
 			if err != nil {
 				ui.Warningln("  [%d] Validation failed for '%s': %v", noteID, note.Title, err)
 				errors = append(errors, fmt.Sprintf("note %d (%s): %v", noteID, note.Title, err))
@@ -560,28 +763,45 @@ func (h *PublicationHandler) Push(ctx context.Context, noteIDs []int64, isDraft
 					ui.Warningln("  [%d] Failed to update '%s': %v", noteID, note.Title, err)
 					errors = append(errors, fmt.Sprintf("note %d (%s): %v", noteID, note.Title, err))
 					failed++
+				} else if h.isQueued(ctx, noteID) {
+					queued++
 				} else {
 					updated++
+					pushed = append(pushed, noteID)
 				}
 			} else {
-				err = h.Post(ctx, noteID, isDraft)
+				err = h.Post(ctx, noteID, isDraft, publication)
 				if err != nil {
 					ui.Warningln("  [%d] Failed to create '%s': %v", noteID, note.Title, err)
 					errors = append(errors, fmt.Sprintf("note %d (%s): %v", noteID, note.Title, err))
 					failed++
+				} else if h.isQueued(ctx, noteID) {
+					queued++
 				} else {
 					created++
+					pushed = append(pushed, noteID)
 				}
 			}
 		}
 	}
 
+	var relinked int
+	if !dryRun {
+		relinked = h.refreshLinks(ctx, pushed, early)
+	}
+
 	ui.Newline()
 	if dryRun {
 		ui.Successln("Dry run complete: %d would be created, %d would be updated, %d failed validation", created, updated, failed)
 		ui.Infoln("No changes made to leaflet")
 	} else {
-		ui.Successln("Push complete: %d created, %d updated, %d failed", created, updated, failed)
+		ui.Successln("Push complete: %d created, %d updated, %d queued, %d failed", created, updated, queued, failed)
+		if relinked > 0 {
+			ui.Infoln("Updated links in %d document(s)", relinked)
+		}
+		if queued > 0 {
+			ui.Infoln("Send queued changes with 'noteleaf pub queue flush'")
+		}
 	}
 
 	if len(errors) > 0 {
@@ -643,45 +863,56 @@ func (h *PublicationHandler) Read(ctx context.Context, identifier string) error
 }
 
 // prepareDocumentForPublish prepares a note for publication by converting to Leaflet format
-func (h *PublicationHandler) prepareDocumentForPublish(ctx context.Context, noteID int64, isDraft bool, forPatch bool) (*models.Note, *public.Document, error) {
+func (h *PublicationHandler) prepareDocumentForPublish(ctx context.Context, noteID int64, isDraft bool, forPatch bool, publication string) (*models.Note, *public.Document, error) {
+	note, doc, _, err := h.prepareDocument(ctx, noteID, isDraft, forPatch, publication, false)
+	return note, doc, err
+}
+
+// prepareDocument converts a note to a Leaflet document and reports the images it references.
+// A dry run resolves images without uploading them, for previews and validation.
+func (h *PublicationHandler) prepareDocument(ctx context.Context, noteID int64, isDraft bool, forPatch bool, publication string, dryRun bool) (*models.Note, *public.Document, []public.ResolvedImage, error) {
 	note, err := h.repos.Notes.Get(ctx, noteID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get note: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get note: %w", err)
+	}
+
+	if note.Encrypted {
+		return nil, nil, nil, fmt.Errorf("note is encrypted - decrypt it before publishing")
 	}
 
 	if !forPatch && note.HasLeafletAssociation() {
-		return nil, nil, fmt.Errorf("note already published - use patch to update")
+		return nil, nil, nil, fmt.Errorf("note already published - use patch to update")
 	}
 
 	if forPatch && !note.HasLeafletAssociation() {
-		return nil, nil, fmt.Errorf("note not published - use post to create")
+		return nil, nil, nil, fmt.Errorf("note not published - use post to create")
 	}
 
 	session, err := h.atproto.GetSession()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	converter := public.NewMarkdownConverter()
-
-	noteDir := extractNoteDirectory(note)
-	if noteDir != "" {
-		imageResolver := &public.LocalImageResolver{
-			BlobUploader: func(data []byte, mimeType string) (public.Blob, error) {
-				return h.atproto.UploadBlob(ctx, data, mimeType)
-			},
-		}
-		converter = converter.WithImageResolver(imageResolver, noteDir)
+	links, err := h.documentLinks(ctx, session.DID)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	content := links.rewrite(note)
+	if h.config != nil && h.config.LeafletBacklinks && !isDraft {
+		content += links.backlinksSection(note)
+	}
+
+	resolver := h.imageResolver(ctx, session.DID, dryRun)
+	converter := public.NewMarkdownConverter().WithImageResolver(resolver, extractNoteDirectory(note))
 
-	blocks, err := converter.ToLeaflet(note.Content)
+	blocks, err := converter.ToLeaflet(content)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to convert markdown to leaflet format: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to convert markdown to leaflet format: %w", err)
 	}
 
-	publicationURI, err := h.atproto.GetDefaultPublication(ctx)
+	publicationURI, err := h.publicationFor(ctx, note, publication, forPatch)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get publication: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get publication: %w", err)
 	}
 
 	docType := public.TypeDocument
@@ -712,7 +943,7 @@ func (h *PublicationHandler) prepareDocumentForPublish(ctx context.Context, note
 		}
 	}
 
-	return note, doc, nil
+	return note, doc, resolver.Resolved, nil
 }
 
 // writeDocumentOutput writes document to a file in JSON or plaintext format
@@ -763,12 +994,12 @@ func writeDocumentOutput(doc *public.Document, note *models.Note, outputPath str
 }
 
 // PostPreview shows what would be posted without actually posting
-func (h *PublicationHandler) PostPreview(ctx context.Context, noteID int64, isDraft bool, outputPath string, plaintext bool) error {
+func (h *PublicationHandler) PostPreview(ctx context.Context, noteID int64, isDraft bool, publication string, outputPath string, plaintext bool) error {
 	if !h.atproto.IsAuthenticated() {
 		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, false)
+	note, doc, images, err := h.prepareDocument(ctx, noteID, isDraft, false, publication, true)
 	if err != nil {
 		return err
 	}
@@ -787,6 +1018,7 @@ func (h *PublicationHandler) PostPreview(ctx context.Context, noteID int64, isDr
 		ui.Infoln("  PublishedAt: %s", doc.PublishedAt)
 	}
 	ui.Infoln("  Note ID: %d", note.ID)
+	printImages(images)
 
 	if outputPath != "" {
 		if err := writeDocumentOutput(doc, note, outputPath, plaintext); err != nil {
@@ -804,18 +1036,36 @@ func (h *PublicationHandler) PostPreview(ctx context.Context, noteID int64, isDr
 	return nil
 }
 
+// validateDocumentSchema checks a document against the pub.leaflet.document
+// lexicon generated by 'noteleaf tools lexgen', and is skipped with a warning in
+// builds without generated schemas
+func validateDocumentSchema(doc *public.Document) error {
+	err := public.ValidateRecord(public.TypeDocument, doc)
+	if errors.Is(err, public.ErrNoSchema) {
+		ui.Warningln("Skipping lexicon checks: %v", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("document does not match the %s lexicon: %w", public.TypeDocument, err)
+	}
+	return nil
+}
+
 // PostValidate validates markdown conversion without posting
-func (h *PublicationHandler) PostValidate(ctx context.Context, noteID int64, isDraft bool, outputPath string, plaintext bool) error {
+func (h *PublicationHandler) PostValidate(ctx context.Context, noteID int64, isDraft bool, publication string, outputPath string, plaintext bool) error {
 	if !h.atproto.IsAuthenticated() {
 		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, isDraft, false)
+	note, doc, _, err := h.prepareDocument(ctx, noteID, isDraft, false, publication, true)
 	if err != nil {
 		return err
 	}
 
 	ui.Infoln("Validating markdown conversion for note %d...", note.ID)
+	if err := validateDocumentSchema(doc); err != nil {
+		return err
+	}
 	ui.Successln("Validation successful!")
 	ui.Infoln("  Title: %s", doc.Title)
 	ui.Infoln("  Blocks converted: %d", len(doc.Pages[0].Blocks))
@@ -845,7 +1095,7 @@ func (h *PublicationHandler) PatchPreview(ctx context.Context, noteID int64, out
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, tempNote.IsDraft, true)
+	note, doc, images, err := h.prepareDocument(ctx, noteID, tempNote.IsDraft, true, "", true)
 	if err != nil {
 		return err
 	}
@@ -864,6 +1114,7 @@ func (h *PublicationHandler) PatchPreview(ctx context.Context, noteID int64, out
 	if doc.PublishedAt != "" {
 		ui.Infoln("  PublishedAt: %s", doc.PublishedAt)
 	}
+	printImages(images)
 
 	if outputPath != "" {
 		if err := writeDocumentOutput(doc, note, outputPath, plaintext); err != nil {
@@ -892,12 +1143,15 @@ func (h *PublicationHandler) PatchValidate(ctx context.Context, noteID int64, ou
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
-	note, doc, err := h.prepareDocumentForPublish(ctx, noteID, tempNote.IsDraft, true)
+	note, doc, _, err := h.prepareDocument(ctx, noteID, tempNote.IsDraft, true, "", true)
 	if err != nil {
 		return err
 	}
 
 	ui.Infoln("Validating markdown conversion for note %d...", note.ID)
+	if err := validateDocumentSchema(doc); err != nil {
+		return err
+	}
 	ui.Successln("Validation successful!")
 	ui.Infoln("  Title: %s", doc.Title)
 	ui.Infoln("  RKey: %s", *note.LeafletRKey)
@@ -964,7 +1218,7 @@ func sessionFromConfig(config *store.Config) (*services.Session, error) {
 		expiresAt = time.Now().Add(-1 * time.Hour)
 	}
 
-	return &services.Session{
+	session := &services.Session{
 		DID:           config.ATProtoDID,
 		Handle:        config.ATProtoHandle,
 		AccessJWT:     config.ATProtoAccessJWT,
@@ -972,5 +1226,34 @@ func sessionFromConfig(config *store.Config) (*services.Session, error) {
 		PDSURL:        config.ATProtoPDSURL,
 		ExpiresAt:     expiresAt,
 		Authenticated: true,
-	}, nil
+	}
+	if config.ATProtoDPoPKey != "" {
+		session.OAuth = &services.OAuthSession{
+			Issuer:        config.ATProtoOAuthIssuer,
+			TokenEndpoint: config.ATProtoOAuthTokenEndpoint,
+			ClientID:      config.ATProtoOAuthClientID,
+			DPoPKey:       config.ATProtoDPoPKey,
+		}
+	}
+	return session, nil
+}
+
+// sessionToConfig copies session credentials into config, clearing OAuth
+// fields left from an earlier OAuth login when session uses an app password
+func sessionToConfig(config *store.Config, session *services.Session) {
+	config.ATProtoDID = session.DID
+	config.ATProtoHandle = session.Handle
+	config.ATProtoAccessJWT = session.AccessJWT
+	config.ATProtoRefreshJWT = session.RefreshJWT
+	config.ATProtoPDSURL = session.PDSURL
+	config.ATProtoExpiresAt = session.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+
+	var oauth services.OAuthSession
+	if session.OAuth != nil {
+		oauth = *session.OAuth
+	}
+	config.ATProtoOAuthIssuer = oauth.Issuer
+	config.ATProtoOAuthTokenEndpoint = oauth.TokenEndpoint
+	config.ATProtoOAuthClientID = oauth.ClientID
+	config.ATProtoDPoPKey = oauth.DPoPKey
 }
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// maxRemoteImageSize caps how much of a remote image is downloaded before it is downscaled
+const maxRemoteImageSize = 20 << 20
+
+// fetchRemoteImage downloads an http(s) image referenced by a note
+var fetchRemoteImage = func(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRemoteImageSize {
+		return nil, fmt.Errorf("image is larger than %d MB", maxRemoteImageSize>>20)
+	}
+	return data, nil
+}
+
+// blobCache adapts the blob_cache table to [public.BlobCache] for one account
+type blobCache struct {
+	ctx   context.Context
+	notes *repo.NoteRepository
+	did   string
+}
+
+func (c *blobCache) LookupBlob(hash string) (*public.ImageInfo, error) {
+	cached, err := c.notes.GetCachedBlob(c.ctx, c.did, hash)
+	if err != nil || cached == nil {
+		return nil, err
+	}
+	return &public.ImageInfo{
+		Blob: public.Blob{
+			Type:     public.TypeBlob,
+			Ref:      public.CID{Link: cached.CID},
+			MimeType: cached.MimeType,
+			Size:     cached.Size,
+		},
+		Width:  cached.Width,
+		Height: cached.Height,
+	}, nil
+}
+
+func (c *blobCache) StoreBlob(hash string, info *public.ImageInfo) error {
+	return c.notes.SaveCachedBlob(c.ctx, &models.CachedBlob{
+		Hash:     hash,
+		DID:      c.did,
+		CID:      info.Blob.Ref.Link,
+		MimeType: info.Blob.MimeType,
+		Size:     info.Blob.Size,
+		Width:    info.Width,
+		Height:   info.Height,
+	})
+}
+
+// imageResolver builds the resolver used to publish a note's images.
+//
+// Images are fetched, downscaled to the PDS blob limit and checked against the
+// blob cache either way; in a dry run nothing is uploaded or cached.
+func (h *PublicationHandler) imageResolver(ctx context.Context, did string, dryRun bool) *public.LocalImageResolver {
+	resolver := &public.LocalImageResolver{
+		Fetch:   fetchRemoteImage,
+		MaxSize: public.MaxImageBlobSize,
+		Cache:   &blobCache{ctx: ctx, notes: h.repos.Notes, did: did},
+	}
+	if !dryRun {
+		resolver.BlobUploader = func(data []byte, mimeType string) (public.Blob, error) {
+			return h.atproto.UploadBlob(ctx, data, mimeType)
+		}
+	}
+	return resolver
+}
+
+// printImages lists the images a preview would upload or reuse
+func printImages(images []public.ResolvedImage) {
+	if len(images) == 0 {
+		return
+	}
+
+	ui.Infoln("  Images: %d", len(images))
+	for _, img := range images {
+		name := img.Source
+		if !public.IsRemoteImage(name) {
+			name = filepath.Base(name)
+		}
+
+		switch {
+		case img.Cached:
+			ui.Plainln("    %s: already uploaded (%s)", name, formatImageSize(img.Size))
+		case img.Resized:
+			ui.Plainln("    %s: would upload %s, downscaled from %s to %dx%d", name,
+				formatImageSize(img.Size), formatImageSize(img.SourceSize), img.Width, img.Height)
+		default:
+			ui.Plainln("    %s: would upload %s", name, formatImageSize(img.Size))
+		}
+	}
+}
+
+func formatImageSize(size int) string {
+	if size < 1024*1024 {
+		return formatRevisionSize(size)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+}
+
+// isMissingBlob reports whether the PDS rejected a record because a blob it
+// references is gone, which happens when a cached upload was garbage collected
+func isMissingBlob(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "BlobNotFound") || strings.Contains(msg, "Could not find blob")
+}
+
+// staleBlobError clears the account's blob cache when err says a cached blob no
+// longer exists, so running the command again uploads the images afresh
+func (h *PublicationHandler) staleBlobError(ctx context.Context, did string, err error) error {
+	if !isMissingBlob(err) {
+		return err
+	}
+	if clearErr := h.repos.Notes.ClearBlobCache(ctx, did); clearErr != nil {
+		return fmt.Errorf("%w (and failed to clear the image cache: %v)", err, clearErr)
+	}
+	return fmt.Errorf("%w - cached image uploads were stale and have been cleared, run the command again to re-upload them", err)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// createImageNote creates a note stored in a directory holding local.png, which
+// also links remote.png from example.com
+func createImageNote(t *testing.T, handler *PublicationHandler, title string) int64 {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "local.png"), testPNG(t, 40, 20), 0o644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	note := &models.Note{
+		Title:    title,
+		Content:  "# " + title + "\n\n![local](local.png)\n\n![remote](https://example.com/remote.png)",
+		FilePath: filepath.Join(dir, "note.md"),
+	}
+	id, err := handler.repos.Notes.Create(context.Background(), note)
+	if err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	return id
+}
+
+// stubRemoteImages serves every remote image from memory and counts the downloads
+func stubRemoteImages(t *testing.T, data []byte) *int {
+	t.Helper()
+	fetches := 0
+	fetch := fetchRemoteImage
+	fetchRemoteImage = func(url string) ([]byte, error) {
+		fetches++
+		return data, nil
+	}
+	t.Cleanup(func() { fetchRemoteImage = fetch })
+	return &fetches
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestPublicationImages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("post uploads local and remote images once and reuses them", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		fetches := stubRemoteImages(t, testPNG(t, 30, 30))
+
+		var uploads int
+		mock.UploadBlobFunc = func(ctx context.Context, data []byte, mimeType string) (public.Blob, error) {
+			uploads++
+			return public.Blob{Type: public.TypeBlob, Ref: public.CID{Link: fmt.Sprintf("bafkrei%d", uploads)}, MimeType: mimeType, Size: len(data)}, nil
+		}
+		var posted public.Document
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			posted = doc
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: "rk", CID: "cid"}}, nil
+		}
+
+		first := createImageNote(t, handler, "First")
+		suite.AssertNoError(handler.Post(ctx, first, false, ""), "post first note")
+		if uploads != 2 || *fetches != 1 {
+			t.Fatalf("expected 2 uploads and 1 download, got %d and %d", uploads, *fetches)
+		}
+
+		var refs []string
+		for _, block := range posted.Pages[0].Blocks {
+			if img, ok := block.Block.(public.ImageBlock); ok {
+				refs = append(refs, img.Image.Ref.Link)
+			}
+		}
+		if strings.Join(refs, ",") != "bafkrei1,bafkrei2" {
+			t.Errorf("expected uploaded blobs in the document, got %v", refs)
+		}
+
+		second := createImageNote(t, handler, "Second")
+		suite.AssertNoError(handler.Post(ctx, second, false, ""), "post second note")
+		if uploads != 2 {
+			t.Errorf("expected cached blobs to be reused, got %d uploads", uploads)
+		}
+	})
+
+	t.Run("preview reports images without uploading them", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		stubRemoteImages(t, testPNG(t, 30, 30))
+		mock.UploadBlobFunc = func(ctx context.Context, data []byte, mimeType string) (public.Blob, error) {
+			t.Fatal("preview should not upload")
+			return public.Blob{}, nil
+		}
+
+		id := createImageNote(t, handler, "Preview")
+		var err error
+		output := captureStdout(t, func() {
+			err = handler.PostPreview(ctx, id, false, "", "", false)
+		})
+		suite.AssertNoError(err, "preview")
+
+		for _, want := range []string{"Images: 2", "local.png: would upload", "https://example.com/remote.png: would upload"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected preview to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		// nothing was cached, so a real post still uploads both images
+		uploads := 0
+		mock.UploadBlobFunc = func(ctx context.Context, data []byte, mimeType string) (public.Blob, error) {
+			uploads++
+			return public.Blob{Type: public.TypeBlob, Ref: public.CID{Link: "bafkreiup"}, MimeType: mimeType, Size: len(data)}, nil
+		}
+		suite.AssertNoError(handler.Post(ctx, id, false, ""), "post")
+		if uploads != 2 {
+			t.Errorf("expected 2 uploads after preview, got %d", uploads)
+		}
+
+		id = createImageNote(t, handler, "Again")
+		output = captureStdout(t, func() {
+			err = handler.PostPreview(ctx, id, false, "", "", false)
+		})
+		suite.AssertNoError(err, "preview")
+		if !strings.Contains(output, "local.png: already uploaded") {
+			t.Errorf("expected preview to report the cached image, got:\n%s", output)
+		}
+	})
+
+	t.Run("missing blob clears the cache", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		stubRemoteImages(t, testPNG(t, 30, 30))
+		suite.AssertNoError(handler.repos.Notes.SaveCachedBlob(ctx, &models.CachedBlob{
+			Hash: "stale", DID: mock.Session.DID, CID: "bafkreigone", MimeType: "image/png", Size: 1, Width: 1, Height: 1,
+		}), "seed cache")
+
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			return nil, fmt.Errorf("failed to create record: InvalidRequest: Could not find blob: bafkreigone")
+		}
+
+		id := createImageNote(t, handler, "Stale")
+		err := handler.Post(ctx, id, false, "")
+		suite.AssertError(err, "post should fail")
+		if !strings.Contains(err.Error(), "run the command again") {
+			t.Errorf("expected a hint to retry, got %v", err)
+		}
+
+		cached, err := handler.repos.Notes.GetCachedBlob(ctx, mock.Session.DID, "stale")
+		suite.AssertNoError(err, "get cached blob")
+		if cached != nil {
+			t.Error("expected the blob cache to be cleared")
+		}
+	})
+}
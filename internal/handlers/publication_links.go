@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// backlinksHeading titles the section listing the documents that link to a document
+const backlinksHeading = "Referenced by"
+
+// documentLinks resolves links between notes to the leaflet documents the notes were published as.
+//
+// Links use the same forms as note export: note:<id>, [[wiki links]] and relative .md paths.
+type documentLinks struct {
+	index *noteExporter
+	did   string
+	bases map[string]string // publication AT URI to base path, loaded on first use
+	load  func() map[string]string
+}
+
+// documentLinks indexes every note so links can be resolved for documents authored by did
+func (h *PublicationHandler) documentLinks(ctx context.Context, did string) (*documentLinks, error) {
+	notes, err := h.repos.Notes.List(ctx, repo.NoteListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	return &documentLinks{
+		index: newNoteExporter(notes, "", ""),
+		did:   did,
+		load: func() map[string]string {
+			bases := map[string]string{}
+			pubs, err := h.atproto.ListPublications(ctx)
+			if err != nil {
+				ui.Warningln("Could not load publications, linking documents by AT URI: %v", err)
+				return bases
+			}
+			for _, pub := range pubs {
+				bases[pub.URI] = pub.Publication.BasePath
+			}
+			return bases
+		},
+	}, nil
+}
+
+// documentURL returns the public URL of the document a note was published as, or its
+// AT URI when the publication has no base path. Drafts and unpublished notes return "".
+func (l *documentLinks) documentURL(note *models.Note) string {
+	if note.LeafletRKey == nil || note.IsDraft {
+		return ""
+	}
+
+	if note.LeafletPublication != nil {
+		if l.bases == nil {
+			l.bases = l.load()
+		}
+		if base := strings.Trim(l.bases[*note.LeafletPublication], "/"); base != "" {
+			if !strings.Contains(base, "://") {
+				base = "https://" + base
+			}
+			return base + "/" + *note.LeafletRKey
+		}
+	}
+	return fmt.Sprintf("at://%s/%s/%s", l.did, public.TypeDocument, *note.LeafletRKey)
+}
+
+// linkURL returns the destination a resolved note link is published with
+func (l *documentLinks) linkURL(target *models.Note, fragment string) string {
+	dest := l.documentURL(target)
+	if dest == "" || strings.HasPrefix(dest, "at://") {
+		return dest
+	}
+	return dest + fragment
+}
+
+// targets returns the notes linked from a note's content, in document order without repeats
+func (l *documentLinks) targets(note *models.Note) []*models.Note {
+	var found []*models.Note
+	for _, dest := range l.index.conv.LinkURLs(expandWikiLinks(note.Content)) {
+		if target, _ := l.index.resolveNote(dest); target != nil && !slices.Contains(found, target) {
+			found = append(found, target)
+		}
+	}
+	return found
+}
+
+// rewrite points links to published notes at their documents. Links to notes that
+// are not published yet are left as written and reported.
+func (l *documentLinks) rewrite(note *models.Note) string {
+	unpublished := map[string]bool{}
+	resolve := func(dest string) string {
+		target, fragment := l.index.resolveNote(dest)
+		if target == nil {
+			return ""
+		}
+		if published := l.linkURL(target, fragment); published != "" {
+			return published
+		}
+		unpublished[target.Title] = true
+		return ""
+	}
+
+	content := wikiLinkPattern.ReplaceAllStringFunc(note.Content, func(match string) string {
+		groups := wikiLinkPattern.FindStringSubmatch(match)
+		target := strings.TrimSpace(groups[1])
+		heading := groups[2]
+		if heading != "" {
+			heading = "#" + slugify(strings.TrimPrefix(heading, "#"))
+		}
+		dest := resolve("wiki:" + url.PathEscape(target) + heading)
+		if dest == "" {
+			return match
+		}
+		label := groups[3]
+		if label == "" {
+			label = target
+		}
+		return fmt.Sprintf("[%s](%s)", label, dest)
+	})
+
+	replacements := map[string]string{}
+	for _, dest := range l.index.conv.LinkURLs(content) {
+		if published := resolve(dest); published != "" {
+			replacements[dest] = published
+		}
+	}
+
+	for _, title := range slices.Sorted(maps.Keys(unpublished)) {
+		ui.Warningln("'%s' links to '%s', which is not published yet - push it first to link to its document", note.Title, title)
+	}
+	return rewriteMarkdownDestinations(content, replacements)
+}
+
+// referencedBy returns the published notes, other than note itself, that link to it, sorted by title
+func (l *documentLinks) referencedBy(note *models.Note) []*models.Note {
+	var refs []*models.Note
+	for _, other := range l.index.notes {
+		if other.ID == note.ID || l.documentURL(other) == "" {
+			continue
+		}
+		if slices.ContainsFunc(l.targets(other), func(t *models.Note) bool { return t.ID == note.ID }) {
+			refs = append(refs, other)
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Title < refs[j].Title })
+	return refs
+}
+
+// backlinksSection renders the "Referenced by" section appended to a published note, or "" when nothing links to it
+func (l *documentLinks) backlinksSection(note *models.Note) string {
+	refs := l.referencedBy(note)
+	if len(refs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## " + backlinksHeading + "\n\n")
+	for _, ref := range refs {
+		fmt.Fprintf(&b, "- [%s](%s)\n", ref.Title, l.documentURL(ref))
+	}
+	return b.String()
+}
+
+// stripBacklinks removes a trailing "Referenced by" section added by [documentLinks.backlinksSection]
+// from pulled markdown, so it doesn't end up in the note. The section is only removed when
+// it is a list of bare links, which is the only form noteleaf writes.
+func stripBacklinks(md string) string {
+	marker := "## " + backlinksHeading + "\n"
+	idx := strings.LastIndex(md, marker)
+	if idx < 0 || (idx > 0 && md[idx-1] != '\n') {
+		return md
+	}
+
+	for line := range strings.SplitSeq(strings.TrimSpace(md[idx+len(marker):]), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "- [") || !strings.HasSuffix(line, ")") || !strings.Contains(line, "](") {
+			return md
+		}
+	}
+	return strings.TrimRight(md[:idx], "\n") + "\n"
+}
+
+// pushOrder sorts notes so that notes linked from others in the batch are pushed first.
+//
+// Notes keep their given order where links don't require otherwise. When notes link to
+// each other in a cycle, the first of them is pushed before its targets and returned in
+// early, so it can be patched again once every note it links to has a document.
+func (l *documentLinks) pushOrder(notes []*models.Note) (ordered, early []*models.Note) {
+	inBatch := map[int64]bool{}
+	for _, note := range notes {
+		inBatch[note.ID] = true
+	}
+
+	deps := map[int64][]int64{}
+	for _, note := range notes {
+		for _, target := range l.targets(note) {
+			if target.ID != note.ID && inBatch[target.ID] {
+				deps[note.ID] = append(deps[note.ID], target.ID)
+			}
+		}
+	}
+
+	done := map[int64]bool{}
+	ready := func(note *models.Note) bool {
+		for _, dep := range deps[note.ID] {
+			if !done[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(ordered) < len(notes) {
+		var next *models.Note
+		for _, note := range notes {
+			if !done[note.ID] && ready(note) {
+				next = note
+				break
+			}
+		}
+		if next == nil {
+			for _, note := range notes {
+				if !done[note.ID] {
+					next = note
+					early = append(early, note)
+					break
+				}
+			}
+		}
+		done[next.ID] = true
+		ordered = append(ordered, next)
+	}
+	return ordered, early
+}
+
+// orderForPush reorders note IDs so link targets are pushed before the notes linking to
+// them, dropping repeats. IDs of missing notes are kept at the end so the push reports
+// them. The second result holds notes pushed before a note they link to.
+func (h *PublicationHandler) orderForPush(ctx context.Context, noteIDs []int64) ([]int64, []int64) {
+	links, err := h.documentLinks(ctx, "")
+	if err != nil {
+		ui.Warningln("Could not order notes by their links: %v", err)
+		return noteIDs, nil
+	}
+
+	byID := map[int64]*models.Note{}
+	for _, note := range links.index.notes {
+		byID[note.ID] = note
+	}
+
+	var notes []*models.Note
+	var missing []int64
+	seen := map[int64]bool{}
+	for _, id := range noteIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if note, ok := byID[id]; ok {
+			notes = append(notes, note)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	ordered, early := links.pushOrder(notes)
+	ids := make([]int64, 0, len(ordered)+len(missing))
+	for _, note := range ordered {
+		ids = append(ids, note.ID)
+	}
+	var earlyIDs []int64
+	for _, note := range early {
+		earlyIDs = append(earlyIDs, note.ID)
+	}
+	return append(ids, missing...), earlyIDs
+}
+
+// refreshLinks patches documents whose links or "Referenced by" section went stale during a push:
+// notes pushed before a note they link to and, with leaflet_backlinks, the published
+// notes that pushed notes link to. It returns how many documents were updated.
+func (h *PublicationHandler) refreshLinks(ctx context.Context, pushed, early []int64) int {
+	var refresh []int64
+	for _, id := range early {
+		if slices.Contains(pushed, id) {
+			refresh = append(refresh, id)
+		}
+	}
+
+	if h.config != nil && h.config.LeafletBacklinks {
+		links, err := h.documentLinks(ctx, "")
+		if err != nil {
+			ui.Warningln("Could not refresh backlinks: %v", err)
+			return 0
+		}
+		for _, note := range links.index.notes {
+			if !slices.Contains(pushed, note.ID) || note.IsDraft {
+				continue
+			}
+			for _, target := range links.targets(note) {
+				if target.HasLeafletAssociation() && !target.IsDraft && !slices.Contains(refresh, target.ID) {
+					refresh = append(refresh, target.ID)
+				}
+			}
+		}
+	}
+
+	var refreshed int
+	for _, id := range refresh {
+		if h.isQueued(ctx, id) {
+			continue
+		}
+		if err := h.Patch(ctx, id); err != nil {
+			ui.Warningln("  [%d] Failed to update links: %v", id, err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
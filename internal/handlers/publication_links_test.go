@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+// createPublishedNote stores a note, marking it published on the blog under rkey when rkey is set
+func createPublishedNote(t *testing.T, handler *PublicationHandler, title, content, rkey string) int64 {
+	t.Helper()
+	note := &models.Note{Title: title, Content: content}
+	if rkey != "" {
+		cid, pub := "cid_"+rkey, blogURI
+		note.LeafletRKey, note.LeafletCID, note.LeafletPublication = &rkey, &cid, &pub
+	}
+	id, err := handler.repos.Notes.Create(context.Background(), note)
+	if err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	return id
+}
+
+func documentJSON(t *testing.T, doc public.Document) string {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+	return string(data)
+}
+
+// withBasePath serves the blog publication from base
+func withBasePath(mock *services.MockATProtoService, base string) {
+	mock.ListPublicationsFunc = func(ctx context.Context) ([]services.PublicationWithMeta, error) {
+		pubs := testPublications()
+		pubs[0].Publication.BasePath = base
+		return pubs, nil
+	}
+}
+
+// recordDocuments makes the mock hand out a fresh rkey per post and remembers every document written, by title
+func recordDocuments(mock *services.MockATProtoService) *[]string {
+	var writes []string
+	var posts int
+	mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+		posts++
+		writes = append(writes, "post "+doc.Title)
+		rkey := fmt.Sprintf("rk%d", posts)
+		return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: rkey, CID: "cid_" + rkey}}, nil
+	}
+	mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+		data, _ := json.Marshal(doc)
+		writes = append(writes, "patch "+doc.Title+" "+string(data))
+		return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: rkey, CID: "cid2_" + rkey}}, nil
+	}
+	return &writes
+}
+
+func TestPublicationLinks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rewrites links to published notes", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		withBasePath(mock, "example.com/blog/")
+
+		target := createPublishedNote(t, handler, "Target Note", "# Target", "target_rkey")
+		content := fmt.Sprintf("See [[Target Note]], [by id](note:%d) and [[Target Note#Some Heading|the heading]].", target)
+		id := createPublishedNote(t, handler, "Source", content, "")
+
+		_, doc, err := handler.prepareDocumentForPublish(ctx, id, false, false, "")
+		suite.AssertNoError(err, "prepare document")
+
+		out := documentJSON(t, *doc)
+		for _, want := range []string{
+			`"uri":"https://example.com/blog/target_rkey"`,
+			`"uri":"https://example.com/blog/target_rkey#some-heading"`,
+			`See Target Note, by id and the heading.`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected document to contain %s, got %s", want, out)
+			}
+		}
+	})
+
+	t.Run("falls back to AT URIs without a base path", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newPublicationsTestHandler(t)
+		createPublishedNote(t, handler, "Target", "# Target", "target_rkey")
+		id := createPublishedNote(t, handler, "Source", "Read [this](target.md#intro).", "")
+
+		_, doc, err := handler.prepareDocumentForPublish(ctx, id, false, false, "")
+		suite.AssertNoError(err, "prepare document")
+
+		out := documentJSON(t, *doc)
+		if !strings.Contains(out, `"atURI":"at://did:plc:test123/pub.leaflet.document/target_rkey"`) {
+			t.Errorf("expected an AT URI mention, got %s", out)
+		}
+	})
+
+	t.Run("leaves links to unpublished notes and drafts", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newPublicationsTestHandler(t)
+		createPublishedNote(t, handler, "Unpublished", "# Unpublished", "")
+		id := createPublishedNote(t, handler, "Source", "Link to [[Unpublished]].", "")
+
+		var doc *public.Document
+		var err error
+		output := captureStdout(t, func() {
+			_, doc, err = handler.prepareDocumentForPublish(ctx, id, false, false, "")
+		})
+		suite.AssertNoError(err, "prepare document")
+
+		out := documentJSON(t, *doc)
+		if !strings.Contains(out, "[[Unpublished]]") {
+			t.Errorf("expected the wiki link to be kept, got %s", out)
+		}
+		if !strings.Contains(output, "links to 'Unpublished', which is not published yet") {
+			t.Errorf("expected a warning about the unpublished note, got:\n%s", output)
+		}
+	})
+
+	t.Run("push publishes link targets first", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		writes := recordDocuments(mock)
+
+		a := createPublishedNote(t, handler, "A", "Links to [[B]].", "")
+		b := createPublishedNote(t, handler, "B", "Links to [[C]].", "")
+		c := createPublishedNote(t, handler, "C", "No links.", "")
+
+		suite.AssertNoError(handler.Push(ctx, []int64{a, b, c, a}, false, "", false), "push")
+
+		want := []string{"post C", "post B", "post A"}
+		if strings.Join(*writes, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("expected writes %v, got %v", want, *writes)
+		}
+
+		note, err := handler.repos.Notes.Get(ctx, a)
+		suite.AssertNoError(err, "get A")
+		if note.Content != "Links to [[B]]." {
+			t.Errorf("expected the note content to stay as written, got %q", note.Content)
+		}
+	})
+
+	t.Run("push patches notes in a link cycle again", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		writes := recordDocuments(mock)
+
+		a := createPublishedNote(t, handler, "A", "Links to [[B]].", "")
+		b := createPublishedNote(t, handler, "B", "Links to [[A]].", "")
+
+		suite.AssertNoError(handler.Push(ctx, []int64{a, b}, false, "", false), "push")
+
+		if len(*writes) != 3 || (*writes)[0] != "post A" || (*writes)[1] != "post B" {
+			t.Fatalf("expected A and B to be posted then A patched, got %v", *writes)
+		}
+		if patch := (*writes)[2]; !strings.HasPrefix(patch, "patch A ") || !strings.Contains(patch, "pub.leaflet.document/rk2") {
+			t.Errorf("expected A to be patched with a link to B, got %s", patch)
+		}
+	})
+
+	t.Run("push refreshes referenced by sections", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		handler.config.LeafletBacklinks = true
+		withBasePath(mock, "example.com")
+		writes := recordDocuments(mock)
+
+		createPublishedNote(t, handler, "Target", "# Target", "target_rkey")
+		source := createPublishedNote(t, handler, "Source", "See [[Target]].", "")
+
+		suite.AssertNoError(handler.Push(ctx, []int64{source}, false, "Blog", false), "push")
+
+		if len(*writes) != 2 || (*writes)[0] != "post Source" {
+			t.Fatalf("expected Source to be posted then Target patched, got %v", *writes)
+		}
+		patch := (*writes)[1]
+		for _, want := range []string{"patch Target ", backlinksHeading, `"uri":"https://example.com/rk1"`} {
+			if !strings.Contains(patch, want) {
+				t.Errorf("expected target patch to contain %s, got %s", want, patch)
+			}
+		}
+	})
+}
+
+func TestStripBacklinks(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "removes the section",
+			md:   "# Title\n\nBody.\n\n## Referenced by\n\n- [A](https://example.com/a)\n- [B](at://did:plc:x/pub.leaflet.document/b)\n",
+			want: "# Title\n\nBody.\n",
+		},
+		{
+			name: "keeps a heading followed by other content",
+			md:   "# Title\n\n## Referenced by\n\nSome prose about references.\n",
+			want: "# Title\n\n## Referenced by\n\nSome prose about references.\n",
+		},
+		{
+			name: "keeps notes without the section",
+			md:   "# Title\n\nBody.\n",
+			want: "# Title\n\nBody.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripBacklinks(tt.md); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
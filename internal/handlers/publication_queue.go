@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// queueRetryDelay is the wait before retrying a queued operation; it doubles after each attempt
+var queueRetryDelay = time.Second
+
+// queueMaxAttempts is how many times flush tries an operation before leaving it for the next flush
+const queueMaxAttempts = 5
+
+// enqueue saves an operation that could not reach leaflet so a later flush can send it.
+//
+// doc is the prepared document, or nil if it could not be prepared offline, in which
+// case the note is converted when the queue is flushed.
+func (h *PublicationHandler) enqueue(ctx context.Context, op *models.LeafletOutboxEntry, note *models.Note, doc *public.Document, cause error) error {
+	if doc != nil {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+		op.Document = string(data)
+		op.BaseTitle = note.Title
+		op.BaseContent = note.Content
+	}
+
+	entry, err := h.repos.Notes.EnqueueLeafletOp(ctx, op)
+	if err != nil {
+		return fmt.Errorf("leaflet is unreachable (%v) and the operation could not be queued: %w", cause, err)
+	}
+
+	ui.Warningln("Leaflet is unreachable: %v", cause)
+	if entry == nil {
+		ui.Successln("Cancelled the queued creation of '%s' instead", note.Title)
+		return nil
+	}
+	ui.Successln("Queued %s of '%s'", entry.Operation, note.Title)
+	ui.Infoln("  Send it later with 'noteleaf pub queue flush'")
+	return nil
+}
+
+// isQueued reports whether a note has an operation waiting in the queue
+func (h *PublicationHandler) isQueued(ctx context.Context, noteID int64) bool {
+	entry, err := h.repos.Notes.GetLeafletOutboxEntry(ctx, noteID)
+	return err == nil && entry != nil
+}
+
+// checkQueued refuses operations that cannot be combined with what is already queued for a note
+func (h *PublicationHandler) checkQueued(ctx context.Context, noteID int64, allowUpdate bool) error {
+	entry, err := h.repos.Notes.GetLeafletOutboxEntry(ctx, noteID)
+	if err != nil || entry == nil {
+		return err
+	}
+
+	if entry.Operation == models.OutboxDelete {
+		return fmt.Errorf("note %d is queued for deletion - run 'noteleaf pub queue drop %d' first", noteID, noteID)
+	}
+	if !allowUpdate {
+		return fmt.Errorf("note %d has queued changes - run 'noteleaf pub queue flush' first", noteID)
+	}
+	return nil
+}
+
+// QueueList shows the operations waiting to be sent to leaflet
+func (h *PublicationHandler) QueueList(ctx context.Context) error {
+	entries, err := h.repos.Notes.ListLeafletOutbox(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		ui.Infoln("The leaflet queue is empty.")
+		return nil
+	}
+
+	ui.Infoln("Queued leaflet operations (%d):", len(entries))
+	ui.Newline()
+	for _, entry := range entries {
+		title := "(note deleted)"
+		if note, err := h.repos.Notes.Get(ctx, entry.NoteID); err == nil {
+			title = note.Title
+		}
+
+		ui.Plainln("  [%d] %s %s", entry.NoteID, entry.Operation, title)
+		if entry.RKey != "" {
+			ui.Plainln("      rkey: %s", entry.RKey)
+		}
+		ui.Plainln("      queued: %s", entry.QueuedAt.Local().Format("2006-01-02 15:04"))
+		if entry.Failed {
+			ui.Warningln("      failed after %d attempt(s): %s", entry.Attempts, entry.LastError)
+		} else if entry.Attempts > 0 {
+			ui.Plainln("      %d attempt(s), last error: %s", entry.Attempts, entry.LastError)
+		}
+	}
+	ui.Newline()
+	return nil
+}
+
+// QueueDrop removes a note's queued operation without sending it, or every operation when all is set
+func (h *PublicationHandler) QueueDrop(ctx context.Context, noteID int64, all bool) error {
+	if all {
+		if err := h.repos.Notes.ClearLeafletOutbox(ctx); err != nil {
+			return err
+		}
+		ui.Successln("Leaflet queue cleared")
+		return nil
+	}
+
+	entry, err := h.repos.Notes.GetLeafletOutboxEntry(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("nothing queued for note %d", noteID)
+	}
+
+	if err := h.repos.Notes.DeleteLeafletOutboxEntry(ctx, noteID); err != nil {
+		return err
+	}
+	ui.Successln("Dropped queued %s for note %d", entry.Operation, noteID)
+	return nil
+}
+
+// QueueFlush sends queued operations to leaflet in the order they were queued.
+//
+// Each operation is retried with exponential backoff while the failure looks
+// temporary. If leaflet is still unreachable the flush stops and the rest stay
+// queued; operations rejected outright are marked failed and skipped by later
+// flushes until they are dropped or queued again.
+func (h *PublicationHandler) QueueFlush(ctx context.Context) error {
+	if !h.atproto.IsAuthenticated() {
+		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
+	}
+
+	entries, err := h.repos.Notes.ListLeafletOutbox(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []*models.LeafletOutboxEntry
+	var stuck int
+	for _, entry := range entries {
+		if entry.Failed {
+			stuck++
+		} else {
+			pending = append(pending, entry)
+		}
+	}
+
+	if len(pending) == 0 {
+		ui.Infoln("Nothing to flush.")
+		if stuck > 0 {
+			return fmt.Errorf("%d queued operation(s) failed - see 'noteleaf pub queue list'", stuck)
+		}
+		return nil
+	}
+
+	ui.Infoln("Flushing %d queued operation(s)...", len(pending))
+
+	var sent, failed int
+	for i, entry := range pending {
+		attempts, err := h.sendWithBackoff(ctx, entry)
+		if err == nil {
+			ui.Successln("  [%d] %s sent", entry.NoteID, entry.Operation)
+			sent++
+			continue
+		}
+
+		transient := services.IsTransient(err)
+		if recErr := h.repos.Notes.RecordLeafletOutboxFailure(ctx, entry.ID, attempts, err.Error(), !transient); recErr != nil {
+			return recErr
+		}
+
+		if transient {
+			ui.Newline()
+			ui.Warningln("Leaflet is still unreachable after %d attempt(s): %v", attempts, err)
+			ui.Infoln("%d sent, %d operation(s) left in the queue", sent, len(pending)-i)
+			return fmt.Errorf("flush stopped: %w", err)
+		}
+
+		ui.Warningln("  [%d] %s failed: %v", entry.NoteID, entry.Operation, err)
+		failed++
+	}
+
+	ui.Newline()
+	ui.Successln("Flush complete: %d sent, %d failed", sent, failed)
+
+	if failed+stuck > 0 {
+		return fmt.Errorf("%d queued operation(s) failed - see 'noteleaf pub queue list'", failed+stuck)
+	}
+	return nil
+}
+
+// sendWithBackoff sends a queued operation, retrying temporary failures with a
+// doubling delay. It returns the number of attempts made and the last error.
+func (h *PublicationHandler) sendWithBackoff(ctx context.Context, entry *models.LeafletOutboxEntry) (int, error) {
+	delay := queueRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := h.sendQueued(ctx, entry)
+		if err == nil || !services.IsTransient(err) || attempt == queueMaxAttempts {
+			return attempt, err
+		}
+
+		ui.Infoln("  [%d] attempt %d failed, retrying in %s", entry.NoteID, attempt, delay)
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// sendQueued performs a single queued operation and updates the note to match
+func (h *PublicationHandler) sendQueued(ctx context.Context, entry *models.LeafletOutboxEntry) error {
+	if entry.Operation == models.OutboxDelete {
+		if err := h.atproto.DeleteDocument(ctx, entry.RKey, entry.IsDraft); err != nil {
+			return err
+		}
+		if note, err := h.repos.Notes.Get(ctx, entry.NoteID); err == nil &&
+			note.LeafletRKey != nil && *note.LeafletRKey == entry.RKey {
+			if err := h.unlinkDocument(ctx, note); err != nil {
+				return err
+			}
+		}
+		return h.repos.Notes.DeleteLeafletOutboxEntry(ctx, entry.NoteID)
+	}
+
+	note, err := h.repos.Notes.Get(ctx, entry.NoteID)
+	if err != nil {
+		return fmt.Errorf("note no longer exists: %w", err)
+	}
+
+	isUpdate := entry.Operation == models.OutboxUpdate
+	if isUpdate {
+		if err := h.checkLeafletSync(ctx, note.ID); err != nil {
+			return err
+		}
+	}
+
+	doc, base, err := h.queuedDocument(ctx, entry, note)
+	if err != nil {
+		return err
+	}
+
+	var result *services.DocumentWithMeta
+	if isUpdate {
+		result, err = h.atproto.PatchDocument(ctx, entry.RKey, *doc, entry.IsDraft)
+	} else {
+		if note.HasLeafletAssociation() {
+			return fmt.Errorf("note already published - drop the queued create")
+		}
+		result, err = h.atproto.PostDocument(ctx, *doc, entry.IsDraft)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !isUpdate {
+		note.LeafletRKey = &result.Meta.RKey
+		note.IsDraft = entry.IsDraft
+		if !entry.IsDraft && doc.PublishedAt != "" {
+			if publishedAt, err := time.Parse(time.RFC3339, doc.PublishedAt); err == nil {
+				note.PublishedAt = &publishedAt
+			}
+		}
+	}
+	note.LeafletCID = &result.Meta.CID
+	note.LeafletPublication = &doc.Publication
+
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("document sent but failed to update local note: %w", err)
+	}
+	if err := h.repos.Notes.SaveLeafletSyncState(ctx, &models.LeafletSyncState{
+		NoteID:      note.ID,
+		RKey:        result.Meta.RKey,
+		BaseTitle:   base.Title,
+		BaseContent: base.Content,
+		Status:      models.LeafletSynced,
+	}); err != nil {
+		return fmt.Errorf("document sent but failed to record sync state: %w", err)
+	}
+	return h.repos.Notes.DeleteLeafletOutboxEntry(ctx, entry.NoteID)
+}
+
+// queuedDocument returns the document to send for a queued entry and the note
+// version it was made from, converting the note now if it was queued unprepared
+func (h *PublicationHandler) queuedDocument(ctx context.Context, entry *models.LeafletOutboxEntry, note *models.Note) (*public.Document, models.Note, error) {
+	if entry.Document == "" {
+		isUpdate := entry.Operation == models.OutboxUpdate
+		_, doc, err := h.prepareDocumentForPublish(ctx, note.ID, entry.IsDraft, isUpdate, entry.Publication)
+		if err != nil {
+			return nil, models.Note{}, err
+		}
+		return doc, *note, nil
+	}
+
+	var doc public.Document
+	if err := json.Unmarshal([]byte(entry.Document), &doc); err != nil {
+		return nil, models.Note{}, fmt.Errorf("failed to decode queued document: %w", err)
+	}
+	return &doc, models.Note{Title: entry.BaseTitle, Content: entry.BaseContent}, nil
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+// errUnreachable stands in for a PDS that is down
+var errUnreachable = fmt.Errorf("failed to update record: %w", &xrpc.Error{StatusCode: http.StatusServiceUnavailable})
+
+// newQueueTestHandler returns a handler with an authenticated mock and no retry delay
+func newQueueTestHandler(t *testing.T) (*PublicationHandler, *services.MockATProtoService) {
+	t.Helper()
+	handler, mock := newPublicationsTestHandler(t)
+
+	delay := queueRetryDelay
+	queueRetryDelay = time.Millisecond
+	t.Cleanup(func() { queueRetryDelay = delay })
+	return handler, mock
+}
+
+func createLinkedNote(t *testing.T, handler *PublicationHandler, title string) *models.Note {
+	t.Helper()
+	ctx := context.Background()
+	rkey, cid, pub := "rk_"+title, "cid_"+title, blogURI
+	note := &models.Note{Title: title, Content: "# " + title + "\n\nBody.", LeafletRKey: &rkey, LeafletCID: &cid, LeafletPublication: &pub}
+	id, err := handler.repos.Notes.Create(ctx, note)
+	if err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	note.ID = id
+	if err := handler.saveLeafletBase(ctx, note); err != nil {
+		t.Fatalf("failed to save base: %v", err)
+	}
+	return note
+}
+
+func queuedEntry(t *testing.T, handler *PublicationHandler, noteID int64) *models.LeafletOutboxEntry {
+	t.Helper()
+	entry, err := handler.repos.Notes.GetLeafletOutboxEntry(context.Background(), noteID)
+	if err != nil {
+		t.Fatalf("failed to get queued entry: %v", err)
+	}
+	return entry
+}
+
+func TestPublicationQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("post queues a create while offline and flush sends it", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			return nil, errUnreachable
+		}
+
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "Offline", Content: "# Offline\n\nWritten on a plane."})
+		suite.AssertNoError(err, "create note")
+
+		suite.AssertNoError(handler.Post(ctx, id, false, "Game Dev"), "post while offline")
+		entry := queuedEntry(t, handler, id)
+		if entry == nil || entry.Operation != models.OutboxCreate || entry.Document == "" {
+			t.Fatalf("expected a prepared create to be queued, got %+v", entry)
+		}
+		suite.AssertNoError(handler.QueueList(ctx), "queue list")
+		suite.AssertNoError(handler.SyncStatus(ctx), "sync status")
+
+		var posted public.Document
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			posted = doc
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: "rk_new", CID: "cid_new"}}, nil
+		}
+		suite.AssertNoError(handler.QueueFlush(ctx), "flush")
+
+		if posted.Title != "Offline" || posted.Publication != gamesURI {
+			t.Errorf("expected queued document to be posted to %s, got %q in %s", gamesURI, posted.Title, posted.Publication)
+		}
+		note, err := handler.repos.Notes.Get(ctx, id)
+		suite.AssertNoError(err, "get note")
+		if note.LeafletRKey == nil || *note.LeafletRKey != "rk_new" || note.PublishedAt == nil {
+			t.Errorf("expected note to be linked to the new document, got %+v", note)
+		}
+		if queuedEntry(t, handler, id) != nil {
+			t.Error("expected queue to be empty after flush")
+		}
+		if status := syncStatus(t, handler, id); status != models.LeafletSynced {
+			t.Errorf("expected synced state after flush, got %q", status)
+		}
+	})
+
+	t.Run("patches coalesce into a single write", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		note := createLinkedNote(t, handler, "Devlog")
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			return nil, errUnreachable
+		}
+
+		for _, title := range []string{"Devlog v2", "Devlog v3"} {
+			note.Title = title
+			suite.AssertNoError(handler.repos.Notes.Update(ctx, note), "edit note")
+			suite.AssertNoError(handler.Patch(ctx, note.ID), "patch while offline")
+		}
+
+		entries, err := handler.repos.Notes.ListLeafletOutbox(ctx)
+		suite.AssertNoError(err, "list queue")
+		if len(entries) != 1 || entries[0].Operation != models.OutboxUpdate {
+			t.Fatalf("expected one queued update, got %d entries", len(entries))
+		}
+
+		var writes []string
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			writes = append(writes, rkey+":"+doc.Title)
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: rkey, CID: "cid_v3"}}, nil
+		}
+		suite.AssertNoError(handler.QueueFlush(ctx), "flush")
+
+		if len(writes) != 1 || writes[0] != "rk_Devlog:Devlog v3" {
+			t.Errorf("expected a single write of the latest version, got %v", writes)
+		}
+		got, err := handler.repos.Notes.Get(ctx, note.ID)
+		suite.AssertNoError(err, "get note")
+		if *got.LeafletCID != "cid_v3" {
+			t.Errorf("expected CID to advance, got %s", *got.LeafletCID)
+		}
+	})
+
+	t.Run("flush retries with backoff", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		note := createLinkedNote(t, handler, "Flaky")
+
+		calls := 0
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			calls++
+			if calls < 4 {
+				return nil, errUnreachable
+			}
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: rkey, CID: "cid_ok"}}, nil
+		}
+
+		suite.AssertNoError(handler.Patch(ctx, note.ID), "patch while offline")
+		suite.AssertNoError(handler.QueueFlush(ctx), "flush")
+		if calls != 4 {
+			t.Errorf("expected the queued patch to succeed on its third retry, got %d calls", calls)
+		}
+		if queuedEntry(t, handler, note.ID) != nil {
+			t.Error("expected queue to be empty after flush")
+		}
+	})
+
+	t.Run("flush stops while leaflet stays unreachable", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		first := createLinkedNote(t, handler, "First")
+		second := createLinkedNote(t, handler, "Second")
+
+		calls := 0
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			calls++
+			return nil, errUnreachable
+		}
+		suite.AssertNoError(handler.Push(ctx, []int64{first.ID, second.ID}, false, "", false), "push while offline")
+
+		calls = 0
+		err := handler.QueueFlush(ctx)
+		if err == nil || !strings.Contains(err.Error(), "flush stopped") {
+			t.Errorf("expected flush to stop, got %v", err)
+		}
+		if calls != queueMaxAttempts {
+			t.Errorf("expected %d attempts before giving up, got %d", queueMaxAttempts, calls)
+		}
+
+		entry := queuedEntry(t, handler, first.ID)
+		if entry == nil || entry.Failed || entry.Attempts != queueMaxAttempts {
+			t.Errorf("expected entry to stay queued with %d attempts, got %+v", queueMaxAttempts, entry)
+		}
+		if queuedEntry(t, handler, second.ID) == nil {
+			t.Error("expected untried entry to stay queued")
+		}
+	})
+
+	t.Run("permanent failures are surfaced and skipped", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		note := createLinkedNote(t, handler, "Rejected")
+
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			return nil, errUnreachable
+		}
+		suite.AssertNoError(handler.Patch(ctx, note.ID), "patch while offline")
+
+		calls := 0
+		mock.PatchDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			calls++
+			return nil, fmt.Errorf("failed to update record: %w", &xrpc.Error{StatusCode: http.StatusForbidden})
+		}
+
+		err := handler.QueueFlush(ctx)
+		if err == nil || !strings.Contains(err.Error(), "failed") {
+			t.Errorf("expected flush to report the failure, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected permanent failure not to be retried, got %d calls", calls)
+		}
+		entry := queuedEntry(t, handler, note.ID)
+		if entry == nil || !entry.Failed || !strings.Contains(entry.LastError, "403") {
+			t.Fatalf("expected entry to be marked failed, got %+v", entry)
+		}
+
+		if err := handler.QueueFlush(ctx); err == nil || calls != 1 {
+			t.Errorf("expected failed entry to be skipped but reported, got %v after %d calls", err, calls)
+		}
+
+		suite.AssertNoError(handler.QueueDrop(ctx, note.ID, false), "drop")
+		if queuedEntry(t, handler, note.ID) != nil {
+			t.Error("expected entry to be dropped")
+		}
+	})
+
+	t.Run("delete is queued and unlinks on flush", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		note := createLinkedNote(t, handler, "Retired")
+
+		mock.DeleteDocumentFunc = func(ctx context.Context, rkey string, isDraft bool) error {
+			return errUnreachable
+		}
+		suite.AssertNoError(handler.Delete(ctx, note.ID), "delete while offline")
+
+		got, err := handler.repos.Notes.Get(ctx, note.ID)
+		suite.AssertNoError(err, "get note")
+		if !got.HasLeafletAssociation() {
+			t.Error("expected note to stay linked until the delete is sent")
+		}
+		if err := handler.Patch(ctx, note.ID); err == nil || !strings.Contains(err.Error(), "queued for deletion") {
+			t.Errorf("expected patch to refuse a note queued for deletion, got %v", err)
+		}
+		if err := handler.Unpublish(ctx, note.ID); err == nil || !strings.Contains(err.Error(), "queued") {
+			t.Errorf("expected unpublish to refuse a queued note, got %v", err)
+		}
+
+		var deleted string
+		mock.DeleteDocumentFunc = func(ctx context.Context, rkey string, isDraft bool) error {
+			deleted = rkey
+			return nil
+		}
+		suite.AssertNoError(handler.QueueFlush(ctx), "flush")
+
+		got, err = handler.repos.Notes.Get(ctx, note.ID)
+		suite.AssertNoError(err, "get note")
+		if deleted != "rk_Retired" || got.HasLeafletAssociation() {
+			t.Errorf("expected document rk_Retired to be deleted and unlinked, got %q linked=%v", deleted, got.HasLeafletAssociation())
+		}
+	})
+
+	t.Run("permanent errors are not queued", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newQueueTestHandler(t)
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			return nil, fmt.Errorf("failed to create record: %w", &xrpc.Error{StatusCode: http.StatusBadRequest})
+		}
+
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "Bad", Content: "# Bad"})
+		suite.AssertNoError(err, "create note")
+
+		if err := handler.Post(ctx, id, false, ""); err == nil {
+			t.Error("expected post to fail")
+		}
+		if queuedEntry(t, handler, id) != nil {
+			t.Error("expected nothing to be queued")
+		}
+	})
+
+	t.Run("drop all", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newQueueTestHandler(t)
+		for _, id := range []int64{1, 2} {
+			_, err := handler.repos.Notes.EnqueueLeafletOp(ctx, &models.LeafletOutboxEntry{NoteID: id, Operation: models.OutboxDelete, RKey: "rk"})
+			suite.AssertNoError(err, "enqueue")
+		}
+
+		suite.AssertNoError(handler.QueueDrop(ctx, 0, true), "drop all")
+		entries, err := handler.repos.Notes.ListLeafletOutbox(ctx)
+		suite.AssertNoError(err, "list queue")
+		if len(entries) != 0 {
+			t.Errorf("expected empty queue, got %d entries", len(entries))
+		}
+		if err := handler.QueueDrop(ctx, 1, false); err == nil {
+			t.Error("expected dropping an empty entry to fail")
+		}
+	})
+}
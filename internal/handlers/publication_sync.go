@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+	"github.com/stormlightlabs/noteleaf/internal/utils"
+)
+
+// Conflict resolution strategies for [PublicationHandler.Resolve]
+const (
+	ResolveOurs   = "ours"   // keep the local note
+	ResolveTheirs = "theirs" // take the leaflet version
+	ResolveMerge  = "merge"  // three-way merge, leaving conflict markers where both sides changed
+)
+
+// pullSummary counts what a pull did to local notes
+type pullSummary struct {
+	created, updated, unchanged, conflicts, deleted, failed int
+}
+
+func (s pullSummary) String() string {
+	parts := []string{
+		fmt.Sprintf("%d created", s.created),
+		fmt.Sprintf("%d updated", s.updated),
+		fmt.Sprintf("%d unchanged", s.unchanged),
+	}
+	if s.conflicts > 0 {
+		parts = append(parts, fmt.Sprintf("%d conflicted", s.conflicts))
+	}
+	if s.deleted > 0 {
+		parts = append(parts, fmt.Sprintf("%d deleted on leaflet", s.deleted))
+	}
+	if s.failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", s.failed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pullDocument merges one leaflet document into its note, creating the note when there is none.
+//
+// The remote side changed when the document CID differs from the one stored on the
+// note; the local side changed when the note differs from the recorded base.
+func (h *PublicationHandler) pullDocument(ctx context.Context, doc services.DocumentWithMeta, content string, note *models.Note, summary *pullSummary) error {
+	if note == nil {
+		note = &models.Note{}
+		applyDocument(note, doc, content)
+		id, err := h.repos.Notes.Create(ctx, note)
+		if err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+		note.ID = id
+		if err := h.saveLeafletBase(ctx, note); err != nil {
+			return err
+		}
+		summary.created++
+		ui.Infoln("  Created: %s", doc.Document.Title)
+		return nil
+	}
+
+	state, err := h.repos.Notes.GetLeafletSyncState(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+
+	remoteChanged := note.LeafletCID == nil || *note.LeafletCID != doc.Meta.CID
+	if state == nil {
+		// Pulled before sync state was tracked: with an unchanged CID the note is the
+		// best available base, otherwise there is no common ancestor to merge from.
+		state = &models.LeafletSyncState{NoteID: note.ID, RKey: doc.Meta.RKey}
+		if !remoteChanged {
+			state.BaseTitle, state.BaseContent = note.Title, note.Content
+		}
+	}
+
+	if !remoteChanged {
+		if state.Status == models.LeafletDeleted {
+			state.Status = models.LeafletSynced
+			if err := h.repos.Notes.SaveLeafletSyncState(ctx, state); err != nil {
+				return err
+			}
+		}
+		summary.unchanged++
+		return nil
+	}
+
+	localChanged := note.Title != state.BaseTitle || note.Content != state.BaseContent
+	sameAsRemote := note.Title == doc.Document.Title && note.Content == content
+	if localChanged && !sameAsRemote {
+		state.Status = models.LeafletConflict
+		state.RemoteCID = doc.Meta.CID
+		state.RemoteTitle = doc.Document.Title
+		state.RemoteContent = content
+		state.SyncedAt = time.Now()
+		if err := h.repos.Notes.SaveLeafletSyncState(ctx, state); err != nil {
+			return err
+		}
+		summary.conflicts++
+		ui.Warningln("  Conflict: [%d] %s changed locally and on leaflet", note.ID, note.Title)
+		return nil
+	}
+
+	applyDocument(note, doc, content)
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+	if err := h.saveLeafletBase(ctx, note); err != nil {
+		return err
+	}
+	summary.updated++
+	ui.Infoln("  Updated: %s", note.Title)
+	return nil
+}
+
+// markRemoteDeleted flags a note whose leaflet document no longer exists
+func (h *PublicationHandler) markRemoteDeleted(ctx context.Context, note *models.Note, summary *pullSummary) error {
+	state, err := h.repos.Notes.GetLeafletSyncState(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &models.LeafletSyncState{NoteID: note.ID, RKey: *note.LeafletRKey, BaseTitle: note.Title, BaseContent: note.Content}
+	}
+
+	summary.deleted++
+	if state.Status == models.LeafletDeleted {
+		return nil
+	}
+
+	state.Status = models.LeafletDeleted
+	state.RemoteCID, state.RemoteTitle, state.RemoteContent = "", "", ""
+	state.SyncedAt = time.Now()
+	if err := h.repos.Notes.SaveLeafletSyncState(ctx, state); err != nil {
+		return err
+	}
+	ui.Warningln("  Deleted on leaflet: [%d] %s", note.ID, note.Title)
+	return nil
+}
+
+// applyDocument copies a leaflet document's content and metadata onto a note
+func applyDocument(note *models.Note, doc services.DocumentWithMeta, content string) {
+	rkey, cid := doc.Meta.RKey, doc.Meta.CID
+	note.Title = doc.Document.Title
+	note.Content = content
+	note.LeafletRKey = &rkey
+	note.LeafletCID = &cid
+	note.IsDraft = doc.Meta.IsDraft
+	if doc.Document.Publication != "" {
+		publication := doc.Document.Publication
+		note.LeafletPublication = &publication
+	}
+
+	if doc.Document.PublishedAt != "" {
+		if publishedAt, err := time.Parse(time.RFC3339, doc.Document.PublishedAt); err == nil {
+			note.PublishedAt = &publishedAt
+		}
+	}
+}
+
+// saveLeafletBase records the note as it now stands as the common ancestor for the next pull
+func (h *PublicationHandler) saveLeafletBase(ctx context.Context, note *models.Note) error {
+	return h.repos.Notes.SaveLeafletSyncState(ctx, &models.LeafletSyncState{
+		NoteID:      note.ID,
+		RKey:        *note.LeafletRKey,
+		BaseTitle:   note.Title,
+		BaseContent: note.Content,
+		Status:      models.LeafletSynced,
+	})
+}
+
+// checkLeafletSync refuses to overwrite a document whose pulled changes have not been resolved
+func (h *PublicationHandler) checkLeafletSync(ctx context.Context, noteID int64) error {
+	state, err := h.repos.Notes.GetLeafletSyncState(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return nil
+	}
+
+	switch state.Status {
+	case models.LeafletConflict:
+		return fmt.Errorf("note %d has unresolved changes from leaflet - run 'noteleaf pub resolve %d' first", noteID, noteID)
+	case models.LeafletDeleted:
+		return fmt.Errorf("document for note %d was deleted on leaflet - run 'noteleaf pub resolve %d' first", noteID, noteID)
+	}
+	return nil
+}
+
+// Resolve settles a conflict or remote deletion flagged by [PublicationHandler.Pull].
+//
+// For a conflict, ours keeps the note as is (the next patch overwrites leaflet),
+// theirs replaces it with the leaflet version, and merge combines both against
+// the last synced version. For a deleted document, ours keeps the note as an
+// unpublished note and theirs archives it.
+func (h *PublicationHandler) Resolve(ctx context.Context, noteID int64, strategy string) error {
+	note, err := h.repos.Notes.Get(ctx, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
+
+	state, err := h.repos.Notes.GetLeafletSyncState(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.Status == models.LeafletSynced {
+		return fmt.Errorf("note %d has no unresolved leaflet changes", noteID)
+	}
+
+	switch strategy {
+	case ResolveOurs, ResolveTheirs, ResolveMerge:
+	default:
+		return fmt.Errorf("invalid resolution: %s (must be 'ours', 'theirs', or 'merge')", strategy)
+	}
+
+	if state.Status == models.LeafletDeleted {
+		return h.resolveDeleted(ctx, note, strategy)
+	}
+	return h.resolveConflict(ctx, note, state, strategy)
+}
+
+func (h *PublicationHandler) resolveConflict(ctx context.Context, note *models.Note, state *models.LeafletSyncState, strategy string) error {
+	if note.Encrypted && strategy != ResolveOurs {
+		return fmt.Errorf("note is encrypted - decrypt it before taking the leaflet version")
+	}
+
+	switch strategy {
+	case ResolveTheirs:
+		note.Title = state.RemoteTitle
+		note.Content = state.RemoteContent
+	case ResolveMerge:
+		if note.Title == state.BaseTitle {
+			note.Title = state.RemoteTitle
+		}
+		merged, conflicted := utils.Merge3(state.BaseContent, note.Content, state.RemoteContent, "local", "leaflet")
+		note.Content = merged
+		if conflicted {
+			ui.Warningln("Both sides changed the same lines; edit note %d to settle the marked sections", note.ID)
+		}
+	}
+
+	cid := state.RemoteCID
+	note.LeafletCID = &cid
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	// The leaflet version becomes the base, so any local differences that remain show
+	// up as local edits waiting to be pushed.
+	if err := h.repos.Notes.SaveLeafletSyncState(ctx, &models.LeafletSyncState{
+		NoteID:      note.ID,
+		RKey:        state.RKey,
+		BaseTitle:   state.RemoteTitle,
+		BaseContent: state.RemoteContent,
+		Status:      models.LeafletSynced,
+	}); err != nil {
+		return err
+	}
+
+	switch strategy {
+	case ResolveOurs:
+		ui.Successln("Kept local version of note %d; run 'noteleaf pub patch %d' to update leaflet", note.ID, note.ID)
+	case ResolveTheirs:
+		ui.Successln("Note %d updated to the leaflet version", note.ID)
+	case ResolveMerge:
+		ui.Successln("Merged leaflet changes into note %d", note.ID)
+	}
+	return nil
+}
+
+func (h *PublicationHandler) resolveDeleted(ctx context.Context, note *models.Note, strategy string) error {
+	if strategy == ResolveMerge {
+		return fmt.Errorf("document was deleted on leaflet - use --ours to keep the note or --theirs to archive it")
+	}
+
+	note.LeafletRKey = nil
+	note.LeafletCID = nil
+	note.LeafletPublication = nil
+	note.PublishedAt = nil
+	note.IsDraft = false
+	if strategy == ResolveTheirs {
+		note.Archived = true
+	}
+
+	if err := h.repos.Notes.Update(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+	if err := h.repos.Notes.DeleteLeafletSyncState(ctx, note.ID); err != nil {
+		return err
+	}
+
+	if strategy == ResolveTheirs {
+		ui.Successln("Archived note %d", note.ID)
+	} else {
+		ui.Successln("Kept note %d as an unpublished note; run 'noteleaf pub post %d' to publish it again", note.ID, note.ID)
+	}
+	return nil
+}
+
+// SyncStatus reports leaflet notes that need attention: conflicts and deletions
+// found by the last pull, local edits not yet pushed, and queued operations
+func (h *PublicationHandler) SyncStatus(ctx context.Context) error {
+	states, err := h.repos.Notes.ListLeafletSyncStates(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.repos.Notes.ListLeafletOutbox(ctx)
+	if err != nil {
+		return err
+	}
+	var queued []string
+	isQueued := make(map[int64]bool, len(entries))
+	for _, entry := range entries {
+		isQueued[entry.NoteID] = true
+		line := fmt.Sprintf("[%d] %s", entry.NoteID, entry.Operation)
+		if entry.Failed {
+			line += " (failed: " + entry.LastError + ")"
+		}
+		queued = append(queued, line)
+	}
+
+	var conflicts, deleted, modified []string
+	for _, state := range states {
+		note, err := h.repos.Notes.Get(ctx, state.NoteID)
+		if err != nil {
+			return fmt.Errorf("failed to get note: %w", err)
+		}
+
+		line := fmt.Sprintf("[%d] %s", note.ID, note.Title)
+		switch state.Status {
+		case models.LeafletConflict:
+			conflicts = append(conflicts, line)
+		case models.LeafletDeleted:
+			deleted = append(deleted, line)
+		default:
+			if isQueued[note.ID] {
+				continue
+			}
+			if note.Title != state.BaseTitle || note.Content != state.BaseContent {
+				modified = append(modified, line)
+			}
+		}
+	}
+
+	if len(conflicts)+len(deleted)+len(modified)+len(queued) == 0 {
+		ui.Infoln("All publications are in sync.")
+		return nil
+	}
+
+	printSyncGroup("Conflicts (changed locally and on leaflet):", conflicts,
+		"Resolve with 'noteleaf pub resolve <id> --ours|--theirs|--merge'")
+	printSyncGroup("Deleted on leaflet:", deleted,
+		"Archive with 'noteleaf pub resolve <id> --theirs' or keep with '--ours'")
+	printSyncGroup("Modified locally:", modified,
+		"Update leaflet with 'noteleaf pub patch <id>'")
+	printSyncGroup("Queued for upload:", queued,
+		"Send with 'noteleaf pub queue flush' or discard with 'noteleaf pub queue drop <id>'")
+	return nil
+}
+
+func printSyncGroup(heading string, lines []string, hint string) {
+	if len(lines) == 0 {
+		return
+	}
+	ui.Infoln("%s", heading)
+	for _, line := range lines {
+		ui.Plainln("  %s", line)
+	}
+	ui.Infoln("  %s", hint)
+	ui.Newline()
+}
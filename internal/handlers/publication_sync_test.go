@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+// leafletDoc builds a pulled document with one text block per paragraph
+func leafletDoc(rkey, cid, title string, paragraphs ...string) services.DocumentWithMeta {
+	var blocks []public.BlockWrap
+	for _, p := range paragraphs {
+		blocks = append(blocks, public.BlockWrap{
+			Type:  "pub.leaflet.pages.linearDocument#block",
+			Block: public.TextBlock{Type: "pub.leaflet.pages.linearDocument#textBlock", Plaintext: p},
+		})
+	}
+	return services.DocumentWithMeta{
+		Document: public.Document{
+			Type:  public.TypeDocument,
+			Title: title,
+			Pages: []public.LinearDocument{{Type: public.TypeLinearDocument, Blocks: blocks}},
+		},
+		Meta: public.DocumentMeta{RKey: rkey, CID: cid, FetchedAt: time.Now()},
+	}
+}
+
+// newPullTestHandler returns a handler whose pulls return whatever docs currently holds
+func newPullTestHandler(t *testing.T, docs *[]services.DocumentWithMeta) *PublicationHandler {
+	t.Helper()
+	handler := CreateHandler(t, NewPublicationHandler)
+	mock := services.SetupSuccessfulPullMocks()
+	mock.PullDocumentsFunc = func(ctx context.Context) ([]services.DocumentWithMeta, error) {
+		return *docs, nil
+	}
+	handler.atproto = mock
+	return handler
+}
+
+func pulledNote(t *testing.T, handler *PublicationHandler, rkey string) *models.Note {
+	t.Helper()
+	note, err := handler.repos.Notes.GetByLeafletRKey(context.Background(), rkey)
+	if err != nil {
+		t.Fatalf("failed to find note for %s: %v", rkey, err)
+	}
+	return note
+}
+
+func syncStatus(t *testing.T, handler *PublicationHandler, noteID int64) string {
+	t.Helper()
+	state, err := handler.repos.Notes.GetLeafletSyncState(context.Background(), noteID)
+	if err != nil {
+		t.Fatalf("failed to get sync state: %v", err)
+	}
+	if state == nil {
+		return ""
+	}
+	return state.Status
+}
+
+func TestPublicationPullSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("updates unchanged notes in place", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		docs := []services.DocumentWithMeta{leafletDoc("rk1", "cid1", "First", "one")}
+		handler := newPullTestHandler(t, &docs)
+
+		suite.AssertNoError(handler.Pull(ctx), "first pull")
+		original := pulledNote(t, handler, "rk1")
+
+		docs = []services.DocumentWithMeta{
+			leafletDoc("rk1", "cid2", "First (edited)", "one", "two"),
+			leafletDoc("rk2", "cid3", "Second", "hello"),
+		}
+		suite.AssertNoError(handler.Pull(ctx), "second pull")
+
+		updated := pulledNote(t, handler, "rk1")
+		if updated.ID != original.ID {
+			t.Errorf("expected note %d to be updated in place, got new note %d", original.ID, updated.ID)
+		}
+		if updated.Title != "First (edited)" || !strings.Contains(updated.Content, "two") {
+			t.Errorf("expected remote changes to be applied, got %q / %q", updated.Title, updated.Content)
+		}
+		if *updated.LeafletCID != "cid2" {
+			t.Errorf("expected CID to advance, got %s", *updated.LeafletCID)
+		}
+
+		notes, err := handler.repos.Notes.GetLeafletNotes(ctx)
+		suite.AssertNoError(err, "list leaflet notes")
+		if len(notes) != 2 {
+			t.Errorf("expected 2 notes, got %d", len(notes))
+		}
+	})
+
+	t.Run("keeps local edits when leaflet is unchanged", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		docs := []services.DocumentWithMeta{leafletDoc("rk1", "cid1", "First", "one")}
+		handler := newPullTestHandler(t, &docs)
+		suite.AssertNoError(handler.Pull(ctx), "first pull")
+
+		note := pulledNote(t, handler, "rk1")
+		note.Content = "local edit\n"
+		suite.AssertNoError(handler.repos.Notes.Update(ctx, note), "edit note")
+
+		suite.AssertNoError(handler.Pull(ctx), "second pull")
+		if got := pulledNote(t, handler, "rk1").Content; got != "local edit\n" {
+			t.Errorf("expected local edit to survive, got %q", got)
+		}
+		if status := syncStatus(t, handler, note.ID); status != models.LeafletSynced {
+			t.Errorf("expected synced status, got %q", status)
+		}
+	})
+
+	t.Run("flags conflicts and resolves them", func(t *testing.T) {
+		setup := func(t *testing.T) (*PublicationHandler, *models.Note, *[]services.DocumentWithMeta) {
+			docs := []services.DocumentWithMeta{leafletDoc("rk1", "cid1", "First", "alpha", "beta", "gamma")}
+			handler := newPullTestHandler(t, &docs)
+			if err := handler.Pull(ctx); err != nil {
+				t.Fatalf("first pull: %v", err)
+			}
+
+			note := pulledNote(t, handler, "rk1")
+			note.Content = strings.Replace(note.Content, "alpha", "local alpha", 1)
+			if err := handler.repos.Notes.Update(ctx, note); err != nil {
+				t.Fatalf("edit note: %v", err)
+			}
+
+			docs = []services.DocumentWithMeta{leafletDoc("rk1", "cid2", "First", "alpha", "beta", "remote gamma")}
+			if err := handler.Pull(ctx); err != nil {
+				t.Fatalf("second pull: %v", err)
+			}
+			return handler, note, &docs
+		}
+
+		t.Run("pull leaves the note untouched", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note, _ := setup(t)
+			got := pulledNote(t, handler, "rk1")
+			if got.Content != note.Content || *got.LeafletCID != "cid1" {
+				t.Errorf("expected note to keep local content and base CID, got %q (%s)", got.Content, *got.LeafletCID)
+			}
+			if status := syncStatus(t, handler, note.ID); status != models.LeafletConflict {
+				t.Errorf("expected conflict status, got %q", status)
+			}
+
+			err := handler.Patch(ctx, note.ID)
+			if err == nil || !strings.Contains(err.Error(), "unresolved changes") {
+				t.Errorf("expected patch to refuse an unresolved conflict, got %v", err)
+			}
+			suite.AssertNoError(handler.SyncStatus(ctx), "sync status")
+		})
+
+		t.Run("merge", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note, _ := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveMerge), "resolve merge")
+
+			got := pulledNote(t, handler, "rk1")
+			if !strings.Contains(got.Content, "local alpha") || !strings.Contains(got.Content, "remote gamma") {
+				t.Errorf("expected both changes to be merged, got %q", got.Content)
+			}
+			if strings.Contains(got.Content, "<<<<<<<") {
+				t.Errorf("expected a clean merge, got %q", got.Content)
+			}
+			if *got.LeafletCID != "cid2" {
+				t.Errorf("expected CID to advance, got %s", *got.LeafletCID)
+			}
+			if status := syncStatus(t, handler, note.ID); status != models.LeafletSynced {
+				t.Errorf("expected synced status, got %q", status)
+			}
+		})
+
+		t.Run("theirs", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note, _ := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveTheirs), "resolve theirs")
+
+			got := pulledNote(t, handler, "rk1")
+			if strings.Contains(got.Content, "local alpha") || !strings.Contains(got.Content, "remote gamma") {
+				t.Errorf("expected leaflet version, got %q", got.Content)
+			}
+		})
+
+		t.Run("ours", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note, docs := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveOurs), "resolve ours")
+
+			got := pulledNote(t, handler, "rk1")
+			if got.Content != note.Content || *got.LeafletCID != "cid2" {
+				t.Errorf("expected local content at the remote CID, got %q (%s)", got.Content, *got.LeafletCID)
+			}
+
+			suite.AssertNoError(handler.Pull(ctx), "pull after resolve")
+			if got := pulledNote(t, handler, "rk1"); got.Content != note.Content {
+				t.Errorf("expected local version to survive the next pull, got %q", got.Content)
+			}
+
+			*docs = []services.DocumentWithMeta{leafletDoc("rk1", "cid3", "First", "another remote edit")}
+			suite.AssertNoError(handler.Pull(ctx), "pull with new remote edit")
+			if status := syncStatus(t, handler, note.ID); status != models.LeafletConflict {
+				t.Errorf("expected unpushed local version to conflict again, got %q", status)
+			}
+		})
+
+		t.Run("rejects notes without conflicts", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note, _ := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveTheirs), "resolve theirs")
+
+			if err := handler.Resolve(ctx, note.ID, ResolveTheirs); err == nil {
+				t.Error("expected resolving a synced note to fail")
+			}
+		})
+	})
+
+	t.Run("flags remote deletions", func(t *testing.T) {
+		setup := func(t *testing.T) (*PublicationHandler, *models.Note) {
+			docs := []services.DocumentWithMeta{leafletDoc("rk1", "cid1", "Gone", "text")}
+			handler := newPullTestHandler(t, &docs)
+			if err := handler.Pull(ctx); err != nil {
+				t.Fatalf("first pull: %v", err)
+			}
+			note := pulledNote(t, handler, "rk1")
+
+			docs = nil
+			if err := handler.Pull(ctx); err != nil {
+				t.Fatalf("second pull: %v", err)
+			}
+			return handler, note
+		}
+
+		t.Run("keeps the note", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note := setup(t)
+			got, err := handler.repos.Notes.Get(ctx, note.ID)
+			suite.AssertNoError(err, "note should still exist")
+			if got.Archived {
+				t.Error("expected note not to be archived automatically")
+			}
+			if status := syncStatus(t, handler, note.ID); status != models.LeafletDeleted {
+				t.Errorf("expected deleted status, got %q", status)
+			}
+			if err := handler.Resolve(ctx, note.ID, ResolveMerge); err == nil {
+				t.Error("expected merge to be rejected for a deleted document")
+			}
+		})
+
+		t.Run("theirs archives", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveTheirs), "resolve theirs")
+
+			got, err := handler.repos.Notes.Get(ctx, note.ID)
+			suite.AssertNoError(err, "get note")
+			if !got.Archived || got.HasLeafletAssociation() {
+				t.Errorf("expected archived note without leaflet association, got archived=%v", got.Archived)
+			}
+			if status := syncStatus(t, handler, note.ID); status != "" {
+				t.Errorf("expected sync state to be cleared, got %q", status)
+			}
+		})
+
+		t.Run("ours unlinks", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler, note := setup(t)
+			suite.AssertNoError(handler.Resolve(ctx, note.ID, ResolveOurs), "resolve ours")
+
+			got, err := handler.repos.Notes.Get(ctx, note.ID)
+			suite.AssertNoError(err, "get note")
+			if got.Archived || got.HasLeafletAssociation() {
+				t.Errorf("expected active note without leaflet association, got archived=%v", got.Archived)
+			}
+		})
+	})
+}
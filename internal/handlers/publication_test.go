@@ -3,6 +3,9 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -130,6 +133,39 @@ func TestPublicationHandler(t *testing.T) {
 				t.Error("Expected ExpiresAt to be in the past when parse fails")
 			}
 		})
+
+		t.Run("round trips OAuth sessions", func(t *testing.T) {
+			session := &services.Session{
+				DID:        "did:plc:test123",
+				Handle:     "test.bsky.social",
+				AccessJWT:  "access_token",
+				RefreshJWT: "refresh_token",
+				PDSURL:     "https://pds.example",
+				ExpiresAt:  time.Now().Add(time.Hour).Truncate(time.Second),
+				OAuth: &services.OAuthSession{
+					Issuer:        "https://auth.example",
+					TokenEndpoint: "https://auth.example/oauth/token",
+					ClientID:      "http://localhost",
+					DPoPKey:       "key",
+				},
+			}
+
+			config := &store.Config{}
+			sessionToConfig(config, session)
+			restored, err := sessionFromConfig(config)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if restored.OAuth == nil || *restored.OAuth != *session.OAuth {
+				t.Errorf("Expected OAuth details to round trip, got %+v", restored.OAuth)
+			}
+
+			session.OAuth = nil
+			sessionToConfig(config, session)
+			if config.ATProtoDPoPKey != "" || config.ATProtoOAuthIssuer != "" {
+				t.Error("Expected app password login to clear stored OAuth details")
+			}
+		})
 	})
 
 	t.Run("Auth", func(t *testing.T) {
@@ -149,6 +185,27 @@ func TestPublicationHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("saves OAuth session", func(t *testing.T) {
+			_ = NewHandlerTestSuite(t)
+			handler := CreateHandler(t, NewPublicationHandler)
+			handler.atproto = services.NewMockATProtoService()
+
+			if err := handler.AuthOAuth(context.Background(), "test.bsky.social"); err != nil {
+				t.Fatalf("AuthOAuth failed: %v", err)
+			}
+
+			config, err := store.LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if err := store.LoadSecrets(config); err != nil {
+				t.Fatalf("Failed to load secrets: %v", err)
+			}
+			if config.ATProtoHandle != "test.bsky.social" || config.ATProtoDPoPKey == "" || config.ATProtoOAuthTokenEndpoint == "" {
+				t.Errorf("Expected OAuth session to be saved, got %+v", config)
+			}
+		})
+
 	})
 
 	t.Run("GetAuthStatus", func(t *testing.T) {
@@ -292,6 +349,15 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to save config: %v", err)
 			}
 
+			configPath, _ := store.GetConfigPath()
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("Failed to read config: %v", err)
+			}
+			if strings.Contains(string(data), "access_token") || strings.Contains(string(data), "refresh_token") {
+				t.Errorf("Expected tokens to be kept out of the config file, got:\n%s", data)
+			}
+
 			handler, err := NewPublicationHandler()
 			if err != nil {
 				t.Fatalf("Expected no error creating handler, got %v", err)
@@ -688,7 +754,7 @@ func TestPublicationHandler(t *testing.T) {
 			handler := CreateHandler(t, NewPublicationHandler)
 			ctx := context.Background()
 
-			err := handler.Post(ctx, 1, false)
+			err := handler.Post(ctx, 1, false, "")
 			if err == nil {
 				t.Error("Expected error when not authenticated")
 			}
@@ -720,7 +786,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Post(ctx, 999, false)
+			err = handler.Post(ctx, 999, false, "")
 			if err == nil {
 				t.Error("Expected error when note does not exist")
 			}
@@ -764,7 +830,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Post(ctx, id, false)
+			err = handler.Post(ctx, id, false, "")
 			if err == nil {
 				t.Error("Expected error when note already published")
 			}
@@ -774,6 +840,43 @@ func TestPublicationHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("returns error when note is encrypted", func(t *testing.T) {
+			suite := NewHandlerTestSuite(t)
+			defer suite.Cleanup()
+
+			handler := CreateHandler(t, NewPublicationHandler)
+			ctx := context.Background()
+
+			note := &models.Note{
+				Title:     "Locked",
+				Content:   "-----BEGIN AGE ENCRYPTED FILE-----\n...\n-----END AGE ENCRYPTED FILE-----\n",
+				Encrypted: true,
+			}
+
+			id, err := handler.repos.Notes.Create(ctx, note)
+			suite.AssertNoError(err, "create note")
+
+			session := &services.Session{
+				DID:           "did:plc:test123",
+				Handle:        "test.bsky.social",
+				AccessJWT:     "access_token",
+				RefreshJWT:    "refresh_token",
+				PDSURL:        "https://bsky.social",
+				ExpiresAt:     time.Now().Add(2 * time.Hour),
+				Authenticated: true,
+			}
+
+			err = handler.atproto.RestoreSession(session)
+			if err != nil {
+				t.Fatalf("Failed to restore session: %v", err)
+			}
+
+			err = handler.Post(ctx, id, false, "")
+			if err == nil || !strings.Contains(err.Error(), "encrypted") {
+				t.Errorf("Expected encrypted note error, got '%v'", err)
+			}
+		})
+
 		t.Run("handles markdown conversion errors", func(t *testing.T) {
 			suite := NewHandlerTestSuite(t)
 			defer suite.Cleanup()
@@ -804,7 +907,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Post(ctx, id, false)
+			err = handler.Post(ctx, id, false, "")
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				if !strings.Contains(err.Error(), "failed to post document") && !strings.Contains(err.Error(), "failed to get session") {
 					t.Logf("Got expected error during post: %v", err)
@@ -842,7 +945,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Post(ctx, id, true)
+			err = handler.Post(ctx, id, true, "")
 
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				t.Logf("Got error during post (expected for external service call): %v", err)
@@ -1076,7 +1179,7 @@ func TestPublicationHandler(t *testing.T) {
 			handler := CreateHandler(t, NewPublicationHandler)
 			ctx := context.Background()
 
-			err := handler.PostPreview(ctx, 1, false, "", false)
+			err := handler.PostPreview(ctx, 1, false, "", "", false)
 			if err == nil {
 				t.Error("Expected error when not authenticated")
 			}
@@ -1108,7 +1211,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.PostPreview(ctx, 999, false, "", false)
+			err = handler.PostPreview(ctx, 999, false, "", "", false)
 			if err == nil {
 				t.Error("Expected error when note does not exist")
 			}
@@ -1152,7 +1255,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.PostPreview(ctx, id, false, "", false)
+			err = handler.PostPreview(ctx, id, false, "", "", false)
 			if err == nil {
 				t.Error("Expected error when note already published")
 			}
@@ -1190,7 +1293,7 @@ func TestPublicationHandler(t *testing.T) {
 			}
 			handler.atproto = mock
 
-			err = handler.PostPreview(ctx, id, false, "", false)
+			err = handler.PostPreview(ctx, id, false, "", "", false)
 			suite.AssertNoError(err, "preview should succeed")
 		})
 
@@ -1222,7 +1325,7 @@ func TestPublicationHandler(t *testing.T) {
 			}
 			handler.atproto = mock
 
-			err = handler.PostPreview(ctx, id, true, "", false)
+			err = handler.PostPreview(ctx, id, true, "", "", false)
 			suite.AssertNoError(err, "preview draft should succeed")
 		})
 	})
@@ -1235,7 +1338,7 @@ func TestPublicationHandler(t *testing.T) {
 			handler := CreateHandler(t, NewPublicationHandler)
 			ctx := context.Background()
 
-			err := handler.PostValidate(ctx, 1, false, "", false)
+			err := handler.PostValidate(ctx, 1, false, "", "", false)
 			if err == nil {
 				t.Error("Expected error when not authenticated")
 			}
@@ -1273,7 +1376,7 @@ func TestPublicationHandler(t *testing.T) {
 			}
 			handler.atproto = mock
 
-			err = handler.PostValidate(ctx, id, false, "", false)
+			err = handler.PostValidate(ctx, id, false, "", "", false)
 			suite.AssertNoError(err, "validation should succeed")
 		})
 	})
@@ -1662,12 +1765,19 @@ func TestPublicationHandler(t *testing.T) {
 			id, err := handler.repos.Notes.Create(ctx, note)
 			suite.AssertNoError(err, "create note")
 
+			pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"InvalidToken","message":"Bad token"}`))
+			}))
+			defer pds.Close()
+
 			session := &services.Session{
 				DID:           "did:plc:test123",
 				Handle:        "test.bsky.social",
 				AccessJWT:     "access_token",
 				RefreshJWT:    "refresh_token",
-				PDSURL:        "https://bsky.social",
+				PDSURL:        pds.URL,
 				ExpiresAt:     time.Now().Add(2 * time.Hour),
 				Authenticated: true,
 			}
@@ -1709,7 +1819,7 @@ func TestPublicationHandler(t *testing.T) {
 			handler := CreateHandler(t, NewPublicationHandler)
 			ctx := context.Background()
 
-			err := handler.Push(ctx, []int64{1, 2, 3}, false, false)
+			err := handler.Push(ctx, []int64{1, 2, 3}, false, "", false)
 			if err == nil {
 				t.Error("Expected error when not authenticated")
 			}
@@ -1741,7 +1851,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{}, false, false)
+			err = handler.Push(ctx, []int64{}, false, "", false)
 			if err == nil {
 				t.Error("Expected error when no note IDs provided")
 			}
@@ -1773,7 +1883,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{999}, false, false)
+			err = handler.Push(ctx, []int64{999}, false, "", false)
 			if err == nil {
 				t.Error("Expected error when note not found")
 			}
@@ -1820,7 +1930,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{id1, id2}, false, false)
+			err = handler.Push(ctx, []int64{id1, id2}, false, "", false)
 
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				t.Logf("Got error during push (expected for external service call): %v", err)
@@ -1877,7 +1987,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{id1, id2}, false, false)
+			err = handler.Push(ctx, []int64{id1, id2}, false, "", false)
 
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				t.Logf("Got error during push (expected for external service call): %v", err)
@@ -1929,7 +2039,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{newID, existingID}, false, false)
+			err = handler.Push(ctx, []int64{newID, existingID}, false, "", false)
 
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				t.Logf("Got error during push (expected for external service call): %v", err)
@@ -1967,7 +2077,7 @@ func TestPublicationHandler(t *testing.T) {
 			}
 
 			invalidID := int64(999)
-			err = handler.Push(ctx, []int64{id1, invalidID}, false, false)
+			err = handler.Push(ctx, []int64{id1, invalidID}, false, "", false)
 
 			if err == nil {
 				t.Error("Expected error due to invalid note ID")
@@ -2008,7 +2118,7 @@ func TestPublicationHandler(t *testing.T) {
 				t.Fatalf("Failed to restore session: %v", err)
 			}
 
-			err = handler.Push(ctx, []int64{id}, true, false)
+			err = handler.Push(ctx, []int64{id}, true, "", false)
 
 			if err != nil && !strings.Contains(err.Error(), "not authenticated") {
 				t.Logf("Got error during push (expected for external service call): %v", err)
@@ -2180,7 +2290,7 @@ func TestPublicationHandler(t *testing.T) {
 		id, err := handler.repos.Notes.Create(ctx, note)
 		suite.AssertNoError(err, "create note should succeed")
 
-		err = handler.Post(ctx, id, false)
+		err = handler.Post(ctx, id, false, "")
 		suite.AssertNoError(err, "post should succeed")
 
 		updatedNote, err := handler.repos.Notes.Get(ctx, id)
@@ -2231,7 +2341,7 @@ func TestPublicationHandler(t *testing.T) {
 		id, err := handler.repos.Notes.Create(ctx, note)
 		suite.AssertNoError(err, "create note should succeed")
 
-		err = handler.Post(ctx, id, true)
+		err = handler.Post(ctx, id, true, "")
 		suite.AssertNoError(err, "post draft should succeed")
 
 		updatedNote, err := handler.repos.Notes.Get(ctx, id)
@@ -2350,3 +2460,142 @@ func TestPublicationHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestPublicationDraftStatus(t *testing.T) {
+	ctx := context.Background()
+
+	newHandler := func(t *testing.T) (*PublicationHandler, *services.MockATProtoService) {
+		handler := CreateHandler(t, NewPublicationHandler)
+		mock := services.SetupSuccessfulPullMocks()
+		handler.atproto = mock
+		return handler, mock
+	}
+
+	createLeafletNote := func(t *testing.T, handler *PublicationHandler, isDraft bool) int64 {
+		rkey, cid := "draft_rkey", "draft_cid"
+		note := &models.Note{Title: "Status Note", Content: "# Status\n\nBody", LeafletRKey: &rkey, LeafletCID: &cid, IsDraft: isDraft}
+		if !isDraft {
+			publishedAt := time.Now().Add(-time.Hour)
+			note.PublishedAt = &publishedAt
+		}
+		id, err := handler.repos.Notes.Create(ctx, note)
+		if err != nil {
+			t.Fatalf("failed to create note: %v", err)
+		}
+		return id
+	}
+
+	t.Run("publishes a draft under the same rkey", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newHandler(t)
+		var movedToDraft *bool
+		mock.MoveDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*services.DocumentWithMeta, error) {
+			movedToDraft = &toDraft
+			if doc.PublishedAt == "" {
+				t.Error("expected published document to carry publishedAt")
+			}
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: rkey, CID: "published_cid"}}, nil
+		}
+
+		id := createLeafletNote(t, handler, true)
+		suite.AssertNoError(handler.Publish(ctx, id), "publish")
+
+		if movedToDraft == nil || *movedToDraft {
+			t.Fatal("expected document to be moved to the published collection")
+		}
+		note, err := handler.repos.Notes.Get(ctx, id)
+		suite.AssertNoError(err, "get note")
+		if note.IsDraft || note.PublishedAt == nil {
+			t.Errorf("expected note to be published, got draft=%v publishedAt=%v", note.IsDraft, note.PublishedAt)
+		}
+		if *note.LeafletRKey != "draft_rkey" || *note.LeafletCID != "published_cid" {
+			t.Errorf("unexpected leaflet keys %s / %s", *note.LeafletRKey, *note.LeafletCID)
+		}
+
+		published, err := handler.repos.Notes.ListPublished(ctx)
+		suite.AssertNoError(err, "list published")
+		if len(published) != 1 {
+			t.Errorf("expected 1 published note, got %d", len(published))
+		}
+	})
+
+	t.Run("unpublishes and records a new rkey", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newHandler(t)
+		mock.MoveDocumentFunc = func(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*services.DocumentWithMeta, error) {
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: "new_rkey", CID: "new_cid", IsDraft: toDraft}}, nil
+		}
+
+		id := createLeafletNote(t, handler, false)
+		suite.AssertNoError(handler.Unpublish(ctx, id), "unpublish")
+
+		note, err := handler.repos.Notes.Get(ctx, id)
+		suite.AssertNoError(err, "get note")
+		if !note.IsDraft || note.PublishedAt != nil {
+			t.Errorf("expected note to be a draft, got draft=%v publishedAt=%v", note.IsDraft, note.PublishedAt)
+		}
+		if *note.LeafletRKey != "new_rkey" {
+			t.Errorf("expected rkey to follow the moved record, got %s", *note.LeafletRKey)
+		}
+
+		drafts, err := handler.repos.Notes.ListDrafts(ctx)
+		suite.AssertNoError(err, "list drafts")
+		if len(drafts) != 1 {
+			t.Errorf("expected 1 draft, got %d", len(drafts))
+		}
+	})
+
+	t.Run("rejects invalid transitions", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newHandler(t)
+		draftID := createLeafletNote(t, handler, true)
+		if err := handler.Unpublish(ctx, draftID); err == nil || !strings.Contains(err.Error(), "already a draft") {
+			t.Errorf("expected already a draft error, got %v", err)
+		}
+
+		localID, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "Local", Content: "text"})
+		suite.AssertNoError(err, "create local note")
+		if err := handler.Publish(ctx, localID); err == nil || !strings.Contains(err.Error(), "not on leaflet") {
+			t.Errorf("expected not on leaflet error, got %v", err)
+		}
+	})
+
+	t.Run("returns error when not authenticated", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler := CreateHandler(t, NewPublicationHandler)
+		if err := handler.Publish(ctx, 1); err == nil || !strings.Contains(err.Error(), "not authenticated") {
+			t.Errorf("expected not authenticated error, got %v", err)
+		}
+	})
+
+	t.Run("pull brings drafts down as drafts", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		draft := leafletDoc("rk_draft", "cid_draft", "A Draft", "wip")
+		draft.Meta.IsDraft = true
+		docs := []services.DocumentWithMeta{leafletDoc("rk_pub", "cid_pub", "Published", "done"), draft}
+		handler := newPullTestHandler(t, &docs)
+
+		suite.AssertNoError(handler.Pull(ctx), "pull")
+
+		drafts, err := handler.repos.Notes.ListDrafts(ctx)
+		suite.AssertNoError(err, "list drafts")
+		if len(drafts) != 1 || drafts[0].Title != "A Draft" {
+			t.Errorf("expected the pulled draft, got %d drafts", len(drafts))
+		}
+		published, err := handler.repos.Notes.ListPublished(ctx)
+		suite.AssertNoError(err, "list published")
+		if len(published) != 1 || published[0].Title != "Published" {
+			t.Errorf("expected the published document, got %d", len(published))
+		}
+	})
+}
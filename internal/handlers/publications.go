@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+	"github.com/stormlightlabs/noteleaf/internal/ui"
+)
+
+// PublicationOptions holds the fields of a publication to create or update.
+//
+// Empty fields are left unchanged by [PublicationHandler.UpdatePublication].
+type PublicationOptions struct {
+	Name        string
+	Description string
+	BasePath    string
+	IconPath    string // local image uploaded as the publication icon
+}
+
+// findPublication returns the publication matching ref by AT URI, rkey or case-insensitive name
+func findPublication(pubs []services.PublicationWithMeta, ref string) (*services.PublicationWithMeta, error) {
+	var matches []*services.PublicationWithMeta
+	for i := range pubs {
+		p := &pubs[i]
+		if p.URI == ref || p.RKey == ref {
+			return p, nil
+		}
+		if strings.EqualFold(p.Publication.Name, ref) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("publication %q not found - run 'noteleaf pub publications list'", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("more than one publication is named %q - use its rkey instead", ref)
+	}
+}
+
+// publicationFor returns the AT URI of the publication a note's document belongs to.
+//
+// An explicit choice wins, then the note's own publication, then a routing rule for
+// one of its tags, then the configured default, and finally the first publication on
+// the account. Notes patched before publications were tracked keep using the first
+// publication, which is where they were posted.
+func (h *PublicationHandler) publicationFor(ctx context.Context, note *models.Note, explicit string, forPatch bool) (string, error) {
+	ref := explicit
+	if ref == "" && note.LeafletPublication != nil {
+		ref = *note.LeafletPublication
+	}
+	if ref == "" && forPatch {
+		return h.atproto.GetDefaultPublication(ctx)
+	}
+	if ref == "" {
+		var err error
+		if ref, err = h.routeByTags(note); err != nil {
+			return "", err
+		}
+	}
+	if ref == "" && h.config != nil {
+		ref = h.config.LeafletPublication
+	}
+
+	if ref == "" {
+		return h.atproto.GetDefaultPublication(ctx)
+	}
+	if strings.HasPrefix(ref, "at://") {
+		return ref, nil
+	}
+
+	pubs, err := h.atproto.ListPublications(ctx)
+	if err != nil {
+		return "", err
+	}
+	pub, err := findPublication(pubs, ref)
+	if err != nil {
+		return "", err
+	}
+	return pub.URI, nil
+}
+
+// routeByTags returns the publication configured for the note's tags, if any
+func (h *PublicationHandler) routeByTags(note *models.Note) (string, error) {
+	if h.config == nil || len(h.config.LeafletPublicationRoutes) == 0 {
+		return "", nil
+	}
+
+	var routes []string
+	for _, tag := range note.Tags {
+		ref, ok := h.config.LeafletPublicationRoutes[tag]
+		if ok && !slices.Contains(routes, ref) {
+			routes = append(routes, ref)
+		}
+	}
+
+	if len(routes) > 1 {
+		return "", fmt.Errorf("note tags route to more than one publication (%s) - choose one with --publication", strings.Join(routes, ", "))
+	}
+	if len(routes) == 1 {
+		return routes[0], nil
+	}
+	return "", nil
+}
+
+// ListPublications displays the publications on the account with their local document counts
+func (h *PublicationHandler) ListPublications(ctx context.Context) error {
+	if !h.atproto.IsAuthenticated() {
+		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
+	}
+
+	pubs, err := h.atproto.ListPublications(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list publications: %w", err)
+	}
+
+	if len(pubs) == 0 {
+		ui.Infoln("No publications found. Create one with 'noteleaf pub publications create <name>'.")
+		return nil
+	}
+
+	notes, err := h.repos.Notes.GetLeafletNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch leaflet notes: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, note := range notes {
+		if note.LeafletPublication != nil {
+			counts[*note.LeafletPublication]++
+		}
+	}
+
+	var defaultURI string
+	if h.config != nil && h.config.LeafletPublication != "" {
+		if pub, err := findPublication(pubs, h.config.LeafletPublication); err == nil {
+			defaultURI = pub.URI
+		}
+	}
+	if defaultURI == "" {
+		defaultURI = pubs[0].URI
+	}
+
+	ui.Infoln("Found %d publication(s):", len(pubs))
+	ui.Newline()
+	for _, pub := range pubs {
+		marker := ""
+		if pub.URI == defaultURI {
+			marker = " (default)"
+		}
+		ui.Infoln("%s%s", pub.Publication.Name, marker)
+		ui.Infoln("    rkey: %s", pub.RKey)
+		if pub.Publication.BasePath != "" {
+			ui.Infoln("    base path: %s", pub.Publication.BasePath)
+		}
+		if pub.Publication.Description != "" {
+			ui.Infoln("    description: %s", pub.Publication.Description)
+		}
+		ui.Infoln("    documents: %d", counts[pub.URI])
+		ui.Newline()
+	}
+	return nil
+}
+
+// CreatePublication creates a new publication on leaflet
+func (h *PublicationHandler) CreatePublication(ctx context.Context, opts PublicationOptions) error {
+	if !h.atproto.IsAuthenticated() {
+		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
+	}
+
+	if opts.Name == "" {
+		return fmt.Errorf("publication name is required")
+	}
+
+	pub, err := h.publicationFromOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.atproto.CreatePublication(ctx, pub)
+	if err != nil {
+		return fmt.Errorf("failed to create publication: %w", err)
+	}
+
+	ui.Successln("Publication '%s' created", result.Publication.Name)
+	ui.Infoln("  RKey: %s", result.RKey)
+	ui.Infoln("  URI: %s", result.URI)
+	return nil
+}
+
+// UpdatePublication changes the fields of an existing publication identified by name, rkey or AT URI
+func (h *PublicationHandler) UpdatePublication(ctx context.Context, ref string, opts PublicationOptions) error {
+	if !h.atproto.IsAuthenticated() {
+		return fmt.Errorf("not authenticated - run 'noteleaf pub auth' first")
+	}
+
+	if opts == (PublicationOptions{}) {
+		return fmt.Errorf("nothing to update - set --name, --description, --base-path or --icon")
+	}
+
+	pubs, err := h.atproto.ListPublications(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list publications: %w", err)
+	}
+	existing, err := findPublication(pubs, ref)
+	if err != nil {
+		return err
+	}
+
+	changes, err := h.publicationFromOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.atproto.UpdatePublication(ctx, existing.RKey, changes)
+	if err != nil {
+		return fmt.Errorf("failed to update publication: %w", err)
+	}
+
+	ui.Successln("Publication '%s' updated", result.Publication.Name)
+	return nil
+}
+
+// publicationFromOptions builds a publication record, uploading the icon if one is given
+func (h *PublicationHandler) publicationFromOptions(ctx context.Context, opts PublicationOptions) (public.Publication, error) {
+	pub := public.Publication{
+		Type:        public.TypePublication,
+		Name:        opts.Name,
+		Description: opts.Description,
+		BasePath:    opts.BasePath,
+	}
+
+	if opts.IconPath == "" {
+		return pub, nil
+	}
+
+	data, err := os.ReadFile(opts.IconPath)
+	if err != nil {
+		return pub, fmt.Errorf("failed to read icon: %w", err)
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return pub, fmt.Errorf("icon must be an image, got %s", mimeType)
+	}
+
+	blob, err := h.atproto.UploadBlob(ctx, data, mimeType)
+	if err != nil {
+		return pub, fmt.Errorf("failed to upload icon: %w", err)
+	}
+	pub.Icon = &blob
+	return pub, nil
+}
+
+// publicationNames maps publication URIs to names for display, or nil when they cannot be fetched
+func (h *PublicationHandler) publicationNames(ctx context.Context) map[string]string {
+	if !h.atproto.IsAuthenticated() {
+		return nil
+	}
+	pubs, err := h.atproto.ListPublications(ctx)
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]string, len(pubs))
+	for _, pub := range pubs {
+		names[pub.URI] = pub.Publication.Name
+	}
+	return names
+}
+
+// groupByPublication splits notes by publication URI, in order of first appearance with
+// notes that have no recorded publication last
+func groupByPublication(notes []*models.Note) ([]string, map[string][]*models.Note) {
+	groups := make(map[string][]*models.Note)
+	var order []string
+	for _, note := range notes {
+		key := ""
+		if note.LeafletPublication != nil {
+			key = *note.LeafletPublication
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], note)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i] != "" && order[j] == "" })
+	return order, groups
+}
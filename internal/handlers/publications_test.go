@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/public"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+const (
+	blogURI   = "at://did:plc:test123/pub.leaflet.publication/blog_rkey"
+	gamesURI  = "at://did:plc:test123/pub.leaflet.publication/games_rkey"
+	pngHeader = "\x89PNG\r\n\x1a\n"
+)
+
+func testPublications() []services.PublicationWithMeta {
+	return []services.PublicationWithMeta{
+		{Publication: public.Publication{Type: public.TypePublication, Name: "Blog"}, RKey: "blog_rkey", URI: blogURI},
+		{Publication: public.Publication{Type: public.TypePublication, Name: "Game Dev"}, RKey: "games_rkey", URI: gamesURI},
+	}
+}
+
+// newPublicationsTestHandler returns an authenticated handler whose account has the test publications
+func newPublicationsTestHandler(t *testing.T) (*PublicationHandler, *services.MockATProtoService) {
+	t.Helper()
+	handler := CreateHandler(t, NewPublicationHandler)
+	mock := services.NewMockATProtoService()
+	mock.IsAuthenticatedVal = true
+	mock.Session = &services.Session{DID: "did:plc:test123", Handle: "test.bsky.social", Authenticated: true}
+	mock.ListPublicationsFunc = func(ctx context.Context) ([]services.PublicationWithMeta, error) {
+		return testPublications(), nil
+	}
+	handler.atproto = mock
+	handler.config.LeafletPublication = ""
+	handler.config.LeafletPublicationRoutes = nil
+	return handler, mock
+}
+
+func TestFindPublication(t *testing.T) {
+	pubs := testPublications()
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{"by URI", gamesURI, gamesURI},
+		{"by rkey", "blog_rkey", blogURI},
+		{"by name", "Game Dev", gamesURI},
+		{"by name ignoring case", "game dev", gamesURI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := findPublication(pubs, tt.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pub.URI != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, pub.URI)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := findPublication(pubs, "Poetry"); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("ambiguous name", func(t *testing.T) {
+		dup := append(testPublications(), services.PublicationWithMeta{
+			Publication: public.Publication{Name: "blog"}, RKey: "other_rkey", URI: "at://x/pub.leaflet.publication/other_rkey",
+		})
+		if _, err := findPublication(dup, "Blog"); err == nil || !strings.Contains(err.Error(), "more than one") {
+			t.Errorf("expected ambiguity error, got %v", err)
+		}
+	})
+}
+
+func TestPublicationRouting(t *testing.T) {
+	ctx := context.Background()
+	games := gamesURI
+
+	t.Run("precedence", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			note     models.Note
+			explicit string
+			config   string
+			routes   map[string]string
+			forPatch bool
+			want     string
+		}{
+			{name: "first publication by default", want: "at://did:plc:test123/pub.leaflet.publication/mock_pub_rkey"},
+			{name: "config default", config: "Game Dev", want: gamesURI},
+			{name: "tag route beats config", note: models.Note{Tags: []string{"gamedev"}}, config: "Blog", routes: map[string]string{"gamedev": "games_rkey"}, want: gamesURI},
+			{name: "note beats tag route", note: models.Note{Tags: []string{"gamedev"}, LeafletPublication: &games}, routes: map[string]string{"gamedev": "Blog"}, want: gamesURI},
+			{name: "explicit beats note", note: models.Note{LeafletPublication: &games}, explicit: "Blog", want: blogURI},
+			{name: "patch without recorded publication ignores routes", note: models.Note{Tags: []string{"gamedev"}}, config: "Blog", routes: map[string]string{"gamedev": "Game Dev"}, forPatch: true, want: "at://did:plc:test123/pub.leaflet.publication/mock_pub_rkey"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				suite := NewHandlerTestSuite(t)
+				defer suite.Cleanup()
+
+				handler, _ := newPublicationsTestHandler(t)
+				handler.config.LeafletPublication = tt.config
+				handler.config.LeafletPublicationRoutes = tt.routes
+
+				got, err := handler.publicationFor(ctx, &tt.note, tt.explicit, tt.forPatch)
+				suite.AssertNoError(err, "publicationFor")
+				if got != tt.want {
+					t.Errorf("expected %s, got %s", tt.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("rejects conflicting tag routes", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newPublicationsTestHandler(t)
+		handler.config.LeafletPublicationRoutes = map[string]string{"gamedev": "Game Dev", "essay": "Blog"}
+
+		note := &models.Note{Tags: []string{"gamedev", "essay"}}
+		if _, err := handler.publicationFor(ctx, note, "", false); err == nil || !strings.Contains(err.Error(), "--publication") {
+			t.Errorf("expected conflicting routes error, got %v", err)
+		}
+		if _, err := handler.publicationFor(ctx, note, "Blog", false); err != nil {
+			t.Errorf("expected explicit publication to settle the conflict, got %v", err)
+		}
+	})
+
+	t.Run("post records the publication", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		var posted string
+		mock.PostDocumentFunc = func(ctx context.Context, doc public.Document, isDraft bool) (*services.DocumentWithMeta, error) {
+			posted = doc.Publication
+			return &services.DocumentWithMeta{Document: doc, Meta: public.DocumentMeta{RKey: "doc_rkey", CID: "doc_cid"}}, nil
+		}
+
+		id, err := handler.repos.Notes.Create(ctx, &models.Note{Title: "Devlog", Content: "# Devlog\n\nProgress.", Tags: []string{"gamedev"}})
+		suite.AssertNoError(err, "create note")
+		handler.config.LeafletPublicationRoutes = map[string]string{"gamedev": "Game Dev"}
+
+		suite.AssertNoError(handler.Post(ctx, id, false, ""), "post")
+		if posted != gamesURI {
+			t.Errorf("expected document to be posted to %s, got %s", gamesURI, posted)
+		}
+
+		note, err := handler.repos.Notes.Get(ctx, id)
+		suite.AssertNoError(err, "get note")
+		if note.LeafletPublication == nil || *note.LeafletPublication != gamesURI {
+			t.Errorf("expected note to record %s, got %v", gamesURI, note.LeafletPublication)
+		}
+
+		suite.AssertNoError(handler.List(ctx, "all"), "list grouped by publication")
+	})
+}
+
+func TestPublicationManagement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires authentication", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler := CreateHandler(t, NewPublicationHandler)
+		handler.atproto = services.NewMockATProtoService()
+
+		for name, err := range map[string]error{
+			"list":   handler.ListPublications(ctx),
+			"create": handler.CreatePublication(ctx, PublicationOptions{Name: "Blog"}),
+			"update": handler.UpdatePublication(ctx, "Blog", PublicationOptions{Name: "Journal"}),
+		} {
+			if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+				t.Errorf("%s: expected not authenticated error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("lists publications", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newPublicationsTestHandler(t)
+		handler.config.LeafletPublication = "Game Dev"
+		suite.AssertNoError(handler.ListPublications(ctx), "list publications")
+	})
+
+	t.Run("creates a publication with an icon", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		icon := filepath.Join(t.TempDir(), "icon.png")
+		if err := os.WriteFile(icon, []byte(pngHeader+"rest of image"), 0o644); err != nil {
+			t.Fatalf("write icon: %v", err)
+		}
+
+		var uploaded string
+		mock.UploadBlobFunc = func(ctx context.Context, data []byte, mimeType string) (public.Blob, error) {
+			uploaded = mimeType
+			return public.Blob{Type: public.TypeBlob, MimeType: mimeType, Size: len(data)}, nil
+		}
+		var created public.Publication
+		mock.CreatePublicationFunc = func(ctx context.Context, pub public.Publication) (*services.PublicationWithMeta, error) {
+			created = pub
+			return &services.PublicationWithMeta{Publication: pub, RKey: "new_rkey"}, nil
+		}
+
+		err := handler.CreatePublication(ctx, PublicationOptions{Name: "Poetry", Description: "Verse", BasePath: "poetry.leaflet.pub", IconPath: icon})
+		suite.AssertNoError(err, "create publication")
+
+		if uploaded != "image/png" {
+			t.Errorf("expected icon upload as image/png, got %q", uploaded)
+		}
+		if created.Name != "Poetry" || created.BasePath != "poetry.leaflet.pub" || created.Icon == nil {
+			t.Errorf("unexpected publication record: %+v", created)
+		}
+	})
+
+	t.Run("rejects non-image icons", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, _ := newPublicationsTestHandler(t)
+		icon := filepath.Join(t.TempDir(), "icon.txt")
+		if err := os.WriteFile(icon, []byte("not an image"), 0o644); err != nil {
+			t.Fatalf("write icon: %v", err)
+		}
+
+		err := handler.CreatePublication(ctx, PublicationOptions{Name: "Poetry", IconPath: icon})
+		if err == nil || !strings.Contains(err.Error(), "must be an image") {
+			t.Errorf("expected image error, got %v", err)
+		}
+	})
+
+	t.Run("updates a publication by name", func(t *testing.T) {
+		suite := NewHandlerTestSuite(t)
+		defer suite.Cleanup()
+
+		handler, mock := newPublicationsTestHandler(t)
+		var updatedRKey string
+		var changes public.Publication
+		mock.UpdatePublicationFunc = func(ctx context.Context, rkey string, c public.Publication) (*services.PublicationWithMeta, error) {
+			updatedRKey, changes = rkey, c
+			return &services.PublicationWithMeta{Publication: public.Publication{Name: "Game Development"}, RKey: rkey}, nil
+		}
+
+		suite.AssertNoError(handler.UpdatePublication(ctx, "game dev", PublicationOptions{Name: "Game Development"}), "update publication")
+		if updatedRKey != "games_rkey" || changes.Name != "Game Development" {
+			t.Errorf("expected games_rkey to be renamed, got %s / %q", updatedRKey, changes.Name)
+		}
+
+		if err := handler.UpdatePublication(ctx, "Blog", PublicationOptions{}); err == nil || !strings.Contains(err.Error(), "nothing to update") {
+			t.Errorf("expected nothing to update error, got %v", err)
+		}
+	})
+}
+
+func TestGroupByPublication(t *testing.T) {
+	blog, games := blogURI, gamesURI
+	notes := []*models.Note{
+		{ID: 1},
+		{ID: 2, LeafletPublication: &games},
+		{ID: 3, LeafletPublication: &blog},
+		{ID: 4, LeafletPublication: &games},
+	}
+
+	order, groups := groupByPublication(notes)
+	if want := []string{gamesURI, blogURI, ""}; strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+	if len(groups[gamesURI]) != 2 || len(groups[""]) != 1 {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+}
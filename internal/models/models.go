@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"slices"
+	"strings"
 	"time"
 )
 
@@ -179,6 +180,9 @@ type Note struct {
 	LeafletCID  *string    `json:"leaflet_cid,omitempty"`  // Leaflet content identifier
 	PublishedAt *time.Time `json:"published_at,omitempty"` // Publication timestamp
 	IsDraft     bool       `json:"is_draft"`               // Draft vs published status
+	Encrypted   bool       `json:"encrypted"`              // Content is age ciphertext
+
+	LeafletPublication *string `json:"leaflet_publication,omitempty"` // AT URI of the publication the document belongs to
 }
 
 // Album represents a music album
@@ -208,6 +212,21 @@ type TimeEntry struct {
 	Modified        time.Time  `json:"modified"`
 }
 
+// Article reading statuses
+const (
+	ArticleUnread   = "unread"
+	ArticleReading  = "reading"
+	ArticleRead     = "read"
+	ArticleArchived = "archived"
+)
+
+// Article import item states
+const (
+	ImportPending = "pending"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
 // Article represents a parsed article from a web URL
 type Article struct {
 	ID           int64     `json:"id"`
@@ -217,8 +236,217 @@ type Article struct {
 	Date         string    `json:"date,omitempty"`
 	MarkdownPath string    `json:"markdown_path"`
 	HTMLPath     string    `json:"html_path"`
-	Created      time.Time `json:"created"`
-	Modified     time.Time `json:"modified"`
+	Status       string    `json:"status"` // unread, reading, read, archived
+	Favorite     bool      `json:"favorite"`
+	Tags         []string  `json:"tags,omitempty"`
+	Progress     int       `json:"progress"`               // percentage 0-100
+	WordCount    int       `json:"word_count,omitempty"`   // words in the saved markdown
+	ReadingTime  int       `json:"reading_time,omitempty"` // estimated minutes
+	NoteID       *int64    `json:"note_id,omitempty"`      // note the highlights were exported to
+	Citation     *Citation `json:"citation,omitempty"`     // bibliographic record of academic papers
+
+	// Results of the last link check
+	LinkStatus     int        `json:"link_status,omitempty"`     // HTTP status, 0 when unreachable
+	LinkError      string     `json:"link_error,omitempty"`      // why the link was unreachable
+	RedirectURL    string     `json:"redirect_url,omitempty"`    // where the URL redirected to
+	RedirectStatus int        `json:"redirect_status,omitempty"` // status of the first redirect, e.g. 301
+	LinkChecked    *time.Time `json:"link_checked,omitempty"`
+	ArchiveURL     string     `json:"archive_url,omitempty"` // archived copy the content was fetched from after the link died
+
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// Citation is the bibliographic record of a paper in CSL-JSON form, the format read by
+// citeproc, pandoc and Zotero (https://citeproc-js.readthedocs.io/en/latest/csl-json/markup.html)
+type Citation struct {
+	ID             string         `json:"id"`
+	Type           string         `json:"type"` // CSL item type: article-journal, paper-conference, article, ...
+	Title          string         `json:"title"`
+	Author         []CitationName `json:"author,omitempty"`
+	ContainerTitle string         `json:"container-title,omitempty"` // journal, proceedings or repository
+	Publisher      string         `json:"publisher,omitempty"`
+	Issued         *CitationDate  `json:"issued,omitempty"`
+	Volume         string         `json:"volume,omitempty"`
+	Issue          string         `json:"issue,omitempty"`
+	Page           string         `json:"page,omitempty"`
+	Number         string         `json:"number,omitempty"` // preprint or report number, e.g. arXiv:2009.03017
+	DOI            string         `json:"DOI,omitempty"`
+	URL            string         `json:"URL,omitempty"`
+	Abstract       string         `json:"abstract,omitempty"`
+	Accessed       *CitationDate  `json:"accessed,omitempty"`
+}
+
+// CitationName is a CSL name: a person's family and given names, or a literal organisation name
+type CitationName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// CitationDate is a CSL date given as year, month and day parts, of which only the year is required
+type CitationDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// ArticleHighlight is a quote from an article with an optional note. Start and End are byte
+// offsets of the quote in the article's markdown file.
+type ArticleHighlight struct {
+	ID        int64     `json:"id"`
+	ArticleID int64     `json:"article_id"`
+	Quote     string    `json:"quote"` // the highlighted text as it appears in the markdown
+	Note      string    `json:"note,omitempty"`
+	Start     int       `json:"start"`
+	End       int       `json:"end"`
+	Created   time.Time `json:"created"`
+}
+
+// ArticleFeed is an RSS, Atom or JSON Feed subscription whose new entries are saved as articles
+type ArticleFeed struct {
+	ID           int64      `json:"id"`
+	URL          string     `json:"url"`
+	Title        string     `json:"title"`
+	SiteURL      string     `json:"site_url,omitempty"`
+	ETag         string     `json:"etag,omitempty"`          // validator sent back on the next refresh
+	LastModified string     `json:"last_modified,omitempty"` // validator sent back on the next refresh
+	Include      []string   `json:"include,omitempty"`       // entries must mention one of these keywords
+	Exclude      []string   `json:"exclude,omitempty"`       // entries mentioning any of these are skipped
+	MaxAge       int        `json:"max_age,omitempty"`       // days; older entries are skipped
+	Tags         []string   `json:"tags,omitempty"`          // added to every article saved from the feed
+	LastFetched  *time.Time `json:"last_fetched,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Created      time.Time  `json:"created"`
+	Modified     time.Time  `json:"modified"`
+}
+
+// ArticleFeedItem records a feed entry that has been saved, keyed by the feed and the entry's GUID
+type ArticleFeedItem struct {
+	FeedID    int64     `json:"feed_id"`
+	GUID      string    `json:"guid"`
+	URL       string    `json:"url"`
+	ArticleID int64     `json:"article_id"`
+	Added     time.Time `json:"added"`
+}
+
+// ArticleImport is a reading list imported from another application. Its items are stored up
+// front so an interrupted import can be resumed.
+type ArticleImport struct {
+	ID       int64     `json:"id"`
+	Source   string    `json:"source"` // pocket, instapaper, wallabag or bookmarks
+	Path     string    `json:"path"`
+	Hash     string    `json:"hash"` // SHA-256 of the export file, used to find the import again
+	Total    int       `json:"total"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// ArticleImportItem is one link of an [ArticleImport] and the state it should be saved with
+type ArticleImportItem struct {
+	ImportID  int64     `json:"import_id"`
+	Position  int       `json:"position"` // order in the export file
+	URL       string    `json:"url"`
+	Title     string    `json:"title,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Status    string    `json:"status"` // reading status given to the saved article
+	Favorite  bool      `json:"favorite"`
+	Saved     time.Time `json:"saved"` // when the link was saved in the other application
+	State     string    `json:"state"` // pending, done or failed
+	ArticleID int64     `json:"article_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// NoteRevision represents a stored snapshot of a note's content
+type NoteRevision struct {
+	ID       int64     `json:"id"`
+	NoteID   int64     `json:"note_id"`
+	Revision int       `json:"revision"` // sequential per note, starting at 1
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Hash     string    `json:"hash"` // SHA-256 of the content
+	Size     int       `json:"size"` // uncompressed content size in bytes
+	Created  time.Time `json:"created"`
+}
+
+// NoteSyncState records the last reconciled state between a note and its markdown file
+type NoteSyncState struct {
+	NoteID   int64     `json:"note_id"`
+	Path     string    `json:"path"`
+	FileHash string    `json:"file_hash"` // SHA-256 of the file contents at last sync
+	NoteHash string    `json:"note_hash"` // SHA-256 of the rendered note at last sync
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Leaflet sync statuses
+const (
+	LeafletSynced   = "synced"   // note and document agree as of the last pull
+	LeafletConflict = "conflict" // both sides changed; the remote version is pending
+	LeafletDeleted  = "deleted"  // the document no longer exists on leaflet
+)
+
+// LeafletSyncState records the common ancestor of a note and its leaflet document.
+//
+// The base is the note as it was after the last pull or push, so a later pull
+// can tell local edits from remote ones. When both changed, the remote version
+// is held here until the conflict is resolved.
+type LeafletSyncState struct {
+	NoteID        int64     `json:"note_id"`
+	RKey          string    `json:"rkey"`
+	BaseTitle     string    `json:"base_title"`
+	BaseContent   string    `json:"base_content"`
+	RemoteCID     string    `json:"remote_cid,omitempty"`
+	RemoteTitle   string    `json:"remote_title,omitempty"`
+	RemoteContent string    `json:"remote_content,omitempty"`
+	Status        string    `json:"status"`
+	SyncedAt      time.Time `json:"synced_at"`
+}
+
+// Leaflet outbox operations
+const (
+	OutboxCreate = "create"
+	OutboxUpdate = "update"
+	OutboxDelete = "delete"
+)
+
+// LeafletOutboxEntry is a leaflet write waiting for the PDS to become reachable.
+//
+// Each note has at most one entry: later operations are folded into it, so any
+// number of patches made while offline are sent as a single write.
+type LeafletOutboxEntry struct {
+	ID          int64     `json:"id"`
+	NoteID      int64     `json:"note_id"`
+	Operation   string    `json:"operation"`
+	RKey        string    `json:"rkey,omitempty"`
+	IsDraft     bool      `json:"is_draft"`
+	Publication string    `json:"publication,omitempty"`
+	Document    string    `json:"document,omitempty"` // prepared public.Document as JSON
+	BaseTitle   string    `json:"base_title,omitempty"`
+	BaseContent string    `json:"base_content,omitempty"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	Failed      bool      `json:"failed"`
+	QueuedAt    time.Time `json:"queued_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CachedBlob is an image blob already uploaded to a PDS, keyed by the hash of the source image
+type CachedBlob struct {
+	Hash     string    `json:"hash"`
+	DID      string    `json:"did"`
+	CID      string    `json:"cid"`
+	MimeType string    `json:"mime_type"`
+	Size     int       `json:"size"`
+	Width    int       `json:"width"`
+	Height   int       `json:"height"`
+	Created  time.Time `json:"created"`
+}
+
+// NoteImport records a note brought in from another application, keyed by the hash of its source
+type NoteImport struct {
+	Hash     string    `json:"hash"`
+	NoteID   int64     `json:"note_id"`
+	Source   string    `json:"source"`
+	Path     string    `json:"path"`
+	Imported time.Time `json:"imported"`
 }
 
 // MarshalTags converts tags slice to JSON string for database storage
@@ -708,3 +936,227 @@ func (a *Article) HasAuthor() bool { return a.Author != "" }
 
 // HasDate returns true if the article has a date
 func (a *Article) HasDate() bool { return a.Date != "" }
+
+// IsUnread returns true if the article has not been started
+func (a *Article) IsUnread() bool { return a.Status == "" || a.Status == ArticleUnread }
+
+// IsRead returns true if the article has been read
+func (a *Article) IsRead() bool { return a.Status == ArticleRead }
+
+// IsArchived returns true if the article has been archived
+func (a *Article) IsArchived() bool { return a.Status == ArticleArchived }
+
+// IsBroken returns true if the last link check found the URL unreachable or answering with an error
+func (a *Article) IsBroken() bool {
+	return a.LinkChecked != nil && (a.LinkStatus == 0 || a.LinkStatus >= 400)
+}
+
+// GetStatus returns the reading status of the article
+func (a *Article) GetStatus() string { return a.Status }
+
+// ValidStatuses returns all valid status values for an article
+func (a *Article) ValidStatuses() []string {
+	return []string{ArticleUnread, ArticleReading, ArticleRead, ArticleArchived}
+}
+
+// GetProgress returns the reading progress percentage (0-100)
+func (a *Article) GetProgress() int { return a.Progress }
+
+// SetProgress sets the reading progress percentage (0-100)
+func (a *Article) SetProgress(progress int) error {
+	if progress < 0 || progress > 100 {
+		return fmt.Errorf("progress must be between 0 and 100, got %d", progress)
+	}
+	a.Progress = progress
+	return nil
+}
+
+// MarshalTags converts tags slice to JSON string for database storage
+func (a *Article) MarshalTags() (string, error) {
+	if len(a.Tags) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(a.Tags)
+	return string(data), err
+}
+
+// UnmarshalTags converts JSON string from database to tags slice
+func (a *Article) UnmarshalTags(data string) error {
+	if data == "" {
+		a.Tags = nil
+		return nil
+	}
+	return json.Unmarshal([]byte(data), &a.Tags)
+}
+
+// MarshalFilters converts the keyword filters and tags of the feed to JSON strings for database storage
+func (f *ArticleFeed) MarshalFilters() (include, exclude, tags string, err error) {
+	if include, err = marshalStrings(f.Include); err != nil {
+		return "", "", "", err
+	}
+	if exclude, err = marshalStrings(f.Exclude); err != nil {
+		return "", "", "", err
+	}
+	if tags, err = marshalStrings(f.Tags); err != nil {
+		return "", "", "", err
+	}
+	return include, exclude, tags, nil
+}
+
+// UnmarshalFilters converts JSON strings from the database to the keyword filters and tags of the feed
+func (f *ArticleFeed) UnmarshalFilters(include, exclude, tags string) error {
+	var err error
+	if f.Include, err = unmarshalStrings(include); err != nil {
+		return err
+	}
+	if f.Exclude, err = unmarshalStrings(exclude); err != nil {
+		return err
+	}
+	f.Tags, err = unmarshalStrings(tags)
+	return err
+}
+
+func marshalStrings(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+func unmarshalStrings(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var values []string
+	err := json.Unmarshal([]byte(data), &values)
+	return values, err
+}
+
+// NewCitationDate builds a [CitationDate] from its parts, leaving out a zero month or day.
+// It returns nil when the year is unknown.
+func NewCitationDate(year, month, day int) *CitationDate {
+	if year == 0 {
+		return nil
+	}
+	parts := []int{year}
+	if month > 0 {
+		parts = append(parts, month)
+		if day > 0 {
+			parts = append(parts, day)
+		}
+	}
+	return &CitationDate{DateParts: [][]int{parts}}
+}
+
+// Year returns the year of the date, or 0 when it has none
+func (d *CitationDate) Year() int {
+	if d == nil || len(d.DateParts) == 0 || len(d.DateParts[0]) == 0 {
+		return 0
+	}
+	return d.DateParts[0][0]
+}
+
+// Month returns the month of the date, or 0 when it has none
+func (d *CitationDate) Month() int {
+	if d == nil || len(d.DateParts) == 0 || len(d.DateParts[0]) < 2 {
+		return 0
+	}
+	return d.DateParts[0][1]
+}
+
+// Day returns the day of the date, or 0 when it has none
+func (d *CitationDate) Day() int {
+	if d == nil || len(d.DateParts) == 0 || len(d.DateParts[0]) < 3 {
+		return 0
+	}
+	return d.DateParts[0][2]
+}
+
+// String formats the date as YYYY-MM-DD, YYYY-MM or YYYY, or "" when it is nil
+func (d *CitationDate) String() string {
+	switch {
+	case d.Year() == 0:
+		return ""
+	case d.Month() == 0:
+		return fmt.Sprintf("%04d", d.Year())
+	case d.Day() == 0:
+		return fmt.Sprintf("%04d-%02d", d.Year(), d.Month())
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year(), d.Month(), d.Day())
+}
+
+// String formats the name as it is written: given names first
+func (n CitationName) String() string {
+	if n.Literal != "" {
+		return n.Literal
+	}
+	return strings.TrimSpace(n.Given + " " + n.Family)
+}
+
+// MarshalCitation converts the citation to a JSON string for database storage
+func (a *Article) MarshalCitation() (string, error) {
+	if a.Citation == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(a.Citation)
+	return string(data), err
+}
+
+// UnmarshalCitation converts a JSON string from the database to the citation
+func (a *Article) UnmarshalCitation(data string) error {
+	a.Citation = nil
+	if data == "" {
+		return nil
+	}
+	var citation Citation
+	if err := json.Unmarshal([]byte(data), &citation); err != nil {
+		return err
+	}
+	a.Citation = &citation
+	return nil
+}
+
+// MarshalTags converts the tags to a JSON string for database storage
+func (i *ArticleImportItem) MarshalTags() (string, error) {
+	return marshalStrings(i.Tags)
+}
+
+// UnmarshalTags converts a JSON string from the database to tags
+func (i *ArticleImportItem) UnmarshalTags(data string) error {
+	var err error
+	i.Tags, err = unmarshalStrings(data)
+	return err
+}
+
+func (f *ArticleFeed) GetID() int64                { return f.ID }
+func (f *ArticleFeed) SetID(id int64)              { f.ID = id }
+func (f *ArticleFeed) GetTableName() string        { return "article_feeds" }
+func (f *ArticleFeed) GetCreatedAt() time.Time     { return f.Created }
+func (f *ArticleFeed) SetCreatedAt(time time.Time) { f.Created = time }
+func (f *ArticleFeed) GetUpdatedAt() time.Time     { return f.Modified }
+func (f *ArticleFeed) SetUpdatedAt(time time.Time) { f.Modified = time }
+
+func (h *ArticleHighlight) GetID() int64                { return h.ID }
+func (h *ArticleHighlight) SetID(id int64)              { h.ID = id }
+func (h *ArticleHighlight) GetTableName() string        { return "article_highlights" }
+func (h *ArticleHighlight) GetCreatedAt() time.Time     { return h.Created }
+func (h *ArticleHighlight) SetCreatedAt(time time.Time) { h.Created = time }
+func (h *ArticleHighlight) GetUpdatedAt() time.Time     { return h.Created }
+func (h *ArticleHighlight) SetUpdatedAt(time time.Time) { h.Created = time }
+
+func (i *ArticleImport) GetID() int64                { return i.ID }
+func (i *ArticleImport) SetID(id int64)              { i.ID = id }
+func (i *ArticleImport) GetTableName() string        { return "article_imports" }
+func (i *ArticleImport) GetCreatedAt() time.Time     { return i.Created }
+func (i *ArticleImport) SetCreatedAt(time time.Time) { i.Created = time }
+func (i *ArticleImport) GetUpdatedAt() time.Time     { return i.Modified }
+func (i *ArticleImport) SetUpdatedAt(time time.Time) { i.Modified = time }
+
+func (r *NoteRevision) GetID() int64                { return r.ID }
+func (r *NoteRevision) SetID(id int64)              { r.ID = id }
+func (r *NoteRevision) GetTableName() string        { return "note_revisions" }
+func (r *NoteRevision) GetCreatedAt() time.Time     { return r.Created }
+func (r *NoteRevision) SetCreatedAt(time time.Time) { r.Created = time }
+func (r *NoteRevision) GetUpdatedAt() time.Time     { return r.Created }
+func (r *NoteRevision) SetUpdatedAt(time time.Time) { r.Created = time }
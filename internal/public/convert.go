@@ -8,14 +8,17 @@ package public
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
+	"html"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/parser"
@@ -43,29 +46,81 @@ type ImageResolver interface {
 	ResolveImage(url string) (*ImageInfo, error)
 }
 
-// LocalImageResolver resolves local file paths to image metadata
+// BlobCache remembers images that were already uploaded, keyed by the SHA-256 of
+// their source bytes, so publishing the same image again reuses its blob
+type BlobCache interface {
+	// LookupBlob returns the cached image for hash, or nil when it has not been uploaded
+	LookupBlob(hash string) (*ImageInfo, error)
+	// StoreBlob records an uploaded image under the hash of its source bytes
+	StoreBlob(hash string, info *ImageInfo) error
+}
+
+// ResolvedImage describes how an image was resolved, for reporting what a publish uploads
+type ResolvedImage struct {
+	Source      string // path or URL as written in the markdown
+	SourceSize  int    // bytes read or downloaded
+	Size        int    // bytes of the blob, after any downscaling
+	MimeType    string
+	Width       int
+	Height      int
+	Resized     bool // re-encoded to fit MaxSize
+	Cached      bool // reused from the blob cache instead of uploaded
+	Placeholder bool // no uploader was set, so the blob is a placeholder
+}
+
+// LocalImageResolver resolves local file paths and remote URLs to image metadata
 type LocalImageResolver struct {
 	// Called to upload image bytes and get a blob reference
 	BlobUploader func(data []byte, mimeType string) (Blob, error)
+	// Fetch downloads http(s) images; remote images cannot be resolved when it is nil
+	Fetch func(url string) ([]byte, error)
+	// MaxSize is the largest blob to upload; bigger images are downscaled and
+	// re-encoded with [FitImage]. Zero disables the limit.
+	MaxSize int
+	// Cache, when set, is checked before an image is re-encoded or uploaded
+	Cache BlobCache
+
+	// Resolved lists every distinct image resolved so far, in order
+	Resolved []ResolvedImage
+
+	seen map[string]*ImageInfo // by source hash, so repeated images are resolved once
 }
 
-// ResolveImage reads a local image file and extracts metadata
+// ResolveImage reads a local image file or downloads a remote one, fits it to
+// MaxSize, and uploads it unless the same image was uploaded before
 func (r *LocalImageResolver) ResolveImage(path string) (*ImageInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := r.readImage(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read image: %w", err)
+		return nil, err
 	}
 
-	img, format, err := image.DecodeConfig(bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if info, ok := r.seen[hash]; ok {
+		return info, nil
+	}
+
+	if r.Cache != nil {
+		cached, err := r.Cache.LookupBlob(hash)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			r.record(path, hash, len(data), cached, false, true, false)
+			return cached, nil
+		}
 	}
 
-	mimeType := "image/" + format
+	out, mimeType, width, height, err := FitImage(data, r.MaxSize)
+	if err != nil {
+		return nil, err
+	}
 
 	var blob Blob
-	if r.BlobUploader != nil {
-		blob, err = r.BlobUploader(data, mimeType)
+	placeholder := r.BlobUploader == nil
+	if !placeholder {
+		blob, err = r.BlobUploader(out, mimeType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload blob: %w", err)
 		}
@@ -74,15 +129,61 @@ func (r *LocalImageResolver) ResolveImage(path string) (*ImageInfo, error) {
 			Type:     TypeBlob,
 			Ref:      CID{Link: "bafkreiplaceholder"},
 			MimeType: mimeType,
-			Size:     len(data),
+			Size:     len(out),
 		}
 	}
 
-	return &ImageInfo{
+	info := &ImageInfo{
 		Blob:   blob,
-		Width:  img.Width,
-		Height: img.Height,
-	}, nil
+		Width:  width,
+		Height: height,
+	}
+
+	if r.Cache != nil && !placeholder {
+		if err := r.Cache.StoreBlob(hash, info); err != nil {
+			return nil, err
+		}
+	}
+
+	r.record(path, hash, len(data), info, len(out) != len(data), false, placeholder)
+	return info, nil
+}
+
+func (r *LocalImageResolver) readImage(path string) ([]byte, error) {
+	if !IsRemoteImage(path) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		return data, nil
+	}
+
+	if r.Fetch == nil {
+		return nil, fmt.Errorf("remote images are not supported")
+	}
+	data, err := r.Fetch(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	return data, nil
+}
+
+func (r *LocalImageResolver) record(src, hash string, sourceSize int, info *ImageInfo, resized, cached, placeholder bool) {
+	if r.seen == nil {
+		r.seen = make(map[string]*ImageInfo)
+	}
+	r.seen[hash] = info
+	r.Resolved = append(r.Resolved, ResolvedImage{
+		Source:      src,
+		SourceSize:  sourceSize,
+		Size:        info.Blob.Size,
+		MimeType:    info.Blob.MimeType,
+		Width:       info.Width,
+		Height:      info.Height,
+		Resized:     resized,
+		Cached:      cached,
+		Placeholder: placeholder,
+	})
 }
 
 // MarkdownConverter implements the [Converter] interface
@@ -99,7 +200,7 @@ type formatContext struct {
 
 // NewMarkdownConverter creates a new markdown converter
 func NewMarkdownConverter() *MarkdownConverter {
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs
+	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.OrderedListStart | parser.Footnotes | parser.Attributes
 	return &MarkdownConverter{
 		extensions: extensions,
 	}
@@ -122,7 +223,10 @@ func (c *MarkdownConverter) ToLeaflet(markdown string) ([]BlockWrap, error) {
 	if c.imageResolver != nil {
 		for _, url := range imageURLs {
 			resolvedPath := url
-			if !filepath.IsAbs(url) && c.basePath != "" {
+			if !IsRemoteImage(url) && !filepath.IsAbs(url) {
+				if c.basePath == "" {
+					continue // relative to an unknown directory; left as a placeholder
+				}
 				resolvedPath = filepath.Join(c.basePath, url)
 			}
 
@@ -143,8 +247,11 @@ func (c *MarkdownConverter) ToLeaflet(markdown string) ([]BlockWrap, error) {
 				blocks = append(blocks, *block)
 			}
 		case *ast.Paragraph:
-			convertedBlocks := c.convertParagraph(n, resolvedImages)
-			blocks = append(blocks, convertedBlocks...)
+			if block := c.convertEmbed(n); block != nil {
+				blocks = append(blocks, *block)
+			} else {
+				blocks = append(blocks, c.convertParagraph(n, resolvedImages)...)
+			}
 		case *ast.CodeBlock:
 			if block := c.convertCodeBlock(n); block != nil {
 				blocks = append(blocks, *block)
@@ -154,7 +261,9 @@ func (c *MarkdownConverter) ToLeaflet(markdown string) ([]BlockWrap, error) {
 				blocks = append(blocks, *block)
 			}
 		case *ast.List:
-			if block := c.convertList(n, resolvedImages); block != nil {
+			if n.IsFootnotesList {
+				blocks = append(blocks, c.convertFootnotes(n, resolvedImages)...)
+			} else if block := c.convertList(n, resolvedImages); block != nil {
 				blocks = append(blocks, *block)
 			}
 		case *ast.HorizontalRule:
@@ -168,6 +277,20 @@ func (c *MarkdownConverter) ToLeaflet(markdown string) ([]BlockWrap, error) {
 			if block := c.convertImage(n, resolvedImages); block != nil {
 				blocks = append(blocks, *block)
 			}
+		case *ast.MathBlock:
+			blocks = append(blocks, BlockWrap{
+				Type: TypeBlock,
+				Block: MathBlock{
+					Type: TypeMathBlock,
+					Tex:  strings.Trim(string(n.Literal), "\n"),
+				},
+			})
+		case *ast.Table:
+			blocks = append(blocks, c.convertTable(n, resolvedImages))
+		case *ast.HTMLBlock:
+			if block := convertIframe(n); block != nil {
+				blocks = append(blocks, *block)
+			}
 		}
 	}
 
@@ -255,17 +378,20 @@ func (c *MarkdownConverter) convertBlockquote(node *ast.BlockQuote, resolvedImag
 	}
 }
 
-// convertList converts an AST list to a leaflet UnorderedListBlock
+// convertList converts an AST list to a leaflet UnorderedListBlock or OrderedListBlock
 func (c *MarkdownConverter) convertList(node *ast.List, resolvedImages map[string]*ImageInfo) *BlockWrap {
-	var items []ListItem
+	ordered := node.ListFlags&ast.ListTypeOrdered != 0
+	items := c.convertListItems(node, ordered, resolvedImages)
 
-	for _, child := range node.Children {
-		if listItem, ok := child.(*ast.ListItem); ok {
-			item := c.convertListItem(listItem, resolvedImages)
-			if item != nil {
-				items = append(items, *item)
-			}
+	if ordered {
+		block := OrderedListBlock{
+			Type:     TypeOrderedListBlock,
+			Children: items,
 		}
+		if node.Start > 1 {
+			block.StartIndex = node.Start
+		}
+		return &BlockWrap{Type: TypeBlock, Block: block}
 	}
 
 	return &BlockWrap{
@@ -277,15 +403,283 @@ func (c *MarkdownConverter) convertList(node *ast.List, resolvedImages map[strin
 	}
 }
 
-// convertListItem converts an AST list item to a leaflet ListItem
-func (c *MarkdownConverter) convertListItem(node *ast.ListItem, resolvedImages map[string]*ImageInfo) *ListItem {
-	text, facets, _ := c.extractTextAndFacets(node, resolvedImages)
-	return &ListItem{
-		Type: TypeListItem,
-		Content: TextBlock{
-			Type:      TypeTextBlock,
-			Plaintext: text,
-			Facets:    facets,
+// convertListItems converts the items of a list. Nested lists take the kind of
+// the outermost list, since leaflet list items can only nest their own kind.
+func (c *MarkdownConverter) convertListItems(node *ast.List, ordered bool, resolvedImages map[string]*ImageInfo) []ListItem {
+	var items []ListItem
+	for _, child := range node.Children {
+		if listItem, ok := child.(*ast.ListItem); ok {
+			items = append(items, c.convertListItem(listItem, ordered, resolvedImages))
+		}
+	}
+	return items
+}
+
+// convertListItem converts an AST list item to a leaflet ListItem.
+// Items starting with "[ ] " or "[x] " become checklist items.
+func (c *MarkdownConverter) convertListItem(node *ast.ListItem, ordered bool, resolvedImages map[string]*ImageInfo) ListItem {
+	item := ListItem{Type: TypeListItem}
+	if ordered {
+		item.Type = TypeOrderedListItem
+	}
+
+	var text string
+	var facets []Facet
+	for _, child := range node.Children {
+		if list, ok := child.(*ast.List); ok {
+			item.Children = append(item.Children, c.convertListItems(list, ordered, resolvedImages)...)
+			continue
+		}
+
+		childText, childFacets, _ := c.extractTextAndFacets(child, resolvedImages)
+		if text != "" && childText != "" {
+			text += " "
+		}
+		facets = append(facets, shiftFacets(childFacets, len(text))...)
+		text += childText
+	}
+
+	if checked, ok := taskMarker(text, facets); ok {
+		item.Checked = &checked
+		text = text[len("[ ] "):]
+		facets = shiftFacets(facets, -len("[ ] "))
+	}
+
+	item.Content = TextBlock{
+		Type:      TypeTextBlock,
+		Plaintext: text,
+		Facets:    facets,
+	}
+	return item
+}
+
+// taskMarker reports whether list item text starts with an unformatted task
+// list marker, and whether the task is checked
+func taskMarker(text string, facets []Facet) (checked, ok bool) {
+	var marker string
+	switch {
+	case strings.HasPrefix(text, "[ ] "):
+		marker = "[ ] "
+	case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+		marker, checked = text[:4], true
+	default:
+		return false, false
+	}
+
+	for _, facet := range facets {
+		if facet.Index.ByteStart < len(marker) {
+			return false, false
+		}
+	}
+	return checked, true
+}
+
+// convertFootnotes converts the footnote definitions gathered at the end of a
+// document to text blocks that keep their markdown labels, so "[^1]: text"
+// round-trips through leaflet, which has no footnotes of its own
+func (c *MarkdownConverter) convertFootnotes(node *ast.List, resolvedImages map[string]*ImageInfo) []BlockWrap {
+	var blocks []BlockWrap
+	for _, child := range node.Children {
+		item, ok := child.(*ast.ListItem)
+		if !ok {
+			continue
+		}
+
+		text, facets, _ := c.extractTextAndFacets(item, resolvedImages)
+		label := "[^" + string(item.RefLink) + "]: "
+		blocks = append(blocks, BlockWrap{
+			Type: TypeBlock,
+			Block: TextBlock{
+				Type:      TypeTextBlock,
+				Plaintext: label + text,
+				Facets:    shiftFacets(facets, len(label)),
+			},
+		})
+	}
+	return blocks
+}
+
+// convertEmbed converts a paragraph holding nothing but a link to a block that
+// leaflet renders on its own. A bare URL becomes a website card; otherwise the
+// block attribute on the line before the paragraph picks the block:
+//
+//	{.website}
+//	[Title](https://example.com "Description")
+//
+//	{.button}
+//	[Subscribe](https://example.com/subscribe)
+//
+//	{.poll cid="bafyrei..."}
+//	[Poll](at://did:plc:abc/pub.leaflet.poll.definition/3kxyz)
+func (c *MarkdownConverter) convertEmbed(node *ast.Paragraph) *BlockWrap {
+	link := soleLink(node)
+	if link == nil {
+		return nil
+	}
+
+	text := linkText(link)
+	dest := string(link.Destination)
+
+	var class string
+	var attrs map[string][]byte
+	if node.Attribute != nil {
+		if len(node.Attribute.Classes) > 0 {
+			class = string(node.Attribute.Classes[0])
+		}
+		attrs = node.Attribute.Attrs
+	}
+
+	var block any
+	switch class {
+	case "website":
+		website := WebsiteBlock{Type: TypeWebsiteBlock, Src: dest, Description: string(link.Title)}
+		if text != dest {
+			website.Title = text
+		}
+		block = website
+	case "button":
+		block = ButtonBlock{Type: TypeButtonBlock, Text: text, URL: dest}
+	case "poll":
+		block = PollBlock{Type: TypePollBlock, PollRef: StrongRef{URI: dest, CID: string(attrs["cid"])}}
+	case "":
+		if text != dest || len(link.Title) > 0 || !isHTTPURL(dest) {
+			return nil
+		}
+		block = WebsiteBlock{Type: TypeWebsiteBlock, Src: dest}
+	default:
+		return nil
+	}
+
+	return &BlockWrap{Type: TypeBlock, Block: block}
+}
+
+// soleLink returns the only link in a paragraph when the paragraph contains
+// nothing else and the link text is unformatted
+func soleLink(node *ast.Paragraph) *ast.Link {
+	var link *ast.Link
+	for _, child := range node.Children {
+		switch n := child.(type) {
+		case *ast.Text:
+			if strings.TrimSpace(string(n.Literal)) != "" {
+				return nil
+			}
+		case *ast.Link:
+			if link != nil || n.NoteID != 0 {
+				return nil
+			}
+			link = n
+		default:
+			return nil
+		}
+	}
+	if link == nil {
+		return nil
+	}
+
+	for _, child := range link.Children {
+		if _, ok := child.(*ast.Text); !ok {
+			return nil
+		}
+	}
+	return link
+}
+
+func linkText(link *ast.Link) string {
+	var text strings.Builder
+	for _, child := range link.Children {
+		text.Write(child.AsLeaf().Literal)
+	}
+	return text.String()
+}
+
+var (
+	iframePattern    = regexp.MustCompile(`(?is)^\s*<iframe\s([^>]*)>\s*</iframe>\s*$`)
+	htmlAttrsPattern = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+)
+
+// convertIframe converts an HTML block holding a single iframe to an IframeBlock
+func convertIframe(node *ast.HTMLBlock) *BlockWrap {
+	match := iframePattern.FindStringSubmatch(string(node.Literal))
+	if match == nil {
+		return nil
+	}
+
+	block := IframeBlock{Type: TypeIframeBlock}
+	for _, attr := range htmlAttrsPattern.FindAllStringSubmatch(match[1], -1) {
+		switch strings.ToLower(attr[1]) {
+		case "src":
+			block.URL = html.UnescapeString(attr[2])
+		case "height":
+			block.Height, _ = strconv.Atoi(attr[2])
+		}
+	}
+	if block.URL == "" {
+		return nil
+	}
+	return &BlockWrap{Type: TypeBlock, Block: block}
+}
+
+// TableLanguage is the code block language used for tables, which leaflet has no
+// block for. The table is kept as markdown in a code block and turned back into
+// a table by [MarkdownConverter.FromLeaflet].
+const TableLanguage = "table"
+
+// convertTable converts a table to a code block holding the table as normalised markdown
+func (c *MarkdownConverter) convertTable(node *ast.Table, resolvedImages map[string]*ImageInfo) BlockWrap {
+	var rows [][]string
+	var aligns []ast.CellAlignFlags
+
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch v := n.(type) {
+		case *ast.TableRow:
+			rows = append(rows, nil)
+		case *ast.TableCell:
+			text, facets, _ := c.extractTextAndFacets(v, resolvedImages)
+			cell := strings.ReplaceAll(c.facetsToMarkdown(text, facets), "|", "\\|")
+			rows[len(rows)-1] = append(rows[len(rows)-1], cell)
+			if v.IsHeader {
+				aligns = append(aligns, v.Align)
+			}
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+
+	var buf strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("| " + strings.Join(row, " | ") + " |")
+
+		if i == 0 {
+			separators := make([]string, len(aligns))
+			for j, align := range aligns {
+				switch align {
+				case ast.TableAlignmentLeft:
+					separators[j] = ":--"
+				case ast.TableAlignmentRight:
+					separators[j] = "--:"
+				case ast.TableAlignmentCenter:
+					separators[j] = ":-:"
+				default:
+					separators[j] = "---"
+				}
+			}
+			buf.WriteString("\n| " + strings.Join(separators, " | ") + " |")
+		}
+	}
+
+	return BlockWrap{
+		Type: TypeBlock,
+		Block: CodeBlock{
+			Type:                    TypeCodeBlock,
+			Plaintext:               buf.String(),
+			Language:                TableLanguage,
+			SyntaxHighlightingTheme: "catppuccin-mocha",
 		},
 	}
 }
@@ -348,97 +742,85 @@ func (c *MarkdownConverter) extractTextAndFacets(node ast.Node, resolvedImages m
 
 	var stack []formatContext
 
+	// emit appends text styled by every open format plus any extra features
+	emit := func(content string, extra ...FacetFeature) {
+		buf.WriteString(content)
+
+		var allFeatures []FacetFeature
+		for _, ctx := range stack {
+			allFeatures = append(allFeatures, ctx.features...)
+		}
+		allFeatures = append(allFeatures, extra...)
+
+		if len(allFeatures) > 0 && content != "" {
+			facet := Facet{
+				Type: TypeFacet,
+				Index: ByteSlice{
+					Type:      TypeByteSlice,
+					ByteStart: offset,
+					ByteEnd:   offset + len(content),
+				},
+				Features: allFeatures,
+			}
+			if last := len(facets) - 1; last >= 0 && facets[last].Index.ByteEnd == offset && slices.Equal(facets[last].Features, allFeatures) {
+				facets[last].Index.ByteEnd = facet.Index.ByteEnd
+			} else {
+				facets = append(facets, facet)
+			}
+		}
+
+		offset += len(content)
+	}
+
+	push := func(entering bool, feature FacetFeature) {
+		if entering {
+			stack = append(stack, formatContext{
+				features: []FacetFeature{feature},
+				start:    offset,
+			})
+		} else if len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
 	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
 		switch v := n.(type) {
 		case *ast.Text:
 			if entering {
-				content := string(v.Literal)
-				buf.WriteString(content)
-
-				if len(stack) > 0 {
-					var allFeatures []FacetFeature
-					for _, ctx := range stack {
-						allFeatures = append(allFeatures, ctx.features...)
-					}
-					facet := Facet{
-						Type: TypeFacet,
-						Index: ByteSlice{
-							Type:      TypeByteSlice,
-							ByteStart: offset,
-							ByteEnd:   offset + len(content),
-						},
-						Features: allFeatures,
-					}
-					facets = append(facets, facet)
-				}
-
-				offset += len(content)
+				emit(string(v.Literal))
 			}
 		case *ast.Strong:
-			if entering {
-				stack = append(stack, formatContext{
-					features: []FacetFeature{FacetBold{Type: TypeFacetBold}},
-					start:    offset,
-				})
-			} else {
-				if len(stack) > 0 {
-					stack = stack[:len(stack)-1]
-				}
-			}
+			push(entering, FacetBold{Type: TypeFacetBold})
 		case *ast.Emph:
-			if entering {
-				stack = append(stack, formatContext{
-					features: []FacetFeature{FacetItalic{Type: TypeFacetItalic}},
-					start:    offset,
-				})
-			} else {
-				if len(stack) > 0 {
-					stack = stack[:len(stack)-1]
-				}
-			}
+			push(entering, FacetItalic{Type: TypeFacetItalic})
 		case *ast.Del:
-			if entering {
-				stack = append(stack, formatContext{
-					features: []FacetFeature{FacetStrikethrough{Type: TypeFacetStrike}},
-					start:    offset,
-				})
-			} else {
-				if len(stack) > 0 {
-					stack = stack[:len(stack)-1]
-				}
+			push(entering, FacetStrikethrough{Type: TypeFacetStrike})
+		case *ast.HTMLSpan:
+			switch strings.ToLower(string(v.Literal)) {
+			case "<u>":
+				push(true, FacetUnderline{Type: TypeFacetUnderline})
+			case "<mark>":
+				push(true, FacetHighlight{Type: TypeFacetHighlight})
+			case "</u>", "</mark>":
+				push(false, nil)
 			}
 		case *ast.Code:
 			if entering {
-				content := string(v.Literal)
-				buf.WriteString(content)
-
-				facet := Facet{
-					Type: TypeFacet,
-					Index: ByteSlice{
-						Type:      TypeByteSlice,
-						ByteStart: offset,
-						ByteEnd:   offset + len(content),
-					},
-					Features: []FacetFeature{FacetCode{Type: TypeFacetCode}},
-				}
-				facets = append(facets, facet)
-
-				offset += len(content)
+				emit(string(v.Literal), FacetCode{Type: TypeFacetCode})
 			}
-		case *ast.Link:
+		case *ast.Math:
 			if entering {
-				stack = append(stack, formatContext{
-					features: []FacetFeature{FacetLink{
-						Type: TypeFacetLink,
-						URI:  string(v.Destination),
-					}},
-					start: offset,
-				})
-			} else {
-				if len(stack) > 0 {
-					stack = stack[:len(stack)-1]
+				emit("$" + string(v.Literal) + "$")
+			}
+		case *ast.Link:
+			if v.NoteID != 0 {
+				// footnote reference, kept as its markdown label
+				if entering {
+					emit("[^" + string(v.DeferredID) + "]")
 				}
+				return ast.SkipChildren
 			}
+			push(entering, linkFeature(string(v.Destination)))
 		case *ast.Image:
 			if entering {
 				if buf.Len() > 0 {
@@ -461,8 +843,7 @@ func (c *MarkdownConverter) extractTextAndFacets(node ast.Node, resolvedImages m
 			}
 		case *ast.Softbreak, *ast.Hardbreak:
 			if entering {
-				buf.WriteString(" ")
-				offset++
+				emit(" ")
 			}
 		}
 		return ast.GoToNext
@@ -483,6 +864,33 @@ func (c *MarkdownConverter) extractTextAndFacets(node ast.Node, resolvedImages m
 	return buf.String(), facets, blocks
 }
 
+// linkFeature returns the facet feature for a link destination: DIDs and AT URIs
+// become mentions, anything else a plain link
+func linkFeature(dest string) FacetFeature {
+	switch {
+	case strings.HasPrefix(dest, "did:"):
+		return FacetDidMention{Type: TypeFacetDid, DID: dest}
+	case strings.HasPrefix(dest, "at://"):
+		return FacetAtMention{Type: TypeFacetAtURI, AtURI: dest}
+	default:
+		return FacetLink{Type: TypeFacetLink, URI: dest}
+	}
+}
+
+// shiftFacets returns facets moved by delta bytes
+func shiftFacets(facets []Facet, delta int) []Facet {
+	if len(facets) == 0 || delta == 0 {
+		return facets
+	}
+	shifted := make([]Facet, len(facets))
+	for i, facet := range facets {
+		facet.Index.ByteStart += delta
+		facet.Index.ByteEnd += delta
+		shifted[i] = facet
+	}
+	return shifted
+}
+
 // FromLeaflet converts leaflet blocks back to markdown
 func (c *MarkdownConverter) FromLeaflet(blocks []BlockWrap) (string, error) {
 	var buf bytes.Buffer
@@ -499,6 +907,10 @@ func (c *MarkdownConverter) FromLeaflet(blocks []BlockWrap) (string, error) {
 			buf.WriteString(" ")
 			buf.WriteString(c.facetsToMarkdown(block.Plaintext, block.Facets))
 		case CodeBlock:
+			if block.Language == TableLanguage {
+				buf.WriteString(strings.TrimRight(block.Plaintext, "\n"))
+				break
+			}
 			buf.WriteString("```")
 			if block.Language != "" {
 				buf.WriteString(block.Language)
@@ -513,13 +925,46 @@ func (c *MarkdownConverter) FromLeaflet(blocks []BlockWrap) (string, error) {
 			buf.WriteString("> ")
 			buf.WriteString(c.facetsToMarkdown(block.Plaintext, block.Facets))
 		case UnorderedListBlock:
-			c.listToMarkdown(&buf, block.Children, 0)
+			c.listToMarkdown(&buf, block.Children, "", 0)
+		case OrderedListBlock:
+			c.listToMarkdown(&buf, block.Children, "", max(block.StartIndex, 1))
 		case HorizontalRuleBlock:
 			buf.WriteString("---")
 		case ImageBlock:
 			buf.WriteString("![")
 			buf.WriteString(block.Alt)
 			buf.WriteString("](image-placeholder)")
+		case MathBlock:
+			buf.WriteString("$$\n")
+			buf.WriteString(block.Tex)
+			buf.WriteString("\n$$")
+		case WebsiteBlock:
+			if block.Title == "" && block.Description == "" {
+				buf.WriteString(block.Src)
+				break
+			}
+			title := block.Title
+			if title == "" {
+				title = block.Src
+			}
+			buf.WriteString("{.website}\n")
+			buf.WriteString(markdownLink(title, block.Src, block.Description))
+		case ButtonBlock:
+			buf.WriteString("{.button}\n")
+			buf.WriteString(markdownLink(block.Text, block.URL, ""))
+		case PollBlock:
+			if block.PollRef.CID != "" {
+				fmt.Fprintf(&buf, "{.poll cid=%q}\n", block.PollRef.CID)
+			} else {
+				buf.WriteString("{.poll}\n")
+			}
+			buf.WriteString(markdownLink("Poll", block.PollRef.URI, ""))
+		case IframeBlock:
+			fmt.Fprintf(&buf, `<iframe src="%s"`, html.EscapeString(block.URL))
+			if block.Height > 0 {
+				fmt.Fprintf(&buf, ` height="%d"`, block.Height)
+			}
+			buf.WriteString("></iframe>")
 		default:
 			return "", fmt.Errorf("unsupported block type: %T", block)
 		}
@@ -528,55 +973,134 @@ func (c *MarkdownConverter) FromLeaflet(blocks []BlockWrap) (string, error) {
 	return buf.String(), nil
 }
 
-// facetsToMarkdown applies facets to plaintext and generates markdown
+func markdownLink(text, dest, title string) string {
+	if title == "" {
+		return "[" + text + "](" + dest + ")"
+	}
+	return "[" + text + "](" + dest + " \"" + strings.ReplaceAll(title, `"`, `\"`) + "\")"
+}
+
+// facetsToMarkdown applies facets to plaintext and generates markdown.
+//
+// Formats shared by neighbouring facets stay open across them, so the markdown
+// nests the way the facets were produced: "**bold *both***" rather than
+// "**bold *****both***". Whitespace at the edge of a facet is moved outside its
+// markers, where markdown expects it.
 func (c *MarkdownConverter) facetsToMarkdown(text string, facets []Facet) string {
 	if len(facets) == 0 {
 		return text
 	}
 
-	var buf bytes.Buffer
-	lastEnd := 0
+	sorted := slices.Clone(facets)
+	slices.SortStableFunc(sorted, func(a, b Facet) int { return a.Index.ByteStart - b.Index.ByteStart })
 
-	for _, facet := range facets {
-		if facet.Index.ByteStart > lastEnd {
-			buf.WriteString(text[lastEnd:facet.Index.ByteStart])
+	var buf strings.Builder
+	var open []FacetFeature
+	var pending string // trailing whitespace of the last facet, written once its markers close
+	pos := 0
+
+	closeTo := func(keep int) {
+		for i := len(open) - 1; i >= keep; i-- {
+			_, closing := facetMarkers(open[i])
+			buf.WriteString(closing)
 		}
+		open = open[:keep]
+		buf.WriteString(pending)
+		pending = ""
+	}
 
-		facetText := text[facet.Index.ByteStart:facet.Index.ByteEnd]
+	for _, facet := range sorted {
+		start := max(facet.Index.ByteStart, pos)
+		end := min(facet.Index.ByteEnd, len(text))
+		if start >= end || strings.TrimSpace(text[start:end]) == "" {
+			continue
+		}
 
-		for _, feature := range facet.Features {
-			switch f := feature.(type) {
-			case FacetBold:
-				facetText = "**" + facetText + "**"
-			case FacetItalic:
-				facetText = "*" + facetText + "*"
-			case FacetCode:
-				facetText = "`" + facetText + "`"
-			case FacetStrikethrough:
-				facetText = "~~" + facetText + "~~"
-			case FacetLink:
-				facetText = "[" + facetText + "](" + f.URI + ")"
-			}
+		if start > pos {
+			closeTo(0)
+			buf.WriteString(text[pos:start])
 		}
 
-		buf.WriteString(facetText)
-		lastEnd = facet.Index.ByteEnd
-	}
+		keep := 0
+		for keep < len(open) && keep < len(facet.Features) && open[keep] == facet.Features[keep] {
+			keep++
+		}
+		if keep < len(open) {
+			closeTo(keep)
+		}
 
-	if lastEnd < len(text) {
-		buf.WriteString(text[lastEnd:])
+		segment := text[start:end]
+		if keep < len(facet.Features) {
+			trimmed := strings.TrimLeftFunc(segment, unicode.IsSpace)
+			buf.WriteString(pending + segment[:len(segment)-len(trimmed)])
+			pending = ""
+			segment = trimmed
+			for _, feature := range facet.Features[keep:] {
+				opening, _ := facetMarkers(feature)
+				buf.WriteString(opening)
+				open = append(open, feature)
+			}
+		}
+
+		trimmed := strings.TrimRightFunc(segment, unicode.IsSpace)
+		buf.WriteString(pending + trimmed)
+		pending = segment[len(trimmed):]
+		pos = end
 	}
 
+	closeTo(0)
+	buf.WriteString(text[pos:])
 	return buf.String()
 }
 
-// listToMarkdown converts a list to markdown with proper indentation
-func (c *MarkdownConverter) listToMarkdown(buf *bytes.Buffer, items []ListItem, depth int) {
-	indent := strings.Repeat("  ", depth)
+// facetMarkers returns the markdown written around text with a facet feature
+func facetMarkers(feature FacetFeature) (string, string) {
+	switch f := feature.(type) {
+	case FacetBold:
+		return "**", "**"
+	case FacetItalic:
+		return "*", "*"
+	case FacetCode:
+		return "`", "`"
+	case FacetStrikethrough:
+		return "~~", "~~"
+	case FacetUnderline:
+		return "<u>", "</u>"
+	case FacetHighlight:
+		return "<mark>", "</mark>"
+	case FacetLink:
+		return "[", "](" + f.URI + ")"
+	case FacetDidMention:
+		return "[", "](" + f.DID + ")"
+	case FacetAtMention:
+		return "[", "](" + f.AtURI + ")"
+	default:
+		return "", ""
+	}
+}
+
+// listToMarkdown converts a list to markdown with proper indentation.
+// start is the number of the first item of an ordered list, or 0 for bullets.
+func (c *MarkdownConverter) listToMarkdown(buf *bytes.Buffer, items []ListItem, indent string, start int) {
+	for i, item := range items {
+		if buf.Len() > 0 && (i > 0 || indent != "") {
+			buf.WriteString("\n")
+		}
 
-	for _, item := range items {
+		marker := "- "
+		if start > 0 {
+			marker = strconv.Itoa(start+i) + ". "
+		}
 		buf.WriteString(indent)
-		buf.WriteString("- ")
+		buf.WriteString(marker)
+
+		if item.Checked != nil {
+			if *item.Checked {
+				buf.WriteString("[x] ")
+			} else {
+				buf.WriteString("[ ] ")
+			}
+		}
 
 		switch content := item.Content.(type) {
 		case TextBlock:
@@ -585,10 +1109,12 @@ func (c *MarkdownConverter) listToMarkdown(buf *bytes.Buffer, items []ListItem,
 			buf.WriteString(c.facetsToMarkdown(content.Plaintext, content.Facets))
 		}
 
-		buf.WriteString("\n")
-
 		if len(item.Children) > 0 {
-			c.listToMarkdown(buf, item.Children, depth+1)
+			childStart := 0
+			if start > 0 {
+				childStart = 1
+			}
+			c.listToMarkdown(buf, item.Children, indent+strings.Repeat(" ", len(marker)), childStart)
 		}
 	}
 }
@@ -1,6 +1,8 @@
 package public
 
 import (
+	"encoding/json"
+	"flag"
 	"image"
 	"image/png"
 	"os"
@@ -369,6 +371,41 @@ This is a paragraph.
 		})
 	})
 
+	t.Run("Facets from leaflet", func(t *testing.T) {
+		t.Run("moves whitespace outside markers", func(t *testing.T) {
+			blocks := []BlockWrap{{Type: TypeBlock, Block: TextBlock{
+				Type:      TypeTextBlock,
+				Plaintext: "some bold text",
+				Facets: []Facet{{
+					Type:     TypeFacet,
+					Index:    ByteSlice{Type: TypeByteSlice, ByteStart: 4, ByteEnd: 10},
+					Features: []FacetFeature{FacetBold{Type: TypeFacetBold}},
+				}},
+			}}}
+
+			markdown, err := converter.FromLeaflet(blocks)
+			shared.AssertNoError(t, err, "FromLeaflet should succeed")
+			shared.AssertEqual(t, "some **bold** text", markdown, "whitespace should sit outside the markers")
+		})
+
+		t.Run("keeps shared formats open across facets", func(t *testing.T) {
+			bold := FacetBold{Type: TypeFacetBold}
+			italic := FacetItalic{Type: TypeFacetItalic}
+			blocks := []BlockWrap{{Type: TypeBlock, Block: TextBlock{
+				Type:      TypeTextBlock,
+				Plaintext: "bold both",
+				Facets: []Facet{
+					{Type: TypeFacet, Index: ByteSlice{Type: TypeByteSlice, ByteStart: 0, ByteEnd: 5}, Features: []FacetFeature{bold}},
+					{Type: TypeFacet, Index: ByteSlice{Type: TypeByteSlice, ByteStart: 5, ByteEnd: 9}, Features: []FacetFeature{bold, italic}},
+				},
+			}}}
+
+			markdown, err := converter.FromLeaflet(blocks)
+			shared.AssertNoError(t, err, "FromLeaflet should succeed")
+			shared.AssertEqual(t, "**bold *both***", markdown, "bold should stay open")
+		})
+	})
+
 	t.Run("Edge Cases", func(t *testing.T) {
 		t.Run("handles empty markdown", func(t *testing.T) {
 			blocks, err := converter.ToLeaflet("")
@@ -586,6 +623,98 @@ Some text with ![inline](body.png) image.
 			shared.AssertTrue(t, imageCount >= 2, "should find multiple images")
 		})
 
+		t.Run("fetches remote images", func(t *testing.T) {
+			path := createTestImage(t, "remote.png", 64, 32)
+			data, err := os.ReadFile(path)
+			shared.AssertNoError(t, err, "should read image")
+
+			var fetched string
+			resolver := &LocalImageResolver{
+				Fetch: func(url string) ([]byte, error) {
+					fetched = url
+					return data, nil
+				},
+			}
+			converter := NewMarkdownConverter().WithImageResolver(resolver, tmpDir)
+
+			blocks, err := converter.ToLeaflet("![remote](https://example.com/remote.png)")
+			shared.AssertNoError(t, err, "ToLeaflet should succeed")
+			shared.AssertEqual(t, "https://example.com/remote.png", fetched, "should fetch the URL, not a path under the base")
+
+			imgBlock := blocks[0].Block.(ImageBlock)
+			shared.AssertEqual(t, 64, imgBlock.AspectRatio.Width, "width should match")
+		})
+
+		t.Run("rejects remote images without a fetcher", func(t *testing.T) {
+			converter := NewMarkdownConverter().WithImageResolver(&LocalImageResolver{}, tmpDir)
+			_, err := converter.ToLeaflet("![remote](https://example.com/remote.png)")
+			shared.AssertError(t, err, "should error without a fetcher")
+		})
+
+		t.Run("leaves relative images as placeholders without a base path", func(t *testing.T) {
+			converter := NewMarkdownConverter().WithImageResolver(&LocalImageResolver{}, "")
+			blocks, err := converter.ToLeaflet("![rel](relative.png)")
+			shared.AssertNoError(t, err, "ToLeaflet should succeed")
+			shared.AssertEqual(t, "bafkreiplaceholder", blocks[0].Block.(ImageBlock).Image.Ref.Link, "should use placeholder")
+		})
+
+		t.Run("reuses cached and repeated images", func(t *testing.T) {
+			_ = createTestImage(t, "cached.png", 10, 10)
+			_ = createTestImage(t, "fresh.png", 20, 20)
+
+			cache := &memoryBlobCache{blobs: make(map[string]*ImageInfo)}
+			uploads := 0
+			resolver := &LocalImageResolver{
+				Cache: cache,
+				BlobUploader: func(data []byte, mimeType string) (Blob, error) {
+					uploads++
+					return Blob{Type: TypeBlob, Ref: CID{Link: "bafkreiuploaded"}, MimeType: mimeType, Size: len(data)}, nil
+				},
+			}
+
+			converter := NewMarkdownConverter().WithImageResolver(resolver, tmpDir)
+			_, err := converter.ToLeaflet("![a](cached.png)\n\n![b](cached.png)")
+			shared.AssertNoError(t, err, "first conversion should succeed")
+			shared.AssertEqual(t, 1, uploads, "repeated image should upload once")
+			shared.AssertEqual(t, 1, len(cache.blobs), "upload should be cached")
+
+			resolver.Resolved = nil
+			_, err = converter.ToLeaflet("![a](cached.png)\n\n![c](fresh.png)")
+			shared.AssertNoError(t, err, "second conversion should succeed")
+			shared.AssertEqual(t, 2, uploads, "only the new image should upload")
+
+			resolver = &LocalImageResolver{Cache: cache, BlobUploader: resolver.BlobUploader}
+			converter = NewMarkdownConverter().WithImageResolver(resolver, tmpDir)
+			_, err = converter.ToLeaflet("![a](cached.png)")
+			shared.AssertNoError(t, err, "conversion with a fresh resolver should succeed")
+			shared.AssertEqual(t, 2, uploads, "cached image should not upload again")
+			shared.AssertEqual(t, 1, len(resolver.Resolved), "image should be reported")
+			shared.AssertTrue(t, resolver.Resolved[0].Cached, "image should be reported as cached")
+		})
+
+		t.Run("downscales images over the size limit", func(t *testing.T) {
+			path := filepath.Join(tmpDir, "big.png")
+			data := encodePNG(t, noisyImage(300, 200, false))
+			shared.AssertNoError(t, os.WriteFile(path, data, 0o644), "should write image")
+
+			var uploaded int
+			resolver := &LocalImageResolver{
+				MaxSize: len(data) / 3,
+				BlobUploader: func(b []byte, mimeType string) (Blob, error) {
+					uploaded = len(b)
+					return Blob{Type: TypeBlob, Ref: CID{Link: "bafkreibig"}, MimeType: mimeType, Size: len(b)}, nil
+				},
+			}
+			converter := NewMarkdownConverter().WithImageResolver(resolver, tmpDir)
+
+			blocks, err := converter.ToLeaflet("![big](big.png)")
+			shared.AssertNoError(t, err, "ToLeaflet should succeed")
+			shared.AssertTrue(t, uploaded > 0 && uploaded <= len(data)/3, "uploaded blob should fit the limit")
+			shared.AssertTrue(t, resolver.Resolved[0].Resized, "image should be reported as resized")
+			shared.AssertEqual(t, len(data), resolver.Resolved[0].SourceSize, "source size should be reported")
+			shared.AssertEqual(t, "image/jpeg", blocks[0].Block.(ImageBlock).Image.MimeType, "blob should be jpeg")
+		})
+
 		t.Run("preserves image dimensions accurately", func(t *testing.T) {
 			testCases := []struct {
 				name   string
@@ -615,3 +744,73 @@ Some text with ![inline](body.png) image.
 		})
 	})
 }
+
+type memoryBlobCache struct {
+	blobs map[string]*ImageInfo
+}
+
+func (c *memoryBlobCache) LookupBlob(hash string) (*ImageInfo, error) {
+	return c.blobs[hash], nil
+}
+
+func (c *memoryBlobCache) StoreBlob(hash string, info *ImageInfo) error {
+	c.blobs[hash] = info
+	return nil
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/roundtrip")
+
+// TestRoundTripCorpus converts every markdown file in testdata/roundtrip to leaflet
+// blocks, compares them with the golden JSON beside it and converts them back.
+// The markdown and the blocks must both come back unchanged; run with -update
+// after an intended change to the block output.
+func TestRoundTripCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.md"))
+	shared.AssertNoError(t, err, "should list corpus")
+	shared.AssertTrue(t, len(files) > 0, "corpus should not be empty")
+
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		goldenPath := strings.TrimSuffix(path, ".md") + ".json"
+
+		t.Run(name, func(t *testing.T) {
+			converter := NewMarkdownConverter()
+
+			source, err := os.ReadFile(path)
+			shared.AssertNoError(t, err, "should read markdown")
+			markdown := strings.TrimRight(string(source), "\n")
+
+			blocks, err := converter.ToLeaflet(markdown)
+			shared.AssertNoError(t, err, "ToLeaflet should succeed")
+			got, err := json.MarshalIndent(blocks, "", "  ")
+			shared.AssertNoError(t, err, "should encode blocks")
+
+			if *updateGolden {
+				shared.AssertNoError(t, os.WriteFile(goldenPath, append(got, '\n'), 0o644), "should write golden file")
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			shared.AssertNoError(t, err, "should read golden file")
+			if string(got) != strings.TrimRight(string(want), "\n") {
+				t.Fatalf("blocks differ from %s:\n%s", goldenPath, got)
+			}
+
+			var decoded []BlockWrap
+			shared.AssertNoError(t, json.Unmarshal(want, &decoded), "should decode golden file")
+
+			back, err := converter.FromLeaflet(decoded)
+			shared.AssertNoError(t, err, "FromLeaflet should succeed")
+			if back != markdown {
+				t.Fatalf("markdown changed in round trip\n--- want\n%s\n--- got\n%s", markdown, back)
+			}
+
+			again, err := converter.ToLeaflet(back)
+			shared.AssertNoError(t, err, "ToLeaflet should succeed on converted markdown")
+			againJSON, err := json.MarshalIndent(again, "", "  ")
+			shared.AssertNoError(t, err, "should encode blocks")
+			if string(againJSON) != string(got) {
+				t.Fatalf("blocks changed in round trip:\n%s", againJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package public
+
+import (
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// HTMLOptions configures [MarkdownConverter.ToHTML]
+type HTMLOptions struct {
+	// XHTML renders self-closing tags, as required by EPUB content documents
+	XHTML bool
+	// RewriteImage maps an image destination to the one used in the output.
+	// Returning an empty string keeps the original destination.
+	RewriteImage func(dest string) string
+	// RewriteLink maps a link destination to the one used in the output.
+	// Returning an empty string keeps the original destination.
+	RewriteLink func(dest string) string
+}
+
+// ToHTML renders markdown to an HTML fragment using the same parser extensions as [MarkdownConverter.ToLeaflet]
+func (c *MarkdownConverter) ToHTML(md string, opts HTMLOptions) []byte {
+	doc := parser.NewWithExtensions(c.extensions).Parse([]byte(md))
+
+	ast.WalkFunc(doc, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch node := n.(type) {
+		case *ast.Image:
+			if opts.RewriteImage != nil {
+				if dest := opts.RewriteImage(string(node.Destination)); dest != "" {
+					node.Destination = []byte(dest)
+				}
+			}
+		case *ast.Link:
+			if opts.RewriteLink != nil && node.NoteID == 0 {
+				if dest := opts.RewriteLink(string(node.Destination)); dest != "" {
+					node.Destination = []byte(dest)
+				}
+			}
+		}
+		return ast.GoToNext
+	})
+
+	flags := html.CommonFlags
+	if opts.XHTML {
+		// smartypants emits named entities like &ldquo; which aren't defined in XML
+		flags = flags&^html.Smartypants | html.UseXHTML
+	}
+	return markdown.Render(doc, html.NewRenderer(html.RendererOptions{Flags: flags}))
+}
+
+// ImageURLs returns the destinations of all images referenced in markdown, in document order
+func (c *MarkdownConverter) ImageURLs(md string) []string {
+	doc := parser.NewWithExtensions(c.extensions).Parse([]byte(md))
+	return c.gatherImages(doc)
+}
+
+// LinkURLs returns the destinations of all links in markdown, in document order, leaving out footnote references
+func (c *MarkdownConverter) LinkURLs(md string) []string {
+	doc := parser.NewWithExtensions(c.extensions).Parse([]byte(md))
+
+	var urls []string
+	ast.WalkFunc(doc, func(n ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := n.(*ast.Link); ok && entering && link.NoteID == 0 {
+			urls = append(urls, string(link.Destination))
+		}
+		return ast.GoToNext
+	})
+	return urls
+}
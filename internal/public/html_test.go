@@ -0,0 +1,55 @@
+package public
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	converter := NewMarkdownConverter()
+
+	t.Run("renders markdown", func(t *testing.T) {
+		out := string(converter.ToHTML("# Title\n\nSome **bold** text", HTMLOptions{}))
+		shared.AssertContains(t, out, `<h1 id="title">Title</h1>`, "heading should render with id")
+		shared.AssertContains(t, out, "<strong>bold</strong>", "bold should render")
+	})
+
+	t.Run("rewrites destinations", func(t *testing.T) {
+		md := "![cat](img/cat.png)\n\n[other](other.md) and [web](https://example.com)"
+		out := string(converter.ToHTML(md, HTMLOptions{
+			RewriteImage: func(dest string) string { return "assets/" + dest },
+			RewriteLink: func(dest string) string {
+				if strings.HasSuffix(dest, ".md") {
+					return "other.html"
+				}
+				return ""
+			},
+		}))
+		shared.AssertContains(t, out, `src="assets/img/cat.png"`, "image should be rewritten")
+		shared.AssertContains(t, out, `href="other.html"`, "note link should be rewritten")
+		shared.AssertContains(t, out, `href="https://example.com"`, "empty rewrite should keep destination")
+	})
+
+	t.Run("xhtml output", func(t *testing.T) {
+		out := string(converter.ToHTML("line \"quoted\"\n\n---\n\n![a](a.png)", HTMLOptions{XHTML: true}))
+		shared.AssertContains(t, out, "<hr />", "void elements should self close")
+		shared.AssertFalse(t, strings.Contains(out, "&ldquo;"), "named entities should not be emitted")
+	})
+}
+
+func TestMarkdownURLs(t *testing.T) {
+	converter := NewMarkdownConverter()
+	md := "![one](a.png) [link](b.md)[^1]\n\n- ![two](https://x.test/c.jpg)\n- [[wiki]] [web](https://example.com)\n\n[^1]: A note."
+
+	images := converter.ImageURLs(md)
+	shared.AssertEqual(t, 2, len(images), "should find both images")
+	shared.AssertEqual(t, "a.png", images[0], "first image")
+	shared.AssertEqual(t, "https://x.test/c.jpg", images[1], "second image")
+
+	links := converter.LinkURLs(md)
+	shared.AssertEqual(t, 2, len(links), "should find both links and skip the footnote")
+	shared.AssertEqual(t, "b.md", links[0], "first link")
+	shared.AssertEqual(t, "https://example.com", links[1], "second link")
+}
@@ -0,0 +1,155 @@
+package public
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// MaxImageBlobSize is the largest image blob accepted by [TypeImageBlock] records
+const MaxImageBlobSize = 1_000_000
+
+// maxImagePixels caps the dimensions of an image FitImage decodes, so a small file declaring
+// huge dimensions cannot allocate gigabytes
+const maxImagePixels = 50_000_000
+
+// jpegQualities are tried in order when re-encoding an image to fit a size limit
+var jpegQualities = []int{90, 80, 70}
+
+// IsRemoteImage reports whether an image destination is an http(s) URL rather than a file path
+func IsRemoteImage(src string) bool {
+	return isHTTPURL(src)
+}
+
+func isHTTPURL(s string) bool {
+	lower := strings.ToLower(s)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// FitImage returns image data no larger than maxSize bytes, with its MIME type and dimensions.
+//
+// Images already within the limit are returned untouched. Larger ones are
+// re-encoded, as JPEG when opaque and PNG when they have transparency, and
+// downscaled until they fit; images over 50 megapixels are refused rather than
+// decoded. A maxSize of zero disables the limit.
+func FitImage(data []byte, maxSize int) ([]byte, string, int, int, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if maxSize <= 0 || len(data) <= maxSize {
+		return data, "image/" + format, cfg.Width, cfg.Height, nil
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > maxImagePixels {
+		return nil, "", 0, 0, fmt.Errorf("image is too large to resize: %dx%d exceeds %d megapixels", cfg.Width, cfg.Height, maxImagePixels/1_000_000)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	src := toRGBA(img)
+	opaque := src.Opaque()
+	scale := 1.0
+
+	for range 12 {
+		w := max(1, int(float64(cfg.Width)*scale))
+		h := max(1, int(float64(cfg.Height)*scale))
+
+		scaled := src
+		if scale < 1 {
+			scaled = downscale(src, w, h)
+		}
+
+		out, mimeType, err := encodeImage(scaled, opaque, maxSize)
+		if err != nil {
+			return nil, "", 0, 0, err
+		}
+		if len(out) <= maxSize {
+			return out, mimeType, w, h, nil
+		}
+
+		// encoded size grows roughly with pixel count, so shrink both sides by the square root
+		scale *= math.Min(0.9, math.Sqrt(float64(maxSize)/float64(len(out)))*0.95)
+	}
+
+	return nil, "", 0, 0, fmt.Errorf("image could not be reduced below %d bytes", maxSize)
+}
+
+// encodeImage encodes img as JPEG when opaque, trying lower qualities until it
+// fits maxSize, or as PNG when it has transparency
+func encodeImage(img image.Image, opaque bool, maxSize int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if !opaque {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	for _, quality := range jpegQualities {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		if buf.Len() <= maxSize {
+			break
+		}
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+// downscale shrinks src to w×h by averaging the source pixels covered by each
+// destination pixel. Averaging premultiplied values keeps edges of transparent
+// areas from darkening.
+func downscale(src *image.RGBA, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+
+	for y := range h {
+		y0 := y * sh / h
+		y1 := max(y0+1, (y+1)*sh/h)
+		for x := range w {
+			x0 := x * sw / w
+			x1 := max(x0+1, (x+1)*sw/w)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,120 @@
+package public
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+// noisyImage returns an image that compresses poorly, so its encoded size is predictable
+func noisyImage(width, height int, alpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			a := uint8(255)
+			if alpha && x < width/2 {
+				a = 128
+			}
+			img.Set(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), a})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	shared.AssertNoError(t, png.Encode(&buf, img), "should encode png")
+	return buf.Bytes()
+}
+
+func TestFitImage(t *testing.T) {
+	t.Run("leaves small images untouched", func(t *testing.T) {
+		data := encodePNG(t, noisyImage(20, 10, false))
+
+		out, mimeType, w, h, err := FitImage(data, MaxImageBlobSize)
+		shared.AssertNoError(t, err, "FitImage should succeed")
+		shared.AssertTrue(t, bytes.Equal(data, out), "data should be unchanged")
+		shared.AssertEqual(t, "image/png", mimeType, "mime type should match")
+		shared.AssertEqual(t, 20, w, "width should match")
+		shared.AssertEqual(t, 10, h, "height should match")
+	})
+
+	t.Run("zero limit disables fitting", func(t *testing.T) {
+		data := encodePNG(t, noisyImage(300, 300, false))
+		out, _, _, _, err := FitImage(data, 0)
+		shared.AssertNoError(t, err, "FitImage should succeed")
+		shared.AssertEqual(t, len(data), len(out), "data should be unchanged")
+	})
+
+	t.Run("re-encodes opaque images as jpeg", func(t *testing.T) {
+		data := encodePNG(t, noisyImage(400, 300, false))
+		limit := len(data) / 2
+
+		out, mimeType, w, h, err := FitImage(data, limit)
+		shared.AssertNoError(t, err, "FitImage should succeed")
+		shared.AssertTrue(t, len(out) <= limit, "output should fit the limit")
+		shared.AssertEqual(t, "image/jpeg", mimeType, "opaque images should become jpeg")
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+		shared.AssertNoError(t, err, "output should be a valid jpeg")
+		shared.AssertEqual(t, w, cfg.Width, "reported width should match output")
+		shared.AssertEqual(t, h, cfg.Height, "reported height should match output")
+	})
+
+	t.Run("downscales transparent images as png", func(t *testing.T) {
+		data := encodePNG(t, noisyImage(400, 200, true))
+		limit := len(data) / 4
+
+		out, mimeType, w, h, err := FitImage(data, limit)
+		shared.AssertNoError(t, err, "FitImage should succeed")
+		shared.AssertTrue(t, len(out) <= limit, "output should fit the limit")
+		shared.AssertEqual(t, "image/png", mimeType, "transparent images should stay png")
+		shared.AssertTrue(t, w < 400 && h < 200, "image should be downscaled")
+
+		ratio := float64(w) / float64(h)
+		shared.AssertTrue(t, ratio > 1.9 && ratio < 2.1, "aspect ratio should be kept")
+
+		img, err := png.Decode(bytes.NewReader(out))
+		shared.AssertNoError(t, err, "output should be a valid png")
+		_, _, _, a := img.At(0, 0).RGBA()
+		shared.AssertTrue(t, a < 0xffff, "transparency should be kept")
+	})
+
+	t.Run("rejects images that declare too many pixels", func(t *testing.T) {
+		data := encodePNG(t, noisyImage(1, 1, false))
+		// rewrite the IHDR chunk to claim 20000x20000 pixels and fix its checksum
+		binary.BigEndian.PutUint32(data[16:], 20000)
+		binary.BigEndian.PutUint32(data[20:], 20000)
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+		_, _, _, _, err := FitImage(data, 10)
+		shared.AssertErrorContains(t, err, "20000x20000 exceeds 50 megapixels", "should refuse to decode")
+
+		_, _, w, h, err := FitImage(data, MaxImageBlobSize)
+		shared.AssertNoError(t, err, "images that fit are not decoded")
+		shared.AssertEqual(t, 20000, w, "should report the declared width")
+		shared.AssertEqual(t, 20000, h, "should report the declared height")
+	})
+
+	t.Run("rejects data that is not an image", func(t *testing.T) {
+		_, _, _, _, err := FitImage([]byte("not an image"), 10)
+		shared.AssertError(t, err, "FitImage should fail")
+	})
+}
+
+func TestIsRemoteImage(t *testing.T) {
+	shared.AssertTrue(t, IsRemoteImage("https://example.com/a.png"), "https should be remote")
+	shared.AssertTrue(t, IsRemoteImage("HTTP://example.com/a.png"), "scheme should be case-insensitive")
+	shared.AssertFalse(t, IsRemoteImage("images/a.png"), "relative path should be local")
+	shared.AssertFalse(t, IsRemoteImage("/tmp/a.png"), "absolute path should be local")
+}
@@ -0,0 +1,1456 @@
+// Code generated by noteleaf tools lexgen from lexdocs/leaflet/; DO NOT EDIT.
+
+package public
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	LexPagesLinearDocumentTextAlignCenter  = "pub.leaflet.pages.linearDocument#textAlignCenter"
+	LexPagesLinearDocumentTextAlignJustify = "pub.leaflet.pages.linearDocument#textAlignJustify"
+	LexPagesLinearDocumentTextAlignLeft    = "pub.leaflet.pages.linearDocument#textAlignLeft"
+	LexPagesLinearDocumentTextAlignRight   = "pub.leaflet.pages.linearDocument#textAlignRight"
+)
+
+func init() {
+	registerSchema("pub.leaflet.document", func(data []byte) (lexRecord, error) {
+		var v LexDocument
+		err := json.Unmarshal(data, &v)
+		return &v, err
+	})
+	registerSchema("pub.leaflet.publication", func(data []byte) (lexRecord, error) {
+		var v LexPublication
+		err := json.Unmarshal(data, &v)
+		return &v, err
+	})
+}
+
+// LexBlocksBlockquote is generated from pub.leaflet.blocks.blockquote
+type LexBlocksBlockquote struct {
+	LexiconTypeID string             `json:"$type,omitempty"`
+	Facets        []LexRichtextFacet `json:"facets,omitempty"`
+	Plaintext     string             `json:"plaintext"`
+}
+
+func (v LexBlocksBlockquote) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksBlockquote
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.blockquote"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksBlockquote) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.blockquote", "plaintext"); err != nil {
+		return err
+	}
+	type raw LexBlocksBlockquote
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.blockquote
+func (v *LexBlocksBlockquote) Validate() error {
+	for i0 := range v.Facets {
+		item0 := &v.Facets[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("facets[%d]", i0), err)
+		}
+	}
+	return nil
+}
+
+// LexBlocksButton is generated from pub.leaflet.blocks.button
+type LexBlocksButton struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	Text          string `json:"text"`
+	URL           string `json:"url"`
+}
+
+func (v LexBlocksButton) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksButton
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.button"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksButton) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.button", "text", "url"); err != nil {
+		return err
+	}
+	type raw LexBlocksButton
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.button
+func (v *LexBlocksButton) Validate() error {
+	if err := checkString("url", v.URL, -1, -1, -1, -1, "uri"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexBlocksCode is generated from pub.leaflet.blocks.code
+type LexBlocksCode struct {
+	LexiconTypeID           string  `json:"$type,omitempty"`
+	Language                *string `json:"language,omitempty"`
+	Plaintext               string  `json:"plaintext"`
+	SyntaxHighlightingTheme *string `json:"syntaxHighlightingTheme,omitempty"`
+}
+
+func (v LexBlocksCode) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksCode
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.code"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksCode) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.code", "plaintext"); err != nil {
+		return err
+	}
+	type raw LexBlocksCode
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.code
+func (v *LexBlocksCode) Validate() error {
+	return nil
+}
+
+// LexBlocksHeader is generated from pub.leaflet.blocks.header
+type LexBlocksHeader struct {
+	LexiconTypeID string             `json:"$type,omitempty"`
+	Facets        []LexRichtextFacet `json:"facets,omitempty"`
+	Level         *int64             `json:"level,omitempty"`
+	Plaintext     string             `json:"plaintext"`
+}
+
+func (v LexBlocksHeader) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksHeader
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.header"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksHeader) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.header", "plaintext"); err != nil {
+		return err
+	}
+	type raw LexBlocksHeader
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.header
+func (v *LexBlocksHeader) Validate() error {
+	for i0 := range v.Facets {
+		item0 := &v.Facets[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("facets[%d]", i0), err)
+		}
+	}
+	if v.Level != nil {
+		if err := checkInteger("level", *v.Level, int64Ptr(1), int64Ptr(6)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LexBlocksHorizontalRule is generated from pub.leaflet.blocks.horizontalRule
+type LexBlocksHorizontalRule struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexBlocksHorizontalRule) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksHorizontalRule
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.horizontalRule"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksHorizontalRule) UnmarshalJSON(data []byte) error {
+	type raw LexBlocksHorizontalRule
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.horizontalRule
+func (v *LexBlocksHorizontalRule) Validate() error {
+	return nil
+}
+
+// LexBlocksIframe is generated from pub.leaflet.blocks.iframe
+type LexBlocksIframe struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	Height        *int64 `json:"height,omitempty"`
+	URL           string `json:"url"`
+}
+
+func (v LexBlocksIframe) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksIframe
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.iframe"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksIframe) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.iframe", "url"); err != nil {
+		return err
+	}
+	type raw LexBlocksIframe
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.iframe
+func (v *LexBlocksIframe) Validate() error {
+	if v.Height != nil {
+		if err := checkInteger("height", *v.Height, int64Ptr(16), int64Ptr(1600)); err != nil {
+			return err
+		}
+	}
+	if err := checkString("url", v.URL, -1, -1, -1, -1, "uri"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexBlocksImage is generated from pub.leaflet.blocks.image
+type LexBlocksImage struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	// Alt text description of the image, for accessibility.
+	Alt         *string                   `json:"alt,omitempty"`
+	AspectRatio LexBlocksImageAspectRatio `json:"aspectRatio"`
+	Image       Blob                      `json:"image"`
+}
+
+func (v LexBlocksImage) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksImage
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.image"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksImage) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.image", "aspectRatio", "image"); err != nil {
+		return err
+	}
+	type raw LexBlocksImage
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.image
+func (v *LexBlocksImage) Validate() error {
+	if err := v.AspectRatio.Validate(); err != nil {
+		return withPath("aspectRatio", err)
+	}
+	if err := checkBlob("image", v.Image, 1000000, "image/*"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexBlocksImageAspectRatio is generated from pub.leaflet.blocks.image#aspectRatio
+type LexBlocksImageAspectRatio struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	Height        int64  `json:"height"`
+	Width         int64  `json:"width"`
+}
+
+func (v LexBlocksImageAspectRatio) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksImageAspectRatio
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.image#aspectRatio"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksImageAspectRatio) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.image#aspectRatio", "height", "width"); err != nil {
+		return err
+	}
+	type raw LexBlocksImageAspectRatio
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.image#aspectRatio
+func (v *LexBlocksImageAspectRatio) Validate() error {
+	if err := checkInteger("height", v.Height, int64Ptr(1), nil); err != nil {
+		return err
+	}
+	if err := checkInteger("width", v.Width, int64Ptr(1), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexBlocksMath is generated from pub.leaflet.blocks.math
+type LexBlocksMath struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	Tex           string `json:"tex"`
+}
+
+func (v LexBlocksMath) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksMath
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.math"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksMath) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.math", "tex"); err != nil {
+		return err
+	}
+	type raw LexBlocksMath
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.math
+func (v *LexBlocksMath) Validate() error {
+	return nil
+}
+
+// LexBlocksOrderedList is generated from pub.leaflet.blocks.orderedList
+type LexBlocksOrderedList struct {
+	LexiconTypeID string                         `json:"$type,omitempty"`
+	Children      []LexBlocksOrderedListListItem `json:"children"`
+	// Number of the first item; 1 when unset.
+	StartIndex *int64 `json:"startIndex,omitempty"`
+}
+
+func (v LexBlocksOrderedList) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksOrderedList
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.orderedList"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksOrderedList) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.orderedList", "children"); err != nil {
+		return err
+	}
+	type raw LexBlocksOrderedList
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.orderedList
+func (v *LexBlocksOrderedList) Validate() error {
+	for i0 := range v.Children {
+		item0 := &v.Children[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("children[%d]", i0), err)
+		}
+	}
+	if v.StartIndex != nil {
+		if err := checkInteger("startIndex", *v.StartIndex, int64Ptr(0), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LexBlocksOrderedListListItem is generated from pub.leaflet.blocks.orderedList#listItem
+type LexBlocksOrderedListListItem struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	// Set for checklist items.
+	Checked  *bool                                    `json:"checked,omitempty"`
+	Children []LexBlocksOrderedListListItem           `json:"children,omitempty"`
+	Content  LexBlocksOrderedListListItemContentUnion `json:"content"`
+}
+
+func (v LexBlocksOrderedListListItem) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksOrderedListListItem
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.orderedList#listItem"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksOrderedListListItem) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.orderedList#listItem", "content"); err != nil {
+		return err
+	}
+	type raw LexBlocksOrderedListListItem
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.orderedList#listItem
+func (v *LexBlocksOrderedListListItem) Validate() error {
+	for i0 := range v.Children {
+		item0 := &v.Children[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("children[%d]", i0), err)
+		}
+	}
+	if err := v.Content.Validate(); err != nil {
+		return withPath("content", err)
+	}
+	return nil
+}
+
+// LexBlocksPoll is generated from pub.leaflet.blocks.poll
+type LexBlocksPoll struct {
+	LexiconTypeID string    `json:"$type,omitempty"`
+	PollRef       StrongRef `json:"pollRef"`
+}
+
+func (v LexBlocksPoll) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksPoll
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.poll"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksPoll) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.poll", "pollRef"); err != nil {
+		return err
+	}
+	type raw LexBlocksPoll
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.poll
+func (v *LexBlocksPoll) Validate() error {
+	return nil
+}
+
+// LexBlocksText is generated from pub.leaflet.blocks.text
+type LexBlocksText struct {
+	LexiconTypeID string             `json:"$type,omitempty"`
+	Facets        []LexRichtextFacet `json:"facets,omitempty"`
+	Plaintext     string             `json:"plaintext"`
+}
+
+func (v LexBlocksText) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksText
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.text"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksText) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.text", "plaintext"); err != nil {
+		return err
+	}
+	type raw LexBlocksText
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.text
+func (v *LexBlocksText) Validate() error {
+	for i0 := range v.Facets {
+		item0 := &v.Facets[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("facets[%d]", i0), err)
+		}
+	}
+	return nil
+}
+
+// LexBlocksUnorderedList is generated from pub.leaflet.blocks.unorderedList
+type LexBlocksUnorderedList struct {
+	LexiconTypeID string                           `json:"$type,omitempty"`
+	Children      []LexBlocksUnorderedListListItem `json:"children"`
+}
+
+func (v LexBlocksUnorderedList) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksUnorderedList
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.unorderedList"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksUnorderedList) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.unorderedList", "children"); err != nil {
+		return err
+	}
+	type raw LexBlocksUnorderedList
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.unorderedList
+func (v *LexBlocksUnorderedList) Validate() error {
+	for i0 := range v.Children {
+		item0 := &v.Children[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("children[%d]", i0), err)
+		}
+	}
+	return nil
+}
+
+// LexBlocksUnorderedListListItem is generated from pub.leaflet.blocks.unorderedList#listItem
+type LexBlocksUnorderedListListItem struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	// Set for checklist items.
+	Checked  *bool                                      `json:"checked,omitempty"`
+	Children []LexBlocksUnorderedListListItem           `json:"children,omitempty"`
+	Content  LexBlocksUnorderedListListItemContentUnion `json:"content"`
+}
+
+func (v LexBlocksUnorderedListListItem) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksUnorderedListListItem
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.unorderedList#listItem"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksUnorderedListListItem) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.unorderedList#listItem", "content"); err != nil {
+		return err
+	}
+	type raw LexBlocksUnorderedListListItem
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.unorderedList#listItem
+func (v *LexBlocksUnorderedListListItem) Validate() error {
+	for i0 := range v.Children {
+		item0 := &v.Children[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("children[%d]", i0), err)
+		}
+	}
+	if err := v.Content.Validate(); err != nil {
+		return withPath("content", err)
+	}
+	return nil
+}
+
+// LexBlocksWebsite is generated from pub.leaflet.blocks.website
+type LexBlocksWebsite struct {
+	LexiconTypeID string  `json:"$type,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	PreviewImage  *Blob   `json:"previewImage,omitempty"`
+	Src           string  `json:"src"`
+	Title         *string `json:"title,omitempty"`
+}
+
+func (v LexBlocksWebsite) MarshalJSON() ([]byte, error) {
+	type raw LexBlocksWebsite
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.blocks.website"
+	return json.Marshal(r)
+}
+
+func (v *LexBlocksWebsite) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.blocks.website", "src"); err != nil {
+		return err
+	}
+	type raw LexBlocksWebsite
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.blocks.website
+func (v *LexBlocksWebsite) Validate() error {
+	if v.PreviewImage != nil {
+		if err := checkBlob("previewImage", *v.PreviewImage, 1000000, "image/*"); err != nil {
+			return err
+		}
+	}
+	if err := checkString("src", v.Src, -1, -1, -1, -1, "uri"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexDocument is generated from pub.leaflet.document
+//
+// Record containing a document
+type LexDocument struct {
+	LexiconTypeID string                  `json:"$type,omitempty"`
+	Author        string                  `json:"author"`
+	Description   *string                 `json:"description,omitempty"`
+	Pages         []LexDocumentPagesUnion `json:"pages"`
+	PostRef       *StrongRef              `json:"postRef,omitempty"`
+	Publication   string                  `json:"publication"`
+	PublishedAt   *string                 `json:"publishedAt,omitempty"`
+	Title         string                  `json:"title"`
+}
+
+func (v LexDocument) MarshalJSON() ([]byte, error) {
+	type raw LexDocument
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.document"
+	return json.Marshal(r)
+}
+
+func (v *LexDocument) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.document", "author", "pages", "publication", "title"); err != nil {
+		return err
+	}
+	type raw LexDocument
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.document
+func (v *LexDocument) Validate() error {
+	if err := checkString("author", v.Author, -1, -1, -1, -1, "at-identifier"); err != nil {
+		return err
+	}
+	if v.Description != nil {
+		if err := checkString("description", *v.Description, -1, 3000, -1, 300, ""); err != nil {
+			return err
+		}
+	}
+	for i0 := range v.Pages {
+		item0 := &v.Pages[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("pages[%d]", i0), err)
+		}
+	}
+	if err := checkString("publication", v.Publication, -1, -1, -1, -1, "at-uri"); err != nil {
+		return err
+	}
+	if v.PublishedAt != nil {
+		if err := checkString("publishedAt", *v.PublishedAt, -1, -1, -1, -1, "datetime"); err != nil {
+			return err
+		}
+	}
+	if err := checkString("title", v.Title, -1, 1280, -1, 128, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexPagesLinearDocument is generated from pub.leaflet.pages.linearDocument
+type LexPagesLinearDocument struct {
+	LexiconTypeID string                        `json:"$type,omitempty"`
+	Blocks        []LexPagesLinearDocumentBlock `json:"blocks"`
+	ID            *string                       `json:"id,omitempty"`
+}
+
+func (v LexPagesLinearDocument) MarshalJSON() ([]byte, error) {
+	type raw LexPagesLinearDocument
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.pages.linearDocument"
+	return json.Marshal(r)
+}
+
+func (v *LexPagesLinearDocument) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.pages.linearDocument", "blocks"); err != nil {
+		return err
+	}
+	type raw LexPagesLinearDocument
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.pages.linearDocument
+func (v *LexPagesLinearDocument) Validate() error {
+	for i0 := range v.Blocks {
+		item0 := &v.Blocks[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("blocks[%d]", i0), err)
+		}
+	}
+	return nil
+}
+
+// LexPagesLinearDocumentBlock is generated from pub.leaflet.pages.linearDocument#block
+type LexPagesLinearDocumentBlock struct {
+	LexiconTypeID string                                `json:"$type,omitempty"`
+	Alignment     *string                               `json:"alignment,omitempty"`
+	Block         LexPagesLinearDocumentBlockBlockUnion `json:"block"`
+}
+
+func (v LexPagesLinearDocumentBlock) MarshalJSON() ([]byte, error) {
+	type raw LexPagesLinearDocumentBlock
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.pages.linearDocument#block"
+	return json.Marshal(r)
+}
+
+func (v *LexPagesLinearDocumentBlock) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.pages.linearDocument#block", "block"); err != nil {
+		return err
+	}
+	type raw LexPagesLinearDocumentBlock
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.pages.linearDocument#block
+func (v *LexPagesLinearDocumentBlock) Validate() error {
+	if err := v.Block.Validate(); err != nil {
+		return withPath("block", err)
+	}
+	return nil
+}
+
+// LexPublication is generated from pub.leaflet.publication
+//
+// Record declaring a publication
+type LexPublication struct {
+	LexiconTypeID string  `json:"$type,omitempty"`
+	BasePath      *string `json:"base_path,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Icon          *Blob   `json:"icon,omitempty"`
+	Name          string  `json:"name"`
+}
+
+func (v LexPublication) MarshalJSON() ([]byte, error) {
+	type raw LexPublication
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.publication"
+	return json.Marshal(r)
+}
+
+func (v *LexPublication) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.publication", "name"); err != nil {
+		return err
+	}
+	type raw LexPublication
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.publication
+func (v *LexPublication) Validate() error {
+	if v.Description != nil {
+		if err := checkString("description", *v.Description, -1, 2000, -1, 200, ""); err != nil {
+			return err
+		}
+	}
+	if v.Icon != nil {
+		if err := checkBlob("icon", *v.Icon, 1000000, "image/*"); err != nil {
+			return err
+		}
+	}
+	if err := checkString("name", v.Name, -1, 2000, -1, 200, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexRichtextFacet is generated from pub.leaflet.richtext.facet
+//
+// Annotation of a sub-string within rich text.
+type LexRichtextFacet struct {
+	LexiconTypeID string                          `json:"$type,omitempty"`
+	Features      []LexRichtextFacetFeaturesUnion `json:"features"`
+	Index         LexRichtextFacetByteSlice       `json:"index"`
+}
+
+func (v LexRichtextFacet) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacet
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacet) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.richtext.facet", "features", "index"); err != nil {
+		return err
+	}
+	type raw LexRichtextFacet
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet
+func (v *LexRichtextFacet) Validate() error {
+	for i0 := range v.Features {
+		item0 := &v.Features[i0]
+		if err := item0.Validate(); err != nil {
+			return withPath(fmt.Sprintf("features[%d]", i0), err)
+		}
+	}
+	if err := v.Index.Validate(); err != nil {
+		return withPath("index", err)
+	}
+	return nil
+}
+
+// LexRichtextFacetAtMention is generated from pub.leaflet.richtext.facet#atMention
+//
+// Facet feature for mentioning an AT URI.
+type LexRichtextFacetAtMention struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	AtURI         string `json:"atURI"`
+}
+
+func (v LexRichtextFacetAtMention) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetAtMention
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#atMention"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetAtMention) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.richtext.facet#atMention", "atURI"); err != nil {
+		return err
+	}
+	type raw LexRichtextFacetAtMention
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#atMention
+func (v *LexRichtextFacetAtMention) Validate() error {
+	if err := checkString("atURI", v.AtURI, -1, -1, -1, -1, "uri"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexRichtextFacetBold is generated from pub.leaflet.richtext.facet#bold
+//
+// Facet feature for bold text
+type LexRichtextFacetBold struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetBold) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetBold
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#bold"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetBold) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetBold
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#bold
+func (v *LexRichtextFacetBold) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetByteSlice is generated from pub.leaflet.richtext.facet#byteSlice
+//
+// Specifies the sub-string range a facet feature applies to. Start index is inclusive, end index is exclusive. Indices are zero-indexed, counting bytes of the UTF-8 encoded text.
+type LexRichtextFacetByteSlice struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	ByteEnd       int64  `json:"byteEnd"`
+	ByteStart     int64  `json:"byteStart"`
+}
+
+func (v LexRichtextFacetByteSlice) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetByteSlice
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#byteSlice"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetByteSlice) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.richtext.facet#byteSlice", "byteEnd", "byteStart"); err != nil {
+		return err
+	}
+	type raw LexRichtextFacetByteSlice
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#byteSlice
+func (v *LexRichtextFacetByteSlice) Validate() error {
+	if err := checkInteger("byteEnd", v.ByteEnd, int64Ptr(0), nil); err != nil {
+		return err
+	}
+	if err := checkInteger("byteStart", v.ByteStart, int64Ptr(0), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexRichtextFacetCode is generated from pub.leaflet.richtext.facet#code
+//
+// Facet feature for inline code.
+type LexRichtextFacetCode struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetCode) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetCode
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#code"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetCode) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetCode
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#code
+func (v *LexRichtextFacetCode) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetDidMention is generated from pub.leaflet.richtext.facet#didMention
+//
+// Facet feature for mentioning a did.
+type LexRichtextFacetDidMention struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	DID           string `json:"did"`
+}
+
+func (v LexRichtextFacetDidMention) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetDidMention
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#didMention"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetDidMention) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.richtext.facet#didMention", "did"); err != nil {
+		return err
+	}
+	type raw LexRichtextFacetDidMention
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#didMention
+func (v *LexRichtextFacetDidMention) Validate() error {
+	if err := checkString("did", v.DID, -1, -1, -1, -1, "did"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LexRichtextFacetHighlight is generated from pub.leaflet.richtext.facet#highlight
+//
+// Facet feature for highlighted text.
+type LexRichtextFacetHighlight struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetHighlight) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetHighlight
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#highlight"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetHighlight) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetHighlight
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#highlight
+func (v *LexRichtextFacetHighlight) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetItalic is generated from pub.leaflet.richtext.facet#italic
+//
+// Facet feature for italic text
+type LexRichtextFacetItalic struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetItalic) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetItalic
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#italic"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetItalic) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetItalic
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#italic
+func (v *LexRichtextFacetItalic) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetLink is generated from pub.leaflet.richtext.facet#link
+//
+// Facet feature for a URL. The text URL may have been simplified or truncated, but the facet reference should be a complete URL.
+type LexRichtextFacetLink struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+	URI           string `json:"uri"`
+}
+
+func (v LexRichtextFacetLink) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetLink
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#link"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetLink) UnmarshalJSON(data []byte) error {
+	if err := checkRequired(data, "pub.leaflet.richtext.facet#link", "uri"); err != nil {
+		return err
+	}
+	type raw LexRichtextFacetLink
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#link
+func (v *LexRichtextFacetLink) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetStrikethrough is generated from pub.leaflet.richtext.facet#strikethrough
+//
+// Facet feature for strikethrough markup
+type LexRichtextFacetStrikethrough struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetStrikethrough) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetStrikethrough
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#strikethrough"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetStrikethrough) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetStrikethrough
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#strikethrough
+func (v *LexRichtextFacetStrikethrough) Validate() error {
+	return nil
+}
+
+// LexRichtextFacetUnderline is generated from pub.leaflet.richtext.facet#underline
+//
+// Facet feature for underline markup
+type LexRichtextFacetUnderline struct {
+	LexiconTypeID string `json:"$type,omitempty"`
+}
+
+func (v LexRichtextFacetUnderline) MarshalJSON() ([]byte, error) {
+	type raw LexRichtextFacetUnderline
+	r := raw(v)
+	r.LexiconTypeID = "pub.leaflet.richtext.facet#underline"
+	return json.Marshal(r)
+}
+
+func (v *LexRichtextFacetUnderline) UnmarshalJSON(data []byte) error {
+	type raw LexRichtextFacetUnderline
+	return json.Unmarshal(data, (*raw)(v))
+}
+
+// Validate checks v against the constraints of pub.leaflet.richtext.facet#underline
+func (v *LexRichtextFacetUnderline) Validate() error {
+	return nil
+}
+
+// LexBlocksOrderedListListItemContentUnion holds one of the types allowed in LexBlocksOrderedListListItem.Content, decoded by $type as a pointer:
+//
+//   - LexBlocksText (pub.leaflet.blocks.text)
+//   - LexBlocksHeader (pub.leaflet.blocks.header)
+//   - LexBlocksImage (pub.leaflet.blocks.image)
+//
+// The union is open, so values of any other $type are kept as json.RawMessage.
+type LexBlocksOrderedListListItemContentUnion struct {
+	Value any
+}
+
+func (u LexBlocksOrderedListListItemContentUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Value)
+}
+
+func (u *LexBlocksOrderedListListItemContentUnion) UnmarshalJSON(data []byte) error {
+	var t TypeCheck
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "":
+		return schemaErrorf("$type", "required field is missing")
+	case "pub.leaflet.blocks.text":
+		var v LexBlocksText
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.header":
+		var v LexBlocksHeader
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.image":
+		var v LexBlocksImage
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	default:
+		u.Value = json.RawMessage(data)
+	}
+	return nil
+}
+
+// Validate checks the union's value against its lexicon
+func (u *LexBlocksOrderedListListItemContentUnion) Validate() error {
+	switch v := u.Value.(type) {
+	case *LexBlocksText:
+		return v.Validate()
+	case *LexBlocksHeader:
+		return v.Validate()
+	case *LexBlocksImage:
+		return v.Validate()
+	}
+	return nil
+}
+
+// LexBlocksUnorderedListListItemContentUnion holds one of the types allowed in LexBlocksUnorderedListListItem.Content, decoded by $type as a pointer:
+//
+//   - LexBlocksText (pub.leaflet.blocks.text)
+//   - LexBlocksHeader (pub.leaflet.blocks.header)
+//   - LexBlocksImage (pub.leaflet.blocks.image)
+//
+// The union is open, so values of any other $type are kept as json.RawMessage.
+type LexBlocksUnorderedListListItemContentUnion struct {
+	Value any
+}
+
+func (u LexBlocksUnorderedListListItemContentUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Value)
+}
+
+func (u *LexBlocksUnorderedListListItemContentUnion) UnmarshalJSON(data []byte) error {
+	var t TypeCheck
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "":
+		return schemaErrorf("$type", "required field is missing")
+	case "pub.leaflet.blocks.text":
+		var v LexBlocksText
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.header":
+		var v LexBlocksHeader
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.image":
+		var v LexBlocksImage
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	default:
+		u.Value = json.RawMessage(data)
+	}
+	return nil
+}
+
+// Validate checks the union's value against its lexicon
+func (u *LexBlocksUnorderedListListItemContentUnion) Validate() error {
+	switch v := u.Value.(type) {
+	case *LexBlocksText:
+		return v.Validate()
+	case *LexBlocksHeader:
+		return v.Validate()
+	case *LexBlocksImage:
+		return v.Validate()
+	}
+	return nil
+}
+
+// LexDocumentPagesUnion holds one of the types allowed in LexDocument.Pages, decoded by $type as a pointer:
+//
+//   - LexPagesLinearDocument (pub.leaflet.pages.linearDocument)
+//
+// The union is open, so values of any other $type are kept as json.RawMessage.
+type LexDocumentPagesUnion struct {
+	Value any
+}
+
+func (u LexDocumentPagesUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Value)
+}
+
+func (u *LexDocumentPagesUnion) UnmarshalJSON(data []byte) error {
+	var t TypeCheck
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "":
+		return schemaErrorf("$type", "required field is missing")
+	case "pub.leaflet.pages.linearDocument":
+		var v LexPagesLinearDocument
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	default:
+		u.Value = json.RawMessage(data)
+	}
+	return nil
+}
+
+// Validate checks the union's value against its lexicon
+func (u *LexDocumentPagesUnion) Validate() error {
+	switch v := u.Value.(type) {
+	case *LexPagesLinearDocument:
+		return v.Validate()
+	}
+	return nil
+}
+
+// LexPagesLinearDocumentBlockBlockUnion holds one of the types allowed in LexPagesLinearDocumentBlock.Block, decoded by $type as a pointer:
+//
+//   - LexBlocksIframe (pub.leaflet.blocks.iframe)
+//   - LexBlocksText (pub.leaflet.blocks.text)
+//   - LexBlocksBlockquote (pub.leaflet.blocks.blockquote)
+//   - LexBlocksHeader (pub.leaflet.blocks.header)
+//   - LexBlocksImage (pub.leaflet.blocks.image)
+//   - LexBlocksUnorderedList (pub.leaflet.blocks.unorderedList)
+//   - LexBlocksOrderedList (pub.leaflet.blocks.orderedList)
+//   - LexBlocksWebsite (pub.leaflet.blocks.website)
+//   - LexBlocksMath (pub.leaflet.blocks.math)
+//   - LexBlocksCode (pub.leaflet.blocks.code)
+//   - LexBlocksHorizontalRule (pub.leaflet.blocks.horizontalRule)
+//   - LexBlocksButton (pub.leaflet.blocks.button)
+//   - LexBlocksPoll (pub.leaflet.blocks.poll)
+//
+// The union is open, so values of any other $type are kept as json.RawMessage.
+type LexPagesLinearDocumentBlockBlockUnion struct {
+	Value any
+}
+
+func (u LexPagesLinearDocumentBlockBlockUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Value)
+}
+
+func (u *LexPagesLinearDocumentBlockBlockUnion) UnmarshalJSON(data []byte) error {
+	var t TypeCheck
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "":
+		return schemaErrorf("$type", "required field is missing")
+	case "pub.leaflet.blocks.iframe":
+		var v LexBlocksIframe
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.text":
+		var v LexBlocksText
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.blockquote":
+		var v LexBlocksBlockquote
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.header":
+		var v LexBlocksHeader
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.image":
+		var v LexBlocksImage
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.unorderedList":
+		var v LexBlocksUnorderedList
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.orderedList":
+		var v LexBlocksOrderedList
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.website":
+		var v LexBlocksWebsite
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.math":
+		var v LexBlocksMath
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.code":
+		var v LexBlocksCode
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.horizontalRule":
+		var v LexBlocksHorizontalRule
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.button":
+		var v LexBlocksButton
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.blocks.poll":
+		var v LexBlocksPoll
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	default:
+		u.Value = json.RawMessage(data)
+	}
+	return nil
+}
+
+// Validate checks the union's value against its lexicon
+func (u *LexPagesLinearDocumentBlockBlockUnion) Validate() error {
+	switch v := u.Value.(type) {
+	case *LexBlocksIframe:
+		return v.Validate()
+	case *LexBlocksText:
+		return v.Validate()
+	case *LexBlocksBlockquote:
+		return v.Validate()
+	case *LexBlocksHeader:
+		return v.Validate()
+	case *LexBlocksImage:
+		return v.Validate()
+	case *LexBlocksUnorderedList:
+		return v.Validate()
+	case *LexBlocksOrderedList:
+		return v.Validate()
+	case *LexBlocksWebsite:
+		return v.Validate()
+	case *LexBlocksMath:
+		return v.Validate()
+	case *LexBlocksCode:
+		return v.Validate()
+	case *LexBlocksHorizontalRule:
+		return v.Validate()
+	case *LexBlocksButton:
+		return v.Validate()
+	case *LexBlocksPoll:
+		return v.Validate()
+	}
+	return nil
+}
+
+// LexRichtextFacetFeaturesUnion holds one of the types allowed in LexRichtextFacet.Features, decoded by $type as a pointer:
+//
+//   - LexRichtextFacetLink (pub.leaflet.richtext.facet#link)
+//   - LexRichtextFacetDidMention (pub.leaflet.richtext.facet#didMention)
+//   - LexRichtextFacetAtMention (pub.leaflet.richtext.facet#atMention)
+//   - LexRichtextFacetCode (pub.leaflet.richtext.facet#code)
+//   - LexRichtextFacetHighlight (pub.leaflet.richtext.facet#highlight)
+//   - LexRichtextFacetUnderline (pub.leaflet.richtext.facet#underline)
+//   - LexRichtextFacetStrikethrough (pub.leaflet.richtext.facet#strikethrough)
+//   - LexRichtextFacetBold (pub.leaflet.richtext.facet#bold)
+//   - LexRichtextFacetItalic (pub.leaflet.richtext.facet#italic)
+//
+// The union is open, so values of any other $type are kept as json.RawMessage.
+type LexRichtextFacetFeaturesUnion struct {
+	Value any
+}
+
+func (u LexRichtextFacetFeaturesUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Value)
+}
+
+func (u *LexRichtextFacetFeaturesUnion) UnmarshalJSON(data []byte) error {
+	var t TypeCheck
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "":
+		return schemaErrorf("$type", "required field is missing")
+	case "pub.leaflet.richtext.facet#link":
+		var v LexRichtextFacetLink
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#didMention":
+		var v LexRichtextFacetDidMention
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#atMention":
+		var v LexRichtextFacetAtMention
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#code":
+		var v LexRichtextFacetCode
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#highlight":
+		var v LexRichtextFacetHighlight
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#underline":
+		var v LexRichtextFacetUnderline
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#strikethrough":
+		var v LexRichtextFacetStrikethrough
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#bold":
+		var v LexRichtextFacetBold
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	case "pub.leaflet.richtext.facet#italic":
+		var v LexRichtextFacetItalic
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		u.Value = &v
+	default:
+		u.Value = json.RawMessage(data)
+	}
+	return nil
+}
+
+// Validate checks the union's value against its lexicon
+func (u *LexRichtextFacetFeaturesUnion) Validate() error {
+	switch v := u.Value.(type) {
+	case *LexRichtextFacetLink:
+		return v.Validate()
+	case *LexRichtextFacetDidMention:
+		return v.Validate()
+	case *LexRichtextFacetAtMention:
+		return v.Validate()
+	case *LexRichtextFacetCode:
+		return v.Validate()
+	case *LexRichtextFacetHighlight:
+		return v.Validate()
+	case *LexRichtextFacetUnderline:
+		return v.Validate()
+	case *LexRichtextFacetStrikethrough:
+		return v.Validate()
+	case *LexRichtextFacetBold:
+		return v.Validate()
+	case *LexRichtextFacetItalic:
+		return v.Validate()
+	}
+	return nil
+}
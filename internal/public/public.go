@@ -25,7 +25,13 @@ const (
 	TypeImageBlock          = "pub.leaflet.blocks.image"
 	TypeBlockquoteBlock     = "pub.leaflet.blocks.blockquote"
 	TypeUnorderedListBlock  = "pub.leaflet.blocks.unorderedList"
+	TypeOrderedListBlock    = "pub.leaflet.blocks.orderedList"
 	TypeHorizontalRuleBlock = "pub.leaflet.blocks.horizontalRule"
+	TypeMathBlock           = "pub.leaflet.blocks.math"
+	TypeWebsiteBlock        = "pub.leaflet.blocks.website"
+	TypeButtonBlock         = "pub.leaflet.blocks.button"
+	TypePollBlock           = "pub.leaflet.blocks.poll"
+	TypeIframeBlock         = "pub.leaflet.blocks.iframe"
 
 	TypeFacet          = "pub.leaflet.richtext.facet"
 	TypeByteSlice      = "pub.leaflet.richtext.facet#byteSlice"
@@ -36,20 +42,23 @@ const (
 	TypeFacetStrike    = "pub.leaflet.richtext.facet#strikethrough"
 	TypeFacetUnderline = "pub.leaflet.richtext.facet#underline"
 	TypeFacetHighlight = "pub.leaflet.richtext.facet#highlight"
+	TypeFacetDid       = "pub.leaflet.richtext.facet#didMention"
+	TypeFacetAtURI     = "pub.leaflet.richtext.facet#atMention"
 
-	TypeListItem    = "pub.leaflet.blocks.unorderedList#listItem"
-	TypeAspectRatio = "pub.leaflet.blocks.image#aspectRatio"
-	TypeBlob        = "blob"
+	TypeListItem        = "pub.leaflet.blocks.unorderedList#listItem"
+	TypeOrderedListItem = "pub.leaflet.blocks.orderedList#listItem"
+	TypeAspectRatio     = "pub.leaflet.blocks.image#aspectRatio"
+	TypeBlob            = "blob"
 )
 
 // Document represents a leaflet document (pub.leaflet.document)
 type Document struct {
 	Type        string           `json:"$type"`
-	Author      string           `json:"author"`      // DID (Decentralized Identifier)
-	Title       string           `json:"title"`       // Max 128 graphemes
-	Description string           `json:"description"` // Max 300 graphemes
-	PublishedAt string           `json:"publishedAt"` // ISO8601 datetime
-	Publication string           `json:"publication"` // URI: at://did/pub.leaflet.publication/rkey
+	Author      string           `json:"author"`                // DID (Decentralized Identifier)
+	Title       string           `json:"title"`                 // Max 128 graphemes
+	Description string           `json:"description"`           // Max 300 graphemes
+	PublishedAt string           `json:"publishedAt,omitempty"` // ISO8601 datetime; unset for drafts
+	Publication string           `json:"publication"`           // URI: at://did/pub.leaflet.publication/rkey
 	Pages       []LinearDocument `json:"pages"`
 }
 
@@ -131,12 +140,48 @@ func (bw *BlockWrap) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		bw.Block = block
+	case TypeOrderedListBlock:
+		var block OrderedListBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
 	case TypeHorizontalRuleBlock:
 		var block HorizontalRuleBlock
 		if err := json.Unmarshal(temp.Block, &block); err != nil {
 			return err
 		}
 		bw.Block = block
+	case TypeMathBlock:
+		var block MathBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
+	case TypeWebsiteBlock:
+		var block WebsiteBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
+	case TypeButtonBlock:
+		var block ButtonBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
+	case TypePollBlock:
+		var block PollBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
+	case TypeIframeBlock:
+		var block IframeBlock
+		if err := json.Unmarshal(temp.Block, &block); err != nil {
+			return err
+		}
+		bw.Block = block
 	default:
 		var block map[string]any
 		if err := json.Unmarshal(temp.Block, &block); err != nil {
@@ -199,10 +244,19 @@ type UnorderedListBlock struct {
 	Children []ListItem `json:"children"`
 }
 
-// ListItem represents a single list item (pub.leaflet.blocks.unorderedList#listItem)
+// OrderedListBlock represents a numbered list (pub.leaflet.blocks.orderedList)
+type OrderedListBlock struct {
+	Type       string     `json:"$type"`
+	StartIndex int        `json:"startIndex,omitempty"` // number of the first item; 1 when unset
+	Children   []ListItem `json:"children"`
+}
+
+// ListItem represents a single list item (pub.leaflet.blocks.unorderedList#listItem or
+// pub.leaflet.blocks.orderedList#listItem). Nested items belong to the same kind of list.
 type ListItem struct {
 	Type     string     `json:"$type"`
 	Content  any        `json:"content"`            // [TextBlock], [HeaderBlock], [ImageBlock]
+	Checked  *bool      `json:"checked,omitempty"`  // set for checklist items
 	Children []ListItem `json:"children,omitempty"` // Nested list items
 }
 
@@ -263,6 +317,47 @@ type HorizontalRuleBlock struct {
 	Type string `json:"$type"`
 }
 
+// MathBlock represents a display LaTeX equation (pub.leaflet.blocks.math)
+type MathBlock struct {
+	Type string `json:"$type"`
+	Tex  string `json:"tex"`
+}
+
+// WebsiteBlock represents a link preview card (pub.leaflet.blocks.website)
+type WebsiteBlock struct {
+	Type         string `json:"$type"`
+	Src          string `json:"src"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	PreviewImage *Blob  `json:"previewImage,omitempty"`
+}
+
+// ButtonBlock represents a call-to-action link button (pub.leaflet.blocks.button)
+type ButtonBlock struct {
+	Type string `json:"$type"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// PollBlock embeds a poll defined in a separate pub.leaflet.poll.definition record (pub.leaflet.blocks.poll)
+type PollBlock struct {
+	Type    string    `json:"$type"`
+	PollRef StrongRef `json:"pollRef"`
+}
+
+// IframeBlock embeds another page (pub.leaflet.blocks.iframe)
+type IframeBlock struct {
+	Type   string `json:"$type"`
+	URL    string `json:"url"`
+	Height int    `json:"height,omitempty"` // pixels, 16 - 1600
+}
+
+// StrongRef points at a specific version of a record (com.atproto.repo.strongRef)
+type StrongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
 // Facet represents text annotation (pub.leaflet.richtext.facet)
 type Facet struct {
 	Type     string         `json:"$type"`
@@ -335,6 +430,18 @@ func (f *Facet) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			feature = fh
+		case TypeFacetDid:
+			var fd FacetDidMention
+			if err := json.Unmarshal(featureData, &fd); err != nil {
+				return err
+			}
+			feature = fd
+		case TypeFacetAtURI:
+			var fa FacetAtMention
+			if err := json.Unmarshal(featureData, &fa); err != nil {
+				return err
+			}
+			feature = fa
 		default:
 			// Skip unknown feature types
 			continue
@@ -408,6 +515,22 @@ type FacetHighlight struct {
 
 func (f FacetHighlight) GetFacetType() string { return TypeFacetHighlight }
 
+// FacetDidMention represents a mention of an account by DID
+type FacetDidMention struct {
+	Type string `json:"$type"`
+	DID  string `json:"did"`
+}
+
+func (f FacetDidMention) GetFacetType() string { return TypeFacetDid }
+
+// FacetAtMention represents a mention of a record or account by AT URI
+type FacetAtMention struct {
+	Type  string `json:"$type"`
+	AtURI string `json:"atURI"`
+}
+
+func (f FacetAtMention) GetFacetType() string { return TypeFacetAtURI }
+
 // Blob represents binary content (images, files)
 type Blob struct {
 	Type     string `json:"$type"`
@@ -423,10 +546,11 @@ type CID struct {
 
 // Publication represents a leaflet publication (pub.leaflet.publication)
 type Publication struct {
-	Type        string    `json:"$type"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"createdAt"`
+	Type        string `json:"$type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	BasePath    string `json:"base_path,omitempty"` // domain and path the publication is served from
+	Icon        *Blob  `json:"icon,omitempty"`
 }
 
 // DocumentMeta holds metadata about a fetched document
@@ -188,6 +188,55 @@ func TestBlockWrap(t *testing.T) {
 			shared.AssertEqual(t, "pub.leaflet.blocks.unknown", block["$type"], "type should be preserved")
 		})
 
+		t.Run("unmarshals ordered list block", func(t *testing.T) {
+			jsonData := `{
+				"$type": "pub.leaflet.pages.linearDocument#block",
+				"block": {
+					"$type": "pub.leaflet.blocks.orderedList",
+					"startIndex": 4,
+					"children": [{
+						"$type": "pub.leaflet.blocks.orderedList#listItem",
+						"checked": true,
+						"content": {"$type": "pub.leaflet.blocks.text", "plaintext": "Fourth"}
+					}]
+				}
+			}`
+
+			var bw BlockWrap
+			err := json.Unmarshal([]byte(jsonData), &bw)
+			shared.AssertNoError(t, err, "unmarshal should succeed")
+
+			block, ok := bw.Block.(OrderedListBlock)
+			shared.AssertTrue(t, ok, "block should be OrderedListBlock")
+			shared.AssertEqual(t, 4, block.StartIndex, "start index should match")
+			shared.AssertEqual(t, 1, len(block.Children), "should have one item")
+			shared.AssertTrue(t, block.Children[0].Checked != nil && *block.Children[0].Checked, "item should be checked")
+
+			content, ok := block.Children[0].Content.(TextBlock)
+			shared.AssertTrue(t, ok, "content should be TextBlock")
+			shared.AssertEqual(t, "Fourth", content.Plaintext, "plaintext should match")
+		})
+
+		t.Run("unmarshals embed blocks", func(t *testing.T) {
+			jsonData := `[
+				{"$type": "pub.leaflet.pages.linearDocument#block", "block": {"$type": "pub.leaflet.blocks.math", "tex": "x^2"}},
+				{"$type": "pub.leaflet.pages.linearDocument#block", "block": {"$type": "pub.leaflet.blocks.website", "src": "https://example.com", "title": "Example"}},
+				{"$type": "pub.leaflet.pages.linearDocument#block", "block": {"$type": "pub.leaflet.blocks.button", "text": "Go", "url": "https://example.com/go"}},
+				{"$type": "pub.leaflet.pages.linearDocument#block", "block": {"$type": "pub.leaflet.blocks.poll", "pollRef": {"uri": "at://did:plc:a/pub.leaflet.poll.definition/1", "cid": "bafyrei"}}},
+				{"$type": "pub.leaflet.pages.linearDocument#block", "block": {"$type": "pub.leaflet.blocks.iframe", "url": "https://example.com/embed", "height": 300}}
+			]`
+
+			var blocks []BlockWrap
+			err := json.Unmarshal([]byte(jsonData), &blocks)
+			shared.AssertNoError(t, err, "unmarshal should succeed")
+
+			shared.AssertEqual(t, "x^2", blocks[0].Block.(MathBlock).Tex, "tex should match")
+			shared.AssertEqual(t, "Example", blocks[1].Block.(WebsiteBlock).Title, "title should match")
+			shared.AssertEqual(t, "https://example.com/go", blocks[2].Block.(ButtonBlock).URL, "url should match")
+			shared.AssertEqual(t, "bafyrei", blocks[3].Block.(PollBlock).PollRef.CID, "poll cid should match")
+			shared.AssertEqual(t, 300, blocks[4].Block.(IframeBlock).Height, "height should match")
+		})
+
 		t.Run("handles block with alignment", func(t *testing.T) {
 			jsonData := `{
 				"$type": "pub.leaflet.pages.linearDocument#block",
@@ -454,6 +503,40 @@ func TestFacet(t *testing.T) {
 			shared.AssertEqual(t, "https://example.com", link.URI, "URI should match")
 		})
 
+		t.Run("unmarshals mention facets", func(t *testing.T) {
+			jsonData := `{
+				"$type": "pub.leaflet.richtext.facet",
+				"index": {
+					"$type": "pub.leaflet.richtext.facet#byteSlice",
+					"byteStart": 0,
+					"byteEnd": 6
+				},
+				"features": [
+					{
+						"$type": "pub.leaflet.richtext.facet#didMention",
+						"did": "did:plc:alice"
+					},
+					{
+						"$type": "pub.leaflet.richtext.facet#atMention",
+						"atURI": "at://did:plc:alice/pub.leaflet.document/1"
+					}
+				]
+			}`
+
+			var f Facet
+			err := json.Unmarshal([]byte(jsonData), &f)
+			shared.AssertNoError(t, err, "unmarshal should succeed")
+			shared.AssertEqual(t, 2, len(f.Features), "should have both features")
+
+			did, ok := f.Features[0].(FacetDidMention)
+			shared.AssertTrue(t, ok, "feature should be FacetDidMention")
+			shared.AssertEqual(t, "did:plc:alice", did.DID, "DID should match")
+
+			at, ok := f.Features[1].(FacetAtMention)
+			shared.AssertTrue(t, ok, "feature should be FacetAtMention")
+			shared.AssertEqual(t, TypeFacetAtURI, at.GetFacetType(), "facet type should match")
+		})
+
 		t.Run("unmarshals strikethrough facet", func(t *testing.T) {
 			jsonData := `{
 				"$type": "pub.leaflet.richtext.facet",
@@ -0,0 +1,314 @@
+package public
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/rivo/uniseg"
+)
+
+// ErrNoSchema is returned by [ValidateRecord] when no lexicon validator was
+// generated for a record type. Run `noteleaf tools fetch lexicons` followed by
+// `noteleaf tools lexgen` to generate them.
+var ErrNoSchema = errors.New("no lexicon schema generated")
+
+// lexRecord is a record type generated from a lexicon
+type lexRecord interface {
+	Validate() error
+}
+
+// schemaDecoders decode record JSON into its generated lexicon type, keyed by NSID.
+// Entries are registered by the init function of the file written by `noteleaf tools lexgen`.
+var schemaDecoders = map[string]func(data []byte) (lexRecord, error){}
+
+func registerSchema(nsid string, decode func(data []byte) (lexRecord, error)) {
+	schemaDecoders[nsid] = decode
+}
+
+// HasSchema reports whether a lexicon validator was generated for nsid
+func HasSchema(nsid string) bool {
+	_, ok := schemaDecoders[nsid]
+	return ok
+}
+
+// ValidateRecord checks a record against the generated lexicon for nsid.
+//
+// The record may be raw JSON or any value that marshals to the record's JSON.
+// Decoding enforces required fields and the generated Validate methods check
+// string lengths, graphemes, formats, enums, integer ranges and array sizes.
+func ValidateRecord(nsid string, record any) error {
+	_, v, err := decodeRecord(nsid, record)
+	if err != nil {
+		return err
+	}
+	return v.Validate()
+}
+
+// LexiconRecord converts a record built from the hand-written types, such as a [Document], into
+// the JSON written to the PDS by encoding it with the type generated for nsid. This keeps the
+// lexicon the source of truth for the record's shape: a field the lexicon does not define, or a
+// value it would change, is reported as a [SchemaError] rather than sent. Constraints such as
+// lengths are left to [ValidateRecord] and the PDS.
+func LexiconRecord(nsid string, record any) (map[string]any, error) {
+	data, v, err := decodeRecord(nsid, record)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s record: %w", nsid, err)
+	}
+
+	var want, got map[string]any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		return nil, err
+	}
+	if path, ok := lostValue("", want, got); ok {
+		return nil, schemaErrorf(path, "is not defined by the %s lexicon", nsid)
+	}
+	return got, nil
+}
+
+func decodeRecord(nsid string, record any) ([]byte, lexRecord, error) {
+	decode, ok := schemaDecoders[nsid]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w for %s", ErrNoSchema, nsid)
+	}
+
+	data, ok := record.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(record); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal record: %w", err)
+		}
+	}
+	v, err := decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, v, nil
+}
+
+// lostValue reports the path of the first value in want that is missing or different in got.
+// Keys got adds, such as a $type the lexicon types always write, are allowed.
+func lostValue(path string, want, got any) (string, bool) {
+	switch want := want.(type) {
+	case map[string]any:
+		gotMap, ok := got.(map[string]any)
+		if !ok {
+			return path, true
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			gotValue, ok := gotMap[key]
+			if !ok {
+				if want[key] == nil {
+					continue // null and omitted are the same record
+				}
+				return child, true
+			}
+			if lost, ok := lostValue(child, want[key], gotValue); ok {
+				return lost, true
+			}
+		}
+		return "", false
+	case []any:
+		gotSlice, ok := got.([]any)
+		if !ok || len(gotSlice) != len(want) {
+			return path, true
+		}
+		for i := range want {
+			if lost, ok := lostValue(fmt.Sprintf("%s[%d]", path, i), want[i], gotSlice[i]); ok {
+				return lost, true
+			}
+		}
+		return "", false
+	default:
+		if want != got {
+			return path, true
+		}
+		return "", false
+	}
+}
+
+// SchemaError reports a value that violates a lexicon constraint
+type SchemaError struct {
+	Path    string // dotted path to the offending field, e.g. pages[0].blocks[2].block.plaintext
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// schemaErrorf builds a [SchemaError] for the field at path
+func schemaErrorf(path, format string, args ...any) error {
+	return &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// withPath prefixes the path of a nested [SchemaError] with its parent field
+func withPath(parent string, err error) error {
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		return fmt.Errorf("%s: %w", parent, err)
+	}
+
+	path := parent
+	switch {
+	case schemaErr.Path == "":
+	case strings.HasPrefix(schemaErr.Path, "["):
+		path += schemaErr.Path
+	default:
+		path += "." + schemaErr.Path
+	}
+	return &SchemaError{Path: path, Message: schemaErr.Message}
+}
+
+// checkRequired reports the first property required by the lexicon nsid that is
+// missing (or null) in an object's JSON
+func checkRequired(data []byte, nsid string, fields ...string) error {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, field := range fields {
+		if raw, ok := props[field]; !ok || string(raw) == "null" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return schemaErrorf(missing[0], "required by %s but missing", nsid)
+}
+
+// checkString validates a string against the length, grapheme and format
+// constraints of its lexicon; negative limits are unset
+func checkString(path, s string, minLen, maxLen, minGraphemes, maxGraphemes int, format string) error {
+	if minLen >= 0 && len(s) < minLen {
+		return schemaErrorf(path, "must be at least %d bytes, got %d", minLen, len(s))
+	}
+	if maxLen >= 0 && len(s) > maxLen {
+		return schemaErrorf(path, "must be at most %d bytes, got %d", maxLen, len(s))
+	}
+	if minGraphemes >= 0 || maxGraphemes >= 0 {
+		count := uniseg.GraphemeClusterCount(s)
+		if minGraphemes >= 0 && count < minGraphemes {
+			return schemaErrorf(path, "must be at least %d graphemes, got %d", minGraphemes, count)
+		}
+		if maxGraphemes >= 0 && count > maxGraphemes {
+			return schemaErrorf(path, "must be at most %d graphemes, got %d", maxGraphemes, count)
+		}
+	}
+	if format != "" {
+		if err := checkFormat(format, s); err != nil {
+			return schemaErrorf(path, "invalid %s: %v", format, err)
+		}
+	}
+	return nil
+}
+
+// checkFormat validates the lexicon string formats with their atproto syntax parsers
+func checkFormat(format, s string) error {
+	var err error
+	switch format {
+	case "at-identifier":
+		_, err = syntax.ParseAtIdentifier(s)
+	case "at-uri":
+		_, err = syntax.ParseATURI(s)
+	case "cid":
+		_, err = syntax.ParseCID(s)
+	case "datetime":
+		_, err = syntax.ParseDatetime(s)
+	case "did":
+		_, err = syntax.ParseDID(s)
+	case "handle":
+		_, err = syntax.ParseHandle(s)
+	case "language":
+		_, err = syntax.ParseLanguage(s)
+	case "nsid":
+		_, err = syntax.ParseNSID(s)
+	case "record-key":
+		_, err = syntax.ParseRecordKey(s)
+	case "tid":
+		_, err = syntax.ParseTID(s)
+	case "uri":
+		_, err = syntax.ParseURI(s)
+	}
+	return err
+}
+
+// checkEnum validates a value against the closed set of values allowed by its lexicon
+func checkEnum[T comparable](path string, v T, allowed ...T) error {
+	if slices.Contains(allowed, v) {
+		return nil
+	}
+	return schemaErrorf(path, "must be one of %v, got %v", allowed, v)
+}
+
+// checkInteger validates an integer against its lexicon's minimum and maximum
+func checkInteger(path string, v int64, minimum, maximum *int64) error {
+	if minimum != nil && v < *minimum {
+		return schemaErrorf(path, "must be at least %d, got %d", *minimum, v)
+	}
+	if maximum != nil && v > *maximum {
+		return schemaErrorf(path, "must be at most %d, got %d", *maximum, v)
+	}
+	return nil
+}
+
+// checkLength validates the number of items in an array; negative limits are unset
+func checkLength(path string, n, minLen, maxLen int) error {
+	if minLen >= 0 && n < minLen {
+		return schemaErrorf(path, "must have at least %d items, got %d", minLen, n)
+	}
+	if maxLen >= 0 && n > maxLen {
+		return schemaErrorf(path, "must have at most %d items, got %d", maxLen, n)
+	}
+	return nil
+}
+
+// checkBlob validates a blob's MIME type and size against the lexicon's accept
+// list, which may contain wildcards like image/*, and maxSize; negative limits are unset
+func checkBlob(path string, b Blob, maxSize int, accept ...string) error {
+	if maxSize >= 0 && b.Size > maxSize {
+		return schemaErrorf(path, "blob must be at most %d bytes, got %d", maxSize, b.Size)
+	}
+	if len(accept) == 0 {
+		return nil
+	}
+	for _, pattern := range accept {
+		if pattern == "*/*" || pattern == b.MimeType {
+			return nil
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(b.MimeType, prefix) {
+			return nil
+		}
+	}
+	return schemaErrorf(path, "blob type %q is not one of %v", b.MimeType, accept)
+}
+
+// int64Ptr is used by generated validators for integer bounds
+func int64Ptr(v int64) *int64 { return &v }
@@ -0,0 +1,173 @@
+package public
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+// stubRecord stands in for a generated record type, so these tests do not depend on lexicon_gen.go
+type stubRecord struct{}
+
+func (stubRecord) Validate() error { return nil }
+
+func TestValidateRecord(t *testing.T) {
+	t.Run("reports missing schemas", func(t *testing.T) {
+		err := ValidateRecord("pub.leaflet.test.missing", map[string]string{})
+		shared.AssertTrue(t, errors.Is(err, ErrNoSchema), "should report ErrNoSchema")
+		shared.AssertFalse(t, HasSchema("pub.leaflet.test.missing"), "should not have a schema")
+	})
+
+	t.Run("passes record JSON to the registered validator", func(t *testing.T) {
+		const nsid = "pub.leaflet.test.record"
+		var got string
+		registerSchema(nsid, func(data []byte) (lexRecord, error) {
+			got = string(data)
+			return stubRecord{}, checkRequired(data, nsid, "title")
+		})
+		t.Cleanup(func() { delete(schemaDecoders, nsid) })
+
+		shared.AssertTrue(t, HasSchema(nsid), "should have a schema")
+		shared.AssertNoError(t, ValidateRecord(nsid, map[string]string{"title": "x"}), "should validate values")
+		shared.AssertEqual(t, `{"title":"x"}`, got, "should marshal values")
+
+		err := ValidateRecord(nsid, []byte(`{"title":null}`))
+		shared.AssertErrorContains(t, err, "title: required by pub.leaflet.test.record but missing", "should reject null required fields")
+	})
+
+	t.Run("accepts documents built from the round trip corpus", func(t *testing.T) {
+		files, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.json"))
+		shared.AssertNoError(t, err, "should list corpus")
+		shared.AssertTrue(t, len(files) > 0, "corpus should not be empty")
+
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			shared.AssertNoError(t, err, "should read "+path)
+
+			var blocks []BlockWrap
+			shared.AssertNoError(t, json.Unmarshal(data, &blocks), "should decode "+path)
+
+			doc := Document{
+				Type:        TypeDocument,
+				Author:      "did:plc:abc123",
+				Title:       filepath.Base(path),
+				PublishedAt: "2024-01-02T03:04:05Z",
+				Publication: "at://did:plc:abc123/pub.leaflet.publication/3k2a",
+				Pages:       []LinearDocument{{Type: TypeLinearDocument, Blocks: blocks}},
+			}
+			shared.AssertNoError(t, ValidateRecord(TypeDocument, doc), path+" should match the document lexicon")
+		}
+	})
+
+	t.Run("rejects documents that break the lexicon", func(t *testing.T) {
+		doc := Document{
+			Type:        TypeDocument,
+			Author:      "did:plc:abc123",
+			Title:       strings.Repeat("x", 129),
+			Publication: "at://did:plc:abc123/pub.leaflet.publication/3k2a",
+			Pages:       []LinearDocument{{Type: TypeLinearDocument, Blocks: []BlockWrap{}}},
+		}
+		err := ValidateRecord(TypeDocument, doc)
+		shared.AssertErrorContains(t, err, "title: must be at most 128 graphemes", "should limit the title")
+
+		err = ValidateRecord(TypeDocument, []byte(`{"$type":"pub.leaflet.document","author":"did:plc:abc123","title":"x","publication":"at://did:plc:abc123/pub.leaflet.publication/3k2a"}`))
+		shared.AssertErrorContains(t, err, "pages: required by pub.leaflet.document but missing", "should require pages")
+	})
+}
+
+func TestLexiconRecord(t *testing.T) {
+	t.Run("encodes documents through the lexicon types without losing fields", func(t *testing.T) {
+		files, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.json"))
+		shared.AssertNoError(t, err, "should list corpus")
+
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			shared.AssertNoError(t, err, "should read "+path)
+
+			var blocks []BlockWrap
+			shared.AssertNoError(t, json.Unmarshal(data, &blocks), "should decode "+path)
+
+			doc := Document{
+				Type:        TypeDocument,
+				Author:      "did:plc:abc123",
+				Title:       filepath.Base(path),
+				Publication: "at://did:plc:abc123/pub.leaflet.publication/3k2a",
+				Pages:       []LinearDocument{{Type: TypeLinearDocument, Blocks: blocks}},
+			}
+			record, err := LexiconRecord(TypeDocument, doc)
+			shared.AssertNoError(t, err, path+" should convert")
+			shared.AssertEqual(t, TypeDocument, record["$type"], "should set the record type")
+		}
+	})
+
+	t.Run("encodes publications", func(t *testing.T) {
+		pub := Publication{Type: TypePublication, Name: "Notes", BasePath: "notes.example.com"}
+		record, err := LexiconRecord(TypePublication, pub)
+		shared.AssertNoError(t, err, "should convert")
+		shared.AssertEqual(t, "notes.example.com", record["base_path"], "should keep the base path")
+		_, hasDescription := record["description"]
+		shared.AssertFalse(t, hasDescription, "should omit unset fields")
+	})
+
+	t.Run("rejects fields the lexicon does not define", func(t *testing.T) {
+		_, err := LexiconRecord(TypePublication, []byte(`{"$type":"pub.leaflet.publication","name":"Notes","createdAt":"2024-01-02T03:04:05Z"}`))
+		shared.AssertErrorContains(t, err, "createdAt: is not defined by the pub.leaflet.publication lexicon", "should report the lost field")
+
+		_, err = LexiconRecord(TypeDocument, []byte(`{"author":"did:plc:abc123","title":"x","publication":"at://did:plc:abc123/pub.leaflet.publication/3k2a",`+
+			`"pages":[{"$type":"pub.leaflet.pages.linearDocument","blocks":[{"$type":"pub.leaflet.pages.linearDocument#block","block":{"$type":"pub.leaflet.blocks.text","plaintext":"hi","color":"red"}}]}]}`))
+		shared.AssertErrorContains(t, err, "pages[0].blocks[0].block.color: is not defined", "should report nested fields")
+	})
+
+	t.Run("requires fields the lexicon requires", func(t *testing.T) {
+		_, err := LexiconRecord(TypeDocument, Document{Title: "x"})
+		shared.AssertErrorContains(t, err, "pages: required by pub.leaflet.document but missing", "should require pages")
+	})
+}
+
+func TestSchemaChecks(t *testing.T) {
+	t.Run("strings", func(t *testing.T) {
+		shared.AssertNoError(t, checkString("title", "héllo", -1, 6, -1, 5, ""), "should count bytes and graphemes")
+		shared.AssertErrorContains(t, checkString("title", "héllo", -1, 5, -1, -1, ""), "at most 5 bytes", "should limit bytes")
+		shared.AssertErrorContains(t, checkString("title", "👍🏽👍🏽", -1, -1, -1, 1, ""), "at most 1 graphemes, got 2", "should count grapheme clusters")
+		shared.AssertErrorContains(t, checkString("title", "", 1, -1, -1, -1, ""), "at least 1 bytes", "should require a minimum length")
+		shared.AssertErrorContains(t, checkString("title", "", -1, -1, 1, -1, ""), "at least 1 graphemes", "should require minimum graphemes")
+	})
+
+	t.Run("formats", func(t *testing.T) {
+		shared.AssertNoError(t, checkString("author", "did:plc:abc123", -1, -1, -1, -1, "did"), "should accept a DID")
+		shared.AssertNoError(t, checkString("publishedAt", "2024-01-02T03:04:05Z", -1, -1, -1, -1, "datetime"), "should accept a datetime")
+		shared.AssertErrorContains(t, checkString("publishedAt", "", -1, -1, -1, -1, "datetime"), "invalid datetime", "should reject an empty datetime")
+		shared.AssertErrorContains(t, checkString("publication", "https://example.com", -1, -1, -1, -1, "at-uri"), "invalid at-uri", "should reject a non AT URI")
+		shared.AssertNoError(t, checkString("x", "anything", -1, -1, -1, -1, "unknown-format"), "should ignore unknown formats")
+	})
+
+	t.Run("enums and integers", func(t *testing.T) {
+		shared.AssertNoError(t, checkEnum("align", "left", "left", "right"), "should accept a listed value")
+		shared.AssertErrorContains(t, checkEnum("align", "up", "left", "right"), "must be one of [left right], got up", "should reject other values")
+		shared.AssertNoError(t, checkInteger("level", 3, int64Ptr(1), int64Ptr(6)), "should accept values in range")
+		shared.AssertErrorContains(t, checkInteger("level", 0, int64Ptr(1), nil), "at least 1", "should enforce the minimum")
+		shared.AssertErrorContains(t, checkInteger("level", 7, nil, int64Ptr(6)), "at most 6", "should enforce the maximum")
+		shared.AssertErrorContains(t, checkLength("pages", 0, 1, -1), "at least 1 items", "should enforce array sizes")
+	})
+
+	t.Run("blobs", func(t *testing.T) {
+		img := Blob{MimeType: "image/png", Size: 100}
+		shared.AssertNoError(t, checkBlob("image", img, 1000, "image/*"), "should match wildcard types")
+		shared.AssertErrorContains(t, checkBlob("image", img, 50, "image/*"), "at most 50 bytes", "should enforce the size limit")
+		shared.AssertErrorContains(t, checkBlob("image", img, -1, "image/jpeg"), "is not one of", "should enforce accepted types")
+	})
+
+	t.Run("nested paths", func(t *testing.T) {
+		err := withPath("pages[0]", withPath("blocks[2]", withPath("block", schemaErrorf("plaintext", "too long"))))
+		shared.AssertEqual(t, "pages[0].blocks[2].block.plaintext: too long", err.Error(), "should join nested paths")
+
+		var schemaErr *SchemaError
+		shared.AssertTrue(t, errors.As(err, &schemaErr), "should be a SchemaError")
+		shared.AssertTrue(t, strings.HasPrefix(schemaErr.Path, "pages[0]"), "should keep the full path")
+	})
+}
@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+func (r *ArticleRepository) scanFeed(s scanner) (*models.ArticleFeed, error) {
+	var feed models.ArticleFeed
+	var siteURL, etag, lastModified, include, exclude, tags, lastError sql.NullString
+	if err := s.Scan(&feed.ID, &feed.URL, &feed.Title, &siteURL, &etag, &lastModified, &include, &exclude,
+		&feed.MaxAge, &tags, &feed.LastFetched, &lastError, &feed.Created, &feed.Modified); err != nil {
+		return nil, err
+	}
+	feed.SiteURL = siteURL.String
+	feed.ETag = etag.String
+	feed.LastModified = lastModified.String
+	feed.LastError = lastError.String
+	if err := feed.UnmarshalFilters(include.String, exclude.String, tags.String); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal feed filters: %w", err)
+	}
+	return &feed, nil
+}
+
+func (r *ArticleRepository) validateFeed(feed *models.ArticleFeed) error {
+	validator := services.NewValidator()
+	validator.Check(services.RequiredString("URL", feed.URL))
+	validator.Check(services.ValidURL("URL", feed.URL))
+	validator.Check(services.RequiredString("Title", feed.Title))
+	if feed.MaxAge < 0 {
+		validator.Check(services.NewValidationError("MaxAge", "cannot be negative"))
+	}
+	return validator.Errors()
+}
+
+// CreateFeed stores a new feed subscription and returns its assigned ID
+func (r *ArticleRepository) CreateFeed(ctx context.Context, feed *models.ArticleFeed) (int64, error) {
+	if err := r.validateFeed(feed); err != nil {
+		return 0, err
+	}
+
+	include, exclude, tags, err := feed.MarshalFilters()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal feed filters: %w", err)
+	}
+
+	now := time.Now()
+	feed.Created = now
+	feed.Modified = now
+
+	result, err := r.db.ExecContext(ctx, queryArticleFeedInsert,
+		feed.URL, feed.Title, feed.SiteURL, feed.ETag, feed.LastModified, include, exclude,
+		feed.MaxAge, tags, feed.LastFetched, feed.LastError, feed.Created, feed.Modified)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert feed: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	feed.ID = id
+	return id, nil
+}
+
+// GetFeed retrieves a feed subscription by its ID
+func (r *ArticleRepository) GetFeed(ctx context.Context, id int64) (*models.ArticleFeed, error) {
+	feed, err := r.scanFeed(r.db.QueryRowContext(ctx, queryArticleFeedByID, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("feed with id %d not found", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	return feed, nil
+}
+
+// GetFeedByURL returns the subscription to a feed URL, or nil if there is none
+func (r *ArticleRepository) GetFeedByURL(ctx context.Context, url string) (*models.ArticleFeed, error) {
+	feed, err := r.scanFeed(r.db.QueryRowContext(ctx, queryArticleFeedByURL, url))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	return feed, nil
+}
+
+// ListFeeds returns every feed subscription in the order they were added
+func (r *ArticleRepository) ListFeeds(ctx context.Context) ([]*models.ArticleFeed, error) {
+	rows, err := r.db.QueryContext(ctx, queryArticleFeedsList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []*models.ArticleFeed
+	for rows.Next() {
+		feed, err := r.scanFeed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// UpdateFeed saves a feed subscription's settings and refresh state
+func (r *ArticleRepository) UpdateFeed(ctx context.Context, feed *models.ArticleFeed) error {
+	if err := r.validateFeed(feed); err != nil {
+		return err
+	}
+
+	include, exclude, tags, err := feed.MarshalFilters()
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed filters: %w", err)
+	}
+
+	feed.Modified = time.Now()
+	result, err := r.db.ExecContext(ctx, queryArticleFeedUpdate,
+		feed.URL, feed.Title, feed.SiteURL, feed.ETag, feed.LastModified, include, exclude,
+		feed.MaxAge, tags, feed.LastFetched, feed.LastError, feed.Modified, feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update feed: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("feed with id %d not found", feed.ID)
+	}
+	return nil
+}
+
+// DeleteFeed removes a feed subscription and its item records. Articles saved from it are kept.
+func (r *ArticleRepository) DeleteFeed(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, queryArticleFeedDelete, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete feed: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("feed with id %d not found", id)
+	}
+	return nil
+}
+
+// HasFeedItem reports whether the entry with guid has already been saved from the feed
+func (r *ArticleRepository) HasFeedItem(ctx context.Context, feedID int64, guid string) (bool, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, queryArticleFeedItemExists, feedID, guid).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check feed item: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AddFeedItem records a saved feed entry. Recording the same entry again is a no-op.
+func (r *ArticleRepository) AddFeedItem(ctx context.Context, item *models.ArticleFeedItem) error {
+	if item.Added.IsZero() {
+		item.Added = time.Now()
+	}
+	if _, err := r.db.ExecContext(ctx, queryArticleFeedItemInsert,
+		item.FeedID, item.GUID, item.URL, item.ArticleID, item.Added); err != nil {
+		return fmt.Errorf("failed to record feed item: %w", err)
+	}
+	return nil
+}
+
+// CountFeedItems returns how many entries have been saved from the feed
+func (r *ArticleRepository) CountFeedItems(ctx context.Context, feedID int64) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, queryArticleFeedItemsCount, feedID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count feed items: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestArticleFeeds(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewArticleRepository(db)
+
+	feed := &models.ArticleFeed{
+		URL:     "https://example.com/feed.xml",
+		Title:   "Example Feed",
+		Include: []string{"go", "sqlite"},
+		Exclude: []string{"sponsored"},
+		MaxAge:  14,
+		Tags:    []string{"feeds"},
+	}
+
+	t.Run("create and get keep filters", func(t *testing.T) {
+		id, err := repo.CreateFeed(ctx, feed)
+		shared.AssertNoError(t, err, "CreateFeed should succeed")
+
+		stored, err := repo.GetFeed(ctx, id)
+		shared.AssertNoError(t, err, "GetFeed should succeed")
+		shared.AssertEqual(t, "go,sqlite", strings.Join(stored.Include, ","), "include mismatch")
+		shared.AssertEqual(t, "sponsored", strings.Join(stored.Exclude, ","), "exclude mismatch")
+		shared.AssertEqual(t, "feeds", strings.Join(stored.Tags, ","), "tags mismatch")
+		shared.AssertEqual(t, 14, stored.MaxAge, "max age mismatch")
+		shared.AssertTrue(t, stored.LastFetched == nil, "new feeds have not been fetched")
+
+		byURL, err := repo.GetFeedByURL(ctx, feed.URL)
+		shared.AssertNoError(t, err, "GetFeedByURL should succeed")
+		shared.AssertEqual(t, id, byURL.ID, "GetFeedByURL returned the wrong feed")
+
+		missing, err := repo.GetFeedByURL(ctx, "https://example.com/other.xml")
+		shared.AssertNoError(t, err, "GetFeedByURL should not fail for unknown feeds")
+		shared.AssertTrue(t, missing == nil, "unknown feeds should be nil")
+
+		_, err = repo.CreateFeed(ctx, &models.ArticleFeed{URL: feed.URL, Title: "Duplicate"})
+		shared.AssertError(t, err, "duplicate feed URLs should fail")
+		_, err = repo.CreateFeed(ctx, &models.ArticleFeed{URL: "not a url", Title: "Invalid"})
+		shared.AssertError(t, err, "invalid feed URLs should fail")
+	})
+
+	t.Run("update stores refresh state", func(t *testing.T) {
+		fetched := time.Now()
+		feed.ETag = `"abc"`
+		feed.LastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+		feed.LastFetched = &fetched
+		feed.LastError = "HTTP error: 500"
+		shared.AssertNoError(t, repo.UpdateFeed(ctx, feed), "UpdateFeed should succeed")
+
+		feeds, err := repo.ListFeeds(ctx)
+		shared.AssertNoError(t, err, "ListFeeds should succeed")
+		shared.AssertEqual(t, 1, len(feeds), "expected one feed")
+		shared.AssertEqual(t, `"abc"`, feeds[0].ETag, "ETag mismatch")
+		shared.AssertEqual(t, feed.LastModified, feeds[0].LastModified, "Last-Modified mismatch")
+		shared.AssertEqual(t, "HTTP error: 500", feeds[0].LastError, "last error mismatch")
+		shared.AssertTrue(t, feeds[0].LastFetched != nil, "last fetched should be set")
+	})
+
+	t.Run("items are recorded once", func(t *testing.T) {
+		item := &models.ArticleFeedItem{FeedID: feed.ID, GUID: "entry-1", URL: "https://example.com/1", ArticleID: 7}
+		shared.AssertNoError(t, repo.AddFeedItem(ctx, item), "AddFeedItem should succeed")
+		shared.AssertNoError(t, repo.AddFeedItem(ctx, item), "recording an item again should be a no-op")
+
+		seen, err := repo.HasFeedItem(ctx, feed.ID, "entry-1")
+		shared.AssertNoError(t, err, "HasFeedItem should succeed")
+		shared.AssertTrue(t, seen, "recorded items should be seen")
+
+		seen, err = repo.HasFeedItem(ctx, feed.ID, "entry-2")
+		shared.AssertNoError(t, err, "HasFeedItem should succeed")
+		shared.AssertFalse(t, seen, "other items should not be seen")
+
+		count, err := repo.CountFeedItems(ctx, feed.ID)
+		shared.AssertNoError(t, err, "CountFeedItems should succeed")
+		shared.AssertEqual(t, 1, count, "expected one item")
+	})
+
+	t.Run("delete removes the feed and its items", func(t *testing.T) {
+		shared.AssertNoError(t, repo.DeleteFeed(ctx, feed.ID), "DeleteFeed should succeed")
+		shared.AssertError(t, repo.DeleteFeed(ctx, feed.ID), "deleting a missing feed should fail")
+
+		_, err := repo.GetFeed(ctx, feed.ID)
+		shared.AssertError(t, err, "deleted feeds should not be found")
+
+		count, err := repo.CountFeedItems(ctx, feed.ID)
+		shared.AssertNoError(t, err, "CountFeedItems should succeed")
+		shared.AssertEqual(t, 0, count, "items should be deleted with the feed")
+	})
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/services"
+)
+
+func (r *ArticleRepository) validateHighlight(highlight *models.ArticleHighlight) error {
+	validator := services.NewValidator()
+	validator.Check(services.RequiredString("Quote", highlight.Quote))
+	if highlight.ArticleID <= 0 {
+		validator.Check(services.NewValidationError("ArticleID", "is required"))
+	}
+	if highlight.Start < 0 || highlight.End <= highlight.Start {
+		validator.Check(services.NewValidationError("End", "must come after Start"))
+	}
+	return validator.Errors()
+}
+
+// CreateHighlight stores a highlight on an article and returns its assigned ID
+func (r *ArticleRepository) CreateHighlight(ctx context.Context, highlight *models.ArticleHighlight) (int64, error) {
+	if err := r.validateHighlight(highlight); err != nil {
+		return 0, err
+	}
+
+	highlight.Created = time.Now()
+	result, err := r.db.ExecContext(ctx, queryArticleHighlightInsert,
+		highlight.ArticleID, highlight.Quote, highlight.Note, highlight.Start, highlight.End, highlight.Created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert highlight: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	highlight.ID = id
+	return id, nil
+}
+
+// ListHighlights returns an article's highlights in the order they appear in the article
+func (r *ArticleRepository) ListHighlights(ctx context.Context, articleID int64) ([]*models.ArticleHighlight, error) {
+	rows, err := r.db.QueryContext(ctx, queryArticleHighlightsList, articleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query highlights: %w", err)
+	}
+	defer rows.Close()
+
+	var highlights []*models.ArticleHighlight
+	for rows.Next() {
+		var highlight models.ArticleHighlight
+		var note sql.NullString
+		if err := rows.Scan(&highlight.ID, &highlight.ArticleID, &highlight.Quote, &note,
+			&highlight.Start, &highlight.End, &highlight.Created); err != nil {
+			return nil, fmt.Errorf("failed to scan highlight: %w", err)
+		}
+		highlight.Note = note.String
+		highlights = append(highlights, &highlight)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over highlights: %w", err)
+	}
+	return highlights, nil
+}
+
+// DeleteHighlight removes one of an article's highlights
+func (r *ArticleRepository) DeleteHighlight(ctx context.Context, articleID, id int64) error {
+	result, err := r.db.ExecContext(ctx, queryArticleHighlightDelete, id, articleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete highlight: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("highlight %d not found on article %d", id, articleID)
+	}
+	return nil
+}
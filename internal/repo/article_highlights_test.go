@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestArticleHighlights(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewArticleRepository(db)
+
+	article := CreateSampleArticle()
+	_, err := repo.Create(ctx, article)
+	shared.AssertNoError(t, err, "Create should succeed")
+
+	t.Run("create and list in article order", func(t *testing.T) {
+		second := &models.ArticleHighlight{ArticleID: article.ID, Quote: "later text", Start: 40, End: 50}
+		first := &models.ArticleHighlight{ArticleID: article.ID, Quote: "early text", Note: "remember this", Start: 5, End: 15}
+		_, err := repo.CreateHighlight(ctx, second)
+		shared.AssertNoError(t, err, "CreateHighlight should succeed")
+		_, err = repo.CreateHighlight(ctx, first)
+		shared.AssertNoError(t, err, "CreateHighlight should succeed")
+
+		highlights, err := repo.ListHighlights(ctx, article.ID)
+		shared.AssertNoError(t, err, "ListHighlights should succeed")
+		shared.AssertEqual(t, 2, len(highlights), "highlight count mismatch")
+		shared.AssertEqual(t, first.ID, highlights[0].ID, "highlights should be ordered by offset")
+		shared.AssertEqual(t, "remember this", highlights[0].Note, "note mismatch")
+		shared.AssertEqual(t, "", highlights[1].Note, "empty notes should scan")
+		shared.AssertFalse(t, highlights[0].Created.IsZero(), "created should be set")
+	})
+
+	t.Run("validates highlights", func(t *testing.T) {
+		_, err := repo.CreateHighlight(ctx, &models.ArticleHighlight{ArticleID: article.ID, Start: 1, End: 2})
+		shared.AssertError(t, err, "an empty quote should fail")
+		_, err = repo.CreateHighlight(ctx, &models.ArticleHighlight{ArticleID: article.ID, Quote: "x", Start: 5, End: 5})
+		shared.AssertError(t, err, "an empty range should fail")
+		_, err = repo.CreateHighlight(ctx, &models.ArticleHighlight{ArticleID: 9999, Quote: "x", Start: 0, End: 1})
+		shared.AssertError(t, err, "an unknown article should fail")
+	})
+
+	t.Run("delete only removes the article's own highlight", func(t *testing.T) {
+		highlights, err := repo.ListHighlights(ctx, article.ID)
+		shared.AssertNoError(t, err, "ListHighlights should succeed")
+
+		shared.AssertError(t, repo.DeleteHighlight(ctx, article.ID+1, highlights[0].ID), "wrong article should fail")
+		shared.AssertNoError(t, repo.DeleteHighlight(ctx, article.ID, highlights[0].ID), "DeleteHighlight should succeed")
+
+		remaining, err := repo.ListHighlights(ctx, article.ID)
+		shared.AssertNoError(t, err, "ListHighlights should succeed")
+		shared.AssertEqual(t, 1, len(remaining), "one highlight should remain")
+	})
+
+	t.Run("note link round trips and highlights go with the article", func(t *testing.T) {
+		noteID := int64(42)
+		article.NoteID = &noteID
+		shared.AssertNoError(t, repo.Update(ctx, article), "Update should succeed")
+
+		stored, err := repo.Get(ctx, article.ID)
+		shared.AssertNoError(t, err, "Get should succeed")
+		shared.AssertTrue(t, stored.NoteID != nil && *stored.NoteID == 42, "note id should be stored")
+
+		shared.AssertNoError(t, repo.Delete(ctx, article.ID), "Delete should succeed")
+		highlights, err := repo.ListHighlights(ctx, article.ID)
+		shared.AssertNoError(t, err, "ListHighlights should succeed")
+		shared.AssertEqual(t, 0, len(highlights), "highlights should be removed with the article")
+	})
+}
@@ -0,0 +1,241 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+// GetImport returns the reading list import of the export file with hash, or nil if that file was never imported
+func (r *ArticleRepository) GetImport(ctx context.Context, hash string) (*models.ArticleImport, error) {
+	var imp models.ArticleImport
+	err := r.db.QueryRowContext(ctx, queryArticleImportByHash, hash).
+		Scan(&imp.ID, &imp.Source, &imp.Path, &imp.Hash, &imp.Total, &imp.Created, &imp.Modified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return &imp, nil
+}
+
+// CreateImport stores a reading list import and its items, all pending, in a single transaction
+func (r *ArticleRepository) CreateImport(ctx context.Context, imp *models.ArticleImport, items []*models.ArticleImportItem) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	imp.Created = now
+	imp.Modified = now
+	imp.Total = len(items)
+
+	result, err := tx.ExecContext(ctx, queryArticleImportInsert, imp.Source, imp.Path, imp.Hash, imp.Total, imp.Created, imp.Modified)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert import: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	for i, item := range items {
+		tags, err := item.MarshalTags()
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		item.ImportID = id
+		item.Position = i
+		item.State = models.ImportPending
+		if item.Status == "" {
+			item.Status = models.ArticleUnread
+		}
+		if _, err := tx.ExecContext(ctx, queryArticleImportItemInsert, item.ImportID, item.Position, item.URL,
+			item.Title, tags, item.Status, item.Favorite, item.Saved, item.State); err != nil {
+			return 0, fmt.Errorf("failed to insert import item %s: %w", item.URL, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	imp.ID = id
+	return id, nil
+}
+
+// UnfinishedImportItems returns the pending and failed items of an import in file order
+func (r *ArticleRepository) UnfinishedImportItems(ctx context.Context, importID int64) ([]*models.ArticleImportItem, error) {
+	rows, err := r.db.QueryContext(ctx, queryArticleImportItemsUndone, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.ArticleImportItem
+	for rows.Next() {
+		var item models.ArticleImportItem
+		var title, tags, errMsg sql.NullString
+		var saved sql.NullTime
+		var articleID sql.NullInt64
+		if err := rows.Scan(&item.ImportID, &item.Position, &item.URL, &title, &tags, &item.Status,
+			&item.Favorite, &saved, &item.State, &articleID, &errMsg); err != nil {
+			return nil, fmt.Errorf("failed to scan import item: %w", err)
+		}
+		item.Title = title.String
+		item.Saved = saved.Time
+		item.ArticleID = articleID.Int64
+		item.Error = errMsg.String
+		if err := item.UnmarshalTags(tags.String); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over import items: %w", err)
+	}
+	return items, nil
+}
+
+// SetImportItemState records the outcome of an import item
+func (r *ArticleRepository) SetImportItemState(ctx context.Context, item *models.ArticleImportItem) error {
+	if _, err := r.db.ExecContext(ctx, queryArticleImportItemState,
+		item.State, item.ArticleID, item.Error, item.ImportID, item.Position); err != nil {
+		return fmt.Errorf("failed to update import item: %w", err)
+	}
+	return nil
+}
+
+// CountImportItems returns the number of items of an import in each state
+func (r *ArticleRepository) CountImportItems(ctx context.Context, importID int64) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, queryArticleImportItemsCounts, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count import items: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var state string
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan import item count: %w", err)
+		}
+		counts[state] = count
+	}
+	return counts, rows.Err()
+}
+
+// ImportArticle stores an article saved from an import item and marks the item done in a single
+// transaction, so an interrupted import never saves a link twice.
+//
+// Unlike [ArticleRepository.Create], the Created timestamp of the article is kept (falling back
+// to now when unset), so articles keep the time they were saved in the other application.
+func (r *ArticleRepository) ImportArticle(ctx context.Context, article *models.Article, item *models.ArticleImportItem) error {
+	if err := r.Validate(article); err != nil {
+		return err
+	}
+
+	tags, err := article.MarshalTags()
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	citation, err := article.MarshalCitation()
+	if err != nil {
+		return fmt.Errorf("failed to marshal citation: %w", err)
+	}
+
+	now := time.Now()
+	article.Modified = now
+	if article.Created.IsZero() || article.Created.After(now) {
+		article.Created = now
+	}
+	if article.Status == "" {
+		article.Status = models.ArticleUnread
+	}
+	if article.Status == models.ArticleRead {
+		article.Progress = 100
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryArticleInsert,
+		article.URL, article.Title, article.Author, article.Date,
+		article.MarkdownPath, article.HTMLPath, article.Status, article.Favorite, tags,
+		article.Progress, article.WordCount, article.ReadingTime, article.NoteID, citation, article.Created, article.Modified)
+	if err != nil {
+		return fmt.Errorf("failed to insert article: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	item.State = models.ImportDone
+	item.ArticleID = id
+	item.Error = ""
+	if _, err := tx.ExecContext(ctx, queryArticleImportItemState,
+		item.State, item.ArticleID, item.Error, item.ImportID, item.Position); err != nil {
+		return fmt.Errorf("failed to update import item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article import: %w", err)
+	}
+	article.ID = id
+	return nil
+}
+
+// MergeImport stores the reading state an import item gave an article that was already saved
+// (its status, favorite flag, tags, progress and Created timestamp) and marks the item done in
+// a single transaction.
+func (r *ArticleRepository) MergeImport(ctx context.Context, article *models.Article, item *models.ArticleImportItem) error {
+	tags, err := article.MarshalTags()
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	article.Modified = time.Now()
+	if article.Status == models.ArticleRead {
+		article.Progress = 100
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, queryArticleImportMerge,
+		article.Status, article.Favorite, tags, article.Progress, article.Created, article.Modified, article.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return ArticleNotFoundError(article.ID)
+	}
+
+	item.State = models.ImportDone
+	item.ArticleID = article.ID
+	item.Error = ""
+	if _, err := tx.ExecContext(ctx, queryArticleImportItemState,
+		item.State, item.ArticleID, item.Error, item.ImportID, item.Position); err != nil {
+		return fmt.Errorf("failed to update import item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article import: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestArticleImports(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewArticleRepository(db)
+
+	saved := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	imp := &models.ArticleImport{Source: "pocket", Path: "/tmp/ril_export.html", Hash: "abc123"}
+	items := []*models.ArticleImportItem{
+		{URL: "https://example.com/one", Title: "One", Tags: []string{"go"}, Saved: saved},
+		{URL: "https://example.com/two", Title: "Two", Status: models.ArticleArchived, Favorite: true},
+		{URL: "https://example.com/three"},
+	}
+
+	t.Run("create stores pending items", func(t *testing.T) {
+		id, err := repo.CreateImport(ctx, imp, items)
+		shared.AssertNoError(t, err, "CreateImport should succeed")
+		shared.AssertEqual(t, 3, imp.Total, "total mismatch")
+
+		stored, err := repo.GetImport(ctx, "abc123")
+		shared.AssertNoError(t, err, "GetImport should succeed")
+		shared.AssertEqual(t, id, stored.ID, "GetImport returned the wrong import")
+		shared.AssertEqual(t, "pocket", stored.Source, "source mismatch")
+
+		missing, err := repo.GetImport(ctx, "other")
+		shared.AssertNoError(t, err, "GetImport should not fail for unknown hashes")
+		shared.AssertTrue(t, missing == nil, "unknown hashes should be nil")
+
+		unfinished, err := repo.UnfinishedImportItems(ctx, id)
+		shared.AssertNoError(t, err, "UnfinishedImportItems should succeed")
+		shared.AssertEqual(t, 3, len(unfinished), "expected every item to be pending")
+		shared.AssertEqual(t, "go", strings.Join(unfinished[0].Tags, ","), "tags mismatch")
+		shared.AssertTrue(t, unfinished[0].Saved.Equal(saved), "saved time mismatch")
+		shared.AssertEqual(t, models.ArticleUnread, unfinished[0].Status, "status should default to unread")
+		shared.AssertEqual(t, models.ArticleArchived, unfinished[1].Status, "status mismatch")
+		shared.AssertTrue(t, unfinished[1].Favorite, "favorite mismatch")
+		shared.AssertEqual(t, models.ImportPending, unfinished[2].State, "state mismatch")
+	})
+
+	t.Run("import article keeps the saved time and finishes the item", func(t *testing.T) {
+		article := &models.Article{
+			URL:          items[0].URL,
+			Title:        "One",
+			MarkdownPath: "/tmp/one.md",
+			HTMLPath:     "/tmp/one.html",
+			Status:       models.ArticleRead,
+			Tags:         items[0].Tags,
+			Created:      saved,
+		}
+		shared.AssertNoError(t, repo.ImportArticle(ctx, article, items[0]), "ImportArticle should succeed")
+
+		stored, err := repo.Get(ctx, article.ID)
+		shared.AssertNoError(t, err, "Get should succeed")
+		shared.AssertTrue(t, stored.Created.Equal(saved), "created should be the saved time")
+		shared.AssertEqual(t, 100, stored.Progress, "read articles should be complete")
+		shared.AssertEqual(t, models.ImportDone, items[0].State, "item should be done")
+
+		items[1].State = models.ImportFailed
+		items[1].Error = "HTTP error: 404"
+		shared.AssertNoError(t, repo.SetImportItemState(ctx, items[1]), "SetImportItemState should succeed")
+
+		unfinished, err := repo.UnfinishedImportItems(ctx, imp.ID)
+		shared.AssertNoError(t, err, "UnfinishedImportItems should succeed")
+		shared.AssertEqual(t, 2, len(unfinished), "done items should be left out")
+		shared.AssertEqual(t, "HTTP error: 404", unfinished[0].Error, "error mismatch")
+
+		counts, err := repo.CountImportItems(ctx, imp.ID)
+		shared.AssertNoError(t, err, "CountImportItems should succeed")
+		shared.AssertEqual(t, 1, counts[models.ImportDone], "done count mismatch")
+		shared.AssertEqual(t, 1, counts[models.ImportFailed], "failed count mismatch")
+		shared.AssertEqual(t, 1, counts[models.ImportPending], "pending count mismatch")
+	})
+
+	t.Run("import article validates the article", func(t *testing.T) {
+		err := repo.ImportArticle(ctx, &models.Article{URL: "not a url"}, items[2])
+		shared.AssertError(t, err, "invalid articles should fail")
+		shared.AssertEqual(t, models.ImportPending, items[2].State, "failed imports should leave the item pending")
+	})
+
+	t.Run("merge import updates an existing article and finishes the item", func(t *testing.T) {
+		article := &models.Article{
+			URL:          items[2].URL,
+			Title:        "Three",
+			MarkdownPath: "/tmp/three.md",
+			HTMLPath:     "/tmp/three.html",
+		}
+		_, err := repo.Create(ctx, article)
+		shared.AssertNoError(t, err, "Create should succeed")
+
+		article.Status, article.Favorite, article.Tags, article.Created = models.ArticleRead, true, []string{"later"}, saved
+		shared.AssertNoError(t, repo.MergeImport(ctx, article, items[2]), "MergeImport should succeed")
+
+		stored, err := repo.Get(ctx, article.ID)
+		shared.AssertNoError(t, err, "Get should succeed")
+		shared.AssertEqual(t, models.ArticleRead, stored.Status, "status mismatch")
+		shared.AssertEqual(t, 100, stored.Progress, "read articles should be complete")
+		shared.AssertTrue(t, stored.Favorite, "favorite mismatch")
+		shared.AssertEqual(t, "later", strings.Join(stored.Tags, ","), "tags mismatch")
+		shared.AssertTrue(t, stored.Created.Equal(saved), "created should be the saved time")
+		shared.AssertEqual(t, "Three", stored.Title, "title should be kept")
+		shared.AssertEqual(t, models.ImportDone, items[2].State, "item should be done")
+		shared.AssertEqual(t, article.ID, items[2].ArticleID, "item should point at the article")
+
+		err = repo.MergeImport(ctx, &models.Article{ID: 999}, items[2])
+		shared.AssertError(t, err, "merging into a missing article should fail")
+	})
+}
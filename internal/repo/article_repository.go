@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -32,6 +33,11 @@ type ArticleListOptions struct {
 	Author   string
 	DateFrom string
 	DateTo   string
+	Status   string   // only articles with this status
+	Archived *bool    // when set and Status is empty, include or exclude archived articles
+	Favorite bool     // only favourites
+	Tags     []string // only articles with every tag
+	Broken   bool     // only articles whose link was dead at the last check
 	Limit    int
 	Offset   int
 }
@@ -39,11 +45,29 @@ type ArticleListOptions struct {
 // scanArticle scans a database row into an Article model
 func (r *ArticleRepository) scanArticle(s scanner) (*models.Article, error) {
 	var article models.Article
+	var tags string
+	var citation, linkError, redirectURL, archiveURL sql.NullString
+	var linkChecked sql.NullTime
 	err := s.Scan(&article.ID, &article.URL, &article.Title, &article.Author, &article.Date,
-		&article.MarkdownPath, &article.HTMLPath, &article.Created, &article.Modified)
+		&article.MarkdownPath, &article.HTMLPath, &article.Status, &article.Favorite, &tags,
+		&article.Progress, &article.WordCount, &article.ReadingTime, &article.NoteID, &citation,
+		&article.LinkStatus, &linkError, &redirectURL, &article.RedirectStatus, &linkChecked, &archiveURL,
+		&article.Created, &article.Modified)
 	if err != nil {
 		return nil, err
 	}
+
+	article.LinkError, article.RedirectURL, article.ArchiveURL = linkError.String, redirectURL.String, archiveURL.String
+	if linkChecked.Valid {
+		article.LinkChecked = &linkChecked.Time
+	}
+
+	if err := article.UnmarshalTags(tags); err != nil {
+		return nil, UnmarshalTagsError(err)
+	}
+	if err := article.UnmarshalCitation(citation.String); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal citation: %w", err)
+	}
 	return &article, nil
 }
 
@@ -84,40 +108,67 @@ func (r *ArticleRepository) queryMany(ctx context.Context, query string, args ..
 	return articles, nil
 }
 
-// buildListQuery constructs a query and arguments for the List method
-func (r *ArticleRepository) buildListQuery(opts *ArticleListOptions) (string, []any) {
-	query := queryArticlesList
+// buildConditions constructs the WHERE clause and arguments shared by List and Count
+func (r *ArticleRepository) buildConditions(opts *ArticleListOptions) (string, []any) {
+	if opts == nil {
+		return "", nil
+	}
+
 	var conditions []string
 	var args []any
 
-	if opts != nil {
-		if opts.URL != "" {
-			conditions = append(conditions, "url LIKE ?")
-			args = append(args, "%"+opts.URL+"%")
-		}
-		if opts.Title != "" {
-			conditions = append(conditions, "title LIKE ?")
-			args = append(args, "%"+opts.Title+"%")
-		}
-		if opts.Author != "" {
-			conditions = append(conditions, "author LIKE ?")
-			args = append(args, "%"+opts.Author+"%")
-		}
-		if opts.DateFrom != "" {
-			conditions = append(conditions, "date >= ?")
-			args = append(args, opts.DateFrom)
-		}
-		if opts.DateTo != "" {
-			conditions = append(conditions, "date <= ?")
-			args = append(args, opts.DateTo)
+	if opts.URL != "" {
+		conditions = append(conditions, "url LIKE ?")
+		args = append(args, "%"+opts.URL+"%")
+	}
+	if opts.Title != "" {
+		conditions = append(conditions, "title LIKE ?")
+		args = append(args, "%"+opts.Title+"%")
+	}
+	if opts.Author != "" {
+		conditions = append(conditions, "author LIKE ?")
+		args = append(args, "%"+opts.Author+"%")
+	}
+	if opts.DateFrom != "" {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, opts.DateFrom)
+	}
+	if opts.DateTo != "" {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, opts.DateTo)
+	}
+	if opts.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, opts.Status)
+	} else if opts.Archived != nil {
+		if *opts.Archived {
+			conditions = append(conditions, "status = ?")
+		} else {
+			conditions = append(conditions, "status != ?")
 		}
+		args = append(args, models.ArticleArchived)
+	}
+	if opts.Favorite {
+		conditions = append(conditions, "favorite = 1")
+	}
+	if opts.Broken {
+		conditions = append(conditions, "link_checked IS NOT NULL AND (link_status = 0 OR link_status >= 400)")
+	}
+	for _, tag := range opts.Tags {
+		conditions = append(conditions, "tags LIKE ?")
+		args = append(args, "%\""+tag+"\"%")
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if len(conditions) == 0 {
+		return "", nil
 	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
-	query += " ORDER BY created DESC"
+// buildListQuery constructs a query and arguments for the List method
+func (r *ArticleRepository) buildListQuery(opts *ArticleListOptions) (string, []any) {
+	where, args := r.buildConditions(opts)
+	query := queryArticlesList + where + " ORDER BY created DESC"
 
 	if opts != nil && opts.Limit > 0 {
 		query += " LIMIT ?"
@@ -133,38 +184,8 @@ func (r *ArticleRepository) buildListQuery(opts *ArticleListOptions) (string, []
 
 // buildCountQuery constructs a count query and arguments
 func (r *ArticleRepository) buildCountQuery(opts *ArticleListOptions) (string, []any) {
-	query := queryArticlesCount
-	var conditions []string
-	var args []any
-
-	if opts != nil {
-		if opts.URL != "" {
-			conditions = append(conditions, "url LIKE ?")
-			args = append(args, "%"+opts.URL+"%")
-		}
-		if opts.Title != "" {
-			conditions = append(conditions, "title LIKE ?")
-			args = append(args, "%"+opts.Title+"%")
-		}
-		if opts.Author != "" {
-			conditions = append(conditions, "author LIKE ?")
-			args = append(args, "%"+opts.Author+"%")
-		}
-		if opts.DateFrom != "" {
-			conditions = append(conditions, "date >= ?")
-			args = append(args, opts.DateFrom)
-		}
-		if opts.DateTo != "" {
-			conditions = append(conditions, "date <= ?")
-			args = append(args, opts.DateTo)
-		}
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	return query, args
+	where, args := r.buildConditions(opts)
+	return queryArticlesCount + where, args
 }
 
 // Create stores a new article and returns its assigned ID
@@ -173,13 +194,26 @@ func (r *ArticleRepository) Create(ctx context.Context, article *models.Article)
 		return 0, err
 	}
 
+	tags, err := article.MarshalTags()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	citation, err := article.MarshalCitation()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal citation: %w", err)
+	}
+
 	now := time.Now()
 	article.Created = now
 	article.Modified = now
+	if article.Status == "" {
+		article.Status = models.ArticleUnread
+	}
 
 	result, err := r.db.ExecContext(ctx, queryArticleInsert,
 		article.URL, article.Title, article.Author, article.Date,
-		article.MarkdownPath, article.HTMLPath, article.Created, article.Modified)
+		article.MarkdownPath, article.HTMLPath, article.Status, article.Favorite, tags,
+		article.Progress, article.WordCount, article.ReadingTime, article.NoteID, citation, article.Created, article.Modified)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert article: %w", err)
 	}
@@ -204,24 +238,51 @@ func (r *ArticleRepository) Get(ctx context.Context, id int64) (*models.Article,
 
 // GetByURL retrieves an article by its URL
 func (r *ArticleRepository) GetByURL(ctx context.Context, url string) (*models.Article, error) {
-	article, err := r.queryOne(ctx, queryArticleByURL, url)
+	article, err := r.FindByURL(ctx, url)
 	if err != nil {
+		return nil, err
+	}
+	if article == nil {
 		return nil, fmt.Errorf("article with url %s not found", url)
 	}
 	return article, nil
 }
 
+// FindByURL returns the article saved from url, or nil if no article has that URL
+func (r *ArticleRepository) FindByURL(ctx context.Context, url string) (*models.Article, error) {
+	article, err := r.scanArticle(r.db.QueryRowContext(ctx, queryArticleByURL, url))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get article by url: %w", err)
+	}
+	return article, nil
+}
+
 // Update modifies an existing article
 func (r *ArticleRepository) Update(ctx context.Context, article *models.Article) error {
 	if err := r.Validate(article); err != nil {
 		return err
 	}
 
+	tags, err := article.MarshalTags()
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	citation, err := article.MarshalCitation()
+	if err != nil {
+		return fmt.Errorf("failed to marshal citation: %w", err)
+	}
+
 	article.Modified = time.Now()
+	if article.Status == "" {
+		article.Status = models.ArticleUnread
+	}
 
 	result, err := r.db.ExecContext(ctx, queryArticleUpdate,
 		article.Title, article.Author, article.Date, article.MarkdownPath,
-		article.HTMLPath, article.Modified, article.ID)
+		article.HTMLPath, article.Status, article.Favorite, tags, article.Progress,
+		article.WordCount, article.ReadingTime, article.NoteID, citation, article.Modified, article.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update article: %w", err)
 	}
@@ -276,6 +337,115 @@ func (r *ArticleRepository) Count(ctx context.Context, opts *ArticleListOptions)
 	return count, nil
 }
 
+// SetStatus changes the reading status of an article. Marking an article read completes its progress.
+func (r *ArticleRepository) SetStatus(ctx context.Context, id int64, status string) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	article.Status = status
+	if status == models.ArticleRead {
+		article.Progress = 100
+	}
+	return r.Update(ctx, article)
+}
+
+// SetFavorite marks or unmarks an article as a favourite
+func (r *ArticleRepository) SetFavorite(ctx context.Context, id int64, favorite bool) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	article.Favorite = favorite
+	return r.Update(ctx, article)
+}
+
+// SetProgress records how far through an article the reader is, moving unread articles to reading
+// and finished ones to read
+func (r *ArticleRepository) SetProgress(ctx context.Context, id int64, progress int) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := article.SetProgress(progress); err != nil {
+		return err
+	}
+	switch {
+	case progress == 100:
+		article.Status = models.ArticleRead
+	case progress > 0 && article.IsUnread():
+		article.Status = models.ArticleReading
+	}
+	return r.Update(ctx, article)
+}
+
+// SaveLinkCheck stores the result of an article's link check, leaving its other fields untouched
+func (r *ArticleRepository) SaveLinkCheck(ctx context.Context, article *models.Article) error {
+	result, err := r.db.ExecContext(ctx, queryArticleLinkCheckUpdate,
+		article.LinkStatus, nullString(article.LinkError), nullString(article.RedirectURL), article.RedirectStatus,
+		article.LinkChecked, nullString(article.ArchiveURL), article.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save link check: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return ArticleNotFoundError(article.ID)
+	}
+	return nil
+}
+
+// SetURL replaces an article's URL, as after a permanent redirect, and clears the recorded redirect
+func (r *ArticleRepository) SetURL(ctx context.Context, id int64, url string) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	article.URL = url
+	if err := r.Validate(article); err != nil {
+		return err
+	}
+	if existing, err := r.GetByURL(ctx, url); err == nil && existing.ID != id {
+		return fmt.Errorf("article %d already has the URL %s", existing.ID, url)
+	}
+
+	if _, err := r.db.ExecContext(ctx, queryArticleSetURL, url, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to update article URL: %w", err)
+	}
+	return nil
+}
+
+// AddTag adds a tag to an article
+func (r *ArticleRepository) AddTag(ctx context.Context, id int64, tag string) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(article.Tags, tag) {
+		return nil
+	}
+
+	article.Tags = append(article.Tags, tag)
+	return r.Update(ctx, article)
+}
+
+// RemoveTag removes a tag from an article
+func (r *ArticleRepository) RemoveTag(ctx context.Context, id int64, tag string) error {
+	article, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	article.Tags = slices.DeleteFunc(article.Tags, func(existing string) bool { return existing == tag })
+	return r.Update(ctx, article)
+}
+
 // Validate validates a model using the validation service
 func (r *ArticleRepository) Validate(model models.Model) error {
 	article, ok := (model).(*models.Article)
@@ -304,6 +474,11 @@ func (r *ArticleRepository) Validate(model models.Model) error {
 
 	validator.Check(services.StringLength("Title", article.Title, 1, 500))
 	validator.Check(services.StringLength("Author", article.Author, 0, 200))
+	validator.Check(services.ValidEnum("Status", article.Status, article.ValidStatuses()))
+
+	if article.Progress < 0 || article.Progress > 100 {
+		validator.Check(services.NewValidationError("Progress", "must be between 0 and 100"))
+	}
 
 	if article.ID > 0 {
 		validator.Check(services.PositiveID("ID", article.ID))
@@ -75,6 +75,89 @@ func TestArticleRepository(t *testing.T) {
 			shared.AssertTrue(t, retrieved.Modified.After(originalModified), "Expected Modified timestamp to be updated")
 		})
 
+		t.Run("Citation round trip", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			article := CreateSampleArticle()
+			article.Citation = &models.Citation{
+				Type:   "article-journal",
+				Title:  article.Title,
+				Author: []models.CitationName{{Family: "Lovelace", Given: "Ada"}},
+				Issued: models.NewCitationDate(2021, 3, 0),
+				DOI:    "10.5555/fixture.2021.42",
+			}
+			id, err := repo.Create(ctx, article)
+			shared.AssertNoError(t, err, "Failed to create article")
+
+			retrieved, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertTrue(t, retrieved.Citation != nil, "Expected a citation")
+			shared.AssertEqual(t, "10.5555/fixture.2021.42", retrieved.Citation.DOI, "DOI mismatch")
+			shared.AssertEqual(t, "Lovelace", retrieved.Citation.Author[0].Family, "Author mismatch")
+			shared.AssertEqual(t, "2021-03", retrieved.Citation.Issued.String(), "Issued mismatch")
+
+			retrieved.Citation = nil
+			shared.AssertNoError(t, repo.Update(ctx, retrieved), "Failed to update article")
+			cleared, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertTrue(t, cleared.Citation == nil, "Expected the citation to be cleared")
+		})
+
+		t.Run("Link checks and URL updates", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			article := CreateSampleArticle()
+			id, err := repo.Create(ctx, article)
+			shared.AssertNoError(t, err, "Failed to create article")
+
+			other := CreateSampleArticle()
+			other.URL = "https://example.com/other"
+			_, err = repo.Create(ctx, other)
+			shared.AssertNoError(t, err, "Failed to create second article")
+
+			broken, err := repo.List(ctx, &ArticleListOptions{Broken: true})
+			shared.AssertNoError(t, err, "Failed to list broken articles")
+			shared.AssertEqual(t, 0, len(broken), "Unchecked articles are not broken")
+
+			checked := time.Now()
+			article.LinkStatus = 404
+			article.LinkError = "HTTP 404"
+			article.LinkChecked = &checked
+			article.ArchiveURL = "https://web.archive.org/web/" + article.URL
+			shared.AssertNoError(t, repo.SaveLinkCheck(ctx, article), "Failed to save link check")
+
+			retrieved, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertEqual(t, 404, retrieved.LinkStatus, "Link status mismatch")
+			shared.AssertEqual(t, "HTTP 404", retrieved.LinkError, "Link error mismatch")
+			shared.AssertEqual(t, article.ArchiveURL, retrieved.ArchiveURL, "Archive URL mismatch")
+			shared.AssertTrue(t, retrieved.IsBroken(), "Expected the article to be broken")
+
+			broken, err = repo.List(ctx, &ArticleListOptions{Broken: true})
+			shared.AssertNoError(t, err, "Failed to list broken articles")
+			shared.AssertEqual(t, 1, len(broken), "Expected one broken article")
+			shared.AssertEqual(t, id, broken[0].ID, "Broken article mismatch")
+
+			retrieved.LinkStatus, retrieved.LinkError = 200, ""
+			retrieved.RedirectURL, retrieved.RedirectStatus = "https://example.com/moved", 301
+			shared.AssertNoError(t, repo.SaveLinkCheck(ctx, retrieved), "Failed to save link check")
+
+			shared.AssertError(t, repo.SetURL(ctx, id, other.URL), "Expected a URL used by another article to be rejected")
+			shared.AssertError(t, repo.SetURL(ctx, id, "not a url"), "Expected an invalid URL to be rejected")
+			shared.AssertNoError(t, repo.SetURL(ctx, id, "https://example.com/moved"), "Failed to set URL")
+
+			moved, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertEqual(t, "https://example.com/moved", moved.URL, "URL mismatch")
+			shared.AssertEqual(t, "", moved.RedirectURL, "Expected the redirect to be cleared")
+			shared.AssertFalse(t, moved.IsBroken(), "Expected the article to be ok")
+
+			missing := &models.Article{ID: 999}
+			shared.AssertError(t, repo.SaveLinkCheck(ctx, missing), "Expected an error for a missing article")
+		})
+
 		t.Run("Delete article", func(t *testing.T) {
 			db := CreateTestDB(t)
 			repo := NewArticleRepository(db)
@@ -237,6 +320,15 @@ func TestArticleRepository(t *testing.T) {
 			shared.AssertError(t, err, "Expected error when getting article by non-existent URL")
 			shared.AssertContains(t, err.Error(), "not found", "Expected 'not found' in error message")
 		})
+
+		t.Run("FindByURL returns nil when URL not found", func(t *testing.T) {
+			article, err := repo.FindByURL(ctx, "https://example.com/nonexistent")
+			shared.AssertNoError(t, err, "FindByURL should not fail for unknown URLs")
+			shared.AssertTrue(t, article == nil, "unknown URLs should be nil")
+
+			_, err = repo.FindByURL(NewCanceledContext(), "https://example.com/nonexistent")
+			shared.AssertError(t, err, "FindByURL should report query errors")
+		})
 	})
 
 	t.Run("List", func(t *testing.T) {
@@ -384,6 +476,116 @@ func TestArticleRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("Reading State", func(t *testing.T) {
+		ctx := context.Background()
+
+		t.Run("New articles are unread", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			article := CreateSampleArticle()
+			article.WordCount = 920
+			article.ReadingTime = 4
+			id, err := repo.Create(ctx, article)
+			shared.AssertNoError(t, err, "Failed to create article")
+
+			retrieved, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertEqual(t, models.ArticleUnread, retrieved.Status, "Expected new articles to be unread")
+			shared.AssertEqual(t, 920, retrieved.WordCount, "WordCount mismatch")
+			shared.AssertEqual(t, 4, retrieved.ReadingTime, "ReadingTime mismatch")
+		})
+
+		t.Run("Progress moves articles through the queue", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			id, err := repo.Create(ctx, CreateSampleArticle())
+			shared.AssertNoError(t, err, "Failed to create article")
+
+			shared.AssertNoError(t, repo.SetProgress(ctx, id, 30), "Failed to set progress")
+			article, _ := repo.Get(ctx, id)
+			shared.AssertEqual(t, models.ArticleReading, article.Status, "Expected progress to start reading")
+			shared.AssertEqual(t, 30, article.Progress, "Progress mismatch")
+
+			shared.AssertNoError(t, repo.SetProgress(ctx, id, 100), "Failed to finish article")
+			article, _ = repo.Get(ctx, id)
+			shared.AssertEqual(t, models.ArticleRead, article.Status, "Expected 100% to mark the article read")
+
+			shared.AssertError(t, repo.SetProgress(ctx, id, 101), "Expected progress over 100 to fail")
+		})
+
+		t.Run("Status, favourites and tags", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			id, err := repo.Create(ctx, CreateSampleArticle())
+			shared.AssertNoError(t, err, "Failed to create article")
+
+			shared.AssertNoError(t, repo.SetStatus(ctx, id, models.ArticleRead), "Failed to set status")
+			shared.AssertNoError(t, repo.SetFavorite(ctx, id, true), "Failed to set favourite")
+			shared.AssertNoError(t, repo.AddTag(ctx, id, "go"), "Failed to add tag")
+			shared.AssertNoError(t, repo.AddTag(ctx, id, "go"), "Failed to add duplicate tag")
+			shared.AssertNoError(t, repo.AddTag(ctx, id, "databases"), "Failed to add tag")
+			shared.AssertNoError(t, repo.RemoveTag(ctx, id, "databases"), "Failed to remove tag")
+
+			article, err := repo.Get(ctx, id)
+			shared.AssertNoError(t, err, "Failed to get article")
+			shared.AssertEqual(t, models.ArticleRead, article.Status, "Status mismatch")
+			shared.AssertEqual(t, 100, article.Progress, "Expected read articles to be complete")
+			shared.AssertTrue(t, article.Favorite, "Expected article to be a favourite")
+			shared.AssertEqual(t, 1, len(article.Tags), "Expected one tag")
+			shared.AssertEqual(t, "go", article.Tags[0], "Tag mismatch")
+
+			shared.AssertError(t, repo.SetStatus(ctx, id, "skimmed"), "Expected an unknown status to fail")
+		})
+
+		t.Run("List filters by status, favourites and tags", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewArticleRepository(db)
+
+			for i, state := range []struct {
+				status   string
+				favorite bool
+				tags     []string
+			}{
+				{models.ArticleUnread, false, []string{"go"}},
+				{models.ArticleUnread, true, []string{"go", "testing"}},
+				{models.ArticleRead, true, []string{"rust"}},
+				{models.ArticleArchived, false, []string{"go"}},
+			} {
+				article := CreateSampleArticle()
+				article.URL = "https://example.com/queue/" + string(rune('a'+i))
+				article.Status, article.Favorite, article.Tags = state.status, state.favorite, state.tags
+				_, err := repo.Create(ctx, article)
+				shared.AssertNoError(t, err, "Failed to create article")
+			}
+
+			notArchived := false
+			for _, tc := range []struct {
+				name string
+				opts ArticleListOptions
+				want int
+			}{
+				{"unread", ArticleListOptions{Status: models.ArticleUnread}, 2},
+				{"hide archived", ArticleListOptions{Archived: &notArchived}, 3},
+				{"archived only", ArticleListOptions{Status: models.ArticleArchived, Archived: &notArchived}, 1},
+				{"favourites", ArticleListOptions{Favorite: true}, 2},
+				{"tag", ArticleListOptions{Tags: []string{"go"}}, 3},
+				{"every tag", ArticleListOptions{Tags: []string{"go", "testing"}}, 1},
+				{"unread with tag", ArticleListOptions{Status: models.ArticleUnread, Tags: []string{"testing"}}, 1},
+			} {
+				results, err := repo.List(ctx, &tc.opts)
+				shared.AssertNoError(t, err, "Failed to list articles")
+				shared.AssertEqual(t, tc.want, len(results), "Unexpected result count for "+tc.name)
+
+				count, err := repo.Count(ctx, &tc.opts)
+				shared.AssertNoError(t, err, "Failed to count articles")
+				shared.AssertEqual(t, int64(tc.want), count, "Unexpected count for "+tc.name)
+			}
+		})
+	})
+
 	t.Run("Context Cancellation Error Paths", func(t *testing.T) {
 		db := CreateTestDB(t)
 		repo := NewArticleRepository(db)
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+// GetCachedBlob returns the blob uploaded for an image hash to the given account, or nil if there is none
+func (r *NoteRepository) GetCachedBlob(ctx context.Context, did, hash string) (*models.CachedBlob, error) {
+	var blob models.CachedBlob
+	err := r.db.QueryRowContext(ctx, queryBlobCacheGet, did, hash).
+		Scan(&blob.Hash, &blob.DID, &blob.CID, &blob.MimeType, &blob.Size, &blob.Width, &blob.Height, &blob.Created)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get cached blob: %w", err)
+	}
+	return &blob, nil
+}
+
+// SaveCachedBlob records an uploaded blob, replacing any earlier upload of the same image
+func (r *NoteRepository) SaveCachedBlob(ctx context.Context, blob *models.CachedBlob) error {
+	if blob.Created.IsZero() {
+		blob.Created = time.Now()
+	}
+	if _, err := r.db.ExecContext(ctx, queryBlobCacheSave, blob.Hash, blob.DID, blob.CID, blob.MimeType,
+		blob.Size, blob.Width, blob.Height, blob.Created); err != nil {
+		return fmt.Errorf("failed to save cached blob: %w", err)
+	}
+	return nil
+}
+
+// ClearBlobCache forgets every blob uploaded to the given account
+func (r *NoteRepository) ClearBlobCache(ctx context.Context, did string) error {
+	if _, err := r.db.ExecContext(ctx, queryBlobCacheClear, did); err != nil {
+		return fmt.Errorf("failed to clear blob cache: %w", err)
+	}
+	return nil
+}
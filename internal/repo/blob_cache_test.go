@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestBlobCache(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewNoteRepository(db)
+
+	blob := &models.CachedBlob{Hash: "abc", DID: "did:plc:one", CID: "bafkreione", MimeType: "image/png", Size: 100, Width: 10, Height: 20}
+
+	t.Run("missing blob returns nil", func(t *testing.T) {
+		got, err := repo.GetCachedBlob(ctx, "did:plc:one", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertTrue(t, got == nil, "nothing should be cached yet")
+	})
+
+	t.Run("saves and reads back a blob", func(t *testing.T) {
+		shared.AssertNoError(t, repo.SaveCachedBlob(ctx, blob), "SaveCachedBlob should succeed")
+
+		got, err := repo.GetCachedBlob(ctx, "did:plc:one", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertNotNil(t, got, "blob should be cached")
+		shared.AssertEqual(t, "bafkreione", got.CID, "cid should match")
+		shared.AssertEqual(t, 20, got.Height, "height should match")
+		shared.AssertFalse(t, got.Created.IsZero(), "created should be set")
+	})
+
+	t.Run("blobs are kept per account", func(t *testing.T) {
+		got, err := repo.GetCachedBlob(ctx, "did:plc:two", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertTrue(t, got == nil, "another account should not see the blob")
+	})
+
+	t.Run("saving again replaces the blob", func(t *testing.T) {
+		replaced := *blob
+		replaced.CID = "bafkreireplaced"
+		shared.AssertNoError(t, repo.SaveCachedBlob(ctx, &replaced), "SaveCachedBlob should succeed")
+
+		got, err := repo.GetCachedBlob(ctx, "did:plc:one", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertEqual(t, "bafkreireplaced", got.CID, "cid should be replaced")
+	})
+
+	t.Run("clear only affects one account", func(t *testing.T) {
+		other := *blob
+		other.DID = "did:plc:two"
+		shared.AssertNoError(t, repo.SaveCachedBlob(ctx, &other), "SaveCachedBlob should succeed")
+
+		shared.AssertNoError(t, repo.ClearBlobCache(ctx, "did:plc:one"), "ClearBlobCache should succeed")
+
+		got, err := repo.GetCachedBlob(ctx, "did:plc:one", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertTrue(t, got == nil, "blob should be cleared")
+
+		got, err = repo.GetCachedBlob(ctx, "did:plc:two", "abc")
+		shared.AssertNoError(t, err, "GetCachedBlob should succeed")
+		shared.AssertNotNil(t, got, "other account should keep its blob")
+	})
+}
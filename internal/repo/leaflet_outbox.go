@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func (r *NoteRepository) scanLeafletOutbox(s scanner) (*models.LeafletOutboxEntry, error) {
+	var entry models.LeafletOutboxEntry
+	var rkey, publication, document, baseTitle, baseContent, lastError sql.NullString
+	if err := s.Scan(&entry.ID, &entry.NoteID, &entry.Operation, &rkey, &entry.IsDraft, &publication,
+		&document, &baseTitle, &baseContent, &entry.Attempts, &lastError, &entry.Failed,
+		&entry.QueuedAt, &entry.UpdatedAt); err != nil {
+		return nil, err
+	}
+	entry.RKey = rkey.String
+	entry.Publication = publication.String
+	entry.Document = document.String
+	entry.BaseTitle = baseTitle.String
+	entry.BaseContent = baseContent.String
+	entry.LastError = lastError.String
+	return &entry, nil
+}
+
+// ListLeafletOutbox returns the queued leaflet operations in the order they were first queued
+func (r *NoteRepository) ListLeafletOutbox(ctx context.Context) ([]*models.LeafletOutboxEntry, error) {
+	rows, err := r.db.QueryContext(ctx, queryLeafletOutboxList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaflet outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.LeafletOutboxEntry
+	for rows.Next() {
+		entry, err := r.scanLeafletOutbox(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leaflet outbox entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over leaflet outbox: %w", err)
+	}
+	return entries, nil
+}
+
+// GetLeafletOutboxEntry returns the queued operation for a note, or nil if nothing is queued
+func (r *NoteRepository) GetLeafletOutboxEntry(ctx context.Context, noteID int64) (*models.LeafletOutboxEntry, error) {
+	entry, err := r.scanLeafletOutbox(r.db.QueryRowContext(ctx, queryLeafletOutboxByNoteID, noteID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get leaflet outbox entry: %w", err)
+	}
+	return entry, nil
+}
+
+// EnqueueLeafletOp queues a leaflet operation, folding it into any operation already queued for the note.
+//
+// It returns the entry as stored, or nil when the operations cancel out, such as
+// deleting a document whose creation never left the queue.
+func (r *NoteRepository) EnqueueLeafletOp(ctx context.Context, op *models.LeafletOutboxEntry) (*models.LeafletOutboxEntry, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := r.scanLeafletOutbox(tx.QueryRowContext(ctx, queryLeafletOutboxByNoteID, op.NoteID))
+	if err == sql.ErrNoRows {
+		existing = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get leaflet outbox entry: %w", err)
+	}
+
+	entry, err := coalesceLeafletOps(existing, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		if _, err := tx.ExecContext(ctx, queryLeafletOutboxDelete, op.NoteID); err != nil {
+			return nil, fmt.Errorf("failed to remove leaflet outbox entry: %w", err)
+		}
+		return nil, tx.Commit()
+	}
+
+	now := time.Now()
+	if entry.QueuedAt.IsZero() {
+		entry.QueuedAt = now
+	}
+	entry.UpdatedAt = now
+
+	if _, err := tx.ExecContext(ctx, queryLeafletOutboxSave,
+		entry.NoteID, entry.Operation, nullString(entry.RKey), entry.IsDraft, nullString(entry.Publication),
+		nullString(entry.Document), nullString(entry.BaseTitle), nullString(entry.BaseContent),
+		entry.Attempts, nullString(entry.LastError), entry.Failed, entry.QueuedAt, entry.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to save leaflet outbox entry: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit leaflet outbox entry: %w", err)
+	}
+	return r.GetLeafletOutboxEntry(ctx, entry.NoteID)
+}
+
+// coalesceLeafletOps folds next into the operation already queued for the same note.
+//
+// The newest document always wins. A create stays a create until it is sent, an
+// update followed by a delete becomes a delete, and a create followed by a delete
+// cancels out. Folding resets the retry count so the merged write is tried afresh.
+func coalesceLeafletOps(existing, next *models.LeafletOutboxEntry) (*models.LeafletOutboxEntry, error) {
+	merged := *next
+	if existing == nil {
+		return &merged, nil
+	}
+
+	merged.ID = existing.ID
+	merged.QueuedAt = existing.QueuedAt
+	merged.Attempts = 0
+	merged.LastError = ""
+	merged.Failed = false
+
+	switch existing.Operation {
+	case models.OutboxCreate:
+		switch next.Operation {
+		case models.OutboxDelete:
+			return nil, nil
+		case models.OutboxUpdate:
+			merged.Operation = models.OutboxCreate
+			merged.IsDraft = existing.IsDraft
+			merged.Publication = existing.Publication
+			merged.RKey = ""
+		}
+	case models.OutboxUpdate:
+		if next.Operation == models.OutboxCreate {
+			return nil, fmt.Errorf("note %d already has a queued update", next.NoteID)
+		}
+		if merged.RKey == "" {
+			merged.RKey = existing.RKey
+		}
+	case models.OutboxDelete:
+		return nil, fmt.Errorf("note %d is queued for deletion - drop it from the queue first", next.NoteID)
+	}
+	return &merged, nil
+}
+
+// RecordLeafletOutboxFailure records a failed attempt to send a queued operation.
+//
+// Permanent failures stay in the queue, marked failed, until they are dropped or requeued.
+func (r *NoteRepository) RecordLeafletOutboxFailure(ctx context.Context, id int64, attempts int, lastError string, permanent bool) error {
+	if _, err := r.db.ExecContext(ctx, queryLeafletOutboxFailure, attempts, lastError, permanent, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record leaflet outbox failure: %w", err)
+	}
+	return nil
+}
+
+// DeleteLeafletOutboxEntry removes the queued operation for a note
+func (r *NoteRepository) DeleteLeafletOutboxEntry(ctx context.Context, noteID int64) error {
+	if _, err := r.db.ExecContext(ctx, queryLeafletOutboxDelete, noteID); err != nil {
+		return fmt.Errorf("failed to delete leaflet outbox entry: %w", err)
+	}
+	return nil
+}
+
+// ClearLeafletOutbox removes every queued operation
+func (r *NoteRepository) ClearLeafletOutbox(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, queryLeafletOutboxClear); err != nil {
+		return fmt.Errorf("failed to clear leaflet outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestLeafletOutbox(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewNoteRepository(db)
+
+	enqueue := func(t *testing.T, op *models.LeafletOutboxEntry) *models.LeafletOutboxEntry {
+		t.Helper()
+		entry, err := repo.EnqueueLeafletOp(ctx, op)
+		shared.AssertNoError(t, err, "Failed to enqueue operation")
+		return entry
+	}
+
+	t.Run("missing entry returns nil", func(t *testing.T) {
+		entry, err := repo.GetLeafletOutboxEntry(ctx, 1)
+		shared.AssertNoError(t, err, "GetLeafletOutboxEntry should not fail")
+		shared.AssertTrue(t, entry == nil, "Expected no entry")
+	})
+
+	t.Run("updates coalesce into one write", func(t *testing.T) {
+		enqueue(t, &models.LeafletOutboxEntry{NoteID: 1, Operation: models.OutboxUpdate, RKey: "rk1", Document: `{"title":"v1"}`})
+		entry := enqueue(t, &models.LeafletOutboxEntry{NoteID: 1, Operation: models.OutboxUpdate, RKey: "rk1", Document: `{"title":"v2"}`})
+
+		shared.AssertEqual(t, models.OutboxUpdate, entry.Operation, "Operation mismatch")
+		shared.AssertEqual(t, `{"title":"v2"}`, entry.Document, "Expected newest document")
+
+		entries, err := repo.ListLeafletOutbox(ctx)
+		shared.AssertNoError(t, err, "Failed to list outbox")
+		shared.AssertEqual(t, 1, len(entries), "Expected a single queued write")
+	})
+
+	t.Run("update then delete becomes delete", func(t *testing.T) {
+		entry := enqueue(t, &models.LeafletOutboxEntry{NoteID: 1, Operation: models.OutboxDelete, RKey: "rk1"})
+		shared.AssertEqual(t, models.OutboxDelete, entry.Operation, "Operation mismatch")
+		shared.AssertEqual(t, "rk1", entry.RKey, "RKey mismatch")
+
+		_, err := repo.EnqueueLeafletOp(ctx, &models.LeafletOutboxEntry{NoteID: 1, Operation: models.OutboxUpdate, RKey: "rk1"})
+		shared.AssertError(t, err, "Expected update after queued delete to fail")
+	})
+
+	t.Run("create absorbs updates", func(t *testing.T) {
+		enqueue(t, &models.LeafletOutboxEntry{NoteID: 2, Operation: models.OutboxCreate, IsDraft: true, Publication: "Blog", Document: "a"})
+		entry := enqueue(t, &models.LeafletOutboxEntry{NoteID: 2, Operation: models.OutboxUpdate, Document: "b"})
+
+		shared.AssertEqual(t, models.OutboxCreate, entry.Operation, "Expected create to be kept")
+		shared.AssertTrue(t, entry.IsDraft, "Expected draft flag from the create")
+		shared.AssertEqual(t, "Blog", entry.Publication, "Publication mismatch")
+		shared.AssertEqual(t, "b", entry.Document, "Expected newest document")
+	})
+
+	t.Run("failures are recorded and reset on coalesce", func(t *testing.T) {
+		entry, err := repo.GetLeafletOutboxEntry(ctx, 2)
+		shared.AssertNoError(t, err, "Failed to get entry")
+
+		shared.AssertNoError(t, repo.RecordLeafletOutboxFailure(ctx, entry.ID, 3, "boom", true), "Failed to record failure")
+		entry, err = repo.GetLeafletOutboxEntry(ctx, 2)
+		shared.AssertNoError(t, err, "Failed to get entry")
+		shared.AssertEqual(t, 3, entry.Attempts, "Attempts mismatch")
+		shared.AssertEqual(t, "boom", entry.LastError, "Last error mismatch")
+		shared.AssertTrue(t, entry.Failed, "Expected entry to be failed")
+
+		entry = enqueue(t, &models.LeafletOutboxEntry{NoteID: 2, Operation: models.OutboxCreate, Document: "c"})
+		shared.AssertEqual(t, 0, entry.Attempts, "Expected attempts to reset")
+		shared.AssertFalse(t, entry.Failed, "Expected failure to clear")
+	})
+
+	t.Run("create then delete cancels out", func(t *testing.T) {
+		entry := enqueue(t, &models.LeafletOutboxEntry{NoteID: 2, Operation: models.OutboxDelete})
+		shared.AssertTrue(t, entry == nil, "Expected operations to cancel out")
+
+		got, err := repo.GetLeafletOutboxEntry(ctx, 2)
+		shared.AssertNoError(t, err, "GetLeafletOutboxEntry should not fail")
+		shared.AssertTrue(t, got == nil, "Expected entry to be removed")
+	})
+
+	t.Run("delete and clear", func(t *testing.T) {
+		enqueue(t, &models.LeafletOutboxEntry{NoteID: 3, Operation: models.OutboxUpdate, RKey: "rk3"})
+		shared.AssertNoError(t, repo.DeleteLeafletOutboxEntry(ctx, 3), "Failed to delete entry")
+		got, err := repo.GetLeafletOutboxEntry(ctx, 3)
+		shared.AssertNoError(t, err, "GetLeafletOutboxEntry should not fail")
+		shared.AssertTrue(t, got == nil, "Expected entry to be removed")
+
+		shared.AssertNoError(t, repo.ClearLeafletOutbox(ctx), "Failed to clear outbox")
+		entries, err := repo.ListLeafletOutbox(ctx)
+		shared.AssertNoError(t, err, "Failed to list outbox")
+		shared.AssertEqual(t, 0, len(entries), "Expected empty outbox")
+	})
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func (r *NoteRepository) scanLeafletSync(s scanner) (*models.LeafletSyncState, error) {
+	var state models.LeafletSyncState
+	var remoteCID, remoteTitle, remoteContent sql.NullString
+	if err := s.Scan(&state.NoteID, &state.RKey, &state.BaseTitle, &state.BaseContent,
+		&remoteCID, &remoteTitle, &remoteContent, &state.Status, &state.SyncedAt); err != nil {
+		return nil, err
+	}
+	state.RemoteCID = remoteCID.String
+	state.RemoteTitle = remoteTitle.String
+	state.RemoteContent = remoteContent.String
+	return &state, nil
+}
+
+// ListLeafletSyncStates returns the leaflet sync state of every tracked note.
+//
+// An empty status returns every state; otherwise only states with that status.
+func (r *NoteRepository) ListLeafletSyncStates(ctx context.Context, status string) ([]*models.LeafletSyncState, error) {
+	var rows *sql.Rows
+	var err error
+	if status == "" {
+		rows, err = r.db.QueryContext(ctx, queryLeafletSyncList)
+	} else {
+		rows, err = r.db.QueryContext(ctx, queryLeafletSyncByStatus, status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaflet sync states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*models.LeafletSyncState
+	for rows.Next() {
+		state, err := r.scanLeafletSync(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leaflet sync state: %w", err)
+		}
+		states = append(states, state)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over leaflet sync states: %w", err)
+	}
+	return states, nil
+}
+
+// GetLeafletSyncState returns the leaflet sync state for a note, or nil if none was recorded
+func (r *NoteRepository) GetLeafletSyncState(ctx context.Context, noteID int64) (*models.LeafletSyncState, error) {
+	state, err := r.scanLeafletSync(r.db.QueryRowContext(ctx, queryLeafletSyncByID, noteID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get leaflet sync state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveLeafletSyncState records the merge base and any pending remote version of a note
+func (r *NoteRepository) SaveLeafletSyncState(ctx context.Context, state *models.LeafletSyncState) error {
+	if state.SyncedAt.IsZero() {
+		state.SyncedAt = time.Now()
+	}
+	if state.Status == "" {
+		state.Status = models.LeafletSynced
+	}
+
+	if _, err := r.db.ExecContext(ctx, queryLeafletSyncSave,
+		state.NoteID, state.RKey, state.BaseTitle, state.BaseContent,
+		nullString(state.RemoteCID), nullString(state.RemoteTitle), nullString(state.RemoteContent),
+		state.Status, state.SyncedAt); err != nil {
+		return fmt.Errorf("failed to save leaflet sync state: %w", err)
+	}
+	return nil
+}
+
+// DeleteLeafletSyncState forgets the leaflet sync state of a note
+func (r *NoteRepository) DeleteLeafletSyncState(ctx context.Context, noteID int64) error {
+	if _, err := r.db.ExecContext(ctx, queryLeafletSyncDelete, noteID); err != nil {
+		return fmt.Errorf("failed to delete leaflet sync state: %w", err)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestLeafletSyncState(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewNoteRepository(db)
+
+	first, err := repo.Create(ctx, CreateSampleNote())
+	shared.AssertNoError(t, err, "Failed to create note")
+	second, err := repo.Create(ctx, CreateSampleNote())
+	shared.AssertNoError(t, err, "Failed to create note")
+
+	t.Run("missing state returns nil", func(t *testing.T) {
+		state, err := repo.GetLeafletSyncState(ctx, first)
+		shared.AssertNoError(t, err, "GetLeafletSyncState should not fail")
+		shared.AssertTrue(t, state == nil, "Expected no state")
+	})
+
+	t.Run("save defaults to synced", func(t *testing.T) {
+		err := repo.SaveLeafletSyncState(ctx, &models.LeafletSyncState{NoteID: first, RKey: "rk1", BaseTitle: "T", BaseContent: "base"})
+		shared.AssertNoError(t, err, "Failed to save state")
+
+		state, err := repo.GetLeafletSyncState(ctx, first)
+		shared.AssertNoError(t, err, "Failed to get state")
+		shared.AssertEqual(t, models.LeafletSynced, state.Status, "Status mismatch")
+		shared.AssertEqual(t, "base", state.BaseContent, "Base content mismatch")
+		shared.AssertEqual(t, "", state.RemoteCID, "Expected no pending remote")
+	})
+
+	t.Run("pending remote version round trips", func(t *testing.T) {
+		err := repo.SaveLeafletSyncState(ctx, &models.LeafletSyncState{
+			NoteID: first, RKey: "rk1", BaseTitle: "T", BaseContent: "base",
+			RemoteCID: "cid2", RemoteTitle: "T2", RemoteContent: "theirs", Status: models.LeafletConflict,
+		})
+		shared.AssertNoError(t, err, "Failed to update state")
+
+		state, err := repo.GetLeafletSyncState(ctx, first)
+		shared.AssertNoError(t, err, "Failed to get state")
+		shared.AssertEqual(t, models.LeafletConflict, state.Status, "Status mismatch")
+		shared.AssertEqual(t, "cid2", state.RemoteCID, "Remote CID mismatch")
+		shared.AssertEqual(t, "theirs", state.RemoteContent, "Remote content mismatch")
+	})
+
+	t.Run("list filters by status", func(t *testing.T) {
+		err := repo.SaveLeafletSyncState(ctx, &models.LeafletSyncState{NoteID: second, RKey: "rk2", BaseTitle: "U", BaseContent: "b"})
+		shared.AssertNoError(t, err, "Failed to save state")
+
+		all, err := repo.ListLeafletSyncStates(ctx, "")
+		shared.AssertNoError(t, err, "Failed to list states")
+		shared.AssertEqual(t, 2, len(all), "Expected two states")
+
+		conflicts, err := repo.ListLeafletSyncStates(ctx, models.LeafletConflict)
+		shared.AssertNoError(t, err, "Failed to list conflicts")
+		shared.AssertEqual(t, 1, len(conflicts), "Expected one conflict")
+		shared.AssertEqual(t, first, conflicts[0].NoteID, "Conflict note mismatch")
+	})
+
+	t.Run("delete and cascade", func(t *testing.T) {
+		shared.AssertNoError(t, repo.DeleteLeafletSyncState(ctx, first), "Failed to delete state")
+		state, err := repo.GetLeafletSyncState(ctx, first)
+		shared.AssertNoError(t, err, "GetLeafletSyncState should not fail")
+		shared.AssertTrue(t, state == nil, "Expected state to be removed")
+
+		shared.AssertNoError(t, repo.Delete(ctx, second), "Failed to delete note")
+		all, err := repo.ListLeafletSyncStates(ctx, "")
+		shared.AssertNoError(t, err, "Failed to list states")
+		shared.AssertEqual(t, 0, len(all), "Expected cascade to remove state")
+	})
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+// GetImport returns the import record for a source hash, or nil if nothing with that hash was imported
+func (r *NoteRepository) GetImport(ctx context.Context, hash string) (*models.NoteImport, error) {
+	var imp models.NoteImport
+	err := r.db.QueryRowContext(ctx, queryNoteImportByHash, hash).
+		Scan(&imp.Hash, &imp.NoteID, &imp.Source, &imp.Path, &imp.Imported)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return &imp, nil
+}
+
+// ImportNotes stores notes brought in from another application in a single transaction.
+//
+// Unlike [NoteRepository.Create], the Created and Modified timestamps of each note are kept
+// (falling back to now when unset). imports[i] describes where notes[i] came from.
+// Once every note has an ID, rewrite is called for each note so its content can link to the
+// others; the returned content is what the first revision records.
+func (r *NoteRepository) ImportNotes(ctx context.Context, notes []*models.Note, imports []*models.NoteImport, rewrite func(*models.Note) string) error {
+	if len(notes) != len(imports) {
+		return fmt.Errorf("got %d notes but %d import records", len(notes), len(imports))
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i, note := range notes {
+		if note.Modified.IsZero() {
+			note.Modified = now
+		}
+		if note.Created.IsZero() || note.Created.After(note.Modified) {
+			note.Created = note.Modified
+		}
+
+		tags, err := note.MarshalTags()
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+
+		id, err := r.insertNote(ctx, tx, note, tags)
+		if err != nil {
+			return err
+		}
+		note.ID = id
+
+		imp := imports[i]
+		imp.NoteID = id
+		if imp.Imported.IsZero() {
+			imp.Imported = now
+		}
+		if _, err := tx.ExecContext(ctx, queryNoteImportInsert, imp.Hash, imp.NoteID, imp.Source, imp.Path, imp.Imported); err != nil {
+			return fmt.Errorf("failed to record import of %s: %w", imp.Path, err)
+		}
+	}
+
+	for _, note := range notes {
+		if rewrite != nil {
+			if content := rewrite(note); content != note.Content {
+				if _, err := tx.ExecContext(ctx, queryNoteContentSet, content, note.ID); err != nil {
+					return fmt.Errorf("failed to update note content: %w", err)
+				}
+				note.Content = content
+			}
+		}
+
+		if err := r.recordRevision(ctx, tx, note.ID, note.Title, note.Content, note.Modified); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestNoteImports(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewNoteRepository(db)
+
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	modified := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	notes := []*models.Note{
+		{Title: "First", Content: "links to LINK", Tags: []string{"imported"}, Created: created, Modified: modified},
+		{Title: "Second", Content: "plain"},
+	}
+	imports := []*models.NoteImport{
+		{Hash: "hash-1", Source: "obsidian", Path: "First.md"},
+		{Hash: "hash-2", Source: "obsidian", Path: "Second.md"},
+	}
+
+	t.Run("import keeps timestamps and rewrites content", func(t *testing.T) {
+		err := repo.ImportNotes(ctx, notes, imports, func(note *models.Note) string {
+			if note.Title == "First" {
+				return fmt.Sprintf("links to [Second](note:%d)", notes[1].ID)
+			}
+			return note.Content
+		})
+		shared.AssertNoError(t, err, "ImportNotes should succeed")
+
+		first, err := repo.Get(ctx, notes[0].ID)
+		shared.AssertNoError(t, err, "Failed to get imported note")
+		shared.AssertTrue(t, first.Created.Equal(created), "Created timestamp should be kept")
+		shared.AssertTrue(t, first.Modified.Equal(modified), "Modified timestamp should be kept")
+		shared.AssertEqual(t, fmt.Sprintf("links to [Second](note:%d)", notes[1].ID), first.Content, "Content should be rewritten")
+
+		revisions, err := repo.ListRevisions(ctx, first.ID)
+		shared.AssertNoError(t, err, "Failed to list revisions")
+		shared.AssertEqual(t, 1, len(revisions), "Expected a single revision")
+		shared.AssertEqual(t, first.Content, revisions[0].Content, "Revision should hold rewritten content")
+
+		second, err := repo.Get(ctx, notes[1].ID)
+		shared.AssertNoError(t, err, "Failed to get imported note")
+		shared.AssertFalse(t, second.Modified.IsZero(), "Missing timestamps should default to now")
+	})
+
+	t.Run("lookup by hash", func(t *testing.T) {
+		imp, err := repo.GetImport(ctx, "hash-1")
+		shared.AssertNoError(t, err, "GetImport should succeed")
+		shared.AssertEqual(t, notes[0].ID, imp.NoteID, "Import should point at note")
+		shared.AssertEqual(t, "First.md", imp.Path, "Path mismatch")
+
+		missing, err := repo.GetImport(ctx, "unknown")
+		shared.AssertNoError(t, err, "GetImport should not fail for unknown hash")
+		shared.AssertTrue(t, missing == nil, "Expected no import")
+	})
+
+	t.Run("duplicate hash rolls back", func(t *testing.T) {
+		before, err := repo.List(ctx, NoteListOptions{})
+		shared.AssertNoError(t, err, "Failed to list notes")
+
+		err = repo.ImportNotes(ctx,
+			[]*models.Note{{Title: "Again", Content: "x"}},
+			[]*models.NoteImport{{Hash: "hash-1", Source: "obsidian", Path: "First.md"}}, nil)
+		shared.AssertError(t, err, "Expected duplicate hash to fail")
+
+		after, err := repo.List(ctx, NoteListOptions{})
+		shared.AssertNoError(t, err, "Failed to list notes")
+		shared.AssertEqual(t, len(before), len(after), "Failed import should not leave notes behind")
+	})
+
+	t.Run("deleting note forgets import", func(t *testing.T) {
+		shared.AssertNoError(t, repo.Delete(ctx, notes[0].ID), "Failed to delete note")
+
+		imp, err := repo.GetImport(ctx, "hash-1")
+		shared.AssertNoError(t, err, "GetImport should succeed")
+		shared.AssertTrue(t, imp == nil, "Import record should be removed with its note")
+	})
+
+	t.Run("mismatched lengths", func(t *testing.T) {
+		err := repo.ImportNotes(ctx, []*models.Note{{Title: "x"}}, nil, nil)
+		shared.AssertError(t, err, "Expected error for mismatched records")
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		err := repo.ImportNotes(NewCanceledContext(), []*models.Note{{Title: "x"}}, []*models.NoteImport{{Hash: "h"}}, nil)
+		AssertCancelledContext(t, err)
+
+		_, err = repo.GetImport(NewCanceledContext(), "hash-2")
+		AssertCancelledContext(t, err)
+	})
+}
@@ -17,7 +17,8 @@ func NoteNotFoundError(id int64) error {
 
 // NoteRepository provides database operations for notes
 type NoteRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	retention RevisionRetention
 }
 
 // NewNoteRepository creates a new note repository
@@ -40,7 +41,7 @@ func (r *NoteRepository) scanNote(s scanner) (*models.Note, error) {
 	var tags string
 	err := s.Scan(&note.ID, &note.Title, &note.Content, &tags, &note.Archived,
 		&note.Created, &note.Modified, &note.FilePath, &note.LeafletRKey,
-		&note.LeafletCID, &note.PublishedAt, &note.IsDraft)
+		&note.LeafletCID, &note.LeafletPublication, &note.PublishedAt, &note.IsDraft, &note.Encrypted)
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +99,33 @@ func (r *NoteRepository) Create(ctx context.Context, note *models.Note) (int64,
 		return 0, fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
-	result, err := r.db.ExecContext(ctx, queryNoteInsert,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := r.insertNote(ctx, tx, note, tags)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.recordRevision(ctx, tx, id, note.Title, note.Content, note.Modified); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit note: %w", err)
+	}
+
+	note.ID = id
+	return id, nil
+}
+
+func (r *NoteRepository) insertNote(ctx context.Context, q execQuerier, note *models.Note, tags string) (int64, error) {
+	result, err := q.ExecContext(ctx, queryNoteInsert,
 		note.Title, note.Content, tags, note.Archived, note.Created, note.Modified, note.FilePath,
-		note.LeafletRKey, note.LeafletCID, note.PublishedAt, note.IsDraft)
+		note.LeafletRKey, note.LeafletCID, note.LeafletPublication, note.PublishedAt, note.IsDraft, note.Encrypted)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert note: %w", err)
 	}
@@ -109,8 +134,6 @@ func (r *NoteRepository) Create(ctx context.Context, note *models.Note) (int64,
 	if err != nil {
 		return 0, fmt.Errorf("failed to get last insert id: %w", err)
 	}
-
-	note.ID = id
 	return id, nil
 }
 
@@ -132,9 +155,19 @@ func (r *NoteRepository) Update(ctx context.Context, note *models.Note) error {
 		return fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
-	result, err := r.db.ExecContext(ctx, queryNoteUpdate,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.ensureBaseline(ctx, tx, note.ID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, queryNoteUpdate,
 		note.Title, note.Content, tags, note.Archived, note.Modified, note.FilePath,
-		note.LeafletRKey, note.LeafletCID, note.PublishedAt, note.IsDraft, note.ID)
+		note.LeafletRKey, note.LeafletCID, note.LeafletPublication, note.PublishedAt, note.IsDraft, note.Encrypted, note.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update note: %w", err)
 	}
@@ -148,6 +181,18 @@ func (r *NoteRepository) Update(ctx context.Context, note *models.Note) error {
 		return NoteNotFoundError(note.ID)
 	}
 
+	if err := r.recordRevision(ctx, tx, note.ID, note.Title, note.Content, note.Modified); err != nil {
+		return err
+	}
+
+	if _, err := r.pruneRevisions(ctx, tx, note.ID, r.retention, note.Modified); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit note: %w", err)
+	}
+
 	return nil
 }
 
@@ -167,6 +212,10 @@ func (r *NoteRepository) Delete(ctx context.Context, id int64) error {
 		return NoteNotFoundError(id)
 	}
 
+	if _, err := r.db.ExecContext(ctx, queryNoteRevisionBlobsOrphans); err != nil {
+		return fmt.Errorf("failed to remove unused revision content: %w", err)
+	}
+
 	return nil
 }
 
@@ -186,7 +235,7 @@ func (r *NoteRepository) buildListQuery(options NoteListOptions) (string, []any)
 	}
 
 	if options.Content != "" {
-		conditions = append(conditions, "content LIKE ?")
+		conditions = append(conditions, "encrypted = 0 AND content LIKE ?")
 		args = append(args, "%"+options.Content+"%")
 	}
 
@@ -346,5 +395,9 @@ func (r *NoteRepository) DeleteAllLeafletNotes(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete leaflet notes: %w", err)
 	}
+
+	if _, err := r.db.ExecContext(ctx, queryNoteRevisionBlobsOrphans); err != nil {
+		return fmt.Errorf("failed to remove unused revision content: %w", err)
+	}
 	return nil
 }
@@ -234,6 +234,16 @@ func TestNoteRepository(t *testing.T) {
 			}
 		})
 
+		t.Run("SearchContent skips encrypted notes", func(t *testing.T) {
+			id, err := repo.Create(ctx, &models.Note{Title: "Locked", Content: "Important but encrypted", Encrypted: true})
+			shared.AssertNoError(t, err, "Failed to create note")
+			defer repo.Delete(ctx, id)
+
+			results, err := repo.SearchContent(ctx, "Important")
+			shared.AssertNoError(t, err, "Failed to search content")
+			shared.AssertEqual(t, 1, len(results), "Expected encrypted note to be excluded")
+		})
+
 		t.Run("GetRecent", func(t *testing.T) {
 			results, err := repo.GetRecent(ctx, 2)
 			shared.AssertNoError(t, err, "Failed to get recent notes")
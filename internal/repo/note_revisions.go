@@ -0,0 +1,251 @@
+package repo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func NoteRevisionNotFoundError(noteID int64, revision int) error {
+	return fmt.Errorf("revision %d of note %d not found", revision, noteID)
+}
+
+// RevisionRetention controls which note revisions survive pruning.
+//
+// The zero value keeps every revision.
+type RevisionRetention struct {
+	KeepLast      int // always keep the N most recent revisions
+	KeepDailyDays int // keep the newest revision of each day for this many days
+}
+
+// IsZero reports whether the policy keeps every revision
+func (p RevisionRetention) IsZero() bool {
+	return p.KeepLast <= 0 && p.KeepDailyDays <= 0
+}
+
+// execQuerier is satisfied by both [sql.DB] and [sql.Tx]
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SetRevisionRetention sets the policy applied after each recorded revision
+func (r *NoteRepository) SetRevisionRetention(policy RevisionRetention) {
+	r.retention = policy
+}
+
+// HashContent returns the hex encoded SHA-256 of note content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func compressContent(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressContent(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (r *NoteRepository) scanRevision(s scanner) (*models.NoteRevision, error) {
+	var rev models.NoteRevision
+	var data []byte
+	if err := s.Scan(&rev.ID, &rev.NoteID, &rev.Revision, &rev.Title, &rev.Hash, &data, &rev.Size, &rev.Created); err != nil {
+		return nil, err
+	}
+
+	content, err := decompressContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress revision %d: %w", rev.Revision, err)
+	}
+	rev.Content = content
+	return &rev, nil
+}
+
+// recordRevision stores the note's current content as a new revision unless it matches the latest one
+func (r *NoteRepository) recordRevision(ctx context.Context, q execQuerier, noteID int64, title, content string, at time.Time) error {
+	hash := HashContent(content)
+
+	var latest int
+	var latestHash string
+	err := q.QueryRowContext(ctx, queryNoteRevisionLatest, noteID).Scan(&latest, &latestHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get latest revision: %w", err)
+	}
+	if err == nil && latestHash == hash {
+		return nil
+	}
+
+	data, err := compressContent(content)
+	if err != nil {
+		return fmt.Errorf("failed to compress revision: %w", err)
+	}
+
+	if _, err := q.ExecContext(ctx, queryNoteRevisionBlobInsert, hash, data, len(content)); err != nil {
+		return fmt.Errorf("failed to store revision content: %w", err)
+	}
+
+	if _, err := q.ExecContext(ctx, queryNoteRevisionInsert, noteID, latest+1, title, hash, at); err != nil {
+		return fmt.Errorf("failed to insert revision: %w", err)
+	}
+	return nil
+}
+
+// ensureBaseline snapshots the stored note when it has no history yet, so notes
+// created before revisions existed don't lose their previous content on update.
+func (r *NoteRepository) ensureBaseline(ctx context.Context, q execQuerier, noteID int64) error {
+	var latest int
+	var latestHash string
+	err := q.QueryRowContext(ctx, queryNoteRevisionLatest, noteID).Scan(&latest, &latestHash)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get latest revision: %w", err)
+	}
+
+	var title, content string
+	var modified time.Time
+	err = q.QueryRowContext(ctx, "SELECT title, content, modified FROM notes WHERE id = ?", noteID).Scan(&title, &content, &modified)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read note for baseline revision: %w", err)
+	}
+
+	return r.recordRevision(ctx, q, noteID, title, content, modified)
+}
+
+// ListRevisions returns all stored revisions of a note, oldest first
+func (r *NoteRepository) ListRevisions(ctx context.Context, noteID int64) ([]*models.NoteRevision, error) {
+	rows, err := r.db.QueryContext(ctx, queryNoteRevisionsList, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.NoteRevision
+	for rows.Next() {
+		rev, err := r.scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves a single revision of a note by its revision number
+func (r *NoteRepository) GetRevision(ctx context.Context, noteID int64, revision int) (*models.NoteRevision, error) {
+	row := r.db.QueryRowContext(ctx, queryNoteRevisionByNumber, noteID, revision)
+	rev, err := r.scanRevision(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NoteRevisionNotFoundError(noteID, revision)
+		}
+		return nil, fmt.Errorf("failed to scan revision: %w", err)
+	}
+	return rev, nil
+}
+
+// PruneRevisions removes revisions of a note that fall outside the retention policy and returns how many were deleted
+func (r *NoteRepository) PruneRevisions(ctx context.Context, noteID int64, policy RevisionRetention) (int, error) {
+	return r.pruneRevisions(ctx, r.db, noteID, policy, time.Now())
+}
+
+// ClearHistory removes every revision of a note except the latest and returns how many were deleted
+func (r *NoteRepository) ClearHistory(ctx context.Context, noteID int64) (int, error) {
+	return r.pruneRevisions(ctx, r.db, noteID, RevisionRetention{KeepLast: 1}, time.Now())
+}
+
+func (r *NoteRepository) pruneRevisions(ctx context.Context, q execQuerier, noteID int64, policy RevisionRetention, now time.Time) (int, error) {
+	if policy.IsZero() {
+		return 0, nil
+	}
+
+	rows, err := q.QueryContext(ctx, queryNoteRevisionTimes, noteID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query revisions: %w", err)
+	}
+
+	type entry struct {
+		id      int64
+		created time.Time
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.created); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating over revisions: %w", err)
+	}
+
+	cutoff := now.AddDate(0, 0, -policy.KeepDailyDays)
+	seenDays := map[string]bool{}
+	deleted := 0
+
+	// entries are newest first, so the first revision seen for a day is that day's newest
+	for i, e := range entries {
+		keep := i == 0 || i < policy.KeepLast
+		if policy.KeepDailyDays > 0 && e.created.After(cutoff) {
+			day := e.created.Local().Format("2006-01-02")
+			if !seenDays[day] {
+				seenDays[day] = true
+				keep = true
+			}
+		}
+		if keep {
+			continue
+		}
+
+		if _, err := q.ExecContext(ctx, queryNoteRevisionDelete, e.id); err != nil {
+			return deleted, fmt.Errorf("failed to delete revision: %w", err)
+		}
+		deleted++
+	}
+
+	if deleted > 0 {
+		if _, err := q.ExecContext(ctx, queryNoteRevisionBlobsOrphans); err != nil {
+			return deleted, fmt.Errorf("failed to remove unused revision content: %w", err)
+		}
+	}
+	return deleted, nil
+}
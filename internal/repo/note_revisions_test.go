@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestNoteRevisions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Create records first revision", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithTitle("First").WithContent("hello").Build()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		revisions, err := repo.ListRevisions(ctx, id)
+		shared.AssertNoError(t, err, "Failed to list revisions")
+		shared.AssertEqual(t, 1, len(revisions), "Expected one revision")
+		shared.AssertEqual(t, 1, revisions[0].Revision, "Expected revision number 1")
+		shared.AssertEqual(t, "hello", revisions[0].Content, "Revision content mismatch")
+		shared.AssertEqual(t, HashContent("hello"), revisions[0].Hash, "Revision hash mismatch")
+		shared.AssertEqual(t, 5, revisions[0].Size, "Revision size mismatch")
+	})
+
+	t.Run("Update records revision only when content changes", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithContent("v1").Build()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		note.Tags = []string{"changed"}
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update tags")
+
+		note.Content = "v2"
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update content")
+
+		revisions, err := repo.ListRevisions(ctx, id)
+		shared.AssertNoError(t, err, "Failed to list revisions")
+		shared.AssertEqual(t, 2, len(revisions), "Metadata-only updates should not add revisions")
+
+		rev, err := repo.GetRevision(ctx, id, 2)
+		shared.AssertNoError(t, err, "Failed to get revision")
+		shared.AssertEqual(t, "v2", rev.Content, "Revision 2 content mismatch")
+	})
+
+	t.Run("Identical content is stored once", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithContent(strings.Repeat("same ", 100)).Build()
+		_, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		other := NewNoteBuilder().WithContent(note.Content).Build()
+		_, err = repo.Create(ctx, other)
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		note.Content = "different"
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update note")
+		note.Content = strings.Repeat("same ", 100)
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to revert note")
+
+		var blobs int
+		err = db.QueryRow("SELECT COUNT(*) FROM note_revision_blobs").Scan(&blobs)
+		shared.AssertNoError(t, err, "Failed to count blobs")
+		shared.AssertEqual(t, 2, blobs, "Expected content to be deduplicated by hash")
+
+		var size int
+		err = db.QueryRow("SELECT LENGTH(data) FROM note_revision_blobs WHERE hash = ?", HashContent(note.Content)).Scan(&size)
+		shared.AssertNoError(t, err, "Failed to read blob size")
+		shared.AssertLessThan(t, size, len(note.Content), "Expected content to be compressed")
+	})
+
+	t.Run("Baseline is captured for notes without history", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithContent("legacy").Build()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+		_, err = db.Exec("DELETE FROM note_revisions")
+		shared.AssertNoError(t, err, "Failed to clear revisions")
+
+		note.Content = "updated"
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update note")
+
+		revisions, err := repo.ListRevisions(ctx, id)
+		shared.AssertNoError(t, err, "Failed to list revisions")
+		shared.AssertEqual(t, 2, len(revisions), "Expected baseline and new revision")
+		shared.AssertEqual(t, "legacy", revisions[0].Content, "Baseline content mismatch")
+		shared.AssertEqual(t, "updated", revisions[1].Content, "New content mismatch")
+	})
+
+	t.Run("GetRevision not found", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := CreateSampleNote()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		_, err = repo.GetRevision(ctx, id, 42)
+		shared.AssertErrorContains(t, err, "revision 42", "Expected not found error")
+	})
+
+	t.Run("Deleting a note removes its history", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithContent("to be removed").Build()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+		shared.AssertNoError(t, repo.Delete(ctx, id), "Failed to delete note")
+
+		var revisions, blobs int
+		shared.AssertNoError(t, db.QueryRow("SELECT COUNT(*) FROM note_revisions").Scan(&revisions), "count revisions")
+		shared.AssertNoError(t, db.QueryRow("SELECT COUNT(*) FROM note_revision_blobs").Scan(&blobs), "count blobs")
+		shared.AssertEqual(t, 0, revisions, "Expected revisions to be removed")
+		shared.AssertEqual(t, 0, blobs, "Expected blobs to be removed")
+	})
+
+	t.Run("ClearHistory keeps only the latest revision", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+
+		note := NewNoteBuilder().WithContent("secret").Build()
+		id, err := repo.Create(ctx, note)
+		shared.AssertNoError(t, err, "Failed to create note")
+		note.Content = "ciphertext"
+		note.Encrypted = true
+		shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update note")
+
+		deleted, err := repo.ClearHistory(ctx, id)
+		shared.AssertNoError(t, err, "ClearHistory should succeed")
+		shared.AssertEqual(t, 1, deleted, "Expected one revision to be removed")
+
+		revisions, err := repo.ListRevisions(ctx, id)
+		shared.AssertNoError(t, err, "Failed to list revisions")
+		shared.AssertEqual(t, 1, len(revisions), "Expected a single revision")
+		shared.AssertEqual(t, "ciphertext", revisions[0].Content, "Expected latest content to remain")
+
+		var blobs int
+		shared.AssertNoError(t, db.QueryRow("SELECT COUNT(*) FROM note_revision_blobs").Scan(&blobs), "count blobs")
+		shared.AssertEqual(t, 1, blobs, "Expected old content to be removed")
+
+		stored, err := repo.Get(ctx, id)
+		shared.AssertNoError(t, err, "Failed to get note")
+		shared.AssertTrue(t, stored.Encrypted, "Expected encrypted flag to be stored")
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		setup := func(t *testing.T, ages ...time.Duration) (*NoteRepository, int64) {
+			db := CreateTestDB(t)
+			repo := NewNoteRepository(db)
+
+			note := NewNoteBuilder().WithContent("rev 0").Build()
+			id, err := repo.Create(ctx, note)
+			shared.AssertNoError(t, err, "Failed to create note")
+
+			for i := range ages {
+				note.Content = "rev " + string(rune('1'+i))
+				shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update note")
+			}
+
+			// backdate revisions, oldest first
+			now := time.Now()
+			all := append([]time.Duration{ages[0] + time.Hour}, ages...)
+			for i, age := range all {
+				_, err := db.Exec("UPDATE note_revisions SET created = ? WHERE note_id = ? AND revision = ?", now.Add(-age), id, i+1)
+				shared.AssertNoError(t, err, "Failed to backdate revision")
+			}
+			return repo, id
+		}
+
+		day := 24 * time.Hour
+
+		t.Run("zero policy keeps everything", func(t *testing.T) {
+			repo, id := setup(t, 10*day, 5*day, time.Hour)
+			deleted, err := repo.PruneRevisions(ctx, id, RevisionRetention{})
+			shared.AssertNoError(t, err, "Failed to prune")
+			shared.AssertEqual(t, 0, deleted, "Expected nothing pruned")
+		})
+
+		t.Run("keep last", func(t *testing.T) {
+			repo, id := setup(t, 10*day, 5*day, time.Hour)
+			deleted, err := repo.PruneRevisions(ctx, id, RevisionRetention{KeepLast: 2})
+			shared.AssertNoError(t, err, "Failed to prune")
+			shared.AssertEqual(t, 2, deleted, "Expected two revisions pruned")
+
+			revisions, err := repo.ListRevisions(ctx, id)
+			shared.AssertNoError(t, err, "Failed to list revisions")
+			shared.AssertEqual(t, 3, revisions[0].Revision, "Expected revision 3 to survive")
+			shared.AssertEqual(t, 4, revisions[1].Revision, "Expected revision 4 to survive")
+		})
+
+		t.Run("keep daily", func(t *testing.T) {
+			repo, id := setup(t, 20*day, 3*day, 3*day-time.Minute, time.Hour)
+			deleted, err := repo.PruneRevisions(ctx, id, RevisionRetention{KeepLast: 1, KeepDailyDays: 7})
+			shared.AssertNoError(t, err, "Failed to prune")
+
+			revisions, err := repo.ListRevisions(ctx, id)
+			shared.AssertNoError(t, err, "Failed to list revisions")
+			shared.AssertTrue(t, deleted >= 2, "Expected revisions older than the window to be pruned")
+			for _, rev := range revisions {
+				shared.AssertTrue(t, rev.Revision >= 3, "Expected old revisions to be pruned")
+			}
+			shared.AssertEqual(t, 5, revisions[len(revisions)-1].Revision, "Latest revision must survive")
+		})
+
+		t.Run("applied on update", func(t *testing.T) {
+			db := CreateTestDB(t)
+			repo := NewNoteRepository(db)
+			repo.SetRevisionRetention(RevisionRetention{KeepLast: 2})
+
+			note := NewNoteBuilder().WithContent("a").Build()
+			id, err := repo.Create(ctx, note)
+			shared.AssertNoError(t, err, "Failed to create note")
+			for _, content := range []string{"b", "c", "d"} {
+				note.Content = content
+				shared.AssertNoError(t, repo.Update(ctx, note), "Failed to update note")
+			}
+
+			revisions, err := repo.ListRevisions(ctx, id)
+			shared.AssertNoError(t, err, "Failed to list revisions")
+			shared.AssertEqual(t, 2, len(revisions), "Expected retention to be applied")
+			shared.AssertEqual(t, "d", revisions[1].Content, "Latest content mismatch")
+		})
+	})
+
+	t.Run("Context Cancellation Error Paths", func(t *testing.T) {
+		db := CreateTestDB(t)
+		repo := NewNoteRepository(db)
+		id, err := repo.Create(ctx, CreateSampleNote())
+		shared.AssertNoError(t, err, "Failed to create note")
+
+		_, err = repo.ListRevisions(NewCanceledContext(), id)
+		AssertCancelledContext(t, err)
+		_, err = repo.GetRevision(NewCanceledContext(), id, 1)
+		AssertCancelledContext(t, err)
+		_, err = repo.PruneRevisions(NewCanceledContext(), id, RevisionRetention{KeepLast: 1})
+		AssertCancelledContext(t, err)
+	})
+}
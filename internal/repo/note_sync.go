@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+)
+
+func (r *NoteRepository) scanSyncState(s scanner) (*models.NoteSyncState, error) {
+	var state models.NoteSyncState
+	if err := s.Scan(&state.NoteID, &state.Path, &state.FileHash, &state.NoteHash, &state.SyncedAt); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ListSyncStates returns the sync state of every note that is mirrored to a file
+func (r *NoteRepository) ListSyncStates(ctx context.Context) ([]*models.NoteSyncState, error) {
+	rows, err := r.db.QueryContext(ctx, queryNoteSyncStatesList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*models.NoteSyncState
+	for rows.Next() {
+		state, err := r.scanSyncState(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync state: %w", err)
+		}
+		states = append(states, state)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over sync states: %w", err)
+	}
+	return states, nil
+}
+
+// GetSyncState returns the sync state for a note, or nil if the note has never been synced
+func (r *NoteRepository) GetSyncState(ctx context.Context, noteID int64) (*models.NoteSyncState, error) {
+	state, err := r.scanSyncState(r.db.QueryRowContext(ctx, queryNoteSyncStateByID, noteID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveSyncState records the reconciled state of a note and its file.
+//
+// Any other note previously mapped to the same path loses its mapping.
+func (r *NoteRepository) SaveSyncState(ctx context.Context, state *models.NoteSyncState) error {
+	if state.SyncedAt.IsZero() {
+		state.SyncedAt = time.Now()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, queryNoteSyncStateDeleteByPath, state.Path, state.NoteID); err != nil {
+		return fmt.Errorf("failed to release sync path: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryNoteSyncStateSave,
+		state.NoteID, state.Path, state.FileHash, state.NoteHash, state.SyncedAt); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSyncState forgets the file mapping of a note
+func (r *NoteRepository) DeleteSyncState(ctx context.Context, noteID int64) error {
+	if _, err := r.db.ExecContext(ctx, queryNoteSyncStateDelete, noteID); err != nil {
+		return fmt.Errorf("failed to delete sync state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+func TestNoteSyncState(t *testing.T) {
+	ctx := context.Background()
+	db := CreateTestDB(t)
+	repo := NewNoteRepository(db)
+
+	first, err := repo.Create(ctx, CreateSampleNote())
+	shared.AssertNoError(t, err, "Failed to create note")
+	second, err := repo.Create(ctx, CreateSampleNote())
+	shared.AssertNoError(t, err, "Failed to create note")
+
+	t.Run("missing state returns nil", func(t *testing.T) {
+		state, err := repo.GetSyncState(ctx, first)
+		shared.AssertNoError(t, err, "GetSyncState should not fail")
+		shared.AssertTrue(t, state == nil, "Expected no state")
+	})
+
+	t.Run("save and update", func(t *testing.T) {
+		err := repo.SaveSyncState(ctx, &models.NoteSyncState{NoteID: first, Path: "/notes/a.md", FileHash: "f1", NoteHash: "n1"})
+		shared.AssertNoError(t, err, "Failed to save state")
+
+		err = repo.SaveSyncState(ctx, &models.NoteSyncState{NoteID: first, Path: "/notes/b.md", FileHash: "f2", NoteHash: "n2"})
+		shared.AssertNoError(t, err, "Failed to update state")
+
+		state, err := repo.GetSyncState(ctx, first)
+		shared.AssertNoError(t, err, "Failed to get state")
+		shared.AssertEqual(t, "/notes/b.md", state.Path, "Path mismatch")
+		shared.AssertEqual(t, "f2", state.FileHash, "File hash mismatch")
+		shared.AssertEqual(t, "n2", state.NoteHash, "Note hash mismatch")
+	})
+
+	t.Run("path moves to new owner", func(t *testing.T) {
+		err := repo.SaveSyncState(ctx, &models.NoteSyncState{NoteID: second, Path: "/notes/b.md", FileHash: "f3", NoteHash: "n3"})
+		shared.AssertNoError(t, err, "Failed to save state")
+
+		states, err := repo.ListSyncStates(ctx)
+		shared.AssertNoError(t, err, "Failed to list states")
+		shared.AssertEqual(t, 1, len(states), "Expected a single state")
+		shared.AssertEqual(t, second, states[0].NoteID, "Expected path to belong to second note")
+	})
+
+	t.Run("delete and cascade", func(t *testing.T) {
+		shared.AssertNoError(t, repo.DeleteSyncState(ctx, second), "Failed to delete state")
+
+		err := repo.SaveSyncState(ctx, &models.NoteSyncState{NoteID: first, Path: "/notes/a.md", FileHash: "f", NoteHash: "n"})
+		shared.AssertNoError(t, err, "Failed to save state")
+		shared.AssertNoError(t, repo.Delete(ctx, first), "Failed to delete note")
+
+		states, err := repo.ListSyncStates(ctx)
+		shared.AssertNoError(t, err, "Failed to list states")
+		shared.AssertEqual(t, 0, len(states), "Expected state to be removed with its note")
+	})
+}
@@ -1,24 +1,140 @@
 package repo
 
 const (
-	noteColumns     = "id, title, content, tags, archived, created, modified, file_path, leaflet_rkey, leaflet_cid, published_at, is_draft"
+	noteColumns     = "id, title, content, tags, archived, created, modified, file_path, leaflet_rkey, leaflet_cid, leaflet_publication, published_at, is_draft, encrypted"
 	queryNoteByID   = "SELECT " + noteColumns + " FROM notes WHERE id = ?"
-	queryNoteInsert = `INSERT INTO notes (title, content, tags, archived, created, modified, file_path, leaflet_rkey, leaflet_cid, published_at, is_draft) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	queryNoteUpdate = `UPDATE notes SET title = ?, content = ?, tags = ?, archived = ?, modified = ?, file_path = ?, leaflet_rkey = ?, leaflet_cid = ?, published_at = ?, is_draft = ? WHERE id = ?`
+	queryNoteInsert = `INSERT INTO notes (title, content, tags, archived, created, modified, file_path, leaflet_rkey, leaflet_cid, leaflet_publication, published_at, is_draft, encrypted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	queryNoteUpdate = `UPDATE notes SET title = ?, content = ?, tags = ?, archived = ?, modified = ?, file_path = ?, leaflet_rkey = ?, leaflet_cid = ?, leaflet_publication = ?, published_at = ?, is_draft = ?, encrypted = ? WHERE id = ?`
 	queryNoteDelete = "DELETE FROM notes WHERE id = ?"
 	queryNotesList  = "SELECT " + noteColumns + " FROM notes"
 )
+
+const (
+	noteRevisionColumns           = "r.id, r.note_id, r.revision, r.title, r.content_hash, b.data, b.size, r.created"
+	noteRevisionFrom              = " FROM note_revisions r JOIN note_revision_blobs b ON b.hash = r.content_hash"
+	queryNoteRevisionsList        = "SELECT " + noteRevisionColumns + noteRevisionFrom + " WHERE r.note_id = ? ORDER BY r.revision"
+	queryNoteRevisionByNumber     = "SELECT " + noteRevisionColumns + noteRevisionFrom + " WHERE r.note_id = ? AND r.revision = ?"
+	queryNoteRevisionLatest       = "SELECT revision, content_hash FROM note_revisions WHERE note_id = ? ORDER BY revision DESC LIMIT 1"
+	queryNoteRevisionTimes        = "SELECT id, created FROM note_revisions WHERE note_id = ? ORDER BY revision DESC"
+	queryNoteRevisionInsert       = `INSERT INTO note_revisions (note_id, revision, title, content_hash, created) VALUES (?, ?, ?, ?, ?)`
+	queryNoteRevisionDelete       = "DELETE FROM note_revisions WHERE id = ?"
+	queryNoteRevisionBlobInsert   = `INSERT OR IGNORE INTO note_revision_blobs (hash, data, size) VALUES (?, ?, ?)`
+	queryNoteRevisionBlobsOrphans = "DELETE FROM note_revision_blobs WHERE hash NOT IN (SELECT content_hash FROM note_revisions)"
+)
+
+const (
+	noteSyncStateColumns    = "note_id, path, file_hash, note_hash, synced_at"
+	queryNoteSyncStatesList = "SELECT " + noteSyncStateColumns + " FROM note_sync_state ORDER BY path"
+	queryNoteSyncStateByID  = "SELECT " + noteSyncStateColumns + " FROM note_sync_state WHERE note_id = ?"
+	queryNoteSyncStateSave  = `
+		INSERT INTO note_sync_state (note_id, path, file_hash, note_hash, synced_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(note_id) DO UPDATE SET
+			path = excluded.path, file_hash = excluded.file_hash,
+			note_hash = excluded.note_hash, synced_at = excluded.synced_at`
+	queryNoteSyncStateDelete       = "DELETE FROM note_sync_state WHERE note_id = ?"
+	queryNoteSyncStateDeleteByPath = "DELETE FROM note_sync_state WHERE path = ? AND note_id != ?"
+)
+const (
+	leafletSyncColumns       = "note_id, rkey, base_title, base_content, remote_cid, remote_title, remote_content, status, synced_at"
+	queryLeafletSyncList     = "SELECT " + leafletSyncColumns + " FROM leaflet_sync ORDER BY note_id"
+	queryLeafletSyncByStatus = "SELECT " + leafletSyncColumns + " FROM leaflet_sync WHERE status = ? ORDER BY note_id"
+	queryLeafletSyncByID     = "SELECT " + leafletSyncColumns + " FROM leaflet_sync WHERE note_id = ?"
+	queryLeafletSyncSave     = `
+		INSERT INTO leaflet_sync (note_id, rkey, base_title, base_content, remote_cid, remote_title, remote_content, status, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(note_id) DO UPDATE SET
+			rkey = excluded.rkey, base_title = excluded.base_title, base_content = excluded.base_content,
+			remote_cid = excluded.remote_cid, remote_title = excluded.remote_title,
+			remote_content = excluded.remote_content, status = excluded.status, synced_at = excluded.synced_at`
+	queryLeafletSyncDelete = "DELETE FROM leaflet_sync WHERE note_id = ?"
+)
+
+const (
+	leafletOutboxColumns       = "id, note_id, operation, rkey, is_draft, publication, document, base_title, base_content, attempts, last_error, failed, queued_at, updated_at"
+	queryLeafletOutboxList     = "SELECT " + leafletOutboxColumns + " FROM leaflet_outbox ORDER BY id"
+	queryLeafletOutboxByNoteID = "SELECT " + leafletOutboxColumns + " FROM leaflet_outbox WHERE note_id = ?"
+	queryLeafletOutboxSave     = `
+		INSERT INTO leaflet_outbox (note_id, operation, rkey, is_draft, publication, document, base_title, base_content, attempts, last_error, failed, queued_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(note_id) DO UPDATE SET
+			operation = excluded.operation, rkey = excluded.rkey, is_draft = excluded.is_draft,
+			publication = excluded.publication, document = excluded.document,
+			base_title = excluded.base_title, base_content = excluded.base_content,
+			attempts = excluded.attempts, last_error = excluded.last_error, failed = excluded.failed,
+			updated_at = excluded.updated_at`
+	queryLeafletOutboxFailure = "UPDATE leaflet_outbox SET attempts = attempts + ?, last_error = ?, failed = ?, updated_at = ? WHERE id = ?"
+	queryLeafletOutboxDelete  = "DELETE FROM leaflet_outbox WHERE note_id = ?"
+	queryLeafletOutboxClear   = "DELETE FROM leaflet_outbox"
+)
+
 const (
-	articleColumns     = "id, url, title, author, date, markdown_path, html_path, created, modified"
+	blobCacheColumns   = "hash, did, cid, mime_type, size, width, height, created"
+	queryBlobCacheGet  = "SELECT " + blobCacheColumns + " FROM blob_cache WHERE did = ? AND hash = ?"
+	queryBlobCacheSave = `
+		INSERT INTO blob_cache (hash, did, cid, mime_type, size, width, height, created)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(hash, did) DO UPDATE SET
+			cid = excluded.cid, mime_type = excluded.mime_type, size = excluded.size,
+			width = excluded.width, height = excluded.height, created = excluded.created`
+	queryBlobCacheClear = "DELETE FROM blob_cache WHERE did = ?"
+)
+
+const (
+	noteImportColumns     = "hash, note_id, source, path, imported"
+	queryNoteImportByHash = "SELECT " + noteImportColumns + " FROM note_imports WHERE hash = ?"
+	queryNoteImportInsert = `INSERT INTO note_imports (hash, note_id, source, path, imported) VALUES (?, ?, ?, ?, ?)`
+	queryNoteContentSet   = "UPDATE notes SET content = ? WHERE id = ?"
+)
+
+const (
+	articleColumns     = "id, url, title, author, date, markdown_path, html_path, status, favorite, tags, progress, word_count, reading_time, note_id, citation, link_status, link_error, redirect_url, redirect_status, link_checked, archive_url, created, modified"
 	queryArticleByID   = "SELECT " + articleColumns + " FROM articles WHERE id = ?"
 	queryArticleByURL  = "SELECT " + articleColumns + " FROM articles WHERE url = ?"
-	queryArticleInsert = `INSERT INTO articles (url, title, author, date, markdown_path, html_path, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	queryArticleUpdate = `UPDATE articles SET title = ?, author = ?, date = ?, markdown_path = ?, html_path = ?, modified = ? WHERE id = ?`
+	queryArticleInsert = `INSERT INTO articles (url, title, author, date, markdown_path, html_path, status, favorite, tags, progress, word_count, reading_time, note_id, citation, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	queryArticleUpdate = `UPDATE articles SET title = ?, author = ?, date = ?, markdown_path = ?, html_path = ?, status = ?, favorite = ?, tags = ?, progress = ?, word_count = ?, reading_time = ?, note_id = ?, citation = ?, modified = ? WHERE id = ?`
 	queryArticleDelete = "DELETE FROM articles WHERE id = ?"
+	queryArticleSetURL = "UPDATE articles SET url = ?, redirect_url = NULL, redirect_status = 0, modified = ? WHERE id = ?"
+
+	queryArticleLinkCheckUpdate = `UPDATE articles SET link_status = ?, link_error = ?, redirect_url = ?, redirect_status = ?, link_checked = ?, archive_url = ? WHERE id = ?`
+
 	queryArticlesList  = "SELECT " + articleColumns + " FROM articles"
 	queryArticlesCount = "SELECT COUNT(*) FROM articles"
 )
 
+const (
+	articleFeedColumns     = "id, url, title, site_url, etag, last_modified, include, exclude, max_age, tags, last_fetched, last_error, created, modified"
+	queryArticleFeedByID   = "SELECT " + articleFeedColumns + " FROM article_feeds WHERE id = ?"
+	queryArticleFeedByURL  = "SELECT " + articleFeedColumns + " FROM article_feeds WHERE url = ?"
+	queryArticleFeedsList  = "SELECT " + articleFeedColumns + " FROM article_feeds ORDER BY id"
+	queryArticleFeedInsert = `INSERT INTO article_feeds (url, title, site_url, etag, last_modified, include, exclude, max_age, tags, last_fetched, last_error, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	queryArticleFeedUpdate = `UPDATE article_feeds SET url = ?, title = ?, site_url = ?, etag = ?, last_modified = ?, include = ?, exclude = ?, max_age = ?, tags = ?, last_fetched = ?, last_error = ?, modified = ? WHERE id = ?`
+	queryArticleFeedDelete = "DELETE FROM article_feeds WHERE id = ?"
+
+	queryArticleFeedItemExists = "SELECT COUNT(*) FROM article_feed_items WHERE feed_id = ? AND guid = ?"
+	queryArticleFeedItemInsert = `INSERT OR IGNORE INTO article_feed_items (feed_id, guid, url, article_id, added) VALUES (?, ?, ?, ?, ?)`
+	queryArticleFeedItemsCount = "SELECT COUNT(*) FROM article_feed_items WHERE feed_id = ?"
+)
+
+const (
+	articleHighlightColumns     = "id, article_id, quote, note, start_offset, end_offset, created"
+	queryArticleHighlightInsert = `INSERT INTO article_highlights (article_id, quote, note, start_offset, end_offset, created) VALUES (?, ?, ?, ?, ?, ?)`
+	queryArticleHighlightsList  = "SELECT " + articleHighlightColumns + " FROM article_highlights WHERE article_id = ? ORDER BY start_offset, id"
+	queryArticleHighlightDelete = "DELETE FROM article_highlights WHERE id = ? AND article_id = ?"
+)
+
+const (
+	articleImportColumns     = "id, source, path, hash, total, created, modified"
+	queryArticleImportByHash = "SELECT " + articleImportColumns + " FROM article_imports WHERE hash = ?"
+	queryArticleImportInsert = `INSERT INTO article_imports (source, path, hash, total, created, modified) VALUES (?, ?, ?, ?, ?, ?)`
+
+	articleImportItemColumns      = "import_id, position, url, title, tags, status, favorite, saved, state, article_id, error"
+	queryArticleImportItemInsert  = `INSERT INTO article_import_items (import_id, position, url, title, tags, status, favorite, saved, state) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	queryArticleImportItemsUndone = "SELECT " + articleImportItemColumns + " FROM article_import_items WHERE import_id = ? AND state != 'done' ORDER BY position"
+	queryArticleImportItemState   = "UPDATE article_import_items SET state = ?, article_id = ?, error = ? WHERE import_id = ? AND position = ?"
+	queryArticleImportItemsCounts = "SELECT state, COUNT(*) FROM article_import_items WHERE import_id = ? GROUP BY state"
+	queryArticleImportMerge       = "UPDATE articles SET status = ?, favorite = ?, tags = ?, progress = ?, created = ?, modified = ? WHERE id = ?"
+)
+
 const (
 	taskColumns     = "id, uuid, description, status, priority, project, context, tags, due, wait, scheduled, entry, modified, end, start, annotations, recur, until, parent_uuid"
 	queryTaskByID   = "SELECT " + taskColumns + " FROM tasks WHERE id = ?"
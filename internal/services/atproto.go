@@ -11,7 +11,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -52,19 +55,28 @@ type Session struct {
 	PDSURL        string    // Personal Data Server URL
 	ExpiresAt     time.Time // When access token expires
 	Authenticated bool      // Whether session is valid
+
+	// OAuth is set for sessions created with [ATProtoService.AuthenticateOAuth].
+	// Their tokens are DPoP bound and only usable together with its key.
+	OAuth *OAuthSession
 }
 
 // ATProtoClient defines the interface for AT Protocol operations
 type ATProtoClient interface {
 	Authenticate(ctx context.Context, handle, password string) error
+	AuthenticateOAuth(ctx context.Context, handle string, openURL func(authURL string) error) error
 	GetSession() (*Session, error)
 	IsAuthenticated() bool
 	RestoreSession(session *Session) error
 	PullDocuments(ctx context.Context) ([]DocumentWithMeta, error)
 	PostDocument(ctx context.Context, doc public.Document, isDraft bool) (*DocumentWithMeta, error)
 	PatchDocument(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*DocumentWithMeta, error)
+	MoveDocument(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*DocumentWithMeta, error)
 	DeleteDocument(ctx context.Context, rkey string, isDraft bool) error
 	UploadBlob(ctx context.Context, data []byte, mimeType string) (public.Blob, error)
+	ListPublications(ctx context.Context) ([]PublicationWithMeta, error)
+	CreatePublication(ctx context.Context, pub public.Publication) (*PublicationWithMeta, error)
+	UpdatePublication(ctx context.Context, rkey string, changes public.Publication) (*PublicationWithMeta, error)
 	GetDefaultPublication(ctx context.Context) (string, error)
 	Close() error
 }
@@ -76,15 +88,10 @@ type ATProtoService struct {
 	session  *Session
 	pdsURL   string // Personal Data Server URL
 	client   *xrpc.Client
+	oauth    *OAuthClient
+	auth     *oauthAuth // set for OAuth sessions
 
-	// TODO: Future enhancement - integrate OS keychain for secure password storage
-	// Consider using keyring libraries like:
-	//   - github.com/zalando/go-keyring (cross-platform)
-	//   - keychain access on macOS (Security.framework)
-	//   - Windows Credential Manager (credman)
-	//   - Linux Secret Service API (libsecret)
-	// This would allow storing app passwords securely in the system keychain
-	// instead of requiring re-authentication every time JWTs expire.
+	onSessionUpdate func(*Session) // persists refreshed tokens; the handler keeps them in a store.SecretStore
 }
 
 // NewATProtoService creates a new AT Protocol service
@@ -95,6 +102,16 @@ func NewATProtoService() *ATProtoService {
 		client: &xrpc.Client{
 			Host: pdsURL,
 		},
+		oauth: NewOAuthClient(),
+	}
+}
+
+// OnSessionUpdate registers fn to be called whenever tokens are refreshed, so
+// rotated refresh tokens can be saved before the old one stops working
+func (s *ATProtoService) OnSessionUpdate(fn func(*Session)) {
+	s.onSessionUpdate = fn
+	if s.auth != nil {
+		s.auth.onRefresh = fn
 	}
 }
 
@@ -106,6 +123,7 @@ func (s *ATProtoService) Authenticate(ctx context.Context, handle, password stri
 
 	s.handle = handle
 	s.password = password
+	s.usePasswordClient()
 
 	input := &atproto.ServerCreateSession_Input{
 		Identifier: handle,
@@ -139,6 +157,52 @@ func (s *ATProtoService) Authenticate(ctx context.Context, handle, password stri
 	return nil
 }
 
+// AuthenticateOAuth signs in through the user's browser with AT Protocol OAuth
+// instead of an app password. openURL is called with the authorization page.
+func (s *ATProtoService) AuthenticateOAuth(ctx context.Context, handle string, openURL func(authURL string) error) error {
+	if handle == "" {
+		return fmt.Errorf("handle is required")
+	}
+
+	session, err := s.oauth.Authorize(ctx, handle, openURL)
+	if err != nil {
+		return err
+	}
+	s.handle = handle
+	return s.useOAuthSession(session)
+}
+
+// useOAuthSession routes PDS requests through a DPoP transport holding the session's tokens
+func (s *ATProtoService) useOAuthSession(session *Session) error {
+	auth, err := newOAuthAuth(session, s.oauth.HTTPClient)
+	if err != nil {
+		return err
+	}
+	auth.onRefresh = s.onSessionUpdate
+
+	base := s.oauth.HTTPClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	s.auth = auth
+	s.session = session
+	s.pdsURL = session.PDSURL
+	s.client = &xrpc.Client{
+		Host:   session.PDSURL,
+		Client: &http.Client{Transport: &dpopTransport{base: base, auth: auth}},
+	}
+	return nil
+}
+
+// usePasswordClient drops an OAuth transport left over from an earlier session
+func (s *ATProtoService) usePasswordClient() {
+	if s.auth != nil {
+		s.auth = nil
+		s.client = &xrpc.Client{Host: s.pdsURL}
+	}
+}
+
 // GetSession returns the current session information
 func (s *ATProtoService) GetSession() (*Session, error) {
 	if s.session == nil || !s.session.Authenticated {
@@ -163,6 +227,22 @@ func (s *ATProtoService) RestoreSession(session *Session) error {
 		return fmt.Errorf("session missing required fields (DID, AccessJWT, RefreshJWT)")
 	}
 
+	if session.OAuth != nil {
+		if session.PDSURL == "" {
+			return fmt.Errorf("OAuth session missing PDS URL")
+		}
+		if err := s.useOAuthSession(session); err != nil {
+			return err
+		}
+		if time.Now().Add(5 * time.Minute).After(session.ExpiresAt) {
+			if err := s.RefreshToken(context.Background()); err != nil {
+				return fmt.Errorf("session expired and refresh failed: %w", err)
+			}
+		}
+		return nil
+	}
+
+	s.usePasswordClient()
 	s.session = session
 
 	s.client.Auth = &xrpc.AuthInfo{
@@ -197,6 +277,11 @@ func (s *ATProtoService) RefreshToken(ctx context.Context) error {
 		return fmt.Errorf("no session available to refresh")
 	}
 
+	if s.auth != nil {
+		_, err := s.auth.refresh(ctx, s.session.AccessJWT)
+		return err
+	}
+
 	s.client.Auth = &xrpc.AuthInfo{
 		AccessJwt:  s.session.AccessJWT,
 		RefreshJwt: s.session.RefreshJWT,
@@ -221,10 +306,13 @@ func (s *ATProtoService) RefreshToken(ctx context.Context) error {
 	s.client.Auth.AccessJwt = output.AccessJwt
 	s.client.Auth.RefreshJwt = output.RefreshJwt
 
+	if s.onSessionUpdate != nil {
+		s.onSessionUpdate(s.session)
+	}
 	return nil
 }
 
-// PullDocuments fetches all leaflet documents from the user's repository
+// PullDocuments fetches all leaflet documents, published and drafts, from the user's repository
 func (s *ATProtoService) PullDocuments(ctx context.Context) ([]DocumentWithMeta, error) {
 	if !s.IsAuthenticated() {
 		return nil, fmt.Errorf("not authenticated")
@@ -241,73 +329,74 @@ func (s *ATProtoService) PullDocuments(ctx context.Context) ([]DocumentWithMeta,
 	}
 
 	var documents []DocumentWithMeta
-	prefix := public.TypeDocument
+	prefix := public.TypeDocument // also matches the draft collection, pub.leaflet.document.draft
 
-	documentCount := 0
 	err = r.ForEach(ctx, prefix, func(k string, v cid.Cid) error {
-		documentCount++
-
 		_, recordBytes, err := r.GetRecordBytes(ctx, k)
 		if err != nil {
 			return fmt.Errorf("failed to get record bytes for %s: %w", k, err)
 		}
 
-		var cborData any
-		if err := cbor.Unmarshal(*recordBytes, &cborData); err != nil {
-			return fmt.Errorf("failed to decode CBOR for document %s: %w", k, err)
-		}
-
-		jsonCompatible := convertCBORToJSONCompatible(cborData)
-
-		jsonBytes, err := json.MarshalIndent(jsonCompatible, "", "  ")
+		doc, err := decodeDocumentRecord(s.session.DID, k, v.String(), *recordBytes)
 		if err != nil {
-			return fmt.Errorf("failed to convert CBOR to JSON for document %s: %w", k, err)
+			return err
 		}
-
-		parts := strings.Split(k, "/")
-		rkey := ""
-		if len(parts) > 0 {
-			rkey = parts[len(parts)-1]
+		if doc != nil {
+			documents = append(documents, *doc)
 		}
+		return nil
+	})
 
-		var typeCheck public.TypeCheck
-
-		if err := json.Unmarshal(jsonBytes, &typeCheck); err != nil {
-			return fmt.Errorf("failed to check $type for %s: %w", k, err)
-		}
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over documents: %w", err)
+	}
 
-		if typeCheck.Type != public.TypeDocument {
-			return nil
-		}
+	return documents, nil
+}
 
-		var doc public.Document
-		if err := json.Unmarshal(jsonBytes, &doc); err != nil {
-			return fmt.Errorf("failed to unmarshal JSON to Document for %s: %w", k, err)
-		}
+// decodeDocumentRecord decodes a repository record at key (collection/rkey) into a document.
+//
+// Both published documents and drafts are decoded; it returns nil for records from
+// other collections or whose $type does not match their collection.
+func decodeDocumentRecord(did, key, recordCID string, recordBytes []byte) (*DocumentWithMeta, error) {
+	parts := strings.Split(key, "/")
+	collection, rkey := parts[0], parts[len(parts)-1]
+	if collection != public.TypeDocument && collection != public.TypeDocumentDraft {
+		return nil, nil
+	}
 
-		uri := fmt.Sprintf("at://%s/%s", s.session.DID, k)
+	var cborData any
+	if err := cbor.Unmarshal(recordBytes, &cborData); err != nil {
+		return nil, fmt.Errorf("failed to decode CBOR for document %s: %w", key, err)
+	}
 
-		meta := public.DocumentMeta{
-			RKey:      rkey,
-			CID:       v.String(),
-			URI:       uri,
-			IsDraft:   false,
-			FetchedAt: time.Now(),
-		}
+	jsonBytes, err := json.Marshal(convertCBORToJSONCompatible(cborData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert CBOR to JSON for document %s: %w", key, err)
+	}
 
-		documents = append(documents, DocumentWithMeta{
-			Document: doc,
-			Meta:     meta,
-		})
+	var typeCheck public.TypeCheck
+	if err := json.Unmarshal(jsonBytes, &typeCheck); err != nil {
+		return nil, fmt.Errorf("failed to check $type for %s: %w", key, err)
+	}
 
-		return nil
-	})
+	if typeCheck.Type != collection {
+		return nil, nil
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to iterate over documents: %w", err)
+	var doc public.Document
+	if err := json.Unmarshal(jsonBytes, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON to Document for %s: %w", key, err)
 	}
 
-	return documents, nil
+	meta := public.DocumentMeta{
+		RKey:      rkey,
+		CID:       recordCID,
+		URI:       fmt.Sprintf("at://%s/%s", did, key),
+		IsDraft:   collection == public.TypeDocumentDraft,
+		FetchedAt: time.Now(),
+	}
+	return &DocumentWithMeta{Document: doc, Meta: meta}, nil
 }
 
 // ListPublications fetches available publications for the authenticated user
@@ -390,6 +479,79 @@ func (s *ATProtoService) GetDefaultPublication(ctx context.Context) (string, err
 	return publications[0].URI, nil
 }
 
+// CreatePublication creates a new publication in the user's repository
+func (s *ATProtoService) CreatePublication(ctx context.Context, pub public.Publication) (*PublicationWithMeta, error) {
+	if !s.IsAuthenticated() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if pub.Name == "" {
+		return nil, fmt.Errorf("publication name is required")
+	}
+
+	pub.Type = public.TypePublication
+
+	m, err := publicationRecord(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := repoCreateRecord(ctx, s.client, s.session.DID, public.TypePublication, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record: %w", err)
+	}
+
+	parts := strings.Split(output.Uri, "/")
+	return &PublicationWithMeta{Publication: pub, RKey: parts[len(parts)-1], CID: output.Cid, URI: output.Uri}, nil
+}
+
+// UpdatePublication changes the name, description, base path or icon of a publication.
+//
+// Empty fields of changes are left as they are. Fields noteleaf does not manage,
+// such as the theme, are kept from the stored record.
+func (s *ATProtoService) UpdatePublication(ctx context.Context, rkey string, changes public.Publication) (*PublicationWithMeta, error) {
+	if !s.IsAuthenticated() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if rkey == "" {
+		return nil, fmt.Errorf("rkey is required")
+	}
+
+	current, err := repoGetRecord(ctx, s.client, s.session.DID, public.TypePublication, rkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+
+	m, err := publicationRecord(changes)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"name", "description", "base_path", "icon"} {
+		if v, ok := m[key]; ok && v != "" {
+			current[key] = v
+		}
+	}
+	current["$type"] = public.TypePublication
+
+	output, err := repoPutRecord(ctx, s.client, s.session.DID, public.TypePublication, rkey, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update record: %w", err)
+	}
+
+	jsonBytes, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	var pub public.Publication
+	if err := json.Unmarshal(jsonBytes, &pub); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	uri := fmt.Sprintf("at://%s/%s/%s", s.session.DID, public.TypePublication, rkey)
+	return &PublicationWithMeta{Publication: pub, RKey: rkey, CID: output.Cid, URI: uri}, nil
+}
+
 // PostDocument creates a new document in the user's repository
 func (s *ATProtoService) PostDocument(ctx context.Context, doc public.Document, isDraft bool) (*DocumentWithMeta, error) {
 	if !s.IsAuthenticated() {
@@ -406,17 +568,11 @@ func (s *ATProtoService) PostDocument(ctx context.Context, doc public.Document,
 	}
 
 	doc.Type = collection
-	jsonBytes, err := json.Marshal(doc)
+	m, err := documentRecord(doc)
 	if err != nil {
-		return nil, fmt.Errorf("marshal: %w", err)
+		return nil, err
 	}
 
-	var m map[string]any
-	if err := json.Unmarshal(jsonBytes, &m); err != nil {
-		return nil, fmt.Errorf("unmarshal: %w", err)
-	}
-	m["$type"] = collection
-
 	output, err := repoCreateRecord(ctx, s.client, s.session.DID, collection, m)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create record: %w", err)
@@ -454,16 +610,10 @@ func (s *ATProtoService) PatchDocument(ctx context.Context, rkey string, doc pub
 	}
 
 	doc.Type = collection
-	jsonBytes, err := json.Marshal(doc)
+	m, err := documentRecord(doc)
 	if err != nil {
-		return nil, fmt.Errorf("marshal: %w", err)
-	}
-
-	var m map[string]any
-	if err := json.Unmarshal(jsonBytes, &m); err != nil {
-		return nil, fmt.Errorf("unmarshal: %w", err)
+		return nil, err
 	}
-	m["$type"] = collection
 
 	output, err := repoPutRecord(ctx, s.client, s.session.DID, collection, rkey, m)
 	if err != nil {
@@ -481,6 +631,55 @@ func (s *ATProtoService) PatchDocument(ctx context.Context, rkey string, doc pub
 	return &DocumentWithMeta{Document: doc, Meta: meta}, nil
 }
 
+// MoveDocument publishes a draft or turns a published document back into a draft.
+//
+// Drafts and published documents live in separate collections, so the record is
+// created in the target collection and removed from the source in one applyWrites
+// commit. The rkey is kept unless the target collection already uses it, in which
+// case the document is given a new one.
+func (s *ATProtoService) MoveDocument(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*DocumentWithMeta, error) {
+	if !s.IsAuthenticated() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if rkey == "" {
+		return nil, fmt.Errorf("rkey is required")
+	}
+
+	if doc.Title == "" {
+		return nil, fmt.Errorf("document title is required")
+	}
+
+	from, to := public.TypeDocumentDraft, public.TypeDocument
+	if toDraft {
+		from, to = to, from
+	}
+
+	doc.Type = to
+	m, err := documentRecord(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := repoMoveRecord(ctx, s.client, s.session.DID, from, to, rkey, rkey, m)
+	if recordExists(err) {
+		result, err = repoMoveRecord(ctx, s.client, s.session.DID, from, to, rkey, "", m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to move record: %w", err)
+	}
+
+	parts := strings.Split(result.Uri, "/")
+	meta := public.DocumentMeta{
+		RKey:      parts[len(parts)-1],
+		CID:       result.Cid,
+		URI:       result.Uri,
+		IsDraft:   toDraft,
+		FetchedAt: time.Now(),
+	}
+	return &DocumentWithMeta{Document: doc, Meta: meta}, nil
+}
+
 // DeleteDocument removes a document from the user's repository
 func (s *ATProtoService) DeleteDocument(ctx context.Context, rkey string, isDraft bool) error {
 	if !s.IsAuthenticated() {
@@ -538,9 +737,58 @@ func (s *ATProtoService) UploadBlob(ctx context.Context, data []byte, mimeType s
 	return blob, nil
 }
 
+// IsTransient reports whether a failed request is worth retrying later: the PDS
+// could not be reached, was overloaded or rate limiting, or the session expired.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var xerr *xrpc.Error
+	if !errors.As(err, &xerr) {
+		return false
+	}
+	if xerr.StatusCode >= http.StatusInternalServerError || xerr.StatusCode == http.StatusTooManyRequests ||
+		xerr.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	var xe *xrpc.XRPCError
+	return errors.As(xerr.Wrapped, &xe) && xe.ErrStr == "ExpiredToken"
+}
+
+// recordExists reports whether a write failed because a record already exists under its rkey.
+// PDSes answer with 400 or 409 and name the conflict in the XRPC error or its message.
+func recordExists(err error) bool {
+	var xerr *xrpc.Error
+	if !errors.As(err, &xerr) {
+		return false
+	}
+	if xerr.StatusCode != http.StatusBadRequest && xerr.StatusCode != http.StatusConflict {
+		return false
+	}
+
+	var xe *xrpc.XRPCError
+	if !errors.As(xerr.Wrapped, &xe) {
+		return false
+	}
+	text := strings.ToLower(xe.ErrStr + " " + xe.Message)
+	return strings.Contains(text, "already exists") || strings.Contains(text, "conflict") ||
+		strings.Contains(text, "alreadyexists")
+}
+
 // Close cleans up resources
 func (s *ATProtoService) Close() error {
 	s.session = nil
+	s.auth = nil
 	return nil
 }
 
@@ -589,6 +837,96 @@ func repoPutRecord(ctx context.Context, client *xrpc.Client, repo, collection, r
 	return &out, nil
 }
 
+// repoMoveRecord creates record in collection to and deletes rkey from collection from in a single commit.
+//
+// An empty newRKey lets the server assign one.
+func repoMoveRecord(ctx context.Context, client *xrpc.Client, repo, from, to, rkey, newRKey string, record map[string]any) (*MutateRecordOutput, error) {
+	create := map[string]any{
+		"$type":      "com.atproto.repo.applyWrites#create",
+		"collection": to,
+		"value":      record,
+	}
+	if newRKey != "" {
+		create["rkey"] = newRKey
+	}
+	body := map[string]any{
+		"repo": repo,
+		"writes": []map[string]any{
+			create,
+			{"$type": "com.atproto.repo.applyWrites#delete", "collection": from, "rkey": rkey},
+		},
+	}
+
+	var out struct {
+		Results []MutateRecordOutput `json:"results"`
+	}
+	if err := client.LexDo(
+		ctx,
+		lexutil.Procedure,
+		"application/json",
+		"com.atproto.repo.applyWrites",
+		nil,
+		body,
+		&out,
+	); err != nil {
+		return nil, fmt.Errorf("repoMoveRecord failed: %w", err)
+	}
+	if len(out.Results) == 0 || out.Results[0].Uri == "" {
+		return nil, fmt.Errorf("repoMoveRecord failed: no create result returned")
+	}
+	return &out.Results[0], nil
+}
+
+func repoGetRecord(ctx context.Context, client *xrpc.Client, repo, collection, rkey string) (map[string]any, error) {
+	params := map[string]any{
+		"repo":       repo,
+		"collection": collection,
+		"rkey":       rkey,
+	}
+
+	var out struct {
+		Value map[string]any `json:"value"`
+	}
+	if err := client.LexDo(
+		ctx,
+		lexutil.Query,
+		"",
+		"com.atproto.repo.getRecord",
+		params,
+		nil,
+		&out,
+	); err != nil {
+		return nil, fmt.Errorf("repoGetRecord failed: %w", err)
+	}
+	if out.Value == nil {
+		return nil, fmt.Errorf("repoGetRecord failed: record %s/%s has no value", collection, rkey)
+	}
+	return out.Value, nil
+}
+
+// publicationRecord converts a publication into a generic record shaped by the publication lexicon
+func publicationRecord(pub public.Publication) (map[string]any, error) {
+	pub.Type = public.TypePublication
+	m, err := public.LexiconRecord(public.TypePublication, pub)
+	if err != nil {
+		return nil, fmt.Errorf("publication record: %w", err)
+	}
+	return m, nil
+}
+
+// documentRecord converts a document into a generic record shaped by the document lexicon, with
+// its $type set from doc.Type since drafts share the document lexicon
+func documentRecord(doc public.Document) (map[string]any, error) {
+	collection := doc.Type
+	doc.Type = public.TypeDocument
+	m, err := public.LexiconRecord(public.TypeDocument, doc)
+	if err != nil {
+		return nil, fmt.Errorf("document record: %w", err)
+	}
+	m["$type"] = collection
+	return m, nil
+}
+
 // convertCBORToJSONCompatible recursively converts CBOR data structures to JSON-compatible types
 //
 // This converts map[any]any to map[string]any to allow usage of [json.Marshal]
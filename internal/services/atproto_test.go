@@ -3,10 +3,14 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/bluesky-social/indigo/xrpc"
 	"github.com/fxamacker/cbor/v2"
 	"github.com/stormlightlabs/noteleaf/internal/public"
 )
@@ -1826,3 +1830,384 @@ func TestATProtoService(t *testing.T) {
 		})
 	})
 }
+
+func TestMoveDocument(t *testing.T) {
+	type write struct {
+		Type       string         `json:"$type"`
+		Collection string         `json:"collection"`
+		RKey       string         `json:"rkey"`
+		Value      map[string]any `json:"value"`
+	}
+
+	newService := func(t *testing.T, handler func(w http.ResponseWriter, writes []write)) *ATProtoService {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/xrpc/com.atproto.repo.applyWrites" {
+				http.NotFound(w, r)
+				return
+			}
+			var body struct {
+				Writes []write `json:"writes"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode applyWrites body: %v", err)
+			}
+			handler(w, body.Writes)
+		}))
+		t.Cleanup(server.Close)
+
+		svc := NewATProtoService()
+		err := svc.RestoreSession(&Session{
+			DID:           "did:plc:test123",
+			Handle:        "test.bsky.social",
+			AccessJWT:     "access",
+			RefreshJWT:    "refresh",
+			PDSURL:        server.URL,
+			ExpiresAt:     time.Now().Add(time.Hour),
+			Authenticated: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to restore session: %v", err)
+		}
+		return svc
+	}
+
+	createResult := func(w http.ResponseWriter, collection, rkey string) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]any{
+				{"uri": "at://did:plc:test123/" + collection + "/" + rkey, "cid": "bafy-moved"},
+				{},
+			},
+		})
+	}
+
+	t.Run("publishes a draft under the same rkey", func(t *testing.T) {
+		var got []write
+		svc := newService(t, func(w http.ResponseWriter, writes []write) {
+			got = writes
+			createResult(w, writes[0].Collection, writes[0].RKey)
+		})
+
+		result, err := svc.MoveDocument(context.Background(), "rk1", public.Document{Title: "Draft", Pages: []public.LinearDocument{}}, false)
+		if err != nil {
+			t.Fatalf("MoveDocument failed: %v", err)
+		}
+
+		if len(got) != 2 {
+			t.Fatalf("expected a create and a delete, got %d writes", len(got))
+		}
+		if got[0].Collection != public.TypeDocument || got[0].RKey != "rk1" {
+			t.Errorf("unexpected create: %+v", got[0])
+		}
+		if got[0].Value["$type"] != public.TypeDocument {
+			t.Errorf("expected record type %s, got %v", public.TypeDocument, got[0].Value["$type"])
+		}
+		if !strings.HasSuffix(got[1].Type, "#delete") || got[1].Collection != public.TypeDocumentDraft || got[1].RKey != "rk1" {
+			t.Errorf("unexpected delete: %+v", got[1])
+		}
+		if result.Meta.RKey != "rk1" || result.Meta.CID != "bafy-moved" || result.Meta.IsDraft {
+			t.Errorf("unexpected result meta: %+v", result.Meta)
+		}
+	})
+
+	t.Run("falls back to a new rkey when the original is taken", func(t *testing.T) {
+		var attempts int
+		svc := newService(t, func(w http.ResponseWriter, writes []write) {
+			attempts++
+			if writes[0].RKey != "" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "record already exists"})
+				return
+			}
+			createResult(w, writes[0].Collection, "rk-new")
+		})
+
+		result, err := svc.MoveDocument(context.Background(), "rk1", public.Document{Title: "Post", Pages: []public.LinearDocument{}}, true)
+		if err != nil {
+			t.Fatalf("MoveDocument failed: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+		if result.Meta.RKey != "rk-new" || !result.Meta.IsDraft {
+			t.Errorf("unexpected result meta: %+v", result.Meta)
+		}
+	})
+
+	t.Run("does not retry other failures", func(t *testing.T) {
+		for _, tc := range []struct {
+			status int
+			body   map[string]string
+		}{
+			{http.StatusForbidden, nil},
+			{http.StatusBadRequest, map[string]string{"error": "InvalidRequest", "message": "Invalid record: title must not be longer than 128 graphemes"}},
+			{http.StatusBadRequest, map[string]string{"error": "InvalidSwap", "message": "Record was at bafyold"}},
+		} {
+			var attempts int
+			svc := newService(t, func(w http.ResponseWriter, writes []write) {
+				attempts++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				json.NewEncoder(w).Encode(tc.body)
+			})
+
+			_, err := svc.MoveDocument(context.Background(), "rk1", public.Document{Title: "Post", Pages: []public.LinearDocument{}}, true)
+			if err == nil {
+				t.Errorf("%d %v: expected an error", tc.status, tc.body)
+			} else if tc.body != nil && !strings.Contains(err.Error(), tc.body["message"]) {
+				t.Errorf("%d %v: expected the PDS error to be returned, got %v", tc.status, tc.body, err)
+			}
+			if attempts != 1 {
+				t.Errorf("%d %v: expected a single attempt, got %d", tc.status, tc.body, attempts)
+			}
+		}
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		if _, err := NewATProtoService().MoveDocument(context.Background(), "rk1", public.Document{Title: "T"}, false); err == nil || err.Error() != "not authenticated" {
+			t.Errorf("expected not authenticated error, got %v", err)
+		}
+
+		svc := newService(t, func(w http.ResponseWriter, writes []write) {})
+		if _, err := svc.MoveDocument(context.Background(), "", public.Document{Title: "T"}, false); err == nil {
+			t.Error("expected error for empty rkey")
+		}
+		if _, err := svc.MoveDocument(context.Background(), "rk1", public.Document{}, false); err == nil {
+			t.Error("expected error for missing title")
+		}
+	})
+}
+
+func TestDecodeDocumentRecord(t *testing.T) {
+	record := func(t *testing.T, recordType string) []byte {
+		t.Helper()
+		data, err := cbor.Marshal(map[string]any{"$type": recordType, "title": "Hello", "author": "did:plc:test123"})
+		if err != nil {
+			t.Fatalf("failed to encode record: %v", err)
+		}
+		return data
+	}
+
+	t.Run("decodes published documents", func(t *testing.T) {
+		doc, err := decodeDocumentRecord("did:plc:test123", public.TypeDocument+"/rk1", "cid1", record(t, public.TypeDocument))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if doc == nil || doc.Document.Title != "Hello" || doc.Meta.IsDraft {
+			t.Fatalf("unexpected document: %+v", doc)
+		}
+		if doc.Meta.RKey != "rk1" || doc.Meta.URI != "at://did:plc:test123/"+public.TypeDocument+"/rk1" {
+			t.Errorf("unexpected meta: %+v", doc.Meta)
+		}
+	})
+
+	t.Run("decodes drafts", func(t *testing.T) {
+		doc, err := decodeDocumentRecord("did:plc:test123", public.TypeDocumentDraft+"/rk2", "cid2", record(t, public.TypeDocumentDraft))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if doc == nil || !doc.Meta.IsDraft || doc.Meta.RKey != "rk2" {
+			t.Fatalf("expected a draft, got %+v", doc)
+		}
+	})
+
+	t.Run("skips other records", func(t *testing.T) {
+		tests := []struct{ key, recordType string }{
+			{public.TypePublication + "/rk3", public.TypePublication},
+			{public.TypeDocument + "/rk4", public.TypeDocumentDraft},
+		}
+		for _, tt := range tests {
+			doc, err := decodeDocumentRecord("did:plc:test123", tt.key, "cid", record(t, tt.recordType))
+			if err != nil || doc != nil {
+				t.Errorf("expected %s to be skipped, got %+v (%v)", tt.key, doc, err)
+			}
+		}
+	})
+
+	t.Run("rejects invalid CBOR", func(t *testing.T) {
+		if _, err := decodeDocumentRecord("did:plc:test123", public.TypeDocument+"/rk5", "cid", []byte{0xff}); err == nil {
+			t.Error("expected error for invalid CBOR")
+		}
+	})
+}
+
+func TestPublicationRecords(t *testing.T) {
+	newService := func(t *testing.T, mux *http.ServeMux) *ATProtoService {
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+
+		svc := NewATProtoService()
+		err := svc.RestoreSession(&Session{
+			DID:           "did:plc:test123",
+			Handle:        "test.bsky.social",
+			AccessJWT:     "access",
+			RefreshJWT:    "refresh",
+			PDSURL:        server.URL,
+			ExpiresAt:     time.Now().Add(time.Hour),
+			Authenticated: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to restore session: %v", err)
+		}
+		return svc
+	}
+
+	t.Run("CreatePublication", func(t *testing.T) {
+		var record map[string]any
+		mux := http.NewServeMux()
+		mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Collection string         `json:"collection"`
+				Record     map[string]any `json:"record"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Collection != public.TypePublication {
+				t.Errorf("unexpected collection %s", body.Collection)
+			}
+			record = body.Record
+			json.NewEncoder(w).Encode(map[string]string{"uri": "at://did:plc:test123/pub.leaflet.publication/pub1", "cid": "cid1"})
+		})
+		svc := newService(t, mux)
+
+		result, err := svc.CreatePublication(context.Background(), public.Publication{Name: "Game Dev", BasePath: "gamedev.leaflet.pub"})
+		if err != nil {
+			t.Fatalf("CreatePublication failed: %v", err)
+		}
+		if result.RKey != "pub1" || result.URI != "at://did:plc:test123/pub.leaflet.publication/pub1" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if record["$type"] != public.TypePublication || record["name"] != "Game Dev" || record["base_path"] != "gamedev.leaflet.pub" {
+			t.Errorf("unexpected record: %v", record)
+		}
+		if _, ok := record["description"]; ok {
+			t.Error("expected empty description to be omitted")
+		}
+
+		if _, err := svc.CreatePublication(context.Background(), public.Publication{}); err == nil {
+			t.Error("expected error for missing name")
+		}
+	})
+
+	t.Run("UpdatePublication keeps unmanaged fields", func(t *testing.T) {
+		var record map[string]any
+		mux := http.NewServeMux()
+		mux.HandleFunc("/xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("rkey") != "pub1" {
+				t.Errorf("unexpected rkey %s", r.URL.Query().Get("rkey"))
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"uri": "at://did:plc:test123/pub.leaflet.publication/pub1",
+				"value": map[string]any{
+					"$type":       public.TypePublication,
+					"name":        "Old",
+					"description": "Kept",
+					"theme":       map[string]any{"accent": "#ff0000"},
+				},
+			})
+		})
+		mux.HandleFunc("/xrpc/com.atproto.repo.putRecord", func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Record map[string]any `json:"record"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			record = body.Record
+			json.NewEncoder(w).Encode(map[string]string{"uri": "at://did:plc:test123/pub.leaflet.publication/pub1", "cid": "cid2"})
+		})
+		svc := newService(t, mux)
+
+		result, err := svc.UpdatePublication(context.Background(), "pub1", public.Publication{Name: "New"})
+		if err != nil {
+			t.Fatalf("UpdatePublication failed: %v", err)
+		}
+		if record["name"] != "New" || record["description"] != "Kept" || record["theme"] == nil {
+			t.Errorf("unexpected record: %v", record)
+		}
+		if result.Publication.Name != "New" || result.CID != "cid2" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("require authentication", func(t *testing.T) {
+		svc := NewATProtoService()
+		if _, err := svc.CreatePublication(context.Background(), public.Publication{Name: "X"}); err == nil {
+			t.Error("expected CreatePublication to fail")
+		}
+		if _, err := svc.UpdatePublication(context.Background(), "pub1", public.Publication{Name: "X"}); err == nil {
+			t.Error("expected UpdatePublication to fail")
+		}
+	})
+}
+
+func TestIsTransient(t *testing.T) {
+	patchAgainst := func(t *testing.T, status int, body string) error {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		svc := NewATProtoService()
+		if err := svc.RestoreSession(&Session{
+			DID:           "did:plc:test123",
+			AccessJWT:     "access",
+			RefreshJWT:    "refresh",
+			PDSURL:        server.URL,
+			ExpiresAt:     time.Now().Add(time.Hour),
+			Authenticated: true,
+		}); err != nil {
+			t.Fatalf("failed to restore session: %v", err)
+		}
+		_, err := svc.PatchDocument(context.Background(), "rk1", public.Document{Title: "T", Pages: []public.LinearDocument{}}, false)
+		return err
+	}
+
+	t.Run("expired token", func(t *testing.T) {
+		err := patchAgainst(t, http.StatusBadRequest, `{"error":"ExpiredToken","message":"Token has expired"}`)
+		if !IsTransient(err) {
+			t.Errorf("expected expired token to be transient: %v", err)
+		}
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		err := patchAgainst(t, http.StatusBadRequest, `{"error":"InvalidRequest","message":"bad record"}`)
+		if err == nil || IsTransient(err) {
+			t.Errorf("expected invalid record to be permanent: %v", err)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		err := patchAgainst(t, http.StatusForbidden, `{"error":"Forbidden","message":"no"}`)
+		if err == nil || IsTransient(err) {
+			t.Errorf("expected forbidden to be permanent: %v", err)
+		}
+	})
+
+	t.Run("unreachable PDS", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		svc := NewATProtoService()
+		svc.RestoreSession(&Session{
+			DID: "did:plc:test123", AccessJWT: "a", RefreshJWT: "r", PDSURL: url,
+			ExpiresAt: time.Now().Add(time.Hour), Authenticated: true,
+		})
+		err := svc.DeleteDocument(context.Background(), "rk1", false)
+		if !IsTransient(err) {
+			t.Errorf("expected connection failure to be transient: %v", err)
+		}
+	})
+
+	t.Run("server errors", func(t *testing.T) {
+		for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusUnauthorized} {
+			err := fmt.Errorf("failed to update record: %w", &xrpc.Error{StatusCode: status})
+			if !IsTransient(err) {
+				t.Errorf("expected status %d to be transient", status)
+			}
+		}
+		if IsTransient(nil) || IsTransient(fmt.Errorf("document title is required")) {
+			t.Error("expected plain errors to be permanent")
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dpopKey signs DPoP proofs (RFC 9449) with an ES256 key bound to the session's tokens
+type dpopKey struct {
+	key *ecdsa.PrivateKey
+	jwk map[string]string
+}
+
+func newDPoPKey() (*dpopKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate DPoP key: %w", err)
+	}
+	return dpopKeyFrom(key), nil
+}
+
+// parseDPoPKey restores a key saved with [dpopKey.encode]
+func parseDPoPKey(encoded string) (*dpopKey, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DPoP key encoding: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DPoP key: %w", err)
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("DPoP key must use P-256")
+	}
+	return dpopKeyFrom(key), nil
+}
+
+func dpopKeyFrom(key *ecdsa.PrivateKey) *dpopKey {
+	var x, y [32]byte
+	key.PublicKey.X.FillBytes(x[:])
+	key.PublicKey.Y.FillBytes(y[:])
+	return &dpopKey{
+		key: key,
+		jwk: map[string]string{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   b64url(x[:]),
+			"y":   b64url(y[:]),
+		},
+	}
+}
+
+// encode returns the private key as base64 SEC 1 DER for storage
+func (k *dpopKey) encode() (string, error) {
+	der, err := x509.MarshalECPrivateKey(k.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode DPoP key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
+
+// proof builds a DPoP proof JWT for one request. accessToken is empty for
+// requests to the authorization server and set for resource requests, where
+// the proof carries its hash in the ath claim.
+func (k *dpopKey) proof(method, target, nonce, accessToken string) (string, error) {
+	htu, err := dpopTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("failed to generate DPoP jti: %w", err)
+	}
+
+	header := map[string]any{"typ": "dpop+jwt", "alg": "ES256", "jwk": k.jwk}
+	claims := map[string]any{
+		"jti": b64url(jti),
+		"htm": method,
+		"htu": htu,
+		"iat": time.Now().Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = b64url(sum[:])
+	}
+	return signES256(k.key, header, claims)
+}
+
+// dpopTarget strips the query and fragment from a request URL as required for the htu claim
+func dpopTarget(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid DPoP target %q: %w", target, err)
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String(), nil
+}
+
+func signES256(key *ecdsa.PrivateKey, header, claims map[string]any) (string, error) {
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := b64url(h) + "." + b64url(c)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign DPoP proof: %w", err)
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + b64url(sig), nil
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// dpopNonces remembers the latest DPoP-Nonce handed out by each server.
+//
+// Servers rotate nonces every few minutes, so the authorization server and the
+// PDS are tracked separately by origin and every response may replace them.
+type dpopNonces struct {
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+func (n *dpopNonces) get(target string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.nonces[originOf(target)]
+}
+
+// update records the nonce from resp and reports whether it changed
+func (n *dpopNonces) update(target string, resp *http.Response) bool {
+	nonce := resp.Header.Get("DPoP-Nonce")
+	if nonce == "" {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.nonces == nil {
+		n.nonces = make(map[string]string)
+	}
+	origin := originOf(target)
+	if n.nonces[origin] == nonce {
+		return false
+	}
+	n.nonces[origin] = nonce
+	return true
+}
+
+func originOf(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// dpopTransport authenticates PDS requests with a DPoP bound access token.
+//
+// A request is retried once when the server asks for a fresh nonce, and once
+// after refreshing the access token when the server rejects it as expired.
+type dpopTransport struct {
+	base http.RoundTripper
+	auth *oauthAuth
+}
+
+func (t *dpopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.auth.validToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.send(req, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var retriedNonce, refreshed bool
+	for resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		switch {
+		case strings.Contains(challenge, "use_dpop_nonce") && !retriedNonce:
+			retriedNonce = true
+		case strings.Contains(challenge, "invalid_token") && !refreshed:
+			refreshed = true
+			if token, err = t.auth.refresh(req.Context(), token); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+		default:
+			return resp, nil
+		}
+
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if resp, err = t.send(req, token); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (t *dpopTransport) send(req *http.Request, token string) (*http.Response, error) {
+	target := req.URL.String()
+	proof, err := t.auth.key.proof(req.Method, target, t.auth.nonces.get(target), token)
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if r.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	r.Header.Set("Authorization", "DPoP "+token)
+	r.Header.Set("DPoP", proof)
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	t.auth.nonces.update(target, resp)
+	return resp, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Identity is a verified account: the handle and DID point at each other
+type Identity struct {
+	Handle string
+	DID    string
+	PDSURL string
+}
+
+// IdentityResolver resolves handles to DIDs and DIDs to their PDS
+type IdentityResolver struct {
+	HTTPClient   *http.Client
+	PLCDirectory string
+
+	// overridden in tests to avoid DNS and TLS
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+	handleURL func(handle string) string
+	didWebURL func(host string) string
+}
+
+// NewIdentityResolver creates a resolver using DNS, HTTPS and the public PLC directory
+func NewIdentityResolver() *IdentityResolver {
+	return &IdentityResolver{
+		HTTPClient:   &http.Client{Timeout: 15 * time.Second},
+		PLCDirectory: "https://plc.directory",
+	}
+}
+
+type didDocument struct {
+	ID          string   `json:"id"`
+	AlsoKnownAs []string `json:"alsoKnownAs"`
+	Service     []struct {
+		ID              string `json:"id"`
+		Type            string `json:"type"`
+		ServiceEndpoint string `json:"serviceEndpoint"`
+	} `json:"service"`
+}
+
+// Resolve resolves a handle to its DID and PDS, and checks that the DID
+// document claims the handle back.
+func (r *IdentityResolver) Resolve(ctx context.Context, handle string) (*Identity, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if handle == "" {
+		return nil, fmt.Errorf("handle is required")
+	}
+
+	did, err := r.ResolveHandle(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := r.resolveDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	if !doc.claimsHandle(handle) {
+		return nil, fmt.Errorf("handle %s is not claimed by %s", handle, did)
+	}
+
+	pds := doc.pdsEndpoint()
+	if pds == "" {
+		return nil, fmt.Errorf("%s has no PDS endpoint", did)
+	}
+	if err := requireSecureURL(pds); err != nil {
+		return nil, err
+	}
+	return &Identity{Handle: handle, DID: did, PDSURL: strings.TrimSuffix(pds, "/")}, nil
+}
+
+// ResolveHandle finds the DID for a handle from the _atproto DNS TXT record,
+// falling back to https://<handle>/.well-known/atproto-did
+func (r *IdentityResolver) ResolveHandle(ctx context.Context, handle string) (string, error) {
+	lookup := r.lookupTXT
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupTXT
+	}
+	if records, err := lookup(ctx, "_atproto."+handle); err == nil {
+		for _, record := range records {
+			if did, ok := strings.CutPrefix(record, "did="); ok && isDID(did) {
+				return did, nil
+			}
+		}
+	}
+
+	wellKnown := "https://" + handle + "/.well-known/atproto-did"
+	if r.handleURL != nil {
+		wellKnown = r.handleURL(handle)
+	}
+	body, err := r.get(ctx, wellKnown, 256)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve handle %s: %w", handle, err)
+	}
+	did := strings.TrimSpace(string(body))
+	if !isDID(did) {
+		return "", fmt.Errorf("failed to resolve handle %s: invalid DID %q", handle, did)
+	}
+	return did, nil
+}
+
+func (r *IdentityResolver) resolveDID(ctx context.Context, did string) (*didDocument, error) {
+	var docURL string
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		docURL = strings.TrimSuffix(r.PLCDirectory, "/") + "/" + did
+	case strings.HasPrefix(did, "did:web:"):
+		host := strings.TrimPrefix(did, "did:web:")
+		if strings.Contains(host, ":") {
+			return nil, fmt.Errorf("did:web with a path or port is not supported: %s", did)
+		}
+		docURL = "https://" + host + "/.well-known/did.json"
+		if r.didWebURL != nil {
+			docURL = r.didWebURL(host)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DID method: %s", did)
+	}
+
+	body, err := r.get(ctx, docURL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", did, err)
+	}
+
+	var doc didDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid DID document for %s: %w", did, err)
+	}
+	if doc.ID != did {
+		return nil, fmt.Errorf("DID document for %s has id %s", did, doc.ID)
+	}
+	return &doc, nil
+}
+
+func (r *IdentityResolver) get(ctx context.Context, target string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+func (d *didDocument) claimsHandle(handle string) bool {
+	for _, aka := range d.AlsoKnownAs {
+		if strings.EqualFold(strings.TrimPrefix(aka, "at://"), handle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *didDocument) pdsEndpoint() string {
+	for _, svc := range d.Service {
+		if (svc.ID == "#atproto_pds" || svc.ID == d.ID+"#atproto_pds") && svc.Type == "AtprotoPersonalDataServer" {
+			return svc.ServiceEndpoint
+		}
+	}
+	return ""
+}
+
+func isDID(s string) bool {
+	return strings.HasPrefix(s, "did:plc:") || strings.HasPrefix(s, "did:web:")
+}
+
+// requireSecureURL rejects plain HTTP except for loopback addresses
+func requireSecureURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing insecure URL %s", raw)
+}
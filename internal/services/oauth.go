@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuthScope is requested for sessions that read and write leaflet records
+const OAuthScope = "atproto transition:generic"
+
+// OAuthSession holds what is needed to use and refresh DPoP bound OAuth tokens
+type OAuthSession struct {
+	Issuer        string // authorization server that issued the tokens
+	TokenEndpoint string
+	ClientID      string
+	DPoPKey       string // base64 SEC 1 DER encoded ES256 private key
+}
+
+// OAuthClient runs the AT Protocol OAuth flow for a command line client:
+// identity resolution and authorization server discovery, a pushed
+// authorization request with PKCE, a loopback redirect, and DPoP bound tokens.
+type OAuthClient struct {
+	HTTPClient   *http.Client
+	Resolver     *IdentityResolver
+	Scope        string
+	CallbackAddr string        // address the loopback redirect server listens on
+	Timeout      time.Duration // how long to wait for the user to finish in the browser
+}
+
+// NewOAuthClient creates an OAuth client with production defaults
+func NewOAuthClient() *OAuthClient {
+	return &OAuthClient{
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		Resolver:     NewIdentityResolver(),
+		Scope:        OAuthScope,
+		CallbackAddr: "127.0.0.1:0",
+		Timeout:      5 * time.Minute,
+	}
+}
+
+type authServerMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	PAREndpoint           string   `json:"pushed_authorization_request_endpoint"`
+	DPoPSigningAlgs       []string `json:"dpop_signing_alg_values_supported"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	Sub          string `json:"sub"`
+}
+
+// OAuthError is an error response from the authorization server
+type OAuthError struct {
+	Status      int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	if e.Code != "" {
+		return e.Code
+	}
+	return fmt.Sprintf("authorization server returned status %d", e.Status)
+}
+
+type oauthCallback struct {
+	code, state, issuer, err string
+}
+
+// Authorize signs handle in through the browser. openURL is given the
+// authorization page to show the user and the returned session is ready for
+// [ATProtoService.RestoreSession].
+func (c *OAuthClient) Authorize(ctx context.Context, handle string, openURL func(authURL string) error) (*Session, error) {
+	ident, err := c.Resolver.Resolve(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := c.discoverIssuer(ctx, ident.PDSURL)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := c.fetchMetadata(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", c.CallbackAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/callback", listener.Addr().(*net.TCPAddr).Port)
+	clientID := loopbackClientID(redirectURI, c.Scope)
+
+	key, err := newDPoPKey()
+	if err != nil {
+		return nil, err
+	}
+	nonces := &dpopNonces{}
+	verifier, challenge, err := newPKCE()
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var par struct {
+		RequestURI string `json:"request_uri"`
+	}
+	err = postTokenForm(ctx, c.HTTPClient, meta.PAREndpoint, key, nonces, url.Values{
+		"client_id":             {clientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {c.Scope},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+		"login_hint":            {ident.Handle},
+	}, &par)
+	if err != nil {
+		return nil, fmt.Errorf("pushed authorization request failed: %w", err)
+	}
+	if par.RequestURI == "" {
+		return nil, fmt.Errorf("pushed authorization request failed: no request_uri returned")
+	}
+
+	callbacks := make(chan oauthCallback, 1)
+	server := &http.Server{Handler: callbackHandler(callbacks), ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := meta.AuthorizationEndpoint + "?" + url.Values{
+		"client_id":   {clientID},
+		"request_uri": {par.RequestURI},
+	}.Encode()
+	if err := openURL(authURL); err != nil {
+		return nil, err
+	}
+
+	var cb oauthCallback
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	select {
+	case cb = <-callbacks:
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out after %s waiting for authorization", c.Timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if cb.err != "" {
+		return nil, fmt.Errorf("authorization denied: %s", cb.err)
+	}
+	if cb.state != state {
+		return nil, fmt.Errorf("authorization callback state does not match")
+	}
+	if cb.issuer != "" && cb.issuer != meta.Issuer {
+		return nil, fmt.Errorf("authorization callback came from unexpected issuer %s", cb.issuer)
+	}
+
+	var tok tokenResponse
+	err = postTokenForm(ctx, c.HTTPClient, meta.TokenEndpoint, key, nonces, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {cb.code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {clientID},
+		"code_verifier": {verifier},
+	}, &tok)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if err := checkTokenResponse(&tok, ident.DID); err != nil {
+		return nil, err
+	}
+
+	encodedKey, err := key.encode()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		DID:           ident.DID,
+		Handle:        ident.Handle,
+		AccessJWT:     tok.AccessToken,
+		RefreshJWT:    tok.RefreshToken,
+		PDSURL:        ident.PDSURL,
+		ExpiresAt:     tokenExpiry(tok.ExpiresIn),
+		Authenticated: true,
+		OAuth: &OAuthSession{
+			Issuer:        meta.Issuer,
+			TokenEndpoint: meta.TokenEndpoint,
+			ClientID:      clientID,
+			DPoPKey:       encodedKey,
+		},
+	}, nil
+}
+
+// discoverIssuer finds the authorization server protecting a PDS
+func (c *OAuthClient) discoverIssuer(ctx context.Context, pdsURL string) (string, error) {
+	var resource struct {
+		AuthorizationServers []string `json:"authorization_servers"`
+	}
+	if err := getJSON(ctx, c.HTTPClient, pdsURL+"/.well-known/oauth-protected-resource", &resource); err != nil {
+		return "", fmt.Errorf("failed to discover authorization server: %w", err)
+	}
+	if len(resource.AuthorizationServers) == 0 {
+		return "", fmt.Errorf("PDS %s does not list an authorization server", pdsURL)
+	}
+	issuer := strings.TrimSuffix(resource.AuthorizationServers[0], "/")
+	if err := requireSecureURL(issuer); err != nil {
+		return "", err
+	}
+	return issuer, nil
+}
+
+func (c *OAuthClient) fetchMetadata(ctx context.Context, issuer string) (*authServerMetadata, error) {
+	var meta authServerMetadata
+	if err := getJSON(ctx, c.HTTPClient, issuer+"/.well-known/oauth-authorization-server", &meta); err != nil {
+		return nil, fmt.Errorf("failed to fetch authorization server metadata: %w", err)
+	}
+
+	if meta.Issuer != issuer {
+		return nil, fmt.Errorf("authorization server metadata issuer %q does not match %q", meta.Issuer, issuer)
+	}
+	for _, endpoint := range []string{meta.AuthorizationEndpoint, meta.TokenEndpoint, meta.PAREndpoint} {
+		if endpoint == "" {
+			return nil, fmt.Errorf("authorization server %s is missing required endpoints", issuer)
+		}
+		if err := requireSecureURL(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	if !slices.Contains(meta.DPoPSigningAlgs, "ES256") {
+		return nil, fmt.Errorf("authorization server %s does not support ES256 DPoP proofs", issuer)
+	}
+	if !slices.Contains(meta.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("authorization server %s does not support S256 PKCE", issuer)
+	}
+	return &meta, nil
+}
+
+func callbackHandler(callbacks chan<- oauthCallback) http.Handler {
+	var once sync.Once
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		cb := oauthCallback{
+			code:   q.Get("code"),
+			state:  q.Get("state"),
+			issuer: q.Get("iss"),
+			err:    q.Get("error"),
+		}
+		if cb.err != "" && q.Get("error_description") != "" {
+			cb.err += ": " + q.Get("error_description")
+		}
+		if cb.code == "" && cb.err == "" {
+			http.Error(w, "missing authorization code", http.StatusBadRequest)
+			return
+		}
+
+		once.Do(func() { callbacks <- cb })
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if cb.err != "" {
+			fmt.Fprintln(w, "Authorization failed. You can close this window and return to noteleaf.")
+			return
+		}
+		fmt.Fprintln(w, "Authorization complete. You can close this window and return to noteleaf.")
+	})
+	return mux
+}
+
+// loopbackClientID builds the client_id for a native client without hosted
+// metadata, as allowed by the AT Protocol OAuth profile for localhost clients
+func loopbackClientID(redirectURI, scope string) string {
+	return "http://localhost?" + url.Values{
+		"redirect_uri": {redirectURI},
+		"scope":        {scope},
+	}.Encode()
+}
+
+func newPKCE() (verifier, challenge string, err error) {
+	if verifier, err = randomToken(); err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, b64url(sum[:]), nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return b64url(b), nil
+}
+
+func checkTokenResponse(tok *tokenResponse, did string) error {
+	if tok.AccessToken == "" || tok.RefreshToken == "" {
+		return fmt.Errorf("token response is missing tokens")
+	}
+	if !strings.EqualFold(tok.TokenType, "DPoP") {
+		return fmt.Errorf("unexpected token type %q", tok.TokenType)
+	}
+	if tok.Sub != did {
+		return fmt.Errorf("token was issued for %q, expected %q", tok.Sub, did)
+	}
+	if !slices.Contains(strings.Fields(tok.Scope), "atproto") {
+		return fmt.Errorf("token scope %q does not include atproto", tok.Scope)
+	}
+	return nil
+}
+
+func tokenExpiry(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		expiresIn = 300
+	}
+	return time.Now().Add(time.Duration(expiresIn) * time.Second)
+}
+
+// postTokenForm sends a DPoP signed form to the authorization server, retrying
+// once when the server asks for a new nonce
+func postTokenForm(ctx context.Context, client *http.Client, endpoint string, key *dpopKey, nonces *dpopNonces, form url.Values, out any) error {
+	for attempt := 0; ; attempt++ {
+		proof, err := key.proof(http.MethodPost, endpoint, nonces.get(endpoint), "")
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("DPoP", proof)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		changed := nonces.update(endpoint, resp)
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("invalid response from %s: %w", endpoint, err)
+			}
+			return nil
+		}
+
+		oauthErr := &OAuthError{Status: resp.StatusCode}
+		_ = json.Unmarshal(body, oauthErr)
+		if oauthErr.Code == "use_dpop_nonce" && changed && attempt == 0 {
+			continue
+		}
+		return oauthErr
+	}
+}
+
+func getJSON(ctx context.Context, client *http.Client, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// oauthAuth owns the tokens of an OAuth session and refreshes them.
+//
+// Refresh tokens are single use, so concurrent requests that find the access
+// token expired must not each refresh it: refresh is serialised and a caller
+// whose stale token was already replaced just picks up the new one.
+type oauthAuth struct {
+	mu         sync.Mutex
+	session    *Session
+	key        *dpopKey
+	nonces     *dpopNonces
+	httpClient *http.Client
+	onRefresh  func(*Session)
+}
+
+func newOAuthAuth(session *Session, httpClient *http.Client) (*oauthAuth, error) {
+	o := session.OAuth
+	if o.TokenEndpoint == "" || o.ClientID == "" || o.DPoPKey == "" {
+		return nil, fmt.Errorf("OAuth session missing required fields (token endpoint, client ID, DPoP key)")
+	}
+	if err := requireSecureURL(o.TokenEndpoint); err != nil {
+		return nil, err
+	}
+	key, err := parseDPoPKey(o.DPoPKey)
+	if err != nil {
+		return nil, err
+	}
+	return &oauthAuth{session: session, key: key, nonces: &dpopNonces{}, httpClient: httpClient}, nil
+}
+
+// validToken returns the access token, refreshing it first when it is about to expire
+func (a *oauthAuth) validToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	token, expires := a.session.AccessJWT, a.session.ExpiresAt
+	a.mu.Unlock()
+
+	if time.Until(expires) > 30*time.Second {
+		return token, nil
+	}
+	return a.refresh(ctx, token)
+}
+
+// refresh exchanges the refresh token for new tokens unless stale has already been replaced
+func (a *oauthAuth) refresh(ctx context.Context, stale string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session.AccessJWT != stale {
+		return a.session.AccessJWT, nil
+	}
+
+	var tok tokenResponse
+	err := postTokenForm(ctx, a.httpClient, a.session.OAuth.TokenEndpoint, a.key, a.nonces, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {a.session.RefreshJWT},
+		"client_id":     {a.session.OAuth.ClientID},
+	}, &tok)
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_grant" {
+			a.session.Authenticated = false
+		}
+		return "", fmt.Errorf("failed to refresh session: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = a.session.RefreshJWT
+	}
+	if err := checkTokenResponse(&tok, a.session.DID); err != nil {
+		return "", fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	a.session.AccessJWT = tok.AccessToken
+	a.session.RefreshJWT = tok.RefreshToken
+	a.session.ExpiresAt = tokenExpiry(tok.ExpiresIn)
+	a.session.Authenticated = true
+
+	if a.onRefresh != nil {
+		a.onRefresh(a.session)
+	}
+	return tok.AccessToken, nil
+}
+
+// OpenBrowser opens target in the user's default browser
+func OpenBrowser(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
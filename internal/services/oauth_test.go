@@ -0,0 +1,592 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/public"
+)
+
+const (
+	fakeHandle = "alice.test"
+	fakeDID    = "did:plc:alice"
+)
+
+// fakeOAuthServer is an in-process PDS with its own authorization server.
+// It enforces PAR, PKCE, DPoP proofs with server nonces, and single use refresh tokens.
+type fakeOAuthServer struct {
+	t    *testing.T
+	pds  *httptest.Server
+	auth *httptest.Server
+
+	mu            sync.Mutex
+	authNonce     string
+	pdsNonce      string
+	seenJTI       map[string]bool
+	requests      map[string]url.Values // request_uri -> PAR form
+	codes         map[string]url.Values // code -> PAR form
+	access        map[string]string     // access token -> DPoP key thumbprint
+	refresh       map[string]string     // refresh token -> DPoP key thumbprint
+	expired       map[string]bool
+	issued        int
+	refreshes     int
+	records       int
+	claimedHandle string
+	tamperState   bool
+}
+
+func newFakeOAuthServer(t *testing.T) *fakeOAuthServer {
+	f := &fakeOAuthServer{
+		t:             t,
+		authNonce:     "auth-nonce-1",
+		pdsNonce:      "pds-nonce-1",
+		seenJTI:       map[string]bool{},
+		requests:      map[string]url.Values{},
+		codes:         map[string]url.Values{},
+		access:        map[string]string{},
+		refresh:       map[string]string{},
+		expired:       map[string]bool{},
+		claimedHandle: fakeHandle,
+	}
+
+	authMux := http.NewServeMux()
+	authMux.HandleFunc("/.well-known/oauth-authorization-server", f.handleMetadata)
+	authMux.HandleFunc("/oauth/par", f.handlePAR)
+	authMux.HandleFunc("/oauth/authorize", f.handleAuthorize)
+	authMux.HandleFunc("/oauth/token", f.handleToken)
+	f.auth = httptest.NewServer(authMux)
+
+	pdsMux := http.NewServeMux()
+	pdsMux.HandleFunc("/plc/", f.handlePLC)
+	pdsMux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"authorization_servers": []string{f.auth.URL}})
+	})
+	pdsMux.HandleFunc("/xrpc/com.atproto.repo.createRecord", f.handleCreateRecord)
+	f.pds = httptest.NewServer(pdsMux)
+
+	t.Cleanup(func() {
+		f.pds.Close()
+		f.auth.Close()
+	})
+	return f
+}
+
+// client returns an OAuth client that resolves identities against the fake servers
+func (f *fakeOAuthServer) client() *OAuthClient {
+	c := NewOAuthClient()
+	c.Timeout = 5 * time.Second
+	c.Resolver.PLCDirectory = f.pds.URL + "/plc"
+	c.Resolver.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		if name == "_atproto."+fakeHandle {
+			return []string{"did=" + fakeDID}, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+	return c
+}
+
+func (f *fakeOAuthServer) service() *ATProtoService {
+	svc := NewATProtoService()
+	svc.oauth = f.client()
+	return svc
+}
+
+// approve plays the user: it follows the authorization page's redirect to the callback
+func approve(authURL string) error {
+	go func() {
+		resp, err := http.Get(authURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+func (f *fakeOAuthServer) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                f.auth.URL,
+		"authorization_endpoint":                f.auth.URL + "/oauth/authorize",
+		"token_endpoint":                        f.auth.URL + "/oauth/token",
+		"pushed_authorization_request_endpoint": f.auth.URL + "/oauth/par",
+		"dpop_signing_alg_values_supported":     []string{"ES256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (f *fakeOAuthServer) handlePLC(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimPrefix(r.URL.Path, "/plc/") != fakeDID {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":          fakeDID,
+		"alsoKnownAs": []string{"at://" + f.claimedHandle},
+		"service": []map[string]string{{
+			"id":              "#atproto_pds",
+			"type":            "AtprotoPersonalDataServer",
+			"serviceEndpoint": f.pds.URL,
+		}},
+	})
+}
+
+func (f *fakeOAuthServer) handlePAR(w http.ResponseWriter, r *http.Request) {
+	if _, ok := f.checkAuthProof(w, r); !ok {
+		return
+	}
+	r.ParseForm()
+	if r.Form.Get("code_challenge_method") != "S256" || r.Form.Get("code_challenge") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if !strings.HasPrefix(r.Form.Get("client_id"), "http://localhost?") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	f.mu.Lock()
+	requestURI := fmt.Sprintf("urn:ietf:params:oauth:request_uri:%d", len(f.requests)+1)
+	f.requests[requestURI] = r.Form
+	f.mu.Unlock()
+	writeJSON(w, http.StatusCreated, map[string]any{"request_uri": requestURI, "expires_in": 60})
+}
+
+func (f *fakeOAuthServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	form, ok := f.requests[r.URL.Query().Get("request_uri")]
+	delete(f.requests, r.URL.Query().Get("request_uri"))
+	code := fmt.Sprintf("code-%d", len(f.codes)+1)
+	f.codes[code] = form
+	tamper := f.tamperState
+	f.mu.Unlock()
+
+	if !ok || form.Get("client_id") != r.URL.Query().Get("client_id") {
+		http.Error(w, "unknown request", http.StatusBadRequest)
+		return
+	}
+
+	state := form.Get("state")
+	if tamper {
+		state = "forged"
+	}
+	redirect := form.Get("redirect_uri") + "?" + url.Values{
+		"code":  {code},
+		"state": {state},
+		"iss":   {f.auth.URL},
+	}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (f *fakeOAuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	jkt, ok := f.checkAuthProof(w, r)
+	if !ok {
+		return
+	}
+	r.ParseForm()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		form, ok := f.codes[r.Form.Get("code")]
+		delete(f.codes, r.Form.Get("code"))
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || b64url(sum[:]) != form.Get("code_challenge") || r.Form.Get("redirect_uri") != form.Get("redirect_uri") {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	case "refresh_token":
+		bound, ok := f.refresh[r.Form.Get("refresh_token")]
+		if !ok || bound != jkt {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(f.refresh, r.Form.Get("refresh_token"))
+		f.refreshes++
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	f.issued++
+	access, refresh := fmt.Sprintf("access-%d", f.issued), fmt.Sprintf("refresh-%d", f.issued)
+	f.access[access] = jkt
+	f.refresh[refresh] = jkt
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"token_type":    "DPoP",
+		"expires_in":    900,
+		"refresh_token": refresh,
+		"scope":         OAuthScope,
+		"sub":           fakeDID,
+	})
+}
+
+func (f *fakeOAuthServer) handleCreateRecord(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "DPoP ")
+	if !ok {
+		http.Error(w, "missing DPoP authorization", http.StatusUnauthorized)
+		return
+	}
+
+	claims, jkt, err := f.verifyProof(r)
+	if err != nil {
+		f.t.Errorf("invalid resource DPoP proof: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sum := sha256.Sum256([]byte(token))
+
+	f.mu.Lock()
+	nonce := f.pdsNonce
+	bound, known := f.access[token]
+	expired := f.expired[token]
+	f.mu.Unlock()
+
+	w.Header().Set("DPoP-Nonce", nonce)
+	if claims["nonce"] != nonce {
+		w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "use_dpop_nonce"})
+		return
+	}
+	if claims["ath"] != b64url(sum[:]) {
+		f.t.Errorf("ath claim does not match access token")
+	}
+	if !known || expired || bound != jkt {
+		w.Header().Set("WWW-Authenticate", `DPoP error="invalid_token"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "InvalidToken"})
+		return
+	}
+
+	var body struct {
+		Repo       string `json:"repo"`
+		Collection string `json:"collection"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	f.records++
+	rkey := fmt.Sprintf("rkey%d", f.records)
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{
+		"uri": fmt.Sprintf("at://%s/%s/%s", body.Repo, body.Collection, rkey),
+		"cid": "bafyfake",
+	})
+}
+
+// checkAuthProof validates a DPoP proof sent to the authorization server and
+// returns the thumbprint of its key
+func (f *fakeOAuthServer) checkAuthProof(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims, jkt, err := f.verifyProof(r)
+	if err != nil {
+		f.t.Errorf("invalid DPoP proof: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_dpop_proof"})
+		return "", false
+	}
+
+	f.mu.Lock()
+	nonce := f.authNonce
+	f.mu.Unlock()
+
+	w.Header().Set("DPoP-Nonce", nonce)
+	if claims["nonce"] != nonce {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "use_dpop_nonce"})
+		return "", false
+	}
+	if _, ok := claims["ath"]; ok {
+		f.t.Errorf("authorization server proof must not carry ath")
+	}
+	return jkt, true
+}
+
+func (f *fakeOAuthServer) verifyProof(r *http.Request) (map[string]any, string, error) {
+	parts := strings.Split(r.Header.Get("DPoP"), ".")
+	if len(parts) != 3 {
+		return nil, "", fmt.Errorf("malformed proof")
+	}
+
+	var header struct {
+		Typ string            `json:"typ"`
+		Alg string            `json:"alg"`
+		JWK map[string]string `json:"jwk"`
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, "", err
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, "", err
+	}
+	if header.Typ != "dpop+jwt" || header.Alg != "ES256" {
+		return nil, "", fmt.Errorf("unexpected header %+v", header)
+	}
+
+	x, _ := base64.RawURLEncoding.DecodeString(header.JWK["x"])
+	y, _ := base64.RawURLEncoding.DecodeString(header.JWK["y"])
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return nil, "", fmt.Errorf("bad signature")
+	}
+
+	if claims["htm"] != r.Method {
+		return nil, "", fmt.Errorf("htm %v does not match %s", claims["htm"], r.Method)
+	}
+	if want := "http://" + r.Host + r.URL.Path; claims["htu"] != want {
+		return nil, "", fmt.Errorf("htu %v does not match %s", claims["htu"], want)
+	}
+
+	jti, _ := claims["jti"].(string)
+	f.mu.Lock()
+	replayed := f.seenJTI[jti]
+	f.seenJTI[jti] = true
+	f.mu.Unlock()
+	if jti == "" || replayed {
+		return nil, "", fmt.Errorf("missing or replayed jti")
+	}
+	return claims, header.JWK["x"] + header.JWK["y"], nil
+}
+
+func (f *fakeOAuthServer) rotateNonces() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authNonce += "-next"
+	f.pdsNonce += "-next"
+}
+
+func (f *fakeOAuthServer) expireTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for token := range f.access {
+		f.expired[token] = true
+	}
+}
+
+func decodeSegment(seg string, out any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestOAuth(t *testing.T) {
+	ctx := context.Background()
+	doc := public.Document{Title: "Hello", Pages: []public.LinearDocument{}}
+
+	t.Run("authenticates and makes DPoP requests", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		svc := f.service()
+
+		if err := svc.AuthenticateOAuth(ctx, fakeHandle, approve); err != nil {
+			t.Fatalf("AuthenticateOAuth failed: %v", err)
+		}
+
+		session, err := svc.GetSession()
+		if err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+		if session.DID != fakeDID || session.PDSURL != f.pds.URL {
+			t.Errorf("Unexpected session identity: %+v", session)
+		}
+		if session.OAuth == nil || session.OAuth.Issuer != f.auth.URL || session.OAuth.DPoPKey == "" {
+			t.Fatalf("Expected OAuth session details, got %+v", session.OAuth)
+		}
+		if !strings.Contains(session.OAuth.ClientID, url.QueryEscape("http://127.0.0.1:")) {
+			t.Errorf("Expected loopback client ID, got %s", session.OAuth.ClientID)
+		}
+
+		result, err := svc.PostDocument(ctx, doc, false)
+		if err != nil {
+			t.Fatalf("PostDocument failed: %v", err)
+		}
+		if result.Meta.RKey != "rkey1" {
+			t.Errorf("Expected rkey1, got %s", result.Meta.RKey)
+		}
+
+		f.rotateNonces()
+		if _, err := svc.PostDocument(ctx, doc, false); err != nil {
+			t.Fatalf("PostDocument after nonce rotation failed: %v", err)
+		}
+	})
+
+	t.Run("refreshes rejected tokens and rotates the refresh token", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		svc := f.service()
+
+		var saved []Session
+		svc.OnSessionUpdate(func(s *Session) { saved = append(saved, *s) })
+
+		if err := svc.AuthenticateOAuth(ctx, fakeHandle, approve); err != nil {
+			t.Fatalf("AuthenticateOAuth failed: %v", err)
+		}
+		first, _ := svc.GetSession()
+		oldRefresh := first.RefreshJWT
+
+		f.expireTokens()
+		if _, err := svc.PostDocument(ctx, doc, false); err != nil {
+			t.Fatalf("PostDocument failed: %v", err)
+		}
+
+		if f.refreshes != 1 {
+			t.Errorf("Expected one refresh, got %d", f.refreshes)
+		}
+		if len(saved) != 1 || saved[0].RefreshJWT == oldRefresh {
+			t.Fatalf("Expected rotated refresh token to be reported, got %+v", saved)
+		}
+	})
+
+	t.Run("concurrent refreshes only refresh once", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		svc := f.service()
+		if err := svc.AuthenticateOAuth(ctx, fakeHandle, approve); err != nil {
+			t.Fatalf("AuthenticateOAuth failed: %v", err)
+		}
+		stale := svc.session.AccessJWT
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 8)
+		errs := make([]error, 8)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tokens[i], errs[i] = svc.auth.refresh(ctx, stale)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range tokens {
+			if errs[i] != nil {
+				t.Fatalf("refresh %d failed: %v", i, errs[i])
+			}
+			if tokens[i] != tokens[0] || tokens[i] == stale {
+				t.Errorf("Expected every caller to get the same new token, got %v", tokens)
+			}
+		}
+		if f.refreshes != 1 {
+			t.Errorf("Expected one refresh, got %d", f.refreshes)
+		}
+	})
+
+	t.Run("restores a stored session and refreshes it", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		svc := f.service()
+		if err := svc.AuthenticateOAuth(ctx, fakeHandle, approve); err != nil {
+			t.Fatalf("AuthenticateOAuth failed: %v", err)
+		}
+		stored := *svc.session
+		oauth := *stored.OAuth
+		stored.OAuth = &oauth
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+
+		restored := f.service()
+		if err := restored.RestoreSession(&stored); err != nil {
+			t.Fatalf("RestoreSession failed: %v", err)
+		}
+		if f.refreshes != 1 {
+			t.Errorf("Expected expired session to be refreshed, got %d refreshes", f.refreshes)
+		}
+		if _, err := restored.PostDocument(ctx, doc, false); err != nil {
+			t.Fatalf("PostDocument failed: %v", err)
+		}
+	})
+
+	t.Run("rejects a callback with the wrong state", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		f.tamperState = true
+
+		err := f.service().AuthenticateOAuth(ctx, fakeHandle, approve)
+		if err == nil || !strings.Contains(err.Error(), "state") {
+			t.Errorf("Expected state mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("times out when the browser flow is abandoned", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		svc := f.service()
+		svc.oauth.Timeout = 50 * time.Millisecond
+
+		err := svc.AuthenticateOAuth(ctx, fakeHandle, func(string) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got %v", err)
+		}
+	})
+
+	t.Run("verifies the handle both ways", func(t *testing.T) {
+		f := newFakeOAuthServer(t)
+		f.claimedHandle = "mallory.test"
+
+		err := f.service().AuthenticateOAuth(ctx, fakeHandle, approve)
+		if err == nil || !strings.Contains(err.Error(), "not claimed") {
+			t.Errorf("Expected handle verification error, got %v", err)
+		}
+	})
+
+	t.Run("requireSecureURL", func(t *testing.T) {
+		for raw, ok := range map[string]bool{
+			"https://bsky.social":     true,
+			"http://127.0.0.1:8080":   true,
+			"http://localhost/x":      true,
+			"http://example.com":      false,
+			"ftp://example.com/file":  false,
+			"http://10.0.0.1/callbak": false,
+		} {
+			if err := requireSecureURL(raw); (err == nil) != ok {
+				t.Errorf("requireSecureURL(%q) = %v", raw, err)
+			}
+		}
+	})
+
+	t.Run("nonces are tracked per server", func(t *testing.T) {
+		var n dpopNonces
+		resp := &http.Response{Header: http.Header{"Dpop-Nonce": {"a"}}}
+		if !n.update("https://auth.example/token", resp) {
+			t.Error("Expected first nonce to be recorded")
+		}
+		if n.update("https://auth.example/par", resp) {
+			t.Error("Expected unchanged nonce to report no change")
+		}
+		if got := n.get("https://pds.example/xrpc"); got != "" {
+			t.Errorf("Expected no nonce for another server, got %q", got)
+		}
+	})
+
+	t.Run("DPoP key round trips", func(t *testing.T) {
+		key, err := newDPoPKey()
+		if err != nil {
+			t.Fatalf("newDPoPKey failed: %v", err)
+		}
+		encoded, err := key.encode()
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		parsed, err := parseDPoPKey(encoded)
+		if err != nil {
+			t.Fatalf("parseDPoPKey failed: %v", err)
+		}
+		if parsed.jwk["x"] != key.jwk["x"] || parsed.jwk["y"] != key.jwk["y"] {
+			t.Error("Expected parsed key to match")
+		}
+	})
+}
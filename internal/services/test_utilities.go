@@ -265,15 +265,20 @@ func TestTVSearch(t *testing.T, service *TVService, query string, expectedTitleF
 // MockATProtoService is a mock implementation of ATProtoService for testing
 type MockATProtoService struct {
 	AuthenticateFunc          func(ctx context.Context, handle, password string) error
+	AuthenticateOAuthFunc     func(ctx context.Context, handle string, openURL func(authURL string) error) error
 	GetSessionFunc            func() (*Session, error)
 	IsAuthenticatedVal        bool
 	RestoreSessionFunc        func(session *Session) error
 	PullDocumentsFunc         func(ctx context.Context) ([]DocumentWithMeta, error)
 	PostDocumentFunc          func(ctx context.Context, doc public.Document, isDraft bool) (*DocumentWithMeta, error)
 	PatchDocumentFunc         func(ctx context.Context, rkey string, doc public.Document, isDraft bool) (*DocumentWithMeta, error)
+	MoveDocumentFunc          func(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*DocumentWithMeta, error)
 	DeleteDocumentFunc        func(ctx context.Context, rkey string, isDraft bool) error
 	UploadBlobFunc            func(ctx context.Context, data []byte, mimeType string) (public.Blob, error)
 	GetDefaultPublicationFunc func(ctx context.Context) (string, error)
+	ListPublicationsFunc      func(ctx context.Context) ([]PublicationWithMeta, error)
+	CreatePublicationFunc     func(ctx context.Context, pub public.Publication) (*PublicationWithMeta, error)
+	UpdatePublicationFunc     func(ctx context.Context, rkey string, changes public.Publication) (*PublicationWithMeta, error)
 	CloseFunc                 func() error
 	Session                   *Session // Exported for test access
 }
@@ -303,6 +308,31 @@ func (m *MockATProtoService) Authenticate(ctx context.Context, handle, password
 	return nil
 }
 
+// AuthenticateOAuth mocks browser based OAuth authentication
+func (m *MockATProtoService) AuthenticateOAuth(ctx context.Context, handle string, openURL func(authURL string) error) error {
+	if m.AuthenticateOAuthFunc != nil {
+		return m.AuthenticateOAuthFunc(ctx, handle, openURL)
+	}
+
+	m.Session = &Session{
+		DID:           "did:plc:test123",
+		Handle:        handle,
+		AccessJWT:     "mock_oauth_access_token",
+		RefreshJWT:    "mock_oauth_refresh_token",
+		PDSURL:        "https://bsky.social",
+		ExpiresAt:     time.Now().Add(15 * time.Minute),
+		Authenticated: true,
+		OAuth: &OAuthSession{
+			Issuer:        "https://bsky.social",
+			TokenEndpoint: "https://bsky.social/oauth/token",
+			ClientID:      "http://localhost",
+			DPoPKey:       "mock_dpop_key",
+		},
+	}
+	m.IsAuthenticatedVal = true
+	return nil
+}
+
 // GetSession returns the current session
 func (m *MockATProtoService) GetSession() (*Session, error) {
 	if m.GetSessionFunc != nil {
@@ -376,6 +406,28 @@ func (m *MockATProtoService) PatchDocument(ctx context.Context, rkey string, doc
 	}, nil
 }
 
+// MoveDocument mocks moving a document between the draft and published collections
+func (m *MockATProtoService) MoveDocument(ctx context.Context, rkey string, doc public.Document, toDraft bool) (*DocumentWithMeta, error) {
+	if m.MoveDocumentFunc != nil {
+		return m.MoveDocumentFunc(ctx, rkey, doc, toDraft)
+	}
+
+	collection := public.TypeDocument
+	if toDraft {
+		collection = public.TypeDocumentDraft
+	}
+	return &DocumentWithMeta{
+		Document: doc,
+		Meta: public.DocumentMeta{
+			RKey:      rkey,
+			CID:       "mock_cid_moved_012",
+			URI:       "at://did:plc:test123/" + collection + "/" + rkey,
+			IsDraft:   toDraft,
+			FetchedAt: time.Now(),
+		},
+	}, nil
+}
+
 // DeleteDocument mocks deleting a document
 func (m *MockATProtoService) DeleteDocument(ctx context.Context, rkey string, isDraft bool) error {
 	if m.DeleteDocumentFunc != nil {
@@ -411,6 +463,53 @@ func (m *MockATProtoService) GetDefaultPublication(ctx context.Context) (string,
 	return "at://did:plc:test123/pub.leaflet.publication/mock_pub_rkey", nil
 }
 
+// ListPublications mocks listing publications, returning the default publication
+func (m *MockATProtoService) ListPublications(ctx context.Context) ([]PublicationWithMeta, error) {
+	if m.ListPublicationsFunc != nil {
+		return m.ListPublicationsFunc(ctx)
+	}
+
+	if !m.IsAuthenticatedVal {
+		return nil, errors.New("not authenticated")
+	}
+	return []PublicationWithMeta{{
+		Publication: public.Publication{Type: public.TypePublication, Name: "Mock Publication"},
+		RKey:        "mock_pub_rkey",
+		CID:         "mock_pub_cid",
+		URI:         "at://did:plc:test123/pub.leaflet.publication/mock_pub_rkey",
+	}}, nil
+}
+
+// CreatePublication mocks creating a publication
+func (m *MockATProtoService) CreatePublication(ctx context.Context, pub public.Publication) (*PublicationWithMeta, error) {
+	if m.CreatePublicationFunc != nil {
+		return m.CreatePublicationFunc(ctx, pub)
+	}
+
+	pub.Type = public.TypePublication
+	return &PublicationWithMeta{
+		Publication: pub,
+		RKey:        "mock_new_pub_rkey",
+		CID:         "mock_new_pub_cid",
+		URI:         "at://did:plc:test123/pub.leaflet.publication/mock_new_pub_rkey",
+	}, nil
+}
+
+// UpdatePublication mocks updating a publication
+func (m *MockATProtoService) UpdatePublication(ctx context.Context, rkey string, changes public.Publication) (*PublicationWithMeta, error) {
+	if m.UpdatePublicationFunc != nil {
+		return m.UpdatePublicationFunc(ctx, rkey, changes)
+	}
+
+	changes.Type = public.TypePublication
+	return &PublicationWithMeta{
+		Publication: changes,
+		RKey:        rkey,
+		CID:         "mock_pub_cid_updated",
+		URI:         "at://did:plc:test123/pub.leaflet.publication/" + rkey,
+	}, nil
+}
+
 // Close mocks cleanup
 func (m *MockATProtoService) Close() error {
 	if m.CloseFunc != nil {
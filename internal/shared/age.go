@@ -0,0 +1,169 @@
+package shared
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+// ErrNoTerminal is returned by [PromptPassphrase] when stdin is not a terminal
+var ErrNoTerminal = errors.New("stdin is not a terminal")
+
+// PassphraseFunc asks the user for a passphrase. confirm requests it twice.
+type PassphraseFunc func(prompt string, confirm bool) (string, error)
+
+// AgeKey derives age recipients and identities from an age identity file or,
+// when none is set, from a passphrase taken from the environment or a prompt
+type AgeKey struct {
+	IdentityFile  string
+	IdentityKey   string         // config key naming the identity file, suggested when no passphrase is available
+	PassphraseEnv string         // checked before prompting
+	Prompt        string         // shown when asking for the passphrase
+	Ask           PassphraseFunc // nil uses [PromptPassphrase]
+	WorkFactor    int            // log2 scrypt cost for new passphrase recipients; 0 keeps age's default
+	Cache         *string        // remembers the passphrase across calls when set
+}
+
+// Recipients returns who new ciphertext is encrypted to: the X25519 identities in
+// the identity file, or a scrypt recipient for the passphrase
+func (k *AgeKey) Recipients() ([]age.Recipient, error) {
+	if k.IdentityFile != "" {
+		identities, err := k.loadIdentityFile()
+		if err != nil {
+			return nil, err
+		}
+
+		var recipients []age.Recipient
+		for _, identity := range identities {
+			if x, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x.Recipient())
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("no X25519 identities found in %s", k.IdentityFile)
+		}
+		return recipients, nil
+	}
+
+	passphrase, err := k.Passphrase(true)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase: %w", err)
+	}
+	if k.WorkFactor > 0 {
+		recipient.SetWorkFactor(k.WorkFactor)
+	}
+	return []age.Recipient{recipient}, nil
+}
+
+// Identities returns the identities that decrypt ciphertext made for [AgeKey.Recipients]
+func (k *AgeKey) Identities() ([]age.Identity, error) {
+	if k.IdentityFile != "" {
+		return k.loadIdentityFile()
+	}
+
+	passphrase, err := k.Passphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase: %w", err)
+	}
+	return []age.Identity{identity}, nil
+}
+
+// Forget drops a cached passphrase, e.g. after it failed to decrypt
+func (k *AgeKey) Forget() {
+	if k.Cache != nil {
+		*k.Cache = ""
+	}
+}
+
+// Passphrase returns the passphrase, asking at most once when a cache is set.
+// confirm asks twice, for passphrases that will encrypt new data.
+func (k *AgeKey) Passphrase(confirm bool) (string, error) {
+	if k.Cache != nil && *k.Cache != "" {
+		return *k.Cache, nil
+	}
+
+	passphrase := os.Getenv(k.PassphraseEnv)
+	if passphrase == "" {
+		ask := k.Ask
+		if ask == nil {
+			ask = PromptPassphrase
+		}
+
+		var err error
+		if passphrase, err = ask(k.Prompt, confirm); errors.Is(err, ErrNoTerminal) {
+			return "", k.noPassphraseError()
+		} else if err != nil {
+			return "", err
+		}
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	if k.Cache != nil {
+		*k.Cache = passphrase
+	}
+	return passphrase, nil
+}
+
+func (k *AgeKey) noPassphraseError() error {
+	if k.IdentityKey != "" {
+		return fmt.Errorf("no passphrase available: set %s or %s, or run in a terminal", k.PassphraseEnv, k.IdentityKey)
+	}
+	return fmt.Errorf("no passphrase available: set %s or run in a terminal", k.PassphraseEnv)
+}
+
+func (k *AgeKey) loadIdentityFile() ([]age.Identity, error) {
+	f, err := os.Open(k.IdentityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file %s: %w", k.IdentityFile, err)
+	}
+	return identities, nil
+}
+
+// PromptPassphrase reads a passphrase from the terminal without echoing it
+func PromptPassphrase(prompt string, confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoTerminal
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if !confirm {
+		return string(first), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if !bytes.Equal(first, second) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return string(first), nil
+}
@@ -0,0 +1,96 @@
+package shared
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeKey(t *testing.T) {
+	roundTrip := func(t *testing.T, encrypt, decrypt *AgeKey) (string, error) {
+		t.Helper()
+		recipients, err := encrypt.Recipients()
+		AssertNoError(t, err, "recipients")
+
+		var buf bytes.Buffer
+		w, err := age.Encrypt(&buf, recipients...)
+		AssertNoError(t, err, "encrypt")
+		io.WriteString(w, "secret")
+		AssertNoError(t, w.Close(), "close")
+
+		identities, err := decrypt.Identities()
+		if err != nil {
+			return "", err
+		}
+		r, err := age.Decrypt(&buf, identities...)
+		if err != nil {
+			return "", err
+		}
+		out, err := io.ReadAll(r)
+		return string(out), err
+	}
+
+	t.Run("asks for the passphrase once", func(t *testing.T) {
+		t.Setenv("NOTELEAF_TEST_PASSPHRASE", "")
+		var cache string
+		prompts := 0
+		key := &AgeKey{
+			PassphraseEnv: "NOTELEAF_TEST_PASSPHRASE",
+			WorkFactor:    10,
+			Cache:         &cache,
+			Ask: func(string, bool) (string, error) {
+				prompts++
+				return "correct horse", nil
+			},
+		}
+
+		plaintext, err := roundTrip(t, key, key)
+		AssertNoError(t, err, "round trip")
+		AssertEqual(t, "secret", plaintext, "should decrypt")
+		AssertEqual(t, 1, prompts, "should prompt once")
+
+		key.Forget()
+		AssertEqual(t, "", cache, "Forget should clear the cache")
+	})
+
+	t.Run("prefers the environment", func(t *testing.T) {
+		t.Setenv("NOTELEAF_TEST_PASSPHRASE", "from env")
+		key := &AgeKey{
+			PassphraseEnv: "NOTELEAF_TEST_PASSPHRASE",
+			Ask: func(string, bool) (string, error) {
+				t.Error("did not expect a prompt")
+				return "", nil
+			},
+		}
+		passphrase, err := key.Passphrase(true)
+		AssertNoError(t, err, "passphrase")
+		AssertEqual(t, "from env", passphrase, "should use the environment")
+	})
+
+	t.Run("suggests alternatives without a terminal", func(t *testing.T) {
+		t.Setenv("NOTELEAF_TEST_PASSPHRASE", "")
+		key := &AgeKey{
+			PassphraseEnv: "NOTELEAF_TEST_PASSPHRASE",
+			IdentityKey:   "test_identity_file",
+			Ask:           func(string, bool) (string, error) { return "", ErrNoTerminal },
+		}
+		_, err := key.Passphrase(false)
+		AssertErrorContains(t, err, "set NOTELEAF_TEST_PASSPHRASE or test_identity_file", "should name both options")
+	})
+
+	t.Run("uses an identity file", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		AssertNoError(t, err, "generate identity")
+		path := filepath.Join(t.TempDir(), "key.txt")
+		AssertNoError(t, os.WriteFile(path, []byte(identity.String()+"\n"), 0o600), "write identity")
+
+		key := &AgeKey{IdentityFile: path}
+		plaintext, err := roundTrip(t, key, key)
+		AssertNoError(t, err, "round trip")
+		AssertEqual(t, "secret", plaintext, "should decrypt with the identity")
+	})
+}
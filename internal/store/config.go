@@ -27,12 +27,40 @@ type Config struct {
 	MovieAPIKey     string `toml:"movie_api_key,omitempty"`
 	BookAPIKey      string `toml:"book_api_key,omitempty"`
 
+	NoteHistoryKeepLast      int `toml:"note_history_keep_last"`       // 0 keeps every revision
+	NoteHistoryKeepDailyDays int `toml:"note_history_keep_daily_days"` // days to keep one revision per day
+
+	NoteIdentityFile string `toml:"note_identity_file,omitempty"` // age identity used for encrypted notes instead of a passphrase
+
+	ArticleArchive       bool `toml:"article_archive,omitempty"`        // download article images and media for offline reading
+	ArticleSelfContained bool `toml:"article_self_contained,omitempty"` // also embed archived assets in the HTML file as data URIs
+
+	ArticleArchiveEndpoint string `toml:"article_archive_endpoint,omitempty"` // web archive `article check` falls back to for dead links
+
+	// Credentials marked below are kept in the secret store, never in this file
+	SecretStore         string `toml:"secret_store,omitempty"`          // auto (default), secret-service or file
+	SecretsIdentityFile string `toml:"secrets_identity_file,omitempty"` // age identity for the file secret store instead of a passphrase
+
+	// Publications are referred to by name, rkey or AT URI
+	LeafletPublication       string            `toml:"leaflet_publication,omitempty"`        // used when no routing rule matches
+	LeafletPublicationRoutes map[string]string `toml:"leaflet_publication_routes,omitempty"` // note tag to publication
+	LeafletBacklinks         bool              `toml:"leaflet_backlinks,omitempty"`          // append a "Referenced by" section to published documents
+
 	ATProtoDID        string `toml:"atproto_did,omitempty"`
 	ATProtoHandle     string `toml:"atproto_handle,omitempty"`
-	ATProtoAccessJWT  string `toml:"atproto_access_jwt,omitempty"`
-	ATProtoRefreshJWT string `toml:"atproto_refresh_jwt,omitempty"`
+	ATProtoAccessJWT  string `toml:"atproto_access_jwt,omitempty"`  // secret
+	ATProtoRefreshJWT string `toml:"atproto_refresh_jwt,omitempty"` // secret
 	ATProtoPDSURL     string `toml:"atproto_pds_url,omitempty"`
 	ATProtoExpiresAt  string `toml:"atproto_expires_at,omitempty"` // ISO8601 timestamp
+
+	// Set when the session came from `pub auth --oauth`; tokens are bound to the DPoP key
+	ATProtoOAuthIssuer        string `toml:"atproto_oauth_issuer,omitempty"`
+	ATProtoOAuthTokenEndpoint string `toml:"atproto_oauth_token_endpoint,omitempty"`
+	ATProtoOAuthClientID      string `toml:"atproto_oauth_client_id,omitempty"`
+	ATProtoDPoPKey            string `toml:"atproto_dpop_key,omitempty"` // secret
+
+	secretBaseline   map[string]string // credentials as last read from or written to storage; missing keys are unknown
+	plaintextSecrets map[string]string // credentials an older version left in the config file, until [LoadSecrets] moves them
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -79,10 +107,20 @@ var LoadConfig = func() (*Config, error) {
 		return nil, shared.ConfigError("failed to parse config file", err)
 	}
 
+	// credentials written by older versions stay in the file until LoadSecrets moves them
+	for key, field := range secretFields(config) {
+		if *field != "" {
+			config.rememberPlaintextSecret(key, *field)
+		}
+	}
+
 	return config, nil
 }
 
-// SaveConfig saves the configuration to the config directory or NOTELEAF_CONFIG path
+// SaveConfig saves the configuration to the config directory or NOTELEAF_CONFIG path.
+//
+// Credentials are written to the secret store and left out of the file, except
+// for plaintext ones from an older version that [LoadSecrets] has not moved yet.
 func SaveConfig(config *Config) error {
 	var configPath string
 
@@ -100,12 +138,22 @@ func SaveConfig(config *Config) error {
 		configPath = filepath.Join(configDir, ".noteleaf.conf.toml")
 	}
 
-	data, err := toml.Marshal(config)
+	if err := saveSecrets(config); err != nil {
+		return shared.ConfigError("failed to save credentials", err)
+	}
+
+	plain := *config
+	for key, field := range secretFields(&plain) {
+		if _, ok := config.plaintextSecrets[key]; !ok {
+			*field = ""
+		}
+	}
+	data, err := toml.Marshal(plain)
 	if err != nil {
 		return shared.ConfigError("failed to marshal config", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
 		return shared.ConfigError("failed to write config file", err)
 	}
 
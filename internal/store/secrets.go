@@ -0,0 +1,465 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+// Secret store backends selected by [Config.SecretStore]
+const (
+	SecretStoreAuto          = "auto"           // Secret Service when a D-Bus session is available, otherwise file
+	SecretStoreSecretService = "secret-service" // desktop keyring over D-Bus, through libsecret's secret-tool
+	SecretStoreFile          = "file"           // age encrypted secrets.age next to the config file
+)
+
+// SecretsPassphraseEnv supplies the passphrase for the file secret store without prompting
+const SecretsPassphraseEnv = "NOTELEAF_SECRETS_PASSPHRASE"
+
+// ErrSecretNotFound is returned by [SecretStore.Get] when no secret is stored under a key
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore keeps credentials out of the plaintext config file
+type SecretStore interface {
+	// Get returns the secret stored under key, or [ErrSecretNotFound]
+	Get(key string) (string, error)
+	// Set stores value under key, replacing any previous secret
+	Set(key, value string) error
+	// Delete removes the secret under key; deleting a missing key is not an error
+	Delete(key string) error
+}
+
+// secretFields returns the config fields kept in the secret store, keyed by their TOML name
+func secretFields(c *Config) map[string]*string {
+	return map[string]*string{
+		"atproto_access_jwt":  &c.ATProtoAccessJWT,
+		"atproto_refresh_jwt": &c.ATProtoRefreshJWT,
+		"atproto_dpop_key":    &c.ATProtoDPoPKey,
+	}
+}
+
+// IsSecretKey reports whether a config key holds a credential that must not be displayed
+func IsSecretKey(key string) bool {
+	switch key {
+	case "sync_token", "movie_api_key", "book_api_key":
+		return true
+	}
+	_, ok := secretFields(&Config{})[key]
+	return ok
+}
+
+// rememberPlaintextSecret records a credential read from the config file itself
+func (c *Config) rememberPlaintextSecret(key, value string) {
+	if c.plaintextSecrets == nil {
+		c.plaintextSecrets = map[string]string{}
+	}
+	c.plaintextSecrets[key] = value
+	c.rememberSecret(key, value)
+}
+
+// rememberSecret records the value a credential has in storage
+func (c *Config) rememberSecret(key, value string) {
+	if c.secretBaseline == nil {
+		c.secretBaseline = map[string]string{}
+	}
+	c.secretBaseline[key] = value
+}
+
+var (
+	secretsMu   sync.Mutex
+	secretStore SecretStore
+)
+
+// OpenSecretStore opens the backend named by config.SecretStore. The Secret Service backend
+// needs libsecret's secret-tool and a D-Bus session; without them both auto and
+// secret-service use the encrypted file.
+var OpenSecretStore = func(config *Config) (SecretStore, error) {
+	backend := config.SecretStore
+	if backend == "" {
+		backend = SecretStoreAuto
+	}
+
+	switch backend {
+	case SecretStoreAuto, SecretStoreSecretService:
+		if SecretServiceAvailable() {
+			return NewSecretServiceStore(), nil
+		}
+		return newConfigFileSecretStore(config)
+	case SecretStoreFile:
+		return newConfigFileSecretStore(config)
+	default:
+		return nil, fmt.Errorf("unknown secret store %q: use %s, %s or %s", backend, SecretStoreAuto, SecretStoreSecretService, SecretStoreFile)
+	}
+}
+
+// Secrets returns the secret store for this process, opening it on first use
+func Secrets(config *Config) (SecretStore, error) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if secretStore == nil {
+		s, err := OpenSecretStore(config)
+		if err != nil {
+			return nil, err
+		}
+		secretStore = s
+	}
+	return secretStore, nil
+}
+
+// UseSecretStore replaces the secret store for this process; nil reopens it from the config on next use
+func UseSecretStore(s SecretStore) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secretStore = s
+}
+
+// MigrateSecrets moves credentials that an older version saved in the config
+// file into the secret store and removes them from the file. It does nothing,
+// and opens no secret store, when the file holds none. If the move fails they
+// stay in the file and the error is returned.
+func MigrateSecrets(config *Config) error {
+	if len(config.plaintextSecrets) == 0 {
+		return nil
+	}
+
+	secrets, err := Secrets(config)
+	if err != nil {
+		return shared.ConfigError("failed to open secret store", err)
+	}
+	for key, value := range config.plaintextSecrets {
+		if err := secrets.Set(key, value); err != nil {
+			return shared.ConfigError("failed to move "+key+" to the secret store", err)
+		}
+	}
+	config.plaintextSecrets = nil
+
+	if err := SaveConfig(config); err != nil {
+		return shared.ConfigError("failed to remove plaintext credentials from the config file", err)
+	}
+	return nil
+}
+
+// LoadSecrets fills config's credential fields from the secret store, after
+// [MigrateSecrets] has moved any left in the config file.
+func LoadSecrets(config *Config) error {
+	if err := MigrateSecrets(config); err != nil {
+		return err
+	}
+
+	secrets, err := Secrets(config)
+	if err != nil {
+		return shared.ConfigError("failed to open secret store", err)
+	}
+	for key, field := range secretFields(config) {
+		value, err := secrets.Get(key)
+		if err != nil && !errors.Is(err, ErrSecretNotFound) {
+			return shared.ConfigError("failed to read "+key, err)
+		}
+		*field = value
+		config.rememberSecret(key, value)
+	}
+	return nil
+}
+
+// saveSecrets writes the credential fields that changed since they were loaded or
+// last saved. Keys whose stored value was never read are only written when set, so
+// saving one credential never clears the others.
+func saveSecrets(config *Config) error {
+	var secrets SecretStore
+	for key, field := range secretFields(config) {
+		if *field == config.secretBaseline[key] {
+			continue
+		}
+		// a changed credential no longer belongs in the config file
+		delete(config.plaintextSecrets, key)
+
+		if secrets == nil {
+			var err error
+			if secrets, err = Secrets(config); err != nil {
+				return fmt.Errorf("failed to open secret store: %w", err)
+			}
+		}
+
+		var err error
+		if *field == "" {
+			err = secrets.Delete(key)
+		} else {
+			err = secrets.Set(key, *field)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		config.rememberSecret(key, *field)
+	}
+	return nil
+}
+
+// MemorySecretStore keeps secrets in memory, for tests
+type MemorySecretStore struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewMemorySecretStore creates an empty [MemorySecretStore]
+func NewMemorySecretStore() *MemorySecretStore {
+	return &MemorySecretStore{secrets: map[string]string{}}
+}
+
+func (s *MemorySecretStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (s *MemorySecretStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[key] = value
+	return nil
+}
+
+func (s *MemorySecretStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.secrets, key)
+	return nil
+}
+
+// secretServiceName is the service attribute noteleaf's keyring entries are stored under
+const secretServiceName = "noteleaf"
+
+// runSecretTool runs libsecret's secret-tool with stdin and returns its output
+var runSecretTool = func(stdin string, args ...string) (stdout, stderr string, err error) {
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err = cmd.Run()
+	return out.String(), strings.TrimSpace(errOut.String()), err
+}
+
+// SecretServiceAvailable reports whether the freedesktop Secret Service can be reached: a D-Bus
+// session is running and libsecret's secret-tool is installed
+func SecretServiceAvailable() bool {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+// SecretServiceStore keeps secrets in the desktop keyring (GNOME Keyring, KWallet, KeePassXC)
+// through the freedesktop Secret Service D-Bus API, using libsecret's secret-tool
+type SecretServiceStore struct {
+	Service string
+}
+
+// NewSecretServiceStore creates a [SecretServiceStore] for noteleaf's keyring entries
+func NewSecretServiceStore() *SecretServiceStore {
+	return &SecretServiceStore{Service: secretServiceName}
+}
+
+func (s *SecretServiceStore) Get(key string) (string, error) {
+	out, stderr, err := runSecretTool("", "lookup", "service", s.Service, "key", key)
+	if err != nil {
+		// secret-tool exits 1 without any output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && out == "" && stderr == "" {
+			return "", ErrSecretNotFound
+		}
+		return "", secretToolError("lookup", err, stderr)
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (s *SecretServiceStore) Set(key, value string) error {
+	label := fmt.Sprintf("%s %s", s.Service, key)
+	if _, stderr, err := runSecretTool(value, "store", "--label", label, "service", s.Service, "key", key); err != nil {
+		return secretToolError("store", err, stderr)
+	}
+	return nil
+}
+
+func (s *SecretServiceStore) Delete(key string) error {
+	if _, stderr, err := runSecretTool("", "clear", "service", s.Service, "key", key); err != nil {
+		return secretToolError("clear", err, stderr)
+	}
+	return nil
+}
+
+func secretToolError(action string, err error, stderr string) error {
+	if stderr != "" {
+		return fmt.Errorf("secret-tool %s failed: %w: %s", action, err, stderr)
+	}
+	return fmt.Errorf("secret-tool %s failed: %w", action, err)
+}
+
+// secretsWorkFactor is the log2 scrypt cost for passphrase encrypted secret files
+var secretsWorkFactor = 18
+
+// FileSecretStore keeps secrets in an age encrypted JSON file, encrypted either to a
+// passphrase or to the X25519 recipients of an age identity file.
+//
+// The file is decrypted on first use and the passphrase asked for at most once.
+type FileSecretStore struct {
+	Path         string
+	IdentityFile string                // age identity used instead of a passphrase
+	Passphrase   shared.PassphraseFunc // asked when no identity file is set; nil reads from the terminal
+	secrets      map[string]string
+	passphrase   string
+}
+
+// newConfigFileSecretStore opens secrets.age next to the config file
+func newConfigFileSecretStore(config *Config) (*FileSecretStore, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return &FileSecretStore{
+		Path:         filepath.Join(filepath.Dir(configPath), "secrets.age"),
+		IdentityFile: config.SecretsIdentityFile,
+	}, nil
+}
+
+func (s *FileSecretStore) Get(key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (s *FileSecretStore) Set(key, value string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	if current, ok := s.secrets[key]; ok && current == value {
+		return nil
+	}
+	s.secrets[key] = value
+	return s.save()
+}
+
+func (s *FileSecretStore) Delete(key string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.secrets[key]; !ok {
+		return nil
+	}
+	delete(s.secrets, key)
+	return s.save()
+}
+
+// load decrypts the secrets file; a missing file holds no secrets and needs no passphrase
+func (s *FileSecretStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		s.secrets = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	identities, err := s.key().Identities()
+	if err != nil {
+		return err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", s.Path, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", s.Path, err)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	s.secrets = secrets
+	return nil
+}
+
+// save encrypts the secrets and replaces the file atomically
+func (s *FileSecretStore) save() error {
+	if len(s.secrets) == 0 {
+		if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secrets file: %w", err)
+		}
+		return nil
+	}
+
+	recipients, err := s.key().Recipients()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// key returns the age key the secrets file is encrypted with
+func (s *FileSecretStore) key() *shared.AgeKey {
+	return &shared.AgeKey{
+		IdentityFile:  s.IdentityFile,
+		IdentityKey:   "secrets_identity_file",
+		PassphraseEnv: SecretsPassphraseEnv,
+		Prompt:        "Secrets passphrase: ",
+		Ask:           s.Passphrase,
+		WorkFactor:    secretsWorkFactor,
+		Cache:         &s.passphrase,
+	}
+}
@@ -0,0 +1,338 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+// useTestSecrets points the config at a temp directory and installs an in-memory secret store
+func useTestSecrets(t *testing.T) (*MemorySecretStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".noteleaf.conf.toml")
+	t.Setenv("NOTELEAF_CONFIG", configPath)
+
+	secrets := NewMemorySecretStore()
+	UseSecretStore(secrets)
+	t.Cleanup(func() { UseSecretStore(nil) })
+	return secrets, configPath
+}
+
+func TestSecretConfig(t *testing.T) {
+	t.Run("SaveConfig keeps credentials out of the config file", func(t *testing.T) {
+		secrets, configPath := useTestSecrets(t)
+
+		config := DefaultConfig()
+		config.ATProtoDID = "did:plc:test"
+		config.ATProtoAccessJWT = "access-token"
+		config.ATProtoRefreshJWT = "refresh-token"
+		shared.AssertNoError(t, SaveConfig(config), "save config")
+
+		data, err := os.ReadFile(configPath)
+		shared.AssertNoError(t, err, "read config")
+		shared.AssertFalse(t, strings.Contains(string(data), "token"), "config file should not contain credentials")
+		shared.AssertContains(t, string(data), "did:plc:test", "config file should keep the DID")
+		shared.AssertEqual(t, "access-token", config.ATProtoAccessJWT, "in-memory config should keep credentials")
+
+		value, err := secrets.Get("atproto_refresh_jwt")
+		shared.AssertNoError(t, err, "get refresh token")
+		shared.AssertEqual(t, "refresh-token", value, "secret store should hold the refresh token")
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(configPath)
+			shared.AssertNoError(t, err, "stat config")
+			shared.AssertEqual(t, os.FileMode(0o600), info.Mode().Perm(), "config file should be private")
+		}
+	})
+
+	t.Run("LoadSecrets restores credentials", func(t *testing.T) {
+		secrets, _ := useTestSecrets(t)
+		shared.AssertNoError(t, secrets.Set("atproto_access_jwt", "access-token"), "seed access token")
+		shared.AssertNoError(t, secrets.Set("atproto_dpop_key", "dpop-key"), "seed dpop key")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		shared.AssertEqual(t, "", config.ATProtoAccessJWT, "LoadConfig should not read secrets")
+
+		shared.AssertNoError(t, LoadSecrets(config), "load secrets")
+		shared.AssertEqual(t, "access-token", config.ATProtoAccessJWT, "should restore the access token")
+		shared.AssertEqual(t, "dpop-key", config.ATProtoDPoPKey, "should restore the DPoP key")
+		shared.AssertEqual(t, "", config.ATProtoRefreshJWT, "should leave missing secrets empty")
+
+		config.ATProtoDPoPKey = ""
+		shared.AssertNoError(t, SaveConfig(config), "save config")
+		_, err = secrets.Get("atproto_dpop_key")
+		shared.AssertTrue(t, errors.Is(err, ErrSecretNotFound), "cleared credentials should be deleted")
+	})
+
+	t.Run("saving without loading leaves stored credentials alone", func(t *testing.T) {
+		secrets, _ := useTestSecrets(t)
+		shared.AssertNoError(t, secrets.Set("atproto_access_jwt", "access-token"), "seed access token")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		config.Editor = "vim"
+		shared.AssertNoError(t, SaveConfig(config), "save config")
+
+		value, err := secrets.Get("atproto_access_jwt")
+		shared.AssertNoError(t, err, "get access token")
+		shared.AssertEqual(t, "access-token", value, "unrelated saves should keep credentials")
+	})
+
+	t.Run("setting one credential without loading keeps the others", func(t *testing.T) {
+		secrets, _ := useTestSecrets(t)
+		shared.AssertNoError(t, secrets.Set("atproto_refresh_jwt", "refresh-token"), "seed refresh token")
+		shared.AssertNoError(t, secrets.Set("atproto_dpop_key", "dpop-key"), "seed dpop key")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		config.ATProtoAccessJWT = "new-access-token"
+		shared.AssertNoError(t, SaveConfig(config), "save config")
+
+		value, err := secrets.Get("atproto_refresh_jwt")
+		shared.AssertNoError(t, err, "get refresh token")
+		shared.AssertEqual(t, "refresh-token", value, "refresh token should survive")
+		value, err = secrets.Get("atproto_dpop_key")
+		shared.AssertNoError(t, err, "get dpop key")
+		shared.AssertEqual(t, "dpop-key", value, "DPoP key should survive")
+		value, err = secrets.Get("atproto_access_jwt")
+		shared.AssertNoError(t, err, "get access token")
+		shared.AssertEqual(t, "new-access-token", value, "changed credential should be stored")
+	})
+
+	t.Run("LoadSecrets migrates plaintext credentials", func(t *testing.T) {
+		secrets, configPath := useTestSecrets(t)
+		legacy := "date_format = \"2006-01-02\"\natproto_did = \"did:plc:test\"\natproto_access_jwt = \"access-token\"\natproto_refresh_jwt = \"refresh-token\"\n"
+		shared.AssertNoError(t, os.WriteFile(configPath, []byte(legacy), 0o644), "write legacy config")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		_, err = secrets.Get("atproto_access_jwt")
+		shared.AssertTrue(t, errors.Is(err, ErrSecretNotFound), "LoadConfig should not touch the secret store")
+
+		config.Editor = "vim"
+		shared.AssertNoError(t, SaveConfig(config), "save config")
+		data, err := os.ReadFile(configPath)
+		shared.AssertNoError(t, err, "read config")
+		shared.AssertContains(t, string(data), "refresh-token", "unrelated saves should keep unmigrated credentials")
+
+		shared.AssertNoError(t, LoadSecrets(config), "load secrets")
+		shared.AssertEqual(t, "refresh-token", config.ATProtoRefreshJWT, "migrated config should keep credentials in memory")
+
+		data, err = os.ReadFile(configPath)
+		shared.AssertNoError(t, err, "read config")
+		shared.AssertFalse(t, strings.Contains(string(data), "access-token"), "migration should remove plaintext credentials")
+		shared.AssertContains(t, string(data), "vim", "migration should keep other settings")
+
+		value, err := secrets.Get("atproto_access_jwt")
+		shared.AssertNoError(t, err, "get access token")
+		shared.AssertEqual(t, "access-token", value, "migration should move credentials to the secret store")
+	})
+
+	t.Run("failed migration keeps plaintext credentials", func(t *testing.T) {
+		_, configPath := useTestSecrets(t)
+		UseSecretStore(nil)
+		origOpen := OpenSecretStore
+		OpenSecretStore = func(*Config) (SecretStore, error) { return nil, errors.New("keyring locked") }
+		t.Cleanup(func() { OpenSecretStore = origOpen })
+
+		legacy := "atproto_did = \"did:plc:test\"\natproto_access_jwt = \"access-token\"\n"
+		shared.AssertNoError(t, os.WriteFile(configPath, []byte(legacy), 0o644), "write legacy config")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		shared.AssertErrorContains(t, LoadSecrets(config), "keyring locked", "LoadSecrets should report the failure")
+
+		data, err := os.ReadFile(configPath)
+		shared.AssertNoError(t, err, "read config")
+		shared.AssertContains(t, string(data), "access-token", "credentials should not be lost")
+	})
+
+	t.Run("MigrateSecrets moves plaintext credentials without reading the others", func(t *testing.T) {
+		secrets, configPath := useTestSecrets(t)
+		legacy := "atproto_did = \"did:plc:test\"\natproto_access_jwt = \"access-token\"\n"
+		shared.AssertNoError(t, os.WriteFile(configPath, []byte(legacy), 0o644), "write legacy config")
+
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		shared.AssertNoError(t, MigrateSecrets(config), "migrate secrets")
+
+		data, err := os.ReadFile(configPath)
+		shared.AssertNoError(t, err, "read config")
+		shared.AssertFalse(t, strings.Contains(string(data), "access-token"), "migration should remove plaintext credentials")
+		value, err := secrets.Get("atproto_access_jwt")
+		shared.AssertNoError(t, err, "get access token")
+		shared.AssertEqual(t, "access-token", value, "migration should move credentials to the secret store")
+	})
+
+	t.Run("MigrateSecrets opens no secret store without plaintext credentials", func(t *testing.T) {
+		_, configPath := useTestSecrets(t)
+		UseSecretStore(nil)
+		origOpen := OpenSecretStore
+		OpenSecretStore = func(*Config) (SecretStore, error) { return nil, errors.New("keyring locked") }
+		t.Cleanup(func() { OpenSecretStore = origOpen })
+
+		shared.AssertNoError(t, os.WriteFile(configPath, []byte("atproto_did = \"did:plc:test\"\n"), 0o644), "write config")
+		config, err := LoadConfig()
+		shared.AssertNoError(t, err, "load config")
+		shared.AssertNoError(t, MigrateSecrets(config), "nothing to migrate should not open the secret store")
+	})
+
+	t.Run("IsSecretKey", func(t *testing.T) {
+		for _, key := range []string{"atproto_access_jwt", "atproto_refresh_jwt", "atproto_dpop_key", "sync_token", "book_api_key"} {
+			shared.AssertTrue(t, IsSecretKey(key), key+" should be secret")
+		}
+		shared.AssertFalse(t, IsSecretKey("atproto_did"), "atproto_did should not be secret")
+	})
+
+	t.Run("OpenSecretStore uses the file without a D-Bus session", func(t *testing.T) {
+		useTestSecrets(t)
+		t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+		for _, backend := range []string{"", SecretStoreAuto, SecretStoreSecretService} {
+			s, err := OpenSecretStore(&Config{SecretStore: backend})
+			shared.AssertNoError(t, err, "open "+backend)
+			if _, ok := s.(*FileSecretStore); !ok {
+				t.Errorf("%q: expected the file secret store, got %T", backend, s)
+			}
+		}
+	})
+
+	t.Run("OpenSecretStore rejects unknown backends", func(t *testing.T) {
+		_, err := OpenSecretStore(&Config{SecretStore: "vault"})
+		shared.AssertErrorContains(t, err, "unknown secret store", "should reject unknown backends")
+	})
+}
+
+func TestFileSecretStore(t *testing.T) {
+	origWorkFactor := secretsWorkFactor
+	secretsWorkFactor = 10
+	t.Cleanup(func() { secretsWorkFactor = origWorkFactor })
+	t.Setenv(SecretsPassphraseEnv, "")
+
+	passphrase := func(value string, prompts *int) func(string, bool) (string, error) {
+		return func(prompt string, confirm bool) (string, error) {
+			*prompts++
+			return value, nil
+		}
+	}
+
+	t.Run("round trips secrets with a passphrase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.age")
+		prompts := 0
+		s := &FileSecretStore{Path: path, Passphrase: passphrase("correct horse", &prompts)}
+
+		_, err := s.Get("token")
+		shared.AssertTrue(t, errors.Is(err, ErrSecretNotFound), "missing file should hold no secrets")
+		shared.AssertEqual(t, 0, prompts, "reading a missing file should not ask for a passphrase")
+
+		shared.AssertNoError(t, s.Set("token", "secret-value"), "set")
+		shared.AssertNoError(t, s.Set("other", "x"), "set other")
+		shared.AssertEqual(t, 1, prompts, "should ask for the passphrase once")
+
+		data, err := os.ReadFile(path)
+		shared.AssertNoError(t, err, "read secrets file")
+		shared.AssertFalse(t, strings.Contains(string(data), "secret-value"), "secrets file should be encrypted")
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			shared.AssertNoError(t, err, "stat secrets file")
+			shared.AssertEqual(t, os.FileMode(0o600), info.Mode().Perm(), "secrets file should be private")
+		}
+
+		reopened := &FileSecretStore{Path: path, Passphrase: passphrase("correct horse", &prompts)}
+		value, err := reopened.Get("token")
+		shared.AssertNoError(t, err, "get")
+		shared.AssertEqual(t, "secret-value", value, "should decrypt the stored secret")
+
+		shared.AssertNoError(t, reopened.Delete("token"), "delete")
+		shared.AssertNoError(t, reopened.Delete("other"), "delete other")
+		_, err = os.Stat(path)
+		shared.AssertTrue(t, os.IsNotExist(err), "removing the last secret should remove the file")
+	})
+
+	t.Run("rejects a wrong passphrase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.age")
+		prompts := 0
+		shared.AssertNoError(t, (&FileSecretStore{Path: path, Passphrase: passphrase("right", &prompts)}).Set("token", "x"), "set")
+
+		_, err := (&FileSecretStore{Path: path, Passphrase: passphrase("wrong", &prompts)}).Get("token")
+		shared.AssertErrorContains(t, err, "failed to decrypt", "should fail to decrypt")
+	})
+
+	t.Run("uses the passphrase environment variable", func(t *testing.T) {
+		t.Setenv(SecretsPassphraseEnv, "from-env")
+		path := filepath.Join(t.TempDir(), "secrets.age")
+		shared.AssertNoError(t, (&FileSecretStore{Path: path}).Set("token", "x"), "set")
+
+		value, err := (&FileSecretStore{Path: path}).Get("token")
+		shared.AssertNoError(t, err, "get")
+		shared.AssertEqual(t, "x", value, "should decrypt with the environment passphrase")
+	})
+
+	t.Run("encrypts to an age identity file", func(t *testing.T) {
+		dir := t.TempDir()
+		identity, err := age.GenerateX25519Identity()
+		shared.AssertNoError(t, err, "generate identity")
+		identityPath := filepath.Join(dir, "key.txt")
+		shared.AssertNoError(t, os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600), "write identity")
+
+		path := filepath.Join(dir, "secrets.age")
+		shared.AssertNoError(t, (&FileSecretStore{Path: path, IdentityFile: identityPath}).Set("token", "x"), "set")
+
+		value, err := (&FileSecretStore{Path: path, IdentityFile: identityPath}).Get("token")
+		shared.AssertNoError(t, err, "get")
+		shared.AssertEqual(t, "x", value, "should decrypt with the identity")
+	})
+}
+
+func TestSecretServiceStore(t *testing.T) {
+	orig := runSecretTool
+	t.Cleanup(func() { runSecretTool = orig })
+
+	keyring := map[string]string{}
+	var calls []string
+	runSecretTool = func(stdin string, args ...string) (string, string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		key := args[len(args)-1]
+		switch args[0] {
+		case "store":
+			keyring[key] = stdin
+		case "lookup":
+			value, ok := keyring[key]
+			if !ok {
+				return "", "", exec.Command("false").Run()
+			}
+			return value, "", nil
+		case "clear":
+			delete(keyring, key)
+		}
+		return "", "", nil
+	}
+
+	s := NewSecretServiceStore()
+	_, err := s.Get("atproto_access_jwt")
+	shared.AssertTrue(t, errors.Is(err, ErrSecretNotFound), "missing entries should be not found")
+
+	shared.AssertNoError(t, s.Set("atproto_access_jwt", "token"), "set")
+	value, err := s.Get("atproto_access_jwt")
+	shared.AssertNoError(t, err, "get")
+	shared.AssertEqual(t, "token", value, "should read the stored secret")
+	shared.AssertEqual(t, "store --label noteleaf atproto_access_jwt service noteleaf key atproto_access_jwt", calls[1], "should store under the noteleaf service")
+
+	shared.AssertNoError(t, s.Delete("atproto_access_jwt"), "delete")
+	_, err = s.Get("atproto_access_jwt")
+	shared.AssertTrue(t, errors.Is(err, ErrSecretNotFound), "deleted entries should be not found")
+
+	runSecretTool = func(stdin string, args ...string) (string, string, error) {
+		return "", "Cannot autolaunch D-Bus without X11 $DISPLAY", exec.Command("false").Run()
+	}
+	_, err = s.Get("atproto_access_jwt")
+	shared.AssertErrorContains(t, err, "D-Bus", "D-Bus failures should not look like missing secrets")
+}
@@ -0,0 +1,5 @@
+-- Drop note revision history
+DROP INDEX IF EXISTS idx_note_revisions_content_hash;
+DROP INDEX IF EXISTS idx_note_revisions_note_id;
+DROP TABLE IF EXISTS note_revisions;
+DROP TABLE IF EXISTS note_revision_blobs;
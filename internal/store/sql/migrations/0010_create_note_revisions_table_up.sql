@@ -0,0 +1,22 @@
+-- Compressed note contents, addressed by SHA-256 so identical revisions share storage
+CREATE TABLE IF NOT EXISTS note_revision_blobs (
+    hash TEXT PRIMARY KEY,
+    data BLOB NOT NULL, -- gzip compressed content
+    size INTEGER NOT NULL -- uncompressed size in bytes
+);
+
+-- Note revision history
+CREATE TABLE IF NOT EXISTS note_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY (content_hash) REFERENCES note_revision_blobs(hash),
+    UNIQUE (note_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_revisions_note_id ON note_revisions(note_id);
+CREATE INDEX IF NOT EXISTS idx_note_revisions_content_hash ON note_revisions(content_hash);
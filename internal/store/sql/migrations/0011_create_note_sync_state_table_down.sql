@@ -0,0 +1,2 @@
+-- Drop note sync state table
+DROP TABLE IF EXISTS note_sync_state;
@@ -0,0 +1,9 @@
+-- Tracks the last reconciled state between a note and its markdown file on disk
+CREATE TABLE IF NOT EXISTS note_sync_state (
+    note_id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL UNIQUE,
+    file_hash TEXT NOT NULL, -- SHA-256 of the file contents at last sync
+    note_hash TEXT NOT NULL, -- SHA-256 of the rendered note at last sync
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Drop note imports table
+DROP INDEX IF EXISTS idx_note_imports_note_id;
+DROP TABLE IF EXISTS note_imports;
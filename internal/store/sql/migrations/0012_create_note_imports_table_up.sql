@@ -0,0 +1,11 @@
+-- Records notes brought in by `note import` so running an import again skips them
+CREATE TABLE IF NOT EXISTS note_imports (
+    hash TEXT PRIMARY KEY, -- SHA-256 of the source note
+    note_id INTEGER NOT NULL,
+    source TEXT NOT NULL, -- obsidian, notion, bear or enex
+    path TEXT NOT NULL,
+    imported DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_imports_note_id ON note_imports(note_id);
@@ -0,0 +1,2 @@
+-- Remove encrypted flag
+ALTER TABLE notes DROP COLUMN encrypted;
@@ -0,0 +1,2 @@
+-- Mark notes whose content is stored as age ciphertext
+ALTER TABLE notes ADD COLUMN encrypted INTEGER DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Drop leaflet sync table
+DROP INDEX IF EXISTS idx_leaflet_sync_status;
+DROP TABLE IF EXISTS leaflet_sync;
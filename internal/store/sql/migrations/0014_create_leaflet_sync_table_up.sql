@@ -0,0 +1,15 @@
+-- Tracks the last version of each leaflet document that was merged into its note
+CREATE TABLE IF NOT EXISTS leaflet_sync (
+    note_id INTEGER PRIMARY KEY,
+    rkey TEXT NOT NULL,
+    base_title TEXT NOT NULL, -- note title at the last sync, the common ancestor for merges
+    base_content TEXT NOT NULL, -- note content at the last sync
+    remote_cid TEXT, -- pending remote version awaiting resolution
+    remote_title TEXT,
+    remote_content TEXT,
+    status TEXT NOT NULL DEFAULT 'synced', -- synced, conflict or deleted
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaflet_sync_status ON leaflet_sync(status);
@@ -0,0 +1,2 @@
+-- Remove leaflet publication
+ALTER TABLE notes DROP COLUMN leaflet_publication;
@@ -0,0 +1,2 @@
+-- Record which leaflet publication a note's document belongs to
+ALTER TABLE notes ADD COLUMN leaflet_publication TEXT;
@@ -0,0 +1,2 @@
+-- Drop leaflet outbox table
+DROP TABLE IF EXISTS leaflet_outbox;
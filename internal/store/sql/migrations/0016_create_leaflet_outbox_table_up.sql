@@ -0,0 +1,17 @@
+-- Queues leaflet writes that could not reach the PDS, one pending operation per note
+CREATE TABLE IF NOT EXISTS leaflet_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL UNIQUE, -- not a foreign key: deletes must outlive the note
+    operation TEXT NOT NULL, -- create, update or delete
+    rkey TEXT, -- record to update or delete
+    is_draft BOOLEAN NOT NULL DEFAULT FALSE,
+    publication TEXT, -- explicit publication for creates
+    document TEXT, -- prepared public.Document as JSON; prepared on flush when empty
+    base_title TEXT, -- note as it was when the document was prepared
+    base_content TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    failed BOOLEAN NOT NULL DEFAULT FALSE, -- permanent failure, kept until dropped or requeued
+    queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Drop blob cache table
+DROP TABLE IF EXISTS blob_cache;
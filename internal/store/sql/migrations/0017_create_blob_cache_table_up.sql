@@ -0,0 +1,12 @@
+-- Remembers image blobs already uploaded to a PDS so the same image is not uploaded twice
+CREATE TABLE IF NOT EXISTS blob_cache (
+    hash TEXT NOT NULL, -- sha256 of the source image bytes
+    did TEXT NOT NULL, -- blobs belong to a repository, so the cache is per account
+    cid TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    size INTEGER NOT NULL, -- uploaded size, after any downscaling
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (hash, did)
+);
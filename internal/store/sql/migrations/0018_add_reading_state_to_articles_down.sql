@@ -0,0 +1,8 @@
+-- Remove read-it-later state from articles
+DROP INDEX IF EXISTS idx_articles_status;
+ALTER TABLE articles DROP COLUMN reading_time;
+ALTER TABLE articles DROP COLUMN word_count;
+ALTER TABLE articles DROP COLUMN progress;
+ALTER TABLE articles DROP COLUMN tags;
+ALTER TABLE articles DROP COLUMN favorite;
+ALTER TABLE articles DROP COLUMN status;
@@ -0,0 +1,9 @@
+-- Read-it-later state for articles: status, favourite flag, tags, progress and size estimates
+ALTER TABLE articles ADD COLUMN status TEXT NOT NULL DEFAULT 'unread'; -- unread, reading, read or archived
+ALTER TABLE articles ADD COLUMN favorite INTEGER DEFAULT 0;
+ALTER TABLE articles ADD COLUMN tags TEXT DEFAULT '';
+ALTER TABLE articles ADD COLUMN progress INTEGER DEFAULT 0; -- percentage 0-100
+ALTER TABLE articles ADD COLUMN word_count INTEGER DEFAULT 0;
+ALTER TABLE articles ADD COLUMN reading_time INTEGER DEFAULT 0; -- estimated minutes
+
+CREATE INDEX IF NOT EXISTS idx_articles_status ON articles(status);
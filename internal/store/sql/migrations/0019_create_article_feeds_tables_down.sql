@@ -0,0 +1,3 @@
+-- Drop feed subscriptions
+DROP TABLE IF EXISTS article_feed_items;
+DROP TABLE IF EXISTS article_feeds;
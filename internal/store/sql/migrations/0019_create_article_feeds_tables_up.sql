@@ -0,0 +1,28 @@
+-- Feed subscriptions whose new entries are saved as articles
+CREATE TABLE IF NOT EXISTS article_feeds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT UNIQUE NOT NULL,
+    title TEXT NOT NULL,
+    site_url TEXT,
+    etag TEXT, -- validators of the last full response, sent back for conditional GET
+    last_modified TEXT,
+    include TEXT, -- JSON array of keywords; entries must mention one when set
+    exclude TEXT, -- JSON array of keywords; entries mentioning any are skipped
+    max_age INTEGER NOT NULL DEFAULT 0, -- days; 0 keeps entries of any age
+    tags TEXT, -- JSON array of tags added to every article saved from the feed
+    last_fetched DATETIME,
+    last_error TEXT,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    modified DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Entries already saved from each feed, so refreshes skip them even when the article URL changed
+CREATE TABLE IF NOT EXISTS article_feed_items (
+    feed_id INTEGER NOT NULL,
+    guid TEXT NOT NULL,
+    url TEXT NOT NULL,
+    article_id INTEGER, -- not a foreign key: removing an article must not bring the entry back
+    added DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (feed_id, guid),
+    FOREIGN KEY (feed_id) REFERENCES article_feeds(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Drop article import state
+DROP INDEX IF EXISTS idx_article_import_items_state;
+DROP TABLE IF EXISTS article_import_items;
+DROP TABLE IF EXISTS article_imports;
@@ -0,0 +1,29 @@
+-- Reading lists imported from other applications, kept so interrupted imports can resume
+CREATE TABLE IF NOT EXISTS article_imports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL, -- pocket, instapaper, wallabag or bookmarks
+    path TEXT NOT NULL,
+    hash TEXT UNIQUE NOT NULL, -- SHA-256 of the export file
+    total INTEGER NOT NULL DEFAULT 0,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    modified DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Links of each import with the state to save them with and how far the import got
+CREATE TABLE IF NOT EXISTS article_import_items (
+    import_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    title TEXT,
+    tags TEXT, -- JSON array
+    status TEXT NOT NULL DEFAULT 'unread',
+    favorite BOOLEAN NOT NULL DEFAULT FALSE,
+    saved DATETIME,
+    state TEXT NOT NULL DEFAULT 'pending', -- pending, done or failed
+    article_id INTEGER, -- not a foreign key: removing an article must not re-import it
+    error TEXT,
+    PRIMARY KEY (import_id, position),
+    FOREIGN KEY (import_id) REFERENCES article_imports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_import_items_state ON article_import_items(import_id, state);
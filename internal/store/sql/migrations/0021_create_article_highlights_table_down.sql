@@ -0,0 +1,4 @@
+-- Drop article highlights and the link to their note
+ALTER TABLE articles DROP COLUMN note_id;
+DROP INDEX IF EXISTS idx_article_highlights_article;
+DROP TABLE IF EXISTS article_highlights;
@@ -0,0 +1,16 @@
+-- Quotes highlighted in saved articles, located by byte offsets into the article's markdown
+CREATE TABLE IF NOT EXISTS article_highlights (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    quote TEXT NOT NULL,
+    note TEXT,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (article_id) REFERENCES articles(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_highlights_article ON article_highlights(article_id);
+
+-- Note the highlights were exported to; not a foreign key so the column can be dropped again
+ALTER TABLE articles ADD COLUMN note_id INTEGER;
@@ -0,0 +1,2 @@
+-- Remove citation data from articles
+ALTER TABLE articles DROP COLUMN citation;
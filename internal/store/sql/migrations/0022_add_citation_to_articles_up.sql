@@ -0,0 +1,2 @@
+-- Bibliographic record of academic papers as CSL-JSON, empty for web pages
+ALTER TABLE articles ADD COLUMN citation TEXT;
@@ -0,0 +1,7 @@
+-- Remove link check results from articles
+ALTER TABLE articles DROP COLUMN archive_url;
+ALTER TABLE articles DROP COLUMN link_checked;
+ALTER TABLE articles DROP COLUMN redirect_status;
+ALTER TABLE articles DROP COLUMN redirect_url;
+ALTER TABLE articles DROP COLUMN link_error;
+ALTER TABLE articles DROP COLUMN link_status;
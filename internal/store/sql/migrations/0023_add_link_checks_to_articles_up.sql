@@ -0,0 +1,7 @@
+-- Results of the last link check of each article
+ALTER TABLE articles ADD COLUMN link_status INTEGER NOT NULL DEFAULT 0; -- HTTP status, 0 when unreachable
+ALTER TABLE articles ADD COLUMN link_error TEXT;
+ALTER TABLE articles ADD COLUMN redirect_url TEXT;
+ALTER TABLE articles ADD COLUMN redirect_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE articles ADD COLUMN link_checked DATETIME;
+ALTER TABLE articles ADD COLUMN archive_url TEXT; -- archived copy the content was fetched from
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+	"github.com/stormlightlabs/noteleaf/internal/utils"
+)
+
+// ArticleRecord adapts models.Article to work with DataList
+type ArticleRecord struct {
+	*models.Article
+}
+
+func (a *ArticleRecord) GetField(name string) any {
+	switch name {
+	case "id":
+		return a.ID
+	case "url":
+		return a.URL
+	case "title":
+		return a.Title
+	case "author":
+		return a.Author
+	case "date":
+		return a.Date
+	case "status":
+		return a.Status
+	case "favorite":
+		return a.Favorite
+	case "tags":
+		return a.Tags
+	case "progress":
+		return a.Progress
+	case "word_count":
+		return a.WordCount
+	case "reading_time":
+		return a.ReadingTime
+	case "created":
+		return a.Created
+	case "modified":
+		return a.Modified
+	default:
+		return ""
+	}
+}
+
+func (a *ArticleRecord) GetTitle() string {
+	if a.Favorite {
+		return "★ " + a.Title
+	}
+	return a.Title
+}
+
+func (a *ArticleRecord) GetDescription() string {
+	var parts []string
+
+	if a.Author != "" {
+		parts = append(parts, "by "+a.Author)
+	}
+
+	if a.Status != "" {
+		parts = append(parts, utils.Titlecase(a.Status))
+	}
+
+	if a.ReadingTime > 0 {
+		parts = append(parts, fmt.Sprintf("%d min", a.ReadingTime))
+	}
+
+	if a.Progress > 0 && a.Progress < 100 {
+		parts = append(parts, fmt.Sprintf("%d%%", a.Progress))
+	}
+
+	if len(a.Tags) > 0 {
+		parts = append(parts, strings.Join(a.Tags, ", "))
+	}
+
+	return strings.Join(parts, " • ")
+}
+
+func (a *ArticleRecord) GetFilterValue() string {
+	searchable := []string{a.Title, a.Author, a.URL}
+	searchable = append(searchable, a.Tags...)
+	return strings.Join(searchable, " ")
+}
+
+// ArticleDataSource adapts ArticleRepository to work with DataList
+type ArticleDataSource struct {
+	repo utils.TestArticleRepository
+	opts repo.ArticleListOptions
+}
+
+func (a *ArticleDataSource) Load(ctx context.Context, opts ListOptions) ([]ListItem, error) {
+	repoOpts := a.opts
+
+	if opts.Search != "" {
+		repoOpts.Title = opts.Search
+	}
+
+	if opts.Limit > 0 {
+		repoOpts.Limit = opts.Limit
+	}
+
+	articles, err := a.repo.List(ctx, &repoOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ListItem, len(articles))
+	for i, article := range articles {
+		items[i] = &ArticleRecord{Article: article}
+	}
+
+	return items, nil
+}
+
+func (a *ArticleDataSource) Count(ctx context.Context, opts ListOptions) (int, error) {
+	items, err := a.Load(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+func (a *ArticleDataSource) Search(ctx context.Context, query string, opts ListOptions) ([]ListItem, error) {
+	opts.Search = query
+	return a.Load(ctx, opts)
+}
+
+// formatArticleForView formats an article's reading state for detailed viewing
+func formatArticleForView(article *models.Article) string {
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("# %s\n\n", article.Title))
+
+	if article.Author != "" {
+		content.WriteString(fmt.Sprintf("**Author:** %s\n", article.Author))
+	}
+
+	if article.Date != "" {
+		content.WriteString(fmt.Sprintf("**Date:** %s\n", article.Date))
+	}
+
+	content.WriteString(fmt.Sprintf("**URL:** %s\n", article.URL))
+
+	if article.Status != "" {
+		content.WriteString(fmt.Sprintf("**Status:** %s\n", utils.Titlecase(article.Status)))
+	}
+
+	if article.Favorite {
+		content.WriteString("**Favourite:** yes\n")
+	}
+
+	if article.Progress > 0 {
+		content.WriteString(fmt.Sprintf("**Progress:** %d%%\n", article.Progress))
+	}
+
+	if article.WordCount > 0 {
+		content.WriteString(fmt.Sprintf("**Length:** %d words, about %d min\n", article.WordCount, article.ReadingTime))
+	}
+
+	if len(article.Tags) > 0 {
+		content.WriteString(fmt.Sprintf("**Tags:** %s\n", strings.Join(article.Tags, ", ")))
+	}
+
+	content.WriteString(fmt.Sprintf("**Added:** %s\n", article.Created.Format("2006-01-02 15:04")))
+
+	return content.String()
+}
+
+// NewArticleDataList creates a new DataList for browsing the reading queue
+func NewArticleDataList(repo utils.TestArticleRepository, opts DataListOptions, listOpts repo.ArticleListOptions) *DataList {
+	if opts.Title == "" {
+		opts.Title = "Articles"
+	}
+
+	opts.ShowSearch = true
+	opts.Searchable = true
+
+	if opts.ViewHandler == nil {
+		opts.ViewHandler = func(item ListItem) string {
+			if articleRecord, ok := item.(*ArticleRecord); ok {
+				return formatArticleForView(articleRecord.Article)
+			}
+			return "Unable to display article"
+		}
+	}
+
+	source := &ArticleDataSource{
+		repo: repo,
+		opts: listOpts,
+	}
+
+	return NewDataList(source, opts)
+}
+
+// NewArticleListFromList creates an article list using DataList, filtered by listOpts
+func NewArticleListFromList(repo utils.TestArticleRepository, output io.Writer, input io.Reader, static bool, listOpts repo.ArticleListOptions) *DataList {
+	opts := DataListOptions{
+		Output: output,
+		Input:  input,
+		Static: static,
+		Title:  "Reading Queue",
+	}
+	return NewArticleDataList(repo, opts, listOpts)
+}
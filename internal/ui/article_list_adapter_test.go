@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/noteleaf/internal/models"
+	"github.com/stormlightlabs/noteleaf/internal/repo"
+)
+
+type mockArticleRepository struct {
+	articles []*models.Article
+	err      error
+}
+
+func (m *mockArticleRepository) List(ctx context.Context, opts *repo.ArticleListOptions) ([]*models.Article, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var filtered []*models.Article
+	for _, article := range m.articles {
+		if opts.Status != "" && article.Status != opts.Status {
+			continue
+		}
+		if opts.Title != "" && !strings.Contains(strings.ToLower(article.Title), strings.ToLower(opts.Title)) {
+			continue
+		}
+		if opts.Favorite && !article.Favorite {
+			continue
+		}
+		if !slices.ContainsFunc(opts.Tags, func(tag string) bool { return !slices.Contains(article.Tags, tag) }) {
+			filtered = append(filtered, article)
+		}
+	}
+	return filtered, nil
+}
+
+func TestArticleAdapter(t *testing.T) {
+	now := time.Now()
+	article := &models.Article{
+		ID:          1,
+		URL:         "https://example.com/go-generics",
+		Title:       "Go Generics",
+		Author:      "Ada",
+		Status:      models.ArticleReading,
+		Favorite:    true,
+		Tags:        []string{"go", "language"},
+		Progress:    40,
+		WordCount:   1840,
+		ReadingTime: 8,
+		Created:     now,
+	}
+
+	t.Run("ArticleRecord", func(t *testing.T) {
+		record := &ArticleRecord{Article: article}
+
+		if got := record.GetField("status"); got != models.ArticleReading {
+			t.Errorf("expected status field, got %v", got)
+		}
+		if got := record.GetField("reading_time"); got != 8 {
+			t.Errorf("expected reading_time field, got %v", got)
+		}
+		if got := record.GetField("unknown"); got != "" {
+			t.Errorf("expected empty string for unknown field, got %v", got)
+		}
+		if got := record.GetTitle(); got != "★ Go Generics" {
+			t.Errorf("expected favourites to be starred, got %q", got)
+		}
+
+		description := record.GetDescription()
+		for _, want := range []string{"by Ada", "Reading", "8 min", "40%", "go, language"} {
+			if !strings.Contains(description, want) {
+				t.Errorf("expected description to contain %q, got %q", want, description)
+			}
+		}
+		if !strings.Contains(record.GetFilterValue(), "language") {
+			t.Error("expected tags to be searchable")
+		}
+	})
+
+	t.Run("ArticleDataSource", func(t *testing.T) {
+		mock := &mockArticleRepository{articles: []*models.Article{
+			article,
+			{ID: 2, Title: "Rust Traits", Status: models.ArticleUnread, Tags: []string{"rust"}},
+			{ID: 3, Title: "Go Modules", Status: models.ArticleUnread, Tags: []string{"go"}},
+		}}
+
+		source := &ArticleDataSource{repo: mock, opts: repo.ArticleListOptions{Status: models.ArticleUnread, Tags: []string{"go"}}}
+		items, err := source.Load(context.Background(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if len(items) != 1 || items[0].GetTitle() != "Go Modules" {
+			t.Errorf("expected only the unread go article, got %v", items)
+		}
+
+		source = &ArticleDataSource{repo: mock}
+		items, err = source.Search(context.Background(), "rust", ListOptions{})
+		if err != nil || len(items) != 1 {
+			t.Errorf("expected search to match one article, got %d (%v)", len(items), err)
+		}
+
+		count, err := source.Count(context.Background(), ListOptions{})
+		if err != nil || count != 3 {
+			t.Errorf("expected 3 articles, got %d (%v)", count, err)
+		}
+
+		source = &ArticleDataSource{repo: &mockArticleRepository{err: fmt.Errorf("database error")}}
+		if _, err := source.Load(context.Background(), ListOptions{}); err == nil {
+			t.Error("expected Load to return the repository error")
+		}
+	})
+
+	t.Run("NewArticleListFromList", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		list := NewArticleListFromList(&mockArticleRepository{articles: []*models.Article{article}}, output, strings.NewReader("q\n"), true, repo.ArticleListOptions{})
+
+		if err := list.Browse(context.Background()); err != nil {
+			t.Fatalf("Browse() failed: %v", err)
+		}
+		for _, want := range []string{"Reading Queue", "Go Generics"} {
+			if !strings.Contains(output.String(), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output.String())
+			}
+		}
+	})
+
+	t.Run("Format Article for View", func(t *testing.T) {
+		result := formatArticleForView(article)
+		for _, want := range []string{"# Go Generics", "**Status:** Reading", "**Favourite:** yes", "**Progress:** 40%", "1840 words, about 8 min", "**Tags:** go, language"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected view to contain %q, got:\n%s", want, result)
+			}
+		}
+	})
+}
@@ -123,4 +123,6 @@ var (
 
 	AdditionStyle = newStyle().Foreground(lipgloss.Color(Pickle.Hex())) // #00A475 - Green
 	DeletionStyle = newStyle().Foreground(lipgloss.Color(Pom.Hex()))    // #AB2454 - Red
+
+	HighlightStyle = newStyle().Foreground(lipgloss.Color(ColorWarning)).Underline(true) // Orange, underlined (article highlights)
 )
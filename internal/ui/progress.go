@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+// ProgressBar renders a one line progress bar for long running commands. On a terminal the line
+// is redrawn as work completes; other writers only get the final state from [ProgressBar.Finish].
+type ProgressBar struct {
+	out   io.Writer
+	label string
+	total int
+	done  int
+	live  bool
+}
+
+// NewProgressBar creates a progress bar for total units of work written to out
+func NewProgressBar(out io.Writer, label string, total int) *ProgressBar {
+	live := false
+	if f, ok := out.(*os.File); ok {
+		live = term.IsTerminal(int(f.Fd()))
+	}
+	return &ProgressBar{out: out, label: label, total: total, live: live}
+}
+
+// Add records n more units of completed work and redraws the bar
+func (p *ProgressBar) Add(n int) {
+	p.done = min(p.done+n, p.total)
+	if p.live {
+		fmt.Fprintf(p.out, "\r%s", p.View())
+	}
+}
+
+// Done returns the units of work completed so far
+func (p *ProgressBar) Done() int { return p.done }
+
+// Clear erases the bar so a message can be printed, for use before [ProgressBar.Add] redraws it
+func (p *ProgressBar) Clear() {
+	if p.live {
+		fmt.Fprint(p.out, "\r\x1b[2K")
+	}
+}
+
+// Finish draws the bar one last time and ends its line
+func (p *ProgressBar) Finish() {
+	if p.live {
+		fmt.Fprintf(p.out, "\r%s\n", p.View())
+		return
+	}
+	fmt.Fprintln(p.out, p.View())
+}
+
+// View renders the bar, e.g. "Importing [██████░░░░] 120/300 40%"
+func (p *ProgressBar) View() string {
+	percent := 100
+	if p.total > 0 {
+		percent = p.done * 100 / p.total
+	}
+	filled := percent * progressBarWidth / 100
+
+	bar := PrimaryStyle.Render(strings.Repeat("█", filled)) + MutedStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+	return fmt.Sprintf("%s [%s] %d/%d %3d%%", p.label, bar, p.done, p.total, percent)
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressBar(t *testing.T) {
+	t.Run("renders done, total and percentage", func(t *testing.T) {
+		bar := NewProgressBar(&bytes.Buffer{}, "Importing", 300)
+		bar.Add(120)
+
+		view := stripAnsi(bar.View())
+		if !strings.HasPrefix(view, "Importing [") || !strings.HasSuffix(view, "] 120/300  40%") {
+			t.Errorf("unexpected view %q", view)
+		}
+		if filled := strings.Count(view, "█"); filled != 12 {
+			t.Errorf("expected 12 of 30 cells filled, got %d", filled)
+		}
+		if bar.Done() != 120 {
+			t.Errorf("expected 120 done, got %d", bar.Done())
+		}
+	})
+
+	t.Run("caps progress at the total", func(t *testing.T) {
+		bar := NewProgressBar(&bytes.Buffer{}, "Work", 2)
+		bar.Add(5)
+		if bar.Done() != 2 || !strings.HasSuffix(stripAnsi(bar.View()), "2/2 100%") {
+			t.Errorf("expected a full bar, got %q", stripAnsi(bar.View()))
+		}
+	})
+
+	t.Run("writes only the final state when not a terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		bar := NewProgressBar(&buf, "Work", 4)
+		bar.Add(1)
+		bar.Clear()
+		bar.Add(1)
+		if buf.Len() != 0 {
+			t.Errorf("expected no output before Finish, got %q", buf.String())
+		}
+
+		bar.Finish()
+		if output := stripAnsi(buf.String()); strings.Count(output, "\n") != 1 || !strings.Contains(output, "2/4  50%") {
+			t.Errorf("expected one final line, got %q", output)
+		}
+	})
+
+	t.Run("empty work is complete", func(t *testing.T) {
+		bar := NewProgressBar(&bytes.Buffer{}, "Work", 0)
+		if !strings.HasSuffix(stripAnsi(bar.View()), "0/0 100%") {
+			t.Errorf("unexpected view %q", stripAnsi(bar.View()))
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiffOp identifies how a line changed between two texts
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffLines computes the shortest edit script between a and b using Myers' algorithm
+func DiffLines(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	var done bool
+	for d := 0; d <= max && !done; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+	}
+
+	var script []DiffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			script = append(script, DiffLine{Op: DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			script = append(script, DiffLine{Op: DiffInsert, Text: b[y-1]})
+		} else {
+			script = append(script, DiffLine{Op: DiffDelete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		script = append(script, DiffLine{Op: DiffEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(script)-1; i < j; i, j = i+1, j-1 {
+		script[i], script[j] = script[j], script[i]
+	}
+	return script
+}
+
+// UnifiedDiff renders the difference between two texts in unified diff format.
+//
+// It returns an empty string when the texts are identical.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+
+	script := DiffLines(splitLines(from), splitLines(to))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// line numbers (1-based) of each script entry in the old and new text
+	oldLine, newLine := make([]int, len(script)), make([]int, len(script))
+	o, n := 1, 1
+	for i, line := range script {
+		oldLine[i], newLine[i] = o, n
+		switch line.Op {
+		case DiffEqual:
+			o++
+			n++
+		case DiffDelete:
+			o++
+		case DiffInsert:
+			n++
+		}
+	}
+
+	for i := 0; i < len(script); {
+		if script[i].Op == DiffEqual {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(script) {
+			if script[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(script) && script[run].Op == DiffEqual {
+				run++
+			}
+			if run == len(script) || run-end > 2*context {
+				end = min(end+context, len(script))
+				break
+			}
+			end = run
+		}
+
+		var oldCount, newCount int
+		for _, line := range script[start:end] {
+			if line.Op != DiffInsert {
+				oldCount++
+			}
+			if line.Op != DiffDelete {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine[start], oldCount), hunkRange(newLine[start], newCount))
+		for _, line := range script[start:end] {
+			switch line.Op {
+			case DiffEqual:
+				out.WriteString(" " + line.Text + "\n")
+			case DiffDelete:
+				out.WriteString("-" + line.Text + "\n")
+			case DiffInsert:
+				out.WriteString("+" + line.Text + "\n")
+			}
+		}
+		i = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Merge3 performs a line-based three-way merge of ours and theirs against their common ancestor base.
+//
+// Regions changed on only one side take that side's lines. Regions changed on both sides
+// differently are kept with conflict markers labelled ourName and theirName, and the
+// returned flag reports whether any such conflict remains.
+func Merge3(base, ours, theirs, ourName, theirName string) (string, bool) {
+	b, o, t := splitLines(base), splitLines(ours), splitLines(theirs)
+	mo, mt := matchLines(b, o), matchLines(b, t)
+
+	var out []string
+	conflicted := false
+	emit := func(bc, oc, tc []string) {
+		switch {
+		case slices.Equal(oc, bc):
+			out = append(out, tc...)
+		case slices.Equal(tc, bc), slices.Equal(oc, tc):
+			out = append(out, oc...)
+		default:
+			conflicted = true
+			out = append(out, "<<<<<<< "+ourName)
+			out = append(out, oc...)
+			out = append(out, "=======")
+			out = append(out, tc...)
+			out = append(out, ">>>>>>> "+theirName)
+		}
+	}
+
+	i, oi, ti := 0, 0, 0
+	for i < len(b) || oi < len(o) || ti < len(t) {
+		k := 0
+		for i+k < len(b) && mo[i+k] == oi+k && mt[i+k] == ti+k {
+			k++
+		}
+		if k > 0 {
+			out = append(out, b[i:i+k]...)
+			i, oi, ti = i+k, oi+k, ti+k
+			continue
+		}
+
+		j := i
+		for j < len(b) && (mo[j] < 0 || mt[j] < 0) {
+			j++
+		}
+		if j == len(b) {
+			emit(b[i:], o[oi:], t[ti:])
+			break
+		}
+		emit(b[i:j], o[oi:mo[j]], t[ti:mt[j]])
+		i, oi, ti = j, mo[j], mt[j]
+	}
+
+	if len(out) == 0 {
+		return "", conflicted
+	}
+	return strings.Join(out, "\n") + "\n", conflicted
+}
+
+// matchLines maps each line of a to the index of the same line in b, or -1 when it was changed
+func matchLines(a, b []string) []int {
+	matches := make([]int, len(a))
+	ai, bi := 0, 0
+	for _, line := range DiffLines(a, b) {
+		switch line.Op {
+		case DiffEqual:
+			matches[ai] = bi
+			ai++
+			bi++
+		case DiffDelete:
+			matches[ai] = -1
+			ai++
+		case DiffInsert:
+			bi++
+		}
+	}
+	return matches
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	apply := func(script []DiffLine) (from, to []string) {
+		for _, line := range script {
+			if line.Op != DiffInsert {
+				from = append(from, line.Text)
+			}
+			if line.Op != DiffDelete {
+				to = append(to, line.Text)
+			}
+		}
+		return from, to
+	}
+
+	tests := []struct {
+		name    string
+		a, b    []string
+		changes int
+	}{
+		{name: "empty", a: nil, b: nil, changes: 0},
+		{name: "insert into empty", a: nil, b: []string{"a", "b"}, changes: 2},
+		{name: "delete all", a: []string{"a", "b"}, b: nil, changes: 2},
+		{name: "identical", a: []string{"a", "b", "c"}, b: []string{"a", "b", "c"}, changes: 0},
+		{name: "replace middle", a: []string{"a", "b", "c"}, b: []string{"a", "x", "c"}, changes: 2},
+		{name: "classic", a: strings.Split("ABCABBA", ""), b: strings.Split("CBABAC", ""), changes: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := DiffLines(tt.a, tt.b)
+			from, to := apply(script)
+
+			if strings.Join(from, "\n") != strings.Join(tt.a, "\n") {
+				t.Errorf("script does not reproduce old text: %v", from)
+			}
+			if strings.Join(to, "\n") != strings.Join(tt.b, "\n") {
+				t.Errorf("script does not reproduce new text: %v", to)
+			}
+
+			changes := 0
+			for _, line := range script {
+				if line.Op != DiffEqual {
+					changes++
+				}
+			}
+			if changes != tt.changes {
+				t.Errorf("expected %d changes, got %d", tt.changes, changes)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("identical texts", func(t *testing.T) {
+		if got := UnifiedDiff("a", "b", "same\n", "same\n", 3); got != "" {
+			t.Errorf("expected empty diff, got %q", got)
+		}
+	})
+
+	t.Run("single hunk", func(t *testing.T) {
+		from := "one\ntwo\nthree\n"
+		to := "one\n2\nthree\nfour\n"
+		expected := "--- rev 1\n+++ rev 2\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four\n"
+
+		if got := UnifiedDiff("rev 1", "rev 2", from, to, 3); got != expected {
+			t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, expected)
+		}
+	})
+
+	t.Run("separate hunks", func(t *testing.T) {
+		var lines []string
+		for i := range 20 {
+			lines = append(lines, strings.Repeat("x", i+1))
+		}
+		from := strings.Join(lines, "\n")
+		changed := append([]string{}, lines...)
+		changed[1] = "changed start"
+		changed[18] = "changed end"
+		to := strings.Join(changed, "\n")
+
+		got := UnifiedDiff("a", "b", from, to, 2)
+		if strings.Count(got, "@@ -") != 2 {
+			t.Fatalf("expected two hunks, got:\n%s", got)
+		}
+		if !strings.Contains(got, "@@ -1,4 +1,4 @@") {
+			t.Errorf("unexpected first hunk header:\n%s", got)
+		}
+		if !strings.Contains(got, "@@ -17,4 +17,4 @@") {
+			t.Errorf("unexpected second hunk header:\n%s", got)
+		}
+	})
+
+	t.Run("from empty", func(t *testing.T) {
+		got := UnifiedDiff("a", "b", "", "new\n", 3)
+		if !strings.Contains(got, "@@ -0,0 +1 @@\n+new\n") {
+			t.Errorf("unexpected diff:\n%s", got)
+		}
+	})
+}
+
+func TestMerge3(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name, ours, theirs, want string
+		conflict                 bool
+	}{
+		{name: "no changes", ours: base, theirs: base, want: base},
+		{name: "ours only", ours: "one\n2\nthree\nfour\nfive\n", theirs: base, want: "one\n2\nthree\nfour\nfive\n"},
+		{name: "theirs only", ours: base, theirs: "one\ntwo\nthree\nfour\nfive\nsix\n", want: "one\ntwo\nthree\nfour\nfive\nsix\n"},
+		{
+			name:   "separate regions",
+			ours:   "one\n2\nthree\nfour\nfive\n",
+			theirs: "one\ntwo\nthree\nfour\n5\n",
+			want:   "one\n2\nthree\nfour\n5\n",
+		},
+		{name: "same change", ours: "one\n2\nthree\nfour\nfive\n", theirs: "one\n2\nthree\nfour\nfive\n", want: "one\n2\nthree\nfour\nfive\n"},
+		{name: "both delete", ours: "one\nthree\nfour\nfive\n", theirs: "one\nthree\nfour\nfive\n", want: "one\nthree\nfour\nfive\n"},
+		{
+			name:     "overlapping change",
+			ours:     "one\nours\nthree\nfour\nfive\n",
+			theirs:   "one\ntheirs\nthree\nfour\nfive\n",
+			want:     "one\n<<<<<<< local\nours\n=======\ntheirs\n>>>>>>> remote\nthree\nfour\nfive\n",
+			conflict: true,
+		},
+		{
+			name:     "conflicting appends",
+			ours:     base + "a\n",
+			theirs:   base + "b\n",
+			want:     base + "<<<<<<< local\na\n=======\nb\n>>>>>>> remote\n",
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := Merge3(base, tt.ours, tt.theirs, "local", "remote")
+			if got != tt.want {
+				t.Errorf("unexpected merge:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if conflict != tt.conflict {
+				t.Errorf("expected conflict %v, got %v", tt.conflict, conflict)
+			}
+		})
+	}
+
+	t.Run("empty base", func(t *testing.T) {
+		got, conflict := Merge3("", "", "new\n", "local", "remote")
+		if got != "new\n" || conflict {
+			t.Errorf("unexpected merge %q (conflict %v)", got, conflict)
+		}
+	})
+}
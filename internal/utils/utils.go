@@ -65,6 +65,11 @@ type TestBookRepository interface {
 	List(ctx context.Context, options repo.BookListOptions) ([]*models.Book, error)
 }
 
+// TestArticleRepository interface for dependency injection in tests
+type TestArticleRepository interface {
+	List(ctx context.Context, opts *repo.ArticleListOptions) ([]*models.Article, error)
+}
+
 // TestNoteRepository interface for dependency injection in tests.
 type TestNoteRepository interface {
 	List(ctx context.Context, options repo.NoteListOptions) ([]*models.Note, error)
@@ -11,11 +11,12 @@ func NewFetchCommand() *cobra.Command {
 		Short: "Fetch remote resources",
 		Long: `Fetch and synchronize remote resources from GitHub repositories.
 
-Includes commands for fetching lexicons, schemas, and other data files.`,
+Includes commands for fetching lexicons, article parsing rules, schemas, and other data files.`,
 	}
 
 	cmd.AddCommand(NewGHRepoCommand())
 	cmd.AddCommand(NewLexiconsCommand())
+	cmd.AddCommand(NewSiteConfigCommand())
 
 	return cmd
 }
@@ -0,0 +1,710 @@
+//go:build !prod
+
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bluesky-social/indigo/atproto/lexicon"
+	"github.com/spf13/cobra"
+)
+
+// NewLexgenCommand creates a command that generates Go types from Leaflet lexicons
+func NewLexgenCommand() *cobra.Command {
+	var input string
+	var output string
+	var prefix string
+
+	cmd := &cobra.Command{
+		Use:   "lexgen",
+		Short: "Generate Go types and validators from Leaflet lexicons",
+		Long: `Generates Go structs, $type-dispatched union types and Validate methods
+from lexicon JSON files, as fetched by 'noteleaf tools fetch lexicons'.
+
+The generated file belongs to the internal/public package: each type is
+prefixed with Lex so it sits alongside the hand-written types, and every
+record registers a decoder. public.LexiconRecord uses it to encode the records
+noteleaf writes, so their shape comes from the lexicon, and
+public.ValidateRecord (and so 'noteleaf pub post --validate') uses it to
+enforce required fields, string lengths and graphemes, formats, enums, integer
+ranges and array sizes.
+
+Refs to lexicons outside the input directory are kept as raw JSON, except
+com.atproto.repo.strongRef which maps to public.StrongRef.`,
+		Example: `  # Regenerate after fetching the latest lexicons
+  noteleaf tools fetch lexicons
+  noteleaf tools lexgen
+
+  # Generate from a custom directory
+  noteleaf tools lexgen --input ./tmp/lexicons --output ./tmp/lexicon_gen.go`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := generateLexicons(input, prefix)
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			if err := os.WriteFile(output, src, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Generated %s from %s\n", output, input)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&input, "input", "lexdocs/leaflet/", "Directory containing lexicon JSON files")
+	cmd.Flags().StringVar(&output, "output", "internal/public/lexicon_gen.go", "Generated Go file")
+	cmd.Flags().StringVar(&prefix, "prefix", "pub.leaflet.", "NSID prefix dropped from generated type names")
+	return cmd
+}
+
+// lexgenTypePrefix keeps generated names clear of the hand-written types in internal/public
+const lexgenTypePrefix = "Lex"
+
+// lexgenExternal maps refs to lexicons outside the input to existing internal/public types
+var lexgenExternal = map[string]string{
+	"com.atproto.repo.strongRef": "StrongRef",
+}
+
+type lexgen struct {
+	input   string
+	prefix  string
+	defs    map[string]lexicon.SchemaDef // keyed by full ref: nsid for main, nsid#name otherwise
+	names   map[string]string            // full ref to generated type name
+	unions  map[string]bool              // union type names already emitted
+	buf     bytes.Buffer
+	tail    bytes.Buffer // union types, written after the structs that use them
+	usesFmt bool
+}
+
+// generateLexicons loads every lexicon under input and returns formatted Go source
+func generateLexicons(input, prefix string) ([]byte, error) {
+	g := &lexgen{
+		input:  input,
+		prefix: prefix,
+		defs:   map[string]lexicon.SchemaDef{},
+		names:  map[string]string{},
+		unions: map[string]bool{},
+	}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	if len(g.defs) == 0 {
+		return nil, fmt.Errorf("no lexicons found in %s - run 'noteleaf tools fetch lexicons' first", input)
+	}
+	if err := g.assignNames(); err != nil {
+		return nil, err
+	}
+	return g.generate()
+}
+
+func (g *lexgen) load() error {
+	return filepath.WalkDir(g.input, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var sf lexicon.SchemaFile
+		if err := json.Unmarshal(data, &sf); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if err := sf.FinishParse(); err != nil {
+			return fmt.Errorf("invalid lexicon %s: %w", path, err)
+		}
+
+		for name, def := range sf.Defs {
+			ref := sf.ID
+			if name != "main" {
+				ref += "#" + name
+			}
+			def.Inner = qualify(sf.ID, def.Inner)
+			g.defs[ref] = def
+		}
+		return nil
+	})
+}
+
+// qualify rewrites local refs such as #block in def to full refs against the lexicon's NSID
+func qualify(nsid string, def any) any {
+	switch def := def.(type) {
+	case lexicon.SchemaRecord:
+		def.Record = qualify(nsid, def.Record).(lexicon.SchemaObject)
+		return def
+	case lexicon.SchemaObject:
+		for name, prop := range def.Properties {
+			prop.Inner = qualify(nsid, prop.Inner)
+			def.Properties[name] = prop
+		}
+		return def
+	case lexicon.SchemaArray:
+		def.Items.Inner = qualify(nsid, def.Items.Inner)
+		return def
+	case lexicon.SchemaRef:
+		def.Ref = qualifyRef(nsid, def.Ref)
+		return def
+	case lexicon.SchemaUnion:
+		refs := make([]string, len(def.Refs))
+		for i, ref := range def.Refs {
+			refs[i] = qualifyRef(nsid, ref)
+		}
+		def.Refs = refs
+		return def
+	}
+	return def
+}
+
+func qualifyRef(nsid, ref string) string {
+	if strings.HasPrefix(ref, "#") {
+		ref = nsid + ref
+	}
+	return strings.TrimSuffix(ref, "#main")
+}
+
+// assignNames gives every record and object def a Go type name derived from its NSID
+func (g *lexgen) assignNames() error {
+	seen := map[string]string{}
+	for _, ref := range g.sortedRefs() {
+		switch g.defs[ref].Inner.(type) {
+		case lexicon.SchemaRecord, lexicon.SchemaObject, lexicon.SchemaToken:
+		default:
+			continue
+		}
+
+		name := lexgenTypePrefix + goName(strings.TrimPrefix(ref, g.prefix))
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("%s and %s both generate type %s", other, ref, name)
+		}
+		seen[name] = ref
+		g.names[ref] = name
+	}
+	return nil
+}
+
+func (g *lexgen) sortedRefs() []string {
+	refs := make([]string, 0, len(g.defs))
+	for ref := range g.defs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func (g *lexgen) generate() ([]byte, error) {
+	var tokens, records []string
+	for _, ref := range g.sortedRefs() {
+		switch def := g.defs[ref].Inner.(type) {
+		case lexicon.SchemaToken:
+			tokens = append(tokens, ref)
+		case lexicon.SchemaRecord:
+			records = append(records, ref)
+			if err := g.writeObject(ref, def.Record, def.Description); err != nil {
+				return nil, err
+			}
+		case lexicon.SchemaObject:
+			if err := g.writeObject(ref, def, def.Description); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by noteleaf tools lexgen from %s; DO NOT EDIT.\n\n", filepath.ToSlash(g.input))
+	out.WriteString("package public\n\nimport (\n\t\"encoding/json\"\n")
+	if g.usesFmt {
+		out.WriteString("\t\"fmt\"\n")
+	}
+	out.WriteString(")\n\n")
+
+	if len(tokens) > 0 {
+		out.WriteString("const (\n")
+		for _, ref := range tokens {
+			fmt.Fprintf(&out, "\t%s = %q\n", g.names[ref], ref)
+		}
+		out.WriteString(")\n\n")
+	}
+
+	if len(records) > 0 {
+		out.WriteString("func init() {\n")
+		for _, ref := range records {
+			fmt.Fprintf(&out, "\tregisterSchema(%q, func(data []byte) (lexRecord, error) {\n", ref)
+			fmt.Fprintf(&out, "\t\tvar v %s\n", g.names[ref])
+			out.WriteString("\t\terr := json.Unmarshal(data, &v)\n\t\treturn &v, err\n\t})\n")
+		}
+		out.WriteString("}\n\n")
+	}
+
+	out.Write(g.buf.Bytes())
+	out.Write(g.tail.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// writeObject emits the struct, JSON methods and Validate method for a record or object def
+func (g *lexgen) writeObject(ref string, obj lexicon.SchemaObject, description *string) error {
+	name := g.names[ref]
+	w := &g.buf
+
+	fmt.Fprintf(w, "// %s is generated from %s\n", name, ref)
+	writeDescription(w, "", description)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	w.WriteString("\tLexiconTypeID string `json:\"$type,omitempty\"`\n")
+
+	props := sortedKeys(obj.Properties)
+	required := map[string]bool{}
+	for _, prop := range obj.Required {
+		required[prop] = true
+	}
+	nullable := map[string]bool{}
+	for _, prop := range obj.Nullable {
+		nullable[prop] = true
+	}
+
+	var mustHave []string
+	for _, prop := range props {
+		def := obj.Properties[prop]
+		optional := !required[prop] || nullable[prop]
+		if required[prop] && !nullable[prop] {
+			mustHave = append(mustHave, strconv.Quote(prop))
+		}
+
+		typ, err := g.goType(name, prop, def.Inner, optional)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", ref, prop, err)
+		}
+		tag := prop
+		if optional {
+			tag += ",omitempty"
+		}
+		writeDescription(w, "\t", schemaDescription(def.Inner))
+		fmt.Fprintf(w, "\t%s %s `json:%q`\n", fieldName(prop), typ, tag)
+	}
+	w.WriteString("}\n\n")
+
+	fmt.Fprintf(w, "func (v %s) MarshalJSON() ([]byte, error) {\n", name)
+	fmt.Fprintf(w, "\ttype raw %s\n\tr := raw(v)\n\tr.LexiconTypeID = %q\n\treturn json.Marshal(r)\n}\n\n", name, ref)
+
+	fmt.Fprintf(w, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	if len(mustHave) > 0 {
+		fmt.Fprintf(w, "\tif err := checkRequired(data, %q, %s); err != nil {\n\t\treturn err\n\t}\n", ref, strings.Join(mustHave, ", "))
+	}
+	fmt.Fprintf(w, "\ttype raw %s\n\treturn json.Unmarshal(data, (*raw)(v))\n}\n\n", name)
+
+	fmt.Fprintf(w, "// Validate checks v against the constraints of %s\n", ref)
+	fmt.Fprintf(w, "func (v *%s) Validate() error {\n", name)
+	for _, prop := range props {
+		def := obj.Properties[prop].Inner
+		if !g.needsCheck(def, 0) {
+			continue
+		}
+		expr := "v." + fieldName(prop)
+		if g.isPointer(def, !required[prop] || nullable[prop]) {
+			fmt.Fprintf(w, "if %s != nil {\n", expr)
+			g.writeCheck(w, expr, true, strconv.Quote(prop), def, 0)
+			w.WriteString("}\n")
+			continue
+		}
+		g.writeCheck(w, expr, false, strconv.Quote(prop), def, 0)
+	}
+	w.WriteString("\treturn nil\n}\n\n")
+	return nil
+}
+
+// goType returns the Go type for a property of owner, a pointer when optional scalars or structs
+func (g *lexgen) goType(owner, prop string, def any, optional bool) (string, error) {
+	ptr := ""
+	if optional {
+		ptr = "*"
+	}
+
+	switch def := def.(type) {
+	case lexicon.SchemaString:
+		return ptr + "string", nil
+	case lexicon.SchemaInteger:
+		return ptr + "int64", nil
+	case lexicon.SchemaBoolean:
+		return ptr + "bool", nil
+	case lexicon.SchemaBlob:
+		return ptr + "Blob", nil
+	case lexicon.SchemaCIDLink:
+		return ptr + "CID", nil
+	case lexicon.SchemaBytes:
+		return "[]byte", nil
+	case lexicon.SchemaUnknown:
+		return "json.RawMessage", nil
+	case lexicon.SchemaArray:
+		item, err := g.goType(owner, prop, def.Items.Inner, false)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case lexicon.SchemaUnion:
+		name := owner + fieldName(prop) + "Union"
+		if err := g.writeUnion(name, owner, prop, def); err != nil {
+			return "", err
+		}
+		return ptr + name, nil
+	case lexicon.SchemaRef:
+		target, ok := g.defs[def.Ref]
+		if !ok {
+			if typ, ok := lexgenExternal[def.Ref]; ok {
+				return ptr + typ, nil
+			}
+			return "json.RawMessage", nil
+		}
+		switch target.Inner.(type) {
+		case lexicon.SchemaObject, lexicon.SchemaRecord:
+			return ptr + g.names[def.Ref], nil
+		case lexicon.SchemaToken:
+			return ptr + "string", nil
+		}
+		return g.goType(owner, prop, target.Inner, optional)
+	}
+	return "", fmt.Errorf("unsupported property type %T", def)
+}
+
+// isPointer reports whether goType returned a pointer type for def
+func (g *lexgen) isPointer(def any, optional bool) bool {
+	if !optional {
+		return false
+	}
+	switch def := def.(type) {
+	case lexicon.SchemaString, lexicon.SchemaInteger, lexicon.SchemaBoolean,
+		lexicon.SchemaBlob, lexicon.SchemaCIDLink, lexicon.SchemaUnion:
+		return true
+	case lexicon.SchemaRef:
+		target, ok := g.defs[def.Ref]
+		if !ok {
+			_, ok := lexgenExternal[def.Ref]
+			return ok
+		}
+		switch target.Inner.(type) {
+		case lexicon.SchemaObject, lexicon.SchemaRecord, lexicon.SchemaToken:
+			return true
+		}
+		return g.isPointer(target.Inner, optional)
+	}
+	return false
+}
+
+// needsCheck reports whether a value of def has any constraint to validate
+func (g *lexgen) needsCheck(def any, depth int) bool {
+	switch def := def.(type) {
+	case lexicon.SchemaString:
+		return def.MinLength != nil || def.MaxLength != nil || def.MinGraphemes != nil ||
+			def.MaxGraphemes != nil || checkedFormat(def.Format) || len(def.Enum) > 0 || def.Const != nil
+	case lexicon.SchemaInteger:
+		return def.Minimum != nil || def.Maximum != nil || len(def.Enum) > 0 || def.Const != nil
+	case lexicon.SchemaBoolean:
+		return def.Const != nil
+	case lexicon.SchemaBlob:
+		return def.MaxSize != nil || len(def.Accept) > 0
+	case lexicon.SchemaBytes:
+		return def.MinLength != nil || def.MaxLength != nil
+	case lexicon.SchemaArray:
+		return def.MinLength != nil || def.MaxLength != nil || g.needsCheck(def.Items.Inner, depth+1)
+	case lexicon.SchemaUnion:
+		return true
+	case lexicon.SchemaRef:
+		target, ok := g.defs[def.Ref]
+		if !ok {
+			return false
+		}
+		switch target.Inner.(type) {
+		case lexicon.SchemaObject, lexicon.SchemaRecord:
+			return true
+		case lexicon.SchemaToken:
+			return false
+		}
+		// guard against refs that loop through primitive defs
+		return depth < 8 && g.needsCheck(target.Inner, depth+1)
+	}
+	return false
+}
+
+// writeCheck emits the validation of expr, a value of def or a pointer to one
+// when ptr is set, at the path built by pathExpr. The emitted code is indented
+// by go/format once the file is complete.
+func (g *lexgen) writeCheck(w io.Writer, expr string, ptr bool, pathExpr string, def any, depth int) {
+	value := expr
+	if ptr {
+		value = "*" + expr
+	}
+	check := func(call string, args ...any) {
+		fmt.Fprintf(w, "if err := "+call+"; err != nil {\nreturn err\n}\n", args...)
+	}
+
+	switch def := def.(type) {
+	case lexicon.SchemaString:
+		if def.MinLength != nil || def.MaxLength != nil || def.MinGraphemes != nil || def.MaxGraphemes != nil || checkedFormat(def.Format) {
+			format := ""
+			if def.Format != nil {
+				format = *def.Format
+			}
+			check("checkString(%s, %s, %s, %s, %s, %s, %q)", pathExpr, value,
+				limit(def.MinLength), limit(def.MaxLength), limit(def.MinGraphemes), limit(def.MaxGraphemes), format)
+		}
+		allowed := def.Enum
+		if def.Const != nil {
+			allowed = []string{*def.Const}
+		}
+		if len(allowed) > 0 {
+			quoted := make([]string, len(allowed))
+			for i, v := range allowed {
+				quoted[i] = strconv.Quote(v)
+			}
+			check("checkEnum(%s, %s, %s)", pathExpr, value, strings.Join(quoted, ", "))
+		}
+	case lexicon.SchemaInteger:
+		if def.Minimum != nil || def.Maximum != nil {
+			check("checkInteger(%s, %s, %s, %s)", pathExpr, value, bound(def.Minimum), bound(def.Maximum))
+		}
+		allowed := def.Enum
+		if def.Const != nil {
+			allowed = []int{*def.Const}
+		}
+		if len(allowed) > 0 {
+			values := make([]string, len(allowed))
+			for i, v := range allowed {
+				values[i] = fmt.Sprintf("int64(%d)", v)
+			}
+			check("checkEnum(%s, %s, %s)", pathExpr, value, strings.Join(values, ", "))
+		}
+	case lexicon.SchemaBoolean:
+		if def.Const != nil {
+			check("checkEnum(%s, %s, %t)", pathExpr, value, *def.Const)
+		}
+	case lexicon.SchemaBlob:
+		accept := ""
+		for _, mime := range def.Accept {
+			accept += ", " + strconv.Quote(mime)
+		}
+		check("checkBlob(%s, %s, %s%s)", pathExpr, value, limit(def.MaxSize), accept)
+	case lexicon.SchemaBytes:
+		check("checkLength(%s, len(%s), %s, %s)", pathExpr, value, limit(def.MinLength), limit(def.MaxLength))
+	case lexicon.SchemaArray:
+		if def.MinLength != nil || def.MaxLength != nil {
+			check("checkLength(%s, len(%s), %s, %s)", pathExpr, value, limit(def.MinLength), limit(def.MaxLength))
+		}
+		if g.needsCheck(def.Items.Inner, 0) {
+			g.usesFmt = true
+			i := fmt.Sprintf("i%d", depth)
+			item := fmt.Sprintf("item%d", depth)
+			if ptr {
+				value = "(" + value + ")"
+			}
+			fmt.Fprintf(w, "for %s := range %s {\n%s := &%s[%s]\n", i, value, item, value, i)
+			g.writeCheck(w, item, true, indexPath(pathExpr, i), def.Items.Inner, depth+1)
+			w.Write([]byte("}\n"))
+		}
+	case lexicon.SchemaUnion:
+		fmt.Fprintf(w, "if err := %s.Validate(); err != nil {\nreturn withPath(%s, err)\n}\n", expr, pathExpr)
+	case lexicon.SchemaRef:
+		target, ok := g.defs[def.Ref]
+		if !ok {
+			return
+		}
+		switch target.Inner.(type) {
+		case lexicon.SchemaObject, lexicon.SchemaRecord:
+			fmt.Fprintf(w, "if err := %s.Validate(); err != nil {\nreturn withPath(%s, err)\n}\n", expr, pathExpr)
+		case lexicon.SchemaToken:
+		default:
+			g.writeCheck(w, expr, ptr, pathExpr, target.Inner, depth)
+		}
+	}
+}
+
+// indexPath returns the expression for the path of item i of the array at pathExpr
+func indexPath(pathExpr, i string) string {
+	if path, err := strconv.Unquote(pathExpr); err == nil {
+		return fmt.Sprintf("fmt.Sprintf(%q, %s)", strings.ReplaceAll(path, "%", "%%")+"[%d]", i)
+	}
+	return fmt.Sprintf("fmt.Sprintf(\"%%s[%%d]\", %s, %s)", pathExpr, i)
+}
+
+// writeUnion emits a wrapper that decodes one of a union's refs by its $type
+func (g *lexgen) writeUnion(name, owner, prop string, def lexicon.SchemaUnion) error {
+	if g.unions[name] {
+		return nil
+	}
+	g.unions[name] = true
+	w := &g.tail
+
+	type member struct{ ref, typ string }
+	var members []member
+	for _, ref := range def.Refs {
+		if typ, ok := g.names[ref]; ok {
+			if _, isToken := g.defs[ref].Inner.(lexicon.SchemaToken); !isToken {
+				members = append(members, member{ref, typ})
+			}
+		}
+	}
+	closed := def.Closed != nil && *def.Closed
+
+	fmt.Fprintf(w, "// %s holds one of the types allowed in %s.%s, decoded by $type as a pointer:\n//\n", name, owner, fieldName(prop))
+	for _, m := range def.Refs {
+		if typ, ok := g.names[m]; ok {
+			fmt.Fprintf(w, "//   - %s (%s)\n", typ, m)
+		} else {
+			fmt.Fprintf(w, "//   - %s, kept as json.RawMessage\n", m)
+		}
+	}
+	if !closed {
+		w.WriteString("//\n// The union is open, so values of any other $type are kept as json.RawMessage.\n")
+	}
+	fmt.Fprintf(w, "type %s struct {\n\tValue any\n}\n\n", name)
+
+	fmt.Fprintf(w, "func (u %s) MarshalJSON() ([]byte, error) {\n\treturn json.Marshal(u.Value)\n}\n\n", name)
+
+	fmt.Fprintf(w, "func (u *%s) UnmarshalJSON(data []byte) error {\n", name)
+	w.WriteString("\tvar t TypeCheck\n\tif err := json.Unmarshal(data, &t); err != nil {\n\t\treturn err\n\t}\n\n\tswitch t.Type {\n")
+	w.WriteString("\tcase \"\":\n\t\treturn schemaErrorf(\"$type\", \"required field is missing\")\n")
+	for _, m := range members {
+		fmt.Fprintf(w, "\tcase %q:\n\t\tvar v %s\n\t\tif err := json.Unmarshal(data, &v); err != nil {\n\t\t\treturn err\n\t\t}\n\t\tu.Value = &v\n", m.ref, m.typ)
+	}
+	if closed {
+		known := []string{}
+		for _, ref := range def.Refs {
+			if _, ok := g.names[ref]; !ok {
+				known = append(known, strconv.Quote(ref))
+			}
+		}
+		if len(known) > 0 {
+			fmt.Fprintf(w, "\tcase %s:\n\t\tu.Value = json.RawMessage(data)\n", strings.Join(known, ", "))
+		}
+		w.WriteString("\tdefault:\n\t\treturn schemaErrorf(\"$type\", \"unexpected type %q\", t.Type)\n")
+	} else {
+		w.WriteString("\tdefault:\n\t\tu.Value = json.RawMessage(data)\n")
+	}
+	w.WriteString("\t}\n\treturn nil\n}\n\n")
+
+	fmt.Fprintf(w, "// Validate checks the union's value against its lexicon\nfunc (u *%s) Validate() error {\n", name)
+	if len(members) > 0 {
+		w.WriteString("\tswitch v := u.Value.(type) {\n")
+		for _, m := range members {
+			fmt.Fprintf(w, "\tcase *%s:\n\t\treturn v.Validate()\n", m.typ)
+		}
+		w.WriteString("\t}\n")
+	}
+	w.WriteString("\treturn nil\n}\n\n")
+	return nil
+}
+
+// checkedFormat reports whether a string format is validated by the generated code
+func checkedFormat(format *string) bool {
+	if format == nil {
+		return false
+	}
+	switch *format {
+	case "at-identifier", "at-uri", "cid", "datetime", "did", "handle", "language", "nsid", "record-key", "tid", "uri":
+		return true
+	}
+	return false
+}
+
+func limit(v *int) string {
+	if v == nil {
+		return "-1"
+	}
+	return strconv.Itoa(*v)
+}
+
+func bound(v *int) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("int64Ptr(%d)", *v)
+}
+
+func schemaDescription(def any) *string {
+	switch def := def.(type) {
+	case lexicon.SchemaString:
+		return def.Description
+	case lexicon.SchemaInteger:
+		return def.Description
+	case lexicon.SchemaBoolean:
+		return def.Description
+	case lexicon.SchemaBlob:
+		return def.Description
+	case lexicon.SchemaArray:
+		return def.Description
+	case lexicon.SchemaRef:
+		return def.Description
+	case lexicon.SchemaUnion:
+		return def.Description
+	case lexicon.SchemaUnknown:
+		return def.Description
+	}
+	return nil
+}
+
+func writeDescription(w io.Writer, indent string, description *string) {
+	if description == nil || strings.TrimSpace(*description) == "" {
+		return
+	}
+	if indent == "" {
+		fmt.Fprintf(w, "//\n")
+	}
+	for line := range strings.SplitSeq(strings.TrimSpace(*description), "\n") {
+		fmt.Fprintf(w, "%s// %s\n", indent, strings.TrimSpace(line))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// goName converts an NSID fragment such as blocks.unorderedList#listItem to BlocksUnorderedListListItem
+func goName(ref string) string {
+	var b strings.Builder
+	for part := range strings.FieldsFuncSeq(ref, func(r rune) bool { return r == '.' || r == '#' }) {
+		b.WriteString(fieldName(part))
+	}
+	return b.String()
+}
+
+// lexgenInitialisms are upper-cased whole when they make up a word of a field name
+var lexgenInitialisms = map[string]string{"id": "ID", "uri": "URI", "url": "URL", "cid": "CID", "did": "DID"}
+
+// fieldName converts a lexicon property name such as base_path or atURI to an exported Go name
+func fieldName(prop string) string {
+	var b strings.Builder
+	for word := range strings.FieldsFuncSeq(prop, func(r rune) bool { return r == '_' || r == '-' }) {
+		if initialism, ok := lexgenInitialisms[word]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
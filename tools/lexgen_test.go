@@ -0,0 +1,163 @@
+//go:build !prod
+
+package tools
+
+import (
+	"encoding/json"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/noteleaf/internal/shared"
+)
+
+// lexgenFixture is a small record lexicon with a union, a local #ref, a
+// maxGraphemes limit and required fields
+const lexgenFixture = `{
+  "lexicon": 1,
+  "id": "pub.leaflet.fixture.post",
+  "defs": {
+    "main": {
+      "type": "record",
+      "key": "tid",
+      "record": {
+        "type": "object",
+        "required": ["title", "author", "body"],
+        "properties": {
+          "title": {"type": "string", "maxLength": 100, "maxGraphemes": 5},
+          "author": {"type": "ref", "ref": "#person"},
+          "body": {"type": "union", "refs": ["#text", "#quote"]},
+          "tags": {"type": "array", "items": {"type": "string"}, "maxLength": 2}
+        }
+      }
+    },
+    "person": {
+      "type": "object",
+      "required": ["did"],
+      "properties": {
+        "did": {"type": "string", "format": "did"}
+      }
+    },
+    "text": {
+      "type": "object",
+      "required": ["plaintext"],
+      "properties": {
+        "plaintext": {"type": "string"}
+      }
+    },
+    "quote": {
+      "type": "object",
+      "required": ["plaintext"],
+      "properties": {
+        "plaintext": {"type": "string", "maxGraphemes": 3},
+        "source": {"type": "ref", "ref": "com.atproto.repo.strongRef"}
+      }
+    }
+  }
+}`
+
+// lexgenFixtureTest is compiled into internal/public next to the generated file
+const lexgenFixtureTest = `package public
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexgenFixture(t *testing.T) {
+	const nsid = "pub.leaflet.fixture.post"
+	valid := ` + "`" + `{"$type":"pub.leaflet.fixture.post","title":"hello","author":{"did":"did:plc:abc"},"body":{"$type":"pub.leaflet.fixture.post#quote","plaintext":"hey"}}` + "`" + `
+	if err := ValidateRecord(nsid, []byte(valid)); err != nil {
+		t.Fatalf("valid record rejected: %v", err)
+	}
+
+	var post LexFixturePost
+	if err := ValidateRecord(nsid, post); err == nil {
+		t.Fatal("record without a body should be rejected")
+	}
+
+	cases := map[string]string{
+		"title: required by pub.leaflet.fixture.post but missing":      ` + "`" + `{"author":{"did":"did:plc:abc"},"body":{"$type":"pub.leaflet.fixture.post#text","plaintext":"x"}}` + "`" + `,
+		"did: required by pub.leaflet.fixture.post#person but missing": ` + "`" + `{"title":"x","author":{},"body":{"$type":"pub.leaflet.fixture.post#text","plaintext":"x"}}` + "`" + `,
+		"title: must be at most 5 graphemes":                           ` + "`" + `{"title":"hello!","author":{"did":"did:plc:abc"},"body":{"$type":"pub.leaflet.fixture.post#text","plaintext":"x"}}` + "`" + `,
+		"body.plaintext: must be at most 3 graphemes":                  ` + "`" + `{"title":"x","author":{"did":"did:plc:abc"},"body":{"$type":"pub.leaflet.fixture.post#quote","plaintext":"long"}}` + "`" + `,
+		"author.did: invalid did":                                      ` + "`" + `{"title":"x","author":{"did":"nope"},"body":{"$type":"pub.leaflet.fixture.post#text","plaintext":"x"}}` + "`" + `,
+		"tags: must have at most 2 items":                              ` + "`" + `{"title":"x","author":{"did":"did:plc:abc"},"body":{"$type":"pub.leaflet.fixture.post#text","plaintext":"x"},"tags":["a","b","c"]}` + "`" + `,
+	}
+	for want, record := range cases {
+		err := ValidateRecord(nsid, []byte(record))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+`
+
+func TestLexgen(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "lexicons")
+	shared.AssertNoError(t, os.MkdirAll(filepath.Join(input, "fixture"), 0o755), "should create input directory")
+	shared.AssertNoError(t, os.WriteFile(filepath.Join(input, "fixture", "post.json"), []byte(lexgenFixture), 0o644), "should write fixture")
+
+	src, err := generateLexicons(input, "pub.leaflet.")
+	shared.AssertNoError(t, err, "generateLexicons should succeed")
+
+	t.Run("output is gofmt'd", func(t *testing.T) {
+		formatted, err := format.Source(src)
+		shared.AssertNoError(t, err, "output should parse")
+		shared.AssertEqual(t, string(formatted), string(src), "output should be formatted")
+	})
+
+	t.Run("names types after the lexicon", func(t *testing.T) {
+		code := string(src)
+		for _, want := range []string{
+			"type LexFixturePost struct",
+			"type LexFixturePostPerson struct",
+			"type LexFixturePostBodyUnion struct",
+			`registerSchema("pub.leaflet.fixture.post"`,
+		} {
+			shared.AssertTrue(t, strings.Contains(code, want), "output should contain "+want)
+		}
+	})
+
+	t.Run("compiles and validates records", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("builds internal/public")
+		}
+		goBin, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go toolchain not available")
+		}
+
+		root, err := filepath.Abs("..")
+		shared.AssertNoError(t, err, "should resolve module root")
+		generated := filepath.Join(dir, "lexicon_gen.go")
+		fixtureTest := filepath.Join(dir, "lexgen_fixture_test.go")
+		shared.AssertNoError(t, os.WriteFile(generated, src, 0o644), "should write generated file")
+		shared.AssertNoError(t, os.WriteFile(fixtureTest, []byte(lexgenFixtureTest), 0o644), "should write fixture test")
+
+		// Swap the checked-in lexicon_gen.go for the generated one without touching the tree
+		overlay, err := json.Marshal(map[string]map[string]string{"Replace": {
+			filepath.Join(root, "internal", "public", "lexicon_gen.go"):         generated,
+			filepath.Join(root, "internal", "public", "lexgen_fixture_test.go"): fixtureTest,
+		}})
+		shared.AssertNoError(t, err, "should encode overlay")
+		overlayPath := filepath.Join(dir, "overlay.json")
+		shared.AssertNoError(t, os.WriteFile(overlayPath, overlay, 0o644), "should write overlay")
+
+		cmd := exec.Command(goBin, "test", "-overlay", overlayPath, "-run", "^TestLexgenFixture$", "./internal/public")
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("generated code failed to build or validate: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("reports an empty input", func(t *testing.T) {
+		_, err := generateLexicons(t.TempDir(), "pub.leaflet.")
+		shared.AssertErrorContains(t, err, "no lexicons found", "should require lexicons")
+	})
+}
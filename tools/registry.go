@@ -14,6 +14,7 @@ and maintenance tasks. These commands are only available in dev builds.`,
 	}
 	cmd.AddCommand(NewDocGenCommand(root))
 	cmd.AddCommand(NewFetchCommand())
+	cmd.AddCommand(NewLexgenCommand())
 
 	return cmd
 }
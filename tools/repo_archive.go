@@ -33,6 +33,7 @@ type ArchiveConfig struct {
 	Output     string
 	SHA        string
 	FormatJSON bool
+	Extension  string // extension of the files to extract, ".json" when empty
 }
 
 // NewGHRepoCommand creates a command for fetching GitHub repository archives
@@ -83,6 +84,7 @@ This is useful for syncing lexicons, schemas, or other data files from GitHub re
 	cmd.Flags().StringVar(&config.Output, "output", "", "Output directory for extracted files")
 	cmd.Flags().StringVar(&config.SHA, "sha", "", "Specific commit SHA (default: latest)")
 	cmd.Flags().BoolVar(&config.FormatJSON, "format-json", true, "Format JSON files with indentation")
+	cmd.Flags().StringVar(&config.Extension, "ext", ".json", "Extension of the files to extract")
 	return cmd
 }
 
@@ -105,7 +107,11 @@ func fetchAndExtractArchive(ctx context.Context, config ArchiveConfig, out io.Wr
 	defer os.RemoveAll(tmpDir)
 
 	fmt.Fprintf(out, "Fetching archive for %s@%s\n", config.Repo, sha[:7])
-	if err := downloadAndExtract(ctx, config.Repo, sha, config.Path, tmpDir, config.FormatJSON, out); err != nil {
+	ext := config.Extension
+	if ext == "" {
+		ext = ".json"
+	}
+	if err := downloadAndExtract(ctx, config.Repo, sha, config.Path, tmpDir, ext, config.FormatJSON, out); err != nil {
 		return fmt.Errorf("failed to download and extract: %w", err)
 	}
 
@@ -159,8 +165,8 @@ func getLatestCommit(ctx context.Context, repo, path string) (string, error) {
 	return commits[0].SHA, nil
 }
 
-// downloadAndExtract downloads a GitHub archive and extracts files from a specific path
-func downloadAndExtract(ctx context.Context, repo, sha, extractPath, outputDir string, formatJSON bool, out io.Writer) error {
+// downloadAndExtract downloads a GitHub archive and extracts the files with extension ext from a specific path
+func downloadAndExtract(ctx context.Context, repo, sha, extractPath, outputDir, ext string, formatJSON bool, out io.Writer) error {
 	url := fmt.Sprintf("https://github.com/%s/archive/%s.tar.gz", repo, sha)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -210,7 +216,7 @@ func downloadAndExtract(ctx context.Context, repo, sha, extractPath, outputDir s
 			continue
 		}
 
-		if !strings.HasSuffix(header.Name, ".json") {
+		if !strings.HasSuffix(header.Name, ext) {
 			continue
 		}
 
@@ -226,7 +232,7 @@ func downloadAndExtract(ctx context.Context, repo, sha, extractPath, outputDir s
 			return fmt.Errorf("failed to read file %s: %w", header.Name, err)
 		}
 
-		if formatJSON {
+		if formatJSON && ext == ".json" {
 			var jsonData any
 			if err := json.Unmarshal(data, &jsonData); err != nil {
 				return fmt.Errorf("failed to parse JSON in %s: %w", header.Name, err)
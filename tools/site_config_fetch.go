@@ -0,0 +1,47 @@
+//go:build !prod
+
+package tools
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSiteConfigCommand creates a command for vendoring FiveFilters site configs as article parsing rules
+func NewSiteConfigCommand() *cobra.Command {
+	var sha string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "site-config",
+		Short: "Fetch FiveFilters site configs as article parsing rules",
+		Long: `Fetches the full rule set from the fivefilters/ftr-site-config repository
+into the article parser's rules directory, replacing the rules there.
+
+The rules are embedded at build time, so rebuild noteleaf after fetching.`,
+		Example: `  # Fetch the latest site configs
+  noteleaf tools fetch site-config
+
+  # Fetch from a specific commit
+  noteleaf tools fetch site-config --sha abc123def`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := ArchiveConfig{
+				Repo:      "fivefilters/ftr-site-config",
+				Output:    output,
+				SHA:       sha,
+				Extension: ".txt",
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			return fetchAndExtractArchive(ctx, config, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&sha, "sha", "", "Specific commit SHA (default: latest)")
+	cmd.Flags().StringVar(&output, "output", "internal/articles/rules/", "Output directory for the rule files")
+	return cmd
+}